@@ -0,0 +1,123 @@
+// Package archive stores the raw messages mailpen sends, for compliance retention and customer-support
+// lookups.
+//
+// Register the hooks returned by Hooks on a Mailpen instance, and every message the provider accepts is
+// stored in RFC 5322 (EML) format, keyed by its mailpen message ID:
+//
+//	archiver, err := archive.NewDir("/var/lib/mail-archive")
+//	mp, err := mailpen.New(provider, config, mailpen.WithHooks(archive.Hooks(archiver)))
+package archive
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/providers/smtp"
+)
+
+// ErrNotFound is returned by Archiver.Open when no message is archived under the ID
+var ErrNotFound = errors.New("archived message not found")
+
+// Archiver stores raw messages by message ID. Implementations must be safe for concurrent use.
+type Archiver interface {
+	// Archive stores the EML of a sent message, replacing any message archived under the same ID
+	Archive(ctx context.Context, id string, eml []byte) error
+	// Open returns the EML archived under the ID, or an error wrapping ErrNotFound
+	Open(ctx context.Context, id string) (io.ReadCloser, error)
+}
+
+// Option configures the archive hooks
+type Option func(h *hooks)
+
+// WithFailureHandler sets a function called when a sent message cannot be archived. Archiving runs after the
+// message is sent, so failures cannot fail the send; by default they are ignored.
+func WithFailureHandler(fn func(msg *mailpen.Message, err error)) Option {
+	return func(h *hooks) {
+		h.onFail = fn
+	}
+}
+
+type hooks struct {
+	archiver Archiver
+	onFail   func(msg *mailpen.Message, err error)
+}
+
+// Hooks returns mailpen hooks that archive every message the provider accepts. BeforeSend buffers the
+// attachments of each message, so they can be read again for the archive after the provider has read them;
+// AfterSend encodes the final message, after rendering and message processors, and stores it. Messages the
+// provider rejects are not archived.
+func Hooks(archiver Archiver, opts ...Option) mailpen.Hooks {
+	h := &hooks{archiver: archiver}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return mailpen.Hooks{
+		BeforeSend: h.beforeSend,
+		AfterSend:  h.afterSend,
+	}
+}
+
+// beforeSend replaces the attachment readers of the message with in-memory copies
+func (h *hooks) beforeSend(_ context.Context, msg *mailpen.Message) error {
+	for i, att := range msg.Attachments {
+		if att.Data == nil {
+			continue
+		}
+		data, err := io.ReadAll(att.Data)
+		if err != nil {
+			return fmt.Errorf("archive: failed to read attachment %s: %w", att.Filename, err)
+		}
+		msg.Attachments[i].Data = bytes.NewReader(data)
+	}
+	return nil
+}
+
+// afterSend archives the message if the provider accepted it
+func (h *hooks) afterSend(ctx context.Context, msg *mailpen.Message, err error) {
+	if err != nil {
+		return
+	}
+	if err := h.archive(context.WithoutCancel(ctx), msg); err != nil && h.onFail != nil {
+		h.onFail(msg, err)
+	}
+}
+
+// archive encodes the message, with its attachments rewound, and stores it
+func (h *hooks) archive(ctx context.Context, msg *mailpen.Message) error {
+	archived := *msg
+	archived.Attachments = slices.Clone(msg.Attachments)
+	for i, att := range archived.Attachments {
+		seeker, ok := att.Data.(io.Seeker)
+		if !ok {
+			return fmt.Errorf("archive: attachment %s cannot be read again", att.Filename)
+		}
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("archive: failed to rewind attachment %s: %w", att.Filename, err)
+		}
+		archived.Attachments[i] = att
+	}
+
+	eml, err := smtp.EML(&archived)
+	if err != nil {
+		return fmt.Errorf("archive: failed to encode message: %w", err)
+	}
+	if err := h.archiver.Archive(ctx, msg.ID, eml); err != nil {
+		return fmt.Errorf("archive: failed to store message %s: %w", msg.ID, err)
+	}
+	return nil
+}
+
+// validID rejects IDs that are empty or could escape a directory or key prefix
+func validID(id string) error {
+	if id == "" || id == "." || id == ".." || strings.ContainsAny(id, "/\\\x00") {
+		return fmt.Errorf("archive: invalid message ID %q", id)
+	}
+	return nil
+}
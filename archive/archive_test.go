@@ -0,0 +1,158 @@
+package archive_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/archive"
+	"github.com/patrickward/mailpen/providers/memory"
+)
+
+func newMessage() *mailpen.Message {
+	msg := mailpen.NewMessage().ID("msg-1").To("recipient@example.com").Subject("Your invoice").
+		Attach("invoice.txt", io.MultiReader(strings.NewReader("invoice contents"))).Must()
+	msg.TextBody = "Thanks for your order"
+	return msg
+}
+
+func readArchived(t *testing.T, a archive.Archiver, id string) string {
+	t.Helper()
+	rc, err := a.Open(context.Background(), id)
+	require.NoError(t, err)
+	defer rc.Close()
+	eml, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	return string(eml)
+}
+
+func TestHooks(t *testing.T) {
+	provider := memory.New()
+	archiver := archive.NewMemory()
+	mp, err := mailpen.New(provider, &mailpen.Config{From: "sender@example.com"},
+		mailpen.WithHooks(archive.Hooks(archiver)))
+	require.NoError(t, err)
+
+	require.NoError(t, mp.Send(context.Background(), newMessage()))
+
+	eml := readArchived(t, archiver, "msg-1")
+	assert.Contains(t, eml, "Subject: Your invoice")
+	assert.Contains(t, eml, "From: <sender@example.com>")
+	assert.Contains(t, eml, "Thanks for your order")
+	assert.Contains(t, eml, `filename="invoice.txt"`)
+	assert.Contains(t, eml, base64.StdEncoding.EncodeToString([]byte("invoice contents")))
+
+	sent := provider.Messages()
+	require.Len(t, sent, 1)
+	data, err := io.ReadAll(sent[0].Attachments[0].Data)
+	require.NoError(t, err)
+	assert.Equal(t, "invoice contents", string(data), "the provider must still get the attachment")
+}
+
+func TestHooks_Failures(t *testing.T) {
+	t.Run("rejected messages are not archived", func(t *testing.T) {
+		provider := memory.New()
+		provider.FailWith(errors.New("rejected"))
+		archiver := archive.NewMemory()
+		mp, err := mailpen.New(provider, &mailpen.Config{From: "sender@example.com"},
+			mailpen.WithHooks(archive.Hooks(archiver)))
+		require.NoError(t, err)
+
+		require.Error(t, mp.Send(context.Background(), newMessage()))
+		assert.Zero(t, archiver.Len())
+	})
+
+	t.Run("archive failures are reported", func(t *testing.T) {
+		var failed []string
+		mp, err := mailpen.New(memory.New(), &mailpen.Config{From: "sender@example.com"},
+			mailpen.WithHooks(archive.Hooks(failingArchiver{}, archive.WithFailureHandler(func(msg *mailpen.Message, err error) {
+				failed = append(failed, fmt.Sprintf("%s: %v", msg.ID, err))
+			}))))
+		require.NoError(t, err)
+
+		require.NoError(t, mp.Send(context.Background(), newMessage()), "archive failures must not fail the send")
+		assert.Equal(t, []string{"msg-1: archive: failed to store message msg-1: disk full"}, failed)
+	})
+}
+
+type failingArchiver struct{}
+
+func (failingArchiver) Archive(context.Context, string, []byte) error { return errors.New("disk full") }
+func (failingArchiver) Open(context.Context, string) (io.ReadCloser, error) {
+	return nil, archive.ErrNotFound
+}
+
+func TestArchivers(t *testing.T) {
+	dir, err := archive.NewDir(t.TempDir() + "/archive")
+	require.NoError(t, err)
+
+	archivers := map[string]archive.Archiver{
+		"memory": archive.NewMemory(),
+		"dir":    dir,
+		"s3":     archive.NewS3(newFakeObjectClient(), "mail-bucket", "sent/"),
+	}
+
+	for name, a := range archivers {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			require.NoError(t, a.Archive(ctx, "abc123", []byte("first")))
+			require.NoError(t, a.Archive(ctx, "abc123", []byte("Subject: hi\r\n\r\nbody")))
+			assert.Equal(t, "Subject: hi\r\n\r\nbody", readArchived(t, a, "abc123"))
+
+			_, err := a.Open(ctx, "missing")
+			assert.ErrorIs(t, err, archive.ErrNotFound)
+
+			for _, id := range []string{"", "..", "../etc/passwd", `a\b`} {
+				assert.Error(t, a.Archive(ctx, id, []byte("x")), "id %q", id)
+			}
+		})
+	}
+}
+
+func TestS3_Keys(t *testing.T) {
+	client := newFakeObjectClient()
+	require.NoError(t, archive.NewS3(client, "mail-bucket", "sent/").Archive(context.Background(), "abc123", []byte("eml")))
+
+	assert.Equal(t, []byte("eml"), client.objects["mail-bucket/sent/abc123.eml"])
+	assert.Equal(t, "message/rfc822", client.contentTypes["mail-bucket/sent/abc123.eml"])
+}
+
+// fakeObjectClient is an in-memory archive.ObjectClient
+type fakeObjectClient struct {
+	objects      map[string][]byte
+	contentTypes map[string]string
+}
+
+func newFakeObjectClient() *fakeObjectClient {
+	return &fakeObjectClient{objects: map[string][]byte{}, contentTypes: map[string]string{}}
+}
+
+func (c *fakeObjectClient) PutObject(_ context.Context, bucket, key string, body io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if int64(len(data)) != size {
+		return fmt.Errorf("size %d does not match body length %d", size, len(data))
+	}
+	c.objects[bucket+"/"+key] = data
+	c.contentTypes[bucket+"/"+key] = contentType
+	return nil
+}
+
+func (c *fakeObjectClient) GetObject(_ context.Context, bucket, key string) (io.ReadCloser, error) {
+	data, ok := c.objects[bucket+"/"+key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", archive.ErrNotFound, key)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
@@ -0,0 +1,71 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Dir archives messages as <id>.eml files in a local directory
+type Dir struct {
+	path string
+}
+
+// NewDir creates an archive in the directory at path, creating the directory if it does not exist
+func NewDir(path string) (*Dir, error) {
+	if err := os.MkdirAll(path, 0o750); err != nil {
+		return nil, fmt.Errorf("archive: failed to create directory: %w", err)
+	}
+	return &Dir{path: path}, nil
+}
+
+// Archive implements Archiver. The file is written under a temporary name and renamed, so readers never see
+// a partial message.
+func (d *Dir) Archive(_ context.Context, id string, eml []byte) error {
+	if err := validID(id); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(d.path, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("archive: failed to create file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(eml); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("archive: failed to write file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("archive: failed to write file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), d.file(id)); err != nil {
+		return fmt.Errorf("archive: failed to save file: %w", err)
+	}
+	return nil
+}
+
+// Open implements Archiver
+func (d *Dir) Open(_ context.Context, id string) (io.ReadCloser, error) {
+	if err := validID(id); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(d.file(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("archive: failed to open file: %w", err)
+	}
+	return f, nil
+}
+
+// file returns the path of the file a message is archived in
+func (d *Dir) file(id string) string {
+	return filepath.Join(d.path, id+".eml")
+}
@@ -0,0 +1,52 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+)
+
+// Memory is an in-memory archive for tests and development. It is safe for concurrent use.
+type Memory struct {
+	mu       sync.RWMutex
+	messages map[string][]byte
+}
+
+// NewMemory creates an empty in-memory archive
+func NewMemory() *Memory {
+	return &Memory{messages: make(map[string][]byte)}
+}
+
+// Archive implements Archiver
+func (m *Memory) Archive(_ context.Context, id string, eml []byte) error {
+	if err := validID(id); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages[id] = slices.Clone(eml)
+	return nil
+}
+
+// Open implements Archiver
+func (m *Memory) Open(_ context.Context, id string) (io.ReadCloser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	eml, ok := m.messages[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return io.NopCloser(bytes.NewReader(eml)), nil
+}
+
+// Len returns the number of archived messages
+func (m *Memory) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.messages)
+}
@@ -0,0 +1,48 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// ObjectClient is the part of an S3-compatible storage client the S3 archive uses. Adapt the AWS SDK, MinIO,
+// or another object storage client to it. GetObject must return an error wrapping ErrNotFound when the key
+// does not exist.
+type ObjectClient interface {
+	PutObject(ctx context.Context, bucket, key string, body io.Reader, size int64, contentType string) error
+	GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, error)
+}
+
+// S3 archives messages as objects in a bucket, keyed <prefix><id>.eml
+type S3 struct {
+	client ObjectClient
+	bucket string
+	prefix string
+}
+
+// NewS3 creates an archive that stores messages in the bucket under the key prefix (e.g. "mail/")
+func NewS3(client ObjectClient, bucket, prefix string) *S3 {
+	return &S3{client: client, bucket: bucket, prefix: prefix}
+}
+
+// Archive implements Archiver
+func (s *S3) Archive(ctx context.Context, id string, eml []byte) error {
+	if err := validID(id); err != nil {
+		return err
+	}
+	return s.client.PutObject(ctx, s.bucket, s.key(id), bytes.NewReader(eml), int64(len(eml)), "message/rfc822")
+}
+
+// Open implements Archiver
+func (s *S3) Open(ctx context.Context, id string) (io.ReadCloser, error) {
+	if err := validID(id); err != nil {
+		return nil, err
+	}
+	return s.client.GetObject(ctx, s.bucket, s.key(id))
+}
+
+// key returns the object key of a message
+func (s *S3) key(id string) string {
+	return s.prefix + id + ".eml"
+}
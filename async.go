@@ -0,0 +1,132 @@
+package mailpen
+
+import (
+	"context"
+	"sync"
+)
+
+// asyncSends counts the sends started with SendAsync. The shutdown check and count in SendAsync, and the
+// switch to waiting in Drain, happen under mu, so a send is either refused or waited for and Drain can run
+// while new sends start.
+type asyncSends struct {
+	mu    sync.Mutex
+	count int
+	idle  chan struct{} // Closed when count drops to zero, replaced when it next rises
+}
+
+// add records a started send. The caller must hold mu.
+func (a *asyncSends) add() {
+	if a.count == 0 {
+		a.idle = make(chan struct{})
+	}
+	a.count++
+}
+
+// done records a finished send
+func (a *asyncSends) done() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.count--; a.count == 0 {
+		close(a.idle)
+	}
+}
+
+// wait returns a channel that is closed once the sends started so far have finished
+func (a *asyncSends) wait() <-chan struct{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.count == 0 {
+		idle := make(chan struct{})
+		close(idle)
+		return idle
+	}
+	return a.idle
+}
+
+// Future is the pending outcome of an asynchronous send
+type Future struct {
+	done   chan struct{}
+	result *SendResult
+	err    error
+}
+
+// newFuture creates an unresolved future
+func newFuture() *Future {
+	return &Future{done: make(chan struct{})}
+}
+
+// resolve records the outcome and releases any waiters
+func (f *Future) resolve(result *SendResult, err error) {
+	f.result = result
+	f.err = err
+	close(f.done)
+}
+
+// Done returns a channel that is closed when the send has finished
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the send finishes and returns its error, or returns the context's error if ctx is done first
+func (f *Future) Wait(ctx context.Context) error {
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Err returns the error of a finished send. It returns nil while the send is still in progress.
+func (f *Future) Err() error {
+	select {
+	case <-f.done:
+		return f.err
+	default:
+		return nil
+	}
+}
+
+// Result returns the SendResult of a finished send. It returns nil while the send is still in progress.
+func (f *Future) Result() *SendResult {
+	select {
+	case <-f.done:
+		return f.result
+	default:
+		return nil
+	}
+}
+
+// SendAsync sends a message in a new goroutine and returns a Future for its outcome. The send uses ctx, so
+// pass context.WithoutCancel(ctx) when the send should outlive a request. Use Drain to wait for all
+// outstanding asynchronous sends, for example during shutdown.
+func (m *Mailpen) SendAsync(ctx context.Context, msg *Message) *Future {
+	f := newFuture()
+
+	m.pending.mu.Lock()
+	if m.closed.Load() {
+		m.pending.mu.Unlock()
+		f.resolve(nil, ErrShutdown)
+		return f
+	}
+	m.pending.add()
+	m.pending.mu.Unlock()
+
+	go func() {
+		defer m.pending.done()
+		f.resolve(m.SendWithResult(ctx, msg))
+	}()
+
+	return f
+}
+
+// Drain waits until every send started with SendAsync has finished, or returns the context's error if ctx
+// is done first. Sends started while Drain waits are waited for too, until none are left.
+func (m *Mailpen) Drain(ctx context.Context) error {
+	select {
+	case <-m.pending.wait():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,162 @@
+package mailpen_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+// blockingProvider implements mailpen.Provider and blocks each send until released
+type blockingProvider struct {
+	mockProvider
+	release chan struct{}
+	mu      sync.Mutex
+}
+
+func (p *blockingProvider) Send(ctx context.Context, msg *mailpen.Message) error {
+	<-p.release
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.mockProvider.Send(ctx, msg)
+}
+
+func TestMailpen_SendAsync(t *testing.T) {
+	tests := []struct {
+		name    string
+		sendErr error
+	}{
+		{name: "successful send"},
+		{name: "failed send", sendErr: errors.New("send failed")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &blockingProvider{mockProvider: mockProvider{err: tt.sendErr}, release: make(chan struct{})}
+			mp, err := mailpen.New(provider, &mailpen.Config{From: "sender@example.com"})
+			require.NoError(t, err)
+
+			msg := mailpen.NewMessage().To("recipient@example.com").Subject("Test").Must()
+			f := mp.SendAsync(context.Background(), msg)
+
+			select {
+			case <-f.Done():
+				t.Fatal("future resolved before the send finished")
+			default:
+			}
+			assert.NoError(t, f.Err())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			assert.ErrorIs(t, f.Wait(ctx), context.DeadlineExceeded)
+
+			close(provider.release)
+			err = f.Wait(context.Background())
+			if tt.sendErr != nil {
+				assert.ErrorIs(t, err, tt.sendErr)
+				assert.ErrorIs(t, f.Err(), tt.sendErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMailpen_Drain(t *testing.T) {
+	provider := &blockingProvider{release: make(chan struct{})}
+	mp, err := mailpen.New(provider, &mailpen.Config{From: "sender@example.com"})
+	require.NoError(t, err)
+
+	var futures []*mailpen.Future
+	for i := 0; i < 3; i++ {
+		msg := mailpen.NewMessage().To("recipient@example.com").Subject("Test").Must()
+		futures = append(futures, mp.SendAsync(context.Background(), msg))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, mp.Drain(ctx), context.DeadlineExceeded)
+
+	close(provider.release)
+	require.NoError(t, mp.Drain(context.Background()))
+
+	for _, f := range futures {
+		select {
+		case <-f.Done():
+		default:
+			t.Fatal("future not resolved after drain")
+		}
+	}
+	assert.Equal(t, 3, provider.sendCalls)
+}
+
+func TestMailpen_SendAsyncDuringShutdown(t *testing.T) {
+	mp, err := mailpen.New(&concurrentProvider{}, &mailpen.Config{From: "sender@example.com"})
+	require.NoError(t, err)
+
+	var (
+		mu      sync.Mutex
+		futures []*mailpen.Future
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			msg := mailpen.NewMessage().To("recipient@example.com").Subject("Test").Must()
+			f := mp.SendAsync(context.Background(), msg)
+			mu.Lock()
+			futures = append(futures, f)
+			mu.Unlock()
+		}()
+	}
+
+	_, err = mp.Shutdown(context.Background())
+	require.NoError(t, err)
+	wg.Wait()
+
+	// Every send was either refused or finished by the time Shutdown returned
+	for _, f := range futures {
+		select {
+		case <-f.Done():
+		default:
+			t.Fatal("future not resolved after shutdown")
+		}
+	}
+}
+
+func TestMailpen_DrainDuringSendAsync(t *testing.T) {
+	provider := &concurrentProvider{}
+	mp, err := mailpen.New(provider, &mailpen.Config{From: "sender@example.com"})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				msg := mailpen.NewMessage().To("recipient@example.com").Subject("Test").Must()
+				mp.SendAsync(context.Background(), msg)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				assert.NoError(t, mp.Drain(context.Background()))
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.NoError(t, mp.Drain(context.Background()))
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	assert.Len(t, provider.sent, 100)
+}
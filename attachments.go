@@ -0,0 +1,197 @@
+package mailpen
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"sync/atomic"
+)
+
+// ErrAttachmentTooLarge is matched by AttachmentSizeError, so callers can check for it with errors.Is
+var ErrAttachmentTooLarge = errors.New("attachment too large")
+
+// AttachmentSizeError is returned when an attachment is larger than the per-attachment limit, or when the
+// attachments of a message together are larger than the total limit. Attachments are checked as the provider
+// reads them, so the error is usually wrapped by the provider. It is not retried.
+type AttachmentSizeError struct {
+	Filename string // Attachment that went over the limit
+	Limit    int64  // The limit in bytes
+	Total    bool   // The limit is on the total size of the message's attachments
+}
+
+func (e *AttachmentSizeError) Error() string {
+	if e.Total {
+		return fmt.Sprintf("attachment %q exceeds the %d byte limit on the total size of attachments", e.Filename, e.Limit)
+	}
+	return fmt.Sprintf("attachment %q exceeds the %d byte attachment size limit", e.Filename, e.Limit)
+}
+
+// Is reports whether target is ErrAttachmentTooLarge
+func (e *AttachmentSizeError) Is(target error) bool { return target == ErrAttachmentTooLarge }
+
+// IsTemp reports false, since sending the same attachments again fails the same way
+func (e *AttachmentSizeError) IsTemp() bool { return false }
+
+// attachmentLimits returns the per-attachment and total attachment size limits: the smaller of the limits in
+// the config and the provider's capabilities, where zero is unlimited
+func (m *Mailpen) attachmentLimits() (each, total int64) {
+	config, caps := m.Config(), m.provider.Capabilities()
+	return minSize(config.MaxAttachmentSize, caps.MaxAttachmentSize),
+		minSize(config.MaxTotalAttachmentSize, caps.MaxTotalAttachmentSize)
+}
+
+// minSize returns the smaller of two size limits, where zero means unlimited
+func minSize(a, b int64) int64 {
+	switch {
+	case a <= 0:
+		return max(b, 0)
+	case b <= 0:
+		return a
+	default:
+		return min(a, b)
+	}
+}
+
+// limitAttachments replaces the attachment readers of msg with readers that fail once a size limit is
+// exceeded, so oversized attachments are caught while the provider streams them instead of after they are
+// buffered. Attachments whose size is known up front, such as bytes.Reader and files, are checked before
+// sending. The returned function restores the original attachments.
+func (m *Mailpen) limitAttachments(msg *Message) (restore func(), err error) {
+	each, total := m.attachmentLimits()
+	if len(msg.Attachments) == 0 || (each == 0 && total == 0) {
+		return func() {}, nil
+	}
+
+	var known int64
+	for _, att := range msg.Attachments {
+		size, ok := readerSize(att.Data)
+		if !ok {
+			continue
+		}
+		if each > 0 && size > each {
+			return nil, &AttachmentSizeError{Filename: att.Filename, Limit: each}
+		}
+		if known += size; total > 0 && known > total {
+			return nil, &AttachmentSizeError{Filename: att.Filename, Limit: total, Total: true}
+		}
+	}
+
+	original := msg.Attachments
+	msg.Attachments = slices.Clone(original)
+	read := &atomic.Int64{}
+	for i, att := range msg.Attachments {
+		if att.Data != nil {
+			msg.Attachments[i].Data = &limitedReader{r: att.Data, filename: att.Filename, each: each, total: total, read: read}
+		}
+	}
+
+	return func() { msg.Attachments = original }, nil
+}
+
+// readerSize returns the number of bytes left in a reader when it reports them, as bytes.Reader and
+// strings.Reader do, or the size of a regular file
+func readerSize(r io.Reader) (int64, bool) {
+	switch r := r.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len()), true
+	case interface{ Stat() (fs.FileInfo, error) }:
+		info, err := r.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return 0, false
+		}
+		return info.Size(), true
+	default:
+		return 0, false
+	}
+}
+
+// limitedReader counts the bytes read from an attachment, and from all of the message's attachments in read,
+// and fails with an AttachmentSizeError once either limit is exceeded
+type limitedReader struct {
+	r           io.Reader
+	filename    string
+	each, total int64
+	n           int64
+	read        *atomic.Int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	read := l.read.Add(int64(n))
+
+	switch {
+	case l.each > 0 && l.n > l.each:
+		return n, &AttachmentSizeError{Filename: l.filename, Limit: l.each}
+	case l.total > 0 && read > l.total:
+		return n, &AttachmentSizeError{Filename: l.filename, Limit: l.total, Total: true}
+	}
+	return n, err
+}
+
+// replayAttachments lets the attachments of msg be read again by each send attempt. Attachments that can seek
+// are rewound to where they started, and other readers are wrapped so the bytes read by the first attempt are
+// replayed to later ones. The returned rewind function is called before every retry, and restore puts back the
+// original attachments. Nothing is wrapped when the retry policy never retries.
+func (m *Mailpen) replayAttachments(msg *Message) (rewind func() error, restore func()) {
+	if _, never := m.retry.(noRetry); never || len(msg.Attachments) == 0 {
+		return func() error { return nil }, func() {}
+	}
+
+	original := msg.Attachments
+	msg.Attachments = slices.Clone(original)
+
+	var rewinds []func() error
+	for i, att := range msg.Attachments {
+		if att.Data == nil {
+			continue
+		}
+		if s, ok := att.Data.(io.Seeker); ok {
+			if offset, err := s.Seek(0, io.SeekCurrent); err == nil {
+				rewinds = append(rewinds, func() error {
+					if _, err := s.Seek(offset, io.SeekStart); err != nil {
+						return fmt.Errorf("rewind attachment %q: %w", att.Filename, err)
+					}
+					return nil
+				})
+				continue
+			}
+		}
+		r := &replayReader{r: att.Data}
+		msg.Attachments[i].Data = r
+		rewinds = append(rewinds, func() error { r.pos = 0; return nil })
+	}
+
+	rewind = func() error {
+		for _, fn := range rewinds {
+			if err := fn(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return rewind, func() { msg.Attachments = original }
+}
+
+// replayReader keeps the bytes read from r, so that after a rewind they are read again before reading
+// continues from r
+type replayReader struct {
+	r   io.Reader
+	buf []byte
+	pos int
+}
+
+func (r *replayReader) Read(p []byte) (int, error) {
+	if r.pos < len(r.buf) {
+		n := copy(p, r.buf[r.pos:])
+		r.pos += n
+		return n, nil
+	}
+
+	n, err := r.r.Read(p)
+	r.buf = append(r.buf, p[:n]...)
+	r.pos += n
+	return n, err
+}
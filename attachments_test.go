@@ -0,0 +1,118 @@
+package mailpen_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/providers/memory"
+)
+
+// streamOf returns a reader of the data that does not report its size, as a network stream would
+func streamOf(data string) io.Reader {
+	return io.MultiReader(strings.NewReader(data))
+}
+
+func TestAttachmentSizeLimits(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      mailpen.Config
+		attachments map[string]io.Reader
+		order       []string
+		wantErr     *mailpen.AttachmentSizeError
+	}{
+		{
+			name:        "within limits",
+			config:      mailpen.Config{MaxAttachmentSize: 10, MaxTotalAttachmentSize: 20},
+			attachments: map[string]io.Reader{"a.txt": streamOf("0123456789"), "b.txt": strings.NewReader("0123456789")},
+			order:       []string{"a.txt", "b.txt"},
+		},
+		{
+			name:        "streamed attachment too large",
+			config:      mailpen.Config{MaxAttachmentSize: 10},
+			attachments: map[string]io.Reader{"a.txt": streamOf("0123456789"), "big.txt": streamOf("0123456789A")},
+			order:       []string{"a.txt", "big.txt"},
+			wantErr:     &mailpen.AttachmentSizeError{Filename: "big.txt", Limit: 10},
+		},
+		{
+			name:        "sized attachment too large",
+			config:      mailpen.Config{MaxAttachmentSize: 10},
+			attachments: map[string]io.Reader{"big.txt": bytes.NewReader(make([]byte, 11))},
+			order:       []string{"big.txt"},
+			wantErr:     &mailpen.AttachmentSizeError{Filename: "big.txt", Limit: 10},
+		},
+		{
+			name:        "streamed total too large",
+			config:      mailpen.Config{MaxTotalAttachmentSize: 15},
+			attachments: map[string]io.Reader{"a.txt": streamOf("0123456789"), "b.txt": streamOf("0123456789")},
+			order:       []string{"a.txt", "b.txt"},
+			wantErr:     &mailpen.AttachmentSizeError{Filename: "b.txt", Limit: 15, Total: true},
+		},
+		{
+			name:        "sized total too large",
+			config:      mailpen.Config{MaxTotalAttachmentSize: 15},
+			attachments: map[string]io.Reader{"a.txt": strings.NewReader("0123456789"), "b.txt": strings.NewReader("0123456789")},
+			order:       []string{"a.txt", "b.txt"},
+			wantErr:     &mailpen.AttachmentSizeError{Filename: "b.txt", Limit: 15, Total: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := memory.New()
+			tt.config.From = "sender@example.com"
+			mp, err := mailpen.New(provider, &tt.config,
+				mailpen.WithRetryPolicy(mailpen.ExponentialRetry{MaxAttempts: 3, InitialDelay: time.Millisecond}))
+			require.NoError(t, err)
+
+			b := mailpen.NewMessage().To("recipient@example.com").Subject("Files")
+			for _, name := range tt.order {
+				b = b.Attach(name, tt.attachments[name])
+			}
+			msg := b.Must()
+			msg.TextBody = "See attached"
+			original := msg.Attachments[0].Data
+
+			res, err := mp.SendWithResult(context.Background(), msg)
+			assert.Same(t, original, msg.Attachments[0].Data, "attachments must be restored after sending")
+
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				require.Len(t, provider.Messages(), 1)
+				assert.Len(t, provider.Messages()[0].Attachments, len(tt.order))
+				return
+			}
+
+			assert.ErrorIs(t, err, mailpen.ErrAttachmentTooLarge)
+			var sizeErr *mailpen.AttachmentSizeError
+			require.ErrorAs(t, err, &sizeErr)
+			assert.Equal(t, tt.wantErr, sizeErr)
+			assert.Empty(t, provider.Messages())
+			assert.LessOrEqual(t, res.Attempts, 1, "size errors must not be retried")
+		})
+	}
+}
+
+func TestAttachmentSizeLimits_Capabilities(t *testing.T) {
+	mock := &mockProvider{capabilities: mailpen.Capabilities{MaxAttachmentSize: 100}}
+	mp, err := mailpen.New(mock, &mailpen.Config{From: "sender@example.com", MaxAttachmentSize: 1000})
+	require.NoError(t, err)
+
+	msg := mailpen.NewMessage().To("recipient@example.com").Subject("Files").
+		Attach("big.bin", bytes.NewReader(make([]byte, 101))).Must()
+	msg.TextBody = "See attached"
+	err = mp.Send(context.Background(), msg)
+
+	var sizeErr *mailpen.AttachmentSizeError
+	require.True(t, errors.As(err, &sizeErr))
+	assert.Equal(t, int64(100), sizeErr.Limit, "the smaller limit applies")
+	assert.Zero(t, mock.sendCalls, "sized attachments are checked before the provider is called")
+}
@@ -0,0 +1,156 @@
+package mailpen
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultBatchConcurrency is the number of messages SendMany prepares at once when Config.BatchConcurrency is unset
+const DefaultBatchConcurrency = 8
+
+// BatchProvider is implemented by providers with a bulk send API. SendBatch returns one error per message, in
+// the same order as msgs.
+type BatchProvider interface {
+	Provider
+	SendBatch(ctx context.Context, msgs []*Message) []error
+}
+
+// BatchResult is the outcome of sending one message with SendMany
+type BatchResult struct {
+	Message *Message
+	Result  *SendResult
+	Err     error
+}
+
+// SendMany sends several messages and returns one result per message, in the same order. A failed message
+// does not stop the others. Messages are rendered concurrently, up to Config.BatchConcurrency at a time.
+//
+// When the provider implements BatchProvider, middleware wraps each message's preparation, and the prepared
+// messages are then handed to the provider in a single SendBatch call. Otherwise each message is sent with Send.
+func (m *Mailpen) SendMany(ctx context.Context, msgs []*Message) []BatchResult {
+	results := make([]BatchResult, len(msgs))
+	for i, msg := range msgs {
+		results[i].Message = msg
+	}
+
+	batch, ok := m.provider.(BatchProvider)
+	if !ok {
+		m.forEach(len(msgs), func(i int) {
+			results[i].Result, results[i].Err = m.SendWithResult(ctx, msgs[i])
+		})
+		return results
+	}
+
+	prepare := m.chain(func(ctx context.Context, msg *Message) error {
+		return m.prepare(ctx, msg)
+	})
+
+	ctxs := make([]context.Context, len(msgs))
+	spans := make([]trace.Span, len(msgs))
+	keys := make([]string, len(msgs))
+	cancels := make([]context.CancelFunc, len(msgs))
+	m.forEach(len(msgs), func(i int) {
+		results[i].Result = &SendResult{}
+		ctxs[i], spans[i] = m.startSendSpan(context.WithValue(ctx, resultKey{}, results[i].Result), msgs[i])
+		results[i].Result.MessageID = msgs[i].ID
+		ctxs[i], cancels[i] = m.withSendTimeout(ctxs[i])
+		if keys[i], results[i].Err = m.reserveDedup(ctxs[i], msgs[i]); results[i].Err == nil {
+			results[i].Err = prepare(ctxs[i], msgs[i])
+		}
+	})
+
+	var ready []int
+	for i := range msgs {
+		if results[i].Err == nil {
+			results[i].Err = m.throttle(ctxs[i], msgs[i])
+		}
+		if results[i].Err == nil {
+			ready = append(ready, i)
+		}
+	}
+
+	if len(ready) > 0 {
+		prepared := make([]*Message, len(ready))
+		for j, i := range ready {
+			prepared[j] = msgs[i]
+			m.logger.DebugContext(ctxs[i], "mailpen: send attempt", m.logAttrs(msgs[i])...)
+		}
+
+		batchCtx, cancel := m.withSendTimeout(ctx)
+		start := time.Now()
+		errs := m.sendBatch(batchCtx, batch, prepared)
+		cancel()
+		duration := time.Since(start)
+
+		for j, i := range ready {
+			results[i].Err = errs[j]
+			m.record(ctxs[i], msgs[i], 1, start, errs[j])
+			m.sent(ctxs[i], msgs[i], 1, errs[j], duration)
+		}
+	}
+
+	for i, span := range spans {
+		cancels[i]()
+		if results[i].Err != nil {
+			m.releaseDedup(ctxs[i], msgs[i], keys[i])
+		}
+		endSpan(span, results[i].Err)
+	}
+
+	return results
+}
+
+// sendBatch sends prepared messages through a batch provider inside its own span, always returning one
+// error per message
+func (m *Mailpen) sendBatch(ctx context.Context, provider BatchProvider, msgs []*Message) []error {
+	ctx, span := m.tracer.Start(ctx, "mailpen.provider.send_batch", trace.WithAttributes(
+		attribute.String("mailpen.provider", provider.Name()),
+		attribute.Int("mailpen.messages", len(msgs)),
+	), trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	errs := provider.SendBatch(ctx, msgs)
+	if len(errs) != len(msgs) {
+		err := fmt.Errorf("provider %s returned %d results for %d messages", provider.Name(), len(errs), len(msgs))
+		errs = make([]error, len(msgs))
+		for i := range errs {
+			errs[i] = err
+		}
+	}
+
+	if m.adaptive != nil {
+		for i, msg := range msgs {
+			m.adaptive.Observe(msg, errs[i])
+		}
+	}
+
+	return errs
+}
+
+// forEach calls fn for each index in [0, n), running up to Config.BatchConcurrency calls at once
+func (m *Mailpen) forEach(n int, fn func(i int)) {
+	limit := m.Config().BatchConcurrency
+	if limit <= 0 {
+		limit = DefaultBatchConcurrency
+	}
+
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
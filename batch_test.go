@@ -0,0 +1,150 @@
+package mailpen_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+// concurrentProvider implements mailpen.Provider and is safe for concurrent sends
+type concurrentProvider struct {
+	mockProvider
+	mu      sync.Mutex
+	sent    []string
+	failFor string
+}
+
+func (p *concurrentProvider) Send(_ context.Context, msg *mailpen.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if msg.To[0] == p.failFor {
+		return errors.New("rejected")
+	}
+	p.sent = append(p.sent, msg.To[0])
+	return nil
+}
+
+// batchProvider implements mailpen.BatchProvider
+type batchProvider struct {
+	concurrentProvider
+	batches [][]*mailpen.Message
+}
+
+func (p *batchProvider) SendBatch(ctx context.Context, msgs []*mailpen.Message) []error {
+	p.batches = append(p.batches, msgs)
+	errs := make([]error, len(msgs))
+	for i, msg := range msgs {
+		errs[i] = p.Send(ctx, msg)
+	}
+	return errs
+}
+
+func batchMessages(t *testing.T, recipients ...string) []*mailpen.Message {
+	t.Helper()
+	msgs := make([]*mailpen.Message, len(recipients))
+	for i, to := range recipients {
+		msgs[i] = mailpen.NewMessage().
+			To(to).
+			Template("welcome").
+			WithData(map[string]any{"Name": to}).
+			Must()
+	}
+	return msgs
+}
+
+func TestMailpen_SendMany(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider func() mailpen.Provider
+	}{
+		{
+			name:     "individual sends",
+			provider: func() mailpen.Provider { return &concurrentProvider{failFor: "bad@example.com"} },
+		},
+		{
+			name: "batch provider",
+			provider: func() mailpen.Provider {
+				return &batchProvider{concurrentProvider: concurrentProvider{failFor: "bad@example.com"}}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := tt.provider()
+
+			var afterSend atomic.Int32
+			mp, err := mailpen.New(provider, &mailpen.Config{
+				From:             "sender@example.com",
+				Sources:          []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+				BatchConcurrency: 2,
+			}, mailpen.WithHooks(mailpen.Hooks{
+				BeforeRender: func(ctx context.Context, msg *mailpen.Message) error {
+					if msg.To[0] == "blocked@example.com" {
+						return errors.New("blocked")
+					}
+					return nil
+				},
+				AfterSend: func(context.Context, *mailpen.Message, error) { afterSend.Add(1) },
+			}))
+			require.NoError(t, err)
+
+			msgs := batchMessages(t, "a@example.com", "blocked@example.com", "b@example.com", "bad@example.com", "c@example.com")
+			results := mp.SendMany(context.Background(), msgs)
+			require.Len(t, results, len(msgs))
+
+			for i, result := range results {
+				assert.Same(t, msgs[i], result.Message)
+			}
+			assert.NoError(t, results[0].Err)
+			assert.ErrorContains(t, results[1].Err, "blocked")
+			assert.NoError(t, results[2].Err)
+			assert.ErrorContains(t, results[3].Err, "rejected")
+			assert.NoError(t, results[4].Err)
+
+			assert.Contains(t, msgs[0].HTMLBody, "Welcome, a@example.com!")
+			assert.Equal(t, int32(4), afterSend.Load())
+
+			if bp, ok := provider.(*batchProvider); ok {
+				require.Len(t, bp.batches, 1)
+				assert.Len(t, bp.batches[0], 4)
+			}
+		})
+	}
+}
+
+func TestMailpen_SendManyMiddleware(t *testing.T) {
+	provider := &batchProvider{}
+	mp, err := mailpen.New(provider, &mailpen.Config{From: "sender@example.com"})
+	require.NoError(t, err)
+
+	var calls atomic.Int32
+	mp.Use(func(next mailpen.SendFunc) mailpen.SendFunc {
+		return func(ctx context.Context, msg *mailpen.Message) error {
+			calls.Add(1)
+			if msg.Subject == "skip" {
+				return errors.New("skipped by policy")
+			}
+			return next(ctx, msg)
+		}
+	})
+
+	msgs := []*mailpen.Message{
+		mailpen.NewMessage().To("a@example.com").Subject("hello").Must(),
+		mailpen.NewMessage().To("b@example.com").Subject("skip").Must(),
+	}
+	results := mp.SendMany(context.Background(), msgs)
+
+	assert.Equal(t, int32(2), calls.Load())
+	assert.NoError(t, results[0].Err)
+	assert.ErrorContains(t, results[1].Err, "skipped by policy")
+	require.Len(t, provider.batches, 1)
+	assert.Len(t, provider.batches[0], 1)
+}
@@ -0,0 +1,132 @@
+package mailpen_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/processors/linkparams"
+	"github.com/patrickward/mailpen/processors/plaintext"
+	"github.com/patrickward/mailpen/providers/memory"
+	"github.com/patrickward/mailpen/templates"
+)
+
+// benchmarkData is the data used to render the welcome email in benchmarks
+var benchmarkData = map[string]any{"Name": "Ada", "CompanyName": "Acme"}
+
+// benchmarkManager creates a manager for the base test templates
+func benchmarkManager(b *testing.B, config *mailpen.ManagerConfig) *mailpen.Manager {
+	b.Helper()
+
+	config.Sources = []mailpen.TemplateSource{{Name: "base", FS: testFS(b, "base")}}
+	manager, err := mailpen.NewManager(config)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return manager
+}
+
+func BenchmarkManager_RenderEmail(b *testing.B) {
+	manager := benchmarkManager(b, &mailpen.ManagerConfig{})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := manager.RenderEmail("welcome", benchmarkData, ""); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkManager_RenderEmail_Uncached(b *testing.B) {
+	manager := benchmarkManager(b, &mailpen.ManagerConfig{})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		manager.ClearCache()
+		if _, err := manager.RenderEmail("welcome", benchmarkData, ""); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkManager_RenderEmail_Processors(b *testing.B) {
+	manager := benchmarkManager(b, &mailpen.ManagerConfig{
+		Processors: []mailpen.ContextProcessor{
+			mailpen.AdaptProcessor(linkparams.New(map[string]string{"utm_source": "email", "utm_medium": "welcome"})),
+		},
+		TextConverter: plaintext.New(),
+	})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := manager.RenderEmail("welcome", benchmarkData, ""); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkManager_RenderEmail_Parallel(b *testing.B) {
+	manager := benchmarkManager(b, &mailpen.ManagerConfig{})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := manager.RenderEmail("welcome", benchmarkData, ""); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkManager_Compose(b *testing.B) {
+	manager := benchmarkManager(b, &mailpen.ManagerConfig{})
+	email := templates.Layout{
+		Subject: "Weekly report",
+		Components: []templates.Component{
+			templates.Heading{Text: "Weekly report"},
+			templates.Paragraph{Text: "Here is how your week went."},
+			templates.Button{Text: "View dashboard", URL: "https://example.com/dashboard"},
+		},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := manager.Compose(context.Background(), email, benchmarkData, mailpen.RenderOptions{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMailpen_Send(b *testing.B) {
+	provider := memory.New()
+	mp, err := mailpen.New(provider, &mailpen.Config{
+		From:    "sender@example.com",
+		Sources: []mailpen.TemplateSource{{Name: "base", FS: testFS(b, "base")}},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		msg := mailpen.NewMessage().
+			To("recipient@example.com").
+			Template("welcome").
+			WithData(benchmarkData).
+			Must()
+		if err := mp.Send(ctx, msg); err != nil {
+			b.Fatal(err)
+		}
+		if i%1000 == 999 {
+			provider.Reset()
+		}
+	}
+}
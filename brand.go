@@ -0,0 +1,132 @@
+package mailpen
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// ErrInvalidBrandKit is returned when a brand kit has color or theme overrides that are unsafe to write into CSS
+var ErrInvalidBrandKit = errors.New("invalid brand kit")
+
+// unsafeCSSChars can end a CSS declaration or rule, or the <style> element the theme CSS is written into
+const unsafeCSSChars = "<>{};\\"
+
+// BrandKit holds the branding used to white-label an email for a single tenant
+type BrandKit struct {
+	ID          string         // Unique identifier, used to cache the themed templates for this kit
+	From        string         // From address used when the message doesn't set one (defaults to Config.From)
+	FromName    string         // Display name for the from address
+	CompanyName string         // Company name shown in templates
+	LogoURL     string         // URL to the tenant logo
+	FooterText  string         // Footer text exposed to templates as FooterText
+	Colors      map[string]any // Color overrides merged into the theme's colors
+	Theme       map[string]any // Theme overrides merged into the base theme
+}
+
+// BrandResolver resolves the brand kit for a message. It returns nil when the message uses the default branding.
+type BrandResolver func(msg *Message) (*BrandKit, error)
+
+// BrandKitsByDataKey returns a BrandResolver that looks up a brand kit by the string value stored under key in
+// the message data (e.g. "TenantID"). Messages without the key use the default branding, while unknown values
+// return an error. It panics when a kit fails Validate, so a bad kit is caught when it is registered.
+func BrandKitsByDataKey(key string, kits ...*BrandKit) BrandResolver {
+	byID := make(map[string]*BrandKit, len(kits))
+	for _, kit := range kits {
+		if err := kit.Validate(); err != nil {
+			panic(fmt.Sprintf("mailpen: BrandKitsByDataKey: %v", err))
+		}
+		byID[kit.ID] = kit
+	}
+
+	return func(msg *Message) (*BrandKit, error) {
+		id, ok := msg.Data[key].(string)
+		if !ok || id == "" {
+			return nil, nil
+		}
+
+		kit, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("no brand kit found for %s %q", key, id)
+		}
+		return kit, nil
+	}
+}
+
+// Validate checks the kit's color and theme overrides, which are written unescaped into the theme's <style>
+// block. Token names and values may not contain <, >, {, }, ; or a backslash.
+func (b *BrandKit) Validate() error {
+	tokens := make(map[string]string)
+	flattenTokens("colors", b.Colors, tokens)
+	flattenTokens("", b.Theme, tokens)
+
+	var problems []string
+	for _, p := range sortedKeys(tokens) {
+		if strings.ContainsAny(p, unsafeCSSChars) {
+			problems = append(problems, fmt.Sprintf("token name %q contains characters not allowed in CSS", p))
+		} else if strings.ContainsAny(tokens[p], unsafeCSSChars) {
+			problems = append(problems, fmt.Sprintf("token %s value %q contains characters not allowed in CSS", p, tokens[p]))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w %q: %s", ErrInvalidBrandKit, b.ID, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// theme returns the base theme with the kit's color and theme overrides applied
+func (b *BrandKit) theme(base map[string]any) map[string]any {
+	theme := MergeTheme(base, b.Theme)
+	if len(b.Colors) > 0 {
+		theme = MergeTheme(theme, map[string]any{"colors": b.Colors})
+	}
+	return theme
+}
+
+// templateData returns the template values the kit overrides
+func (b *BrandKit) templateData(cfg *Config, now time.Time) map[string]any {
+	now = cfg.localTime(now)
+	data := map[string]any{
+		"Brand": b,
+	}
+
+	if b.CompanyName != "" {
+		data["CompanyName"] = b.CompanyName
+		data["Copyright"] = fmt.Sprintf("© %d %s. All rights reserved", now.Year(), b.CompanyName)
+
+		footer := footerData(cfg, now)
+		footer.CompanyName = b.CompanyName
+		footer.CopyrightText = fmt.Sprintf("© %d %s. All rights reserved.", now.Year(), b.CompanyName)
+		data["FooterData"] = footer
+	}
+	if b.LogoURL != "" {
+		data["LogoURL"] = b.LogoURL
+	}
+	if b.FooterText != "" {
+		data["FooterText"] = b.FooterText
+	}
+
+	return data
+}
+
+// from returns the from address for the kit, falling back to the given default address. When the kit sets
+// FromName, an address that already has a display name, such as a Config.From of "Acme <noreply@acme.com>",
+// contributes only its address, so the header holds a single name.
+func (b *BrandKit) from(fallback string) string {
+	address := fallback
+	if b.From != "" {
+		address = b.From
+	}
+
+	if b.FromName == "" || address == "" {
+		return address
+	}
+	if parsed, err := mail.ParseAddress(address); err == nil {
+		address = parsed.Address
+	}
+
+	return (&mail.Address{Name: b.FromName, Address: address}).String()
+}
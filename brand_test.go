@@ -0,0 +1,224 @@
+package mailpen_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+func TestMailpen_SendWithBrandKit(t *testing.T) {
+	acme := &mailpen.BrandKit{
+		ID:          "acme",
+		FromName:    "ACME Support",
+		CompanyName: "ACME Corp",
+		FooterText:  "Sent by ACME",
+		Colors: map[string]any{
+			"primary": "#ff0000",
+		},
+	}
+
+	mock := &mockProvider{}
+	mp, err := mailpen.New(mock, &mailpen.Config{
+		From:          "sender@example.com",
+		CompanyName:   "Default Inc",
+		BrandResolver: mailpen.BrandKitsByDataKey("TenantID", acme),
+		Sources:       []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+	})
+	require.NoError(t, err)
+
+	t.Run("tenant branding applied", func(t *testing.T) {
+		msg := mailpen.NewMessage().
+			To("recipient@example.com").
+			Template("headers-test").
+			WithData(map[string]any{"TenantID": "acme", "mainTitle": "Hello"}).
+			Must()
+
+		require.NoError(t, mp.Send(context.Background(), msg))
+		assert.Equal(t, `"ACME Support" <sender@example.com>`, mock.lastMessage.From)
+		assert.Contains(t, mock.lastMessage.HTMLBody, "color: #ff0000;")
+		assert.NotContains(t, mock.lastMessage.HTMLBody, "color: #4DA647;")
+	})
+
+	t.Run("default branding without tenant", func(t *testing.T) {
+		msg := mailpen.NewMessage().
+			To("recipient@example.com").
+			Template("headers-test").
+			WithData(map[string]any{"mainTitle": "Hello"}).
+			Must()
+
+		require.NoError(t, mp.Send(context.Background(), msg))
+		assert.Equal(t, "sender@example.com", mock.lastMessage.From)
+		assert.Contains(t, mock.lastMessage.HTMLBody, "color: #4DA647;")
+	})
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		msg := mailpen.NewMessage().
+			To("recipient@example.com").
+			Template("headers-test").
+			WithData(map[string]any{"TenantID": "globex"}).
+			Must()
+
+		err := mp.Send(context.Background(), msg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `no brand kit found for TenantID "globex"`)
+	})
+}
+
+func TestBrandKit_FooterData(t *testing.T) {
+	acme := &mailpen.BrandKit{ID: "acme", CompanyName: "ACME Corp"}
+
+	mp, err := mailpen.New(&mockProvider{}, &mailpen.Config{
+		From:            "sender@example.com",
+		CompanyName:     "Default Inc",
+		CompanyAddress1: "1 Main St",
+		BrandResolver:   mailpen.BrandKitsByDataKey("TenantID", acme),
+		Sources: []mailpen.TemplateSource{{Name: "test", FS: fstest.MapFS{
+			"emails/footer.html": {Data: []byte(`{{define "content"}}{{.FooterData.CompanyName}}, {{.FooterData.AddressLine1}}{{end}}`)},
+		}}},
+	})
+	require.NoError(t, err)
+
+	msg := mailpen.NewMessage().To("recipient@example.com").Template("footer").
+		WithData(map[string]any{"TenantID": "acme"}).Must()
+	email, err := mp.Render(context.Background(), msg)
+	require.NoError(t, err)
+	assert.Contains(t, email.HTML, "ACME Corp, 1 Main St")
+}
+
+func TestMergeTheme(t *testing.T) {
+	base := mailpen.DefaultTheme()
+	merged := mailpen.MergeTheme(base, map[string]any{
+		"colors": map[string]any{
+			"text": map[string]any{"primary": "#000000"},
+		},
+	})
+
+	assert.Equal(t, "#000000", mailpen.GetThemeValue(merged, "colors.text.primary"))
+	assert.Equal(t, "#666666", mailpen.GetThemeValue(merged, "colors.text.secondary"))
+	assert.Equal(t, "#333333", mailpen.GetThemeValue(base, "colors.text.primary"), "base theme must not change")
+}
+
+func TestBrandKit_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		kit     *mailpen.BrandKit
+		wantErr string
+	}{
+		{
+			name: "valid tokens",
+			kit: &mailpen.BrandKit{
+				ID:     "acme",
+				Colors: map[string]any{"primary": "#ff0000", "text": "rgb(0, 0, 0)"},
+				Theme:  map[string]any{"spacing": map[string]any{"md": "16px"}},
+			},
+		},
+		{
+			name:    "style element breakout",
+			kit:     &mailpen.BrandKit{ID: "acme", Colors: map[string]any{"primary": "red</style><script>alert(1)</script>"}},
+			wantErr: `token colors.primary value`,
+		},
+		{
+			name:    "extra declaration",
+			kit:     &mailpen.BrandKit{ID: "acme", Colors: map[string]any{"primary": "red; background: url(https://evil.test)"}},
+			wantErr: `token colors.primary value`,
+		},
+		{
+			name:    "nested theme rule",
+			kit:     &mailpen.BrandKit{ID: "acme", Theme: map[string]any{"spacing": map[string]any{"md": "1px } body { display: none"}}},
+			wantErr: `token spacing.md value`,
+		},
+		{
+			name:    "token name",
+			kit:     &mailpen.BrandKit{ID: "acme", Colors: map[string]any{"x{}": "red"}},
+			wantErr: `token name "colors.x{}"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.kit.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, mailpen.ErrInvalidBrandKit)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestBrandKitsByDataKey_RejectsUnsafeKit(t *testing.T) {
+	kit := &mailpen.BrandKit{ID: "acme", Colors: map[string]any{"primary": "red</style>"}}
+	assert.Panics(t, func() { mailpen.BrandKitsByDataKey("TenantID", kit) })
+}
+
+func TestMailpen_SendRejectsUnsafeResolvedKit(t *testing.T) {
+	kit := &mailpen.BrandKit{ID: "acme", Colors: map[string]any{"primary": "red;}</style><img src=x>"}}
+
+	mock := &mockProvider{}
+	mp, err := mailpen.New(mock, &mailpen.Config{
+		From: "sender@example.com",
+		BrandResolver: func(*mailpen.Message) (*mailpen.BrandKit, error) {
+			return kit, nil
+		},
+		Sources: []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+	})
+	require.NoError(t, err)
+
+	msg := mailpen.NewMessage().
+		To("recipient@example.com").
+		Template("headers-test").
+		WithData(map[string]any{"mainTitle": "Hello"}).
+		Must()
+
+	err = mp.Send(context.Background(), msg)
+	require.ErrorIs(t, err, mailpen.ErrInvalidBrandKit)
+	assert.Nil(t, mock.lastMessage)
+}
+
+func TestBrandKit_FromWithNamedConfigFrom(t *testing.T) {
+	tests := []struct {
+		name string
+		kit  *mailpen.BrandKit
+		want string
+	}{
+		{
+			name: "kit name replaces the config name",
+			kit:  &mailpen.BrandKit{ID: "acme", FromName: "ACME Support"},
+			want: `"ACME Support" <noreply@example.com>`,
+		},
+		{
+			name: "config from is kept without a kit name",
+			kit:  &mailpen.BrandKit{ID: "acme"},
+			want: "Default Inc <noreply@example.com>",
+		},
+		{
+			name: "named kit from",
+			kit:  &mailpen.BrandKit{ID: "acme", From: "ACME <hello@acme.example>", FromName: "ACME Support"},
+			want: `"ACME Support" <hello@acme.example>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockProvider{}
+			mp, err := mailpen.New(mock, &mailpen.Config{
+				From:          "Default Inc <noreply@example.com>",
+				BrandResolver: mailpen.BrandKitsByDataKey("TenantID", tt.kit),
+			})
+			require.NoError(t, err)
+
+			msg := mailpen.NewMessage().To("recipient@example.com").Subject("Hello").
+				WithData(map[string]any{"TenantID": "acme"}).Must()
+			msg.TextBody = "Hello"
+
+			require.NoError(t, mp.Send(context.Background(), msg))
+			assert.Equal(t, tt.want, mock.lastMessage.From)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package mailpen
+
+import (
+	"hash/fnv"
+	"html/template"
+	"sync"
+	"sync/atomic"
+)
+
+// cacheShards is the number of independently locked shards of the email template cache
+const cacheShards = 32
+
+// DefaultTemplateCacheSize is the number of parsed email templates a Manager keeps when
+// ManagerConfig.TemplateCacheSize is not set
+const DefaultTemplateCacheSize = 1024
+
+// templateCache caches parsed email templates. Keys are spread over shards with their own locks, so renders
+// of different templates do not contend, and concurrent misses on the same key parse the template once.
+// Each shard holds at most shardSize templates and evicts the least recently used one when full, which bounds
+// the cache when every brand or theme variant of a template gets its own entry.
+type templateCache struct {
+	shards    [cacheShards]cacheShard
+	shardSize int
+	clock     atomic.Int64 // Incremented on every lookup to order entries by use
+}
+
+// cacheShard is a locked part of the cache
+type cacheShard struct {
+	mu      sync.RWMutex
+	entries map[string]*cacheEntry
+}
+
+// cacheEntry is a cached template, or one being built. ready is closed once tmpl and err are set.
+type cacheEntry struct {
+	ready chan struct{}
+	tmpl  *template.Template
+	err   error
+	used  atomic.Int64 // Clock value of the last lookup
+}
+
+// newTemplateCache creates an empty cache holding about size templates, or DefaultTemplateCacheSize when size
+// is not positive
+func newTemplateCache(size int) *templateCache {
+	if size <= 0 {
+		size = DefaultTemplateCacheSize
+	}
+
+	c := &templateCache{shardSize: (size + cacheShards - 1) / cacheShards}
+	for i := range c.shards {
+		c.shards[i].entries = make(map[string]*cacheEntry)
+	}
+	return c
+}
+
+// shard returns the shard of a key
+func (c *templateCache) shard(key string) *cacheShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return &c.shards[h.Sum32()%cacheShards]
+}
+
+// get returns the cached template for key, calling build on a miss. Callers that miss the same key while it
+// is being built wait for that build and share its result. Build errors are returned to the waiting callers
+// but not cached, so the next call tries again.
+func (c *templateCache) get(key string, build func() (*template.Template, error)) (*template.Template, error) {
+	shard := c.shard(key)
+	now := c.clock.Add(1)
+
+	shard.mu.RLock()
+	entry, ok := shard.entries[key]
+	shard.mu.RUnlock()
+
+	if !ok {
+		shard.mu.Lock()
+		if entry, ok = shard.entries[key]; !ok {
+			if len(shard.entries) >= c.shardSize {
+				shard.evict()
+			}
+			entry = &cacheEntry{ready: make(chan struct{})}
+			entry.used.Store(now)
+			shard.entries[key] = entry
+		}
+		shard.mu.Unlock()
+
+		if !ok {
+			entry.tmpl, entry.err = build()
+			close(entry.ready)
+
+			if entry.err != nil {
+				shard.mu.Lock()
+				if shard.entries[key] == entry {
+					delete(shard.entries, key)
+				}
+				shard.mu.Unlock()
+			}
+			return entry.tmpl, entry.err
+		}
+	}
+
+	entry.used.Store(now)
+	<-entry.ready
+	return entry.tmpl, entry.err
+}
+
+// evict removes the least recently used entry. The caller holds the shard's write lock.
+func (s *cacheShard) evict() {
+	var (
+		oldest string
+		used   int64
+		found  bool
+	)
+	for key, entry := range s.entries {
+		if u := entry.used.Load(); !found || u < used {
+			oldest, used, found = key, u, true
+		}
+	}
+	if found {
+		delete(s.entries, oldest)
+	}
+}
+
+// clear removes every template. Builds in progress finish for their callers, but their templates are not
+// kept, since they may have been built from the templates the caller is invalidating.
+func (c *templateCache) clear() {
+	for i := range c.shards {
+		shard := &c.shards[i]
+		shard.mu.Lock()
+		shard.entries = make(map[string]*cacheEntry)
+		shard.mu.Unlock()
+	}
+}
@@ -0,0 +1,149 @@
+package mailpen_test
+
+import (
+	"fmt"
+	"io/fs"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+// countingFS counts the opens of each file
+type countingFS struct {
+	fs.FS
+	mu    sync.Mutex
+	opens map[string]int
+}
+
+func (c *countingFS) Open(name string) (fs.File, error) {
+	c.mu.Lock()
+	c.opens[name]++
+	c.mu.Unlock()
+	return c.FS.Open(name)
+}
+
+func (c *countingFS) count(name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.opens[name]
+}
+
+func TestManager_CacheConcurrentRenders(t *testing.T) {
+	files := fstest.MapFS{
+		"layouts/base.html":      {Data: []byte(`<html>{{template "content" .}}</html>`)},
+		"layouts/marketing.html": {Data: []byte(`<div>{{template "content" .}}</div>`)},
+	}
+	for i := 0; i < 5; i++ {
+		files[fmt.Sprintf("emails/email%d.html", i)] = &fstest.MapFile{
+			Data: []byte(fmt.Sprintf(`{{define "content"}}email %d for {{.Name}}{{end}}`, i)),
+		}
+	}
+	source := &countingFS{FS: files, opens: make(map[string]int)}
+
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{{Name: "test", FS: source}},
+	})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("email%d", i%5)
+			layout := []string{"base", "marketing"}[i%2]
+			email, err := manager.RenderEmail(name, map[string]any{"Name": "Ada"}, layout)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if want := fmt.Sprintf("email %d for Ada", i%5); !assert.Contains(t, email.HTML, want) {
+				errs <- fmt.Errorf("%s: unexpected HTML %q", name, email.HTML)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, 1, source.count(fmt.Sprintf("emails/email%d.html", i)), "email%d is parsed once for all layouts", i)
+	}
+}
+
+func TestManager_CacheInvalidation(t *testing.T) {
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{{Name: "base", FS: fstest.MapFS{
+			"layouts/base.html":   {Data: []byte(`{{template "content" .}}`)},
+			"emails/welcome.html": {Data: []byte(`{{define "content"}}Welcome{{end}}`)},
+		}}},
+	})
+	require.NoError(t, err)
+
+	email, err := manager.RenderEmail("welcome", nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome", email.HTML)
+
+	_, err = manager.RenderEmail("goodbye", nil, "")
+	require.Error(t, err, "missing templates are not cached")
+
+	require.NoError(t, manager.AddSource(mailpen.TemplateSource{Name: "override", FS: fstest.MapFS{
+		"emails/welcome.html": {Data: []byte(`{{define "content"}}Welcome back{{end}}`)},
+		"emails/goodbye.html": {Data: []byte(`{{define "content"}}Goodbye{{end}}`)},
+	}}))
+
+	email, err = manager.RenderEmail("welcome", nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome back", email.HTML)
+
+	email, err = manager.RenderEmail("goodbye", nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Goodbye", email.HTML)
+}
+
+func TestManager_CacheSize(t *testing.T) {
+	const emails = 100
+
+	files := fstest.MapFS{
+		"layouts/base.html": {Data: []byte(`{{template "content" .}}`)},
+	}
+	for i := 0; i < emails; i++ {
+		files[fmt.Sprintf("emails/email%d.html", i)] = &fstest.MapFile{
+			Data: []byte(fmt.Sprintf(`{{define "content"}}email %d{{end}}`, i)),
+		}
+	}
+
+	parses := func(t *testing.T, size int) int {
+		source := &countingFS{FS: files, opens: make(map[string]int)}
+		manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+			Sources:           []mailpen.TemplateSource{{Name: "test", FS: source}},
+			TemplateCacheSize: size,
+		})
+		require.NoError(t, err)
+
+		for range 2 {
+			for i := 0; i < emails; i++ {
+				_, err := manager.RenderEmail(fmt.Sprintf("email%d", i), nil, "")
+				require.NoError(t, err)
+			}
+		}
+
+		total := 0
+		for i := 0; i < emails; i++ {
+			total += source.count(fmt.Sprintf("emails/email%d.html", i))
+		}
+		return total
+	}
+
+	assert.Equal(t, emails, parses(t, 0), "the default size keeps every email")
+	// A size of one allows one template per shard, so most emails are evicted before the second pass
+	assert.GreaterOrEqual(t, parses(t, 1), 2*emails-32)
+}
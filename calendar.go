@@ -0,0 +1,163 @@
+package mailpen
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CalendarEvent describes an event rendered by the @event component and attached with Builder.AttachEvent
+type CalendarEvent struct {
+	UID         string    // Unique identifier; derived from the title and start time when empty
+	Title       string    // e.g., "Quarterly Review"
+	Description string    // Optional details
+	Location    string    // Venue or address
+	URL         string    // Optional event page or meeting link
+	Start       time.Time // Shown in the time zone of Start
+	End         time.Time // Defaults to one hour after Start
+	ICSURL      string    // Optional link to download the event as an .ics file
+}
+
+// end returns the end of the event
+func (e CalendarEvent) end() time.Time {
+	if e.End.IsZero() {
+		return e.Start.Add(time.Hour)
+	}
+	return e.End
+}
+
+// When returns the date and time of the event in the time zone of Start, e.g.,
+// "Monday, March 3, 2025, 9:00 AM – 10:30 AM PST"
+func (e CalendarEvent) When() string {
+	end := e.end().In(e.Start.Location())
+	if sameDay(e.Start, end) {
+		return fmt.Sprintf("%s, %s – %s", e.Start.Format("Monday, January 2, 2006"), e.Start.Format("3:04 PM"), end.Format("3:04 PM MST"))
+	}
+	return fmt.Sprintf("%s – %s", e.Start.Format("Monday, January 2, 2006, 3:04 PM MST"), end.Format("Monday, January 2, 2006, 3:04 PM MST"))
+}
+
+// GoogleCalendarURL returns a link that adds the event to Google Calendar
+func (e CalendarEvent) GoogleCalendarURL() string {
+	query := url.Values{
+		"action": {"TEMPLATE"},
+		"text":   {e.Title},
+		"dates":  {icsTime(e.Start) + "/" + icsTime(e.end())},
+	}
+	if details := e.details(); details != "" {
+		query.Set("details", details)
+	}
+	if e.Location != "" {
+		query.Set("location", e.Location)
+	}
+	return "https://calendar.google.com/calendar/render?" + query.Encode()
+}
+
+// OutlookCalendarURL returns a link that adds the event to Outlook on the web
+func (e CalendarEvent) OutlookCalendarURL() string {
+	query := url.Values{
+		"path":    {"/calendar/action/compose"},
+		"rru":     {"addevent"},
+		"subject": {e.Title},
+		"startdt": {e.Start.UTC().Format(time.RFC3339)},
+		"enddt":   {e.end().UTC().Format(time.RFC3339)},
+	}
+	if details := e.details(); details != "" {
+		query.Set("body", details)
+	}
+	if e.Location != "" {
+		query.Set("location", e.Location)
+	}
+	return "https://outlook.live.com/calendar/0/deeplink/compose?" + query.Encode()
+}
+
+// ICS returns the event as an iCalendar (RFC 5545) document
+func (e CalendarEvent) ICS() []byte {
+	var b strings.Builder
+	line := func(name, value string) {
+		b.WriteString(foldICSLine(name + ":" + value))
+		b.WriteString("\r\n")
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//mailpen//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("BEGIN", "VEVENT")
+	line("UID", e.uid())
+	line("DTSTAMP", icsTime(time.Now()))
+	line("DTSTART", icsTime(e.Start))
+	line("DTEND", icsTime(e.end()))
+	line("SUMMARY", escapeICS(e.Title))
+	if e.Description != "" {
+		line("DESCRIPTION", escapeICS(e.Description))
+	}
+	if e.Location != "" {
+		line("LOCATION", escapeICS(e.Location))
+	}
+	if e.URL != "" {
+		line("URL", e.URL)
+	}
+	line("END", "VEVENT")
+	line("END", "VCALENDAR")
+
+	return []byte(b.String())
+}
+
+// uid returns the UID of the event
+func (e CalendarEvent) uid() string {
+	if e.UID != "" {
+		return e.UID
+	}
+	sum := sha1.Sum([]byte(e.Title + "|" + e.Start.UTC().Format(time.RFC3339)))
+	return hex.EncodeToString(sum[:]) + "@mailpen"
+}
+
+// details returns the description followed by the event URL
+func (e CalendarEvent) details() string {
+	return strings.TrimSpace(e.Description + "\n\n" + e.URL)
+}
+
+// icsTime formats a time in UTC as an iCalendar date-time
+func icsTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// sameDay reports whether two times fall on the same calendar day
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// icsEscaper escapes iCalendar text values
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escapeICS escapes an iCalendar text value
+func escapeICS(s string) string {
+	return icsEscaper.Replace(s)
+}
+
+// foldICSLine folds a content line into lines of at most 75 octets, without splitting UTF-8 characters
+func foldICSLine(line string) string {
+	const limit = 75
+	if len(line) <= limit {
+		return line
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
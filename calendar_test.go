@@ -0,0 +1,132 @@
+package mailpen_test
+
+import (
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+func TestCalendarEvent_When(t *testing.T) {
+	pst := time.FixedZone("PST", -8*60*60)
+
+	tests := []struct {
+		name  string
+		event mailpen.CalendarEvent
+		want  string
+	}{
+		{
+			name:  "same day",
+			event: mailpen.CalendarEvent{Start: time.Date(2025, 3, 3, 9, 0, 0, 0, pst), End: time.Date(2025, 3, 3, 17, 0, 0, 0, pst)},
+			want:  "Monday, March 3, 2025, 9:00 AM – 5:00 PM PST",
+		},
+		{
+			name:  "default duration",
+			event: mailpen.CalendarEvent{Start: time.Date(2025, 3, 3, 9, 0, 0, 0, pst)},
+			want:  "Monday, March 3, 2025, 9:00 AM – 10:00 AM PST",
+		},
+		{
+			name:  "end in another time zone",
+			event: mailpen.CalendarEvent{Start: time.Date(2025, 3, 3, 9, 0, 0, 0, pst), End: time.Date(2025, 3, 3, 18, 0, 0, 0, time.UTC)},
+			want:  "Monday, March 3, 2025, 9:00 AM – 10:00 AM PST",
+		},
+		{
+			name:  "multiple days",
+			event: mailpen.CalendarEvent{Start: time.Date(2025, 3, 3, 9, 0, 0, 0, pst), End: time.Date(2025, 3, 5, 12, 0, 0, 0, pst)},
+			want:  "Monday, March 3, 2025, 9:00 AM PST – Wednesday, March 5, 2025, 12:00 PM PST",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.event.When())
+		})
+	}
+}
+
+func TestCalendarEvent_URLs(t *testing.T) {
+	event := mailpen.CalendarEvent{
+		Title:       "Launch party",
+		Description: "Drinks & demos",
+		Location:    "Moscone Center",
+		URL:         "https://example.com/launch",
+		Start:       time.Date(2025, 3, 3, 17, 0, 0, 0, time.UTC),
+	}
+
+	google, err := url.Parse(event.GoogleCalendarURL())
+	require.NoError(t, err)
+	assert.Equal(t, "calendar.google.com", google.Host)
+	assert.Equal(t, "TEMPLATE", google.Query().Get("action"))
+	assert.Equal(t, "Launch party", google.Query().Get("text"))
+	assert.Equal(t, "20250303T170000Z/20250303T180000Z", google.Query().Get("dates"))
+	assert.Equal(t, "Drinks & demos\n\nhttps://example.com/launch", google.Query().Get("details"))
+	assert.Equal(t, "Moscone Center", google.Query().Get("location"))
+
+	outlook, err := url.Parse(event.OutlookCalendarURL())
+	require.NoError(t, err)
+	assert.Equal(t, "outlook.live.com", outlook.Host)
+	assert.Equal(t, "Launch party", outlook.Query().Get("subject"))
+	assert.Equal(t, "2025-03-03T17:00:00Z", outlook.Query().Get("startdt"))
+	assert.Equal(t, "2025-03-03T18:00:00Z", outlook.Query().Get("enddt"))
+}
+
+func TestCalendarEvent_ICS(t *testing.T) {
+	event := mailpen.CalendarEvent{
+		Title:       "Review; Q1, 2025",
+		Description: "Agenda:\nNumbers\nPlans " + strings.Repeat("x", 80),
+		Location:    "Room 1",
+		Start:       time.Date(2025, 3, 3, 9, 0, 0, 0, time.FixedZone("PST", -8*60*60)),
+	}
+
+	ics := string(event.ICS())
+	assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(ics, "END:VEVENT\r\nEND:VCALENDAR\r\n"))
+	assert.Contains(t, ics, "DTSTART:20250303T170000Z\r\n")
+	assert.Contains(t, ics, "DTEND:20250303T180000Z\r\n")
+	assert.Contains(t, ics, `SUMMARY:Review\; Q1\, 2025`+"\r\n")
+	assert.Contains(t, ics, "LOCATION:Room 1\r\n")
+	assert.Contains(t, ics, `DESCRIPTION:Agenda:\nNumbers\nPlans`)
+	assert.Contains(t, ics, "@mailpen\r\n")
+
+	for _, line := range strings.Split(ics, "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, line)
+	}
+
+	// The derived UID is stable
+	assert.Equal(t, uidOf(ics), uidOf(string(event.ICS())))
+
+	event.UID = "event-1@example.com"
+	assert.Equal(t, "event-1@example.com", uidOf(string(event.ICS())))
+}
+
+// uidOf returns the UID of an iCalendar document
+func uidOf(ics string) string {
+	for _, line := range strings.Split(ics, "\r\n") {
+		if uid, ok := strings.CutPrefix(line, "UID:"); ok {
+			return uid
+		}
+	}
+	return ""
+}
+
+func TestBuilder_AttachEvent(t *testing.T) {
+	event := mailpen.CalendarEvent{Title: "Standup", Start: time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)}
+
+	msg, err := mailpen.NewMessage().To("user@example.com").Template("invite").AttachEvent(event).Build()
+	require.NoError(t, err)
+	require.Len(t, msg.Attachments, 1)
+
+	attachment := msg.Attachments[0]
+	assert.Equal(t, "invite.ics", attachment.Filename)
+	assert.Equal(t, mailpen.ContentType("text/calendar; method=PUBLISH"), attachment.ContentType)
+
+	data, err := io.ReadAll(attachment.Data)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "SUMMARY:Standup")
+}
@@ -0,0 +1,245 @@
+// Package clienttest submits rendered emails to email client testing services, Litmus and Email on Acid, and
+// retrieves screenshots of each email client, so client-matrix checks can run in CI.
+package clienttest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultPollInterval is how often Run checks for results
+const DefaultPollInterval = 10 * time.Second
+
+// Status is the state of a client result
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusComplete Status = "complete"
+	StatusFailed   Status = "failed"
+)
+
+// Email is a rendered email to test
+type Email struct {
+	Subject string
+	HTML    string
+	Clients []string // Provider client IDs, e.g. "outlook16" or "gmailnew"
+}
+
+// Result is the outcome of a test in one client
+type Result struct {
+	Client        string // Provider client ID
+	Name          string // Display name, if the provider has one
+	Status        Status
+	ScreenshotURL string
+	ThumbnailURL  string
+}
+
+// Test is a submitted email test and its results so far
+type Test struct {
+	ID      string
+	Results []Result
+}
+
+// Complete reports whether every client has finished, successfully or not
+func (t *Test) Complete() bool {
+	for _, r := range t.Results {
+		if r.Status == StatusPending {
+			return false
+		}
+	}
+	return true
+}
+
+// Failed returns the results of clients that failed
+func (t *Test) Failed() []Result {
+	var failed []Result
+	for _, r := range t.Results {
+		if r.Status == StatusFailed {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// Provider is an email client testing service
+type Provider interface {
+	// Submit starts a test of the email and returns its ID
+	Submit(ctx context.Context, email Email) (string, error)
+
+	// Results returns the current results of a test
+	Results(ctx context.Context, id string) (*Test, error)
+}
+
+// Run submits the email and waits for every client to finish, polling every DefaultPollInterval. Set a
+// deadline on the context to bound the wait.
+func Run(ctx context.Context, provider Provider, email Email) (*Test, error) {
+	id, err := provider.Submit(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	return Wait(ctx, provider, id, DefaultPollInterval)
+}
+
+// Wait polls a test at the interval until every client finishes or the context is done, in which case it
+// returns the latest results with the context's error
+func Wait(ctx context.Context, provider Provider, id string, interval time.Duration) (*Test, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		test, err := provider.Results(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if test.Complete() {
+			return test, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return test, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// SaveScreenshots downloads the screenshot of each completed result to dir, named "<client>.png" after the
+// client ID, and returns the paths of the written files
+func SaveScreenshots(ctx context.Context, client *http.Client, test *Test, dir string) ([]string, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, r := range test.Results {
+		if r.Status != StatusComplete || r.ScreenshotURL == "" {
+			continue
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.ScreenshotURL, nil)
+		if err != nil {
+			return paths, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return paths, fmt.Errorf("failed to download screenshot for %s: %w", r.Client, err)
+		}
+		data, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return paths, fmt.Errorf("failed to download screenshot for %s: %w", r.Client, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return paths, fmt.Errorf("failed to download screenshot for %s: %s", r.Client, resp.Status)
+		}
+
+		name := path.Base(r.Client)
+		p := filepath.Join(dir, name+".png")
+		if err := os.WriteFile(p, data, 0o644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, p)
+	}
+
+	return paths, nil
+}
+
+// APIError is an error response from a testing service
+type APIError struct {
+	Service    string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: %d %s", e.Service, e.StatusCode, e.Message)
+}
+
+// Option configures a provider
+type Option func(c *config)
+
+type config struct {
+	client  *http.Client
+	baseURL string
+}
+
+// WithHTTPClient sets the HTTP client used for API requests (defaults to a client with a 30 second timeout)
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *config) {
+		c.client = client
+	}
+}
+
+// WithBaseURL overrides the API base URL, e.g. for a proxy or a test server
+func WithBaseURL(url string) Option {
+	return func(c *config) {
+		c.baseURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// newConfig applies the options over the defaults
+func newConfig(baseURL string, opts []Option) config {
+	c := config{client: &http.Client{Timeout: 30 * time.Second}, baseURL: baseURL}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// do sends a JSON request with basic auth and decodes the JSON response into out
+func (c config) do(ctx context.Context, service, method, path, username, password string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(username, password)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", service, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", service, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &APIError{Service: service, StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%s: invalid response: %w", service, err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package clienttest_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen/clienttest"
+)
+
+func TestEmailOnAcid(t *testing.T) {
+	var mu sync.Mutex
+	polls := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /email/tests", func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		assert.Equal(t, "key", user)
+		assert.Equal(t, "secret", pass)
+
+		var req map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "Welcome", req["subject"])
+		assert.Equal(t, "<p>Hi</p>", req["html"])
+		assert.Equal(t, []any{"outlook16", "gmailw10"}, req["clients"])
+
+		_, _ = w.Write([]byte(`{"id": "abc"}`))
+	})
+	mux.HandleFunc("GET /email/tests/abc", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		polls++
+		if polls == 1 {
+			_, _ = w.Write([]byte(`{"completed": [], "processing": ["outlook16", "gmailw10"], "bounced": []}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"completed": ["outlook16"], "processing": [], "bounced": ["gmailw10"]}`))
+	})
+	mux.HandleFunc("GET /email/tests/abc/results", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"outlook16": {"display_name": "Outlook 2016", "screenshots": {"default": "https://example.com/o.png"}, "thumbnail": "https://example.com/t.png"}}`))
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	provider := clienttest.NewEmailOnAcid("key", "secret", clienttest.WithBaseURL(server.URL))
+
+	id, err := provider.Submit(context.Background(), clienttest.Email{
+		Subject: "Welcome",
+		HTML:    "<p>Hi</p>",
+		Clients: []string{"outlook16", "gmailw10"},
+	})
+	require.NoError(t, err)
+
+	test, err := clienttest.Wait(context.Background(), provider, id, time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, 2, polls)
+	assert.Equal(t, []clienttest.Result{
+		{Client: "gmailw10", Status: clienttest.StatusFailed},
+		{
+			Client:        "outlook16",
+			Name:          "Outlook 2016",
+			Status:        clienttest.StatusComplete,
+			ScreenshotURL: "https://example.com/o.png",
+			ThumbnailURL:  "https://example.com/t.png",
+		},
+	}, test.Results)
+	assert.Len(t, test.Failed(), 1)
+}
+
+func TestEmailOnAcid_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": {"name": "AccessDenied"}}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	provider := clienttest.NewEmailOnAcid("key", "wrong", clienttest.WithBaseURL(server.URL))
+	_, err := provider.Submit(context.Background(), clienttest.Email{HTML: "<p>Hi</p>"})
+
+	var apiErr *clienttest.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.Contains(t, apiErr.Message, "AccessDenied")
+}
+
+func TestLitmus(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /emails", func(w http.ResponseWriter, r *http.Request) {
+		user, _, _ := r.BasicAuth()
+		assert.Equal(t, "key", user)
+
+		var req map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "<p>Hi</p>", req["html_text"])
+
+		_, _ = w.Write([]byte(`{"email_guid": "guid-1"}`))
+	})
+	mux.HandleFunc("GET /emails/guid-1/previews/OL2019", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"full_url": "https://example.com/full.png", "thumbnail_url": "https://example.com/thumb.png"}`))
+	})
+	mux.HandleFunc("GET /emails/guid-1/previews/BOGUS", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unknown client", http.StatusNotFound)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	provider := clienttest.NewLitmus("key", clienttest.WithBaseURL(server.URL))
+
+	_, err := provider.Submit(context.Background(), clienttest.Email{HTML: "<p>Hi</p>"})
+	assert.EqualError(t, err, "litmus: no clients to test")
+
+	test, err := clienttest.Run(context.Background(), provider, clienttest.Email{
+		Subject: "Welcome",
+		HTML:    "<p>Hi</p>",
+		Clients: []string{"OL2019", "BOGUS"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "guid-1", test.ID)
+	assert.Equal(t, []clienttest.Result{
+		{
+			Client:        "OL2019",
+			Status:        clienttest.StatusComplete,
+			ScreenshotURL: "https://example.com/full.png",
+			ThumbnailURL:  "https://example.com/thumb.png",
+		},
+		{Client: "BOGUS", Status: clienttest.StatusFailed},
+	}, test.Results)
+
+	_, err = provider.Results(context.Background(), "unknown")
+	assert.Error(t, err)
+}
+
+// pendingProvider never finishes
+type pendingProvider struct{}
+
+func (pendingProvider) Submit(ctx context.Context, email clienttest.Email) (string, error) {
+	return "id", nil
+}
+
+func (pendingProvider) Results(ctx context.Context, id string) (*clienttest.Test, error) {
+	return &clienttest.Test{ID: id, Results: []clienttest.Result{{Client: "c", Status: clienttest.StatusPending}}}, nil
+}
+
+func TestWait_ContextDone(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	test, err := clienttest.Wait(ctx, pendingProvider{}, "id", time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	require.NotNil(t, test)
+	assert.False(t, test.Complete())
+}
+
+func TestSaveScreenshots(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("png:" + r.URL.Path))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	paths, err := clienttest.SaveScreenshots(context.Background(), nil, &clienttest.Test{Results: []clienttest.Result{
+		{Client: "outlook16", Status: clienttest.StatusComplete, ScreenshotURL: server.URL + "/o.png"},
+		{Client: "gmailw10", Status: clienttest.StatusFailed},
+	}}, dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "outlook16.png")}, paths)
+
+	data, err := os.ReadFile(paths[0])
+	require.NoError(t, err)
+	assert.Equal(t, "png:/o.png", string(data))
+}
@@ -0,0 +1,95 @@
+package clienttest
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+// EmailOnAcidURL is the base URL of the Email on Acid v5 API
+const EmailOnAcidURL = "https://api.emailonacid.com/v5"
+
+// EmailOnAcid is a Provider for the Email on Acid API
+type EmailOnAcid struct {
+	apiKey   string
+	password string
+	config   config
+}
+
+// NewEmailOnAcid creates an Email on Acid provider with an API key and account password. Emails without
+// clients are tested in the account's default clients.
+func NewEmailOnAcid(apiKey, password string, opts ...Option) *EmailOnAcid {
+	return &EmailOnAcid{
+		apiKey:   apiKey,
+		password: password,
+		config:   newConfig(EmailOnAcidURL, opts),
+	}
+}
+
+// Submit starts an email test
+func (e *EmailOnAcid) Submit(ctx context.Context, email Email) (string, error) {
+	req := struct {
+		Subject string   `json:"subject"`
+		HTML    string   `json:"html"`
+		Clients []string `json:"clients,omitempty"`
+	}{email.Subject, email.HTML, email.Clients}
+
+	var resp struct {
+		ID string `json:"id"`
+	}
+	if err := e.do(ctx, http.MethodPost, "/email/tests", req, &resp); err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+// Results returns the results of an email test. Clients still processing are pending and bounced clients
+// have failed.
+func (e *EmailOnAcid) Results(ctx context.Context, id string) (*Test, error) {
+	var status struct {
+		Completed  []string `json:"completed"`
+		Processing []string `json:"processing"`
+		Bounced    []string `json:"bounced"`
+	}
+	if err := e.do(ctx, http.MethodGet, "/email/tests/"+url.PathEscape(id), nil, &status); err != nil {
+		return nil, err
+	}
+
+	var results map[string]struct {
+		DisplayName string            `json:"display_name"`
+		Screenshots map[string]string `json:"screenshots"`
+		Thumbnail   string            `json:"thumbnail"`
+	}
+	if len(status.Completed) > 0 {
+		if err := e.do(ctx, http.MethodGet, "/email/tests/"+url.PathEscape(id)+"/results", nil, &results); err != nil {
+			return nil, err
+		}
+	}
+
+	test := &Test{ID: id}
+	for _, client := range status.Completed {
+		r := results[client]
+		test.Results = append(test.Results, Result{
+			Client:        client,
+			Name:          r.DisplayName,
+			Status:        StatusComplete,
+			ScreenshotURL: r.Screenshots["default"],
+			ThumbnailURL:  r.Thumbnail,
+		})
+	}
+	for _, client := range status.Processing {
+		test.Results = append(test.Results, Result{Client: client, Status: StatusPending})
+	}
+	for _, client := range status.Bounced {
+		test.Results = append(test.Results, Result{Client: client, Status: StatusFailed})
+	}
+
+	sort.Slice(test.Results, func(i, j int) bool { return test.Results[i].Client < test.Results[j].Client })
+	return test, nil
+}
+
+// do sends an authenticated API request
+func (e *EmailOnAcid) do(ctx context.Context, method, path string, in, out any) error {
+	return e.config.do(ctx, "email on acid", method, path, e.apiKey, e.password, in, out)
+}
@@ -0,0 +1,98 @@
+package clienttest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// LitmusURL is the base URL of the Litmus Instant API
+const LitmusURL = "https://instant-api.litmus.com/v1"
+
+// Litmus is a Provider for the Litmus Instant API. Litmus captures each client on request, so the clients of
+// a test are remembered by the Litmus value that submitted it.
+type Litmus struct {
+	apiKey string
+	config config
+
+	mu      sync.Mutex
+	clients map[string][]string
+}
+
+// NewLitmus creates a Litmus provider with an Instant API key
+func NewLitmus(apiKey string, opts ...Option) *Litmus {
+	return &Litmus{
+		apiKey:  apiKey,
+		config:  newConfig(LitmusURL, opts),
+		clients: make(map[string][]string),
+	}
+}
+
+// Submit uploads the email. Litmus tests require at least one client.
+func (l *Litmus) Submit(ctx context.Context, email Email) (string, error) {
+	if len(email.Clients) == 0 {
+		return "", errors.New("litmus: no clients to test")
+	}
+
+	req := struct {
+		Subject  string `json:"subject"`
+		HTMLText string `json:"html_text"`
+	}{email.Subject, email.HTML}
+
+	var resp struct {
+		EmailGUID string `json:"email_guid"`
+	}
+	if err := l.do(ctx, http.MethodPost, "/emails", req, &resp); err != nil {
+		return "", err
+	}
+
+	l.mu.Lock()
+	l.clients[resp.EmailGUID] = append([]string(nil), email.Clients...)
+	l.mu.Unlock()
+
+	return resp.EmailGUID, nil
+}
+
+// Results requests the preview of each client of the test. Clients whose preview fails have failed.
+func (l *Litmus) Results(ctx context.Context, id string) (*Test, error) {
+	l.mu.Lock()
+	clients, ok := l.clients[id]
+	l.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("litmus: unknown test %q", id)
+	}
+
+	test := &Test{ID: id}
+	for _, client := range clients {
+		var resp struct {
+			FullURL      string `json:"full_url"`
+			ThumbnailURL string `json:"thumbnail_url"`
+		}
+		path := "/emails/" + url.PathEscape(id) + "/previews/" + url.PathEscape(client)
+		if err := l.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				return nil, err
+			}
+			test.Results = append(test.Results, Result{Client: client, Status: StatusFailed})
+			continue
+		}
+
+		test.Results = append(test.Results, Result{
+			Client:        client,
+			Status:        StatusComplete,
+			ScreenshotURL: resp.FullURL,
+			ThumbnailURL:  resp.ThumbnailURL,
+		})
+	}
+
+	return test, nil
+}
+
+// do sends an authenticated API request
+func (l *Litmus) do(ctx context.Context, method, path string, in, out any) error {
+	return l.config.do(ctx, "litmus", method, path, l.apiKey, "", in, out)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/patrickward/mailpen"
+)
+
+// loadData reads template data from a JSON or YAML file, chosen by extension. An empty path returns nil.
+func loadData(path string) (map[string]any, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data file: %w", err)
+	}
+
+	data := map[string]any{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		err = json.Unmarshal(content, &data)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &data)
+	default:
+		return nil, fmt.Errorf("unsupported data file extension %q (want .json, .yaml, or .yml)", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse data file %s: %w", path, err)
+	}
+
+	return data, nil
+}
+
+// loadDataOrSample reads the data file, or the email's sample data file in the templates (see
+// Manager.SampleData) when no data file is given
+func loadDataOrSample(path string, manager *mailpen.Manager, email string) (map[string]any, error) {
+	if path != "" {
+		return loadData(path)
+	}
+	return manager.SampleData(email)
+}
+
+// dataExtensions are the data file extensions, in lookup order
+var dataExtensions = []string{".json", ".yaml", ".yml"}
+
+// findData reads the sample data for an email from dir/<email>.json, .yaml, or .yml. It returns nil when dir
+// is empty or holds no data file for the email.
+func findData(dir, email string) (map[string]any, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	for _, ext := range dataExtensions {
+		path := filepath.Join(dir, filepath.FromSlash(email)+ext)
+		if _, err := os.Stat(path); err == nil {
+			return loadData(path)
+		}
+	}
+
+	return nil, nil
+}
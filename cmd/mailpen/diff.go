@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/patrickward/mailpen/diff"
+)
+
+// runDiff renders every email from two versions of the templates and prints how the output differs
+func runDiff(args []string, stdout, stderr io.Writer) error {
+	var templates, old, rev, theme, layout, data, htmlPath string
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&templates, "templates", ".", "templates `directory` with the new version")
+	flags.StringVar(&old, "old", "", "templates `directory` with the old version")
+	flags.StringVar(&rev, "rev", "", "git `revision` of the templates directory used as the old version (defaults to HEAD without -old)")
+	flags.StringVar(&theme, "theme", "", "JSON theme `file` merged over the default theme")
+	flags.StringVar(&layout, "layout", "", "layout to render into (defaults to base)")
+	flags.StringVar(&data, "data", "", "`directory` of sample data files named <email>.json, .yaml, or .yml, used before the templates' own sample data")
+	flags.StringVar(&htmlPath, "html", "", "also write an HTML report to `file`")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: mailpen diff [flags] [email...]")
+		fmt.Fprintln(stderr)
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if old != "" && rev != "" {
+		flags.Usage()
+		return errors.New("diff: -old and -rev cannot be used together")
+	}
+
+	ctx := context.Background()
+
+	var oldFS fs.FS
+	if old != "" {
+		oldFS = os.DirFS(old)
+	} else {
+		if rev == "" {
+			rev = "HEAD"
+		}
+		var err error
+		if oldFS, err = diff.GitFS(ctx, templates, rev); err != nil {
+			return fmt.Errorf("diff: %w", err)
+		}
+	}
+
+	oldManager, err := newManagerFS(oldFS, theme, false)
+	if err != nil {
+		return fmt.Errorf("diff: old templates: %w", err)
+	}
+	newManager, err := newManager(templates, theme, false)
+	if err != nil {
+		return fmt.Errorf("diff: new templates: %w", err)
+	}
+
+	report, err := diff.Compare(ctx, oldManager, newManager,
+		diff.WithEmails(flags.Args()...),
+		diff.WithLayout(layout),
+		diff.WithSampleData(func(_ context.Context, email string) (map[string]any, error) {
+			found, err := findData(data, email)
+			if err != nil || found != nil {
+				return found, err
+			}
+			if found, err = newManager.SampleData(email); err != nil || found != nil {
+				return found, err
+			}
+			return oldManager.SampleData(email)
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("diff: %w", err)
+	}
+
+	if err := report.WriteText(stdout); err != nil {
+		return fmt.Errorf("diff: %w", err)
+	}
+	if htmlPath != "" {
+		file, err := os.Create(htmlPath)
+		if err != nil {
+			return fmt.Errorf("diff: %w", err)
+		}
+		defer file.Close()
+		if err := report.WriteHTML(file, "Email template changes"); err != nil {
+			return fmt.Errorf("diff: %w", err)
+		}
+	}
+
+	fmt.Fprintf(stderr, "%d of %d email(s) changed\n", len(report.Changed()), len(report.Emails))
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// greetingTemplates writes a templates directory whose welcome email opens with greeting
+func greetingTemplates(t *testing.T, greeting string) string {
+	return writeTemplates(t, map[string]string{
+		"layouts/base.html":   `<html><body>{{template "content" .}}</body></html>`,
+		"layouts/base.txt":    `{{template "content" .}}`,
+		"emails/welcome.html": `{{define "content"}}` + "\n<p>" + greeting + `, {{.Name}}!</p>` + "\n{{end}}",
+		"emails/welcome.txt":  `{{define "content"}}` + greeting + `, {{.Name}}!{{end}}`,
+	})
+}
+
+func TestDiff(t *testing.T) {
+	old, new := greetingTemplates(t, "Hello"), greetingTemplates(t, "Welcome")
+	dataDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "welcome.json"), []byte(`{"Name": "Ada"}`), 0o644))
+	htmlPath := filepath.Join(t.TempDir(), "diff.html")
+
+	var stdout, stderr bytes.Buffer
+	require.NoError(t, run([]string{"diff", "-old", old, "-templates", new, "-data", dataDir, "-html", htmlPath, "welcome"}, &stdout, &stderr))
+
+	assert.Contains(t, stdout.String(), "-<p>Hello, Ada!</p>\n+<p>Welcome, Ada!</p>\n")
+	assert.Contains(t, stdout.String(), "-Hello, Ada!\n+Welcome, Ada!\n")
+	assert.Equal(t, "1 of 1 email(s) changed\n", stderr.String())
+
+	report, err := os.ReadFile(htmlPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(report), "<title>Email template changes</title>")
+}
+
+func TestDiff_Errors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.ErrorContains(t, run([]string{"diff", "-old", "a", "-rev", "HEAD"}, &stdout, &stderr), "-old and -rev cannot be used together")
+	assert.ErrorContains(t, run([]string{"diff", "-templates", t.TempDir(), "-rev", "HEAD"}, &stdout, &stderr), "git")
+}
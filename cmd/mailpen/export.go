@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/patrickward/mailpen/export"
+)
+
+// runExport renders every email × layout × locale combination into a static HTML site
+func runExport(args []string, stdout, stderr io.Writer) error {
+	var templates, theme, outDir, data, layouts, locales, title string
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&templates, "templates", ".", "templates `directory` containing emails, layouts, and partials")
+	fs.StringVar(&theme, "theme", "", "JSON theme `file` merged over the default theme")
+	fs.StringVar(&outDir, "o", "site", "write the site to `directory`")
+	fs.StringVar(&data, "data", "", "`directory` of sample data files named <email>.json, .yaml, or .yml, used before the templates' own sample data")
+	fs.StringVar(&layouts, "layouts", "", "comma-separated `layouts` to render each email into (defaults to every layout)")
+	fs.StringVar(&locales, "locales", "", "comma-separated `locales` to render each email in")
+	fs.StringVar(&title, "title", "Email previews", "`title` of the index page")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: mailpen export [flags]")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errors.New("export: unexpected arguments")
+	}
+
+	manager, err := newManager(templates, theme, false)
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+
+	report, err := export.Site(context.Background(), manager, outDir,
+		export.WithLayouts(splitList(layouts)...),
+		export.WithLocales(splitList(locales)...),
+		export.WithTitle(title),
+		export.WithSampleData(func(_ context.Context, email string) (map[string]any, error) {
+			found, err := findData(data, email)
+			if err != nil || found != nil {
+				return found, err
+			}
+			return manager.SampleData(email)
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+
+	failed := report.Failed()
+	for _, page := range failed {
+		fmt.Fprintf(stderr, "%s (%s): %v\n", page.Email, page.Layout, page.Err)
+	}
+	fmt.Fprintf(stdout, "Exported %d page(s) to %s\n", len(report.Pages)-len(failed), filepath.Join(outDir, "index.html"))
+
+	if len(failed) > 0 {
+		return fmt.Errorf("export: %d page(s) failed to render", len(failed))
+	}
+	return nil
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExport(t *testing.T) {
+	templates := writeTemplates(t, map[string]string{
+		"layouts/base.html":   `<html><body>{{template "content" .}}</body></html>`,
+		"layouts/base.txt":    `{{template "content" .}}`,
+		"emails/welcome.html": `{{define "content"}}<p>Welcome, {{.Name}}!</p>{{end}}`,
+		"emails/welcome.txt":  `{{define "content"}}Welcome, {{.Name}}!{{end}}`,
+		"emails/broken.html":  `{{define "content"}}<p>{{.Link.Missing}}</p>{{end}}`,
+		"emails/broken.txt":   `{{define "content"}}Broken{{end}}`,
+	})
+
+	dataDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "welcome.yaml"), []byte("Name: Ada\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "broken.json"), []byte(`{"Link": "https://example.com"}`), 0o644))
+
+	out := filepath.Join(t.TempDir(), "site")
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"export", "-templates", templates, "-data", dataDir, "-locales", "en, fr", "-title", "Acme", "-o", out}, &stdout, &stderr)
+	assert.EqualError(t, err, "export: 2 page(s) failed to render")
+	assert.Contains(t, stdout.String(), "Exported 2 page(s)")
+	assert.Contains(t, stderr.String(), "broken (base):")
+
+	html, err := os.ReadFile(filepath.Join(out, "emails", "welcome", "base.fr.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "Welcome, Ada!")
+
+	index, err := os.ReadFile(filepath.Join(out, "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(index), "<title>Acme</title>")
+}
+
+func TestExport_Errors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	assert.ErrorContains(t, run([]string{"export", "extra"}, &stdout, &stderr), "unexpected arguments")
+}
+
+func TestSplitList(t *testing.T) {
+	assert.Equal(t, []string{"en", "fr"}, splitList(" en, ,fr,"))
+	assert.Nil(t, splitList(""))
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/processors/htmlvalidate"
+)
+
+// runLint checks the templates and fails when any issue is found
+func runLint(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	templates := fs.String("templates", ".", "templates `directory` containing emails, layouts, and partials")
+	allowMissingText := fs.Bool("allow-missing-text", false, "do not report emails without a text version")
+	validateHTML := fs.Bool("html", false, "also render each email with its sample data and check the HTML for unclosed tags, duplicate IDs, and images without dimensions")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: mailpen lint [flags]")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errors.New("lint: unexpected arguments")
+	}
+
+	// Layouts, partials, and components that do not parse fail here
+	manager, err := newManager(*templates, "", false)
+	if err != nil {
+		return fmt.Errorf("lint: %w", err)
+	}
+
+	issues, err := manager.Lint()
+	if err != nil {
+		return fmt.Errorf("lint: %w", err)
+	}
+
+	count := 0
+	for _, issue := range issues {
+		if *allowMissingText && issue.Kind == mailpen.LintMissingText {
+			continue
+		}
+		fmt.Fprintf(stdout, "%s [%s]\n", issue, issue.Kind)
+		count++
+	}
+
+	if *validateHTML {
+		n, err := lintHTML(manager, stdout)
+		if err != nil {
+			return fmt.Errorf("lint: %w", err)
+		}
+		count += n
+	}
+
+	if count > 0 {
+		return fmt.Errorf("lint: %d issue(s) found", count)
+	}
+
+	return nil
+}
+
+// lintHTML renders every email with its sample data, prints the problems found in the rendered HTML, and
+// returns how many it printed
+func lintHTML(manager *mailpen.Manager, stdout io.Writer) (int, error) {
+	emails, err := manager.Emails()
+	if err != nil {
+		return 0, err
+	}
+
+	validator := htmlvalidate.New()
+	count := 0
+	for _, name := range emails {
+		filename := "emails/" + name + ".html"
+
+		data, err := manager.SampleData(name)
+		if err != nil {
+			continue // Reported by Manager.Lint as invalid-sample
+		}
+
+		email, err := manager.RenderEmail(name, data, "")
+		if err != nil {
+			fmt.Fprintf(stdout, "%s: failed to render: %v [render-error]\n", filename, err)
+			count++
+			continue
+		}
+
+		issues, err := validator.Validate(email.HTML)
+		if err != nil {
+			return 0, err
+		}
+		for _, issue := range issues {
+			fmt.Fprintf(stdout, "%s: rendered %s [%s]\n", filename, issue, issue.Kind)
+			count++
+		}
+	}
+
+	return count, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTemplates writes template files into a new directory and returns its path
+func writeTemplates(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		args       []string
+		wantErr    string
+		wantOutput []string
+	}{
+		{
+			name: "clean",
+			files: map[string]string{
+				"emails/welcome.html": `{{define "content"}}{{template "@p" "Hi"}}{{end}}`,
+				"emails/welcome.txt":  `{{define "content"}}Hi{{end}}`,
+			},
+		},
+		{
+			name: "issues",
+			files: map[string]string{
+				"emails/welcome.html": `{{define "content"}}{{template "@missing" .}}{{end}}`,
+				"emails/receipt.html": `{{define "content"}}{{if .Paid}}{{end}}`,
+				"emails/receipt.txt":  `{{define "content"}}Paid{{end}}`,
+			},
+			wantErr: "lint: 3 issue(s) found",
+			wantOutput: []string{
+				"emails/receipt.html: ",
+				"[parse-error]",
+				`emails/welcome.html: line 1: template "@missing" is not defined [unknown-template]`,
+				"emails/welcome.txt: no text version [missing-text]",
+			},
+		},
+		{
+			name: "allow missing text",
+			files: map[string]string{
+				"emails/welcome.html": `{{define "content"}}Hi{{end}}`,
+			},
+			args: []string{"-allow-missing-text"},
+		},
+		{
+			name: "rendered html",
+			files: map[string]string{
+				"layouts/base.html":          `<html><body>{{template "content" .}}</body></html>`,
+				"emails/welcome.html":        `{{define "content"}}<div id="a"><img src="{{.Logo}}" alt=""></div><p id="a">{{end}}`,
+				"emails/welcome.txt":         `{{define "content"}}Hi{{end}}`,
+				"emails/welcome.sample.json": `{"Logo": "https://example.com/logo.png"}`,
+				"emails/broken.html":         `{{define "content"}}{{len 3}}{{end}}`,
+				"emails/broken.txt":          `{{define "content"}}Hi{{end}}`,
+			},
+			args:    []string{"-html"},
+			wantErr: "lint: 5 issue(s) found",
+			wantOutput: []string{
+				"emails/broken.html: failed to render: ",
+				`emails/welcome.html: rendered line 1: <img src="https://example.com/logo.png"> is missing a width attribute [image-dimensions]`,
+				`emails/welcome.html: rendered line 1: id "a" is already used on line 1 [duplicate-id]`,
+				"emails/welcome.html: rendered line 1: <p> is not closed before </body> on line 1 [unclosed-tag]",
+			},
+		},
+		{
+			name: "base template parse error",
+			files: map[string]string{
+				"partials/header.html": `{{define "site-header"}}{{end`,
+			},
+			wantErr: "lint: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeTemplates(t, tt.files)
+			args := append([]string{"lint", "-templates", dir}, tt.args...)
+
+			var stdout, stderr bytes.Buffer
+			err := run(args, &stdout, &stderr)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				assert.Empty(t, stdout.String())
+				return
+			}
+
+			assert.ErrorContains(t, err, tt.wantErr)
+			for _, want := range tt.wantOutput {
+				assert.Contains(t, stdout.String(), want)
+			}
+		})
+	}
+}
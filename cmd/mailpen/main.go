@@ -0,0 +1,86 @@
+// Command mailpen works with mailpen email templates from the command line.
+//
+// Usage:
+//
+//	mailpen <command> [flags]
+//
+// The commands are:
+//
+//	diff        show how the rendered output of each email changed between two template versions
+//	export      render every email, layout, and locale into a static preview site
+//	lint        check templates for parse errors, unknown templates, and missing versions
+//	preview     serve a live-reloading preview of every email
+//	render      render an email template to HTML and text
+//	screenshot  capture PNG screenshots of an email at several widths
+//	send        render an email template and deliver it to a test inbox over SMTP
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// command is a mailpen subcommand
+type command struct {
+	summary string
+	run     func(args []string, stdout, stderr io.Writer) error
+}
+
+// commands are the subcommands by name
+var commands = map[string]command{
+	"diff":       {summary: "show how the rendered output of each email changed between two template versions", run: runDiff},
+	"export":     {summary: "render every email, layout, and locale into a static preview site", run: runExport},
+	"lint":       {summary: "check templates for parse errors, unknown templates, and missing versions", run: runLint},
+	"preview":    {summary: "serve a live-reloading preview of every email", run: runPreview},
+	"render":     {summary: "render an email template to HTML and text", run: runRender},
+	"screenshot": {summary: "capture PNG screenshots of an email at several widths", run: runScreenshot},
+	"send":       {summary: "render an email template and deliver it to a test inbox over SMTP", run: runSend},
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "mailpen:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+// run runs the subcommand named by the first argument
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		usage(stderr)
+		if len(args) == 0 {
+			return errors.New("no command given")
+		}
+		return flag.ErrHelp
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		usage(stderr)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+
+	return cmd.run(args[1:], stdout, stderr)
+}
+
+// usage prints the list of commands
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Usage: mailpen <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-12s %s\n", name, commands[name].summary)
+	}
+}
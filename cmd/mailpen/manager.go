@@ -0,0 +1,27 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/patrickward/mailpen"
+)
+
+// newManager creates a manager for a templates directory and an optional JSON theme file
+func newManager(templatesDir, themeFile string, devMode bool) (*mailpen.Manager, error) {
+	return newManagerFS(os.DirFS(templatesDir), themeFile, devMode)
+}
+
+// newManagerFS creates a manager for a templates file system and an optional JSON theme file
+func newManagerFS(templates fs.FS, themeFile string, devMode bool) (*mailpen.Manager, error) {
+	config := &mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{{Name: "templates", FS: templates}},
+		DevMode: devMode,
+	}
+	if themeFile != "" {
+		config.ThemeFile = &mailpen.ThemeFile{FS: os.DirFS(filepath.Dir(themeFile)), Path: filepath.Base(themeFile)}
+	}
+
+	return mailpen.NewManager(config)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/preview"
+)
+
+// previewFlags are the flags of the preview command
+type previewFlags struct {
+	addr      string
+	templates string
+	data      string
+	theme     string
+	layout    string
+	interval  time.Duration
+}
+
+// runPreview serves a web UI that previews every email and reloads when the templates change
+func runPreview(args []string, stdout, stderr io.Writer) error {
+	var f previewFlags
+	fs := flag.NewFlagSet("preview", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&f.addr, "addr", "localhost:4000", "`address` to listen on")
+	fs.StringVar(&f.templates, "templates", ".", "templates `directory` containing emails, layouts, and partials")
+	fs.StringVar(&f.data, "data", "", "`directory` of sample data files named <email>.json, .yaml, or .yml, used before the templates' own sample data")
+	fs.StringVar(&f.theme, "theme", "", "JSON theme `file` merged over the default theme")
+	fs.StringVar(&f.layout, "layout", "", "layout to render into (defaults to base)")
+	fs.DurationVar(&f.interval, "interval", 500*time.Millisecond, "how often to check the templates for changes")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: mailpen preview [flags]")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errors.New("preview: unexpected arguments")
+	}
+
+	server, err := newPreviewServer(f)
+	if err != nil {
+		return fmt.Errorf("preview: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	go server.watch(ctx, f.interval)
+
+	srv := &http.Server{Addr: f.addr, Handler: server, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	fmt.Fprintf(stdout, "Previewing %s at http://%s\n", f.templates, f.addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("preview: %w", err)
+	}
+
+	return nil
+}
+
+// previewServer renders emails for the preview UI and notifies browsers of template changes
+type previewServer struct {
+	manager *mailpen.Manager
+	flags   previewFlags
+	mux     *http.ServeMux
+
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+}
+
+// newPreviewServer creates a preview server for the templates directory
+func newPreviewServer(f previewFlags) (*previewServer, error) {
+	manager, err := newManager(f.templates, f.theme, true)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &previewServer{
+		manager: manager,
+		flags:   f,
+		mux:     http.NewServeMux(),
+		clients: make(map[chan struct{}]struct{}),
+	}
+	s.mux.Handle("/", preview.Handler(manager,
+		preview.WithLayout(f.layout),
+		preview.WithHead(liveReloadScript),
+		preview.WithSampleData(func(_ context.Context, email string) (map[string]any, error) {
+			data, err := findData(f.data, email)
+			if data != nil || err != nil {
+				return data, err
+			}
+			return manager.SampleData(email)
+		}),
+	))
+	s.mux.HandleFunc("GET /events", s.handleEvents)
+
+	return s, nil
+}
+
+// liveReloadScript reloads the preview UI when the server reports a change
+const liveReloadScript template.HTML = `<script>new EventSource("/events").onmessage = function () { location.reload(); };</script>`
+
+// ServeHTTP implements http.Handler
+func (s *previewServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleEvents streams a server-sent "reload" event whenever the templates change
+func (s *previewServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	changes := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.clients[changes] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, changes)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changes:
+			if _, err := io.WriteString(w, "data: reload\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// notify tells every connected browser to reload
+func (s *previewServer) notify() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for client := range s.clients {
+		select {
+		case client <- struct{}{}:
+		default: // A reload is already pending
+		}
+	}
+}
+
+// watch polls the templates, data, and theme for changes until the context is done
+func (s *previewServer) watch(ctx context.Context, interval time.Duration) {
+	paths := []string{s.flags.templates, s.flags.data, s.flags.theme}
+	last := snapshot(paths)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if current := snapshot(paths); current != last {
+				last = current
+				s.notify()
+			}
+		}
+	}
+}
+
+// snapshot summarizes the names, sizes, and modification times of the files under the given paths
+func snapshot(paths []string) string {
+	var b strings.Builder
+	for _, root := range paths {
+		if root == "" {
+			continue
+		}
+		_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			fmt.Fprintf(&b, "%s|%d|%d\n", path, info.Size(), info.ModTime().UnixNano())
+			return nil
+		})
+	}
+	return b.String()
+}
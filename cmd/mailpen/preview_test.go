@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPreviewServer(t *testing.T) (*previewServer, string) {
+	t.Helper()
+
+	dataDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "welcome.yaml"), []byte("Name: Ada\nCompanyName: Acme\n"), 0o644))
+
+	server, err := newPreviewServer(previewFlags{templates: templatesDir, data: dataDir})
+	require.NoError(t, err)
+
+	return server, dataDir
+}
+
+func get(t *testing.T, handler http.Handler, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
+
+func TestPreview_Index(t *testing.T) {
+	server, _ := newTestPreviewServer(t)
+
+	t.Run("lists emails and shows the first", func(t *testing.T) {
+		rec := get(t, server, "/")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `>welcome</a>`)
+		assert.Contains(t, rec.Body.String(), `>simple</a>`)
+		assert.Contains(t, rec.Body.String(), `src="render/address-test?format=html"`)
+	})
+
+	t.Run("selected email, text, and mobile width", func(t *testing.T) {
+		rec := get(t, server, "/?email=welcome&view=text&width=mobile")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `src="render/welcome?format=text"`)
+		assert.Contains(t, rec.Body.String(), `class="frame mobile"`)
+		assert.Contains(t, rec.Body.String(), `new EventSource("/events")`)
+		assert.Contains(t, rec.Body.String(), `&#34;Name&#34;: &#34;Ada&#34;`)
+	})
+}
+
+func TestPreview_Render(t *testing.T) {
+	server, _ := newTestPreviewServer(t)
+
+	tests := []struct {
+		name        string
+		target      string
+		wantCode    int
+		wantType    string
+		wantContain string
+	}{
+		{name: "html with sample data", target: "/render/welcome", wantCode: http.StatusOK, wantType: "text/html; charset=utf-8", wantContain: "Welcome, Ada!"},
+		{name: "text", target: "/render/welcome?format=text", wantCode: http.StatusOK, wantType: "text/plain; charset=utf-8", wantContain: "Ada"},
+		{name: "without sample data", target: "/render/simple", wantCode: http.StatusOK, wantType: "text/html; charset=utf-8"},
+		{name: "unknown email renders the error in development mode", target: "/render/missing", wantCode: http.StatusOK, wantType: "text/html; charset=utf-8", wantContain: "template not found: emails/missing.html"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := get(t, server, tt.target)
+			assert.Equal(t, tt.wantCode, rec.Code)
+			if tt.wantType != "" {
+				assert.Equal(t, tt.wantType, rec.Header().Get("Content-Type"))
+			}
+			assert.Contains(t, rec.Body.String(), tt.wantContain)
+		})
+	}
+}
+
+func TestPreview_LiveReload(t *testing.T) {
+	server, dataDir := newTestPreviewServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go server.watch(ctx, 10*time.Millisecond)
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/events", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// Wait for the stream to be registered before changing a file
+	require.Eventually(t, func() bool {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		return len(server.clients) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "simple.json"), []byte(`{}`), 0o644))
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "data: reload\n", line)
+}
+
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	before := snapshot([]string{dir, ""})
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.html"), []byte("a"), 0o644))
+	after := snapshot([]string{dir, ""})
+	assert.NotEqual(t, before, after)
+	assert.Equal(t, after, snapshot([]string{dir}))
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/patrickward/mailpen"
+)
+
+// renderFlags are the flags of the render command
+type renderFlags struct {
+	templates string
+	layout    string
+	data      string
+	theme     string
+	htmlOut   string
+	textOut   string
+	outDir    string
+	format    string
+}
+
+// runRender renders an email template and writes the HTML and text to files or stdout
+func runRender(args []string, stdout, stderr io.Writer) error {
+	var f renderFlags
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&f.templates, "templates", ".", "templates `directory` containing emails, layouts, and partials")
+	fs.StringVar(&f.layout, "layout", "", "layout to render into (defaults to base)")
+	fs.StringVar(&f.data, "data", "", "JSON or YAML `file` with the template data (defaults to the email's sample data)")
+	fs.StringVar(&f.theme, "theme", "", "JSON theme `file` merged over the default theme")
+	fs.StringVar(&f.htmlOut, "html", "", "write the HTML to `file`")
+	fs.StringVar(&f.textOut, "text", "", "write the text to `file`")
+	fs.StringVar(&f.outDir, "o", "", "write <email>.html and <email>.txt to `directory`")
+	fs.StringVar(&f.format, "format", "html", "format written to stdout when no output file is given: html or text")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: mailpen render [flags] <email>")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("render: expected exactly one email name")
+	}
+	if f.format != "html" && f.format != "text" {
+		return fmt.Errorf("render: unknown format %q", f.format)
+	}
+
+	name := fs.Arg(0)
+	email, err := renderEmail(f, name)
+	if err != nil {
+		return fmt.Errorf("render: %w", err)
+	}
+
+	for _, warning := range email.Warnings {
+		fmt.Fprintln(stderr, "warning:", warning)
+	}
+
+	if f.outDir != "" {
+		if err := os.MkdirAll(f.outDir, 0o755); err != nil {
+			return fmt.Errorf("render: %w", err)
+		}
+		if f.htmlOut == "" {
+			f.htmlOut = filepath.Join(f.outDir, filepath.Base(name)+".html")
+		}
+		if f.textOut == "" {
+			f.textOut = filepath.Join(f.outDir, filepath.Base(name)+".txt")
+		}
+	}
+
+	if f.htmlOut == "" && f.textOut == "" {
+		out := email.HTML
+		if f.format == "text" {
+			out = email.Text
+		}
+		_, err := io.WriteString(stdout, out)
+		return err
+	}
+
+	if f.htmlOut != "" {
+		if err := os.WriteFile(f.htmlOut, []byte(email.HTML), 0o644); err != nil {
+			return fmt.Errorf("render: %w", err)
+		}
+	}
+	if f.textOut != "" {
+		if err := os.WriteFile(f.textOut, []byte(email.Text), 0o644); err != nil {
+			return fmt.Errorf("render: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// renderEmail creates a manager for the templates directory and renders the email
+func renderEmail(f renderFlags, name string) (*mailpen.RenderedEmail, error) {
+	manager, err := newManager(f.templates, f.theme, false)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := loadDataOrSample(f.data, manager, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return manager.Render(context.Background(), name, data, mailpen.RenderOptions{Layout: f.layout})
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const templatesDir = "../../testdata/base"
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestRender(t *testing.T) {
+	jsonData := writeFile(t, "data.json", `{"Name": "Ada", "CompanyName": "Acme"}`)
+	yamlData := writeFile(t, "data.yaml", "Name: Grace\nCompanyName: Initech\n")
+
+	tests := []struct {
+		name     string
+		args     []string
+		contains []string
+	}{
+		{
+			name:     "html to stdout with JSON data",
+			args:     []string{"render", "-templates", templatesDir, "-data", jsonData, "welcome"},
+			contains: []string{"<h1>Welcome, Ada!</h1>", "<title>Welcome to Acme</title>"},
+		},
+		{
+			name:     "text to stdout with YAML data",
+			args:     []string{"render", "-templates", templatesDir, "-data", yamlData, "-format", "text", "welcome"},
+			contains: []string{"Grace"},
+		},
+		{
+			name:     "sample data from the templates",
+			args:     []string{"render", "-templates", templatesDir, "welcome"},
+			contains: []string{"<h1>Welcome, Sam!</h1>", "<title>Welcome to Sample Co</title>"},
+		},
+		{
+			name:     "layout",
+			args:     []string{"render", "-templates", templatesDir, "-layout", "marketing", "-data", jsonData, "welcome"},
+			contains: []string{"Welcome, Ada!"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			require.NoError(t, run(tt.args, &stdout, &stderr))
+			for _, want := range tt.contains {
+				assert.Contains(t, stdout.String(), want)
+			}
+		})
+	}
+}
+
+func TestRender_OutputFiles(t *testing.T) {
+	data := writeFile(t, "data.json", `{"Name": "Ada", "CompanyName": "Acme"}`)
+
+	t.Run("directory", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "out")
+		var stdout, stderr bytes.Buffer
+		require.NoError(t, run([]string{"render", "-templates", templatesDir, "-data", data, "-o", dir, "welcome"}, &stdout, &stderr))
+		assert.Empty(t, stdout.String())
+
+		html, err := os.ReadFile(filepath.Join(dir, "welcome.html"))
+		require.NoError(t, err)
+		assert.Contains(t, string(html), "Welcome, Ada!")
+
+		text, err := os.ReadFile(filepath.Join(dir, "welcome.txt"))
+		require.NoError(t, err)
+		assert.Contains(t, string(text), "Ada")
+	})
+
+	t.Run("html file only", func(t *testing.T) {
+		htmlPath := filepath.Join(t.TempDir(), "email.html")
+		var stdout, stderr bytes.Buffer
+		require.NoError(t, run([]string{"render", "-templates", templatesDir, "-data", data, "-html", htmlPath, "welcome"}, &stdout, &stderr))
+
+		html, err := os.ReadFile(htmlPath)
+		require.NoError(t, err)
+		assert.Contains(t, string(html), "Welcome, Ada!")
+	})
+}
+
+func TestRender_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "no command", args: nil, wantErr: "no command given"},
+		{name: "unknown command", args: []string{"bogus"}, wantErr: `unknown command "bogus"`},
+		{name: "missing email", args: []string{"render", "-templates", templatesDir}, wantErr: "expected exactly one email name"},
+		{name: "unknown format", args: []string{"render", "-format", "pdf", "welcome"}, wantErr: `unknown format "pdf"`},
+		{name: "unknown email", args: []string{"render", "-templates", templatesDir, "missing"}, wantErr: "not found"},
+		{name: "missing data file", args: []string{"render", "-templates", templatesDir, "-data", "missing.json", "welcome"}, wantErr: "failed to read data file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := run(tt.args, &stdout, &stderr)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestLoadData(t *testing.T) {
+	_, err := loadData(writeFile(t, "data.toml", `Name = "Ada"`))
+	assert.ErrorContains(t, err, `unsupported data file extension ".toml"`)
+
+	_, err = loadData(writeFile(t, "data.json", `{`))
+	assert.ErrorContains(t, err, "failed to parse data file")
+
+	data, err := loadData(writeFile(t, "data.yml", "Items:\n  - one\n  - two\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []any{"one", "two"}, data["Items"])
+}
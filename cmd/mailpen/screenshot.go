@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/patrickward/mailpen/screenshot"
+)
+
+// runScreenshot renders an email and writes PNG screenshots of it at each width
+func runScreenshot(args []string, stdout, stderr io.Writer) error {
+	var f renderFlags
+	var widths, browser string
+	fs := flag.NewFlagSet("screenshot", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&f.templates, "templates", ".", "templates `directory` containing emails, layouts, and partials")
+	fs.StringVar(&f.layout, "layout", "", "layout to render into (defaults to base)")
+	fs.StringVar(&f.data, "data", "", "JSON or YAML `file` with the template data (defaults to the email's sample data)")
+	fs.StringVar(&f.theme, "theme", "", "JSON theme `file` merged over the default theme")
+	fs.StringVar(&f.outDir, "o", "screenshots", "write <email>-<width>.png to `directory`")
+	fs.StringVar(&widths, "widths", "375,600,1024", "comma-separated viewport `widths` in pixels")
+	fs.StringVar(&browser, "browser", "", "Chrome or Chromium `executable` (defaults to the first one found)")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: mailpen screenshot [flags] <email>")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("screenshot: expected exactly one email name")
+	}
+
+	sizes, err := parseWidths(widths)
+	if err != nil {
+		return fmt.Errorf("screenshot: %w", err)
+	}
+
+	name := fs.Arg(0)
+	email, err := renderEmail(f, name)
+	if err != nil {
+		return fmt.Errorf("screenshot: %w", err)
+	}
+
+	renderer := screenshot.New(screenshot.WithWidths(sizes...), screenshot.WithExecPath(browser))
+	paths, err := renderer.WriteFiles(context.Background(), email.HTML, f.outDir, filepath.Base(name))
+	if err != nil {
+		return fmt.Errorf("screenshot: %w", err)
+	}
+
+	for _, path := range paths {
+		fmt.Fprintln(stdout, path)
+	}
+	return nil
+}
+
+// parseWidths parses a comma-separated list of positive widths
+func parseWidths(list string) ([]int, error) {
+	var widths []int
+	for _, field := range strings.Split(list, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		width, err := strconv.Atoi(field)
+		if err != nil || width <= 0 {
+			return nil, fmt.Errorf("invalid width %q", field)
+		}
+		widths = append(widths, width)
+	}
+	if len(widths) == 0 {
+		return nil, errors.New("no widths given")
+	}
+	return widths, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWidths(t *testing.T) {
+	widths, err := parseWidths(" 375, 600 ,,1024")
+	require.NoError(t, err)
+	assert.Equal(t, []int{375, 600, 1024}, widths)
+
+	_, err = parseWidths("375,wide")
+	assert.ErrorContains(t, err, `invalid width "wide"`)
+
+	_, err = parseWidths("-5")
+	assert.ErrorContains(t, err, `invalid width "-5"`)
+
+	_, err = parseWidths(" , ")
+	assert.ErrorContains(t, err, "no widths given")
+}
+
+func TestScreenshot_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "missing email", args: []string{"screenshot"}, wantErr: "expected exactly one email name"},
+		{name: "bad widths", args: []string{"screenshot", "-widths", "big", "simple"}, wantErr: `invalid width "big"`},
+		{name: "no browser", args: []string{"screenshot", "-templates", templatesDir, "-browser", filepath.Join(t.TempDir(), "no-chrome"), "simple"}, wantErr: "chrome or chromium not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert.ErrorContains(t, run(tt.args, &stdout, &stderr), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/providers/smtp"
+)
+
+// newSMTPProvider creates the provider used by the send command
+var newSMTPProvider = func(config *smtp.Config) (mailpen.Provider, error) {
+	return smtp.New(config)
+}
+
+// sendFlags are the flags of the send command
+type sendFlags struct {
+	templates string
+	layout    string
+	data      string
+	theme     string
+	to        string
+	from      string
+	subject   string
+	smtp      smtp.Config
+}
+
+// runSend renders an email and delivers it over SMTP to a test inbox
+func runSend(args []string, stdout, stderr io.Writer) error {
+	var f sendFlags
+	fs := flag.NewFlagSet("send", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&f.templates, "templates", ".", "templates `directory` containing emails, layouts, and partials")
+	fs.StringVar(&f.layout, "layout", "", "layout to render into (defaults to base)")
+	fs.StringVar(&f.data, "data", "", "JSON or YAML `file` with the template data (defaults to the email's sample data)")
+	fs.StringVar(&f.theme, "theme", "", "JSON theme `file` merged over the default theme")
+	fs.StringVar(&f.to, "to", os.Getenv("MAILPEN_TO"), "comma-separated recipient `addresses` ($MAILPEN_TO)")
+	fs.StringVar(&f.from, "from", envOr("MAILPEN_FROM", "mailpen@localhost"), "sender `address` ($MAILPEN_FROM)")
+	fs.StringVar(&f.subject, "subject", "", "subject (defaults to \"[mailpen test] <email>\")")
+	fs.StringVar(&f.smtp.Host, "smtp-host", envOr("MAILPEN_SMTP_HOST", "localhost"), "SMTP `host` ($MAILPEN_SMTP_HOST)")
+	fs.IntVar(&f.smtp.Port, "smtp-port", envInt("MAILPEN_SMTP_PORT", 1025), "SMTP `port` ($MAILPEN_SMTP_PORT)")
+	fs.StringVar(&f.smtp.Username, "smtp-username", os.Getenv("MAILPEN_SMTP_USERNAME"), "SMTP `username` ($MAILPEN_SMTP_USERNAME)")
+	fs.StringVar(&f.smtp.Password, "smtp-password", os.Getenv("MAILPEN_SMTP_PASSWORD"), "SMTP `password` ($MAILPEN_SMTP_PASSWORD)")
+	fs.StringVar(&f.smtp.AuthType, "smtp-auth", os.Getenv("MAILPEN_SMTP_AUTH"), "SMTP auth `type`, e.g. PLAIN or LOGIN (defaults to LOGIN with a username, NOAUTH without) ($MAILPEN_SMTP_AUTH)")
+	fs.IntVar(&f.smtp.TLSPolicy, "smtp-tls", envInt("MAILPEN_SMTP_TLS", 1), "TLS `policy`: 0 none, 1 opportunistic, 2 mandatory ($MAILPEN_SMTP_TLS)")
+	fs.DurationVar(&f.smtp.Timeout, "timeout", 30*time.Second, "SMTP `timeout`")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: mailpen send [flags] <email>")
+		fmt.Fprintln(stderr)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("send: expected exactly one email name")
+	}
+
+	to := splitAddresses(f.to)
+	if len(to) == 0 {
+		return errors.New("send: at least one recipient is required (-to or $MAILPEN_TO)")
+	}
+
+	if f.smtp.AuthType == "" && f.smtp.Username == "" {
+		f.smtp.AuthType = "NOAUTH"
+	}
+
+	name := fs.Arg(0)
+	if f.subject == "" {
+		f.subject = "[mailpen test] " + name
+	}
+
+	manager, err := newManager(f.templates, f.theme, false)
+	if err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+
+	data, err := loadDataOrSample(f.data, manager, name)
+	if err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+
+	provider, err := newSMTPProvider(&f.smtp)
+	if err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+
+	config := &mailpen.Config{
+		From:    f.from,
+		Sources: []mailpen.TemplateSource{{Name: "templates", FS: os.DirFS(f.templates)}},
+	}
+	if f.theme != "" {
+		config.ThemeFile = &mailpen.ThemeFile{FS: os.DirFS(filepath.Dir(f.theme)), Path: filepath.Base(f.theme)}
+	}
+
+	mp, err := mailpen.New(provider, config)
+	if err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+
+	builder := mailpen.NewMessage().
+		From(f.from).
+		To(to...).
+		Subject(f.subject).
+		Template(name).
+		WithData(data)
+	if f.layout != "" {
+		builder = builder.Layout(f.layout)
+	}
+
+	msg, err := builder.Build()
+	if err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+
+	if err := mp.Send(context.Background(), msg); err != nil {
+		return fmt.Errorf("send: %w", err)
+	}
+
+	fmt.Fprintf(stdout, "Sent %s to %s\n", name, strings.Join(to, ", "))
+	return nil
+}
+
+// splitAddresses splits a comma-separated list of addresses
+func splitAddresses(list string) []string {
+	var addresses []string
+	for _, address := range strings.Split(list, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// envOr returns the value of an environment variable, or def when it is unset or empty
+func envOr(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return def
+}
+
+// envInt returns the integer value of an environment variable, or def when it is unset or not a number
+func envInt(key string, def int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return def
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/providers/smtp"
+)
+
+// fakeProvider records sent messages
+type fakeProvider struct {
+	config *smtp.Config
+	sent   []*mailpen.Message
+}
+
+func (p *fakeProvider) Send(_ context.Context, msg *mailpen.Message) error {
+	p.sent = append(p.sent, msg)
+	return nil
+}
+
+func (p *fakeProvider) Name() string                    { return "fake" }
+func (p *fakeProvider) Validate(*mailpen.Message) error { return nil }
+func (p *fakeProvider) Capabilities() mailpen.Capabilities {
+	return mailpen.Capabilities{SupportsHTMLOnly: true}
+}
+
+func useFakeProvider(t *testing.T) *fakeProvider {
+	t.Helper()
+	provider := &fakeProvider{}
+	original := newSMTPProvider
+	newSMTPProvider = func(config *smtp.Config) (mailpen.Provider, error) {
+		provider.config = config
+		return provider, nil
+	}
+	t.Cleanup(func() { newSMTPProvider = original })
+	return provider
+}
+
+func TestSend(t *testing.T) {
+	provider := useFakeProvider(t)
+	data := writeFile(t, "data.json", `{"Name": "Ada", "CompanyName": "Acme"}`)
+
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"send",
+		"-templates", templatesDir,
+		"-data", data,
+		"-to", "designer@example.com, qa@example.com",
+		"-from", "test@example.com",
+		"-smtp-host", "mail.example.com",
+		"-smtp-port", "2525",
+		"welcome",
+	}, &stdout, &stderr)
+	require.NoError(t, err)
+	assert.Equal(t, "Sent welcome to designer@example.com, qa@example.com\n", stdout.String())
+
+	require.Len(t, provider.sent, 1)
+	msg := provider.sent[0]
+	assert.Equal(t, []string{"designer@example.com", "qa@example.com"}, msg.To)
+	assert.Equal(t, "test@example.com", msg.From)
+	assert.Equal(t, "[mailpen test] welcome", msg.Subject)
+	assert.Contains(t, msg.HTMLBody, "Welcome, Ada!")
+	assert.Contains(t, msg.TextBody, "Ada")
+
+	assert.Equal(t, "mail.example.com", provider.config.Host)
+	assert.Equal(t, 2525, provider.config.Port)
+	assert.Equal(t, "NOAUTH", provider.config.AuthType)
+}
+
+func TestSend_Environment(t *testing.T) {
+	provider := useFakeProvider(t)
+	t.Setenv("MAILPEN_TO", "inbox@example.com")
+	t.Setenv("MAILPEN_SMTP_HOST", "smtp.example.com")
+	t.Setenv("MAILPEN_SMTP_PORT", "587")
+	t.Setenv("MAILPEN_SMTP_USERNAME", "user")
+	t.Setenv("MAILPEN_SMTP_PASSWORD", "secret")
+
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"send", "-templates", templatesDir, "-subject", "Check this", "simple"}, &stdout, &stderr)
+	require.NoError(t, err)
+
+	require.Len(t, provider.sent, 1)
+	assert.Equal(t, []string{"inbox@example.com"}, provider.sent[0].To)
+	assert.Equal(t, "Check this", provider.sent[0].Subject)
+	assert.Equal(t, "smtp.example.com", provider.config.Host)
+	assert.Equal(t, 587, provider.config.Port)
+	assert.Equal(t, "user", provider.config.Username)
+	assert.Equal(t, "secret", provider.config.Password)
+	assert.Empty(t, provider.config.AuthType)
+}
+
+func TestSend_Errors(t *testing.T) {
+	useFakeProvider(t)
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "missing email", args: []string{"send", "-to", "a@example.com"}, wantErr: "expected exactly one email name"},
+		{name: "missing recipient", args: []string{"send", "welcome"}, wantErr: "at least one recipient is required"},
+		{name: "unknown email", args: []string{"send", "-templates", templatesDir, "-to", "a@example.com", "missing"}, wantErr: "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MAILPEN_TO", "")
+			var stdout, stderr bytes.Buffer
+			assert.ErrorContains(t, run(tt.args, &stdout, &stderr), tt.wantErr)
+		})
+	}
+}
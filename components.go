@@ -1,21 +1,34 @@
 package mailpen
 
 import (
+	"errors"
 	"fmt"
+	"html/template"
+	"io/fs"
+	"maps"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/patrickward/mailpen/templates"
 )
 
 // The following structs represent the data needed to render various components in an email templates.
 
-// commonEmailData adds common data to the email data map.
-func commonTemplateData(cfg *Config, data map[string]any) map[string]any {
-	data["FooterData"] = FooterData{
-		CompanyName:   cfg.CompanyName,
-		SupportEmail:  cfg.SupportEmail,
-		CopyrightText: fmt.Sprintf("© 2024 %s. All rights reserved.", cfg.CompanyName),
-		AddressLine1:  cfg.CompanyAddress1,
+// footerData returns the footer data for the configuration, with the copyright year taken from now
+func footerData(cfg *Config, now time.Time) FooterData {
+	return FooterData{
+		CompanyName:    cfg.CompanyName,
+		SupportEmail:   cfg.SupportEmail,
+		CopyrightText:  fmt.Sprintf("© %d %s. All rights reserved.", now.Year(), cfg.CompanyName),
+		AddressLine1:   cfg.CompanyAddress1,
+		AddressLine2:   cfg.CompanyAddress2,
+		PreferencesURL: cfg.PreferencesURL,
 	}
-
-	return data
 }
 
 // TableHeader represents a header in a table
@@ -54,11 +67,12 @@ type TwoColumnData struct {
 
 // FooterData represents the data needed to render a footer
 type FooterData struct {
-	CompanyName   string
-	SupportEmail  string
-	CopyrightText string // e.g., "© 2024 Crystal Springs Foundation. All rights reserved."
-	AddressLine1  string // e.g., "1234 Business Street, Suite 500"
-	AddressLine2  string // e.g., "San Francisco, CA 94111"
+	CompanyName    string
+	SupportEmail   string
+	CopyrightText  string // e.g., "© 2024 Crystal Springs Foundation. All rights reserved."
+	AddressLine1   string // e.g., "1234 Business Street, Suite 500"
+	AddressLine2   string // e.g., "San Francisco, CA 94111"
+	PreferencesURL string // Email preference center, from Config.PreferencesURL
 }
 
 // NotificationButton represents the type of button to render in a notification box
@@ -97,3 +111,290 @@ type Card struct {
 type CardGridData struct {
 	Cards []Card
 }
+
+// ListItem represents an entry in a list. Checked marks completed items in a checklist.
+type ListItem struct {
+	Text    string
+	Checked bool
+}
+
+// LineItem represents a line in an order summary. Prices are in minor units, e.g. cents.
+type LineItem struct {
+	Name        string
+	Description string
+	Quantity    int
+	UnitPrice   int64
+}
+
+// Total returns the price of the line
+func (l LineItem) Total() int64 {
+	return int64(l.Quantity) * l.UnitPrice
+}
+
+// OrderSummaryData represents the data needed to render an order summary. Amounts are in minor units of
+// Currency, an ISO 4217 code such as "USD".
+type OrderSummaryData struct {
+	Items    []LineItem
+	Currency string
+	Discount int64
+	Shipping int64
+	Tax      int64
+	TaxLabel string // Defaults to "Tax"
+}
+
+// Subtotal returns the sum of the line items
+func (o OrderSummaryData) Subtotal() int64 {
+	var total int64
+	for _, item := range o.Items {
+		total += item.Total()
+	}
+	return total
+}
+
+// Total returns the subtotal less the discount, plus shipping and tax
+func (o OrderSummaryData) Total() int64 {
+	return o.Subtotal() - o.Discount + o.Shipping + o.Tax
+}
+
+// NameWidth returns the width of the item column in the plain-text summary
+func (o OrderSummaryData) NameWidth() int {
+	width := len("Subtotal")
+	if label := o.taxLabel(); len(label) > width {
+		width = len(label)
+	}
+	for _, item := range o.Items {
+		if n := utf8.RuneCountInString(item.Name); n > width {
+			width = n
+		}
+	}
+	return width
+}
+
+// taxLabel returns the label of the tax line
+func (o OrderSummaryData) taxLabel() string {
+	if o.TaxLabel == "" {
+		return "Tax"
+	}
+	return o.TaxLabel
+}
+
+// Stat represents a metric in a stats row
+type Stat struct {
+	Label         string  // e.g., "New signups"
+	Value         string  // Formatted value, e.g., "1,204"
+	Change        float64 // Change in percent; zero hides the indicator
+	Note          string  // Shown after the change, e.g., "vs last week"
+	LowerIsBetter bool    // Show decreases as good news (e.g., churn or response time)
+}
+
+// Trend returns "up", "down", or "" when the value did not change
+func (s Stat) Trend() string {
+	switch {
+	case s.Change > 0:
+		return "up"
+	case s.Change < 0:
+		return "down"
+	default:
+		return ""
+	}
+}
+
+// Improved reports whether the change is good news
+func (s Stat) Improved() bool {
+	return (s.Change > 0) != s.LowerIsBetter
+}
+
+// ChangeText returns the change as an arrow and a percentage, e.g., "▲ 12.5%"
+func (s Stat) ChangeText() string {
+	arrow := "▲"
+	if s.Change < 0 {
+		arrow = "▼"
+	}
+	return fmt.Sprintf("%s %s%%", arrow, strconv.FormatFloat(math.Abs(s.Change), 'f', -1, 64))
+}
+
+// StatsData represents the data needed to render a stats row
+type StatsData struct {
+	Stats   []Stat
+	Columns int // Stats per row, between 2 and 4 (defaults to the number of stats, at most 4)
+}
+
+// Rows returns the stats split into rows of Columns stats
+func (s StatsData) Rows() [][]Stat {
+	return chunk(s.Stats, s.columns())
+}
+
+// ColumnWidth returns the width of each stat in percent
+func (s StatsData) ColumnWidth() int {
+	return 100 / s.columns()
+}
+
+// columns returns the number of stats per row
+func (s StatsData) columns() int {
+	columns := s.Columns
+	if columns == 0 {
+		columns = len(s.Stats)
+	}
+	return max(2, min(columns, 4))
+}
+
+// GalleryImage represents an image in an image gallery
+type GalleryImage struct {
+	URL     string
+	Alt     string
+	Caption string // Optional caption below the image
+	LinkURL string // Optional link wrapping the image
+}
+
+// GalleryData represents the data needed to render an image gallery
+type GalleryData struct {
+	Images  []GalleryImage
+	Columns int // Images per row, between 1 and 4 (defaults to 3)
+}
+
+// Rows returns the images split into rows of Columns images
+func (g GalleryData) Rows() [][]GalleryImage {
+	return chunk(g.Images, g.columns())
+}
+
+// ColumnWidth returns the width of each image in percent
+func (g GalleryData) ColumnWidth() int {
+	return 100 / g.columns()
+}
+
+// columns returns the number of images per row
+func (g GalleryData) columns() int {
+	if g.Columns == 0 {
+		return 3
+	}
+	return max(1, min(g.Columns, 4))
+}
+
+// chunk splits items into consecutive slices of at most size items
+func chunk[T any](items []T, size int) [][]T {
+	var chunks [][]T
+	for start := 0; start < len(items); start += size {
+		chunks = append(chunks, items[start:min(start+size, len(items))])
+	}
+	return chunks
+}
+
+// customComponent is a component registered with RegisterComponent
+type customComponent struct {
+	html     string
+	text     string
+	defaults map[string]any
+}
+
+// componentDefinePattern finds the components defined by a template file
+var componentDefinePattern = regexp.MustCompile(`{{-?\s*define\s+"(@[^"]+)"`)
+
+// builtinComponents returns the names of the components shipped in the templates package
+var builtinComponents = sync.OnceValue(func() map[string]bool {
+	names := make(map[string]bool)
+	_ = fs.WalkDir(templates.FS, ComponentsDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		content, err := fs.ReadFile(templates.FS, p)
+		if err != nil {
+			return nil
+		}
+		for _, match := range componentDefinePattern.FindAllStringSubmatch(string(content), -1) {
+			names[match[1]] = true
+		}
+		return nil
+	})
+	return names
+})
+
+// componentName returns the template name of a component, which starts with "@"
+func componentName(name string) string {
+	return "@" + strings.TrimPrefix(strings.TrimSpace(name), "@")
+}
+
+// RegisterComponent adds a component from template strings, used in templates like the built-in components
+// as {{template "@name" (dict "Key" "value")}}. The templates hold the component body without a define
+// block; textTmpl may be empty for HTML-only components. Defaults, a map or struct, supply values for keys
+// the caller does not pass. Names that collide with a built-in component or a registered one are rejected.
+func (m *Manager) RegisterComponent(name, htmlTmpl, textTmpl string, defaults any) error {
+	full := componentName(name)
+	if full == "@" {
+		return errors.New("component name is required")
+	}
+	if builtinComponents()[full] {
+		return fmt.Errorf("component %q is a built-in component", full)
+	}
+	if htmlTmpl == "" {
+		return fmt.Errorf("component %q requires an HTML template", full)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.components[full]; ok {
+		return fmt.Errorf("component %q is already registered", full)
+	}
+
+	m.components[full] = customComponent{html: htmlTmpl, text: textTmpl, defaults: typedData(defaults)}
+	if err := m.loadBaseTemplates(); err != nil {
+		delete(m.components, full)
+		_ = m.loadBaseTemplates()
+		return fmt.Errorf("failed to register component %q: %w", full, err)
+	}
+
+	m.emailCache.clear()
+	return nil
+}
+
+// loadComponents parses the registered components into the base templates. The caller must hold m.mu.
+func (m *Manager) loadComponents() error {
+	for name, c := range m.components {
+		for format, body := range map[TemplateFormat]string{FormatHTML: c.html, FormatText: c.text} {
+			if body == "" {
+				continue
+			}
+			base := m.baseTemplates[format]
+			if _, err := base.New(name + "/body").Parse(body); err != nil {
+				return fmt.Errorf("failed to parse %s template: %w", format, err)
+			}
+			wrapper := fmt.Sprintf(`{{template %q (componentData %q .)}}`, name+"/body", name)
+			if _, err := base.New(name).Parse(wrapper); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// componentFuncs returns the template functions registered components rely on
+func (m *Manager) componentFuncs() template.FuncMap {
+	return template.FuncMap{
+		"componentData": m.componentData,
+	}
+}
+
+// componentData merges the data passed to a registered component over its defaults. Data that is not a map is
+// passed through unchanged.
+func (m *Manager) componentData(name string, data any) any {
+	m.mu.RLock()
+	defaults := m.components[name].defaults
+	m.mu.RUnlock()
+
+	if len(defaults) == 0 {
+		return data
+	}
+
+	if data == nil {
+		return maps.Clone(defaults)
+	}
+
+	values, ok := data.(map[string]any)
+	if !ok {
+		return data
+	}
+
+	merged := maps.Clone(defaults)
+	maps.Copy(merged, values)
+	return merged
+}
@@ -1,7 +1,11 @@
 package mailpen_test
 
 import (
+	"fmt"
+	"io/fs"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -238,6 +242,13 @@ func TestEmailComponents(t *testing.T) {
 				`background-color: ` + theme("colors.success"),
 				`background-color: ` + theme("colors.danger"),
 				`color: ` + theme("colors.background.primary"),
+				// Outlook VML fallback
+				`<!--[if mso]>`,
+				`<v:roundrect xmlns:v="urn:schemas-microsoft-com:vml" xmlns:w="urn:schemas-microsoft-com:office:word" href="https://example.com/danger" style="height: ` + theme("components.button.vml.height") + `; v-text-anchor: middle; width: ` + theme("components.button.vml.width") + `;" arcsize="` + theme("components.button.vml.arcsize") + `" stroke="f" fillcolor="` + theme("colors.danger") + `">`,
+				`Delete</center>`,
+				`<![endif]-->`,
+				`<!--[if !mso]><!-->`,
+				`<!--<![endif]-->`,
 				`font-family: ` + theme("typography.font.family"),
 				`border-radius: ` + theme("borders.radius.md"),
 				`padding: ` + theme("components.button.padding.y") + ` ` + theme("components.button.padding.x"),
@@ -250,6 +261,304 @@ func TestEmailComponents(t *testing.T) {
 				"Delete: https://example.com/danger",
 			},
 		},
+		{
+			name:      "email with hero",
+			emailName: "hero-test",
+			data: map[string]interface{}{
+				"headline":   "Spring Sale",
+				"subhead":    "Everything 20% off",
+				"image":      "https://example.com/hero.jpg",
+				"buttonText": "Shop Now",
+				"buttonURL":  "https://example.com/sale",
+			},
+			wantHTML: []string{
+				`<img src="https://example.com/hero.jpg" alt="Spring flowers"`,
+				`background-color: ` + theme("colors.secondary"),
+				`padding: ` + theme("components.hero.padding"),
+				`font-size: ` + theme("components.hero.headline.fontSize"),
+				`color: ` + theme("components.hero.textColor"),
+				`Spring Sale</h1>`,
+				`Everything 20% off</p>`,
+				`href="https://example.com/sale"`,
+				`Shop Now</a>`,
+				`background-color: #123456`,
+				`Plain banner</h1>`,
+			},
+			wantText: []string{
+				"Spring Sale",
+				"Everything 20% off",
+				"Shop Now: https://example.com/sale",
+			},
+		},
+		{
+			name:      "email with order summary",
+			emailName: "order-summary-test",
+			data: map[string]interface{}{
+				"order": mailpen.OrderSummaryData{
+					Items: []mailpen.LineItem{
+						{Name: "Widget", Description: "Blue, large", Quantity: 2, UnitPrice: 1250},
+						{Name: "Gadget Pro", Quantity: 1, UnitPrice: 149999},
+					},
+					Currency: "USD",
+					Discount: 500,
+					Tax:      1200,
+					TaxLabel: "VAT",
+				},
+			},
+			wantHTML: []string{
+				`Widget`,
+				`Blue, large`,
+				`$12.50`,
+				`$25.00`,
+				`$1,499.99`,
+				`Subtotal`,
+				`$1,524.99`,
+				`-$5.00`,
+				`VAT`,
+				`$12.00`,
+				`$1,531.99`,
+			},
+			notWantHTML: []string{
+				`Shipping`,
+			},
+			wantText: []string{
+				"Widget         2 x     $12.50        $25.00",
+				"Gadget Pro     1 x  $1,499.99     $1,499.99",
+				"Subtotal                          $1,524.99",
+				"Discount                             -$5.00",
+				"VAT                                  $12.00",
+				"Total                             $1,531.99",
+			},
+		},
+		{
+			name:      "email with progress bar",
+			emailName: "progress-test",
+			data: map[string]interface{}{
+				"used": 72.4,
+			},
+			wantHTML: []string{
+				`Storage used`,
+				`72%`,
+				`width="72%"`,
+				`background-color: ` + theme("colors.warning"),
+				`width="28%"`,
+				`background-color: ` + theme("components.progress.trackColor"),
+				`height: ` + theme("components.progress.height"),
+				`width="100%" height="` + theme("components.progress.height") + `" bgcolor="` + theme("colors.primary") + `"`,
+			},
+			notWantHTML: []string{
+				`width="0%"`,
+			},
+			wantText: []string{
+				"Storage used: [##############------] 72%",
+			},
+		},
+		{
+			name:      "email with avatar",
+			emailName: "avatar-test",
+			data: map[string]interface{}{
+				"image":    "https://example.com/ada.png",
+				"name":     "Ada Lovelace",
+				"subtitle": "mentioned you in Analytical Engine",
+				"url":      "https://example.com/comments/1",
+			},
+			wantHTML: []string{
+				`<img src="https://example.com/ada.png" alt="Ada Lovelace" width="` + theme("components.avatar.size") + `"`,
+				`Ada Lovelace</p>`,
+				`mentioned you in Analytical Engine</p>`,
+				`href="https://example.com/comments/1"`,
+				`View comment</a>`,
+				`background-color: ` + theme("colors.success"),
+				`>GH</td>`,
+			},
+			wantText: []string{
+				"Ada Lovelace mentioned you in Analytical Engine",
+				"View comment: https://example.com/comments/1",
+			},
+		},
+		{
+			name:      "email with lists",
+			emailName: "list-test",
+			data: map[string]interface{}{
+				"features": []string{"Fast", "Reliable"},
+				"steps": []any{
+					mailpen.ListItem{Text: "Create an account", Checked: true},
+					map[string]any{"text": "Verify your email", "checked": true},
+					"Invite your team",
+				},
+			},
+			wantHTML: []string{
+				theme("components.list.bullet") + ` </p>`,
+				`1. </p>`,
+				`2. </p>`,
+				`color: ` + theme("colors.success") + `;"> ` + theme("components.list.checked") + ` </p>`,
+				`color: ` + theme("colors.text.muted") + `;"> ` + theme("components.list.unchecked") + ` </p>`,
+				`Create an account`,
+				`Verify your email`,
+				`Invite your team`,
+			},
+			wantText: []string{
+				"- Fast\n- Reliable",
+				"1. Fast\n2. Reliable",
+				"[x] Create an account\n[x] Verify your email\n[ ] Invite your team",
+			},
+		},
+		{
+			name:      "email with stats",
+			emailName: "stats-test",
+			data: map[string]interface{}{
+				"stats": mailpen.StatsData{
+					Stats: []mailpen.Stat{
+						{Label: "Signups", Value: "1,204", Change: 12.5, Note: "vs last week"},
+						{Label: "Churn", Value: "2.1%", Change: -0.4, LowerIsBetter: true},
+						{Label: "Response time", Value: "340ms", Change: 8, LowerIsBetter: true},
+						{Label: "Active users", Value: "9,876"},
+						{Label: "Revenue", Value: "$12,000", Change: -3},
+					},
+					Columns: 3,
+				},
+			},
+			wantHTML: []string{
+				`width="33%"`,
+				`Signups</p>`,
+				`1,204</p>`,
+				`color: ` + theme("colors.success") + `; font-family: ` + theme("typography.font.family") + `; font-size: ` + theme("typography.font.size.xs") + `; font-weight: ` + theme("typography.font.weight.bold") + `;">▲ 12.5% <span`,
+				`vs last week</span>`,
+				`color: ` + theme("colors.success") + `; font-family: ` + theme("typography.font.family") + `; font-size: ` + theme("typography.font.size.xs") + `; font-weight: ` + theme("typography.font.weight.bold") + `;">▼ 0.4%`,
+				`color: ` + theme("colors.danger") + `; font-family: ` + theme("typography.font.family") + `; font-size: ` + theme("typography.font.size.xs") + `; font-weight: ` + theme("typography.font.weight.bold") + `;">▲ 8%`,
+				`color: ` + theme("colors.danger") + `; font-family: ` + theme("typography.font.family") + `; font-size: ` + theme("typography.font.size.xs") + `; font-weight: ` + theme("typography.font.weight.bold") + `;">▼ 3%`,
+				`font-size: ` + theme("components.stat.valueSize"),
+			},
+			wantText: []string{
+				"Signups: 1,204 (▲ 12.5% vs last week)",
+				"Churn: 2.1% (▼ 0.4%)",
+				"Active users: 9,876\n",
+			},
+		},
+		{
+			name:      "email with gallery",
+			emailName: "gallery-test",
+			data: map[string]interface{}{
+				"gallery": mailpen.GalleryData{
+					Images: []mailpen.GalleryImage{
+						{URL: "https://example.com/1.jpg", Alt: "Beach", Caption: "Day one", LinkURL: "https://example.com/photos/1"},
+						{URL: "https://example.com/2.jpg", Alt: "Mountains", LinkURL: "https://example.com/photos/2"},
+						{URL: "https://example.com/3.jpg", Alt: "Forest"},
+					},
+					Columns: 2,
+				},
+			},
+			wantHTML: []string{
+				`width="50%"`,
+				`padding: ` + theme("components.gallery.gap"),
+				`<a href="https://example.com/photos/1"><img src="https://example.com/1.jpg" alt="Beach"`,
+				`Day one</p>`,
+				`<img src="https://example.com/3.jpg" alt="Forest" width="100%"`,
+			},
+			notWantHTML: []string{
+				`<a href="https://example.com/3.jpg"`,
+			},
+			wantText: []string{
+				"Day one: https://example.com/photos/1\nMountains: https://example.com/photos/2\n",
+			},
+		},
+		{
+			name:      "email with rating",
+			emailName: "rating-test",
+			data: map[string]interface{}{
+				"starsURL": "https://example.com/rate?order=7",
+				"npsURL":   "https://example.com/nps/{score}",
+			},
+			wantHTML: []string{
+				`How was your order?</p>`,
+				`href="https://example.com/rate?order=7&amp;score=1" title="1 out of 5"`,
+				`href="https://example.com/rate?order=7&amp;score=5" title="5 out of 5"`,
+				`>` + theme("components.rating.star") + `</a>`,
+				`href="https://example.com/nps/0" title="0"`,
+				`href="https://example.com/nps/10" title="10"`,
+				`Not likely</td>`,
+				`Very likely</td>`,
+			},
+			notWantHTML: []string{
+				`score=6`,
+				`nps/11`,
+			},
+			wantText: []string{
+				"How was your order?\n\n1 out of 5: https://example.com/rate?order=7&amp;score=1",
+				"5 out of 5: https://example.com/rate?order=7&amp;score=5",
+				"(0 = Not likely, 10 = Very likely)\n\n0: https://example.com/nps/0",
+				"10: https://example.com/nps/10",
+			},
+		},
+		{
+			name:      "email with address",
+			emailName: "address-test",
+			data: map[string]interface{}{
+				"CompanyName":     "ACME Corp",
+				"CompanyAddress1": "1234 Business Street, Suite 500",
+				"CompanyAddress2": "San Francisco, CA 94111",
+				"venue":           "747 Howard St\nSan Francisco, CA 94103",
+			},
+			wantHTML: []string{
+				`ACME Corp</strong><br>`,
+				`1234 Business Street, Suite 500<br>San Francisco, CA 94111`,
+				`Moscone Center</strong><br>`,
+				`747 Howard St<br>San Francisco, CA 94103`,
+				`href="https://www.google.com/maps/search/?api=1&amp;query=747&#43;Howard&#43;St%2C&#43;San&#43;Francisco%2C&#43;CA&#43;94103"`,
+				`View map</a>`,
+			},
+			wantText: []string{
+				"ACME Corp\n1234 Business Street, Suite 500\nSan Francisco, CA 94111",
+				"Moscone Center\n747 Howard St\nSan Francisco, CA 94103\nMap: https://www.google.com/maps/search/",
+			},
+		},
+		{
+			name:      "email with event",
+			emailName: "event-test",
+			data: map[string]interface{}{
+				"event": mailpen.CalendarEvent{
+					Title:    "Quarterly Review",
+					Location: "Moscone Center",
+					Start:    time.Date(2025, 3, 3, 9, 0, 0, 0, time.FixedZone("PST", -8*60*60)),
+					End:      time.Date(2025, 3, 3, 10, 30, 0, 0, time.FixedZone("PST", -8*60*60)),
+					ICSURL:   "https://example.com/events/1.ics",
+				},
+			},
+			wantHTML: []string{
+				`Quarterly Review</p>`,
+				`Monday, March 3, 2025, 9:00 AM – 10:30 AM PST</p>`,
+				`Moscone Center</p>`,
+				`href="https://calendar.google.com/calendar/render?action=TEMPLATE&amp;dates=20250303T170000Z%2F20250303T183000Z`,
+				`href="https://outlook.live.com/calendar/0/deeplink/compose?`,
+				`href="https://example.com/events/1.ics"`,
+			},
+			notWantHTML: []string{
+				`Event details</a>`,
+			},
+			wantText: []string{
+				"Quarterly Review\nWhen: Monday, March 3, 2025, 9:00 AM – 10:30 AM PST\nWhere: Moscone Center",
+				"Add to Google Calendar: https://calendar.google.com/calendar/render?",
+				"Download .ics: https://example.com/events/1.ics",
+			},
+		},
+		{
+			name:      "email with badges",
+			emailName: "badge-test",
+			wantHTML: []string{
+				`Payment <span class="mp-badge mp-badge-success"`,
+				`background-color: ` + theme("colors.success") + `; color: ` + theme("colors.background.primary") + `; font-family: ` + theme("typography.font.family") + `; font-size: ` + theme("components.badge.fontSize") + `; font-weight: ` + theme("typography.font.weight.bold") + `; line-height: 1.5; text-transform: uppercase; letter-spacing: ` + theme("typography.font.letterSpacing") + `; white-space: nowrap;">success</span>`,
+				`background-color: ` + theme("colors.danger") + `; color: ` + theme("colors.background.primary") + `; font-family: ` + theme("typography.font.family") + `; font-size: ` + theme("components.badge.fontSize") + `; font-weight: ` + theme("typography.font.weight.bold") + `; line-height: 1.5; text-transform: uppercase; letter-spacing: ` + theme("typography.font.letterSpacing") + `; white-space: nowrap;">Broken</span>`,
+				`background-color: ` + theme("colors.warning") + `; color: ` + theme("colors.background.primary") + `; font-family: ` + theme("typography.font.family") + `; font-size: ` + theme("components.badge.fontSize") + `; font-weight: ` + theme("typography.font.weight.bold") + `; line-height: 1.5; text-transform: uppercase; letter-spacing: ` + theme("typography.font.letterSpacing") + `; white-space: nowrap;">Pending</span>`,
+				`background-color: ` + theme("colors.info") + `; color: ` + theme("colors.background.primary") + `; font-family: ` + theme("typography.font.family") + `; font-size: ` + theme("components.badge.fontSize") + `; font-weight: ` + theme("typography.font.weight.bold") + `; line-height: 1.5; text-transform: uppercase; letter-spacing: ` + theme("typography.font.letterSpacing") + `; white-space: nowrap;">info</span>`,
+				`<span class="mp-badge mp-badge-neutral"`,
+				`background-color: ` + theme("colors.text.muted") + `; color: ` + theme("colors.background.primary") + `; font-family: ` + theme("typography.font.family") + `; font-size: ` + theme("components.badge.fontSize") + `; font-weight: ` + theme("typography.font.weight.bold") + `; line-height: 1.5; text-transform: uppercase; letter-spacing: ` + theme("typography.font.letterSpacing") + `; white-space: nowrap;">Archived</span>`,
+				`padding: ` + theme("components.badge.padding"),
+			},
+			wantText: []string{
+				"Payment [success]",
+				"Build [Broken]",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -272,3 +581,209 @@ func TestEmailComponents(t *testing.T) {
 		})
 	}
 }
+
+func TestManager_RegisterComponent(t *testing.T) {
+	templateFS := fstest.MapFS{
+		"emails/promo.html": {Data: []byte(`{{define "content"}}{{template "@tag" (dict "Label" .Label)}}{{template "@tag"}}{{end}}`)},
+		"emails/promo.txt":  {Data: []byte(`{{define "content"}}{{template "@tag" (dict "Label" .Label)}}{{end}}`)},
+	}
+
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{{Name: "test", FS: templateFS}},
+	})
+	require.NoError(t, err)
+
+	type tagDefaults struct {
+		Label string
+		Color string
+	}
+
+	err = manager.RegisterComponent("tag",
+		`<span style="color: {{.Color}}">{{.Label}}</span>`,
+		`[{{.Label}}]`,
+		tagDefaults{Label: "Default", Color: "#ff0000"},
+	)
+	require.NoError(t, err)
+
+	email, err := manager.RenderEmail("promo", map[string]any{"Label": "New"}, "")
+	require.NoError(t, err)
+	assert.Contains(t, email.HTML, `<span style="color: #ff0000">New</span>`)
+	assert.Contains(t, email.HTML, `<span style="color: #ff0000">Default</span>`)
+	assert.Contains(t, email.Text, "[New]")
+
+	t.Run("survives reload", func(t *testing.T) {
+		require.NoError(t, manager.Reload())
+
+		email, err := manager.RenderEmail("promo", map[string]any{"Label": "Again"}, "")
+		require.NoError(t, err)
+		assert.Contains(t, email.HTML, "Again</span>")
+	})
+
+	tests := []struct {
+		name        string
+		component   string
+		html        string
+		errContains string
+	}{
+		{name: "built-in collision", component: "button", html: "<a></a>", errContains: "built-in"},
+		{name: "built-in collision with prefix", component: "@alert", html: "<div></div>", errContains: "built-in"},
+		{name: "duplicate", component: "@tag", html: "<span></span>", errContains: "already registered"},
+		{name: "empty name", component: "", html: "<span></span>", errContains: "name is required"},
+		{name: "missing html", component: "chip", html: "", errContains: "requires an HTML template"},
+		{name: "invalid template", component: "broken", html: "{{.Label", errContains: "failed to register"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := manager.RegisterComponent(tt.component, tt.html, "", nil)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errContains)
+		})
+	}
+
+	// A failed registration leaves the manager usable
+	email, err = manager.RenderEmail("promo", map[string]any{"Label": "Still"}, "")
+	require.NoError(t, err)
+	assert.Contains(t, email.HTML, "Still</span>")
+}
+
+func TestFormatCurrency(t *testing.T) {
+	tests := []struct {
+		amount   int64
+		currency string
+		want     string
+	}{
+		{amount: 0, currency: "USD", want: "$0.00"},
+		{amount: 5, currency: "USD", want: "$0.05"},
+		{amount: 123456789, currency: "usd", want: "$1,234,567.89"},
+		{amount: -1999, currency: "EUR", want: "-€19.99"},
+		{amount: 1500, currency: "JPY", want: "¥1,500"},
+		{amount: 100000, currency: "CHF", want: "CHF 1,000.00"},
+		{amount: 250, currency: "", want: "2.50"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, mailpen.FormatCurrency(tt.amount, tt.currency))
+		})
+	}
+}
+
+func TestSocialComponent(t *testing.T) {
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{{Name: "test", FS: testFS(t, "base")}},
+	})
+	require.NoError(t, err)
+
+	data := mailpen.NewTemplateData(&mailpen.Config{
+		SocialMediaLinks: map[string]string{
+			"linkedin": "https://linkedin.com/company/acme",
+			"github":   "https://github.com/acme",
+			"myspace":  "https://myspace.com/acme",
+			"empty":    "",
+		},
+		SocialIcons:       map[string]string{"github": "https://cdn.acme.test/github.png"},
+		SocialIconBaseURL: "https://acme.test/email-icons/",
+	})
+
+	result, err := manager.RenderEmail("social-test", data, "")
+	require.NoError(t, err)
+
+	// Configured icon
+	assert.Contains(t, result.HTML, `<a href="https://github.com/acme" title="GitHub"><img src="https://cdn.acme.test/github.png" alt="GitHub"`)
+	// Bundled icon
+	assert.Contains(t, result.HTML, `<img src="https://acme.test/email-icons/linkedin.png" alt="LinkedIn"`)
+	assert.Contains(t, result.HTML, `width="`+theme("components.social.iconSize")+`"`)
+	// No icon available
+	assert.Contains(t, result.HTML, `Myspace</a>`)
+	assert.NotContains(t, result.HTML, `myspace.png`)
+	assert.NotContains(t, result.HTML, `Empty`)
+
+	assert.Contains(t, result.Text, "GitHub: https://github.com/acme\nLinkedIn: https://linkedin.com/company/acme\nMyspace: https://myspace.com/acme")
+
+	t.Run("without icon base URL", func(t *testing.T) {
+		data := mailpen.NewTemplateData(&mailpen.Config{
+			SocialMediaLinks: map[string]string{"linkedin": "https://linkedin.com/company/acme"},
+		})
+
+		result, err := manager.RenderEmail("social-test", data, "")
+		require.NoError(t, err)
+		assert.Contains(t, result.HTML, `LinkedIn</a>`)
+		assert.NotContains(t, result.HTML, `<img`)
+	})
+
+	t.Run("bundled icons", func(t *testing.T) {
+		for _, network := range []string{"facebook", "x", "linkedin", "instagram", "youtube", "github"} {
+			_, err := fs.Stat(mailpen.SocialIcons(), network+".png")
+			assert.NoError(t, err, network)
+		}
+	})
+}
+
+func TestStatsData_Rows(t *testing.T) {
+	stats := func(n int) []mailpen.Stat {
+		return make([]mailpen.Stat, n)
+	}
+
+	tests := []struct {
+		name      string
+		data      mailpen.StatsData
+		wantRows  []int
+		wantWidth int
+	}{
+		{name: "one row of three", data: mailpen.StatsData{Stats: stats(3)}, wantRows: []int{3}, wantWidth: 33},
+		{name: "at most four across", data: mailpen.StatsData{Stats: stats(6)}, wantRows: []int{4, 2}, wantWidth: 25},
+		{name: "at least two across", data: mailpen.StatsData{Stats: stats(1)}, wantRows: []int{1}, wantWidth: 50},
+		{name: "explicit columns", data: mailpen.StatsData{Stats: stats(5), Columns: 2}, wantRows: []int{2, 2, 1}, wantWidth: 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sizes []int
+			for _, row := range tt.data.Rows() {
+				sizes = append(sizes, len(row))
+			}
+			assert.Equal(t, tt.wantRows, sizes)
+			assert.Equal(t, tt.wantWidth, tt.data.ColumnWidth())
+		})
+	}
+}
+
+func TestComplianceFooterComponent(t *testing.T) {
+	templateFS := fstest.MapFS{
+		"emails/news.html": {Data: []byte(`{{define "content"}}{{template "@compliance-footer" (dict "footer" .FooterData "unsubscribeURL" .unsubscribeURL "reason" .reason)}}{{end}}`)},
+		"emails/news.txt":  {Data: []byte(`{{define "content"}}{{template "@compliance-footer" (dict "footer" .FooterData "unsubscribeURL" .unsubscribeURL "reason" .reason)}}{{end}}`)},
+		"emails/bare.html": {Data: []byte(`{{define "content"}}{{template "@compliance-footer" (dict "unsubscribeURL" "https://example.com/u")}}{{end}}`)},
+	}
+
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{{Name: "test", FS: templateFS}},
+	})
+	require.NoError(t, err)
+
+	data := mailpen.NewTemplateData(&mailpen.Config{
+		CompanyName:     "ACME Corp",
+		CompanyAddress1: "1234 Business Street",
+		CompanyAddress2: "San Francisco, CA 94111",
+		PreferencesURL:  "https://example.com/preferences",
+	}).Merge(map[string]any{
+		"unsubscribeURL": "https://example.com/unsubscribe?token=abc",
+		"reason":         "You are receiving this because you subscribed to our newsletter.",
+	})
+
+	result, err := manager.RenderEmail("news", data, "")
+	require.NoError(t, err)
+	assert.Contains(t, result.HTML, `You are receiving this because you subscribed to our newsletter.</p>`)
+	assert.Contains(t, result.HTML, `<a href="https://example.com/unsubscribe?token=abc"`)
+	assert.Contains(t, result.HTML, `<a href="https://example.com/preferences"`)
+	assert.Contains(t, result.HTML, `ACME Corp<br>1234 Business Street<br>San Francisco, CA 94111`)
+	assert.Contains(t, result.HTML, fmt.Sprintf("© %d ACME Corp. All rights reserved.", time.Now().Year()))
+	assert.Contains(t, result.Text, "Unsubscribe: https://example.com/unsubscribe?token=abc\nManage email preferences: https://example.com/preferences")
+	assert.Contains(t, result.Text, "ACME Corp\n1234 Business Street\nSan Francisco, CA 94111")
+
+	result, err = manager.RenderEmail("bare", nil, "")
+	require.NoError(t, err)
+	assert.Contains(t, result.HTML, `<a href="https://example.com/u"`)
+	assert.NotContains(t, result.HTML, `Manage email preferences`)
+	assert.NotContains(t, result.HTML, `&middot;`)
+}
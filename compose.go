@@ -0,0 +1,109 @@
+package mailpen
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/patrickward/mailpen/templates"
+)
+
+// composedName is the template name used for composed emails in tracing, processors, and errors
+const composedName = "composed"
+
+// Compose renders an email composed from components into a layout, without an email template file. The
+// layout and partials receive data as usual; each component receives its own Data. Components without a
+// text version are left out of the text email. Composed templates are not cached.
+func (m *Manager) Compose(ctx context.Context, email templates.Layout, data interface{}, opts RenderOptions) (rendered *RenderedEmail, err error) {
+	layout := email.Name
+	if layout == "" {
+		layout = opts.Layout
+	}
+	if layout == "" {
+		layout = m.defaultLayout
+	}
+
+	ctx, span := m.tracer.Start(ctx, "mailpen.render", trace.WithAttributes(
+		attribute.String("mailpen.template", composedName),
+		attribute.String("mailpen.layout", layout),
+	))
+	defer func() { endSpan(span, err) }()
+	dev := m.devMode.Load()
+	timings, report := m.startTimings(ctx, composedName, layout)
+	defer func() {
+		report(err)
+		if err != nil && dev {
+			rendered = m.errorEmail(composedName, err)
+		}
+	}()
+
+	for i, component := range email.Components {
+		if component == nil {
+			return nil, fmt.Errorf("component %d is nil", i)
+		}
+	}
+
+	if dev {
+		if err := m.Reload(); err != nil {
+			return nil, fmt.Errorf("failed to reload templates: %w", err)
+		}
+	}
+
+	theme := opts.Theme
+	if opts.Variant == "" {
+		theme = nil
+	}
+
+	return m.renderFormats(ctx, composedName, layout, theme, data, opts.Message, m.renderPolicy(opts.Policy), timings, dev, func(format TemplateFormat) (*template.Template, error) {
+		return m.composeTemplate(email, layout, format, theme)
+	})
+}
+
+// composeTemplate clones the base template for a format and defines the subject and content blocks from
+// the components of a composed email
+func (m *Manager) composeTemplate(email templates.Layout, layout string, format TemplateFormat, theme map[string]any) (*template.Template, error) {
+	m.mu.RLock()
+	tmpl, err := m.baseTemplates[format].Clone()
+	m.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if tmpl.Lookup("layout:"+layout) == nil {
+		return nil, fmt.Errorf("%w: %s", ErrLayoutNotFound, layout)
+	}
+
+	if theme != nil {
+		tmpl.Funcs(themeFuncs(func() map[string]any { return theme }, m.strictTheme, nil))
+	}
+
+	tmpl.Funcs(template.FuncMap{
+		"composed_subject": func() string { return email.Subject },
+		"composed_data":    func(i int) any { return email.Components[i].Data() },
+	})
+
+	var src strings.Builder
+	src.WriteString(`{{define "subject"}}{{composed_subject}}{{end}}`)
+	src.WriteString(`{{define "content"}}`)
+	for i, component := range email.Components {
+		name := component.Template()
+		if tmpl.Lookup(name) == nil {
+			if format == FormatText {
+				continue
+			}
+			return nil, fmt.Errorf("component %q not found", name)
+		}
+		fmt.Fprintf(&src, `{{template %q (composed_data %d)}}`, name, i)
+	}
+	src.WriteString(`{{end}}`)
+
+	if _, err := tmpl.New(composedName).Parse(src.String()); err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
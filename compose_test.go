@@ -0,0 +1,99 @@
+package mailpen_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/templates"
+)
+
+// note is a custom component that reuses the built-in @p component
+type note struct{ text string }
+
+func (n note) Template() string { return "@p" }
+func (n note) Data() any        { return "Note: " + n.text }
+
+func TestManager_Compose(t *testing.T) {
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{{Name: "test", FS: testFS(t, "base")}},
+	})
+	require.NoError(t, err)
+
+	email, err := manager.Compose(context.Background(), templates.Layout{
+		Subject: "Your weekly report",
+		Components: []templates.Component{
+			templates.Heading{Text: "Weekly report", Align: "center"},
+			templates.Paragraph{Text: "Here is how your week went."},
+			templates.Table{
+				Headers: []string{"Metric", "Value"},
+				Rows:    [][]string{{"Visits", "1,204"}, {"Signups", "37"}},
+			},
+			templates.Divider{},
+			templates.Button{Text: "View dashboard", URL: "https://example.com/dashboard"},
+			note{text: "Reports are sent every Monday."},
+		},
+	}, nil, mailpen.RenderOptions{})
+	require.NoError(t, err)
+
+	assert.Contains(t, email.HTML, "<title>Your weekly report</title>")
+	assert.Contains(t, email.HTML, "text-align: center;\"> Weekly report </h1>")
+	assert.Contains(t, email.HTML, "Here is how your week went.")
+	assert.Contains(t, email.HTML, "Signups")
+	assert.Contains(t, email.HTML, `href="https://example.com/dashboard"`)
+	assert.Contains(t, email.HTML, "Note: Reports are sent every Monday.")
+	assert.Less(t, strings.Index(email.HTML, "Weekly report </h1>"), strings.Index(email.HTML, "Here is how your week went."))
+
+	assert.Contains(t, email.Text, "Weekly report")
+	assert.Contains(t, email.Text, "Here is how your week went.")
+	assert.Contains(t, email.Text, "View dashboard: https://example.com/dashboard")
+	assert.Contains(t, email.Text, "Note: Reports are sent every Monday.")
+	assert.NotContains(t, email.Text, "<table")
+}
+
+func TestManager_Compose_Layout(t *testing.T) {
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{{Name: "test", FS: testFS(t, "base")}},
+	})
+	require.NoError(t, err)
+
+	hero := templates.Hero{Headline: "Spring sale", ButtonText: "Shop now", ButtonURL: "https://example.com/shop"}
+
+	t.Run("named layout", func(t *testing.T) {
+		email, err := manager.Compose(context.Background(), templates.Layout{
+			Name:       "marketing",
+			Components: []templates.Component{hero},
+		}, nil, mailpen.RenderOptions{})
+		require.NoError(t, err)
+		assert.Contains(t, email.HTML, "Spring sale")
+		assert.Contains(t, email.Text, "Shop now: https://example.com/shop")
+	})
+
+	t.Run("layout from options", func(t *testing.T) {
+		email, err := manager.Compose(context.Background(), templates.Layout{
+			Components: []templates.Component{hero},
+		}, nil, mailpen.RenderOptions{Layout: "marketing"})
+		require.NoError(t, err)
+		assert.Contains(t, email.HTML, "Spring sale")
+	})
+
+	t.Run("unknown layout", func(t *testing.T) {
+		_, err := manager.Compose(context.Background(), templates.Layout{
+			Name:       "missing",
+			Components: []templates.Component{hero},
+		}, nil, mailpen.RenderOptions{})
+		assert.ErrorIs(t, err, mailpen.ErrLayoutNotFound)
+		assert.ErrorContains(t, err, "layout not found: missing")
+	})
+
+	t.Run("nil component", func(t *testing.T) {
+		_, err := manager.Compose(context.Background(), templates.Layout{
+			Components: []templates.Component{hero, nil},
+		}, nil, mailpen.RenderOptions{})
+		assert.ErrorContains(t, err, "component 1 is nil")
+	})
+}
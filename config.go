@@ -1,36 +1,151 @@
 package mailpen
 
 import (
+	"errors"
+	"fmt"
 	"html/template"
+	"log/slog"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Config holds the mailpen configuration
 type Config struct {
 	// From address
-	From    string // From address
-	ReplyTo string // Reply-to address
+	From    string `env:"FROM"`     // From address
+	ReplyTo string `env:"REPLY_TO"` // Reply-to address
 
 	// Company/Branding
-	BaseURL         string // Base URL of the website
-	CompanyAddress1 string // The first line of the company address (usually the street address)
-	CompanyAddress2 string // The second line of the company address (usually the city, state, and ZIP code)
-	CompanyName     string // Company name
-	LogoURL         string // URL to the company logo
-	SupportEmail    string // Support email address
-	SupportPhone    string // Support phone number
-	WebsiteName     string // Name of the website
-	WebsiteURL      string // URL to the company website.
+	BaseURL         string `env:"BASE_URL"`         // Base URL of the website
+	CompanyAddress1 string `env:"COMPANY_ADDRESS1"` // The first line of the company address (usually the street address)
+	CompanyAddress2 string `env:"COMPANY_ADDRESS2"` // The second line of the company address (usually the city, state, and ZIP code)
+	CompanyName     string `env:"COMPANY_NAME"`     // Company name
+	LogoURL         string `env:"LOGO_URL"`         // URL to the company logo
+	SupportEmail    string `env:"SUPPORT_EMAIL"`    // Support email address
+	SupportPhone    string `env:"SUPPORT_PHONE"`    // Support phone number
+	WebsiteName     string `env:"WEBSITE_NAME"`     // Name of the website
+	WebsiteURL      string `env:"WEBSITE_URL"`      // URL to the company website.
+
+	// Dates
+	TimeZone        string `env:"TIME_ZONE"`        // IANA time zone of template dates (e.g. "Europe/Berlin"; defaults to the server's local time zone)
+	Locale          string `env:"LOCALE"`           // Locale whose date formats are used for CurrentDate and CurrentTimestamp (e.g. "en-GB")
+	DateFormat      string `env:"DATE_FORMAT"`      // Go layout of CurrentDate, overriding Locale (defaults to "January 2, 2006")
+	TimestampFormat string `env:"TIMESTAMP_FORMAT"` // Go layout of CurrentTimestamp, overriding Locale (defaults to "2006-01-02 15:04:05")
+
+	// Branding
+	BrandResolver BrandResolver // Resolves a per-message brand kit for white-labeled email (optional)
 
 	// HTML processor for processing HTML content
-	HTMLProcessor HTMLProcessor // HTML processor for processing HTML content
+	HTMLProcessor     HTMLProcessor      // HTML processor for processing HTML content
+	Processors        []ContextProcessor // Context-aware HTML processors applied after HTMLProcessor
+	TextConverter     TextConverter      // Derives the text body from the processed HTML when an email has no text template
+	Analyzers         []HTMLAnalyzer     // Inspect the processed HTML and report warnings on the rendered email
+	MessageProcessors []MessageProcessor // Processors applied in order to each rendered message before it is sent
+
+	// Sending
+	BatchConcurrency int                  `env:"BATCH_CONCURRENCY"` // Maximum number of messages SendMany prepares at once (defaults to DefaultBatchConcurrency)
+	SendTimeout      time.Duration        `env:"SEND_TIMEOUT"`      // Limits rendering, processing, and provider delivery of each message, including retries (zero is unlimited)
+	RateLimit        RateLimit            `env:"RATE_LIMIT"`        // Overall send rate limit (zero is unlimited)
+	DomainRateLimits map[string]RateLimit // Send rate limits per recipient domain (e.g. "gmail.com")
+	RetryPolicy      RetryPolicy          // Retries failed provider sends (defaults to NoRetry)
+	Suppressions     SuppressionStore     // Recipients on this list are skipped (optional)
+	Dedup            *Dedup               // Skips identical messages sent within a time window (optional)
+	History          History              // Records every provider send attempt (optional)
+	SafetyNet        *SafetyNet           // Redirects or drops recipients outside an allowlist (for development and staging)
+	AlwaysBcc        []string             `env:"ALWAYS_BCC"` // Addresses blind-copied on every message, for compliance archiving (see Message.SkipArchive)
+
+	// Attachments
+	MaxAttachmentSize      int64 `env:"MAX_ATTACHMENT_SIZE"`       // Largest attachment in bytes; the provider's Capabilities limit applies too (zero is unlimited)
+	MaxTotalAttachmentSize int64 `env:"MAX_TOTAL_ATTACHMENT_SIZE"` // Largest total size of a message's attachments in bytes; the provider's limit applies too (zero is unlimited)
+
+	// Environment
+	Environment          string `env:"ENVIRONMENT"`           // Environment name; outside production, messages are tagged by EnvironmentTagger (e.g. "staging")
+	EnvironmentWatermark bool   `env:"ENVIRONMENT_WATERMARK"` // Add an environment banner to HTML bodies outside production
+
+	// Logging
+	Logger        *slog.Logger // Logger for render and send events (defaults to discarding logs)
+	LogRecipients bool         `env:"LOG_RECIPIENTS"` // Log full recipient addresses instead of redacting them
+
+	// Tracing
+	TracerProvider trace.TracerProvider // OpenTelemetry tracer provider (defaults to the global provider)
+	RenderTiming   RenderTimingFunc     // Receives the stage timings of each render, e.g. to record metrics (optional)
 
 	// Links
-	SiteLinks        map[string]string // Site links
-	SocialMediaLinks map[string]string // Social media links
+	SiteLinks         map[string]string `env:"SITE_LINKS"`         // Site links
+	SocialMediaLinks  map[string]string `env:"SOCIAL_MEDIA_LINKS"` // Social media links
+	SocialIcons       map[string]string // Icon URLs by social network, overriding the bundled icons
+	SocialIconBaseURL string            `env:"SOCIAL_ICON_BASE_URL"` // URL the bundled icons from SocialIcons() are served at (optional)
+	PreferencesURL    string            `env:"PREFERENCES_URL"`      // Email preference center, linked from the @compliance-footer component
 
 	// Template configuration
-	FuncMap       template.FuncMap // Additional template functions to add to the template engine. These will be merged with the default functions.
-	Sources       []TemplateSource // Template sources
-	Theme         map[string]any   // Theme configuration
-	DefaultLayout string           // Default layout to use for emails (defaults to "base")
+	FuncMap           template.FuncMap      // Additional template functions to add to the template engine. These will be merged with the default functions.
+	Sources           []TemplateSource      // Template sources
+	Theme             map[string]any        // Theme configuration
+	ThemeFile         *ThemeFile            // Optional JSON theme file merged over Theme
+	DevMode           bool                  `env:"DEV_MODE"`     // Start the manager in development mode (see Manager.DevMode; development only)
+	StrictTheme       bool                  `env:"STRICT_THEME"` // Fail rendering when a theme path without a fallback is not found
+	RenderPolicy      RenderPolicy          // Formats every email must have (defaults to RequireHTML)
+	DefaultLayout     string                `env:"DEFAULT_LAYOUT"` // Default layout to use for emails (defaults to "base")
+	Schemas           map[string]DataSchema // Data schemas by email template name, checked before rendering
+	TemplateCacheSize int                   `env:"TEMPLATE_CACHE_SIZE"` // Parsed email templates kept, counting each format, variant and brand (defaults to DefaultTemplateCacheSize)
+}
+
+// ErrInvalidConfig is returned by Config.Validate and New when the configuration is invalid
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Validate checks the fields New cannot fix at send time: the From and ReplyTo addresses, the BaseURL, and
+// the TimeZone.
+// Empty fields are valid. New calls it, so a bad configuration fails at startup instead of on the first send.
+func (c *Config) Validate() error {
+	var problems []string
+
+	for _, field := range []struct{ name, value string }{{"From", c.From}, {"ReplyTo", c.ReplyTo}} {
+		if field.value == "" {
+			continue
+		}
+		if _, err := mail.ParseAddress(field.value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s %q is not a valid address: %v", field.name, field.value, err))
+		}
+	}
+
+	if c.BaseURL != "" {
+		if u, err := url.Parse(c.BaseURL); err != nil {
+			problems = append(problems, fmt.Sprintf("BaseURL %q is not a valid URL: %v", c.BaseURL, err))
+		} else if !u.IsAbs() || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("BaseURL %q must be absolute", c.BaseURL))
+		}
+	}
+
+	if c.TimeZone != "" {
+		if _, err := loadLocation(c.TimeZone); err != nil {
+			problems = append(problems, fmt.Sprintf("TimeZone %q is not a known time zone: %v", c.TimeZone, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidConfig, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Warnings reports fields that are valid but leave the default templates incomplete, such as a footer
+// without a company name. New logs them at warning level.
+func (c *Config) Warnings() []string {
+	var warnings []string
+	if c.CompanyName == "" {
+		warnings = append(warnings, "CompanyName is not set; default footers and the copyright line omit it")
+	}
+	if c.SupportEmail == "" {
+		warnings = append(warnings, "SupportEmail is not set; templates that show the support address render it empty")
+	}
+	if c.Locale != "" && c.DateFormat == "" && c.TimestampFormat == "" {
+		if _, ok := localeDateFormats(c.Locale); !ok {
+			warnings = append(warnings, fmt.Sprintf("Locale %q has no built-in date formats; set DateFormat and TimestampFormat", c.Locale))
+		}
+	}
+	return warnings
 }
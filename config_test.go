@@ -0,0 +1,107 @@
+package mailpen_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      mailpen.Config
+		errContains []string
+	}{
+		{name: "empty", config: mailpen.Config{}},
+		{
+			name: "valid",
+			config: mailpen.Config{
+				From:    "Acme <noreply@acme.com>",
+				ReplyTo: "support@acme.com",
+				BaseURL: "https://acme.com/app",
+			},
+		},
+		{
+			name:        "invalid from",
+			config:      mailpen.Config{From: "noreply"},
+			errContains: []string{`From "noreply" is not a valid address`},
+		},
+		{
+			name:        "unknown time zone",
+			config:      mailpen.Config{TimeZone: "Mars/Olympus_Mons"},
+			errContains: []string{`TimeZone "Mars/Olympus_Mons" is not a known time zone`},
+		},
+		{
+			name:        "invalid reply-to",
+			config:      mailpen.Config{ReplyTo: "support@"},
+			errContains: []string{`ReplyTo "support@" is not a valid address`},
+		},
+		{
+			name:        "unparseable base URL",
+			config:      mailpen.Config{BaseURL: "https://acme.com/%zz"},
+			errContains: []string{`BaseURL "https://acme.com/%zz" is not a valid URL`},
+		},
+		{
+			name:        "relative base URL",
+			config:      mailpen.Config{BaseURL: "acme.com"},
+			errContains: []string{`BaseURL "acme.com" must be absolute`},
+		},
+		{
+			name:   "every problem is reported",
+			config: mailpen.Config{From: "noreply", BaseURL: "/app"},
+			errContains: []string{
+				`From "noreply" is not a valid address`,
+				`BaseURL "/app" must be absolute`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if len(tt.errContains) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, mailpen.ErrInvalidConfig)
+			for _, want := range tt.errContains {
+				assert.ErrorContains(t, err, want)
+			}
+		})
+	}
+}
+
+func TestConfig_Warnings(t *testing.T) {
+	assert.Len(t, (&mailpen.Config{}).Warnings(), 2)
+	assert.Empty(t, (&mailpen.Config{CompanyName: "Acme", SupportEmail: "support@acme.com"}).Warnings())
+
+	complete := mailpen.Config{CompanyName: "Acme", SupportEmail: "support@acme.com", Locale: "tlh"}
+	assert.Equal(t, []string{`Locale "tlh" has no built-in date formats; set DateFormat and TimestampFormat`}, complete.Warnings())
+	complete.DateFormat = "2006-01-02"
+	assert.Empty(t, complete.Warnings())
+}
+
+func TestNew_ValidatesConfig(t *testing.T) {
+	t.Run("invalid config fails", func(t *testing.T) {
+		_, err := mailpen.New(&mockProvider{}, &mailpen.Config{From: "noreply"})
+		assert.ErrorIs(t, err, mailpen.ErrInvalidConfig)
+	})
+
+	t.Run("warnings are logged", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+		_, err := mailpen.New(&mockProvider{}, &mailpen.Config{From: "noreply@acme.com", CompanyName: "Acme"}, mailpen.WithLogger(logger))
+		require.NoError(t, err)
+
+		assert.Contains(t, buf.String(), "level=WARN")
+		assert.Contains(t, buf.String(), "SupportEmail is not set")
+		assert.NotContains(t, buf.String(), "CompanyName is not set")
+	})
+}
@@ -0,0 +1,88 @@
+package mailpen
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDateFormat      = "January 2, 2006"
+	defaultTimestampFormat = "2006-01-02 15:04:05"
+)
+
+// dateFormats holds the CurrentDate and CurrentTimestamp layouts by locale. Go formats month names in English
+// only, so locales in other languages use numeric dates.
+var dateFormats = map[string]struct{ date, timestamp string }{
+	"en":    {"January 2, 2006", "Jan 2, 2006 3:04 PM MST"},
+	"en-us": {"January 2, 2006", "Jan 2, 2006 3:04 PM MST"},
+	"en-ca": {"January 2, 2006", "Jan 2, 2006 3:04 PM MST"},
+	"en-gb": {"2 January 2006", "2 Jan 2006 15:04 MST"},
+	"en-au": {"2 January 2006", "2 Jan 2006 3:04 PM MST"},
+	"en-ie": {"2 January 2006", "2 Jan 2006 15:04 MST"},
+	"en-nz": {"2 January 2006", "2 Jan 2006 3:04 PM MST"},
+	"de":    {"02.01.2006", "02.01.2006 15:04 MST"},
+	"es":    {"02/01/2006", "02/01/2006 15:04 MST"},
+	"fr":    {"02/01/2006", "02/01/2006 15:04 MST"},
+	"it":    {"02/01/2006", "02/01/2006 15:04 MST"},
+	"ja":    {"2006/01/02", "2006/01/02 15:04 MST"},
+	"nl":    {"02-01-2006", "02-01-2006 15:04 MST"},
+	"pt":    {"02/01/2006", "02/01/2006 15:04 MST"},
+	"sv":    {"2006-01-02", "2006-01-02 15:04 MST"},
+	"zh":    {"2006/01/02", "2006/01/02 15:04 MST"},
+}
+
+// locations caches the time zones loaded for Config.TimeZone by name
+var locations sync.Map
+
+// loadLocation loads a time zone by IANA name, caching the result
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// localTime returns t in Config.TimeZone. An empty or unknown time zone leaves t unchanged.
+func (c *Config) localTime(t time.Time) time.Time {
+	if c.TimeZone == "" {
+		return t
+	}
+	loc, err := loadLocation(c.TimeZone)
+	if err != nil {
+		return t
+	}
+	return t.In(loc)
+}
+
+// dateLayouts returns the layouts of CurrentDate and CurrentTimestamp: DateFormat and TimestampFormat when
+// set, then the layouts of Locale, then the defaults
+func (c *Config) dateLayouts() (date, timestamp string) {
+	date, timestamp = defaultDateFormat, defaultTimestampFormat
+	if formats, ok := localeDateFormats(c.Locale); ok {
+		date, timestamp = formats.date, formats.timestamp
+	}
+	if c.DateFormat != "" {
+		date = c.DateFormat
+	}
+	if c.TimestampFormat != "" {
+		timestamp = c.TimestampFormat
+	}
+	return date, timestamp
+}
+
+// localeDateFormats looks up the layouts of a locale such as "en-GB" or "de_DE", falling back to its language
+func localeDateFormats(locale string) (struct{ date, timestamp string }, bool) {
+	locale = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+	if formats, ok := dateFormats[locale]; ok {
+		return formats, true
+	}
+	lang, _, _ := strings.Cut(locale, "-")
+	formats, ok := dateFormats[lang]
+	return formats, ok
+}
@@ -0,0 +1,79 @@
+package mailpen_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+func TestTemplateData_Dates(t *testing.T) {
+	// 23:30 UTC on New Year's Eve is already the next day and year in Tokyo
+	fixed := time.Date(2030, 12, 31, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		config        mailpen.Config
+		wantDate      string
+		wantTimestamp string
+		wantYear      int
+	}{
+		{
+			name:          "defaults",
+			config:        mailpen.Config{TimeZone: "UTC"},
+			wantDate:      "December 31, 2030",
+			wantTimestamp: "2030-12-31 23:30:00",
+			wantYear:      2030,
+		},
+		{
+			name:          "time zone",
+			config:        mailpen.Config{TimeZone: "Asia/Tokyo"},
+			wantDate:      "January 1, 2031",
+			wantTimestamp: "2031-01-01 08:30:00",
+			wantYear:      2031,
+		},
+		{
+			name:          "locale",
+			config:        mailpen.Config{TimeZone: "Europe/Berlin", Locale: "de_DE"},
+			wantDate:      "01.01.2031",
+			wantTimestamp: "01.01.2031 00:30 CET",
+			wantYear:      2031,
+		},
+		{
+			name:          "locale falls back to language",
+			config:        mailpen.Config{TimeZone: "UTC", Locale: "en-ZA"},
+			wantDate:      "December 31, 2030",
+			wantTimestamp: "Dec 31, 2030 11:30 PM UTC",
+			wantYear:      2030,
+		},
+		{
+			name:          "formats override locale",
+			config:        mailpen.Config{TimeZone: "UTC", Locale: "en-GB", DateFormat: "2006-01-02", TimestampFormat: time.RFC3339},
+			wantDate:      "2030-12-31",
+			wantTimestamp: "2030-12-31T23:30:00Z",
+			wantYear:      2030,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.From = "sender@example.com"
+			mp, err := mailpen.New(&mockProvider{}, &tt.config,
+				mailpen.WithClock(mailpen.ClockFunc(func() time.Time { return fixed })))
+			require.NoError(t, err)
+
+			data := mp.NewTemplateData()
+			assert.Equal(t, tt.wantDate, data["CurrentDate"])
+			assert.Equal(t, tt.wantTimestamp, data["CurrentTimestamp"])
+			assert.Equal(t, tt.wantYear, data["CurrentYear"])
+
+			now, ok := data["Now"].(time.Time)
+			require.True(t, ok)
+			assert.True(t, now.Equal(fixed))
+			assert.Equal(t, tt.config.TimeZone, now.Location().String())
+		})
+	}
+}
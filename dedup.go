@@ -0,0 +1,115 @@
+package mailpen
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// ErrDuplicateMessage is returned when an identical message was already sent within the dedup window
+var ErrDuplicateMessage = errors.New("duplicate message within dedup window")
+
+// DedupStore records recently sent messages by key. See the dedup package for implementations.
+type DedupStore interface {
+	// Reserve records a key for the given duration. It reports false if the key is already recorded.
+	Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Release removes a key, so a message that failed to send can be sent again
+	Release(ctx context.Context, key string) error
+}
+
+// Dedup configures duplicate-send protection. A message whose key was reserved within the window is not
+// sent again, which guards against retried jobs and double-clicked buttons.
+type Dedup struct {
+	Store  DedupStore                // Where keys are recorded
+	Window time.Duration             // How long a sent message blocks identical ones
+	Key    func(msg *Message) string // Derives the key of a message (defaults to DedupKey); an empty key skips the check
+}
+
+// WithDedup enables duplicate-send protection
+func WithDedup(d Dedup) Option {
+	return func(m *Mailpen) error {
+		m.dedup = &d
+		return nil
+	}
+}
+
+// DedupKey returns a hash of a message's template, recipients, and data. Messages without a template are keyed
+// by their subject and bodies instead.
+func DedupKey(msg *Message) string {
+	recipients := append(append(append([]string{}, msg.To...), msg.Cc...), msg.Bcc...)
+	for i, addr := range recipients {
+		recipients[i] = NormalizeAddress(addr)
+	}
+	slices.Sort(recipients)
+
+	h := sha256.New()
+	write := func(s string) {
+		fmt.Fprintf(h, "%d:%s;", len(s), s)
+	}
+
+	for _, addr := range recipients {
+		write(addr)
+	}
+
+	if msg.Template != "" {
+		write(msg.Template)
+		write(msg.Layout)
+		data, err := json.Marshal(msg.Data)
+		if err != nil {
+			data = []byte(fmt.Sprint(msg.Data))
+		}
+		write(string(data))
+	} else {
+		write(msg.Subject)
+		write(msg.TextBody)
+		write(msg.HTMLBody)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// reserveDedup reserves the dedup key of a message. It returns the key to release if the send fails, or a
+// permanent ErrDuplicateMessage if the message was already sent within the window.
+func (m *Mailpen) reserveDedup(ctx context.Context, msg *Message) (string, error) {
+	if m.dedup == nil {
+		return "", nil
+	}
+
+	keyFn := m.dedup.Key
+	if keyFn == nil {
+		keyFn = DedupKey
+	}
+
+	key := keyFn(msg)
+	if key == "" {
+		return "", nil
+	}
+
+	ok, err := m.dedup.Store.Reserve(ctx, key, m.dedup.Window)
+	if err != nil {
+		return "", fmt.Errorf("failed to check dedup store: %w", err)
+	}
+	if !ok {
+		m.logger.InfoContext(ctx, "mailpen: skipped duplicate message", m.logAttrs(msg)...)
+		return "", Permanent(ErrDuplicateMessage)
+	}
+
+	return key, nil
+}
+
+// releaseDedup releases a reserved dedup key after a failed send
+func (m *Mailpen) releaseDedup(ctx context.Context, msg *Message, key string) {
+	if key == "" {
+		return
+	}
+
+	if err := m.dedup.Store.Release(context.WithoutCancel(ctx), key); err != nil {
+		m.logger.WarnContext(ctx, "mailpen: failed to release dedup key", append(m.logAttrs(msg), slog.Any("error", err))...)
+	}
+}
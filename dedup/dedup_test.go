@@ -0,0 +1,85 @@
+package dedup_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/dedup"
+)
+
+func TestStores(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	stores := map[string]mailpen.DedupStore{
+		"memory": dedup.NewMemoryStore(),
+		"redis":  dedup.NewRedisStore(client, ""),
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			ok, err := store.Reserve(ctx, "key", time.Minute)
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			ok, err = store.Reserve(ctx, "key", time.Minute)
+			require.NoError(t, err)
+			assert.False(t, ok, "key is already reserved")
+
+			ok, err = store.Reserve(ctx, "other", time.Minute)
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			require.NoError(t, store.Release(ctx, "key"))
+			ok, err = store.Reserve(ctx, "key", time.Minute)
+			require.NoError(t, err)
+			assert.True(t, ok, "released key can be reserved again")
+		})
+	}
+}
+
+func TestMemoryStore_Expiry(t *testing.T) {
+	store := dedup.NewMemoryStore()
+	ctx := context.Background()
+
+	ok, err := store.Reserve(ctx, "key", 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	time.Sleep(20 * time.Millisecond)
+
+	ok, err = store.Reserve(ctx, "key", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok, "expired key can be reserved again")
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestRedisStore_Expiry(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	store := dedup.NewRedisStore(client, "test")
+	ctx := context.Background()
+
+	ok, err := store.Reserve(ctx, "key", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, server.Exists("test:key"))
+
+	server.FastForward(2 * time.Minute)
+
+	ok, err = store.Reserve(ctx, "key", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
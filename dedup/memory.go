@@ -0,0 +1,62 @@
+// Package dedup provides mailpen.DedupStore implementations.
+package dedup
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often MemoryStore removes expired keys
+const sweepInterval = time.Minute
+
+// MemoryStore is an in-memory dedup store for a single process. It is safe for concurrent use.
+type MemoryStore struct {
+	mu        sync.Mutex
+	expires   map[string]time.Time
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore creates an empty in-memory dedup store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{expires: make(map[string]time.Time), now: time.Now}
+}
+
+// Reserve implements mailpen.DedupStore
+func (s *MemoryStore) Reserve(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		for k, exp := range s.expires {
+			if !now.Before(exp) {
+				delete(s.expires, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if exp, ok := s.expires[key]; ok && now.Before(exp) {
+		return false, nil
+	}
+
+	s.expires[key] = now.Add(ttl)
+	return true, nil
+}
+
+// Release implements mailpen.DedupStore
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.expires, key)
+	return nil
+}
+
+// Len returns the number of keys held, including expired keys that have not been swept yet
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.expires)
+}
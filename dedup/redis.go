@@ -0,0 +1,40 @@
+package dedup
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore is a dedup store shared by all processes using the same Redis server
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore creates a Redis-backed dedup store. Keys are stored under prefix (defaults to "mailpen:dedup").
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	if prefix == "" {
+		prefix = "mailpen:dedup"
+	}
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Reserve implements mailpen.DedupStore
+func (s *RedisStore) Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	ok, err := s.client.SetNX(ctx, s.prefix+":"+key, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve dedup key: %w", err)
+	}
+	return ok, nil
+}
+
+// Release implements mailpen.DedupStore
+func (s *RedisStore) Release(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, s.prefix+":"+key).Err(); err != nil {
+		return fmt.Errorf("failed to release dedup key: %w", err)
+	}
+	return nil
+}
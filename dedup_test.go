@@ -0,0 +1,107 @@
+package mailpen_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/dedup"
+)
+
+func TestDedupKey(t *testing.T) {
+	base := func() *mailpen.Message {
+		return mailpen.NewMessage().
+			To("jane@example.com").
+			Template("welcome").
+			WithData(map[string]any{"name": "Jane", "plan": "pro"}).
+			Must()
+	}
+
+	key := mailpen.DedupKey(base())
+	assert.Len(t, key, 64)
+
+	tests := []struct {
+		name   string
+		modify func(msg *mailpen.Message)
+		same   bool
+	}{
+		{name: "identical", modify: func(msg *mailpen.Message) {}, same: true},
+		{name: "recipient case and display name", modify: func(msg *mailpen.Message) { msg.To = []string{"Jane <JANE@example.com>"} }, same: true},
+		{name: "subject ignored for templates", modify: func(msg *mailpen.Message) { msg.Subject = "Other" }, same: true},
+		{name: "different recipient", modify: func(msg *mailpen.Message) { msg.To = []string{"bob@example.com"} }},
+		{name: "different template", modify: func(msg *mailpen.Message) { msg.Template = "reset" }},
+		{name: "different data", modify: func(msg *mailpen.Message) { msg.Data["plan"] = "free" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := base()
+			tt.modify(msg)
+			if tt.same {
+				assert.Equal(t, key, mailpen.DedupKey(msg))
+			} else {
+				assert.NotEqual(t, key, mailpen.DedupKey(msg))
+			}
+		})
+	}
+}
+
+func TestMailpen_Dedup(t *testing.T) {
+	newMessage := func(subject string) *mailpen.Message {
+		msg := mailpen.NewMessage().To("jane@example.com").Subject(subject).Must()
+		msg.TextBody = "Hello"
+		return msg
+	}
+
+	t.Run("duplicate is skipped", func(t *testing.T) {
+		mock := &mockProvider{}
+		mp, err := mailpen.New(mock, &mailpen.Config{From: "sender@example.com"},
+			mailpen.WithDedup(mailpen.Dedup{Store: dedup.NewMemoryStore(), Window: time.Minute}))
+		require.NoError(t, err)
+
+		require.NoError(t, mp.Send(context.Background(), newMessage("Welcome")))
+		err = mp.Send(context.Background(), newMessage("Welcome"))
+		assert.ErrorIs(t, err, mailpen.ErrDuplicateMessage)
+		assert.True(t, mailpen.IsPermanent(err))
+
+		require.NoError(t, mp.Send(context.Background(), newMessage("Different")))
+		assert.Equal(t, 2, mock.sendCalls)
+	})
+
+	t.Run("failed send can be retried", func(t *testing.T) {
+		mock := &mockProvider{}
+		mp, err := mailpen.New(mock, &mailpen.Config{From: "sender@example.com"},
+			mailpen.WithDedup(mailpen.Dedup{Store: dedup.NewMemoryStore(), Window: time.Minute}))
+		require.NoError(t, err)
+
+		mock.err = errors.New("provider down")
+		assert.Error(t, mp.Send(context.Background(), newMessage("Welcome")))
+
+		mock.err = nil
+		require.NoError(t, mp.Send(context.Background(), newMessage("Welcome")))
+	})
+
+	t.Run("empty key skips the check", func(t *testing.T) {
+		mock := &mockProvider{}
+		mp, err := mailpen.New(mock, &mailpen.Config{From: "sender@example.com"}, mailpen.WithDedup(mailpen.Dedup{
+			Store:  dedup.NewMemoryStore(),
+			Window: time.Minute,
+			Key:    func(*mailpen.Message) string { return "" },
+		}))
+		require.NoError(t, err)
+
+		require.NoError(t, mp.Send(context.Background(), newMessage("Welcome")))
+		require.NoError(t, mp.Send(context.Background(), newMessage("Welcome")))
+		assert.Equal(t, 2, mock.sendCalls)
+	})
+
+	t.Run("store is required", func(t *testing.T) {
+		_, err := mailpen.New(&mockProvider{}, &mailpen.Config{Dedup: &mailpen.Dedup{Window: time.Minute}})
+		assert.Error(t, err)
+	})
+}
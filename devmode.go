@@ -0,0 +1,174 @@
+package mailpen
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DevMode switches development mode on or off. In development mode the manager:
+//
+//   - reloads the theme file and templates before every render and skips the template cache, so edits show
+//     up on the next render
+//   - renders template errors into an error page, with the failing template source, which is returned along
+//     with the error so previews can show it; sends still fail
+//   - reports theme paths that resolve to no value as warnings on the rendered email
+//
+// Development mode is meant for previews and local sending; do not enable it in production.
+func (m *Manager) DevMode(enabled bool) {
+	m.devMode.Store(enabled)
+}
+
+// InDevMode reports whether the manager is in development mode
+func (m *Manager) InDevMode() bool {
+	return m.devMode.Load()
+}
+
+// recordMissingTheme wraps a template lookup so the theme function of each template reports paths without
+// a value to missing. The templates must not be shared, which holds in development mode where they are not
+// cached.
+func (m *Manager) recordMissingTheme(lookup func(TemplateFormat) (*template.Template, error), theme map[string]any, missing *[]string) func(TemplateFormat) (*template.Template, error) {
+	current := m.Theme
+	if theme != nil {
+		current = func() map[string]any { return theme }
+	}
+
+	return func(format TemplateFormat) (*template.Template, error) {
+		tmpl, err := lookup(format)
+		if err != nil {
+			return nil, err
+		}
+		return tmpl.Funcs(themeFuncs(current, m.strictTheme, func(path string) {
+			*missing = append(*missing, path)
+		})), nil
+	}
+}
+
+// missingThemeWarnings returns a warning for each distinct missing theme path
+func missingThemeWarnings(paths []string) []string {
+	var warnings []string
+	seen := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		warnings = append(warnings, fmt.Sprintf("theme: no value for %q", p))
+	}
+	return warnings
+}
+
+// errorEmail renders a template error as an email, for development mode
+func (m *Manager) errorEmail(name string, err error) *RenderedEmail {
+	file, line, source := m.errorSource(err)
+
+	var html bytes.Buffer
+	if execErr := errorPage.Execute(&html, map[string]any{
+		"Name":   name,
+		"Error":  err.Error(),
+		"File":   file,
+		"Line":   line,
+		"Source": source,
+	}); execErr != nil {
+		html.Reset()
+		html.WriteString("<pre>" + template.HTMLEscapeString(err.Error()) + "</pre>")
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Template error rendering %s\n\n%v\n", name, err)
+	if len(source) > 0 {
+		fmt.Fprintf(&text, "\n%s:\n", file)
+		for _, l := range source {
+			marker := " "
+			if l.Error {
+				marker = ">"
+			}
+			fmt.Fprintf(&text, "%s %4d | %s\n", marker, l.Number, l.Text)
+		}
+	}
+
+	return &RenderedEmail{
+		HTML:     html.String(),
+		Text:     text.String(),
+		Warnings: []string{"template error: " + err.Error()},
+	}
+}
+
+// sourceLine is a line of template source shown on the error page
+type sourceLine struct {
+	Number int
+	Text   string
+	Error  bool // The line the error points at
+}
+
+// templateErrorLocation matches the template name and line of a parse or execution error, such as
+// "template: layout:base:12:" or "template: welcome:3:14:"
+var templateErrorLocation = regexp.MustCompile(`template: ((?:layout:|component:|partial:)?[^:\s]+):(\d+):`)
+
+// sourceContext is the number of lines shown before and after the failing line
+const sourceContext = 3
+
+// errorSource finds the template file and lines around the location in a template error. It returns no
+// source when the error has no location or the file cannot be read.
+func (m *Manager) errorSource(err error) (string, int, []sourceLine) {
+	match := templateErrorLocation.FindStringSubmatch(err.Error())
+	if match == nil {
+		return "", 0, nil
+	}
+	line, _ := strconv.Atoi(match[2])
+
+	ext := FormatHTML.Extension()
+	if strings.Contains(err.Error(), "text template") {
+		ext = FormatText.Extension()
+	}
+
+	dir, name := EmailsDir, match[1]
+	for prefix, d := range map[string]string{"layout:": LayoutsDir, "component:": ComponentsDir, "partial:": PartialsDir} {
+		if strings.HasPrefix(name, prefix) {
+			dir, name = d, strings.TrimPrefix(name, prefix)
+		}
+	}
+	file := path.Join(dir, name+ext)
+
+	m.mu.RLock()
+	sources := m.sources
+	m.mu.RUnlock()
+
+	for i := len(sources) - 1; i >= 0; i-- {
+		content, readErr := fs.ReadFile(sources[i].FS, file)
+		if readErr != nil {
+			continue
+		}
+
+		lines := strings.Split(string(content), "\n")
+		var source []sourceLine
+		for n := max(line-sourceContext, 1); n <= min(line+sourceContext, len(lines)); n++ {
+			source = append(source, sourceLine{Number: n, Text: lines[n-1], Error: n == line})
+		}
+		return file, line, source
+	}
+
+	return file, line, nil
+}
+
+// errorPage shows a template error in development mode
+var errorPage = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Template error: {{.Name}}</title>
+</head>
+<body style="margin: 0; padding: 24px; font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', sans-serif; background: #fff5f5; color: #1a1a1a;">
+<h1 style="font-size: 20px; color: #c62828; margin: 0 0 16px;">Template error rendering {{.Name}}</h1>
+<pre style="white-space: pre-wrap; font-size: 13px; background: #fff; border: 1px solid #f5c2c2; padding: 12px; margin: 0 0 16px;">{{.Error}}</pre>
+{{if .Source}}<h2 style="font-size: 14px; margin: 0 0 8px;">{{.File}}, line {{.Line}}</h2>
+<pre style="font-size: 13px; background: #fff; border: 1px solid #ddd; padding: 12px 0; margin: 0;">{{range .Source}}<div style="padding: 0 12px;{{if .Error}} background: #ffe0e0;{{end}}">{{printf "%4d" .Number}} | {{.Text}}</div>{{end}}</pre>
+{{end}}<p style="font-size: 12px; color: #666;">Shown because the manager is in development mode.</p>
+</body>
+</html>
+`))
@@ -0,0 +1,155 @@
+package mailpen_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/templates"
+)
+
+// devFS returns templates with a plain layout and a welcome email
+func devFS() fstest.MapFS {
+	return fstest.MapFS{
+		"layouts/plain.html":  {Data: []byte("<html><body>{{template \"content\" .}}</body></html>")},
+		"layouts/plain.txt":   {Data: []byte(`{{template "content" .}}`)},
+		"emails/welcome.html": {Data: []byte(`{{define "content"}}<p>v1</p>{{end}}`)},
+		"emails/welcome.txt":  {Data: []byte(`{{define "content"}}v1{{end}}`)},
+	}
+}
+
+func newDevManager(t *testing.T, fsys fstest.MapFS) *mailpen.Manager {
+	t.Helper()
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources:       []mailpen.TemplateSource{{Name: "dev", FS: fsys}},
+		DefaultLayout: "plain",
+	})
+	require.NoError(t, err)
+	return manager
+}
+
+func TestManager_DevMode(t *testing.T) {
+	fsys := devFS()
+	manager := newDevManager(t, fsys)
+	assert.False(t, manager.InDevMode())
+
+	email, err := manager.RenderEmail("welcome", nil, "")
+	require.NoError(t, err)
+	assert.Contains(t, email.HTML, "v1")
+
+	fsys["emails/welcome.html"] = &fstest.MapFile{Data: []byte(`{{define "content"}}<p>v2</p>{{end}}`)}
+	email, err = manager.RenderEmail("welcome", nil, "")
+	require.NoError(t, err)
+	assert.Contains(t, email.HTML, "v1", "cached outside development mode")
+
+	manager.DevMode(true)
+	assert.True(t, manager.InDevMode())
+	email, err = manager.RenderEmail("welcome", nil, "")
+	require.NoError(t, err)
+	assert.Contains(t, email.HTML, "v2")
+
+	fsys["emails/welcome.html"] = &fstest.MapFile{Data: []byte(`{{define "content"}}<p>v3</p>{{end}}`)}
+	email, err = manager.RenderEmail("welcome", nil, "")
+	require.NoError(t, err)
+	assert.Contains(t, email.HTML, "v3")
+
+	manager.DevMode(false)
+	fsys["emails/welcome.html"] = &fstest.MapFile{Data: []byte(`{{define "content"}}<p>{{len 3}}</p>{{end}}`)}
+	manager.ClearCache()
+	_, err = manager.RenderEmail("welcome", nil, "")
+	assert.Error(t, err, "errors are returned outside development mode")
+}
+
+func TestManager_DevModeErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		html       string
+		wantError  string
+		wantFile   string
+		wantSource string
+	}{
+		{
+			name:       "execution error",
+			html:       "{{define \"content\"}}\n<p>ok</p>\n<p>{{len 3}}</p>\n{{end}}",
+			wantError:  "error calling len",
+			wantFile:   "emails/welcome.html, line 3",
+			wantSource: "   3 | &lt;p&gt;{{len 3}}&lt;/p&gt;",
+		},
+		{
+			name:       "parse error",
+			html:       "{{define \"content\"}}\n<p>{{if}}</p>\n{{end}}",
+			wantError:  "missing value for if",
+			wantFile:   "emails/welcome.html, line 2",
+			wantSource: "   2 | &lt;p&gt;{{if}}&lt;/p&gt;",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := devFS()
+			fsys["emails/welcome.html"] = &fstest.MapFile{Data: []byte(tt.html)}
+			manager := newDevManager(t, fsys)
+			manager.DevMode(true)
+
+			email, err := manager.RenderEmail("welcome", nil, "")
+			require.ErrorContains(t, err, tt.wantError)
+			require.NotNil(t, email)
+			assert.Contains(t, email.HTML, "Template error rendering welcome")
+			assert.Contains(t, email.HTML, tt.wantError)
+			assert.Contains(t, email.HTML, tt.wantFile)
+			assert.Contains(t, email.HTML, tt.wantSource)
+			assert.Contains(t, email.Text, tt.wantError)
+			require.Len(t, email.Warnings, 1)
+			assert.Contains(t, email.Warnings[0], "template error: ")
+		})
+	}
+
+	t.Run("compose", func(t *testing.T) {
+		manager := newDevManager(t, devFS())
+		manager.DevMode(true)
+
+		email, err := manager.Compose(context.Background(), templates.Layout{Components: []templates.Component{nil}}, nil, mailpen.RenderOptions{})
+		require.ErrorContains(t, err, "component 0 is nil")
+		require.NotNil(t, email)
+		assert.Contains(t, email.HTML, "component 0 is nil")
+	})
+}
+
+func TestMailpen_DevModeDoesNotSendErrorPage(t *testing.T) {
+	mock := &mockProvider{}
+	mp, err := mailpen.New(mock, &mailpen.Config{
+		From:          "sender@example.com",
+		DevMode:       true,
+		DefaultLayout: "plain",
+		Sources:       []mailpen.TemplateSource{{Name: "dev", FS: devFS()}},
+	})
+	require.NoError(t, err)
+
+	msg := mailpen.NewMessage().To("recipient@example.com").Template("missing").Must()
+	err = mp.Send(context.Background(), msg)
+	require.ErrorIs(t, err, mailpen.ErrTemplateNotFound)
+	assert.Equal(t, 0, mock.sendCalls)
+}
+
+func TestManager_DevModeMissingTheme(t *testing.T) {
+	fsys := devFS()
+	fsys["emails/welcome.html"] = &fstest.MapFile{Data: []byte(`{{define "content"}}<p style="color: {{theme "colors.brand"}}; background: {{theme "colors.accent" "#fff"}}; border-color: {{theme "colors.brand"}};">{{theme "colors.primary"}}</p>{{end}}`)}
+	manager := newDevManager(t, fsys)
+
+	email, err := manager.RenderEmail("welcome", nil, "")
+	require.NoError(t, err)
+	assert.Empty(t, email.Warnings)
+
+	manager.DevMode(true)
+	email, err = manager.RenderEmail("welcome", nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{`theme: no value for "colors.brand"`}, email.Warnings)
+
+	email, err = manager.RenderEmailWithTheme("welcome", nil, "", "empty", map[string]any{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{`theme: no value for "colors.brand"`, `theme: no value for "colors.primary"`}, email.Warnings)
+}
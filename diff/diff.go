@@ -0,0 +1,189 @@
+// Package diff renders the emails of two template versions, such as two source sets or two git revisions, and
+// reports how their HTML and text output differs, so reviewers can see exactly what a template change alters.
+package diff
+
+import (
+	"context"
+	"sort"
+
+	"github.com/patrickward/mailpen"
+)
+
+// Status is how an email changed between the old and new templates
+type Status string
+
+const (
+	Unchanged Status = "unchanged"
+	Changed   Status = "changed"
+	Added     Status = "added"   // Only in the new templates
+	Removed   Status = "removed" // Only in the old templates
+)
+
+// Option configures a comparison
+type Option func(c *comparer)
+
+// WithEmails limits the comparison to the named emails (defaults to every email of either version)
+func WithEmails(names ...string) Option {
+	return func(c *comparer) {
+		c.emails = names
+	}
+}
+
+// WithLayout sets the layout emails are rendered into (defaults to each manager's default layout)
+func WithLayout(layout string) Option {
+	return func(c *comparer) {
+		c.layout = layout
+	}
+}
+
+// WithSampleData sets a function that returns the data an email is rendered with, the same for both versions
+// (defaults to the email's sample data file, read from the new templates and then the old, see
+// Manager.SampleData)
+func WithSampleData(fn func(ctx context.Context, email string) (map[string]any, error)) Option {
+	return func(c *comparer) {
+		c.sampleData = fn
+	}
+}
+
+type comparer struct {
+	emails     []string
+	layout     string
+	sampleData func(ctx context.Context, email string) (map[string]any, error)
+}
+
+// Email is the difference in one email's rendered output
+type Email struct {
+	Name   string
+	Status Status
+	HTML   []Line // Line diff of the HTML output
+	Text   []Line // Line diff of the text output
+	OldErr error  // Error rendering the old version
+	NewErr error  // Error rendering the new version
+}
+
+// Failed reports whether either version of the email failed to render
+func (e Email) Failed() bool {
+	return e.OldErr != nil || e.NewErr != nil
+}
+
+// Report is the result of a comparison
+type Report struct {
+	Emails []Email
+}
+
+// Changed returns the emails that were changed, added, removed, or failed to render
+func (r *Report) Changed() []Email {
+	var changed []Email
+	for _, e := range r.Emails {
+		if e.Status != Unchanged || e.Failed() {
+			changed = append(changed, e)
+		}
+	}
+	return changed
+}
+
+// Compare renders every email with both managers and diffs the output. Render errors are recorded on the
+// email rather than stopping the comparison; the returned error is for failures to list emails.
+func Compare(ctx context.Context, old, new *mailpen.Manager, opts ...Option) (*Report, error) {
+	c := &comparer{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.sampleData == nil {
+		c.sampleData = func(_ context.Context, email string) (map[string]any, error) {
+			data, err := new.SampleData(email)
+			if data != nil || err != nil {
+				return data, err
+			}
+			return old.SampleData(email)
+		}
+	}
+
+	oldEmails, err := old.Emails()
+	if err != nil {
+		return nil, err
+	}
+	newEmails, err := new.Emails()
+	if err != nil {
+		return nil, err
+	}
+
+	inOld, inNew := set(oldEmails), set(newEmails)
+	names := c.emails
+	if len(names) == 0 {
+		names = union(oldEmails, newEmails)
+	}
+
+	report := &Report{}
+	for _, name := range names {
+		email := Email{Name: name}
+		switch {
+		case !inOld[name] && !inNew[name]:
+			continue
+		case !inOld[name]:
+			email.Status = Added
+		case !inNew[name]:
+			email.Status = Removed
+		}
+
+		data, err := c.sampleData(ctx, name)
+		if err != nil {
+			email.OldErr, email.NewErr = err, err
+			report.Emails = append(report.Emails, email)
+			continue
+		}
+
+		var oldHTML, oldText, newHTML, newText string
+		if inOld[name] {
+			oldHTML, oldText, email.OldErr = c.render(ctx, old, name, data)
+		}
+		if inNew[name] {
+			newHTML, newText, email.NewErr = c.render(ctx, new, name, data)
+		}
+
+		email.HTML = Lines(oldHTML, newHTML)
+		email.Text = Lines(oldText, newText)
+		if email.Status == "" {
+			email.Status = Unchanged
+			if Differs(email.HTML) || Differs(email.Text) {
+				email.Status = Changed
+			}
+		}
+
+		report.Emails = append(report.Emails, email)
+	}
+
+	return report, nil
+}
+
+// render renders an email with a manager
+func (c *comparer) render(ctx context.Context, manager *mailpen.Manager, name string, data map[string]any) (string, string, error) {
+	email, err := manager.Render(ctx, name, data, mailpen.RenderOptions{Layout: c.layout})
+	if err != nil {
+		return "", "", err
+	}
+	return email.HTML, email.Text, nil
+}
+
+// set returns a set of names
+func set(names []string) map[string]bool {
+	s := make(map[string]bool, len(names))
+	for _, name := range names {
+		s[name] = true
+	}
+	return s
+}
+
+// union returns the sorted names in a or b
+func union(a, b []string) []string {
+	s := set(a)
+	for _, name := range b {
+		s[name] = true
+	}
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
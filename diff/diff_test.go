@@ -0,0 +1,133 @@
+package diff_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/diff"
+)
+
+// templates returns a file system with a base layout and the given email templates
+func templates(emails map[string]string) fstest.MapFS {
+	fsys := fstest.MapFS{
+		"layouts/base.html": {Data: []byte("<html>\n<body>\n{{template \"content\" .}}\n</body>\n</html>")},
+		"layouts/base.txt":  {Data: []byte(`{{template "content" .}}`)},
+	}
+	for name, content := range emails {
+		fsys[name] = &fstest.MapFile{Data: []byte(content)}
+	}
+	return fsys
+}
+
+func newManager(t *testing.T, fsys fstest.MapFS) *mailpen.Manager {
+	t.Helper()
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{{Name: "test", FS: fsys}},
+	})
+	require.NoError(t, err)
+	return manager
+}
+
+func compare(t *testing.T, opts ...diff.Option) *diff.Report {
+	t.Helper()
+	old := newManager(t, templates(map[string]string{
+		"emails/welcome.html":   `{{define "content"}}<p>Hello, {{.Name}}!</p>{{end}}`,
+		"emails/welcome.txt":    `{{define "content"}}Hello, {{.Name}}!{{end}}`,
+		"emails/receipt.html":   `{{define "content"}}<p>Thanks</p>{{end}}`,
+		"emails/receipt.txt":    `{{define "content"}}Thanks{{end}}`,
+		"emails/old-promo.html": `{{define "content"}}<p>Sale</p>{{end}}`,
+		"emails/old-promo.txt":  `{{define "content"}}Sale{{end}}`,
+	}))
+	new := newManager(t, templates(map[string]string{
+		"emails/welcome.html":        `{{define "content"}}<p>Welcome, {{.Name}}!</p>{{end}}`,
+		"emails/welcome.txt":         `{{define "content"}}Hello, {{.Name}}!{{end}}`,
+		"emails/welcome.sample.json": `{"Name": "Sam"}`,
+		"emails/receipt.html":        `{{define "content"}}<p>Thanks</p>{{end}}`,
+		"emails/receipt.txt":         `{{define "content"}}Thanks{{end}}`,
+		"emails/reset.html":          `{{define "content"}}<p>{{len 3}}</p>{{end}}`,
+		"emails/reset.txt":           `{{define "content"}}Reset{{end}}`,
+	}))
+
+	report, err := diff.Compare(context.Background(), old, new, opts...)
+	require.NoError(t, err)
+	return report
+}
+
+func TestCompare(t *testing.T) {
+	report := compare(t)
+
+	statuses := map[string]diff.Status{}
+	for _, email := range report.Emails {
+		statuses[email.Name] = email.Status
+	}
+	assert.Equal(t, map[string]diff.Status{
+		"old-promo": diff.Removed,
+		"receipt":   diff.Unchanged,
+		"reset":     diff.Added,
+		"welcome":   diff.Changed,
+	}, statuses)
+
+	changed := report.Changed()
+	require.Len(t, changed, 3)
+
+	welcome := changed[2]
+	assert.Equal(t, "welcome", welcome.Name)
+	assert.Contains(t, welcome.HTML, diff.Line{Op: diff.Delete, Text: "<p>Hello, Sam!</p>"})
+	assert.Contains(t, welcome.HTML, diff.Line{Op: diff.Insert, Text: "<p>Welcome, Sam!</p>"})
+	assert.False(t, diff.Differs(welcome.Text))
+
+	reset := changed[1]
+	assert.True(t, reset.Failed())
+	assert.NoError(t, reset.OldErr)
+	assert.Error(t, reset.NewErr)
+}
+
+func TestCompare_Options(t *testing.T) {
+	report := compare(t,
+		diff.WithEmails("welcome", "missing"),
+		diff.WithSampleData(func(_ context.Context, email string) (map[string]any, error) {
+			return map[string]any{"Name": "Ada"}, nil
+		}),
+	)
+	require.Len(t, report.Emails, 1)
+	assert.Contains(t, report.Emails[0].HTML, diff.Line{Op: diff.Insert, Text: "<p>Welcome, Ada!</p>"})
+
+	report = compare(t, diff.WithEmails("welcome"), diff.WithSampleData(func(context.Context, string) (map[string]any, error) {
+		return nil, errors.New("no data")
+	}))
+	require.Len(t, report.Emails, 1)
+	assert.EqualError(t, report.Emails[0].NewErr, "no data")
+}
+
+func TestReport_WriteText(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, compare(t).WriteText(&buf))
+	out := buf.String()
+
+	assert.Contains(t, out, "Removed: old-promo\n")
+	assert.Contains(t, out, "Added: reset\n")
+	assert.Contains(t, out, "Error: reset (new):")
+	assert.Contains(t, out, "--- a/emails/welcome.html\n+++ b/emails/welcome.html\n")
+	assert.Contains(t, out, "-<p>Hello, Sam!</p>\n+<p>Welcome, Sam!</p>\n")
+	assert.NotContains(t, out, "welcome.txt")
+	assert.NotContains(t, out, "receipt")
+}
+
+func TestReport_WriteHTML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, compare(t).WriteHTML(&buf, "Template changes"))
+	out := buf.String()
+
+	assert.Contains(t, out, "<title>Template changes</title>")
+	assert.Contains(t, out, "3 of 4 email(s) changed.")
+	assert.Contains(t, out, `<div class="del">-&lt;p&gt;Hello, Sam!&lt;/p&gt;</div>`)
+	assert.Contains(t, out, `<div class="ins">&#43;&lt;p&gt;Welcome, Sam!&lt;/p&gt;</div>`)
+	assert.Contains(t, out, "New version failed to render:")
+}
@@ -0,0 +1,72 @@
+package diff
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os/exec"
+	"strings"
+	"testing/fstest"
+)
+
+// GitFS returns the contents of dir at a git revision, such as "main" or "HEAD~1", as an in-memory file
+// system. dir must be inside a git work tree; the git executable must be on the PATH.
+func GitFS(ctx context.Context, dir, rev string) (fs.FS, error) {
+	prefix, err := git(ctx, dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, err
+	}
+	top, err := git(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+
+	// <rev>:<prefix> is the tree of dir itself, so the archive paths are relative to dir. git archive refuses
+	// to run from a subdirectory when given a tree, so it runs from the top of the work tree.
+	archive, err := git(ctx, strings.TrimSpace(top), "archive", "--format=tar", rev+":"+strings.TrimSpace(prefix))
+	if err != nil {
+		return nil, err
+	}
+
+	fsys := fstest.MapFS{}
+	reader := tar.NewReader(strings.NewReader(archive))
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read git archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read git archive: %w", err)
+		}
+		fsys[strings.TrimPrefix(header.Name, "./")] = &fstest.MapFile{Data: content, Mode: 0o644, ModTime: header.ModTime}
+	}
+
+	return fsys, nil
+}
+
+// git runs a git command in dir and returns its output
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,50 @@
+package diff_test
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen/diff"
+)
+
+func TestGitFS(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	repo := t.TempDir()
+	gitRun := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(repo, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	gitRun("init", "-q")
+	write("templates/emails/welcome.html", "v1")
+	gitRun("add", ".")
+	gitRun("commit", "-q", "-m", "v1")
+	write("templates/emails/welcome.html", "v2")
+
+	fsys, err := diff.GitFS(context.Background(), filepath.Join(repo, "templates"), "HEAD")
+	require.NoError(t, err)
+	content, err := fs.ReadFile(fsys, "emails/welcome.html")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(content))
+
+	_, err = diff.GitFS(context.Background(), filepath.Join(repo, "templates"), "no-such-rev")
+	assert.ErrorContains(t, err, "git archive")
+}
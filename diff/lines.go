@@ -0,0 +1,197 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op is the kind of change of a line
+type Op int
+
+const (
+	Equal  Op = iota // Line is in both versions
+	Delete           // Line is only in the old version
+	Insert           // Line is only in the new version
+)
+
+// Line is a line of a line diff
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Lines returns the line diff that turns a into b, using a longest common subsequence of lines
+func Lines(a, b string) []Line {
+	old, new := splitLines(a), splitLines(b)
+
+	// Common prefix and suffix are kept out of the table
+	prefix := 0
+	for prefix < len(old) && prefix < len(new) && old[prefix] == new[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(new)-prefix && old[len(old)-1-suffix] == new[len(new)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]Line, 0, len(old)+len(new))
+	for _, text := range old[:prefix] {
+		lines = append(lines, Line{Op: Equal, Text: text})
+	}
+	lines = append(lines, lcs(old[prefix:len(old)-suffix], new[prefix:len(new)-suffix])...)
+	for _, text := range old[len(old)-suffix:] {
+		lines = append(lines, Line{Op: Equal, Text: text})
+	}
+
+	return lines
+}
+
+// lcs diffs a and b with the classic dynamic programming table
+func lcs(a, b []string) []Line {
+	n, m := len(a), len(b)
+	table := make([][]int, n+1)
+	for i := range table {
+		table[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+
+	var lines []Line
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, Line{Op: Equal, Text: a[i]})
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			lines = append(lines, Line{Op: Delete, Text: a[i]})
+			i++
+		default:
+			lines = append(lines, Line{Op: Insert, Text: b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		lines = append(lines, Line{Op: Delete, Text: a[i]})
+	}
+	for ; j < m; j++ {
+		lines = append(lines, Line{Op: Insert, Text: b[j]})
+	}
+
+	return lines
+}
+
+// splitLines splits text into lines without their line endings
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(text, "\r\n", "\n"), "\n"), "\n")
+}
+
+// Differs reports whether a line diff has any insertions or deletions
+func Differs(lines []Line) bool {
+	for _, line := range lines {
+		if line.Op != Equal {
+			return true
+		}
+	}
+	return false
+}
+
+// Hunk is a run of changed lines with surrounding context
+type Hunk struct {
+	OldStart, OldLines int // 1-based start and length in the old version
+	NewStart, NewLines int // 1-based start and length in the new version
+	Lines              []Line
+}
+
+// Hunks groups a line diff into hunks with up to context unchanged lines around each change
+func Hunks(lines []Line, context int) []Hunk {
+	// Ranges of lines to show, merged when their context overlaps
+	var ranges [][2]int
+	for i, line := range lines {
+		if line.Op == Equal {
+			continue
+		}
+		start, end := max(i-context, 0), min(i+context+1, len(lines))
+		if n := len(ranges); n > 0 && start <= ranges[n-1][1] {
+			ranges[n-1][1] = end
+		} else {
+			ranges = append(ranges, [2]int{start, end})
+		}
+	}
+
+	hunks := make([]Hunk, 0, len(ranges))
+	oldLine, newLine, next := 0, 0, 0
+	for _, r := range ranges {
+		for _, line := range lines[next:r[0]] {
+			oldLine, newLine = advance(line.Op, oldLine, newLine)
+		}
+
+		hunk := Hunk{OldStart: oldLine, NewStart: newLine, Lines: lines[r[0]:r[1]]}
+		for _, line := range hunk.Lines {
+			if line.Op != Insert {
+				hunk.OldLines++
+			}
+			if line.Op != Delete {
+				hunk.NewLines++
+			}
+			oldLine, newLine = advance(line.Op, oldLine, newLine)
+		}
+		// Empty ranges start at the line before them, as in diff -u
+		if hunk.OldLines > 0 {
+			hunk.OldStart++
+		}
+		if hunk.NewLines > 0 {
+			hunk.NewStart++
+		}
+
+		hunks = append(hunks, hunk)
+		next = r[1]
+	}
+
+	return hunks
+}
+
+// advance counts a line in the old and new line numbers
+func advance(op Op, oldLine, newLine int) (int, int) {
+	if op != Insert {
+		oldLine++
+	}
+	if op != Delete {
+		newLine++
+	}
+	return oldLine, newLine
+}
+
+// Unified formats a line diff in the unified diff format
+func Unified(oldName, newName string, lines []Line, context int) string {
+	hunks := Hunks(lines, context)
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+	for _, hunk := range hunks {
+		fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", hunk.OldStart, hunk.OldLines, hunk.NewStart, hunk.NewLines)
+		for _, line := range hunk.Lines {
+			b.WriteString(prefixes[line.Op])
+			b.WriteString(line.Text)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// prefixes are the unified diff line prefixes by op
+var prefixes = map[Op]string{Equal: " ", Delete: "-", Insert: "+"}
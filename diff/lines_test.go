@@ -0,0 +1,108 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/patrickward/mailpen/diff"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []diff.Line
+	}{
+		{name: "both empty", a: "", b: "", want: []diff.Line{}},
+		{
+			name: "equal",
+			a:    "one\ntwo\n",
+			b:    "one\r\ntwo",
+			want: []diff.Line{{Op: diff.Equal, Text: "one"}, {Op: diff.Equal, Text: "two"}},
+		},
+		{
+			name: "insert",
+			a:    "",
+			b:    "one",
+			want: []diff.Line{{Op: diff.Insert, Text: "one"}},
+		},
+		{
+			name: "change in the middle",
+			a:    "one\ntwo\nthree",
+			b:    "one\n2\nthree",
+			want: []diff.Line{
+				{Op: diff.Equal, Text: "one"},
+				{Op: diff.Delete, Text: "two"},
+				{Op: diff.Insert, Text: "2"},
+				{Op: diff.Equal, Text: "three"},
+			},
+		},
+		{
+			name: "moved line",
+			a:    "a\nb\nc\nd",
+			b:    "b\nc\na\nd",
+			want: []diff.Line{
+				{Op: diff.Delete, Text: "a"},
+				{Op: diff.Equal, Text: "b"},
+				{Op: diff.Equal, Text: "c"},
+				{Op: diff.Insert, Text: "a"},
+				{Op: diff.Equal, Text: "d"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, diff.Lines(tt.a, tt.b))
+		})
+	}
+}
+
+func TestDiffers(t *testing.T) {
+	assert.False(t, diff.Differs(diff.Lines("a\nb", "a\nb")))
+	assert.True(t, diff.Differs(diff.Lines("a\nb", "a\nc")))
+}
+
+func TestUnified(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13"
+
+	want := `--- old
++++ new
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++13
+`
+	assert.Equal(t, want, diff.Unified("old", "new", diff.Lines(a, b), 3))
+	assert.Empty(t, diff.Unified("old", "new", diff.Lines(a, a), 3))
+}
+
+func TestHunks_Merged(t *testing.T) {
+	hunks := diff.Hunks(diff.Lines("1\n2\n3\n4\n5", "one\n2\n3\n4\nfive"), 1)
+	assert.Len(t, hunks, 2)
+
+	hunks = diff.Hunks(diff.Lines("1\n2\n3\n4\n5", "one\n2\n3\n4\nfive"), 2)
+	if assert.Len(t, hunks, 1) {
+		assert.Equal(t, 1, hunks[0].OldStart)
+		assert.Equal(t, 5, hunks[0].OldLines)
+		assert.Equal(t, 5, hunks[0].NewLines)
+	}
+}
+
+func TestHunks_AddedFile(t *testing.T) {
+	hunks := diff.Hunks(diff.Lines("", "a\nb"), 3)
+	if assert.Len(t, hunks, 1) {
+		assert.Equal(t, diff.Hunk{OldStart: 0, OldLines: 0, NewStart: 1, NewLines: 2, Lines: hunks[0].Lines}, hunks[0])
+	}
+}
@@ -0,0 +1,111 @@
+package diff
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// DefaultContext is the number of unchanged lines shown around each change in reports
+const DefaultContext = 3
+
+// WriteText writes the changed emails as unified diffs of their HTML and text output, with a line per email
+// that was added, removed, or failed to render
+func (r *Report) WriteText(w io.Writer) error {
+	var b strings.Builder
+	for _, email := range r.Changed() {
+		switch email.Status {
+		case Added:
+			fmt.Fprintf(&b, "Added: %s\n", email.Name)
+		case Removed:
+			fmt.Fprintf(&b, "Removed: %s\n", email.Name)
+		}
+		if email.OldErr != nil {
+			fmt.Fprintf(&b, "Error: %s (old): %v\n", email.Name, email.OldErr)
+		}
+		if email.NewErr != nil {
+			fmt.Fprintf(&b, "Error: %s (new): %v\n", email.Name, email.NewErr)
+		}
+		b.WriteString(Unified("a/emails/"+email.Name+".html", "b/emails/"+email.Name+".html", email.HTML, DefaultContext))
+		b.WriteString(Unified("a/emails/"+email.Name+".txt", "b/emails/"+email.Name+".txt", email.Text, DefaultContext))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteHTML writes a standalone HTML page with the diff of each changed email
+func (r *Report) WriteHTML(w io.Writer, title string) error {
+	type section struct {
+		Name  string
+		Hunks []Hunk
+	}
+	type entry struct {
+		Email    Email
+		Sections []section
+	}
+
+	var entries []entry
+	for _, email := range r.Changed() {
+		e := entry{Email: email}
+		if hunks := Hunks(email.HTML, DefaultContext); len(hunks) > 0 {
+			e.Sections = append(e.Sections, section{Name: "HTML", Hunks: hunks})
+		}
+		if hunks := Hunks(email.Text, DefaultContext); len(hunks) > 0 {
+			e.Sections = append(e.Sections, section{Name: "Text", Hunks: hunks})
+		}
+		entries = append(entries, e)
+	}
+
+	return reportPage.Execute(w, map[string]any{
+		"Title":   title,
+		"Total":   len(r.Emails),
+		"Entries": entries,
+	})
+}
+
+// reportPage is the HTML diff report
+var reportPage = template.Must(template.New("report").Funcs(template.FuncMap{
+	"prefix": func(op Op) string { return prefixes[op] },
+	"class": func(op Op) string {
+		switch op {
+		case Insert:
+			return "ins"
+		case Delete:
+			return "del"
+		}
+		return ""
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; font-size: 14px; margin: 24px; }
+h2 { font-size: 16px; margin-top: 32px; }
+h3 { font-size: 13px; color: #555; }
+.status { font-size: 12px; font-weight: normal; padding: 2px 6px; border-radius: 3px; background: #eee; }
+.error { color: #c62828; }
+pre { margin: 0; font-size: 12px; border: 1px solid #ddd; overflow-x: auto; }
+.hunk { background: #f1f8ff; color: #555; }
+.ins { background: #e6ffed; }
+.del { background: #ffeef0; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{len .Entries}} of {{.Total}} email(s) changed.</p>
+{{range .Entries}}
+<h2>{{.Email.Name}} <span class="status">{{.Email.Status}}</span></h2>
+{{with .Email.OldErr}}<p class="error">Old version failed to render: {{.}}</p>{{end}}
+{{with .Email.NewErr}}<p class="error">New version failed to render: {{.}}</p>{{end}}
+{{range .Sections}}
+<h3>{{.Name}}</h3>
+<pre>{{range .Hunks}}<div class="hunk">@@ -{{.OldStart}},{{.OldLines}} +{{.NewStart}},{{.NewLines}} @@</div>{{range .Lines}}<div class="{{class .Op}}">{{prefix .Op}}{{.Text}}</div>{{end}}{{end}}</pre>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,283 @@
+// Package digest buffers notifications per recipient and sends them as one combined email, either on a
+// schedule or once enough have accumulated (for example, "5 new comments").
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/patrickward/mailpen"
+)
+
+// Sender sends a message. *mailpen.Mailpen implements Sender.
+type Sender interface {
+	Send(ctx context.Context, msg *mailpen.Message) error
+}
+
+// Item is one buffered notification
+type Item struct {
+	Recipient string         // Recipient address
+	Topic     string         // Groups related notifications in the digest, such as "comments" (optional)
+	Data      map[string]any // Notification details for the digest template
+	CreatedAt time.Time      // When the notification was added
+}
+
+// Store buffers items until they are sent. Implementations must be safe for concurrent use.
+type Store interface {
+	// Add buffers an item and returns how many items are buffered for its recipient
+	Add(ctx context.Context, item Item) (int, error)
+	// Take removes and returns the buffered items of a recipient, oldest first
+	Take(ctx context.Context, recipient string) ([]Item, error)
+	// Recipients returns the recipients with buffered items
+	Recipients(ctx context.Context) ([]string, error)
+}
+
+// Engine collects notifications and flushes them as digest emails rendered with a digest template. The
+// template receives .Items (the notifications, oldest first), .Count, .Topics (items grouped by topic),
+// and .Recipient.
+type Engine struct {
+	sender    Sender
+	store     Store
+	template  string
+	layout    string
+	threshold int
+	interval  time.Duration
+	subject   func(recipient string, items []Item) string
+	build     func(msg *mailpen.Message, items []Item)
+	now       func() time.Time
+}
+
+// Option configures an Engine
+type Option func(e *Engine)
+
+// WithStore sets the store that buffers items (defaults to an in-memory store)
+func WithStore(store Store) Option {
+	return func(e *Engine) {
+		e.store = store
+	}
+}
+
+// WithLayout sets the layout of digest emails
+func WithLayout(layout string) Option {
+	return func(e *Engine) {
+		e.layout = layout
+	}
+}
+
+// WithThreshold sends a recipient's digest as soon as that many items are buffered (zero disables the threshold)
+func WithThreshold(n int) Option {
+	return func(e *Engine) {
+		e.threshold = n
+	}
+}
+
+// WithInterval sets how often Run flushes every buffered digest (defaults to one hour)
+func WithInterval(d time.Duration) Option {
+	return func(e *Engine) {
+		e.interval = d
+	}
+}
+
+// WithSubject sets the function that builds the subject of a digest (defaults to "N new notifications")
+func WithSubject(fn func(recipient string, items []Item) string) Option {
+	return func(e *Engine) {
+		e.subject = fn
+	}
+}
+
+// WithMessage sets a function that customizes each digest message before it is sent, for example to add tags
+func WithMessage(fn func(msg *mailpen.Message, items []Item)) Option {
+	return func(e *Engine) {
+		e.build = fn
+	}
+}
+
+// WithClock sets the function used to timestamp items, mainly for testing
+func WithClock(now func() time.Time) Option {
+	return func(e *Engine) {
+		e.now = now
+	}
+}
+
+// New creates a digest engine that sends digests with sender using the named email template
+func New(sender Sender, template string, opts ...Option) *Engine {
+	e := &Engine{
+		sender:   sender,
+		template: template,
+		interval: time.Hour,
+		subject:  defaultSubject,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if e.store == nil {
+		e.store = NewMemoryStore()
+	}
+	return e
+}
+
+// Add buffers a notification for a recipient. When the recipient reaches the threshold, their digest is sent
+// right away.
+func (e *Engine) Add(ctx context.Context, recipient, topic string, data map[string]any) error {
+	recipient = mailpen.NormalizeAddress(recipient)
+	if recipient == "" {
+		return errors.New("recipient is required")
+	}
+
+	n, err := e.store.Add(ctx, Item{Recipient: recipient, Topic: topic, Data: data, CreatedAt: e.now()})
+	if err != nil {
+		return fmt.Errorf("failed to buffer digest item: %w", err)
+	}
+
+	if e.threshold > 0 && n >= e.threshold {
+		return e.FlushRecipient(ctx, recipient)
+	}
+	return nil
+}
+
+// FlushRecipient sends the buffered items of one recipient as a digest. Items are returned to the store when
+// the send fails, so they are included in the next flush.
+func (e *Engine) FlushRecipient(ctx context.Context, recipient string) error {
+	items, err := e.store.Take(ctx, recipient)
+	if err != nil {
+		return fmt.Errorf("failed to take digest items: %w", err)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	if err := e.sender.Send(ctx, e.message(recipient, items)); err != nil {
+		for _, item := range items {
+			if _, addErr := e.store.Add(context.WithoutCancel(ctx), item); addErr != nil {
+				return errors.Join(err, fmt.Errorf("failed to return digest items: %w", addErr))
+			}
+		}
+		return fmt.Errorf("failed to send digest to %s: %w", recipient, err)
+	}
+	return nil
+}
+
+// Flush sends the digest of every recipient with buffered items
+func (e *Engine) Flush(ctx context.Context) error {
+	recipients, err := e.store.Recipients(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list digest recipients: %w", err)
+	}
+
+	var errs []error
+	for _, recipient := range recipients {
+		errs = append(errs, e.FlushRecipient(ctx, recipient))
+	}
+	return errors.Join(errs...)
+}
+
+// Run flushes all digests every interval until ctx is done. Flush errors are passed to onError, if set.
+func (e *Engine) Run(ctx context.Context, onError func(error)) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.Flush(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// message builds the digest message for a recipient
+func (e *Engine) message(recipient string, items []Item) *mailpen.Message {
+	topics := make(map[string][]Item)
+	for _, item := range items {
+		topics[item.Topic] = append(topics[item.Topic], item)
+	}
+
+	msg := &mailpen.Message{
+		To:       []string{recipient},
+		Subject:  e.subject(recipient, items),
+		Template: e.template,
+		Layout:   e.layout,
+		Data: map[string]any{
+			"Items":     items,
+			"Count":     len(items),
+			"Topics":    topics,
+			"Recipient": recipient,
+		},
+		Tags: []string{"digest"},
+	}
+	if e.build != nil {
+		e.build(msg, items)
+	}
+	return msg
+}
+
+// defaultSubject names the number of notifications, and the topic when there is only one
+func defaultSubject(_ string, items []Item) string {
+	topic := items[0].Topic
+	for _, item := range items[1:] {
+		if item.Topic != topic {
+			topic = ""
+			break
+		}
+	}
+	if topic == "" {
+		topic = "notifications"
+	}
+	if len(items) == 1 {
+		return fmt.Sprintf("1 new %s", topic)
+	}
+	return fmt.Sprintf("%d new %s", len(items), topic)
+}
+
+// MemoryStore is an in-memory Store. Items are lost when the process exits.
+type MemoryStore struct {
+	mu    sync.Mutex
+	items map[string][]Item
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{items: make(map[string][]Item)}
+}
+
+// Add implements Store
+func (s *MemoryStore) Add(_ context.Context, item Item) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := append(s.items[item.Recipient], item)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].CreatedAt.Before(items[j].CreatedAt) })
+	s.items[item.Recipient] = items
+	return len(items), nil
+}
+
+// Take implements Store
+func (s *MemoryStore) Take(_ context.Context, recipient string) ([]Item, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := s.items[recipient]
+	delete(s.items, recipient)
+	return items, nil
+}
+
+// Recipients implements Store
+func (s *MemoryStore) Recipients(context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recipients := make([]string, 0, len(s.items))
+	for recipient := range s.items {
+		recipients = append(recipients, recipient)
+	}
+	sort.Strings(recipients)
+	return recipients, nil
+}
@@ -0,0 +1,132 @@
+package digest_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/digest"
+)
+
+// recordingSender records sent messages and fails while err is set
+type recordingSender struct {
+	mu   sync.Mutex
+	sent []*mailpen.Message
+	err  error
+}
+
+func (s *recordingSender) Send(_ context.Context, msg *mailpen.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func (s *recordingSender) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sent)
+}
+
+func TestEngine_Threshold(t *testing.T) {
+	sender := &recordingSender{}
+	engine := digest.New(sender, "digest", digest.WithThreshold(3), digest.WithLayout("base"))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, engine.Add(ctx, "Jane@Example.com", "comments", map[string]any{"N": i}))
+	}
+	assert.Zero(t, sender.count())
+
+	require.NoError(t, engine.Add(ctx, "jane@example.com", "comments", map[string]any{"N": 2}))
+	require.Equal(t, 1, sender.count())
+
+	msg := sender.sent[0]
+	assert.Equal(t, []string{"jane@example.com"}, msg.To)
+	assert.Equal(t, "3 new comments", msg.Subject)
+	assert.Equal(t, "digest", msg.Template)
+	assert.Equal(t, "base", msg.Layout)
+	assert.Equal(t, 3, msg.Data["Count"])
+	items := msg.Data["Items"].([]digest.Item)
+	assert.Equal(t, 0, items[0].Data["N"])
+	assert.Len(t, msg.Data["Topics"].(map[string][]digest.Item)["comments"], 3)
+
+	assert.Error(t, engine.Add(ctx, "", "comments", nil))
+}
+
+func TestEngine_Flush(t *testing.T) {
+	sender := &recordingSender{}
+	engine := digest.New(sender, "digest",
+		digest.WithMessage(func(msg *mailpen.Message, _ []digest.Item) { msg.Tags = append(msg.Tags, "weekly") }))
+	ctx := context.Background()
+
+	require.NoError(t, engine.Add(ctx, "a@example.com", "comments", nil))
+	require.NoError(t, engine.Add(ctx, "a@example.com", "likes", nil))
+	require.NoError(t, engine.Add(ctx, "b@example.com", "likes", nil))
+
+	sender.err = errors.New("provider down")
+	assert.Error(t, engine.Flush(ctx))
+	assert.Zero(t, sender.count())
+
+	sender.err = nil
+	require.NoError(t, engine.Flush(ctx), "failed items are kept for the next flush")
+	require.Equal(t, 2, sender.count())
+	assert.Equal(t, "2 new notifications", sender.sent[0].Subject)
+	assert.Equal(t, "1 new likes", sender.sent[1].Subject)
+	assert.Equal(t, []string{"digest", "weekly"}, sender.sent[0].Tags)
+
+	require.NoError(t, engine.Flush(ctx))
+	assert.Equal(t, 2, sender.count(), "nothing left to send")
+}
+
+func TestEngine_Run(t *testing.T) {
+	sender := &recordingSender{}
+	engine := digest.New(sender, "digest", digest.WithInterval(5*time.Millisecond))
+	require.NoError(t, engine.Add(context.Background(), "a@example.com", "", nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		engine.Run(ctx, nil)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return sender.count() == 1 }, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+}
+
+type provider struct{ last *mailpen.Message }
+
+func (p *provider) Send(_ context.Context, msg *mailpen.Message) error { p.last = msg; return nil }
+func (p *provider) Name() string                                       { return "test" }
+func (p *provider) Validate(*mailpen.Message) error                    { return nil }
+func (p *provider) Capabilities() mailpen.Capabilities                 { return mailpen.Capabilities{} }
+
+func TestEngine_RendersWithMailpen(t *testing.T) {
+	p := &provider{}
+	mp, err := mailpen.New(p, &mailpen.Config{
+		From: "sender@example.com",
+		Sources: []mailpen.TemplateSource{{Name: "app", FS: fstest.MapFS{
+			"emails/digest.html": {Data: []byte(`{{define "content"}}{{.Count}} updates:{{range .Items}} [{{.Data.Title}}]{{end}}{{end}}`)},
+		}}},
+	})
+	require.NoError(t, err)
+
+	engine := digest.New(mp, "digest", digest.WithThreshold(2))
+	require.NoError(t, engine.Add(context.Background(), "a@example.com", "comments", map[string]any{"Title": "First"}))
+	require.NoError(t, engine.Add(context.Background(), "a@example.com", "comments", map[string]any{"Title": "Second"}))
+
+	require.NotNil(t, p.last)
+	assert.Contains(t, p.last.HTMLBody, "2 updates: [First] [Second]")
+}
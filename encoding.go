@@ -29,6 +29,9 @@ const (
 
 	// TypeTextPlain represents the MIME type for plain text content.
 	TypeTextPlain ContentType = "text/plain"
+
+	// TypeTextCalendar represents the MIME type for iCalendar content.
+	TypeTextCalendar ContentType = "text/calendar"
 )
 
 // String returns the string representation of the ContentType and implements the Stringer interface.
@@ -0,0 +1,52 @@
+package mailpen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNoQueue is returned by Enqueue when no queue has been configured
+var ErrNoQueue = errors.New("no queue configured")
+
+// Queue accepts messages for background delivery. See the queue package for an implementation.
+type Queue interface {
+	Enqueue(ctx context.Context, msg *Message) error
+}
+
+// WithQueue sets the queue used by Enqueue
+func WithQueue(q Queue) Option {
+	return func(m *Mailpen) error {
+		m.queue = q
+		return nil
+	}
+}
+
+// Enqueue validates a message and hands it to the configured queue for background delivery. The queue's
+// workers deliver it with Send. A Queued event is published for each recipient once the queue accepts it, with
+// ctx, so when the queue writes in the caller's transaction (see sqloutbox.WithTx) the event is published
+// before that transaction commits and is not withdrawn if it rolls back.
+func (m *Mailpen) Enqueue(ctx context.Context, msg *Message) error {
+	if m.closed.Load() {
+		return ErrShutdown
+	}
+
+	if m.queue == nil {
+		return ErrNoQueue
+	}
+
+	if err := m.provider.Validate(msg); err != nil {
+		return fmt.Errorf("invalid message: %w", err)
+	}
+
+	if msg.ID == "" {
+		msg.ID = newMessageID()
+	}
+
+	if err := m.queue.Enqueue(ctx, msg); err != nil {
+		return err
+	}
+
+	m.publishMessage(ctx, EventQueued, msg)
+	return nil
+}
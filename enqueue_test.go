@@ -0,0 +1,44 @@
+package mailpen_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/queue"
+)
+
+func TestMailpen_Enqueue(t *testing.T) {
+	mock := &mockProvider{}
+
+	t.Run("without queue", func(t *testing.T) {
+		mp, err := mailpen.New(mock, &mailpen.Config{From: "sender@example.com"})
+		require.NoError(t, err)
+
+		msg := mailpen.NewMessage().To("recipient@example.com").Subject("Test").Must()
+		assert.ErrorIs(t, mp.Enqueue(context.Background(), msg), mailpen.ErrNoQueue)
+	})
+
+	t.Run("with queue", func(t *testing.T) {
+		store := queue.NewMemoryStore(10)
+		q := queue.New(store)
+		mp, err := mailpen.New(mock, &mailpen.Config{From: "sender@example.com"}, mailpen.WithQueue(q))
+		require.NoError(t, err)
+
+		assert.Error(t, mp.Enqueue(context.Background(), &mailpen.Message{Subject: "No recipients"}))
+		assert.Equal(t, 0, store.Len())
+
+		msg := mailpen.NewMessage().To("recipient@example.com").Subject("Test").Must()
+		require.NoError(t, mp.Enqueue(context.Background(), msg))
+		assert.Equal(t, 1, store.Len())
+
+		require.NoError(t, q.Start(context.Background(), mp))
+		assert.Eventually(t, func() bool { return store.Len() == 0 }, time.Second, 5*time.Millisecond)
+		require.NoError(t, q.Stop(context.Background()))
+		assert.Equal(t, "sender@example.com", mock.lastMessage.From)
+	})
+}
@@ -0,0 +1,151 @@
+package mailpen
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix is the prefix of the environment variables read by LoadConfigFromEnv
+const EnvPrefix = "MAILPEN_"
+
+// LoadConfigFromEnv returns a Config read from MAILPEN_* environment variables, such as MAILPEN_FROM,
+// MAILPEN_BASE_URL, and MAILPEN_COMPANY_NAME. The variable of each field is named by its env struct tag.
+// Fields that are not plain values, such as providers and template sources, are left for the caller to set.
+func LoadConfigFromEnv() (*Config, error) {
+	config := &Config{}
+	if err := LoadEnv(EnvPrefix, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// LoadEnv sets the fields of the struct dst points to from environment variables. A field tagged
+// `env:"NAME"` is read from prefix+NAME, and a nested struct tagged `env:"NAME"` reads its own fields from
+// prefix+NAME+"_". Unset variables leave fields unchanged, so defaults can be set on dst beforehand.
+//
+// Strings, bools, integers, floats, and durations (e.g. "30s") are supported, as are string slices
+// ("a,b") and string maps ("key=value,key=value"). Every variable that fails to parse is reported, wrapped
+// with ErrInvalidConfig.
+func LoadEnv(prefix string, dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("env: destination must be a pointer to a struct, got %T", dst)
+	}
+
+	var problems []string
+	loadEnvStruct(prefix, v.Elem(), &problems)
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidConfig, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// loadEnvStruct sets the tagged fields of a struct value, recording variables that fail to parse
+func loadEnvStruct(prefix string, v reflect.Value, problems *[]string) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, ok := field.Tag.Lookup("env")
+		if !ok || name == "-" || !field.IsExported() {
+			continue
+		}
+
+		key := prefix + name
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+			loadEnvStruct(key+"_", v.Field(i), problems)
+			continue
+		}
+
+		value, ok := os.LookupEnv(key)
+		if !ok {
+			continue
+		}
+		if err := setEnvField(v.Field(i), value); err != nil {
+			*problems = append(*problems, fmt.Sprintf("%s: %v", key, err))
+		}
+	}
+}
+
+// setEnvField parses an environment variable into a field
+func setEnvField(field reflect.Value, value string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(value), 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(value), 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported field type %s", field.Type())
+		}
+		items := splitEnvList(value)
+		slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+		for i, item := range items {
+			slice.Index(i).SetString(item)
+		}
+		field.Set(slice)
+	case reflect.Map:
+		if field.Type().Key().Kind() != reflect.String || field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported field type %s", field.Type())
+		}
+		m := reflect.MakeMap(field.Type())
+		for _, item := range splitEnvList(value) {
+			key, val, ok := strings.Cut(item, "=")
+			if !ok {
+				return errors.New("expected key=value pairs")
+			}
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(key)).Convert(field.Type().Key()),
+				reflect.ValueOf(strings.TrimSpace(val)).Convert(field.Type().Elem()))
+		}
+		field.Set(m)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+// splitEnvList splits a comma-separated list, dropping empty items
+func splitEnvList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,77 @@
+package mailpen_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Setenv("MAILPEN_FROM", "Acme <noreply@acme.com>")
+	t.Setenv("MAILPEN_BASE_URL", "https://acme.com")
+	t.Setenv("MAILPEN_COMPANY_NAME", "Acme")
+	t.Setenv("MAILPEN_SEND_TIMEOUT", "15s")
+	t.Setenv("MAILPEN_RATE_LIMIT_PER_SECOND", "2.5")
+	t.Setenv("MAILPEN_RATE_LIMIT_BURST", "5")
+	t.Setenv("MAILPEN_ALWAYS_BCC", "archive@acme.com, legal@acme.com")
+	t.Setenv("MAILPEN_ENVIRONMENT_WATERMARK", "true")
+	t.Setenv("MAILPEN_SITE_LINKS", "help=https://acme.com/help, terms=https://acme.com/terms")
+
+	config, err := mailpen.LoadConfigFromEnv()
+	require.NoError(t, err)
+
+	assert.Equal(t, "Acme <noreply@acme.com>", config.From)
+	assert.Equal(t, "https://acme.com", config.BaseURL)
+	assert.Equal(t, "Acme", config.CompanyName)
+	assert.Equal(t, 15*time.Second, config.SendTimeout)
+	assert.Equal(t, mailpen.RateLimit{PerSecond: 2.5, Burst: 5}, config.RateLimit)
+	assert.Equal(t, []string{"archive@acme.com", "legal@acme.com"}, config.AlwaysBcc)
+	assert.True(t, config.EnvironmentWatermark)
+	assert.Equal(t, map[string]string{"help": "https://acme.com/help", "terms": "https://acme.com/terms"}, config.SiteLinks)
+	assert.Empty(t, config.ReplyTo)
+}
+
+func TestLoadEnv(t *testing.T) {
+	type settings struct {
+		Name     string        `env:"NAME"`
+		Count    int           `env:"COUNT"`
+		Enabled  bool          `env:"ENABLED"`
+		Interval time.Duration `env:"INTERVAL"`
+		Ignored  string
+	}
+
+	t.Run("unset variables keep defaults", func(t *testing.T) {
+		t.Setenv("APP_NAME", "mailer")
+
+		s := settings{Count: 3, Ignored: "kept"}
+		require.NoError(t, mailpen.LoadEnv("APP_", &s))
+		assert.Equal(t, settings{Name: "mailer", Count: 3, Ignored: "kept"}, s)
+	})
+
+	t.Run("every invalid variable is reported", func(t *testing.T) {
+		t.Setenv("APP_COUNT", "many")
+		t.Setenv("APP_ENABLED", "maybe")
+		t.Setenv("APP_INTERVAL", "5")
+
+		err := mailpen.LoadEnv("APP_", &settings{})
+		assert.ErrorIs(t, err, mailpen.ErrInvalidConfig)
+		assert.ErrorContains(t, err, "APP_COUNT")
+		assert.ErrorContains(t, err, "APP_ENABLED")
+		assert.ErrorContains(t, err, "APP_INTERVAL")
+	})
+
+	t.Run("invalid map", func(t *testing.T) {
+		t.Setenv("MAILPEN_SITE_LINKS", "help")
+
+		_, err := mailpen.LoadConfigFromEnv()
+		assert.ErrorContains(t, err, "MAILPEN_SITE_LINKS: expected key=value pairs")
+	})
+
+	t.Run("destination must be a struct pointer", func(t *testing.T) {
+		assert.Error(t, mailpen.LoadEnv("APP_", settings{}))
+	})
+}
@@ -0,0 +1,76 @@
+package mailpen
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// EnvironmentHeader is the header that names the environment a message was sent from
+const EnvironmentHeader = "X-Environment"
+
+// environmentMarker marks an HTML body that already carries the environment watermark
+const environmentMarker = "<!-- mailpen:environment -->"
+
+// bodyTagPattern matches the opening body tag of an HTML document
+var bodyTagPattern = regexp.MustCompile(`(?i)<body[^>]*>`)
+
+// IsProduction reports whether an environment name refers to production. An empty name counts as production,
+// so messages are only tagged when an environment is configured.
+func IsProduction(env string) bool {
+	switch strings.ToLower(strings.TrimSpace(env)) {
+	case "", "production", "prod":
+		return true
+	default:
+		return false
+	}
+}
+
+// EnvironmentTagger is a MessageProcessor that marks messages sent outside production: it prefixes the
+// subject (for example, "[STAGING] "), sets the X-Environment header, and optionally adds a banner to the
+// top of the HTML body. It is applied automatically when Config.Environment names a non-production
+// environment. Tagging is idempotent, so a message that is sent again is not tagged twice.
+type EnvironmentTagger struct {
+	Environment string // Environment name (e.g. "staging")
+	Watermark   bool   // Add a banner to the top of the HTML body
+}
+
+// ProcessMessage implements MessageProcessor
+func (t EnvironmentTagger) ProcessMessage(_ context.Context, msg *Message) error {
+	env := strings.TrimSpace(t.Environment)
+	if IsProduction(env) {
+		return nil
+	}
+
+	prefix := "[" + strings.ToUpper(env) + "] "
+	if !strings.HasPrefix(msg.Subject, prefix) {
+		msg.Subject = prefix + msg.Subject
+	}
+
+	if msg.Headers == nil {
+		msg.Headers = make(map[string]string)
+	}
+	msg.Headers[EnvironmentHeader] = env
+
+	if t.Watermark && msg.HTMLBody != "" && !strings.Contains(msg.HTMLBody, environmentMarker) {
+		msg.HTMLBody = watermark(msg.HTMLBody, env)
+	}
+
+	return nil
+}
+
+// watermark inserts an environment banner at the start of the HTML body
+func watermark(body, env string) string {
+	banner := environmentMarker + fmt.Sprintf(
+		`<div style="background-color: #ffd54f; color: #333333; font-family: Arial, sans-serif; font-size: 14px; `+
+			`font-weight: bold; padding: 8px; text-align: center;">%s: this email was sent from a non-production environment</div>`,
+		html.EscapeString(strings.ToUpper(env)),
+	)
+
+	if loc := bodyTagPattern.FindStringIndex(body); loc != nil {
+		return body[:loc[1]] + banner + body[loc[1]:]
+	}
+	return banner + body
+}
@@ -0,0 +1,94 @@
+package mailpen_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+func TestIsProduction(t *testing.T) {
+	for env, want := range map[string]bool{"": true, "production": true, " Prod ": true, "staging": false, "dev": false} {
+		assert.Equal(t, want, mailpen.IsProduction(env), env)
+	}
+}
+
+func TestEnvironmentTagger(t *testing.T) {
+	tests := []struct {
+		name        string
+		tagger      mailpen.EnvironmentTagger
+		html        string
+		wantSubject string
+		wantHeader  string
+		wantHTML    string
+	}{
+		{
+			name:        "production is untouched",
+			tagger:      mailpen.EnvironmentTagger{Environment: "production", Watermark: true},
+			html:        "<p>Hi</p>",
+			wantSubject: "Welcome",
+			wantHTML:    "<p>Hi</p>",
+		},
+		{
+			name:        "staging",
+			tagger:      mailpen.EnvironmentTagger{Environment: "staging"},
+			html:        "<p>Hi</p>",
+			wantSubject: "[STAGING] Welcome",
+			wantHeader:  "staging",
+			wantHTML:    "<p>Hi</p>",
+		},
+		{
+			name:        "watermark after body tag",
+			tagger:      mailpen.EnvironmentTagger{Environment: "dev", Watermark: true},
+			html:        `<html><body class="x"><p>Hi</p></body></html>`,
+			wantSubject: "[DEV] Welcome",
+			wantHeader:  "dev",
+			wantHTML:    `<html><body class="x"><!-- mailpen:environment --><div`,
+		},
+		{
+			name:        "watermark without body tag",
+			tagger:      mailpen.EnvironmentTagger{Environment: "dev", Watermark: true},
+			html:        "<p>Hi</p>",
+			wantSubject: "[DEV] Welcome",
+			wantHeader:  "dev",
+			wantHTML:    "<!-- mailpen:environment --><div",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &mailpen.Message{Subject: "Welcome", HTMLBody: tt.html}
+
+			// Processing twice must not tag twice
+			require.NoError(t, tt.tagger.ProcessMessage(context.Background(), msg))
+			require.NoError(t, tt.tagger.ProcessMessage(context.Background(), msg))
+
+			assert.Equal(t, tt.wantSubject, msg.Subject)
+			assert.Equal(t, tt.wantHeader, msg.Headers[mailpen.EnvironmentHeader])
+			assert.True(t, strings.HasPrefix(msg.HTMLBody, tt.wantHTML), msg.HTMLBody)
+			assert.LessOrEqual(t, strings.Count(msg.HTMLBody, "non-production environment"), 1)
+		})
+	}
+}
+
+func TestMailpen_Environment(t *testing.T) {
+	mock := &mockProvider{}
+	mp, err := mailpen.New(mock, &mailpen.Config{
+		From:                 "sender@example.com",
+		Environment:          "staging",
+		EnvironmentWatermark: true,
+	})
+	require.NoError(t, err)
+
+	msg := mailpen.NewMessage().To("a@example.com").Subject("Welcome").Must()
+	msg.HTMLBody = "<body><p>Hi</p></body>"
+	require.NoError(t, mp.Send(context.Background(), msg))
+
+	assert.Equal(t, "[STAGING] Welcome", mock.lastMessage.Subject)
+	assert.Equal(t, "staging", mock.lastMessage.Headers["X-Environment"])
+	assert.Contains(t, mock.lastMessage.HTMLBody, "STAGING: this email was sent from a non-production environment")
+}
@@ -0,0 +1,154 @@
+package mailpen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// EventType identifies the kind of delivery event
+type EventType string
+
+const (
+	EventQueued     EventType = "queued"     // The message was accepted by the queue (before a caller's transaction commits)
+	EventSent       EventType = "sent"       // The provider accepted the message
+	EventDelivered  EventType = "delivered"  // The receiving server accepted the message
+	EventBounced    EventType = "bounced"    // The message could not be delivered
+	EventComplained EventType = "complained" // The recipient marked the message as spam
+	EventOpened     EventType = "opened"     // The recipient opened the message
+	EventClicked    EventType = "clicked"    // The recipient clicked a link in the message
+)
+
+// Event is a normalized delivery event for a single recipient. Queued and Sent events are published by
+// Mailpen itself; the others come from provider webhooks (see the events package).
+type Event struct {
+	Type      EventType
+	Provider  string            // Provider that reported the event (e.g. "ses", "sendgrid")
+	MessageID string            // Message ID for Queued and Sent events, the provider's message ID otherwise
+	Recipient string            // Recipient address the event applies to
+	Timestamp time.Time         // When the event occurred
+	Permanent bool              // For bounces, whether the failure is permanent (a hard bounce)
+	Reason    string            // Bounce or complaint details reported by the provider
+	URL       string            // For clicks, the link that was clicked
+	UserAgent string            // For opens and clicks, the recipient's user agent
+	IP        string            // For opens and clicks, the recipient's IP address
+	Metadata  map[string]string // Message metadata, or custom values the provider echoed back (tags, custom args)
+	Raw       json.RawMessage   // The provider's original payload for the event
+}
+
+// EventHandler is called for each published event
+type EventHandler func(ctx context.Context, e Event) error
+
+// subscription is a registered event handler
+type subscription struct {
+	handler EventHandler
+	types   []EventType
+}
+
+// eventBus holds the event subscriptions of a Mailpen instance
+type eventBus struct {
+	mu   sync.RWMutex
+	subs []*subscription
+}
+
+// Subscribe registers a handler for events of the given types, or for all events when no types are given.
+// It returns a function that removes the subscription. Handlers run synchronously in the order they were added.
+func (m *Mailpen) Subscribe(handler EventHandler, types ...EventType) (unsubscribe func()) {
+	sub := &subscription{handler: handler, types: types}
+
+	m.events.mu.Lock()
+	m.events.subs = append(m.events.subs, sub)
+	m.events.mu.Unlock()
+
+	return func() {
+		m.events.mu.Lock()
+		defer m.events.mu.Unlock()
+		m.events.subs = slices.DeleteFunc(m.events.subs, func(s *subscription) bool { return s == sub })
+	}
+}
+
+// Publish delivers an event to the matching subscribers and returns their joined errors. Permanent bounces
+// and complaints also add the recipient to the suppression list, if one is configured. Publish has the
+// signature of an events.Handler, so webhook handlers can feed provider events into Mailpen:
+//
+//	ses, err := events.SES(mp.Publish, events.WithTopicARNs(topicARN))
+func (m *Mailpen) Publish(ctx context.Context, e Event) error {
+	var errs []error
+	if err := m.suppressFromEvent(ctx, e); err != nil {
+		errs = append(errs, err)
+	}
+
+	m.events.mu.RLock()
+	subs := slices.Clone(m.events.subs)
+	m.events.mu.RUnlock()
+
+	for _, sub := range subs {
+		if len(sub.types) > 0 && !slices.Contains(sub.types, e.Type) {
+			continue
+		}
+		if err := sub.handler(ctx, e); err != nil {
+			errs = append(errs, fmt.Errorf("%s event handler: %w", e.Type, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// suppressFromEvent adds the recipient of a hard bounce or complaint to the suppression list
+func (m *Mailpen) suppressFromEvent(ctx context.Context, e Event) error {
+	if m.suppressions == nil || e.Recipient == "" {
+		return nil
+	}
+
+	var reason SuppressionReason
+	switch {
+	case e.Type == EventBounced && e.Permanent:
+		reason = SuppressionBounce
+	case e.Type == EventComplained:
+		reason = SuppressionComplaint
+	default:
+		return nil
+	}
+
+	err := m.suppressions.Suppress(ctx, Suppression{Address: NormalizeAddress(e.Recipient), Reason: reason, CreatedAt: m.clock.Now()})
+	if err != nil {
+		return fmt.Errorf("failed to suppress recipient: %w", err)
+	}
+	return nil
+}
+
+// publishMessage publishes an event of the given type for each recipient of a message. Handler errors are
+// logged rather than returned, since the message has already been handed off.
+func (m *Mailpen) publishMessage(ctx context.Context, typ EventType, msg *Message) {
+	m.events.mu.RLock()
+	empty := len(m.events.subs) == 0
+	m.events.mu.RUnlock()
+	if empty {
+		return
+	}
+
+	now := m.clock.Now()
+	for _, list := range [][]string{msg.To, msg.Cc, msg.Bcc} {
+		for _, addr := range list {
+			err := m.Publish(ctx, Event{
+				Type:      typ,
+				Provider:  m.providerName(msg),
+				MessageID: msg.ID,
+				Recipient: addr,
+				Timestamp: now,
+				Metadata:  msg.Metadata,
+			})
+			if err != nil {
+				m.logger.WarnContext(ctx, "mailpen: event handler failed", append(m.logAttrs(msg),
+					slog.String("event", string(typ)),
+					slog.Any("error", err),
+				)...)
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package mailpen_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/queue"
+	"github.com/patrickward/mailpen/suppression"
+)
+
+func TestMailpen_Subscribe(t *testing.T) {
+	mp, err := mailpen.New(&mockProvider{}, &mailpen.Config{From: "sender@example.com"})
+	require.NoError(t, err)
+
+	var all, bounces []mailpen.Event
+	mp.Subscribe(func(_ context.Context, e mailpen.Event) error {
+		all = append(all, e)
+		return nil
+	})
+	unsubscribe := mp.Subscribe(func(_ context.Context, e mailpen.Event) error {
+		bounces = append(bounces, e)
+		return nil
+	}, mailpen.EventBounced)
+
+	ctx := context.Background()
+	require.NoError(t, mp.Publish(ctx, mailpen.Event{Type: mailpen.EventDelivered, Recipient: "a@example.com"}))
+	require.NoError(t, mp.Publish(ctx, mailpen.Event{Type: mailpen.EventBounced, Recipient: "b@example.com"}))
+
+	unsubscribe()
+	require.NoError(t, mp.Publish(ctx, mailpen.Event{Type: mailpen.EventBounced, Recipient: "c@example.com"}))
+
+	assert.Len(t, all, 3)
+	require.Len(t, bounces, 1)
+	assert.Equal(t, "b@example.com", bounces[0].Recipient)
+}
+
+func TestMailpen_PublishErrors(t *testing.T) {
+	mp, err := mailpen.New(&mockProvider{}, &mailpen.Config{From: "sender@example.com"})
+	require.NoError(t, err)
+
+	errHandler := errors.New("handler failed")
+	var called bool
+	mp.Subscribe(func(context.Context, mailpen.Event) error { return errHandler })
+	mp.Subscribe(func(context.Context, mailpen.Event) error {
+		called = true
+		return nil
+	})
+
+	err = mp.Publish(context.Background(), mailpen.Event{Type: mailpen.EventOpened})
+	assert.ErrorIs(t, err, errHandler)
+	assert.True(t, called, "later handlers still run after an error")
+}
+
+func TestMailpen_SentEvents(t *testing.T) {
+	mock := &mockProvider{}
+	mp, err := mailpen.New(mock, &mailpen.Config{From: "sender@example.com"})
+	require.NoError(t, err)
+
+	var got []mailpen.Event
+	mp.Subscribe(func(_ context.Context, e mailpen.Event) error {
+		got = append(got, e)
+		return errors.New("ignored")
+	})
+
+	msg := mailpen.NewMessage().
+		To("a@example.com").
+		Cc("b@example.com").
+		Subject("Test").
+		Metadata("user_id", "42").
+		Must()
+	msg.TextBody = "Hello"
+
+	require.NoError(t, mp.Send(context.Background(), msg))
+	require.Len(t, got, 2)
+	for i, recipient := range []string{"a@example.com", "b@example.com"} {
+		assert.Equal(t, mailpen.EventSent, got[i].Type)
+		assert.Equal(t, "mock", got[i].Provider)
+		assert.Equal(t, msg.ID, got[i].MessageID)
+		assert.Equal(t, recipient, got[i].Recipient)
+		assert.Equal(t, "42", got[i].Metadata["user_id"])
+		assert.False(t, got[i].Timestamp.IsZero())
+	}
+
+	got = nil
+	mock.err = errors.New("provider down")
+	assert.Error(t, mp.Send(context.Background(), msg))
+	assert.Empty(t, got, "failed sends publish no events")
+}
+
+func TestMailpen_QueuedEvents(t *testing.T) {
+	q := queue.New(queue.NewMemoryStore(10))
+	mp, err := mailpen.New(&mockProvider{}, &mailpen.Config{From: "sender@example.com"}, mailpen.WithQueue(q))
+	require.NoError(t, err)
+
+	var got []mailpen.Event
+	mp.Subscribe(func(_ context.Context, e mailpen.Event) error {
+		got = append(got, e)
+		return nil
+	}, mailpen.EventQueued)
+
+	msg := mailpen.NewMessage().To("a@example.com").Subject("Test").Must()
+	require.NoError(t, mp.Enqueue(context.Background(), msg))
+
+	require.Len(t, got, 1)
+	assert.Equal(t, mailpen.EventQueued, got[0].Type)
+	assert.NotEmpty(t, msg.ID)
+	assert.Equal(t, msg.ID, got[0].MessageID)
+}
+
+func TestMailpen_PublishSuppresses(t *testing.T) {
+	tests := []struct {
+		name       string
+		event      mailpen.Event
+		suppressed bool
+	}{
+		{
+			name:       "hard bounce",
+			event:      mailpen.Event{Type: mailpen.EventBounced, Permanent: true, Recipient: "Jane <Jane@example.com>"},
+			suppressed: true,
+		},
+		{
+			name:  "soft bounce",
+			event: mailpen.Event{Type: mailpen.EventBounced, Recipient: "jane@example.com"},
+		},
+		{
+			name:       "complaint",
+			event:      mailpen.Event{Type: mailpen.EventComplained, Recipient: "jane@example.com"},
+			suppressed: true,
+		},
+		{
+			name:  "delivery",
+			event: mailpen.Event{Type: mailpen.EventDelivered, Recipient: "jane@example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := suppression.NewMemoryStore()
+			mp, err := mailpen.New(&mockProvider{}, &mailpen.Config{From: "sender@example.com"}, mailpen.WithSuppressionStore(store))
+			require.NoError(t, err)
+
+			require.NoError(t, mp.Publish(context.Background(), tt.event))
+
+			suppressed, err := store.Suppressed(context.Background(), []string{"jane@example.com"})
+			require.NoError(t, err)
+			assert.Equal(t, tt.suppressed, len(suppressed) == 1)
+		})
+	}
+}
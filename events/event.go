@@ -0,0 +1,27 @@
+// Package events receives delivery webhooks from email providers and normalizes them into provider-neutral
+// events, so application code can react to deliveries, bounces, and complaints regardless of provider.
+package events
+
+import (
+	"context"
+
+	"github.com/patrickward/mailpen"
+)
+
+// Event is a normalized delivery event for a single recipient
+type Event = mailpen.Event
+
+// Type identifies the kind of event
+type Type = mailpen.EventType
+
+const (
+	Delivered  = mailpen.EventDelivered  // The receiving server accepted the message
+	Bounced    = mailpen.EventBounced    // The message could not be delivered
+	Complained = mailpen.EventComplained // The recipient marked the message as spam
+	Opened     = mailpen.EventOpened     // The recipient opened the message
+	Clicked    = mailpen.EventClicked    // The recipient clicked a link in the message
+)
+
+// Handler is called for each event parsed from a webhook. Returning an error responds with a server error,
+// so the provider retries the webhook later. Mailpen.Publish is a Handler.
+type Handler func(ctx context.Context, e Event) error
@@ -0,0 +1,228 @@
+package events
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// mailgunWebhook is a Mailgun webhook payload
+type mailgunWebhook struct {
+	Signature struct {
+		Timestamp string `json:"timestamp"`
+		Token     string `json:"token"`
+		Signature string `json:"signature"`
+	} `json:"signature"`
+	EventData json.RawMessage `json:"event-data"`
+}
+
+// mailgunEvent is the event-data of a Mailgun webhook
+type mailgunEvent struct {
+	Event     string  `json:"event"`
+	Severity  string  `json:"severity"`
+	Recipient string  `json:"recipient"`
+	Timestamp float64 `json:"timestamp"`
+	URL       string  `json:"url"`
+	IP        string  `json:"ip"`
+	Reason    string  `json:"reason"`
+	Message   struct {
+		Headers struct {
+			MessageID string `json:"message-id"`
+		} `json:"headers"`
+	} `json:"message"`
+	DeliveryStatus struct {
+		Description string `json:"description"`
+		Message     string `json:"message"`
+	} `json:"delivery-status"`
+	ClientInfo struct {
+		UserAgent string `json:"user-agent"`
+	} `json:"client-info"`
+	UserVariables map[string]any `json:"user-variables"`
+}
+
+// mailgunTokenLimit is the number of recently used webhook tokens a Mailgun handler remembers
+const mailgunTokenLimit = 10000
+
+// Mailgun returns a handler for Mailgun webhooks. Requests are verified with the account's HTTP webhook
+// signing key, which must not be empty. Tokens of verified requests are remembered, so a captured request
+// cannot be replayed while its timestamp is within the tolerance.
+func Mailgun(signingKey string, handler Handler, opts ...Option) (http.Handler, error) {
+	o := newOptions(opts)
+	if signingKey == "" && !o.skipVerify {
+		return nil, errors.New("signing key is required")
+	}
+
+	tokens := newTokenCache(mailgunTokenLimit, 2*o.tolerance, o.now)
+
+	return &webhook{
+		opts:    o,
+		handler: handler,
+		parse: func(r *http.Request, body []byte) ([]Event, error) {
+			var payload mailgunWebhook
+			if err := json.Unmarshal(body, &payload); err != nil {
+				return nil, fmt.Errorf("failed to decode Mailgun webhook: %w", err)
+			}
+
+			if !o.skipVerify {
+				sig := payload.Signature
+				if err := verifyMailgun(o, signingKey, sig.Timestamp, sig.Token, sig.Signature); err != nil {
+					return nil, err
+				}
+				if !tokens.add(sig.Token) {
+					return nil, fmt.Errorf("%w: token already used", ErrVerification)
+				}
+			}
+
+			return parseMailgun(payload.EventData)
+		},
+		failed: func(body []byte) {
+			var payload mailgunWebhook
+			if json.Unmarshal(body, &payload) == nil {
+				tokens.remove(payload.Signature.Token)
+			}
+		},
+	}, nil
+}
+
+// VerifyMailgun checks a Mailgun webhook signature: the HMAC-SHA256 of timestamp and token, keyed with the
+// account's HTTP webhook signing key. Mailgun sends the three values in the "signature" object of JSON
+// webhooks and as form fields of legacy webhooks. The timestamp must be within the replay tolerance (see
+// WithTolerance). Tokens are not remembered, so reject reused tokens yourself to stop replays.
+func VerifyMailgun(signingKey, timestamp, token, signature string, opts ...Option) error {
+	return verifyMailgun(newOptions(opts), signingKey, timestamp, token, signature)
+}
+
+// verifyMailgun checks the HMAC signature over the timestamp and token
+func verifyMailgun(o *options, signingKey, timestamp, token, signature string) error {
+	if signingKey == "" {
+		return fmt.Errorf("%w: no signing key", ErrVerification)
+	}
+
+	secs, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed timestamp", ErrVerification)
+	}
+	if err := o.checkTimestamp(time.Unix(secs, 0)); err != nil {
+		return err
+	}
+
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write([]byte(timestamp + token))
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("%w: signature mismatch", ErrVerification)
+	}
+	return nil
+}
+
+// parseMailgun converts Mailgun event data, skipping event kinds without a normalized type
+func parseMailgun(data json.RawMessage) ([]Event, error) {
+	var me mailgunEvent
+	if err := json.Unmarshal(data, &me); err != nil {
+		return nil, fmt.Errorf("failed to decode Mailgun event: %w", err)
+	}
+
+	secs, frac := math.Modf(me.Timestamp)
+	e := Event{
+		Provider:  "mailgun",
+		MessageID: me.Message.Headers.MessageID,
+		Recipient: me.Recipient,
+		Timestamp: time.Unix(int64(secs), int64(frac*1e9)).UTC(),
+		IP:        me.IP,
+		UserAgent: me.ClientInfo.UserAgent,
+		URL:       me.URL,
+		Raw:       data,
+	}
+
+	for k, v := range me.UserVariables {
+		if e.Metadata == nil {
+			e.Metadata = make(map[string]string)
+		}
+		e.Metadata[k] = fmt.Sprint(v)
+	}
+
+	switch me.Event {
+	case "delivered":
+		e.Type = Delivered
+	case "failed":
+		e.Type = Bounced
+		e.Permanent = me.Severity == "permanent"
+		e.Reason = me.DeliveryStatus.Description
+		if e.Reason == "" {
+			e.Reason = me.DeliveryStatus.Message
+		}
+	case "complained":
+		e.Type = Complained
+	case "opened":
+		e.Type = Opened
+	case "clicked":
+		e.Type = Clicked
+	default:
+		return nil, nil
+	}
+
+	return []Event{e}, nil
+}
+
+// tokenCache remembers recently used webhook tokens. Tokens are forgotten after ttl, or oldest first once
+// limit is reached; a zero ttl keeps them until they are pushed out.
+type tokenCache struct {
+	limit int
+	ttl   time.Duration
+	now   func() time.Time
+
+	mu    sync.Mutex
+	seen  map[string]time.Time
+	order []usedToken
+}
+
+// usedToken is a token in the order it was added
+type usedToken struct {
+	token string
+	added time.Time
+}
+
+func newTokenCache(limit int, ttl time.Duration, now func() time.Time) *tokenCache {
+	return &tokenCache{limit: limit, ttl: ttl, now: now, seen: make(map[string]time.Time)}
+}
+
+// add records a token, reporting false when it was already recorded
+func (c *tokenCache) add(token string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for len(c.order) > 0 {
+		oldest := c.order[0]
+		if added, ok := c.seen[oldest.token]; ok && added.Equal(oldest.added) {
+			if len(c.order) < c.limit && (c.ttl <= 0 || now.Sub(added) < c.ttl) {
+				break
+			}
+			delete(c.seen, oldest.token)
+		}
+		c.order = c.order[1:]
+	}
+
+	if _, ok := c.seen[token]; ok {
+		return false
+	}
+	c.seen[token] = now
+	c.order = append(c.order, usedToken{token: token, added: now})
+	return true
+}
+
+// remove forgets a token
+func (c *tokenCache) remove(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.seen, token)
+}
@@ -0,0 +1,208 @@
+package events_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen/events"
+)
+
+const mailgunKey = "key-test"
+
+func mailgunBody(key string, ts time.Time, eventData string) string {
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(timestamp + "token"))
+
+	return fmt.Sprintf(`{"signature":{"timestamp":%q,"token":"token","signature":%q},"event-data":%s}`,
+		timestamp, hex.EncodeToString(mac.Sum(nil)), eventData)
+}
+
+func TestMailgun(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventData string
+		want      *events.Event
+	}{
+		{
+			name:      "delivered",
+			eventData: `{"event":"delivered","recipient":"a@example.com","timestamp":1767323045.5,"message":{"headers":{"message-id":"mg-1"}},"user-variables":{"user_id":42}}`,
+			want:      &events.Event{Type: events.Delivered, Recipient: "a@example.com", MessageID: "mg-1", Metadata: map[string]string{"user_id": "42"}},
+		},
+		{
+			name:      "permanent failure",
+			eventData: `{"event":"failed","severity":"permanent","recipient":"a@example.com","timestamp":1767323045,"delivery-status":{"description":"No such user"}}`,
+			want:      &events.Event{Type: events.Bounced, Recipient: "a@example.com", Permanent: true, Reason: "No such user"},
+		},
+		{
+			name:      "temporary failure",
+			eventData: `{"event":"failed","severity":"temporary","recipient":"a@example.com","timestamp":1767323045,"delivery-status":{"message":"Mailbox full"}}`,
+			want:      &events.Event{Type: events.Bounced, Recipient: "a@example.com", Reason: "Mailbox full"},
+		},
+		{
+			name:      "complained",
+			eventData: `{"event":"complained","recipient":"a@example.com","timestamp":1767323045}`,
+			want:      &events.Event{Type: events.Complained, Recipient: "a@example.com"},
+		},
+		{
+			name:      "clicked",
+			eventData: `{"event":"clicked","recipient":"a@example.com","timestamp":1767323045,"url":"https://example.com","ip":"192.0.2.1","client-info":{"user-agent":"UA"}}`,
+			want:      &events.Event{Type: events.Clicked, Recipient: "a@example.com", URL: "https://example.com", IP: "192.0.2.1", UserAgent: "UA"},
+		},
+		{
+			name:      "unsupported event",
+			eventData: `{"event":"accepted","recipient":"a@example.com","timestamp":1767323045}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []events.Event
+			handler, err := events.Mailgun(mailgunKey, func(_ context.Context, e events.Event) error {
+				got = append(got, e)
+				return nil
+			}, events.WithTolerance(0))
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(mailgunBody(mailgunKey, time.Now(), tt.eventData))))
+
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+			if tt.want == nil {
+				assert.Empty(t, got)
+				return
+			}
+
+			require.Len(t, got, 1)
+			assert.Equal(t, "mailgun", got[0].Provider)
+			assert.Equal(t, tt.want.Type, got[0].Type)
+			assert.Equal(t, tt.want.Recipient, got[0].Recipient)
+			assert.Equal(t, tt.want.MessageID, got[0].MessageID)
+			assert.Equal(t, tt.want.Permanent, got[0].Permanent)
+			assert.Equal(t, tt.want.Reason, got[0].Reason)
+			assert.Equal(t, tt.want.URL, got[0].URL)
+			assert.Equal(t, tt.want.IP, got[0].IP)
+			assert.Equal(t, tt.want.UserAgent, got[0].UserAgent)
+			assert.Equal(t, tt.want.Metadata, got[0].Metadata)
+			assert.Equal(t, int64(1767323045), got[0].Timestamp.Unix())
+		})
+	}
+}
+
+func TestMailgun_Verification(t *testing.T) {
+	eventData := `{"event":"delivered","recipient":"a@example.com","timestamp":1767323045}`
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{name: "valid", body: mailgunBody(mailgunKey, time.Now(), eventData), want: http.StatusNoContent},
+		{name: "wrong key", body: mailgunBody("other", time.Now(), eventData), want: http.StatusUnauthorized},
+		{name: "stale timestamp", body: mailgunBody(mailgunKey, time.Now().Add(-time.Hour), eventData), want: http.StatusUnauthorized},
+		{name: "malformed body", body: "not json", want: http.StatusBadRequest},
+	}
+
+	handler, err := events.Mailgun(mailgunKey, func(context.Context, events.Event) error { return nil })
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)))
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestMailgun_Replay(t *testing.T) {
+	body := mailgunBody(mailgunKey, time.Now(), `{"event":"delivered","recipient":"a@example.com","timestamp":1767323045}`)
+	serve := func(handler http.Handler) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		return rec.Code
+	}
+
+	t.Run("replayed request is rejected", func(t *testing.T) {
+		handler, err := events.Mailgun(mailgunKey, func(context.Context, events.Event) error { return nil })
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusNoContent, serve(handler))
+		assert.Equal(t, http.StatusUnauthorized, serve(handler))
+	})
+
+	t.Run("redelivery after a handler error is accepted", func(t *testing.T) {
+		calls := 0
+		handler, err := events.Mailgun(mailgunKey, func(context.Context, events.Event) error {
+			if calls++; calls == 1 {
+				return errors.New("database down")
+			}
+			return nil
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusInternalServerError, serve(handler))
+		assert.Equal(t, http.StatusNoContent, serve(handler))
+		assert.Equal(t, http.StatusUnauthorized, serve(handler))
+	})
+}
+
+func TestMailgun_RequiresSigningKey(t *testing.T) {
+	handle := func(context.Context, events.Event) error { return nil }
+
+	_, err := events.Mailgun("", handle)
+	assert.ErrorContains(t, err, "signing key is required")
+
+	_, err = events.Mailgun("", handle, events.WithoutVerification())
+	assert.NoError(t, err)
+}
+
+func TestVerifyMailgun(t *testing.T) {
+	sign := func(key, timestamp, token string) string {
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write([]byte(timestamp + token))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name      string
+		timestamp string
+		signature string
+		opts      []events.Option
+		wantErr   bool
+	}{
+		{name: "valid", timestamp: now, signature: sign(mailgunKey, now, "token")},
+		{name: "wrong key", timestamp: now, signature: sign("other-key", now, "token"), wantErr: true},
+		{name: "replayed", timestamp: old, signature: sign(mailgunKey, old, "token"), wantErr: true},
+		{name: "replay check disabled", timestamp: old, signature: sign(mailgunKey, old, "token"), opts: []events.Option{events.WithTolerance(0)}},
+		{name: "malformed timestamp", timestamp: "yesterday", signature: sign(mailgunKey, "yesterday", "token"), wantErr: true},
+	}
+
+	assert.ErrorIs(t, events.VerifyMailgun("", now, "token", sign("", now, "token")), events.ErrVerification)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := events.VerifyMailgun(mailgunKey, tt.timestamp, "token", tt.signature, tt.opts...)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, events.ErrVerification)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
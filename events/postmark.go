@@ -0,0 +1,102 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// postmarkEvent is a Postmark webhook payload. Recipient is used by delivery, open, and click webhooks, and
+// Email by bounce and spam complaint webhooks.
+type postmarkEvent struct {
+	RecordType   string            `json:"RecordType"`
+	MessageID    string            `json:"MessageID"`
+	Recipient    string            `json:"Recipient"`
+	Email        string            `json:"Email"`
+	Type         string            `json:"Type"`
+	Description  string            `json:"Description"`
+	Details      string            `json:"Details"`
+	OriginalLink string            `json:"OriginalLink"`
+	UserAgent    string            `json:"UserAgent"`
+	Metadata     map[string]string `json:"Metadata"`
+	DeliveredAt  time.Time         `json:"DeliveredAt"`
+	BouncedAt    time.Time         `json:"BouncedAt"`
+	ReceivedAt   time.Time         `json:"ReceivedAt"`
+	Geo          struct {
+		IP string `json:"IP"`
+	} `json:"Geo"`
+}
+
+// postmarkSoftBounces lists Postmark bounce types that are temporary
+var postmarkSoftBounces = map[string]bool{
+	"Transient": true, "SoftBounce": true, "DnsError": true, "AutoResponder": true,
+	"ChallengeVerification": true, "AddressChange": true, "OpenRelayTest": true,
+}
+
+// Postmark returns a handler for Postmark webhooks. Postmark does not sign webhooks, so the endpoint must be
+// protected with WithBasicAuth and matching credentials in the webhook URL; only WithoutVerification allows
+// unauthenticated requests.
+func Postmark(handler Handler, opts ...Option) (http.Handler, error) {
+	o := newOptions(opts)
+	if o.password == "" && !o.skipVerify {
+		return nil, errors.New("basic auth credentials are required (see WithBasicAuth)")
+	}
+
+	return &webhook{
+		opts:    o,
+		handler: handler,
+		parse: func(_ *http.Request, body []byte) ([]Event, error) {
+			return parsePostmark(body)
+		},
+	}, nil
+}
+
+// parsePostmark converts a Postmark webhook, skipping record types without a normalized type
+func parsePostmark(body []byte) ([]Event, error) {
+	var pe postmarkEvent
+	if err := json.Unmarshal(body, &pe); err != nil {
+		return nil, fmt.Errorf("failed to decode Postmark webhook: %w", err)
+	}
+
+	e := Event{
+		Provider:  "postmark",
+		MessageID: pe.MessageID,
+		Recipient: pe.Recipient,
+		UserAgent: pe.UserAgent,
+		IP:        pe.Geo.IP,
+		Metadata:  pe.Metadata,
+		Raw:       json.RawMessage(body),
+	}
+
+	switch pe.RecordType {
+	case "Delivery":
+		e.Type = Delivered
+		e.Timestamp = pe.DeliveredAt
+	case "Bounce":
+		e.Type = Bounced
+		e.Recipient = pe.Email
+		e.Timestamp = pe.BouncedAt
+		e.Permanent = !postmarkSoftBounces[pe.Type]
+		e.Reason = pe.Description
+		if pe.Details != "" {
+			e.Reason = pe.Details
+		}
+	case "SpamComplaint":
+		e.Type = Complained
+		e.Recipient = pe.Email
+		e.Timestamp = pe.BouncedAt
+	case "Open":
+		e.Type = Opened
+		e.Timestamp = pe.ReceivedAt
+	case "Click":
+		e.Type = Clicked
+		e.Timestamp = pe.ReceivedAt
+		e.URL = pe.OriginalLink
+	default:
+		return nil, nil
+	}
+
+	return []Event{e}, nil
+}
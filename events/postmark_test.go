@@ -0,0 +1,105 @@
+package events_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen/events"
+)
+
+func TestPostmark(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want *events.Event
+	}{
+		{
+			name: "delivery",
+			body: `{"RecordType":"Delivery","MessageID":"pm-1","Recipient":"a@example.com","DeliveredAt":"2026-01-02T03:04:05Z","Metadata":{"user_id":"42"}}`,
+			want: &events.Event{Type: events.Delivered, Recipient: "a@example.com", Metadata: map[string]string{"user_id": "42"}},
+		},
+		{
+			name: "hard bounce",
+			body: `{"RecordType":"Bounce","MessageID":"pm-1","Type":"HardBounce","Email":"a@example.com","BouncedAt":"2026-01-02T03:04:05Z","Description":"Unknown user","Details":"550 5.1.1"}`,
+			want: &events.Event{Type: events.Bounced, Recipient: "a@example.com", Permanent: true, Reason: "550 5.1.1"},
+		},
+		{
+			name: "soft bounce",
+			body: `{"RecordType":"Bounce","MessageID":"pm-1","Type":"SoftBounce","Email":"a@example.com","BouncedAt":"2026-01-02T03:04:05Z","Description":"Mailbox full"}`,
+			want: &events.Event{Type: events.Bounced, Recipient: "a@example.com", Reason: "Mailbox full"},
+		},
+		{
+			name: "spam complaint",
+			body: `{"RecordType":"SpamComplaint","MessageID":"pm-1","Email":"a@example.com","BouncedAt":"2026-01-02T03:04:05Z"}`,
+			want: &events.Event{Type: events.Complained, Recipient: "a@example.com"},
+		},
+		{
+			name: "open",
+			body: `{"RecordType":"Open","MessageID":"pm-1","Recipient":"a@example.com","ReceivedAt":"2026-01-02T03:04:05Z","UserAgent":"UA","Geo":{"IP":"192.0.2.1"}}`,
+			want: &events.Event{Type: events.Opened, Recipient: "a@example.com", UserAgent: "UA", IP: "192.0.2.1"},
+		},
+		{
+			name: "click",
+			body: `{"RecordType":"Click","MessageID":"pm-1","Recipient":"a@example.com","ReceivedAt":"2026-01-02T03:04:05Z","OriginalLink":"https://example.com"}`,
+			want: &events.Event{Type: events.Clicked, Recipient: "a@example.com", URL: "https://example.com"},
+		},
+		{
+			name: "unsupported record type",
+			body: `{"RecordType":"SubscriptionChange","MessageID":"pm-1"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []events.Event
+			handler, err := events.Postmark(func(_ context.Context, e events.Event) error {
+				got = append(got, e)
+				return nil
+			}, events.WithBasicAuth("postmark", "secret"))
+			require.NoError(t, err)
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			r.SetBasicAuth("postmark", "secret")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+			if tt.want == nil {
+				assert.Empty(t, got)
+				return
+			}
+
+			require.Len(t, got, 1)
+			assert.Equal(t, "postmark", got[0].Provider)
+			assert.Equal(t, "pm-1", got[0].MessageID)
+			assert.Equal(t, tt.want.Type, got[0].Type)
+			assert.Equal(t, tt.want.Recipient, got[0].Recipient)
+			assert.Equal(t, tt.want.Permanent, got[0].Permanent)
+			assert.Equal(t, tt.want.Reason, got[0].Reason)
+			assert.Equal(t, tt.want.URL, got[0].URL)
+			assert.Equal(t, tt.want.IP, got[0].IP)
+			assert.Equal(t, tt.want.UserAgent, got[0].UserAgent)
+			assert.Equal(t, tt.want.Metadata, got[0].Metadata)
+			assert.False(t, got[0].Timestamp.IsZero())
+		})
+	}
+}
+
+func TestPostmark_RequiresCredentials(t *testing.T) {
+	handle := func(context.Context, events.Event) error { return nil }
+
+	_, err := events.Postmark(handle)
+	assert.ErrorContains(t, err, "basic auth credentials are required")
+
+	_, err = events.Postmark(handle, events.WithBasicAuth("postmark", ""))
+	assert.Error(t, err)
+
+	_, err = events.Postmark(handle, events.WithoutVerification())
+	assert.NoError(t, err)
+}
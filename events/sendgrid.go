@@ -0,0 +1,180 @@
+package events
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	sendGridSignatureHeader = "X-Twilio-Email-Event-Webhook-Signature"
+	sendGridTimestampHeader = "X-Twilio-Email-Event-Webhook-Timestamp"
+)
+
+// sendGridReserved lists SendGrid event fields that are not custom arguments
+var sendGridReserved = map[string]bool{
+	"email": true, "timestamp": true, "event": true, "sg_event_id": true, "sg_message_id": true,
+	"reason": true, "status": true, "response": true, "type": true, "bounce_classification": true,
+	"url": true, "url_offset": true, "useragent": true, "ip": true, "category": true, "attempt": true,
+	"tls": true, "cert_err": true, "smtp-id": true, "asm_group_id": true, "sg_machine_open": true,
+	"marketing_campaign_id": true, "marketing_campaign_name": true, "pool": true,
+}
+
+// SendGrid returns a handler for the SendGrid Event Webhook. Requests are verified with the webhook's
+// verification key, given as the base64-encoded public key from the SendGrid settings.
+func SendGrid(verificationKey string, handler Handler, opts ...Option) (http.Handler, error) {
+	o := newOptions(opts)
+
+	var key *ecdsa.PublicKey
+	if !o.skipVerify {
+		var err error
+		if key, err = ParseSendGridKey(verificationKey); err != nil {
+			return nil, err
+		}
+	}
+
+	return &webhook{
+		opts:    o,
+		handler: handler,
+		parse: func(r *http.Request, body []byte) ([]Event, error) {
+			if !o.skipVerify {
+				if err := verifySendGrid(o, key, r, body); err != nil {
+					return nil, err
+				}
+			}
+			return parseSendGrid(body)
+		},
+	}, nil
+}
+
+// ParseSendGridKey parses the base64-encoded verification key from the SendGrid Event Webhook settings
+func ParseSendGridKey(verificationKey string) (*ecdsa.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(verificationKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode verification key: %w", err)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse verification key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("verification key is not an ECDSA public key")
+	}
+	return key, nil
+}
+
+// VerifySendGrid checks the ECDSA signature SendGrid sends in the X-Twilio-Email-Event-Webhook-Signature and
+// -Timestamp headers against the raw request body, for applications that parse SendGrid events themselves.
+// The timestamp must be within the replay tolerance (see WithTolerance).
+func VerifySendGrid(key *ecdsa.PublicKey, r *http.Request, body []byte, opts ...Option) error {
+	return verifySendGrid(newOptions(opts), key, r, body)
+}
+
+// verifySendGrid checks the ECDSA signature over the timestamp and body
+func verifySendGrid(o *options, key *ecdsa.PublicKey, r *http.Request, body []byte) error {
+	timestamp := r.Header.Get(sendGridTimestampHeader)
+	sig, err := base64.StdEncoding.DecodeString(r.Header.Get(sendGridSignatureHeader))
+	if err != nil || len(sig) == 0 || timestamp == "" {
+		return fmt.Errorf("%w: missing signature", ErrVerification)
+	}
+
+	secs, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed timestamp", ErrVerification)
+	}
+	if err := o.checkTimestamp(time.Unix(secs, 0)); err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(append([]byte(timestamp), body...))
+	if !ecdsa.VerifyASN1(key, digest[:], sig) {
+		return fmt.Errorf("%w: signature mismatch", ErrVerification)
+	}
+	return nil
+}
+
+// parseSendGrid converts a batch of SendGrid events, skipping event kinds without a normalized type
+func parseSendGrid(body []byte) ([]Event, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode SendGrid events: %w", err)
+	}
+
+	var events []Event
+	for _, data := range raw {
+		var fields map[string]any
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return nil, fmt.Errorf("failed to decode SendGrid event: %w", err)
+		}
+
+		e := Event{
+			Provider:  "sendgrid",
+			MessageID: sendGridMessageID(stringField(fields, "sg_message_id")),
+			Recipient: stringField(fields, "email"),
+			UserAgent: stringField(fields, "useragent"),
+			IP:        stringField(fields, "ip"),
+			URL:       stringField(fields, "url"),
+			Raw:       data,
+		}
+		if ts, ok := fields["timestamp"].(float64); ok {
+			e.Timestamp = time.Unix(int64(ts), 0).UTC()
+		}
+
+		switch stringField(fields, "event") {
+		case "delivered":
+			e.Type = Delivered
+		case "bounce":
+			e.Type = Bounced
+			e.Permanent = stringField(fields, "type") != "blocked"
+			e.Reason = stringField(fields, "reason")
+		case "dropped":
+			e.Type = Bounced
+			e.Permanent = true
+			e.Reason = stringField(fields, "reason")
+		case "spamreport":
+			e.Type = Complained
+		case "open":
+			e.Type = Opened
+		case "click":
+			e.Type = Clicked
+		default:
+			continue
+		}
+
+		for k, v := range fields {
+			if s, ok := v.(string); ok && !sendGridReserved[k] {
+				if e.Metadata == nil {
+					e.Metadata = make(map[string]string)
+				}
+				e.Metadata[k] = s
+			}
+		}
+
+		events = append(events, e)
+	}
+
+	return events, nil
+}
+
+// sendGridMessageID strips the filter suffix SendGrid appends to message IDs in events
+func sendGridMessageID(id string) string {
+	if i := strings.Index(id, ".filter"); i > 0 {
+		return id[:i]
+	}
+	return id
+}
+
+// stringField returns a string field of a decoded JSON object, or an empty string
+func stringField(fields map[string]any, key string) string {
+	s, _ := fields[key].(string)
+	return s
+}
@@ -0,0 +1,181 @@
+package events_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen/events"
+)
+
+func newSendGridKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	return key, base64.StdEncoding.EncodeToString(der)
+}
+
+func signedSendGridRequest(t *testing.T, key *ecdsa.PrivateKey, ts time.Time, body string) *http.Request {
+	t.Helper()
+
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	digest := sha256.Sum256([]byte(timestamp + body))
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r.Header.Set("X-Twilio-Email-Event-Webhook-Signature", base64.StdEncoding.EncodeToString(sig))
+	r.Header.Set("X-Twilio-Email-Event-Webhook-Timestamp", timestamp)
+	return r
+}
+
+func TestSendGrid(t *testing.T) {
+	key, public := newSendGridKey(t)
+
+	var got []events.Event
+	handler, err := events.SendGrid(public, func(_ context.Context, e events.Event) error {
+		got = append(got, e)
+		return nil
+	})
+	require.NoError(t, err)
+
+	body := `[
+		{"email":"a@example.com","timestamp":1767323045,"event":"delivered","sg_message_id":"sg-1.filter0001","user_id":"42"},
+		{"email":"b@example.com","timestamp":1767323045,"event":"bounce","type":"bounce","reason":"550 unknown user","sg_message_id":"sg-1.filter0001"},
+		{"email":"c@example.com","timestamp":1767323045,"event":"bounce","type":"blocked","reason":"blocked","sg_message_id":"sg-1"},
+		{"email":"d@example.com","timestamp":1767323045,"event":"dropped","reason":"Bounced Address","sg_message_id":"sg-1"},
+		{"email":"a@example.com","timestamp":1767323045,"event":"spamreport","sg_message_id":"sg-1"},
+		{"email":"a@example.com","timestamp":1767323045,"event":"open","useragent":"UA","ip":"192.0.2.1","sg_message_id":"sg-1"},
+		{"email":"a@example.com","timestamp":1767323045,"event":"click","url":"https://example.com","sg_message_id":"sg-1"},
+		{"email":"a@example.com","timestamp":1767323045,"event":"processed","sg_message_id":"sg-1"}
+	]`
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, signedSendGridRequest(t, key, time.Now(), body))
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	require.Len(t, got, 7)
+
+	assert.Equal(t, events.Delivered, got[0].Type)
+	assert.Equal(t, "sendgrid", got[0].Provider)
+	assert.Equal(t, "sg-1", got[0].MessageID)
+	assert.Equal(t, "a@example.com", got[0].Recipient)
+	assert.Equal(t, time.Unix(1767323045, 0).UTC(), got[0].Timestamp)
+	assert.Equal(t, map[string]string{"user_id": "42"}, got[0].Metadata)
+
+	assert.Equal(t, events.Bounced, got[1].Type)
+	assert.True(t, got[1].Permanent)
+	assert.Equal(t, "550 unknown user", got[1].Reason)
+	assert.Equal(t, events.Bounced, got[2].Type)
+	assert.False(t, got[2].Permanent)
+	assert.Equal(t, events.Bounced, got[3].Type)
+	assert.True(t, got[3].Permanent)
+
+	assert.Equal(t, events.Complained, got[4].Type)
+	assert.Equal(t, events.Opened, got[5].Type)
+	assert.Equal(t, "UA", got[5].UserAgent)
+	assert.Equal(t, "192.0.2.1", got[5].IP)
+	assert.Equal(t, events.Clicked, got[6].Type)
+	assert.Equal(t, "https://example.com", got[6].URL)
+}
+
+func TestSendGrid_Verification(t *testing.T) {
+	key, public := newSendGridKey(t)
+	other, _ := newSendGridKey(t)
+	body := `[{"email":"a@example.com","timestamp":1767323045,"event":"delivered"}]`
+
+	tests := []struct {
+		name    string
+		request func(t *testing.T) *http.Request
+		want    int
+	}{
+		{
+			name:    "valid",
+			request: func(t *testing.T) *http.Request { return signedSendGridRequest(t, key, time.Now(), body) },
+			want:    http.StatusNoContent,
+		},
+		{
+			name:    "wrong key",
+			request: func(t *testing.T) *http.Request { return signedSendGridRequest(t, other, time.Now(), body) },
+			want:    http.StatusUnauthorized,
+		},
+		{
+			name: "stale timestamp",
+			request: func(t *testing.T) *http.Request {
+				return signedSendGridRequest(t, key, time.Now().Add(-time.Hour), body)
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "tampered body",
+			request: func(t *testing.T) *http.Request {
+				r := signedSendGridRequest(t, key, time.Now(), body)
+				r.Body = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Replace(body, "a@", "b@", 1))).Body
+				return r
+			},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "missing signature",
+			request: func(t *testing.T) *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			},
+			want: http.StatusUnauthorized,
+		},
+	}
+
+	handler, err := events.SendGrid(public, func(context.Context, events.Event) error { return nil })
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, tt.request(t))
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestSendGrid_InvalidKey(t *testing.T) {
+	_, err := events.SendGrid("not base64!", func(context.Context, events.Event) error { return nil })
+	assert.Error(t, err)
+
+	_, err = events.SendGrid(base64.StdEncoding.EncodeToString([]byte("garbage")), func(context.Context, events.Event) error { return nil })
+	assert.Error(t, err)
+}
+
+func TestVerifySendGrid(t *testing.T) {
+	key, public := newSendGridKey(t)
+	parsed, err := events.ParseSendGridKey(public)
+	require.NoError(t, err)
+
+	body := `[{"email":"a@example.com","event":"delivered"}]`
+	require.NoError(t, events.VerifySendGrid(parsed, signedSendGridRequest(t, key, time.Now(), body), []byte(body)))
+
+	err = events.VerifySendGrid(parsed, signedSendGridRequest(t, key, time.Now(), body), []byte(body+" "))
+	assert.ErrorIs(t, err, events.ErrVerification)
+
+	old := signedSendGridRequest(t, key, time.Now().Add(-time.Hour), body)
+	assert.ErrorIs(t, events.VerifySendGrid(parsed, old, []byte(body)), events.ErrVerification)
+	assert.NoError(t, events.VerifySendGrid(parsed, old, []byte(body), events.WithTolerance(2*time.Hour)))
+
+	_, err = events.ParseSendGridKey("not a key")
+	assert.Error(t, err)
+}
@@ -0,0 +1,423 @@
+package events
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CertificateFetcher loads the certificate used to sign SNS messages from its URL
+type CertificateFetcher func(ctx context.Context, certURL string) (*x509.Certificate, error)
+
+// WithCertificateFetcher overrides how SNS signing certificates are loaded. The certificate URL is always
+// checked to be an HTTPS URL on an SNS host first.
+func WithCertificateFetcher(fetch CertificateFetcher) Option {
+	return func(o *options) {
+		o.certFetcher = fetch
+	}
+}
+
+// WithTopicARNs sets the SNS topics the SES handler accepts messages from. A valid SNS signature only proves
+// that a message came from some SNS topic, which any AWS account can create, so SES requires at least one.
+func WithTopicARNs(arns ...string) Option {
+	return func(o *options) {
+		o.topicARNs = append(o.topicARNs, arns...)
+	}
+}
+
+// WithSubscriptionConfirmation makes the SES handler confirm SNS subscriptions to an allowed topic
+// automatically, by visiting the SubscribeURL. Without it, confirm the subscription in the AWS console.
+func WithSubscriptionConfirmation() Option {
+	return func(o *options) {
+		o.autoConfirm = true
+	}
+}
+
+// snsHost matches the hosts that serve SNS signing certificates
+var snsHost = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// snsMessage is an Amazon SNS HTTP(S) delivery
+type snsMessage struct {
+	Type             string `json:"Type"`
+	MessageID        string `json:"MessageId"`
+	Token            string `json:"Token"`
+	TopicArn         string `json:"TopicArn"`
+	Subject          string `json:"Subject"`
+	Message          string `json:"Message"`
+	Timestamp        string `json:"Timestamp"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+	SubscribeURL     string `json:"SubscribeURL"`
+}
+
+// sesNotification is an SES event published through SNS, either as an identity notification
+// (notificationType) or through a configuration set (eventType)
+type sesNotification struct {
+	NotificationType string `json:"notificationType"`
+	EventType        string `json:"eventType"`
+	Mail             struct {
+		MessageID   string              `json:"messageId"`
+		Timestamp   time.Time           `json:"timestamp"`
+		Destination []string            `json:"destination"`
+		Tags        map[string][]string `json:"tags"`
+	} `json:"mail"`
+	Bounce *struct {
+		BounceType        string    `json:"bounceType"`
+		Timestamp         time.Time `json:"timestamp"`
+		BouncedRecipients []struct {
+			EmailAddress   string `json:"emailAddress"`
+			DiagnosticCode string `json:"diagnosticCode"`
+		} `json:"bouncedRecipients"`
+	} `json:"bounce"`
+	Complaint *struct {
+		Timestamp             time.Time `json:"timestamp"`
+		ComplaintFeedbackType string    `json:"complaintFeedbackType"`
+		ComplainedRecipients  []struct {
+			EmailAddress string `json:"emailAddress"`
+		} `json:"complainedRecipients"`
+	} `json:"complaint"`
+	Delivery *struct {
+		Timestamp  time.Time `json:"timestamp"`
+		Recipients []string  `json:"recipients"`
+	} `json:"delivery"`
+	Open *struct {
+		Timestamp time.Time `json:"timestamp"`
+		UserAgent string    `json:"userAgent"`
+		IPAddress string    `json:"ipAddress"`
+	} `json:"open"`
+	Click *struct {
+		Timestamp time.Time `json:"timestamp"`
+		UserAgent string    `json:"userAgent"`
+		IPAddress string    `json:"ipAddress"`
+		Link      string    `json:"link"`
+	} `json:"click"`
+}
+
+// SES returns a handler for Amazon SES events delivered by an SNS HTTPS subscription. SNS signatures are
+// verified, and only messages from the topics given with WithTopicARNs are accepted. Subscription
+// confirmations are accepted automatically only with WithSubscriptionConfirmation.
+func SES(handler Handler, opts ...Option) (http.Handler, error) {
+	o := newOptions(opts)
+	if o.certFetcher == nil {
+		o.certFetcher = newCertCache(o.httpClient).fetch
+	}
+	if len(o.topicARNs) == 0 && !o.skipVerify {
+		return nil, errors.New("at least one SNS topic ARN is required (see WithTopicARNs)")
+	}
+
+	return &webhook{
+		opts:    o,
+		handler: handler,
+		parse: func(r *http.Request, body []byte) ([]Event, error) {
+			var msg snsMessage
+			if err := json.Unmarshal(body, &msg); err != nil {
+				return nil, fmt.Errorf("failed to decode SNS message: %w", err)
+			}
+
+			if !o.skipVerify {
+				if err := verifySNS(r.Context(), o.certFetcher, &msg); err != nil {
+					return nil, err
+				}
+			}
+			if len(o.topicARNs) > 0 && !slices.Contains(o.topicARNs, msg.TopicArn) {
+				return nil, fmt.Errorf("%w: unexpected topic %q", ErrVerification, msg.TopicArn)
+			}
+
+			switch msg.Type {
+			case "SubscriptionConfirmation":
+				if o.autoConfirm {
+					return nil, confirmSubscription(r.Context(), o.httpClient, msg.SubscribeURL)
+				}
+				return nil, nil
+			case "Notification":
+				return parseSES([]byte(msg.Message))
+			default:
+				return nil, nil
+			}
+		},
+	}, nil
+}
+
+// parseSES converts an SES notification into one event per affected recipient
+func parseSES(data []byte) ([]Event, error) {
+	var n sesNotification
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, fmt.Errorf("failed to decode SES notification: %w", err)
+	}
+
+	base := Event{
+		Provider:  "ses",
+		MessageID: n.Mail.MessageID,
+		Timestamp: n.Mail.Timestamp,
+		Raw:       json.RawMessage(data),
+	}
+	if len(n.Mail.Tags) > 0 {
+		base.Metadata = make(map[string]string, len(n.Mail.Tags))
+		for k, v := range n.Mail.Tags {
+			if len(v) > 0 {
+				base.Metadata[k] = v[0]
+			}
+		}
+	}
+
+	kind := n.EventType
+	if kind == "" {
+		kind = n.NotificationType
+	}
+
+	var events []Event
+	switch {
+	case kind == "Bounce" && n.Bounce != nil:
+		for _, r := range n.Bounce.BouncedRecipients {
+			e := base
+			e.Type = Bounced
+			e.Recipient = r.EmailAddress
+			e.Timestamp = n.Bounce.Timestamp
+			e.Permanent = n.Bounce.BounceType == "Permanent"
+			e.Reason = r.DiagnosticCode
+			events = append(events, e)
+		}
+	case kind == "Complaint" && n.Complaint != nil:
+		for _, r := range n.Complaint.ComplainedRecipients {
+			e := base
+			e.Type = Complained
+			e.Recipient = r.EmailAddress
+			e.Timestamp = n.Complaint.Timestamp
+			e.Reason = n.Complaint.ComplaintFeedbackType
+			events = append(events, e)
+		}
+	case kind == "Delivery" && n.Delivery != nil:
+		for _, r := range n.Delivery.Recipients {
+			e := base
+			e.Type = Delivered
+			e.Recipient = r
+			e.Timestamp = n.Delivery.Timestamp
+			events = append(events, e)
+		}
+	case kind == "Open" && n.Open != nil:
+		for _, r := range n.Mail.Destination {
+			e := base
+			e.Type = Opened
+			e.Recipient = r
+			e.Timestamp = n.Open.Timestamp
+			e.UserAgent = n.Open.UserAgent
+			e.IP = n.Open.IPAddress
+			events = append(events, e)
+		}
+	case kind == "Click" && n.Click != nil:
+		for _, r := range n.Mail.Destination {
+			e := base
+			e.Type = Clicked
+			e.Recipient = r
+			e.Timestamp = n.Click.Timestamp
+			e.UserAgent = n.Click.UserAgent
+			e.IP = n.Click.IPAddress
+			e.URL = n.Click.Link
+			events = append(events, e)
+		}
+	}
+
+	return events, nil
+}
+
+// SNSCertificateFetcher returns a CertificateFetcher that downloads SNS signing certificates with the client
+// and caches them by URL. Share one between calls to VerifySNS.
+func SNSCertificateFetcher(client *http.Client) CertificateFetcher {
+	return newCertCache(client).fetch
+}
+
+// defaultCertFetcher is used by VerifySNS when no fetcher is given
+var defaultCertFetcher = sync.OnceValue(func() CertificateFetcher {
+	return SNSCertificateFetcher(&http.Client{Timeout: 10 * time.Second})
+})
+
+// VerifySNS checks the signature of an Amazon SNS HTTP(S) delivery, such as an SES event notification or a
+// subscription confirmation, given its raw body. The signing certificate must be served over HTTPS from an
+// SNS host. A nil fetch uses a shared SNSCertificateFetcher. Any AWS account can sign messages from its own
+// topics, so also check that the TopicArn is one of yours.
+func VerifySNS(ctx context.Context, body []byte, fetch CertificateFetcher) error {
+	var msg snsMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return fmt.Errorf("%w: failed to decode SNS message: %w", ErrVerification, err)
+	}
+	if fetch == nil {
+		fetch = defaultCertFetcher()
+	}
+	return verifySNS(ctx, fetch, &msg)
+}
+
+// verifySNS checks the signature of an SNS message against its signing certificate
+func verifySNS(ctx context.Context, fetch CertificateFetcher, msg *snsMessage) error {
+	u, err := url.Parse(msg.SigningCertURL)
+	if err != nil || u.Scheme != "https" || !snsHost.MatchString(u.Hostname()) {
+		return fmt.Errorf("%w: untrusted signing certificate URL", ErrVerification)
+	}
+
+	cert, err := fetch(ctx, msg.SigningCertURL)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrVerification, err)
+	}
+
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("%w: unsupported certificate key", ErrVerification)
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(msg.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: malformed signature", ErrVerification)
+	}
+
+	var hash crypto.Hash
+	var digest []byte
+	switch msg.SignatureVersion {
+	case "1":
+		sum := sha1.Sum([]byte(snsStringToSign(msg)))
+		hash, digest = crypto.SHA1, sum[:]
+	case "2":
+		sum := sha256.Sum256([]byte(snsStringToSign(msg)))
+		hash, digest = crypto.SHA256, sum[:]
+	default:
+		return fmt.Errorf("%w: unsupported signature version %q", ErrVerification, msg.SignatureVersion)
+	}
+
+	if err := rsa.VerifyPKCS1v15(pub, hash, digest, sig); err != nil {
+		return fmt.Errorf("%w: %w", ErrVerification, err)
+	}
+	return nil
+}
+
+// snsStringToSign builds the canonical string SNS signs for a message
+func snsStringToSign(msg *snsMessage) string {
+	var fields [][2]string
+	switch msg.Type {
+	case "Notification":
+		fields = append(fields, [2]string{"Message", msg.Message}, [2]string{"MessageId", msg.MessageID})
+		if msg.Subject != "" {
+			fields = append(fields, [2]string{"Subject", msg.Subject})
+		}
+		fields = append(fields,
+			[2]string{"Timestamp", msg.Timestamp},
+			[2]string{"TopicArn", msg.TopicArn},
+			[2]string{"Type", msg.Type},
+		)
+	default:
+		fields = append(fields,
+			[2]string{"Message", msg.Message},
+			[2]string{"MessageId", msg.MessageID},
+			[2]string{"SubscribeURL", msg.SubscribeURL},
+			[2]string{"Timestamp", msg.Timestamp},
+			[2]string{"Token", msg.Token},
+			[2]string{"TopicArn", msg.TopicArn},
+			[2]string{"Type", msg.Type},
+		)
+	}
+
+	var b strings.Builder
+	for _, f := range fields {
+		b.WriteString(f[0])
+		b.WriteByte('\n')
+		b.WriteString(f[1])
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// confirmSubscription visits the SubscribeURL of a verified subscription confirmation
+func confirmSubscription(ctx context.Context, client *http.Client, subscribeURL string) error {
+	u, err := url.Parse(subscribeURL)
+	if err != nil || u.Scheme != "https" || !snsHost.MatchString(u.Hostname()) {
+		return errors.New("untrusted subscribe URL")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, subscribeURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to confirm subscription: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to confirm subscription: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// certCache fetches SNS signing certificates and caches them by URL
+type certCache struct {
+	client *http.Client
+	mu     sync.Mutex
+	certs  map[string]*x509.Certificate
+}
+
+func newCertCache(client *http.Client) *certCache {
+	return &certCache{client: client, certs: make(map[string]*x509.Certificate)}
+}
+
+// fetch implements CertificateFetcher
+func (c *certCache) fetch(ctx context.Context, certURL string) (*x509.Certificate, error) {
+	c.mu.Lock()
+	cert, ok := c.certs[certURL]
+	c.mu.Unlock()
+	if ok {
+		return cert, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, certURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signing certificate: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch signing certificate: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing certificate: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signing certificate is not PEM encoded")
+	}
+
+	cert, err = x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing certificate: %w", err)
+	}
+
+	c.mu.Lock()
+	c.certs[certURL] = cert
+	c.mu.Unlock()
+
+	return cert, nil
+}
@@ -0,0 +1,324 @@
+package events_test
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen/events"
+)
+
+const (
+	snsCertURL  = "https://sns.us-east-1.amazonaws.com/SimpleNotificationService-test.pem"
+	snsTopicARN = "arn:aws:sns:us-east-1:123456789012:ses-events"
+)
+
+type snsSigner struct {
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+func newSNSSigner(t *testing.T) *snsSigner {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &snsSigner{key: key, cert: cert}
+}
+
+func (s *snsSigner) fetcher() events.CertificateFetcher {
+	return func(_ context.Context, certURL string) (*x509.Certificate, error) {
+		return s.cert, nil
+	}
+}
+
+// notification builds a signed SNS notification from snsTopicARN wrapping the given SES event
+func (s *snsSigner) notification(t *testing.T, message string) string {
+	t.Helper()
+	return s.notificationFrom(t, snsTopicARN, message)
+}
+
+// notificationFrom builds a signed SNS notification from the given topic wrapping the given SES event
+func (s *snsSigner) notificationFrom(t *testing.T, topicARN, message string) string {
+	t.Helper()
+
+	msg := map[string]string{
+		"Type":             "Notification",
+		"MessageId":        "sns-1",
+		"TopicArn":         topicARN,
+		"Message":          message,
+		"Timestamp":        "2026-01-02T03:04:05.000Z",
+		"SignatureVersion": "2",
+		"SigningCertURL":   snsCertURL,
+	}
+
+	var b strings.Builder
+	for _, k := range []string{"Message", "MessageId", "Timestamp", "TopicArn", "Type"} {
+		b.WriteString(k + "\n" + msg[k] + "\n")
+	}
+	digest := sha256.Sum256([]byte(b.String()))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	msg["Signature"] = base64.StdEncoding.EncodeToString(sig)
+
+	data, err := json.Marshal(msg)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestSES(t *testing.T) {
+	signer := newSNSSigner(t)
+
+	tests := []struct {
+		name    string
+		message string
+		want    []events.Event
+	}{
+		{
+			name: "permanent bounce per recipient",
+			message: `{"notificationType":"Bounce","mail":{"messageId":"ses-1","destination":["a@example.com","b@example.com"]},
+				"bounce":{"bounceType":"Permanent","timestamp":"2026-01-02T03:04:05Z","bouncedRecipients":[
+				{"emailAddress":"a@example.com","diagnosticCode":"550 user unknown"},{"emailAddress":"b@example.com"}]}}`,
+			want: []events.Event{
+				{Type: events.Bounced, Recipient: "a@example.com", Permanent: true, Reason: "550 user unknown"},
+				{Type: events.Bounced, Recipient: "b@example.com", Permanent: true},
+			},
+		},
+		{
+			name: "transient bounce",
+			message: `{"eventType":"Bounce","mail":{"messageId":"ses-1"},"bounce":{"bounceType":"Transient",
+				"timestamp":"2026-01-02T03:04:05Z","bouncedRecipients":[{"emailAddress":"a@example.com"}]}}`,
+			want: []events.Event{{Type: events.Bounced, Recipient: "a@example.com"}},
+		},
+		{
+			name: "complaint",
+			message: `{"notificationType":"Complaint","mail":{"messageId":"ses-1"},"complaint":{
+				"timestamp":"2026-01-02T03:04:05Z","complainedRecipients":[{"emailAddress":"a@example.com"}]}}`,
+			want: []events.Event{{Type: events.Complained, Recipient: "a@example.com"}},
+		},
+		{
+			name: "delivery",
+			message: `{"eventType":"Delivery","mail":{"messageId":"ses-1"},"delivery":{
+				"timestamp":"2026-01-02T03:04:05Z","recipients":["a@example.com"]}}`,
+			want: []events.Event{{Type: events.Delivered, Recipient: "a@example.com"}},
+		},
+		{
+			name: "click",
+			message: `{"eventType":"Click","mail":{"messageId":"ses-1","destination":["a@example.com"]},"click":{
+				"timestamp":"2026-01-02T03:04:05Z","link":"https://example.com","ipAddress":"192.0.2.1","userAgent":"UA"}}`,
+			want: []events.Event{{Type: events.Clicked, Recipient: "a@example.com", URL: "https://example.com", IP: "192.0.2.1", UserAgent: "UA"}},
+		},
+		{
+			name:    "unsupported event",
+			message: `{"eventType":"Send","mail":{"messageId":"ses-1"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []events.Event
+			handler, err := events.SES(func(_ context.Context, e events.Event) error {
+				got = append(got, e)
+				return nil
+			}, events.WithCertificateFetcher(signer.fetcher()), events.WithTopicARNs(snsTopicARN))
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(signer.notification(t, tt.message))))
+
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+			require.Len(t, got, len(tt.want))
+			for i, want := range tt.want {
+				assert.Equal(t, want.Type, got[i].Type)
+				assert.Equal(t, "ses", got[i].Provider)
+				assert.Equal(t, "ses-1", got[i].MessageID)
+				assert.Equal(t, want.Recipient, got[i].Recipient)
+				assert.Equal(t, want.Permanent, got[i].Permanent)
+				assert.Equal(t, want.Reason, got[i].Reason)
+				assert.Equal(t, want.URL, got[i].URL)
+				assert.Equal(t, want.IP, got[i].IP)
+				assert.Equal(t, want.UserAgent, got[i].UserAgent)
+				assert.False(t, got[i].Timestamp.IsZero())
+			}
+		})
+	}
+}
+
+func TestSES_Verification(t *testing.T) {
+	signer := newSNSSigner(t)
+	other := newSNSSigner(t)
+	message := `{"eventType":"Delivery","mail":{"messageId":"ses-1"},"delivery":{"recipients":["a@example.com"]}}`
+
+	tests := []struct {
+		name string
+		body func(t *testing.T) string
+		opts []events.Option
+		want int
+	}{
+		{
+			name: "wrong key",
+			body: func(t *testing.T) string { return other.notification(t, message) },
+			opts: []events.Option{events.WithCertificateFetcher(signer.fetcher())},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "tampered message",
+			body: func(t *testing.T) string {
+				return strings.Replace(signer.notification(t, message), "a@example.com", "b@example.com", 1)
+			},
+			opts: []events.Option{events.WithCertificateFetcher(signer.fetcher())},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "untrusted certificate host",
+			body: func(t *testing.T) string {
+				return strings.Replace(signer.notification(t, message), "sns.us-east-1.amazonaws.com", "evil.example.com", 1)
+			},
+			opts: []events.Option{events.WithCertificateFetcher(signer.fetcher())},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "validly signed message from another topic",
+			body: func(t *testing.T) string {
+				return signer.notificationFrom(t, "arn:aws:sns:us-east-1:999999999999:forged", message)
+			},
+			opts: []events.Option{events.WithCertificateFetcher(signer.fetcher())},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "verification disabled",
+			body: func(t *testing.T) string { return other.notification(t, message) },
+			opts: []events.Option{events.WithoutVerification()},
+			want: http.StatusNoContent,
+		},
+		{
+			name: "malformed body",
+			body: func(t *testing.T) string { return "not json" },
+			opts: []events.Option{events.WithCertificateFetcher(signer.fetcher())},
+			want: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]events.Option{events.WithTopicARNs(snsTopicARN)}, tt.opts...)
+			handler, err := events.SES(func(context.Context, events.Event) error { return nil }, opts...)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body(t))))
+
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestSES_SubscriptionConfirmation(t *testing.T) {
+	var confirmed bool
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		confirmed = true
+	}))
+	defer srv.Close()
+
+	body, err := json.Marshal(map[string]string{
+		"Type":         "SubscriptionConfirmation",
+		"SubscribeURL": srv.URL + "/confirm",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		opts []events.Option
+		want int
+	}{
+		{name: "not confirmed by default", want: http.StatusNoContent},
+		{name: "untrusted subscribe URL", opts: []events.Option{events.WithSubscriptionConfirmation()}, want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]events.Option{events.WithoutVerification(), events.WithHTTPClient(srv.Client())}, tt.opts...)
+			handler, err := events.SES(func(context.Context, events.Event) error { return nil }, opts...)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body))))
+
+			assert.Equal(t, tt.want, rec.Code)
+			assert.False(t, confirmed)
+		})
+	}
+}
+
+func TestSES_RequiresTopicARNs(t *testing.T) {
+	_, err := events.SES(func(context.Context, events.Event) error { return nil })
+	assert.ErrorContains(t, err, "topic ARN is required")
+
+	_, err = events.SES(func(context.Context, events.Event) error { return nil }, events.WithoutVerification())
+	assert.NoError(t, err)
+}
+
+func TestVerifySNS(t *testing.T) {
+	signer := newSNSSigner(t)
+	body := signer.notification(t, `{"notificationType":"Delivery"}`)
+
+	require.NoError(t, events.VerifySNS(context.Background(), []byte(body), signer.fetcher()))
+
+	tampered := strings.Replace(body, "Delivery", "Bounce", 1)
+	assert.ErrorIs(t, events.VerifySNS(context.Background(), []byte(tampered), signer.fetcher()), events.ErrVerification)
+
+	untrusted := strings.Replace(body, "sns.us-east-1.amazonaws.com", "sns.example.com", 1)
+	assert.ErrorIs(t, events.VerifySNS(context.Background(), []byte(untrusted), signer.fetcher()), events.ErrVerification)
+
+	assert.ErrorIs(t, events.VerifySNS(context.Background(), []byte("not json"), signer.fetcher()), events.ErrVerification)
+}
+
+func TestSNSCertificateFetcher(t *testing.T) {
+	signer := newSNSSigner(t)
+	requests := 0
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requests++
+		pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: signer.cert.Raw})
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(pemData)), Request: r}, nil
+	})}
+
+	fetch := events.SNSCertificateFetcher(client)
+	body := signer.notification(t, `{"notificationType":"Delivery"}`)
+	require.NoError(t, events.VerifySNS(context.Background(), []byte(body), fetch))
+	require.NoError(t, events.VerifySNS(context.Background(), []byte(body), fetch))
+	assert.Equal(t, 1, requests, "certificates are cached by URL")
+}
+
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
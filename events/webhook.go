@@ -0,0 +1,177 @@
+package events
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ErrVerification is wrapped by the errors of requests that fail authentication or signature checks, both in
+// the webhook handlers and the Verify functions
+var ErrVerification = errors.New("webhook verification failed")
+
+// Option configures a webhook handler
+type Option func(o *options)
+
+type options struct {
+	username    string
+	password    string
+	maxBodySize int64
+	tolerance   time.Duration
+	skipVerify  bool
+	httpClient  *http.Client
+	certFetcher CertificateFetcher
+	topicARNs   []string
+	autoConfirm bool
+	now         func() time.Time
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		maxBodySize: 1 << 20,
+		tolerance:   5 * time.Minute,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithBasicAuth requires HTTP basic authentication with the given credentials. Use it with providers that do
+// not sign webhooks, such as Postmark.
+func WithBasicAuth(username, password string) Option {
+	return func(o *options) {
+		o.username = username
+		o.password = password
+	}
+}
+
+// WithMaxBodySize limits the size of webhook request bodies (defaults to 1 MiB)
+func WithMaxBodySize(n int64) Option {
+	return func(o *options) {
+		o.maxBodySize = n
+	}
+}
+
+// WithTolerance sets how old a signed webhook timestamp may be before the request is rejected as a replay
+// (defaults to five minutes). A zero tolerance disables the check.
+func WithTolerance(d time.Duration) Option {
+	return func(o *options) {
+		o.tolerance = d
+	}
+}
+
+// WithoutVerification disables signature verification, and lets Postmark handlers run without basic auth. Only
+// use it in local development.
+func WithoutVerification() Option {
+	return func(o *options) {
+		o.skipVerify = true
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to fetch signing certificates and confirm SNS subscriptions
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// webhook is the shared HTTP handling for all providers
+type webhook struct {
+	opts    *options
+	handler Handler
+	parse   func(r *http.Request, body []byte) ([]Event, error)
+	// failed, when set, is called with the body of a request whose events could not be handled, so state
+	// recorded while parsing it, such as a replay token, does not block the provider's redelivery
+	failed func(body []byte)
+}
+
+// ServeHTTP implements http.Handler
+func (w *webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		rw.Header().Set("Allow", http.MethodPost)
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if w.opts.username != "" || w.opts.password != "" {
+		if err := VerifyBasicAuth(r, w.opts.username, w.opts.password); err != nil {
+			rw.Header().Set("WWW-Authenticate", `Basic realm="webhooks"`)
+			http.Error(rw, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, w.opts.maxBodySize))
+	if err != nil {
+		http.Error(rw, "failed to read body", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	events, err := w.parse(r, body)
+	if err != nil {
+		if errors.Is(err, ErrVerification) {
+			http.Error(rw, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		http.Error(rw, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	if err := dispatch(r.Context(), w.handler, events); err != nil {
+		if w.failed != nil {
+			w.failed(body)
+		}
+		http.Error(rw, "failed to handle events", http.StatusInternalServerError)
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// dispatch calls the handler for each event, stopping at the first error
+func dispatch(ctx context.Context, handler Handler, events []Event) error {
+	for _, e := range events {
+		if err := handler(ctx, e); err != nil {
+			return fmt.Errorf("failed to handle %s event: %w", e.Type, err)
+		}
+	}
+	return nil
+}
+
+// checkTimestamp rejects timestamps outside the replay tolerance
+func (o *options) checkTimestamp(ts time.Time) error {
+	if o.tolerance <= 0 {
+		return nil
+	}
+	if d := o.now().Sub(ts); d > o.tolerance || d < -o.tolerance {
+		return fmt.Errorf("%w: timestamp outside tolerance", ErrVerification)
+	}
+	return nil
+}
+
+// VerifyBasicAuth checks that a request carries HTTP basic authentication with the given credentials, comparing
+// them in constant time. Use it for providers that do not sign webhooks, such as Postmark.
+func VerifyBasicAuth(r *http.Request, username, password string) error {
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return fmt.Errorf("%w: missing credentials", ErrVerification)
+	}
+	// Compare both, so the time taken does not reveal which one was wrong
+	userOK, passOK := equal(user, username), equal(pass, password)
+	if !userOK || !passOK {
+		return fmt.Errorf("%w: invalid credentials", ErrVerification)
+	}
+	return nil
+}
+
+// equal compares strings in constant time
+func equal(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
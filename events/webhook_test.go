@@ -0,0 +1,128 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen/events"
+)
+
+func TestWebhook(t *testing.T) {
+	body := `{"RecordType":"Delivery","MessageID":"pm-1","Recipient":"a@example.com"}`
+
+	tests := []struct {
+		name    string
+		method  string
+		body    string
+		auth    []string
+		opts    []events.Option
+		handler events.Handler
+		want    int
+	}{
+		{
+			name:   "method not allowed",
+			method: http.MethodGet,
+			want:   http.StatusMethodNotAllowed,
+		},
+		{
+			name: "missing basic auth",
+			opts: []events.Option{events.WithBasicAuth("user", "secret")},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "wrong basic auth",
+			auth: []string{"user", "wrong"},
+			opts: []events.Option{events.WithBasicAuth("user", "secret")},
+			want: http.StatusUnauthorized,
+		},
+		{
+			name: "valid basic auth",
+			auth: []string{"user", "secret"},
+			opts: []events.Option{events.WithBasicAuth("user", "secret")},
+			want: http.StatusNoContent,
+		},
+		{
+			name: "body too large",
+			opts: []events.Option{events.WithMaxBodySize(10)},
+			want: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "malformed payload",
+			body: "{",
+			want: http.StatusBadRequest,
+		},
+		{
+			name:    "handler error",
+			handler: func(context.Context, events.Event) error { return errors.New("boom") },
+			want:    http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			payload := tt.body
+			if payload == "" {
+				payload = body
+			}
+			handler := tt.handler
+			if handler == nil {
+				handler = func(context.Context, events.Event) error { return nil }
+			}
+
+			r := httptest.NewRequest(method, "/", strings.NewReader(payload))
+			if tt.auth != nil {
+				r.SetBasicAuth(tt.auth[0], tt.auth[1])
+			}
+
+			webhook, err := events.Postmark(handler, append([]events.Option{events.WithoutVerification()}, tt.opts...)...)
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			webhook.ServeHTTP(rec, r)
+
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
+
+func TestVerifyBasicAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		password string
+		noAuth   bool
+		wantErr  bool
+	}{
+		{name: "valid", username: "postmark", password: "secret"},
+		{name: "wrong password", username: "postmark", password: "guess", wantErr: true},
+		{name: "wrong username", username: "admin", password: "secret", wantErr: true},
+		{name: "missing", noAuth: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			if !tt.noAuth {
+				r.SetBasicAuth(tt.username, tt.password)
+			}
+
+			err := events.VerifyBasicAuth(r, "postmark", "secret")
+			if tt.wantErr {
+				assert.ErrorIs(t, err, events.ErrVerification)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,168 @@
+// Package export renders every email of a mailpen Manager, in each layout and locale, into a static HTML site
+// with an index page, so stakeholders can review all emails from a hosted artifact.
+package export
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/patrickward/mailpen"
+)
+
+// Option configures an export
+type Option func(e *exporter)
+
+// WithLayouts sets the layouts each email is rendered in (defaults to every layout of the manager)
+func WithLayouts(layouts ...string) Option {
+	return func(e *exporter) {
+		e.layouts = layouts
+	}
+}
+
+// WithLocales sets the locales each email is rendered in. The locale is set on the message passed to
+// processors, as for a message sent with Message.Locale. Without locales, emails are rendered once with no
+// locale.
+func WithLocales(locales ...string) Option {
+	return func(e *exporter) {
+		e.locales = locales
+	}
+}
+
+// WithSampleData sets a function that returns the data an email is rendered with (defaults to the email's
+// sample data file, see Manager.SampleData)
+func WithSampleData(fn func(ctx context.Context, email string) (map[string]any, error)) Option {
+	return func(e *exporter) {
+		e.sampleData = fn
+	}
+}
+
+// WithTitle sets the title of the index page (defaults to "Email previews")
+func WithTitle(title string) Option {
+	return func(e *exporter) {
+		e.title = title
+	}
+}
+
+type exporter struct {
+	manager    *mailpen.Manager
+	layouts    []string
+	locales    []string
+	sampleData func(ctx context.Context, email string) (map[string]any, error)
+	title      string
+}
+
+// Page is one rendered combination of email, layout, and locale
+type Page struct {
+	Email    string
+	Layout   string
+	Locale   string
+	HTMLPath string // Path of the HTML file, relative to the site directory
+	TextPath string // Path of the text file, relative to the site directory
+	Warnings []string
+	Err      error // Render error; the page files are not written when set
+}
+
+// Report lists the exported pages
+type Report struct {
+	Pages []Page
+}
+
+// Failed returns the pages that failed to render
+func (r *Report) Failed() []Page {
+	var failed []Page
+	for _, p := range r.Pages {
+		if p.Err != nil {
+			failed = append(failed, p)
+		}
+	}
+	return failed
+}
+
+// Site renders every email × layout × locale combination into dir and writes dir/index.html. Render failures
+// are listed on the index page and in the report rather than stopping the export; the returned error is for
+// failures to list emails or write files.
+func Site(ctx context.Context, manager *mailpen.Manager, dir string, opts ...Option) (*Report, error) {
+	e := &exporter{manager: manager, title: "Email previews"}
+	for _, opt := range opts {
+		opt(e)
+	}
+	if len(e.layouts) == 0 {
+		e.layouts = manager.Layouts()
+	}
+	if len(e.locales) == 0 {
+		e.locales = []string{""}
+	}
+	if e.sampleData == nil {
+		e.sampleData = func(_ context.Context, email string) (map[string]any, error) {
+			return manager.SampleData(email)
+		}
+	}
+
+	emails, err := manager.Emails()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{}
+	for _, email := range emails {
+		data, dataErr := e.sampleData(ctx, email)
+
+		for _, layout := range e.layouts {
+			for _, locale := range e.locales {
+				page := Page{Email: email, Layout: layout, Locale: locale}
+				if dataErr != nil {
+					page.Err = dataErr
+				} else if err := e.render(ctx, dir, &page, data); err != nil {
+					return nil, err
+				}
+				report.Pages = append(report.Pages, page)
+			}
+		}
+	}
+
+	if err := writeIndex(filepath.Join(dir, "index.html"), e.title, report); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// render renders a page and writes its files. Render errors are recorded on the page; write errors are
+// returned.
+func (e *exporter) render(ctx context.Context, dir string, page *Page, data map[string]any) error {
+	rendered, err := e.manager.Render(ctx, page.Email, data, mailpen.RenderOptions{
+		Layout:  page.Layout,
+		Message: &mailpen.Message{Template: page.Email, Layout: page.Layout, Locale: page.Locale, Data: data},
+	})
+	if err != nil {
+		page.Err = err
+		return nil
+	}
+	page.Warnings = rendered.Warnings
+
+	base := path.Join("emails", page.Email, page.Layout)
+	if page.Locale != "" {
+		base += "." + page.Locale
+	}
+	page.HTMLPath = base + ".html"
+	page.TextPath = base + ".txt"
+
+	if err := writeFile(filepath.Join(dir, filepath.FromSlash(page.HTMLPath)), rendered.HTML); err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, filepath.FromSlash(page.TextPath)), rendered.Text)
+}
+
+// writeFile writes a file, creating its directory
+func writeFile(name, content string) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package export_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/export"
+)
+
+// localeProcessor stamps the message locale into the HTML
+type localeProcessor struct{}
+
+func (localeProcessor) Process(_ context.Context, rc *mailpen.RenderContext) (string, error) {
+	return strings.Replace(rc.HTML, "</body>", "<p>locale="+rc.Locale+"</p></body>", 1), nil
+}
+
+func newManager(t *testing.T) *mailpen.Manager {
+	t.Helper()
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{{Name: "test", FS: fstest.MapFS{
+			"layouts/plain.html":               {Data: []byte(`<html><body>{{template "content" .}}</body></html>`)},
+			"layouts/plain.txt":                {Data: []byte(`{{template "content" .}}`)},
+			"emails/welcome.html":              {Data: []byte(`{{define "content"}}<p>Welcome, {{.Name}}!</p>{{end}}`)},
+			"emails/welcome.txt":               {Data: []byte(`{{define "content"}}Welcome, {{.Name}}!{{end}}`)},
+			"emails/welcome.sample.json":       {Data: []byte(`{"Name": "Sam"}`)},
+			"emails/account/reset.html":        {Data: []byte(`{{define "content"}}<p>{{.Link.Missing}}</p>{{end}}`)},
+			"emails/account/reset.txt":         {Data: []byte(`{{define "content"}}Reset{{end}}`)},
+			"emails/account/reset.sample.yaml": {Data: []byte("Link: https://example.com\n")},
+		}}},
+		Processors: []mailpen.ContextProcessor{localeProcessor{}},
+	})
+	require.NoError(t, err)
+	return manager
+}
+
+func TestSite(t *testing.T) {
+	dir := t.TempDir()
+
+	report, err := export.Site(context.Background(), newManager(t), dir,
+		export.WithLayouts("plain"),
+		export.WithLocales("en", "fr"),
+		export.WithTitle("Acme emails"),
+	)
+	require.NoError(t, err)
+	require.Len(t, report.Pages, 4)
+
+	failed := report.Failed()
+	require.Len(t, failed, 2)
+	assert.Equal(t, "account/reset", failed[0].Email)
+
+	welcome := report.Pages[2]
+	assert.Equal(t, "welcome", welcome.Email)
+	assert.Equal(t, "en", welcome.Locale)
+	assert.Equal(t, "emails/welcome/plain.en.html", welcome.HTMLPath)
+
+	html, err := os.ReadFile(filepath.Join(dir, "emails", "welcome", "plain.fr.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "Welcome, Sam!")
+	assert.Contains(t, string(html), "locale=fr")
+
+	text, err := os.ReadFile(filepath.Join(dir, "emails", "welcome", "plain.en.txt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(text), "Welcome, Sam!")
+
+	index, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	require.NoError(t, err)
+	body := string(index)
+	assert.Contains(t, body, "<title>Acme emails</title>")
+	assert.Contains(t, body, `<a href="emails/welcome/plain.en.html" target="view">plain · en</a>`)
+	assert.Contains(t, body, `<iframe name="view" src="emails/welcome/plain.en.html">`)
+	assert.Contains(t, body, "2 page(s) failed to render")
+	assert.NoFileExists(t, filepath.Join(dir, "emails", "account", "reset", "plain.en.html"))
+}
+
+func TestSite_Defaults(t *testing.T) {
+	dir := t.TempDir()
+
+	report, err := export.Site(context.Background(), newManager(t), dir,
+		export.WithSampleData(func(_ context.Context, email string) (map[string]any, error) {
+			if email == "welcome" {
+				return map[string]any{"Name": "Fixture"}, nil
+			}
+			return nil, errors.New("no fixture")
+		}),
+	)
+	require.NoError(t, err)
+
+	for _, page := range report.Pages {
+		assert.Empty(t, page.Locale)
+		if page.Email == "welcome" && page.Layout == "plain" {
+			require.NoError(t, page.Err)
+			html, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(page.HTMLPath)))
+			require.NoError(t, err)
+			assert.Contains(t, string(html), "Welcome, Fixture!")
+		}
+		if page.Email == "account/reset" {
+			assert.EqualError(t, page.Err, "no fixture")
+		}
+	}
+	assert.Len(t, report.Pages, 2*len(newManager(t).Layouts()))
+}
@@ -0,0 +1,88 @@
+package export
+
+import (
+	"bytes"
+	"html/template"
+)
+
+// group is the pages of one email on the index page
+type group struct {
+	Email string
+	Pages []Page
+}
+
+// writeIndex writes the index page, which lists the pages by email and shows the selected one in an iframe
+func writeIndex(name, title string, report *Report) error {
+	var groups []group
+	for _, p := range report.Pages {
+		if len(groups) == 0 || groups[len(groups)-1].Email != p.Email {
+			groups = append(groups, group{Email: p.Email})
+		}
+		groups[len(groups)-1].Pages = append(groups[len(groups)-1].Pages, p)
+	}
+
+	first := ""
+	for _, p := range report.Pages {
+		if p.Err == nil {
+			first = p.HTMLPath
+			break
+		}
+	}
+
+	var buf bytes.Buffer
+	err := indexPage.Execute(&buf, map[string]any{
+		"Title":  title,
+		"Groups": groups,
+		"First":  first,
+		"Failed": len(report.Failed()),
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeFile(name, buf.String())
+}
+
+// indexPage is the site's index. Links are relative so the site works from any directory or host.
+var indexPage = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { margin: 0; display: flex; height: 100vh; font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; font-size: 14px; }
+nav { width: 280px; overflow-y: auto; border-right: 1px solid #ddd; background: #fafafa; }
+nav h1 { font-size: 16px; margin: 16px; }
+nav h2 { font-size: 14px; margin: 16px 16px 4px; }
+nav ul { list-style: none; margin: 0; padding: 0; }
+nav li { padding: 4px 16px 4px 24px; }
+nav a { color: #1976d2; text-decoration: none; }
+.warning { color: #b26a00; font-size: 12px; }
+.error { color: #c62828; font-size: 12px; }
+main { flex: 1; display: flex; background: #eee; }
+iframe { flex: 1; border: 0; background: #fff; margin: 16px; }
+</style>
+</head>
+<body>
+<nav>
+<h1>{{.Title}}</h1>
+{{if .Failed}}<p class="error" style="margin: 0 16px">{{.Failed}} page(s) failed to render</p>{{end}}
+{{range .Groups}}
+<h2>{{.Email}}</h2>
+<ul>
+{{range .Pages}}<li>
+{{if .Err}}{{.Layout}}{{with .Locale}} · {{.}}{{end}} <div class="error">{{.Err}}</div>
+{{else}}<a href="{{.HTMLPath}}" target="view">{{.Layout}}{{with .Locale}} · {{.}}{{end}}</a> (<a href="{{.TextPath}}" target="view">text</a>)
+{{range .Warnings}}<div class="warning">{{.}}</div>{{end}}
+{{end}}</li>
+{{end}}
+</ul>
+{{else}}<p style="margin: 16px">No emails found.</p>
+{{end}}
+</nav>
+<main>
+<iframe name="view"{{with .First}} src="{{.}}"{{end}}></iframe>
+</main>
+</body>
+</html>
+`))
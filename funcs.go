@@ -3,6 +3,10 @@ package mailpen
 import (
 	"fmt"
 	"html/template"
+	"net/url"
+	"reflect"
+	"strings"
+	"unicode/utf8"
 )
 
 // MergeFuncMaps merges the provided function maps into a single function map.
@@ -31,6 +35,8 @@ func DefaultFuncMap() template.FuncMap {
 	// TODO: Add default function maps here
 	cachedFuncMap = MergeFuncMaps(
 		mapFuncs(),
+		formatFuncs(),
+		msoFuncs(),
 	)
 
 	return cachedFuncMap
@@ -39,13 +45,40 @@ func DefaultFuncMap() template.FuncMap {
 // Helper functions for template functions
 func mapFuncs() template.FuncMap {
 	return template.FuncMap{
-		"map_new": newMap, // Create a new map from key-value pairs
-		"dict":    newMap, // Alias for map_new
-		"add":     intAdd,
-		"num_add": intAdd,
-		"num_mod": mod,
-		"sub":     intSub,
-		"last":    indexLast,
+		"map_new":   newMap, // Create a new map from key-value pairs
+		"dict":      newMap, // Alias for map_new
+		"add":       intAdd,
+		"num_add":   intAdd,
+		"num_mod":   mod,
+		"sub":       intSub,
+		"last":      indexLast,
+		"list_item": toListItem,
+	}
+}
+
+// msoFuncs returns the functions that emit Outlook conditional comments, which html/template would otherwise
+// strip from templates
+func msoFuncs() template.FuncMap {
+	return template.FuncMap{
+		"if_mso":         func() template.HTML { return "<!--[if mso]>" },
+		"end_if_mso":     func() template.HTML { return "<![endif]-->" },
+		"if_not_mso":     func() template.HTML { return "<!--[if !mso]><!-->" },
+		"end_if_not_mso": func() template.HTML { return "<!--<![endif]-->" },
+	}
+}
+
+func formatFuncs() template.FuncMap {
+	return template.FuncMap{
+		"address_lines": addressLines,
+		"currency":      FormatCurrency,
+		"map_url":       mapURL,
+		"pad_left":      padLeft,
+		"pad_right":     padRight,
+		"initials":      initials,
+		"percent":       percent,
+		"progress_bar":  progressBar,
+		"score_url":     scoreURL,
+		"seq":           seq,
 	}
 }
 
@@ -67,9 +100,13 @@ func intSub(a, b int) int {
 	return a - b
 }
 
-// indexLast returns true if the index is the last element in the array
-func indexLast(index int, arr []any) bool {
-	return index == len(arr)-1
+// indexLast returns true if the index is the last element in the array or slice
+func indexLast(index int, arr any) bool {
+	v := reflect.ValueOf(arr)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return false
+	}
+	return index == v.Len()-1
 }
 
 // newMap creates a new map from key-value pairs
@@ -90,3 +127,228 @@ func newMap(pairs ...any) (map[string]any, error) {
 	}
 	return result, nil
 }
+
+// currencySymbols maps ISO 4217 codes to the symbol printed before an amount
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"CAD": "CA$",
+	"AUD": "A$",
+	"EUR": "€",
+	"GBP": "£",
+	"JPY": "¥",
+	"INR": "₹",
+}
+
+// zeroDecimalCurrencies lists the currencies that have no minor unit
+var zeroDecimalCurrencies = map[string]bool{
+	"JPY": true,
+	"KRW": true,
+	"VND": true,
+	"CLP": true,
+}
+
+// FormatCurrency formats an amount given in minor units (cents for USD) with the currency's symbol, or its
+// code for currencies without a known symbol, and thousands separators.
+//
+// Example: {{ currency 123456 "USD" }} -> $1,234.56
+func FormatCurrency(amount int64, currency string) string {
+	currency = strings.ToUpper(currency)
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	var number string
+	if zeroDecimalCurrencies[currency] {
+		number = groupThousands(amount)
+	} else {
+		number = fmt.Sprintf("%s.%02d", groupThousands(amount/100), amount%100)
+	}
+
+	if symbol, ok := currencySymbols[currency]; ok {
+		return sign + symbol + number
+	}
+	if currency == "" {
+		return sign + number
+	}
+	return sign + currency + " " + number
+}
+
+// groupThousands formats a non-negative integer with comma separators
+func groupThousands(n int64) string {
+	digits := fmt.Sprintf("%d", n)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return b.String()
+}
+
+// padLeft right-aligns s in a column of the given width
+func padLeft(width int, s string) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return strings.Repeat(" ", width-n) + s
+	}
+	return s
+}
+
+// padRight left-aligns s in a column of the given width
+func padRight(width int, s string) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// percent converts a number to a whole percentage between 0 and 100
+func percent(value any) int {
+	var p float64
+	switch v := value.(type) {
+	case int:
+		p = float64(v)
+	case int64:
+		p = float64(v)
+	case float32:
+		p = float64(v)
+	case float64:
+		p = v
+	case string:
+		if _, err := fmt.Sscanf(strings.TrimSuffix(v, "%"), "%g", &p); err != nil {
+			return 0
+		}
+	default:
+		return 0
+	}
+
+	switch {
+	case p < 0:
+		return 0
+	case p > 100:
+		return 100
+	default:
+		return int(p + 0.5)
+	}
+}
+
+// progressBar draws a plain-text progress bar of the given width
+//
+// Example: {{ progress_bar 40 10 }} -> [####------]
+func progressBar(value any, width int) string {
+	filled := percent(value) * width / 100
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", width-filled) + "]"
+}
+
+// initials returns up to two uppercase initials of a name
+//
+// Example: {{ initials "Ada Lovelace" }} -> AL
+func initials(name string) string {
+	words := strings.Fields(name)
+	if len(words) > 2 {
+		words = []string{words[0], words[len(words)-1]}
+	}
+
+	var b strings.Builder
+	for _, word := range words {
+		r, _ := utf8.DecodeRuneInString(word)
+		b.WriteString(strings.ToUpper(string(r)))
+	}
+	return b.String()
+}
+
+// toListItem converts a list entry to a ListItem. Entries may be a ListItem, a map with "text" and "checked"
+// keys, or any other value, which is used as the text.
+func toListItem(v any) ListItem {
+	switch item := v.(type) {
+	case ListItem:
+		return item
+	case *ListItem:
+		return *item
+	case map[string]any:
+		checked, _ := item["checked"].(bool)
+		return ListItem{Text: fmt.Sprint(item["text"]), Checked: checked}
+	default:
+		return ListItem{Text: fmt.Sprint(v)}
+	}
+}
+
+// seq returns the integers from start to end, inclusive
+//
+// Example: {{ range seq 1 5 }}{{ . }}{{ end }} -> 12345
+func seq(start, end int) []int {
+	if end < start {
+		return nil
+	}
+	numbers := make([]int, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		numbers = append(numbers, i)
+	}
+	return numbers
+}
+
+// scoreURL adds a score to a feedback URL, replacing a "{score}" placeholder or setting the "score" query
+// parameter
+//
+// Example: {{ score_url "https://example.com/rate?id=7" 5 }} -> https://example.com/rate?id=7&score=5
+func scoreURL(rawURL string, score int) string {
+	value := fmt.Sprintf("%d", score)
+	if strings.Contains(rawURL, "{score}") {
+		return strings.ReplaceAll(rawURL, "{score}", value)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	query.Set("score", value)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// addressLines flattens strings, multi-line strings, and slices of strings into the non-empty lines of an
+// address
+//
+// Example: {{ address_lines .CompanyAddress1 .CompanyAddress2 }}
+func addressLines(values ...any) []string {
+	var lines []string
+	var add func(v any)
+	add = func(v any) {
+		switch value := v.(type) {
+		case nil:
+		case string:
+			for _, line := range strings.Split(value, "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					lines = append(lines, line)
+				}
+			}
+		case []string:
+			for _, item := range value {
+				add(item)
+			}
+		case []any:
+			for _, item := range value {
+				add(item)
+			}
+		default:
+			add(fmt.Sprint(value))
+		}
+	}
+
+	for _, v := range values {
+		add(v)
+	}
+	return lines
+}
+
+// mapURL returns a Google Maps search link for an address
+//
+// Example: {{ map_url (address_lines "1 Main St" "Springfield") }} -> https://www.google.com/maps/search/?api=1&query=1+Main+St%2C+Springfield
+func mapURL(lines []string) string {
+	query := url.Values{"api": {"1"}, "query": {strings.Join(lines, ", ")}}
+	return "https://www.google.com/maps/search/?" + query.Encode()
+}
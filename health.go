@@ -0,0 +1,57 @@
+package mailpen
+
+import (
+	"context"
+	"fmt"
+)
+
+// HealthChecker is implemented by providers that can check they are reachable without sending, such as the
+// SMTP provider. Unreachable providers should return an error wrapping ErrProviderUnavailable.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// QueueSizer is implemented by queues that can report how many messages they hold. The queue package's Queue
+// implements it.
+type QueueSizer interface {
+	Len(ctx context.Context) (int, error)
+}
+
+// Health describes the state of a Mailpen instance for readiness probes and dashboards
+type Health struct {
+	Provider      string // Provider name
+	ProviderError error  // Why the provider failed its check; nil when it passed or cannot be checked
+	Queued        int    // Messages waiting in the queue, or -1 when there is no queue or it cannot report its size
+	QueueError    error  // Why the queue could not report its size
+	ShutDown      bool   // Shutdown has been called
+}
+
+// Healthy reports whether the instance can send: it is not shut down and no check failed
+func (h Health) Healthy() bool {
+	return !h.ShutDown && h.ProviderError == nil && h.QueueError == nil
+}
+
+// Health checks the provider when it implements HealthChecker and reports the queue depth when the queue
+// implements QueueSizer, within the deadline of ctx
+func (m *Mailpen) Health(ctx context.Context) Health {
+	health := Health{
+		Provider: m.provider.Name(),
+		Queued:   -1,
+		ShutDown: m.closed.Load(),
+	}
+
+	if checker, ok := m.provider.(HealthChecker); ok {
+		health.ProviderError = checker.CheckHealth(ctx)
+	}
+
+	if sizer, ok := m.queue.(QueueSizer); ok {
+		n, err := sizer.Len(ctx)
+		if err != nil {
+			health.QueueError = fmt.Errorf("failed to get queue size: %w", err)
+		} else {
+			health.Queued = n
+		}
+	}
+
+	return health
+}
@@ -0,0 +1,84 @@
+package mailpen
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+)
+
+// SendRecord is one recorded provider send attempt
+type SendRecord struct {
+	ID                string            // Unique record identifier
+	MessageID         string            // Mailpen message ID
+	ProviderMessageID string            // Message ID reported by the provider, if any
+	Provider          string            // Provider name
+	Template          string            // Template the message was rendered from
+	Subject           string            // Rendered subject
+	From              string            // Sender address
+	To                []string          // Recipients
+	Cc                []string          // CC recipients
+	Bcc               []string          // BCC recipients
+	Tags              []string          // Message tags
+	Metadata          map[string]string // Message metadata
+	Attempt           int               // Attempt number, starting at 1
+	Error             string            // Provider error; empty when the provider accepted the message
+	StartedAt         time.Time         // When the attempt started
+	FinishedAt        time.Time         // When the provider returned
+}
+
+// Succeeded reports whether the provider accepted the message
+func (r SendRecord) Succeeded() bool {
+	return r.Error == ""
+}
+
+// History records send attempts for auditing and support tooling. See the history package for
+// implementations.
+type History interface {
+	// Record stores a send attempt
+	Record(ctx context.Context, rec SendRecord) error
+	// ByRecipient returns up to limit attempts sent to an address, newest first. A limit of zero returns all.
+	ByRecipient(ctx context.Context, address string, limit int) ([]SendRecord, error)
+}
+
+// WithHistory sets the history that records every provider send attempt
+func WithHistory(h History) Option {
+	return func(m *Mailpen) error {
+		m.history = h
+		return nil
+	}
+}
+
+// record stores a provider send attempt in the history, if one is configured. Failures are logged rather
+// than returned, so an unavailable history never blocks sending.
+func (m *Mailpen) record(ctx context.Context, msg *Message, attempt int, started time.Time, err error) {
+	if m.history == nil {
+		return
+	}
+
+	rec := SendRecord{
+		ID:                newMessageID(),
+		MessageID:         msg.ID,
+		ProviderMessageID: msg.ProviderMessageID,
+		Provider:          m.providerName(msg),
+		Template:          msg.Template,
+		Subject:           msg.Subject,
+		From:              msg.From,
+		To:                slices.Clone(msg.To),
+		Cc:                slices.Clone(msg.Cc),
+		Bcc:               slices.Clone(msg.Bcc),
+		Tags:              slices.Clone(msg.Tags),
+		Metadata:          maps.Clone(msg.Metadata),
+		Attempt:           attempt,
+		StartedAt:         started,
+		FinishedAt:        time.Now(),
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+
+	if err := m.history.Record(context.WithoutCancel(ctx), rec); err != nil {
+		m.logger.WarnContext(ctx, "mailpen: failed to record send history", append(m.logAttrs(msg), slog.Any("error", err))...)
+	}
+}
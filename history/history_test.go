@@ -0,0 +1,124 @@
+package history_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/history"
+	"github.com/patrickward/mailpen/sqldialect"
+)
+
+func stores(t *testing.T) map[string]mailpen.History {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", "file:"+t.Name()+"?mode=memory&cache=shared")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+
+	sqlStore, err := history.NewSQLStore(db, sqldialect.SQLite)
+	require.NoError(t, err)
+	require.NoError(t, sqlStore.Migrate(context.Background()))
+	require.NoError(t, sqlStore.Migrate(context.Background()), "migrate is idempotent")
+
+	return map[string]mailpen.History{
+		"memory": history.NewMemoryStore(0),
+		"sql":    sqlStore,
+	}
+}
+
+func TestStores(t *testing.T) {
+	start := time.UnixMilli(time.Now().UnixMilli())
+
+	records := []mailpen.SendRecord{
+		{
+			ID:         "r1",
+			MessageID:  "m1",
+			Provider:   "smtp",
+			Template:   "welcome",
+			Subject:    "Welcome",
+			From:       "sender@example.com",
+			To:         []string{"Jane <jane@example.com>"},
+			Tags:       []string{"onboarding"},
+			Metadata:   map[string]string{"user_id": "42"},
+			Attempt:    1,
+			Error:      "connection refused",
+			StartedAt:  start,
+			FinishedAt: start.Add(time.Second),
+		},
+		{
+			ID:                "r2",
+			MessageID:         "m1",
+			ProviderMessageID: "<abc@example.com>",
+			Provider:          "smtp",
+			Template:          "welcome",
+			Subject:           "Welcome",
+			From:              "sender@example.com",
+			To:                []string{"Jane <jane@example.com>"},
+			Attempt:           2,
+			StartedAt:         start.Add(2 * time.Second),
+			FinishedAt:        start.Add(3 * time.Second),
+		},
+		{
+			ID:         "r3",
+			MessageID:  "m2",
+			Provider:   "smtp",
+			Subject:    "Report",
+			To:         []string{"bob@example.com"},
+			Bcc:        []string{"JANE@example.com"},
+			Attempt:    1,
+			StartedAt:  start.Add(4 * time.Second),
+			FinishedAt: start.Add(5 * time.Second),
+		},
+	}
+
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			for _, rec := range records {
+				require.NoError(t, store.Record(ctx, rec))
+			}
+
+			got, err := store.ByRecipient(ctx, "jane@EXAMPLE.com", 0)
+			require.NoError(t, err)
+			require.Len(t, got, 3)
+			assert.Equal(t, []string{"r3", "r2", "r1"}, []string{got[0].ID, got[1].ID, got[2].ID})
+
+			assert.Equal(t, records[0], got[2])
+			assert.False(t, got[2].Succeeded())
+			assert.Equal(t, "<abc@example.com>", got[1].ProviderMessageID)
+			assert.True(t, got[1].Succeeded())
+			assert.Equal(t, []string{"JANE@example.com"}, got[0].Bcc)
+
+			got, err = store.ByRecipient(ctx, "jane@example.com", 1)
+			require.NoError(t, err)
+			require.Len(t, got, 1)
+			assert.Equal(t, "r3", got[0].ID)
+
+			got, err = store.ByRecipient(ctx, "nobody@example.com", 0)
+			require.NoError(t, err)
+			assert.Empty(t, got)
+		})
+	}
+}
+
+func TestMemoryStore_Limit(t *testing.T) {
+	store := history.NewMemoryStore(2)
+	for _, id := range []string{"r1", "r2", "r3"} {
+		require.NoError(t, store.Record(context.Background(), mailpen.SendRecord{ID: id, To: []string{"jane@example.com"}}))
+	}
+
+	assert.Equal(t, 2, store.Len())
+	got, err := store.ByRecipient(context.Background(), "jane@example.com", 0)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "r3", got[0].ID)
+	assert.Equal(t, "r2", got[1].ID)
+}
@@ -0,0 +1,73 @@
+// Package history provides mailpen.History implementations.
+package history
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/patrickward/mailpen"
+)
+
+// MemoryStore is an in-memory send history. It is safe for concurrent use.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records []mailpen.SendRecord
+	limit   int
+}
+
+// NewMemoryStore creates an in-memory history that keeps the most recent limit records. A limit of zero
+// keeps every record.
+func NewMemoryStore(limit int) *MemoryStore {
+	return &MemoryStore{limit: limit}
+}
+
+// Record implements mailpen.History
+func (s *MemoryStore) Record(_ context.Context, rec mailpen.SendRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = append(s.records, rec)
+	if s.limit > 0 && len(s.records) > s.limit {
+		s.records = slices.Delete(s.records, 0, len(s.records)-s.limit)
+	}
+	return nil
+}
+
+// ByRecipient implements mailpen.History
+func (s *MemoryStore) ByRecipient(_ context.Context, address string, limit int) ([]mailpen.SendRecord, error) {
+	address = mailpen.NormalizeAddress(address)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found []mailpen.SendRecord
+	for i := len(s.records) - 1; i >= 0; i-- {
+		if limit > 0 && len(found) == limit {
+			break
+		}
+		if hasRecipient(s.records[i], address) {
+			found = append(found, s.records[i])
+		}
+	}
+	return found, nil
+}
+
+// Len returns the number of records held
+func (s *MemoryStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.records)
+}
+
+// hasRecipient reports whether a record was sent to a normalized address
+func hasRecipient(rec mailpen.SendRecord, address string) bool {
+	return slices.ContainsFunc(recipients(rec), func(addr string) bool {
+		return mailpen.NormalizeAddress(addr) == address
+	})
+}
+
+// recipients returns every recipient of a record
+func recipients(rec mailpen.SendRecord) []string {
+	return slices.Concat(rec.To, rec.Cc, rec.Bcc)
+}
@@ -0,0 +1,195 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/sqldialect"
+)
+
+// SQLStore is a send history stored in database tables via database/sql. Attempts are stored in one table
+// and their recipients in a second, indexed table for lookups by address.
+type SQLStore struct {
+	db      *sql.DB
+	dialect sqldialect.Dialect
+	table   string
+}
+
+// SQLOption configures a SQLStore
+type SQLOption func(s *SQLStore)
+
+// WithTable sets the attempts table name (defaults to "mailpen_send_history"). The recipients table uses the
+// same name with a "_recipients" suffix.
+func WithTable(table string) SQLOption {
+	return func(s *SQLStore) {
+		s.table = table
+	}
+}
+
+// NewSQLStore creates a send history backed by SQL tables
+func NewSQLStore(db *sql.DB, dialect sqldialect.Dialect, opts ...SQLOption) (*SQLStore, error) {
+	if db == nil {
+		return nil, errors.New("database is required")
+	}
+	if dialect.Placeholder == nil {
+		return nil, errors.New("dialect is required")
+	}
+
+	s := &SQLStore{db: db, dialect: dialect, table: "mailpen_send_history"}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+func (s *SQLStore) recipientsTable() string { return s.table + "_recipients" }
+
+// Migrate creates the history tables if they do not exist
+func (s *SQLStore) Migrate(ctx context.Context) error {
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id VARCHAR(64) PRIMARY KEY,
+	message_id VARCHAR(255) NOT NULL,
+	provider_message_id VARCHAR(255) NOT NULL,
+	provider VARCHAR(64) NOT NULL,
+	template VARCHAR(255) NOT NULL,
+	subject %[2]s NOT NULL,
+	from_address VARCHAR(320) NOT NULL,
+	recipients %[2]s NOT NULL,
+	tags %[2]s NOT NULL,
+	metadata %[2]s NOT NULL,
+	attempt INTEGER NOT NULL,
+	error %[2]s NOT NULL,
+	started_at BIGINT NOT NULL,
+	finished_at BIGINT NOT NULL
+)`, s.table, s.dialect.PayloadType),
+		// The primary key doubles as the index for lookups by address
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	address VARCHAR(320) NOT NULL,
+	started_at BIGINT NOT NULL,
+	record_id VARCHAR(64) NOT NULL,
+	PRIMARY KEY (address, started_at, record_id)
+)`, s.recipientsTable()),
+	}
+
+	for _, stmt := range statements {
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create history tables: %w", err)
+		}
+	}
+	return nil
+}
+
+// storedRecipients is the serialized recipients of a record
+type storedRecipients struct {
+	To  []string `json:"to,omitempty"`
+	Cc  []string `json:"cc,omitempty"`
+	Bcc []string `json:"bcc,omitempty"`
+}
+
+// Record implements mailpen.History
+func (s *SQLStore) Record(ctx context.Context, rec mailpen.SendRecord) error {
+	recips, err := json.Marshal(storedRecipients{To: rec.To, Cc: rec.Cc, Bcc: rec.Bcc})
+	if err != nil {
+		return fmt.Errorf("failed to encode recipients: %w", err)
+	}
+	tags, err := json.Marshal(rec.Tags)
+	if err != nil {
+		return fmt.Errorf("failed to encode tags: %w", err)
+	}
+	metadata, err := json.Marshal(rec.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, s.dialect.Rebind(fmt.Sprintf(`INSERT INTO %s
+	(id, message_id, provider_message_id, provider, template, subject, from_address, recipients, tags, metadata, attempt, error, started_at, finished_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, s.table)),
+		rec.ID, rec.MessageID, rec.ProviderMessageID, rec.Provider, rec.Template, rec.Subject, rec.From,
+		string(recips), string(tags), string(metadata), rec.Attempt, rec.Error,
+		rec.StartedAt.UnixMilli(), rec.FinishedAt.UnixMilli(),
+	); err != nil {
+		return fmt.Errorf("failed to record send: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, addr := range recipients(rec) {
+		addr = mailpen.NormalizeAddress(addr)
+		if seen[addr] {
+			continue
+		}
+		seen[addr] = true
+
+		if _, err := tx.ExecContext(ctx, s.dialect.Rebind(fmt.Sprintf(
+			"INSERT INTO %s (record_id, address, started_at) VALUES (?, ?, ?)", s.recipientsTable())),
+			rec.ID, addr, rec.StartedAt.UnixMilli(),
+		); err != nil {
+			return fmt.Errorf("failed to record recipient: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ByRecipient implements mailpen.History
+func (s *SQLStore) ByRecipient(ctx context.Context, address string, limit int) ([]mailpen.SendRecord, error) {
+	query := fmt.Sprintf(`SELECT h.id, h.message_id, h.provider_message_id, h.provider, h.template, h.subject,
+	h.from_address, h.recipients, h.tags, h.metadata, h.attempt, h.error, h.started_at, h.finished_at
+FROM %s h JOIN %s r ON r.record_id = h.id
+WHERE r.address = ?
+ORDER BY r.started_at DESC, h.attempt DESC`, s.table, s.recipientsTable())
+
+	args := []any{mailpen.NormalizeAddress(address)}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.dialect.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query send history: %w", err)
+	}
+	defer rows.Close()
+
+	var records []mailpen.SendRecord
+	for rows.Next() {
+		var rec mailpen.SendRecord
+		var recips, tags, metadata string
+		var started, finished int64
+		if err := rows.Scan(&rec.ID, &rec.MessageID, &rec.ProviderMessageID, &rec.Provider, &rec.Template,
+			&rec.Subject, &rec.From, &recips, &tags, &metadata, &rec.Attempt, &rec.Error, &started, &finished,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan send history: %w", err)
+		}
+
+		var stored storedRecipients
+		if err := json.Unmarshal([]byte(recips), &stored); err != nil {
+			return nil, fmt.Errorf("failed to decode recipients: %w", err)
+		}
+		rec.To, rec.Cc, rec.Bcc = stored.To, stored.Cc, stored.Bcc
+		if err := json.Unmarshal([]byte(tags), &rec.Tags); err != nil {
+			return nil, fmt.Errorf("failed to decode tags: %w", err)
+		}
+		if err := json.Unmarshal([]byte(metadata), &rec.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode metadata: %w", err)
+		}
+		rec.StartedAt = time.UnixMilli(started)
+		rec.FinishedAt = time.UnixMilli(finished)
+
+		records = append(records, rec)
+	}
+
+	return slices.Clip(records), rows.Err()
+}
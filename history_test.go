@@ -0,0 +1,58 @@
+package mailpen_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/history"
+)
+
+func TestMailpen_History(t *testing.T) {
+	mock := &mockProvider{}
+	store := history.NewMemoryStore(0)
+	mp, err := mailpen.New(mock, &mailpen.Config{From: "sender@example.com"}, mailpen.WithHistory(store))
+	require.NoError(t, err)
+
+	newMessage := func() *mailpen.Message {
+		msg := mailpen.NewMessage().
+			To("jane@example.com").
+			Subject("Welcome").
+			Tag("onboarding").
+			Metadata("user_id", "42").
+			Must()
+		msg.TextBody = "Hello"
+		return msg
+	}
+
+	mock.err = errors.New("provider down")
+	assert.Error(t, mp.Send(context.Background(), newMessage()))
+
+	mock.err = nil
+	msg := newMessage()
+	require.NoError(t, mp.Send(context.Background(), msg))
+
+	records, err := store.ByRecipient(context.Background(), "jane@example.com", 0)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	rec := records[0]
+	assert.True(t, rec.Succeeded())
+	assert.NotEmpty(t, rec.ID)
+	assert.Equal(t, msg.ID, rec.MessageID)
+	assert.Equal(t, "mock", rec.Provider)
+	assert.Equal(t, "Welcome", rec.Subject)
+	assert.Equal(t, "sender@example.com", rec.From)
+	assert.Equal(t, []string{"jane@example.com"}, rec.To)
+	assert.Equal(t, []string{"onboarding"}, rec.Tags)
+	assert.Equal(t, map[string]string{"user_id": "42"}, rec.Metadata)
+	assert.Equal(t, 1, rec.Attempt)
+	assert.False(t, rec.StartedAt.After(rec.FinishedAt))
+
+	assert.False(t, records[1].Succeeded())
+	assert.Equal(t, "provider down", records[1].Error)
+}
@@ -0,0 +1,104 @@
+package mailpen
+
+import (
+	"context"
+	"fmt"
+)
+
+// SendFunc sends a message
+type SendFunc func(ctx context.Context, msg *Message) error
+
+// Middleware wraps a SendFunc to add behavior around sending, such as logging, auditing, or policy checks.
+// Middleware sees the message before it is rendered and may inspect the result after next returns.
+type Middleware func(next SendFunc) SendFunc
+
+// Hooks are callbacks invoked at fixed points of the send pipeline. Any hook may be nil. An error returned
+// from BeforeRender, AfterRender, or BeforeSend aborts the send.
+type Hooks struct {
+	BeforeRender func(ctx context.Context, msg *Message) error                          // Before templates are rendered
+	AfterRender  func(ctx context.Context, msg *Message, rendered *RenderedEmail) error // After rendering; rendered is nil for messages without a template
+	BeforeSend   func(ctx context.Context, msg *Message) error                          // After message processors, right before the provider is called
+	AfterSend    func(ctx context.Context, msg *Message, err error)                     // After the provider returns, with its error
+}
+
+// WithMiddleware adds middleware to the send pipeline
+func WithMiddleware(mw ...Middleware) Option {
+	return func(m *Mailpen) error {
+		m.Use(mw...)
+		return nil
+	}
+}
+
+// WithHooks adds hooks to the send pipeline
+func WithHooks(hooks Hooks) Option {
+	return func(m *Mailpen) error {
+		m.AddHooks(hooks)
+		return nil
+	}
+}
+
+// Use adds middleware to the send pipeline. Middleware runs in the order it was added, so the first middleware
+// added is the outermost. Use is not safe to call concurrently with Send.
+func (m *Mailpen) Use(mw ...Middleware) {
+	m.middleware = append(m.middleware, mw...)
+}
+
+// AddHooks adds a set of hooks to the send pipeline. Hooks of the same kind run in the order they were added.
+// AddHooks is not safe to call concurrently with Send.
+func (m *Mailpen) AddHooks(hooks Hooks) {
+	m.hooks = append(m.hooks, hooks)
+}
+
+// chain wraps the final send function with the registered middleware
+func (m *Mailpen) chain(final SendFunc) SendFunc {
+	next := final
+	for i := len(m.middleware) - 1; i >= 0; i-- {
+		next = m.middleware[i](next)
+	}
+	return next
+}
+
+// runBeforeRender runs the BeforeRender hooks
+func (m *Mailpen) runBeforeRender(ctx context.Context, msg *Message) error {
+	for _, h := range m.hooks {
+		if h.BeforeRender != nil {
+			if err := h.BeforeRender(ctx, msg); err != nil {
+				return fmt.Errorf("before render hook: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// runAfterRender runs the AfterRender hooks
+func (m *Mailpen) runAfterRender(ctx context.Context, msg *Message, rendered *RenderedEmail) error {
+	for _, h := range m.hooks {
+		if h.AfterRender != nil {
+			if err := h.AfterRender(ctx, msg, rendered); err != nil {
+				return fmt.Errorf("after render hook: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// runBeforeSend runs the BeforeSend hooks
+func (m *Mailpen) runBeforeSend(ctx context.Context, msg *Message) error {
+	for _, h := range m.hooks {
+		if h.BeforeSend != nil {
+			if err := h.BeforeSend(ctx, msg); err != nil {
+				return fmt.Errorf("before send hook: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// runAfterSend runs the AfterSend hooks
+func (m *Mailpen) runAfterSend(ctx context.Context, msg *Message, err error) {
+	for _, h := range m.hooks {
+		if h.AfterSend != nil {
+			h.AfterSend(ctx, msg, err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package mailpen_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+func TestMailpen_Use(t *testing.T) {
+	var calls []string
+	record := func(name string) mailpen.Middleware {
+		return func(next mailpen.SendFunc) mailpen.SendFunc {
+			return func(ctx context.Context, msg *mailpen.Message) error {
+				calls = append(calls, name+":before")
+				err := next(ctx, msg)
+				calls = append(calls, name+":after")
+				return err
+			}
+		}
+	}
+
+	mock := &mockProvider{}
+	mp, err := mailpen.New(mock, &mailpen.Config{From: "sender@example.com"}, mailpen.WithMiddleware(record("outer")))
+	require.NoError(t, err)
+	mp.Use(record("inner"))
+
+	msg := mailpen.NewMessage().To("recipient@example.com").Subject("Test").Must()
+	require.NoError(t, mp.Send(context.Background(), msg))
+
+	assert.Equal(t, []string{"outer:before", "inner:before", "inner:after", "outer:after"}, calls)
+	assert.Equal(t, 1, mock.sendCalls)
+}
+
+func TestMailpen_UseShortCircuit(t *testing.T) {
+	blocked := errors.New("blocked by policy")
+
+	mock := &mockProvider{}
+	mp, err := mailpen.New(mock, &mailpen.Config{From: "sender@example.com"})
+	require.NoError(t, err)
+	mp.Use(func(next mailpen.SendFunc) mailpen.SendFunc {
+		return func(ctx context.Context, msg *mailpen.Message) error {
+			return blocked
+		}
+	})
+
+	msg := mailpen.NewMessage().To("recipient@example.com").Subject("Test").Must()
+	assert.ErrorIs(t, mp.Send(context.Background(), msg), blocked)
+	assert.Equal(t, 0, mock.sendCalls)
+}
+
+func TestMailpen_Hooks(t *testing.T) {
+	hookErr := errors.New("hook failed")
+	sendErr := errors.New("send failed")
+
+	tests := []struct {
+		name      string
+		hooks     mailpen.Hooks
+		sendErr   error
+		wantErr   error
+		wantCalls []string
+		sendCalls int
+	}{
+		{
+			name:      "all hooks run in order",
+			wantCalls: []string{"before-render", "after-render", "before-send", "after-send"},
+			sendCalls: 1,
+		},
+		{
+			name:      "before render error aborts",
+			hooks:     mailpen.Hooks{BeforeRender: func(context.Context, *mailpen.Message) error { return hookErr }},
+			wantErr:   hookErr,
+			wantCalls: []string{"before-render"},
+		},
+		{
+			name:      "before send error aborts",
+			hooks:     mailpen.Hooks{BeforeSend: func(context.Context, *mailpen.Message) error { return hookErr }},
+			wantErr:   hookErr,
+			wantCalls: []string{"before-render", "after-render", "before-send"},
+		},
+		{
+			name:      "after send receives provider error",
+			sendErr:   sendErr,
+			wantErr:   sendErr,
+			wantCalls: []string{"before-render", "after-render", "before-send", "after-send:send failed"},
+			sendCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			recorder := mailpen.Hooks{
+				BeforeRender: func(ctx context.Context, msg *mailpen.Message) error {
+					calls = append(calls, "before-render")
+					return nil
+				},
+				AfterRender: func(ctx context.Context, msg *mailpen.Message, rendered *mailpen.RenderedEmail) error {
+					calls = append(calls, "after-render")
+					return nil
+				},
+				BeforeSend: func(ctx context.Context, msg *mailpen.Message) error {
+					calls = append(calls, "before-send")
+					return nil
+				},
+				AfterSend: func(ctx context.Context, msg *mailpen.Message, err error) {
+					if err != nil {
+						calls = append(calls, "after-send:"+err.Error())
+						return
+					}
+					calls = append(calls, "after-send")
+				},
+			}
+
+			mock := &mockProvider{err: tt.sendErr}
+			mp, err := mailpen.New(mock, &mailpen.Config{From: "sender@example.com"}, mailpen.WithHooks(recorder))
+			require.NoError(t, err)
+			mp.AddHooks(tt.hooks)
+
+			msg := mailpen.NewMessage().To("recipient@example.com").Subject("Test").Must()
+			err = mp.Send(context.Background(), msg)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantCalls, calls)
+			assert.Equal(t, tt.sendCalls, mock.sendCalls)
+		})
+	}
+}
+
+func TestMailpen_HooksAfterRender(t *testing.T) {
+	mock := &mockProvider{}
+	mp, err := mailpen.New(mock, &mailpen.Config{
+		From:    "sender@example.com",
+		Sources: []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+	}, mailpen.WithHooks(mailpen.Hooks{
+		AfterRender: func(ctx context.Context, msg *mailpen.Message, rendered *mailpen.RenderedEmail) error {
+			require.NotNil(t, rendered)
+			assert.Contains(t, rendered.HTML, "Welcome, John!")
+			return nil
+		},
+		BeforeSend: func(ctx context.Context, msg *mailpen.Message) error {
+			msg.Headers = map[string]string{"X-Audit": "checked"}
+			return nil
+		},
+	}))
+	require.NoError(t, err)
+
+	msg := mailpen.NewMessage().
+		To("recipient@example.com").
+		Template("welcome").
+		WithData(map[string]any{"Name": "John"}).
+		Must()
+	require.NoError(t, mp.Send(context.Background(), msg))
+	require.NotNil(t, mock.lastMessage)
+	assert.Equal(t, "checked", mock.lastMessage.Headers["X-Audit"])
+}
@@ -0,0 +1,17 @@
+// Package hosts matches URL hosts against configured host lists.
+package hosts
+
+import "strings"
+
+// Match reports whether host equals, or is a subdomain of, any of the given hosts. Matching is case-insensitive
+// and a leading dot on a listed host is ignored.
+func Match(host string, hosts []string) bool {
+	host = strings.ToLower(host)
+	for _, h := range hosts {
+		h = strings.ToLower(strings.TrimPrefix(h, "."))
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
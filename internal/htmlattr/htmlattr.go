@@ -0,0 +1,163 @@
+// Package htmlattr rewrites attribute values in rendered HTML without re-serializing the document, so the
+// rest of the markup (including client-specific hacks and conditional comments) is left untouched.
+package htmlattr
+
+import (
+	"html"
+	"strings"
+)
+
+// attribute is an attribute of a scanned tag. start and end are the offsets of its raw value in the content,
+// excluding quotes, and start is -1 for an attribute without a value.
+type attribute struct {
+	name       string
+	start, end int
+	quote      byte // '"' or '\'' for a quoted value, 0 for an unquoted one
+}
+
+// Replace calls fn with the unescaped value of every attr attribute found on the given tags (e.g. "a") and
+// writes back the escaped result in the same quotes, or double quotes for an unquoted value. Returning the value
+// unchanged leaves the attribute as it was. An empty tags list matches every tag. Tags inside comments are matched
+// too, so links in Outlook conditional comments (such as VML buttons) are rewritten.
+func Replace(content, attr string, tags []string, fn func(tag, value string) (string, error)) (string, error) {
+	var b strings.Builder
+	last := 0
+
+	for i := 0; i < len(content); i++ {
+		if content[i] != '<' || i+1 == len(content) || !isLetter(content[i+1]) {
+			continue
+		}
+
+		name, attrs, end, ok := scanTag(content, i+1)
+		if !ok {
+			break
+		}
+		if !matchesTag(name, tags) {
+			i = end - 1
+			continue
+		}
+
+		for _, a := range attrs {
+			if a.start < 0 || !strings.EqualFold(a.name, attr) {
+				continue
+			}
+
+			value := html.UnescapeString(content[a.start:a.end])
+			replaced, err := fn(name, value)
+			if err != nil {
+				return "", err
+			}
+			if replaced == value {
+				continue
+			}
+
+			from, to, quote := a.start, a.end, `"`
+			if a.quote != 0 {
+				from, to, quote = from-1, to+1, string(a.quote)
+			}
+			b.WriteString(content[last:from])
+			b.WriteString(quote + html.EscapeString(replaced) + quote)
+			last = to
+		}
+		i = end - 1
+	}
+
+	if last == 0 {
+		return content, nil
+	}
+	b.WriteString(content[last:])
+	return b.String(), nil
+}
+
+// Find returns the unescaped value of every attr attribute on the given tags, in document order
+func Find(content, attr string, tags []string) []string {
+	var values []string
+	_, _ = Replace(content, attr, tags, func(_, value string) (string, error) {
+		values = append(values, value)
+		return value, nil
+	})
+	return values
+}
+
+// scanTag scans the opening tag whose name starts at i, following the HTML tokenizer's rules for attributes,
+// so a > inside a quoted value does not end the tag. It returns the lowercased tag name, its attributes and the
+// offset just past the closing >, or false when the tag is not closed.
+func scanTag(s string, i int) (name string, attrs []attribute, end int, ok bool) {
+	start := i
+	for i < len(s) && !isSpace(s[i]) && s[i] != '/' && s[i] != '>' {
+		i++
+	}
+	name = strings.ToLower(s[start:i])
+
+	for {
+		for i < len(s) && (isSpace(s[i]) || s[i] == '/') {
+			i++
+		}
+		if i == len(s) {
+			return "", nil, 0, false
+		}
+		if s[i] == '>' {
+			return name, attrs, i + 1, true
+		}
+
+		// The first character is always part of the name, even when it is =
+		nameStart := i
+		for i++; i < len(s) && !isSpace(s[i]) && s[i] != '/' && s[i] != '>' && s[i] != '='; i++ {
+		}
+		a := attribute{name: s[nameStart:i], start: -1}
+
+		j := i
+		for j < len(s) && isSpace(s[j]) {
+			j++
+		}
+		if j == len(s) || s[j] != '=' {
+			attrs = append(attrs, a)
+			continue
+		}
+
+		for i = j + 1; i < len(s) && isSpace(s[i]); i++ {
+		}
+		if i == len(s) {
+			return "", nil, 0, false
+		}
+
+		switch q := s[i]; q {
+		case '"', '\'':
+			n := strings.IndexByte(s[i+1:], q)
+			if n < 0 {
+				return "", nil, 0, false
+			}
+			a.start, a.end, a.quote = i+1, i+1+n, q
+			i = a.end + 1
+		case '>':
+			// An empty unquoted value, such as <a href=>
+		default:
+			a.start = i
+			for i < len(s) && !isSpace(s[i]) && s[i] != '>' {
+				i++
+			}
+			a.end = i
+		}
+		attrs = append(attrs, a)
+	}
+}
+
+func isLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func matchesTag(name string, tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		if strings.EqualFold(name, tag) {
+			return true
+		}
+	}
+	return false
+}
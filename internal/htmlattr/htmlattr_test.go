@@ -0,0 +1,109 @@
+package htmlattr_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen/internal/htmlattr"
+)
+
+func TestReplace(t *testing.T) {
+	upper := func(_, value string) (string, error) { return strings.ToUpper(value), nil }
+
+	tests := []struct {
+		name    string
+		content string
+		attr    string
+		tags    []string
+		want    string
+	}{
+		{
+			name:    "double and single quotes",
+			content: `<a href="/a">A</a><a href='/b'>B</a>`,
+			attr:    "href",
+			tags:    []string{"a"},
+			want:    `<a href="/A">A</a><a href='/B'>B</a>`,
+		},
+		{
+			name:    "quoted value containing >",
+			content: `<img alt="a>b" src="/logo.png">`,
+			attr:    "src",
+			tags:    []string{"img"},
+			want:    `<img alt="a>b" src="/LOGO.PNG">`,
+		},
+		{
+			name:    "rewrites the value containing >",
+			content: `<img alt="a>b" src="/logo.png">`,
+			attr:    "alt",
+			tags:    []string{"img"},
+			want:    `<img alt="A&gt;B" src="/logo.png">`,
+		},
+		{
+			name:    "unquoted value",
+			content: `<a href=unquoted class=x>Link</a>`,
+			attr:    "href",
+			tags:    []string{"a"},
+			want:    `<a href="UNQUOTED" class=x>Link</a>`,
+		},
+		{
+			name:    "unquoted value at the end of the tag",
+			content: `<a class=x href=/path/>Link</a>`,
+			attr:    "href",
+			tags:    []string{"a"},
+			want:    `<a class=x href="/PATH/">Link</a>`,
+		},
+		{
+			name:    "attribute names are case insensitive",
+			content: `<A HREF = "/a">A</A>`,
+			attr:    "href",
+			tags:    []string{"a"},
+			want:    `<A HREF = "/A">A</A>`,
+		},
+		{
+			name:    "other tags and attributes are untouched",
+			content: `<p title="/a"><a data-href="/b" href="/c">C</a></p>`,
+			attr:    "href",
+			tags:    []string{"a"},
+			want:    `<p title="/a"><a data-href="/b" href="/C">C</a></p>`,
+		},
+		{
+			name:    "tags inside conditional comments",
+			content: `<!--[if mso]><v:roundrect href="/a" arcsize="10%"></v:roundrect><![endif]-->`,
+			attr:    "href",
+			tags:    []string{"v:roundrect"},
+			want:    `<!--[if mso]><v:roundrect href="/A" arcsize="10%"></v:roundrect><![endif]-->`,
+		},
+		{
+			name:    "unclosed tag is left alone",
+			content: `<a href="/a">A</a><a href="/b`,
+			attr:    "href",
+			tags:    []string{"a"},
+			want:    `<a href="/A">A</a><a href="/b`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := htmlattr.Replace(tt.content, tt.attr, tt.tags, upper)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReplace_Error(t *testing.T) {
+	errBad := errors.New("bad value")
+	_, err := htmlattr.Replace(`<a href="/a">A</a>`, "href", nil, func(_, _ string) (string, error) {
+		return "", errBad
+	})
+	assert.ErrorIs(t, err, errBad)
+}
+
+func TestFind(t *testing.T) {
+	content := `<img alt="a>b" src="/one.png"><img src=/two.png><img src="/a&amp;b.png">`
+	assert.Equal(t, []string{"/one.png", "/two.png", "/a&b.png"}, htmlattr.Find(content, "src", []string{"img"}))
+}
@@ -0,0 +1,284 @@
+// Package linkcheck extracts the links from rendered emails and optionally requests them, so broken
+// call-to-action URLs are caught in CI rather than by customers.
+package linkcheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/internal/hosts"
+	"github.com/patrickward/mailpen/internal/htmlattr"
+)
+
+// Source is where a link was found
+type Source string
+
+const (
+	SourceHTML Source = "html"
+	SourceText Source = "text"
+)
+
+// Link is a URL found in an email
+type Link struct {
+	URL    string
+	Source Source
+	Tag    string // HTML tag the URL was found on (e.g. "a", "img", "v:roundrect"); empty for text links
+}
+
+// linkAttrs are the attributes that hold URLs, by tag
+var linkAttrs = []struct {
+	attr string
+	tags []string
+}{
+	{attr: "href", tags: []string{"a", "area", "v:roundrect"}},
+	{attr: "src", tags: []string{"img"}},
+}
+
+// textURL matches an http or https URL in plain text, stopping before closing punctuation
+var textURL = regexp.MustCompile(`https?://[^\s<>"'()\[\]]+[^\s<>"'()\[\].,;:!?]`)
+
+// Extract returns the http and https links in the HTML and text of an email: link targets, then image
+// sources, then URLs in the text, each in document order. Other schemes, such as mailto: and tel:, and fragment links are left out. Duplicate URLs are kept,
+// so callers can report every place a broken link appears.
+func Extract(html, text string) []Link {
+	var links []Link
+	for _, la := range linkAttrs {
+		_, _ = htmlattr.Replace(html, la.attr, la.tags, func(tag, value string) (string, error) {
+			if u := strings.TrimSpace(value); checkable(u) {
+				links = append(links, Link{URL: u, Source: SourceHTML, Tag: tag})
+			}
+			return value, nil
+		})
+	}
+
+	for _, match := range textURL.FindAllString(text, -1) {
+		links = append(links, Link{URL: match, Source: SourceText})
+	}
+
+	return links
+}
+
+// ExtractEmail returns the links in a rendered email
+func ExtractEmail(email *mailpen.RenderedEmail) []Link {
+	return Extract(email.HTML, email.Text)
+}
+
+// checkable reports whether a URL is an absolute http or https URL
+func checkable(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Result is the outcome of checking a link
+type Result struct {
+	Link
+	StatusCode int   // HTTP status of the response; zero when skipped or the request failed
+	Err        error // Request error, such as a timeout or DNS failure
+	Skipped    bool  // The link's host is not in the allowed hosts, so it was not requested
+}
+
+// Broken reports whether the link was requested and failed or returned a 4xx or 5xx status
+func (r Result) Broken() bool {
+	return !r.Skipped && (r.Err != nil || r.StatusCode >= 400)
+}
+
+// String describes the result, e.g. "https://example.com/missing: 404 Not Found"
+func (r Result) String() string {
+	switch {
+	case r.Skipped:
+		return r.URL + ": skipped"
+	case r.Err != nil:
+		return fmt.Sprintf("%s: %v", r.URL, r.Err)
+	default:
+		return fmt.Sprintf("%s: %d %s", r.URL, r.StatusCode, http.StatusText(r.StatusCode))
+	}
+}
+
+// Default checker settings
+const (
+	DefaultConcurrency = 4
+	DefaultTimeout     = 10 * time.Second
+)
+
+// Checker requests links and reports the broken ones
+type Checker struct {
+	client      *http.Client
+	allowed     []string
+	concurrency int
+	timeout     time.Duration
+	userAgent   string
+}
+
+// Option configures a Checker
+type Option func(c *Checker)
+
+// WithHTTPClient sets the HTTP client used for requests (defaults to a client that follows redirects)
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Checker) {
+		c.client = client
+	}
+}
+
+// WithAllowedHosts limits requests to links whose host is, or is a subdomain of, one of the given hosts.
+// Other links are reported as skipped. Without allowed hosts, every link is requested.
+func WithAllowedHosts(hosts ...string) Option {
+	return func(c *Checker) {
+		c.allowed = hosts
+	}
+}
+
+// WithConcurrency sets how many links are requested at once (defaults to DefaultConcurrency)
+func WithConcurrency(n int) Option {
+	return func(c *Checker) {
+		if n > 0 {
+			c.concurrency = n
+		}
+	}
+}
+
+// WithTimeout sets the timeout of each request (defaults to DefaultTimeout)
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Checker) {
+		c.timeout = timeout
+	}
+}
+
+// WithUserAgent sets the User-Agent header of requests (defaults to "mailpen-linkcheck")
+func WithUserAgent(userAgent string) Option {
+	return func(c *Checker) {
+		c.userAgent = userAgent
+	}
+}
+
+// New creates a new Checker
+func New(opts ...Option) *Checker {
+	c := &Checker{
+		client:      http.DefaultClient,
+		concurrency: DefaultConcurrency,
+		timeout:     DefaultTimeout,
+		userAgent:   "mailpen-linkcheck",
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Check requests each link and returns the results in the order of the links. Each distinct URL is requested
+// once. Links are requested with HEAD, retried with GET when the server does not allow HEAD.
+func (c *Checker) Check(ctx context.Context, links []Link) []Result {
+	results := make([]Result, len(links))
+	byURL := make(map[string][]int)
+	var urls []string
+	for i, link := range links {
+		results[i].Link = link
+		if len(c.allowed) > 0 && !c.allowedHost(link.URL) {
+			results[i].Skipped = true
+			continue
+		}
+		if _, ok := byURL[link.URL]; !ok {
+			urls = append(urls, link.URL)
+		}
+		byURL[link.URL] = append(byURL[link.URL], i)
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.concurrency)
+	for _, u := range urls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(u string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			status, err := c.request(ctx, u)
+			for _, i := range byURL[u] {
+				results[i].StatusCode, results[i].Err = status, err
+			}
+		}(u)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// CheckEmail extracts the links of a rendered email and checks them
+func (c *Checker) CheckEmail(ctx context.Context, email *mailpen.RenderedEmail) []Result {
+	return c.Check(ctx, ExtractEmail(email))
+}
+
+// allowedHost reports whether a URL's host is in the allowed hosts
+func (c *Checker) allowedHost(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return hosts.Match(u.Hostname(), c.allowed)
+}
+
+// request requests a URL and returns the response status
+func (c *Checker) request(ctx context.Context, u string) (int, error) {
+	status, err := c.do(ctx, http.MethodHead, u)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		return c.do(ctx, http.MethodGet, u)
+	}
+	return status, err
+}
+
+// do sends one request and discards the response body
+func (c *Checker) do(ctx context.Context, method, u string) (int, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	return resp.StatusCode, nil
+}
+
+// Broken returns the broken results
+func Broken(results []Result) []Result {
+	var broken []Result
+	for _, r := range results {
+		if r.Broken() {
+			broken = append(broken, r)
+		}
+	}
+	return broken
+}
+
+// AssertNoBrokenLinks checks the links of a rendered email and fails the test with each broken one
+func (c *Checker) AssertNoBrokenLinks(t testing.TB, email *mailpen.RenderedEmail) []Result {
+	t.Helper()
+
+	results := c.CheckEmail(context.Background(), email)
+	for _, r := range Broken(results) {
+		t.Errorf("broken link: %s", r)
+	}
+	return results
+}
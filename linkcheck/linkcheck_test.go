@@ -0,0 +1,132 @@
+package linkcheck_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/linkcheck"
+)
+
+func TestExtract(t *testing.T) {
+	html := `<p><a href="https://example.com/start?a=1&amp;b=2">Start</a>
+<a href="mailto:help@example.com">Email us</a> <a href="#top">Top</a> <a href="/relative">Relative</a>
+<!--[if mso]><v:roundrect href="https://example.com/start?a=1&amp;b=2"><![endif]-->
+<img src="https://cdn.example.com/logo.png" width="10" height="10"></p>`
+	text := "Start here: https://example.com/start?a=1&b=2.\nHelp (https://example.com/help) or tel:555-0100"
+
+	assert.Equal(t, []linkcheck.Link{
+		{URL: "https://example.com/start?a=1&b=2", Source: linkcheck.SourceHTML, Tag: "a"},
+		{URL: "https://example.com/start?a=1&b=2", Source: linkcheck.SourceHTML, Tag: "v:roundrect"},
+		{URL: "https://cdn.example.com/logo.png", Source: linkcheck.SourceHTML, Tag: "img"},
+		{URL: "https://example.com/start?a=1&b=2", Source: linkcheck.SourceText},
+		{URL: "https://example.com/help", Source: linkcheck.SourceText},
+	}, linkcheck.Extract(html, text))
+}
+
+func TestChecker_Check(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/get-only":
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	links := []linkcheck.Link{
+		{URL: server.URL + "/ok"},
+		{URL: server.URL + "/missing"},
+		{URL: server.URL + "/get-only"},
+		{URL: server.URL + "/slow"},
+		{URL: server.URL + "/ok"},
+		{URL: "https://example.com/elsewhere"},
+	}
+
+	checker := linkcheck.New(
+		linkcheck.WithHTTPClient(server.Client()),
+		linkcheck.WithAllowedHosts("127.0.0.1"),
+		linkcheck.WithConcurrency(2),
+		linkcheck.WithTimeout(50*time.Millisecond),
+	)
+	results := checker.Check(context.Background(), links)
+	require.Len(t, results, len(links))
+
+	assert.Equal(t, http.StatusOK, results[0].StatusCode)
+	assert.False(t, results[0].Broken())
+	assert.Equal(t, http.StatusNotFound, results[1].StatusCode)
+	assert.True(t, results[1].Broken())
+	assert.Equal(t, server.URL+"/missing: 404 Not Found", results[1].String())
+	assert.Equal(t, http.StatusOK, results[2].StatusCode, "falls back to GET")
+	assert.Error(t, results[3].Err, "times out")
+	assert.True(t, results[3].Broken())
+	assert.Equal(t, http.StatusOK, results[4].StatusCode)
+	assert.True(t, results[5].Skipped)
+	assert.False(t, results[5].Broken())
+
+	assert.Len(t, linkcheck.Broken(results), 2)
+	assert.Equal(t, int32(5), requests.Load(), "duplicate URLs are requested once")
+}
+
+func TestChecker_CheckEmail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/dashboard" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	email := &mailpen.RenderedEmail{
+		HTML: `<a href="` + server.URL + `/dashboard">Dashboard</a> <a href="` + server.URL + `/old-offer">Offer</a>`,
+		Text: "Dashboard: " + server.URL + "/dashboard",
+	}
+
+	broken := linkcheck.Broken(linkcheck.New().CheckEmail(context.Background(), email))
+	require.Len(t, broken, 1)
+	assert.Equal(t, server.URL+"/old-offer", broken[0].URL)
+	assert.Equal(t, "a", broken[0].Tag)
+}
+
+// recordingT records test failures
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestChecker_AssertNoBrokenLinks(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	rt := &recordingT{TB: t}
+	linkcheck.New().AssertNoBrokenLinks(rt, &mailpen.RenderedEmail{HTML: `<a href="` + server.URL + `/gone">Gone</a>`})
+	assert.Equal(t, []string{"broken link: " + server.URL + "/gone: 404 Not Found"}, rt.errors)
+
+	rt = &recordingT{TB: t}
+	linkcheck.New(linkcheck.WithAllowedHosts("example.com")).AssertNoBrokenLinks(rt, &mailpen.RenderedEmail{HTML: `<a href="` + server.URL + `/gone">Gone</a>`})
+	assert.Empty(t, rt.errors)
+}
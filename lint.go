@@ -0,0 +1,179 @@
+package mailpen
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+)
+
+// LintKind identifies the kind of problem reported by Lint
+type LintKind string
+
+const (
+	LintParseError      LintKind = "parse-error"      // The email template does not parse
+	LintUnknownTemplate LintKind = "unknown-template" // A {{template}} call names a template that is not defined
+	LintMissingText     LintKind = "missing-text"     // The email has an HTML version but no text version
+	LintMissingHTML     LintKind = "missing-html"     // The email has a text version but no HTML version
+	LintInvalidSample   LintKind = "invalid-sample"   // The email's sample data does not parse or match its schema
+)
+
+// LintIssue is a problem found in a template by Lint
+type LintIssue struct {
+	Template string   // Email file (e.g. "emails/welcome.html") or base template name (e.g. "layout:base")
+	Kind     LintKind // Kind of problem
+	Message  string   // Description of the problem
+}
+
+// String returns the issue as "template: message"
+func (i LintIssue) String() string {
+	return fmt.Sprintf("%s: %s", i.Template, i.Message)
+}
+
+// Lint checks the layouts, partials, components, and every email template in the sources for parse errors,
+// references to undefined templates, and missing text or HTML versions, and checks each email's sample data
+// against its registered schema. Issues are sorted by template.
+func (m *Manager) Lint() ([]LintIssue, error) {
+	emails, err := m.Emails()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var issues []LintIssue
+	for _, format := range []TemplateFormat{FormatHTML, FormatText} {
+		base := m.baseTemplates[format]
+		for _, t := range base.Templates() {
+			issues = append(issues, unknownTemplates(t.Name(), t.Tree, base.Lookup)...)
+		}
+
+		for _, name := range emails {
+			issues = append(issues, m.lintEmail(name, format)...)
+		}
+	}
+
+	for _, name := range emails {
+		issues = append(issues, m.lintSample(name)...)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Template < issues[j].Template })
+	return issues, nil
+}
+
+// lintEmail checks one format of an email template
+func (m *Manager) lintEmail(name string, format TemplateFormat) []LintIssue {
+	filename := path.Join(EmailsDir, name+format.Extension())
+
+	content, found := m.readEmail(name, format)
+	if !found {
+		if format == FormatText {
+			return []LintIssue{{Template: filename, Kind: LintMissingText, Message: "no text version"}}
+		}
+		return []LintIssue{{Template: filename, Kind: LintMissingHTML, Message: "no HTML version"}}
+	}
+
+	tmpl, err := m.baseTemplates[format].Clone()
+	if err != nil {
+		return []LintIssue{{Template: filename, Kind: LintParseError, Message: err.Error()}}
+	}
+
+	before := make(map[string]*parse.Tree)
+	for _, t := range tmpl.Templates() {
+		before[t.Name()] = t.Tree
+	}
+
+	if _, err := tmpl.New(name).Parse(content); err != nil {
+		return []LintIssue{{Template: filename, Kind: LintParseError, Message: err.Error()}}
+	}
+
+	// Only check the templates defined or redefined by the email; the base templates are checked once
+	var issues []LintIssue
+	for _, t := range tmpl.Templates() {
+		if tree, ok := before[t.Name()]; ok && tree == t.Tree {
+			continue
+		}
+		issues = append(issues, unknownTemplates(filename, t.Tree, tmpl.Lookup)...)
+	}
+
+	return issues
+}
+
+// lintSample checks that the sample data of an email parses and matches the email's schema
+func (m *Manager) lintSample(name string) []LintIssue {
+	data, err := m.sampleData(name)
+	if err != nil {
+		return []LintIssue{{Template: path.Join(EmailsDir, name), Kind: LintInvalidSample, Message: err.Error()}}
+	}
+
+	schema, ok := m.schemas[name]
+	if data == nil || !ok {
+		return nil
+	}
+
+	var issues []LintIssue
+	for _, problem := range schema.Validate(data) {
+		issues = append(issues, LintIssue{
+			Template: path.Join(EmailsDir, name),
+			Kind:     LintInvalidSample,
+			Message:  "sample data: " + problem,
+		})
+	}
+	return issues
+}
+
+// unknownTemplates reports the {{template}} calls in a parse tree that name templates lookup cannot find
+func unknownTemplates[T any](owner string, tree *parse.Tree, lookup func(string) *T) []LintIssue {
+	if tree == nil || tree.Root == nil {
+		return nil
+	}
+
+	var issues []LintIssue
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.IfNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			if lookup(n.Name) == nil {
+				issues = append(issues, LintIssue{
+					Template: owner,
+					Kind:     LintUnknownTemplate,
+					Message:  fmt.Sprintf("line %d: template %q is not defined", lineOf(tree, n), n.Name),
+				})
+			}
+		}
+	}
+	walk(tree.Root)
+
+	return issues
+}
+
+// lineOf returns the line of a node in its template
+func lineOf(tree *parse.Tree, node parse.Node) int {
+	// The location is "name:line:col", and the name may itself contain colons
+	location, _ := tree.ErrorContext(node)
+	parts := strings.Split(location, ":")
+	if len(parts) < 3 {
+		return 0
+	}
+	line, _ := strconv.Atoi(parts[len(parts)-2])
+	return line
+}
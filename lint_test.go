@@ -0,0 +1,108 @@
+package mailpen_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+func TestManager_Lint(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		schemas map[string]mailpen.DataSchema
+		want    []mailpen.LintIssue
+	}{
+		{
+			name: "clean",
+			files: fstest.MapFS{
+				"partials/header.html": {Data: []byte(`{{define "site-header"}}<h1>Acme</h1>{{end}}`)},
+				"emails/welcome.html":  {Data: []byte(`{{define "content"}}{{template "site-header" .}}{{template "@p" "Hi"}}{{end}}`)},
+				"emails/welcome.txt":   {Data: []byte(`{{define "content"}}Hi{{end}}`)},
+			},
+		},
+		{
+			name: "unknown templates",
+			files: fstest.MapFS{
+				"partials/header.html": {Data: []byte(`{{define "site-header"}}{{template "logo" .}}{{end}}`)},
+				"emails/welcome.html":  {Data: []byte("{{define \"content\"}}\n{{if .Name}}{{template \"@missing\" .}}{{end}}{{end}}")},
+				"emails/welcome.txt":   {Data: []byte(`{{define "content"}}{{range .Items}}{{template "item" .}}{{end}}{{end}}`)},
+			},
+			want: []mailpen.LintIssue{
+				{Template: "emails/welcome.html", Kind: mailpen.LintUnknownTemplate, Message: `line 2: template "@missing" is not defined`},
+				{Template: "emails/welcome.txt", Kind: mailpen.LintUnknownTemplate, Message: `line 1: template "item" is not defined`},
+				{Template: "site-header", Kind: mailpen.LintUnknownTemplate, Message: `line 1: template "logo" is not defined`},
+			},
+		},
+		{
+			name: "parse error",
+			files: fstest.MapFS{
+				"emails/welcome.html": {Data: []byte(`{{define "content"}}{{if .Name}}{{end}}`)},
+				"emails/welcome.txt":  {Data: []byte(`{{define "content"}}Hi{{end}}`)},
+			},
+			want: []mailpen.LintIssue{
+				{Template: "emails/welcome.html", Kind: mailpen.LintParseError},
+			},
+		},
+		{
+			name: "missing versions",
+			files: fstest.MapFS{
+				"emails/welcome.html": {Data: []byte(`{{define "content"}}Hi{{end}}`)},
+				"emails/receipt.txt":  {Data: []byte(`{{define "content"}}Paid{{end}}`)},
+			},
+			want: []mailpen.LintIssue{
+				{Template: "emails/receipt.html", Kind: mailpen.LintMissingHTML, Message: "no HTML version"},
+				{Template: "emails/welcome.txt", Kind: mailpen.LintMissingText, Message: "no text version"},
+			},
+		},
+		{
+			name: "invalid sample data",
+			files: fstest.MapFS{
+				"emails/welcome.html":        {Data: []byte(`{{define "content"}}Hi {{.Name}}{{end}}`)},
+				"emails/welcome.txt":         {Data: []byte(`{{define "content"}}Hi {{.Name}}{{end}}`)},
+				"emails/welcome.sample.json": {Data: []byte(`{"Name": 42}`)},
+				"emails/receipt.html":        {Data: []byte(`{{define "content"}}Paid{{end}}`)},
+				"emails/receipt.txt":         {Data: []byte(`{{define "content"}}Paid{{end}}`)},
+				"emails/receipt.sample.yaml": {Data: []byte("total: [1, 2")},
+			},
+			schemas: map[string]mailpen.DataSchema{
+				"welcome": mailpen.MapSchema{"Name": {Type: mailpen.FieldString, Required: true}},
+			},
+			want: []mailpen.LintIssue{
+				{Template: "emails/receipt", Kind: mailpen.LintInvalidSample},
+				{Template: "emails/welcome", Kind: mailpen.LintInvalidSample},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+				Sources: []mailpen.TemplateSource{{Name: "test", FS: tt.files}},
+				Schemas: tt.schemas,
+			})
+			require.NoError(t, err)
+
+			issues, err := manager.Lint()
+			require.NoError(t, err)
+			require.Len(t, issues, len(tt.want), "issues: %v", issues)
+
+			for i, want := range tt.want {
+				assert.Equal(t, want.Template, issues[i].Template)
+				assert.Equal(t, want.Kind, issues[i].Kind)
+				if want.Message != "" {
+					assert.Equal(t, want.Message, issues[i].Message)
+				}
+			}
+		})
+	}
+}
+
+func TestLintIssue_String(t *testing.T) {
+	issue := mailpen.LintIssue{Template: "emails/welcome.txt", Kind: mailpen.LintMissingText, Message: "no text version"}
+	assert.Equal(t, "emails/welcome.txt: no text version", issue.String())
+}
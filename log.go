@@ -0,0 +1,70 @@
+package mailpen
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// WithLogger sets the logger used for render and send events
+func WithLogger(logger *slog.Logger) Option {
+	return func(m *Mailpen) error {
+		m.logger = logger
+		return nil
+	}
+}
+
+// discardLogger returns a logger that drops every record
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// newMessageID generates a random message identifier
+func newMessageID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// logAttrs returns the common log attributes for a message. Recipient addresses are redacted unless
+// Config.LogRecipients is set.
+func (m *Mailpen) logAttrs(msg *Message) []any {
+	redact := !m.Config().LogRecipients
+	recipients := make([]string, 0, len(msg.To)+len(msg.Cc)+len(msg.Bcc))
+	for _, list := range [][]string{msg.To, msg.Cc, msg.Bcc} {
+		for _, addr := range list {
+			if redact {
+				addr = RedactAddress(addr)
+			}
+			recipients = append(recipients, addr)
+		}
+	}
+
+	return []any{
+		slog.String("message_id", msg.ID),
+		slog.String("provider", m.provider.Name()),
+		slog.String("template", msg.Template),
+		slog.Any("recipients", recipients),
+	}
+}
+
+// RedactAddress masks the local part of an email address, keeping its first character and the domain
+// (e.g. "jane@example.com" becomes "j***@example.com")
+func RedactAddress(addr string) string {
+	at := strings.LastIndex(addr, "@")
+	if at <= 0 {
+		return "***"
+	}
+
+	local := strings.TrimSpace(addr[:at])
+	if i := strings.LastIndex(local, "<"); i >= 0 {
+		local = local[i+1:]
+	}
+	if local == "" {
+		return "***" + strings.TrimSuffix(addr[at:], ">")
+	}
+
+	return local[:1] + "***" + strings.TrimSuffix(addr[at:], ">")
+}
@@ -0,0 +1,113 @@
+package mailpen_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+func TestRedactAddress(t *testing.T) {
+	tests := []struct {
+		name string
+		addr string
+		want string
+	}{
+		{name: "plain address", addr: "jane@example.com", want: "j***@example.com"},
+		{name: "named address", addr: "Jane Doe <jane@example.com>", want: "j***@example.com"},
+		{name: "single character", addr: "j@example.com", want: "j***@example.com"},
+		{name: "not an address", addr: "jane", want: "***"},
+		{name: "empty", addr: "", want: "***"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, mailpen.RedactAddress(tt.addr))
+		})
+	}
+}
+
+func TestMailpen_Logging(t *testing.T) {
+	tests := []struct {
+		name          string
+		logRecipients bool
+		sendErr       error
+		wantContains  []string
+		wantMissing   []string
+	}{
+		{
+			name:         "successful send redacts recipients",
+			wantContains: []string{"mailpen: send attempt", "mailpen: sent", "provider=mock", "message_id=", "r***@example.com"},
+			wantMissing:  []string{"recipient@example.com"},
+		},
+		{
+			name:          "full recipients when enabled",
+			logRecipients: true,
+			wantContains:  []string{"recipient@example.com"},
+		},
+		{
+			name:         "failed send",
+			sendErr:      errors.New("connection refused"),
+			wantContains: []string{"mailpen: send failed", "connection refused"},
+			wantMissing:  []string{"mailpen: sent "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+			mock := &mockProvider{err: tt.sendErr}
+			mp, err := mailpen.New(mock, &mailpen.Config{
+				From:          "sender@example.com",
+				LogRecipients: tt.logRecipients,
+			}, mailpen.WithLogger(logger))
+			require.NoError(t, err)
+
+			msg := mailpen.NewMessage().To("recipient@example.com").Subject("Test").Must()
+			_ = mp.Send(context.Background(), msg)
+			assert.NotEmpty(t, msg.ID)
+
+			out := buf.String()
+			for _, want := range tt.wantContains {
+				assert.Contains(t, out, want)
+			}
+			for _, missing := range tt.wantMissing {
+				assert.NotContains(t, out, missing)
+			}
+		})
+	}
+}
+
+func TestMailpen_LoggingRender(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	mp, err := mailpen.New(&mockProvider{}, &mailpen.Config{
+		From:    "sender@example.com",
+		Sources: []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+		Logger:  logger,
+	})
+	require.NoError(t, err)
+
+	msg := mailpen.NewMessage().
+		ID("msg-42").
+		To("recipient@example.com").
+		Template("welcome").
+		WithData(map[string]any{"Name": "John"}).
+		Must()
+	require.NoError(t, mp.Send(context.Background(), msg))
+
+	out := buf.String()
+	assert.Contains(t, out, "mailpen: render started")
+	assert.Contains(t, out, "mailpen: render finished")
+	assert.Contains(t, out, "template=welcome")
+	assert.Contains(t, out, "message_id=msg-42")
+}
@@ -4,13 +4,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	gomail "github.com/wneessen/go-mail"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
-	ErrNoContent = errors.New("email must have either plain text or HTML body")
-	ErrNoSubject = errors.New("email must have a subject")
+	ErrNoContent           = errors.New("email must have either plain text or HTML body")
+	ErrNoSubject           = errors.New("email must have a subject")
+	ErrNoRecipients        = errors.New("email must have at least one recipient")
+	ErrProviderUnavailable = errors.New("provider unavailable") // The provider could not be reached; wrapped with the cause
 )
 
 // SMTPClient defines the interface for an SMTP client, mainly used for testing
@@ -23,6 +32,34 @@ type HTMLProcessor interface {
 	Process(html string) (string, error)
 }
 
+// HTMLAnalyzer defines the interface for inspecting processed HTML without modifying it. Reported warnings are
+// returned alongside the rendered email.
+type HTMLAnalyzer interface {
+	Analyze(html string) ([]string, error)
+}
+
+// MessageProcessor defines the interface for processing a fully rendered message before it is sent. Unlike an
+// HTMLProcessor, it has access to the whole message, including its metadata.
+type MessageProcessor interface {
+	ProcessMessage(ctx context.Context, msg *Message) error
+}
+
+// TextConverter defines the interface for deriving a plain-text body from rendered HTML
+type TextConverter interface {
+	Convert(html string) (string, error)
+}
+
+// Mailer is the sending and rendering surface of *Mailpen. Application services can depend on it instead of
+// *Mailpen so tests can substitute a mailpentest.Recorder.
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+	SendWithResult(ctx context.Context, msg *Message) (*SendResult, error)
+	SendMany(ctx context.Context, msgs []*Message) []BatchResult
+	SendAsync(ctx context.Context, msg *Message) *Future
+	Enqueue(ctx context.Context, msg *Message) error
+	Render(ctx context.Context, msg *Message) (*RenderedEmail, error)
+}
+
 // StringList is an alias for a slice of strings
 type StringList = []string
 
@@ -31,10 +68,33 @@ type Option func(mailpen *Mailpen) error
 
 // Mailpen handles email sending operations
 type Mailpen struct {
-	config        *Config
+	config        *atomic.Pointer[Config]
 	provider      Provider
 	templateMgr   *Manager
+	sources       []TemplateSource // Template sources added by options, before the manager is created
+	defaultLayout string           // Layout for messages that do not name one (defaults to the manager's)
+	renderTiming  RenderTimingFunc // Render timing set by an option, before the manager is created
 	htmlProcessor HTMLProcessor
+	middleware    []Middleware
+	hooks         []Hooks
+	logger        *slog.Logger
+	tracer        trace.Tracer
+	queue         Queue
+	pending       *asyncSends
+	limiter       *RateLimiter
+	adaptive      *AdaptiveThrottle
+	retry         RetryPolicy
+	suppressions  SuppressionStore
+	safetyNet     *SafetyNet
+	dedup         *Dedup
+	history       History
+	events        *eventBus
+	closed        *atomic.Bool
+	clock         Clock
+	sendTimeout   time.Duration
+	processors    []MessageProcessor
+	theme         map[string]any // Theme overrides of an instance derived with With
+	variant       string         // Template cache variant for theme
 }
 
 // New creates a new Mailpen instance using the provided configuration and the default SMTP client
@@ -47,29 +107,29 @@ func New(provider Provider, config *Config, opts ...Option) (*Mailpen, error) {
 		return nil, errors.New("config is required")
 	}
 
-	tmOpts := &ManagerConfig{
-		FuncMap:       config.FuncMap,
-		Processor:     config.HTMLProcessor,
-		Sources:       config.Sources,
-		Theme:         config.Theme,
-		DefaultLayout: config.DefaultLayout,
-	}
-
-	tm, err := NewManager(tmOpts)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create templates manager: %w", err)
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
 
 	mp := &Mailpen{
-		config:      config,
-		provider:    provider,
-		templateMgr: tm,
+		config:       &atomic.Pointer[Config]{},
+		provider:     provider,
+		logger:       config.Logger,
+		tracer:       newTracer(config.TracerProvider),
+		retry:        config.RetryPolicy,
+		suppressions: config.Suppressions,
+		safetyNet:    config.SafetyNet,
+		dedup:        config.Dedup,
+		history:      config.History,
+		clock:        systemClock{},
+		sendTimeout:  config.SendTimeout,
+		processors:   slices.Clone(config.MessageProcessors),
+		pending:      &asyncSends{},
+		events:       &eventBus{},
+		closed:       &atomic.Bool{},
 	}
 
-	// Apply additional template sources
-	if err := mp.addTemplateSources(config.Sources); err != nil {
-		return nil, fmt.Errorf("failed to add template sources: %w", err)
-	}
+	mp.config.Store(config)
 
 	// Apply options
 	for _, opt := range opts {
@@ -78,58 +138,422 @@ func New(provider Provider, config *Config, opts ...Option) (*Mailpen, error) {
 		}
 	}
 
-	return mp, nil
-}
+	// Create the template manager once the options are known, so a shared manager skips parsing entirely
+	if err := mp.setupManager(config); err != nil {
+		return nil, err
+	}
+
+	if mp.logger == nil {
+		mp.logger = discardLogger()
+	}
+
+	for _, warning := range config.Warnings() {
+		mp.logger.Warn("mailpen: incomplete config", slog.String("warning", warning))
+	}
 
-// addTemplateSource adds a new template source to the templates manager
-func (m *Mailpen) addTemplateSource(source TemplateSource) error {
-	return m.templateMgr.AddSource(source)
+	// Tag messages last, after any processors added by options
+	if !IsProduction(config.Environment) {
+		mp.processors = append(mp.processors, EnvironmentTagger{
+			Environment: config.Environment,
+			Watermark:   config.EnvironmentWatermark,
+		})
+	}
+
+	if mp.dedup != nil && mp.dedup.Store == nil {
+		return nil, errors.New("dedup store is required")
+	}
+
+	if rp, ok := provider.(RetryPolicyProvider); ok && mp.retry == nil {
+		mp.retry = rp.DefaultRetryPolicy()
+	}
+	if mp.retry == nil {
+		mp.retry = NoRetry
+	}
+
+	if mp.limiter == nil && (config.RateLimit.PerSecond > 0 || len(config.DomainRateLimits) > 0) {
+		mp.limiter = NewRateLimiter(config.RateLimit, config.DomainRateLimits)
+	}
+
+	return mp, nil
 }
 
-// addTemplateSources adds additional template sources
-func (m *Mailpen) addTemplateSources(sources []TemplateSource) error {
-	for _, source := range sources {
-		if err := m.addTemplateSource(source); err != nil {
-			return fmt.Errorf("failed to add template source: %w", err)
+// setupManager creates the template manager from the template fields of the config and the options, unless
+// WithManager set a shared one
+func (m *Mailpen) setupManager(config *Config) error {
+	if m.templateMgr != nil {
+		switch {
+		case len(m.sources) > 0:
+			return errors.New("template sources cannot be added to a shared manager")
+		case m.renderTiming != nil:
+			return errors.New("render timing cannot be set on a shared manager")
 		}
+		return nil
+	}
+
+	renderTiming := config.RenderTiming
+	if m.renderTiming != nil {
+		renderTiming = m.renderTiming
 	}
+
+	tm, err := NewManager(&ManagerConfig{
+		FuncMap:           config.FuncMap,
+		Processor:         config.HTMLProcessor,
+		Processors:        config.Processors,
+		TextConverter:     config.TextConverter,
+		Analyzers:         config.Analyzers,
+		Sources:           append(slices.Clone(config.Sources), m.sources...),
+		Theme:             config.Theme,
+		ThemeFile:         config.ThemeFile,
+		DefaultLayout:     config.DefaultLayout,
+		DevMode:           config.DevMode,
+		StrictTheme:       config.StrictTheme,
+		TemplateCacheSize: config.TemplateCacheSize,
+		RenderPolicy:      config.RenderPolicy,
+		Schemas:           config.Schemas,
+		RenderTiming:      renderTiming,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create templates manager: %w", err)
+	}
+
+	tm.tracer = m.tracer
+	m.templateMgr = tm
 	return nil
 }
 
-// Config returns the mailpen configuration
+// Manager returns the template manager, which WithManager can share with other instances
+func (m *Mailpen) Manager() *Manager {
+	return m.templateMgr
+}
+
+// Config returns the current mailpen configuration. It must not be modified; use UpdateConfig to change
+// it at runtime.
 func (m *Mailpen) Config() *Config {
-	return m.config
+	return m.config.Load()
 }
 
 // Send sends an email using the provided templates and data
 func (m *Mailpen) Send(ctx context.Context, msg *Message) error {
-	if err := m.processTemplates(msg); err != nil {
-		return fmt.Errorf("failed to process templates: %w", err)
+	if deferred, err := m.schedule(ctx, msg); deferred || err != nil {
+		return err
+	}
+	return m.chain(m.send)(ctx, msg)
+}
+
+// send renders, processes, and delivers a message. It is the innermost function of the middleware chain.
+func (m *Mailpen) send(ctx context.Context, msg *Message) (err error) {
+	ctx, span := m.startSendSpan(ctx, msg)
+	defer func() { endSpan(span, err) }()
+
+	if res := resultFrom(ctx); res != nil {
+		res.MessageID = msg.ID
+	}
+
+	ctx, cancel := m.withSendTimeout(ctx)
+	defer cancel()
+
+	key, err := m.reserveDedup(ctx, msg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			m.releaseDedup(ctx, msg, key)
+		}
+	}()
+
+	if err := m.prepare(ctx, msg); err != nil {
+		return err
+	}
+
+	return m.dispatch(ctx, msg)
+}
+
+// withSendTimeout applies Config.SendTimeout, if set, to a send's context
+func (m *Mailpen) withSendTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.sendTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, m.sendTimeout)
+}
+
+// startSendSpan assigns the message ID, if needed, and starts the span covering a message's send
+func (m *Mailpen) startSendSpan(ctx context.Context, msg *Message) (context.Context, trace.Span) {
+	if msg.ID == "" {
+		msg.ID = newMessageID()
+	}
+
+	return m.tracer.Start(ctx, "mailpen.send", trace.WithAttributes(
+		attribute.String("mailpen.message_id", msg.ID),
+		attribute.String("mailpen.template", msg.Template),
+		attribute.String("mailpen.layout", msg.Layout),
+		attribute.String("mailpen.provider", m.provider.Name()),
+		attribute.Int("mailpen.recipients", len(msg.To)+len(msg.Cc)+len(msg.Bcc)),
+	))
+}
+
+// prepare renders and processes a message, leaving it ready for the provider
+func (m *Mailpen) prepare(ctx context.Context, msg *Message) error {
+	if len(msg.To)+len(msg.Cc)+len(msg.Bcc) == 0 {
+		return Permanent(ErrNoRecipients)
+	}
+
+	if err := m.filterSuppressed(ctx, msg); err != nil {
+		return err
+	}
+
+	m.addAlwaysBcc(msg)
+
+	blocked, err := m.applySafetyNet(ctx, msg)
+	if err != nil {
+		return err
+	}
+
+	if _, err := m.renderMessage(ctx, msg); err != nil {
+		return err
+	}
+	annotateSubject(msg, blocked)
+
+	return m.runBeforeSend(ctx, msg)
+}
+
+// renderMessage resolves the brand, renders the templates, fills in the sender, and runs the message
+// processors and render hooks. It returns the rendered email, or nil when the message has no template.
+func (m *Mailpen) renderMessage(ctx context.Context, msg *Message) (*RenderedEmail, error) {
+	brand, err := m.resolveBrand(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve brand kit: %w", err)
+	}
+
+	if err := m.runBeforeRender(ctx, msg); err != nil {
+		return nil, err
+	}
+
+	attrs := m.logAttrs(msg)
+	renderStart := time.Now()
+	if msg.Template != "" {
+		m.logger.DebugContext(ctx, "mailpen: render started", attrs...)
+	}
+
+	rendered, err := m.processTemplates(ctx, msg, brand)
+	if err != nil {
+		m.logger.ErrorContext(ctx, "mailpen: render failed", append(attrs, slog.Any("error", err))...)
+		// Rendering the same message again fails the same way, so a queue should not retry it
+		return nil, Permanent(fmt.Errorf("failed to process templates: %w", err))
+	}
+
+	if rendered != nil {
+		renderDuration := time.Since(renderStart)
+		if res := resultFrom(ctx); res != nil {
+			res.RenderDuration = renderDuration
+		}
+		m.logger.DebugContext(ctx, "mailpen: render finished", append(attrs,
+			slog.Duration("duration", renderDuration),
+			slog.Int("warnings", len(rendered.Warnings)),
+		)...)
+	}
+
+	if err := m.runAfterRender(ctx, msg, rendered); err != nil {
+		return nil, err
 	}
 
 	if msg.From == "" {
-		msg.From = m.config.From
+		msg.From = m.Config().From
+		if brand != nil {
+			msg.From = brand.from(msg.From)
+		}
+	}
+
+	ctx = context.WithValue(ctx, layoutKey{}, m.messageLayout(msg))
+	for _, processor := range m.processors {
+		if err := processor.ProcessMessage(ctx, msg); err != nil {
+			return nil, fmt.Errorf("failed to process message: %w", err)
+		}
+	}
+
+	return rendered, nil
+}
+
+// dispatch sends a prepared message through the provider, retrying failures according to the retry policy
+func (m *Mailpen) dispatch(ctx context.Context, msg *Message) error {
+	start := time.Now()
+
+	rewind, restore := m.replayAttachments(msg)
+	defer restore()
+
+	for attempt := 1; ; attempt++ {
+		if err := m.throttle(ctx, msg); err != nil {
+			return err
+		}
+		if attempt > 1 {
+			if err := rewind(); err != nil {
+				m.sent(ctx, msg, attempt-1, err, time.Since(start))
+				return err
+			}
+		}
+
+		m.logger.DebugContext(ctx, "mailpen: send attempt", append(m.logAttrs(msg), slog.Int("attempt", attempt))...)
+		attemptStart := time.Now()
+		err := m.sendProvider(ctx, msg)
+		if m.adaptive != nil {
+			m.adaptive.Observe(msg, err)
+		}
+		m.record(ctx, msg, attempt, attemptStart, err)
+		if err == nil {
+			m.sent(ctx, msg, attempt, nil, time.Since(start))
+			return nil
+		}
+
+		delay, retry := m.retry.Retry(attempt, err)
+		if !retry {
+			m.sent(ctx, msg, attempt, err, time.Since(start))
+			return err
+		}
+		if hint, ok := RetryAfterHint(err); ok && hint > delay {
+			delay = hint
+		}
+
+		m.logger.WarnContext(ctx, "mailpen: retrying send", append(m.logAttrs(msg),
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+			slog.Any("error", err),
+		)...)
+
+		if err := sleep(ctx, delay); err != nil {
+			m.sent(ctx, msg, attempt, err, time.Since(start))
+			return err
+		}
+	}
+}
+
+// sleep waits for the duration or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// throttle waits for the rate limiter and the adaptive throttle, if any, before a message is handed to the
+// provider
+func (m *Mailpen) throttle(ctx context.Context, msg *Message) error {
+	var waited time.Duration
+	if m.limiter != nil {
+		d, err := m.limiter.Wait(ctx, msg)
+		if err != nil {
+			return fmt.Errorf("rate limit wait: %w", err)
+		}
+		waited += d
+	}
+
+	if m.adaptive != nil {
+		d, err := m.adaptive.Wait(ctx, msg)
+		if err != nil {
+			return fmt.Errorf("adaptive throttle wait: %w", err)
+		}
+		waited += d
+	}
+
+	if waited > 0 {
+		m.logger.DebugContext(ctx, "mailpen: throttled", append(m.logAttrs(msg), slog.Duration("wait", waited))...)
+	}
+	return nil
+}
+
+// sent logs the outcome of a provider send, records it in the send result, runs the AfterSend hooks, and
+// publishes Sent events
+func (m *Mailpen) sent(ctx context.Context, msg *Message, attempts int, err error, duration time.Duration) {
+	attrs := append(m.logAttrs(msg), slog.Duration("duration", duration))
+	if err != nil {
+		m.logger.ErrorContext(ctx, "mailpen: send failed", append(attrs, slog.Any("error", err))...)
+	} else {
+		m.logger.InfoContext(ctx, "mailpen: sent", attrs...)
+	}
+	m.recordResult(ctx, msg, attempts, duration, err)
+	m.runAfterSend(ctx, msg, err)
+	if err == nil {
+		m.publishMessage(ctx, EventSent, msg)
+	}
+}
+
+// sendProvider sends a message through the provider inside its own span
+func (m *Mailpen) sendProvider(ctx context.Context, msg *Message) (err error) {
+	ctx, span := m.tracer.Start(ctx, "mailpen.provider.send", trace.WithAttributes(
+		attribute.String("mailpen.provider", m.providerName(msg)),
+	), trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { endSpan(span, err) }()
+
+	restore, err := m.limitAttachments(msg)
+	if err != nil {
+		return err
 	}
+	defer restore()
 
-	// Send via provider
 	return m.provider.Send(ctx, msg)
 }
 
 // NewTemplateData creates a new templates data map with default values
 func (m *Mailpen) NewTemplateData() TemplateData {
-	return NewTemplateData(m.config)
+	return newTemplateData(m.Config(), m.clock.Now())
 }
 
-func (m *Mailpen) processTemplates(msg *Message) error {
+// resolveBrand returns the brand kit for the message, or nil when no resolver is configured. Kits returned by
+// custom resolvers are validated here, since they are never registered up front.
+func (m *Mailpen) resolveBrand(msg *Message) (*BrandKit, error) {
+	resolver := m.Config().BrandResolver
+	if resolver == nil {
+		return nil, nil
+	}
+
+	brand, err := resolver(msg)
+	if err != nil || brand == nil {
+		return brand, err
+	}
+	if err := brand.Validate(); err != nil {
+		return nil, Permanent(err)
+	}
+	return brand, nil
+}
+
+// processTemplates renders the message template into the message bodies, and into the subject when the message
+// has none. It returns nil when the message has no template.
+func (m *Mailpen) processTemplates(ctx context.Context, msg *Message, brand *BrandKit) (*RenderedEmail, error) {
 	if msg.Template == "" {
-		return nil
+		return nil, nil
 	}
 
-	data := m.prepareTemplateData(msg.Data)
+	data := m.prepareTemplateData(msg.Data, brand)
 
-	rendered, err := m.templateMgr.RenderEmail(msg.Template, data, msg.Layout)
+	opts := RenderOptions{Layout: m.messageLayout(msg), Message: msg}
+	if m.theme != nil {
+		opts.Variant = m.variant
+		opts.Theme = MergeTheme(m.templateMgr.Theme(), m.theme)
+	}
+	if brand != nil {
+		base := m.templateMgr.Theme()
+		if opts.Theme != nil {
+			base = opts.Theme
+		}
+		opts.Variant = strings.TrimPrefix(opts.Variant+"/brand:"+brand.ID, "/")
+		opts.Theme = brand.theme(base)
+	}
+
+	rendered, err := m.templateMgr.Render(ctx, msg.Template, data, opts)
 	if err != nil {
-		return fmt.Errorf("failed to render email: %w", err)
+		return nil, fmt.Errorf("failed to render email: %w", err)
+	}
+
+	if msg.Subject == "" {
+		msg.Subject = rendered.Subject
 	}
 
 	if rendered.Text != "" {
@@ -140,30 +564,43 @@ func (m *Mailpen) processTemplates(msg *Message) error {
 		msg.HTMLBody = rendered.HTML
 	}
 
-	return nil
+	return rendered, nil
 }
 
-func (m *Mailpen) prepareTemplateData(data map[string]any) TemplateData {
-	// Merge data with default values
-	data = mergeData(m.NewTemplateData(), data)
+func (m *Mailpen) prepareTemplateData(data map[string]any, brand *BrandKit) TemplateData {
+	// Merge data with default values, applying brand overrides before the message data. The config is read
+	// once, so a concurrent UpdateConfig cannot mix old and new values in one render.
+	config, now := m.Config(), m.clock.Now()
+	base := newTemplateData(config, now)
+	if brand != nil {
+		base = base.Merge(brand.templateData(config, now))
+	}
+	data = base.DeepMerge(data)
 
 	// Add global data
-	data["Config"] = m.config
+	data["Config"] = config
 
 	return data
 }
 
-// mergeData merges two data maps
-func mergeData(base, overlay map[string]any) map[string]any {
-	result := make(map[string]any)
-
-	for k, v := range base {
-		result[k] = v
+// addAlwaysBcc blind-copies the Config.AlwaysBcc addresses that are not already recipients of the message,
+// unless the message opts out with SkipArchive
+func (m *Mailpen) addAlwaysBcc(msg *Message) {
+	if msg.SkipArchive || len(m.Config().AlwaysBcc) == 0 {
+		return
 	}
 
-	for k, v := range overlay {
-		result[k] = v
+	seen := make(map[string]bool)
+	for _, list := range [][]string{msg.To, msg.Cc, msg.Bcc} {
+		for _, addr := range list {
+			seen[NormalizeAddress(addr)] = true
+		}
 	}
 
-	return result
+	for _, addr := range m.Config().AlwaysBcc {
+		if key := NormalizeAddress(addr); !seen[key] {
+			seen[key] = true
+			msg.Bcc = append(msg.Bcc, addr)
+		}
+	}
 }
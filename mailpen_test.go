@@ -3,14 +3,19 @@ package mailpen_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/suppression"
 )
 
+var _ mailpen.Mailer = (*mailpen.Mailpen)(nil)
+
 // mockProvider implements mailpen.Provider for testing
 type mockProvider struct {
 	sendCalls    int
@@ -209,3 +214,126 @@ func TestMailpen_Send(t *testing.T) {
 		})
 	}
 }
+
+func TestMailpen_SendSentinelErrors(t *testing.T) {
+	store := suppression.NewMemoryStore()
+	require.NoError(t, store.Suppress(context.Background(), mailpen.Suppression{Address: "bounced@example.com", Reason: mailpen.SuppressionBounce}))
+
+	tests := []struct {
+		name        string
+		message     *mailpen.Message
+		providerErr error
+		want        error
+	}{
+		{
+			name:    "no recipients",
+			message: &mailpen.Message{Subject: "Test", TextBody: "Hello"},
+			want:    mailpen.ErrNoRecipients,
+		},
+		{
+			name:    "template not found",
+			message: &mailpen.Message{To: []string{"recipient@example.com"}, Template: "missing"},
+			want:    mailpen.ErrTemplateNotFound,
+		},
+		{
+			name:    "layout not found",
+			message: &mailpen.Message{To: []string{"recipient@example.com"}, Template: "welcome", Layout: "missing"},
+			want:    mailpen.ErrLayoutNotFound,
+		},
+		{
+			name:    "suppressed recipient",
+			message: &mailpen.Message{To: []string{"bounced@example.com"}, Subject: "Test", TextBody: "Hello"},
+			want:    mailpen.ErrSuppressedRecipient,
+		},
+		{
+			name:        "provider unavailable",
+			message:     &mailpen.Message{To: []string{"recipient@example.com"}, Subject: "Test", TextBody: "Hello"},
+			providerErr: fmt.Errorf("%w: connection refused", mailpen.ErrProviderUnavailable),
+			want:        mailpen.ErrProviderUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockProvider{err: tt.providerErr}
+			mp, err := mailpen.New(mock, &mailpen.Config{
+				From:         "sender@example.com",
+				Sources:      []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+				Suppressions: store,
+			})
+			require.NoError(t, err)
+
+			err = mp.Send(context.Background(), tt.message)
+			assert.ErrorIs(t, err, tt.want)
+			if tt.providerErr == nil {
+				assert.Zero(t, mock.sendCalls)
+			}
+		})
+	}
+}
+
+// hangingProvider blocks each send until its context is done
+type hangingProvider struct {
+	mockProvider
+}
+
+func (p *hangingProvider) Send(ctx context.Context, msg *mailpen.Message) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestMailpen_SendTimeout(t *testing.T) {
+	mp, err := mailpen.New(&hangingProvider{}, &mailpen.Config{
+		From:        "sender@example.com",
+		SendTimeout: 20 * time.Millisecond,
+		RetryPolicy: mailpen.ExponentialRetry{MaxAttempts: 5, InitialDelay: time.Millisecond},
+	})
+	require.NoError(t, err)
+
+	msg := mailpen.NewMessage().To("recipient@example.com").Subject("Test").Must()
+	msg.TextBody = "Hello"
+
+	start := time.Now()
+	err = mp.Send(context.Background(), msg)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second, "the timeout covers retries")
+}
+
+func TestMailpen_AlwaysBcc(t *testing.T) {
+	mock := &mockProvider{}
+	mp, err := mailpen.New(mock, &mailpen.Config{
+		From:      "sender@example.com",
+		AlwaysBcc: []string{"archive@example.com", "legal@example.com"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		message *mailpen.Message
+		wantBcc []string
+	}{
+		{
+			name:    "archive added",
+			message: mailpen.NewMessage().To("user@example.com").Bcc("audit@example.com").Subject("Receipt").Must(),
+			wantBcc: []string{"audit@example.com", "archive@example.com", "legal@example.com"},
+		},
+		{
+			name:    "existing recipients not duplicated",
+			message: mailpen.NewMessage().To("user@example.com").Cc("Legal@Example.com").Subject("Receipt").Must(),
+			wantBcc: []string{"archive@example.com"},
+		},
+		{
+			name:    "opt out",
+			message: mailpen.NewMessage().To("user@example.com").Subject("Password reset").SkipArchive().Must(),
+			wantBcc: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.message.TextBody = "Hello"
+			require.NoError(t, mp.Send(context.Background(), tt.message))
+			assert.Equal(t, tt.wantBcc, mock.lastMessage.Bcc)
+		})
+	}
+}
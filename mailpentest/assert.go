@@ -0,0 +1,88 @@
+package mailpentest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// AssertSentTo asserts that a message was sent to the address as a To, Cc, or Bcc recipient. Addresses are
+// compared case-insensitively.
+func AssertSentTo(t testing.TB, box Mailbox, address string) bool {
+	t.Helper()
+
+	messages := box.SentMessages(t)
+	for _, msg := range messages {
+		for _, list := range [][]string{msg.To, msg.Cc, msg.Bcc} {
+			for _, recipient := range list {
+				if strings.EqualFold(recipient, address) {
+					return true
+				}
+			}
+		}
+	}
+
+	t.Errorf("no message was sent to %s\n%s", address, summary(messages))
+	return false
+}
+
+// AssertSubjectContains asserts that a message was sent with a subject containing substr
+func AssertSubjectContains(t testing.TB, box Mailbox, substr string) bool {
+	t.Helper()
+
+	messages := box.SentMessages(t)
+	for _, msg := range messages {
+		if strings.Contains(msg.Subject, substr) {
+			return true
+		}
+	}
+
+	t.Errorf("no message was sent with a subject containing %q\n%s", substr, summary(messages))
+	return false
+}
+
+// AssertHTMLContains asserts that a message was sent with an HTML body containing substr
+func AssertHTMLContains(t testing.TB, box Mailbox, substr string) bool {
+	t.Helper()
+
+	messages := box.SentMessages(t)
+	for _, msg := range messages {
+		if strings.Contains(msg.HTML, substr) {
+			return true
+		}
+	}
+
+	t.Errorf("no message was sent with an HTML body containing %q\n%s", substr, summary(messages))
+	return false
+}
+
+// AssertAttachmentCount asserts that a message was sent with exactly count attachments
+func AssertAttachmentCount(t testing.TB, box Mailbox, count int) bool {
+	t.Helper()
+
+	messages := box.SentMessages(t)
+	for _, msg := range messages {
+		if len(msg.Attachments) == count {
+			return true
+		}
+	}
+
+	t.Errorf("no message was sent with %d attachment(s)\n%s", count, summary(messages))
+	return false
+}
+
+// summary describes the sent messages for a failure message
+func summary(messages []Message) string {
+	if len(messages) == 0 {
+		return "no messages were sent"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d message(s) sent:", len(messages))
+	for i, msg := range messages {
+		recipients := append(append(append([]string(nil), msg.To...), msg.Cc...), msg.Bcc...)
+		fmt.Fprintf(&b, "\n  %d. to %s, subject %q, %d attachment(s)", i+1, strings.Join(recipients, ", "),
+			msg.Subject, len(msg.Attachments))
+	}
+	return b.String()
+}
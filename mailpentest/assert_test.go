@@ -0,0 +1,125 @@
+package mailpentest_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/mailpentest"
+	"github.com/patrickward/mailpen/providers/memory"
+)
+
+// recordingT captures failures instead of failing the test
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func sentMailbox(t *testing.T) mailpentest.Mailbox {
+	t.Helper()
+
+	provider := memory.New()
+	mp, err := mailpen.New(provider, &mailpen.Config{From: "sender@example.com"})
+	require.NoError(t, err)
+
+	err = mp.Send(context.Background(), &mailpen.Message{
+		To:       []string{"Ada Lovelace <ada@example.com>"},
+		Cc:       []string{"team@example.com"},
+		Subject:  "Your invoice #42",
+		HTMLBody: `<p>Total: <strong>$10</strong></p>`,
+		TextBody: "Total: $10",
+		Attachments: []mailpen.Attachment{
+			{Filename: "invoice.pdf", Data: strings.NewReader("%PDF"), ContentType: mailpen.TypeAppOctetStream},
+		},
+	})
+	require.NoError(t, err)
+
+	return mailpentest.Memory(provider)
+}
+
+func TestAssertions(t *testing.T) {
+	box := sentMailbox(t)
+
+	tests := []struct {
+		name    string
+		assert  func(t testing.TB) bool
+		wantErr string
+	}{
+		{
+			name:   "sent to",
+			assert: func(t testing.TB) bool { return mailpentest.AssertSentTo(t, box, "ADA@example.com") },
+		},
+		{
+			name:   "sent to cc",
+			assert: func(t testing.TB) bool { return mailpentest.AssertSentTo(t, box, "team@example.com") },
+		},
+		{
+			name:    "not sent to",
+			assert:  func(t testing.TB) bool { return mailpentest.AssertSentTo(t, box, "bob@example.com") },
+			wantErr: `no message was sent to bob@example.com`,
+		},
+		{
+			name:   "subject contains",
+			assert: func(t testing.TB) bool { return mailpentest.AssertSubjectContains(t, box, "invoice #42") },
+		},
+		{
+			name:    "subject does not contain",
+			assert:  func(t testing.TB) bool { return mailpentest.AssertSubjectContains(t, box, "receipt") },
+			wantErr: `no message was sent with a subject containing "receipt"`,
+		},
+		{
+			name:   "HTML contains",
+			assert: func(t testing.TB) bool { return mailpentest.AssertHTMLContains(t, box, "<strong>$10</strong>") },
+		},
+		{
+			name:    "HTML does not contain",
+			assert:  func(t testing.TB) bool { return mailpentest.AssertHTMLContains(t, box, "$20") },
+			wantErr: `no message was sent with an HTML body containing "$20"`,
+		},
+		{
+			name:   "attachment count",
+			assert: func(t testing.TB) bool { return mailpentest.AssertAttachmentCount(t, box, 1) },
+		},
+		{
+			name:    "wrong attachment count",
+			assert:  func(t testing.TB) bool { return mailpentest.AssertAttachmentCount(t, box, 2) },
+			wantErr: `no message was sent with 2 attachment(s)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recordingT{TB: t}
+			ok := tt.assert(rec)
+
+			if tt.wantErr == "" {
+				assert.True(t, ok)
+				assert.Empty(t, rec.errors)
+				return
+			}
+
+			assert.False(t, ok)
+			require.Len(t, rec.errors, 1)
+			assert.Contains(t, rec.errors[0], tt.wantErr)
+			assert.Contains(t, rec.errors[0], `1. to ada@example.com, team@example.com, subject "Your invoice #42", 1 attachment(s)`)
+		})
+	}
+}
+
+func TestAssertions_NoMessages(t *testing.T) {
+	rec := &recordingT{TB: t}
+	assert.False(t, mailpentest.AssertSentTo(rec, mailpentest.Memory(memory.New()), "ada@example.com"))
+	require.Len(t, rec.errors, 1)
+	assert.Contains(t, rec.errors[0], "no messages were sent")
+}
@@ -0,0 +1,109 @@
+// Package mailpentest provides helpers for testing applications that send email with mailpen: assertions on
+// sent messages from a memory provider or a Mailpit container.
+package mailpentest
+
+import (
+	"bytes"
+	"io"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/providers/memory"
+)
+
+// Message is a sent message as seen by the assertions
+type Message struct {
+	From        string
+	To          []string // Recipient addresses, without display names
+	Cc          []string
+	Bcc         []string
+	Subject     string
+	HTML        string
+	Text        string
+	Attachments []Attachment
+}
+
+// Attachment is an attachment of a sent message
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Size        int
+}
+
+// Mailbox is a source of sent messages, such as a memory provider wrapped by Memory or a testutil.Mailpit
+// container
+type Mailbox interface {
+	// SentMessages returns the sent messages, failing the test if they can't be retrieved
+	SentMessages(t testing.TB) []Message
+}
+
+// Memory returns a Mailbox of the messages sent with a memory provider
+func Memory(provider *memory.Provider) Mailbox {
+	return memoryMailbox{provider}
+}
+
+type memoryMailbox struct {
+	provider *memory.Provider
+}
+
+func (m memoryMailbox) SentMessages(t testing.TB) []Message {
+	t.Helper()
+
+	sent := m.provider.Messages()
+	messages := make([]Message, 0, len(sent))
+	for _, msg := range sent {
+		messages = append(messages, fromMailpen(msg))
+	}
+	return messages
+}
+
+// fromMailpen converts a sent mailpen message
+func fromMailpen(msg mailpen.Message) Message {
+	m := Message{
+		From:    address(msg.From),
+		To:      addresses(msg.To),
+		Cc:      addresses(msg.Cc),
+		Bcc:     addresses(msg.Bcc),
+		Subject: msg.Subject,
+		HTML:    msg.HTMLBody,
+		Text:    msg.TextBody,
+	}
+
+	for _, att := range msg.Attachments {
+		size := 0
+		if att.Data != nil {
+			data, _ := io.ReadAll(att.Data)
+			size = len(data)
+			att.Data = bytes.NewReader(data)
+		}
+		m.Attachments = append(m.Attachments, Attachment{
+			Filename:    att.Filename,
+			ContentType: att.ContentType.String(),
+			Size:        size,
+		})
+	}
+
+	return m
+}
+
+// address returns the address part of "Name <user@example.com>"
+func address(s string) string {
+	if parsed, err := mail.ParseAddress(s); err == nil {
+		return parsed.Address
+	}
+	return strings.TrimSpace(s)
+}
+
+// addresses returns the address parts of a list of addresses
+func addresses(list []string) []string {
+	if len(list) == 0 {
+		return nil
+	}
+	out := make([]string, len(list))
+	for i, s := range list {
+		out[i] = address(s)
+	}
+	return out
+}
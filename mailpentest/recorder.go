@@ -0,0 +1,250 @@
+package mailpentest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/providers/memory"
+)
+
+// Call is a recorded call to a Recorder
+type Call struct {
+	Method   string                 // "Send", "SendWithResult", "SendMany", "SendAsync", "Enqueue", or "Render"
+	Messages []*mailpen.Message     // Messages passed to the call
+	Sent     []mailpen.Message      // Messages as delivered to the provider, rendered and processed
+	Rendered *mailpen.RenderedEmail // Output of a Render call
+	Err      error                  // Error returned by the call; for SendAsync, set once the send finishes
+}
+
+// Recorder is a mailpen.Mailer for unit tests. It renders and processes messages with a real Mailpen, so
+// templates, processors, and middleware run as in production, but records the delivered messages instead of
+// sending them. Enqueued messages are recorded without being delivered. It is safe for concurrent use.
+type Recorder struct {
+	mailpen  *mailpen.Mailpen
+	provider *memory.Provider
+
+	mu    sync.Mutex
+	calls []*Call
+}
+
+var _ mailpen.Mailer = (*Recorder)(nil)
+
+// NewRecorder creates a Recorder with the configuration and options of the Mailpen under test, such as the
+// templates sources and message processors. The config's provider-specific settings are unused.
+func NewRecorder(config *mailpen.Config, opts ...mailpen.Option) (*Recorder, error) {
+	if config == nil {
+		config = &mailpen.Config{}
+	}
+
+	r := &Recorder{provider: memory.New()}
+
+	opts = append(opts, mailpen.WithQueue(recorderQueue{}))
+	mp, err := mailpen.New(recorderProvider{r}, config, opts...)
+	if err != nil {
+		return nil, err
+	}
+	r.mailpen = mp
+
+	return r, nil
+}
+
+// Send renders and records a message
+func (r *Recorder) Send(ctx context.Context, msg *mailpen.Message) error {
+	call := r.begin("Send", msg)
+	err := r.mailpen.Send(withCall(ctx, call), msg)
+	r.finish(call, err)
+	return err
+}
+
+// SendWithResult renders and records a message and returns the result of the send
+func (r *Recorder) SendWithResult(ctx context.Context, msg *mailpen.Message) (*mailpen.SendResult, error) {
+	call := r.begin("SendWithResult", msg)
+	res, err := r.mailpen.SendWithResult(withCall(ctx, call), msg)
+	r.finish(call, err)
+	return res, err
+}
+
+// SendMany renders and records several messages. The call's Err is the first error of the batch, if any.
+func (r *Recorder) SendMany(ctx context.Context, msgs []*mailpen.Message) []mailpen.BatchResult {
+	call := r.begin("SendMany", msgs...)
+	results := r.mailpen.SendMany(withCall(ctx, call), msgs)
+
+	var err error
+	for _, res := range results {
+		if res.Err != nil {
+			err = res.Err
+			break
+		}
+	}
+	r.finish(call, err)
+
+	return results
+}
+
+// SendAsync renders and records a message in a new goroutine. Use Drain to wait for it.
+func (r *Recorder) SendAsync(ctx context.Context, msg *mailpen.Message) *mailpen.Future {
+	call := r.begin("SendAsync", msg)
+	f := r.mailpen.SendAsync(withCall(ctx, call), msg)
+
+	go func() {
+		<-f.Done()
+		r.finish(call, f.Err())
+	}()
+
+	return f
+}
+
+// Drain waits until every SendAsync call has finished
+func (r *Recorder) Drain(ctx context.Context) error {
+	return r.mailpen.Drain(ctx)
+}
+
+// Enqueue validates and records a message without delivering it
+func (r *Recorder) Enqueue(ctx context.Context, msg *mailpen.Message) error {
+	call := r.begin("Enqueue", msg)
+	err := r.mailpen.Enqueue(ctx, msg)
+	r.finish(call, err)
+	return err
+}
+
+// Render renders a message and records the output
+func (r *Recorder) Render(ctx context.Context, msg *mailpen.Message) (*mailpen.RenderedEmail, error) {
+	call := r.begin("Render", msg)
+	email, err := r.mailpen.Render(ctx, msg)
+
+	r.mu.Lock()
+	call.Rendered = email
+	r.mu.Unlock()
+	r.finish(call, err)
+
+	return email, err
+}
+
+// Calls returns the recorded calls, oldest first
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	calls := make([]Call, len(r.calls))
+	for i, call := range r.calls {
+		calls[i] = *call
+	}
+	return calls
+}
+
+// Sent returns the messages delivered by all calls, oldest first
+func (r *Recorder) Sent() []mailpen.Message {
+	return r.provider.Messages()
+}
+
+// SentMessages returns the delivered messages for the mailpentest assertions
+func (r *Recorder) SentMessages(t testing.TB) []Message {
+	t.Helper()
+	return Memory(r.provider).SentMessages(t)
+}
+
+// FailWith makes every following send fail with err. A nil err restores sending.
+func (r *Recorder) FailWith(err error) {
+	r.provider.FailWith(err)
+}
+
+// Reset removes all recorded calls and messages
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = nil
+	r.provider.Reset()
+}
+
+// begin records the start of a call
+func (r *Recorder) begin(method string, msgs ...*mailpen.Message) *Call {
+	call := &Call{Method: method, Messages: msgs}
+
+	r.mu.Lock()
+	r.calls = append(r.calls, call)
+	r.mu.Unlock()
+
+	return call
+}
+
+// finish records the outcome of a call
+func (r *Recorder) finish(call *Call, err error) {
+	r.mu.Lock()
+	call.Err = err
+	r.mu.Unlock()
+}
+
+// callKey is the context key of the call a send belongs to
+type callKey struct{}
+
+func withCall(ctx context.Context, call *Call) context.Context {
+	return context.WithValue(ctx, callKey{}, call)
+}
+
+// recorderProvider delivers messages to the recorder's memory provider and adds them to their call
+type recorderProvider struct {
+	r *Recorder
+}
+
+func (p recorderProvider) Send(ctx context.Context, msg *mailpen.Message) error {
+	// Buffer attachments so both the memory provider and the call get readable copies
+	buffered := make([][]byte, len(msg.Attachments))
+	for i, att := range msg.Attachments {
+		if att.Data == nil {
+			continue
+		}
+		data, err := io.ReadAll(att.Data)
+		if err != nil {
+			return fmt.Errorf("failed to read attachment %s: %w", att.Filename, err)
+		}
+		buffered[i] = data
+	}
+
+	withData := func(m mailpen.Message) mailpen.Message {
+		m.Attachments = append([]mailpen.Attachment(nil), m.Attachments...)
+		for i := range m.Attachments {
+			if buffered[i] != nil {
+				m.Attachments[i].Data = bytes.NewReader(buffered[i])
+			}
+		}
+		return m
+	}
+
+	delivered := withData(*msg)
+	if err := p.r.provider.Send(ctx, &delivered); err != nil {
+		return err
+	}
+	msg.ProviderMessageID = delivered.ProviderMessageID
+
+	if call, ok := ctx.Value(callKey{}).(*Call); ok {
+		p.r.mu.Lock()
+		call.Sent = append(call.Sent, withData(delivered))
+		p.r.mu.Unlock()
+	}
+	return nil
+}
+
+func (p recorderProvider) Name() string {
+	return "recorder"
+}
+
+func (p recorderProvider) Validate(msg *mailpen.Message) error {
+	return p.r.provider.Validate(msg)
+}
+
+func (p recorderProvider) Capabilities() mailpen.Capabilities {
+	return p.r.provider.Capabilities()
+}
+
+// recorderQueue accepts enqueued messages without delivering them
+type recorderQueue struct{}
+
+func (q recorderQueue) Enqueue(ctx context.Context, msg *mailpen.Message) error {
+	return nil
+}
@@ -0,0 +1,112 @@
+package mailpentest_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/mailpentest"
+)
+
+// welcomeService is an application service that depends on mailpen.Mailer
+type welcomeService struct {
+	mailer mailpen.Mailer
+}
+
+func (s welcomeService) Welcome(ctx context.Context, name, email string) error {
+	return s.mailer.Send(ctx, &mailpen.Message{
+		To:       []string{email},
+		Subject:  "Welcome",
+		Template: "welcome",
+		Data:     map[string]any{"Name": name, "CompanyName": "Acme"},
+	})
+}
+
+func newRecorder(t *testing.T) *mailpentest.Recorder {
+	t.Helper()
+
+	recorder, err := mailpentest.NewRecorder(&mailpen.Config{
+		From:    "sender@example.com",
+		Sources: []mailpen.TemplateSource{{Name: "base", FS: os.DirFS("../testdata/base")}},
+	})
+	require.NoError(t, err)
+	return recorder
+}
+
+func TestRecorder_Send(t *testing.T) {
+	recorder := newRecorder(t)
+	service := welcomeService{mailer: recorder}
+
+	require.NoError(t, service.Welcome(context.Background(), "Ada", "ada@example.com"))
+
+	calls := recorder.Calls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "Send", calls[0].Method)
+	assert.NoError(t, calls[0].Err)
+	require.Len(t, calls[0].Sent, 1)
+	assert.Contains(t, calls[0].Sent[0].HTMLBody, "Welcome, Ada!")
+	assert.Contains(t, calls[0].Sent[0].TextBody, "Welcome, Ada!")
+	assert.Equal(t, "sender@example.com", calls[0].Sent[0].From)
+
+	mailpentest.AssertSentTo(t, recorder, "ada@example.com")
+	mailpentest.AssertHTMLContains(t, recorder, "join us at Acme")
+
+	recorder.Reset()
+	assert.Empty(t, recorder.Calls())
+	assert.Empty(t, recorder.Sent())
+}
+
+func TestRecorder_FailWith(t *testing.T) {
+	recorder := newRecorder(t)
+	service := welcomeService{mailer: recorder}
+
+	recorder.FailWith(errors.New("smtp down"))
+	err := service.Welcome(context.Background(), "Ada", "ada@example.com")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "smtp down")
+
+	calls := recorder.Calls()
+	require.Len(t, calls, 1)
+	assert.Error(t, calls[0].Err)
+	assert.Empty(t, calls[0].Sent)
+}
+
+func TestRecorder_OtherMethods(t *testing.T) {
+	recorder := newRecorder(t)
+	ctx := context.Background()
+
+	msg := func(to string) *mailpen.Message {
+		return &mailpen.Message{To: []string{to}, Subject: "Hi", TextBody: "Hi"}
+	}
+
+	results := recorder.SendMany(ctx, []*mailpen.Message{msg("a@example.com"), msg("b@example.com")})
+	require.Len(t, results, 2)
+
+	f := recorder.SendAsync(ctx, msg("c@example.com"))
+	require.NoError(t, f.Wait(ctx))
+	require.NoError(t, recorder.Drain(ctx))
+
+	require.NoError(t, recorder.Enqueue(ctx, msg("d@example.com")))
+
+	rendered, err := recorder.Render(ctx, &mailpen.Message{Subject: "Hi", Template: "welcome", Data: map[string]any{"Name": "Bob"}})
+	require.NoError(t, err)
+	assert.Contains(t, rendered.HTML, "Welcome, Bob!")
+
+	calls := recorder.Calls()
+	require.Len(t, calls, 4)
+	assert.Equal(t, "SendMany", calls[0].Method)
+	assert.Len(t, calls[0].Sent, 2)
+	assert.Equal(t, "SendAsync", calls[1].Method)
+	assert.Len(t, calls[1].Sent, 1)
+	assert.Equal(t, "Enqueue", calls[2].Method)
+	assert.Empty(t, calls[2].Sent)
+	assert.Equal(t, "Render", calls[3].Method)
+	assert.Same(t, rendered, calls[3].Rendered)
+
+	assert.Len(t, recorder.Sent(), 3)
+}
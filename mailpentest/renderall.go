@@ -0,0 +1,109 @@
+package mailpentest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/patrickward/mailpen"
+)
+
+// DataProvider returns the data an email is rendered with in RenderAll
+type DataProvider func(name string) (any, error)
+
+// SampleData returns a DataProvider that reads each email's sample data file (emails/<name>.sample.json and
+// friends). Emails without a sample file are rendered with nil data.
+func SampleData(manager *mailpen.Manager) DataProvider {
+	return func(name string) (any, error) {
+		data, err := manager.SampleData(name)
+		if err != nil || data == nil {
+			return nil, err
+		}
+		return data, nil
+	}
+}
+
+// RenderAllOption configures RenderAll
+type RenderAllOption func(c *renderAllConfig)
+
+type renderAllConfig struct {
+	layouts []string
+	skip    map[string]bool
+}
+
+// WithLayouts renders the emails with the given layouts instead of every layout
+func WithLayouts(layouts ...string) RenderAllOption {
+	return func(c *renderAllConfig) {
+		c.layouts = layouts
+	}
+}
+
+// WithSkip leaves the given emails out
+func WithSkip(emails ...string) RenderAllOption {
+	return func(c *renderAllConfig) {
+		for _, name := range emails {
+			c.skip[name] = true
+		}
+	}
+}
+
+// RenderAll renders every email of the manager with every layout, using data for each email's data (nil
+// uses the sample data files), and fails the test once with a report of every email that failed to render
+// either format. It returns whether every email rendered.
+func RenderAll(t testing.TB, manager *mailpen.Manager, data DataProvider, opts ...RenderAllOption) bool {
+	t.Helper()
+
+	cfg := &renderAllConfig{skip: make(map[string]bool)}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if data == nil {
+		data = SampleData(manager)
+	}
+
+	emails, err := manager.Emails()
+	if err != nil {
+		t.Fatalf("failed to list emails: %v", err)
+	}
+
+	layouts := cfg.layouts
+	if layouts == nil {
+		layouts = manager.Layouts()
+	}
+	if len(layouts) == 0 {
+		layouts = []string{""} // The manager's default layout
+	}
+
+	var failures []string
+	count := 0
+	for _, name := range emails {
+		if cfg.skip[name] {
+			continue
+		}
+		count++
+
+		emailData, err := data(name)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: failed to get data: %v", name, err))
+			continue
+		}
+
+		for _, layout := range layouts {
+			label := name
+			if layout != "" {
+				label += " (layout " + layout + ")"
+			}
+
+			if _, err := manager.Render(context.Background(), name, emailData, mailpen.RenderOptions{Layout: layout}); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", label, err))
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		t.Errorf("%d problem(s) rendering %d email(s) with %d layout(s):\n  %s", len(failures), count, len(layouts), strings.Join(failures, "\n  "))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package mailpentest_test
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/mailpentest"
+)
+
+func renderAllManager(t *testing.T, files fstest.MapFS) *mailpen.Manager {
+	t.Helper()
+
+	files["layouts/base.html"] = &fstest.MapFile{Data: []byte(`<html><body>{{template "content" .}}</body></html>`)}
+	files["layouts/base.txt"] = &fstest.MapFile{Data: []byte(`{{template "content" .}}`)}
+	files["layouts/plain.html"] = &fstest.MapFile{Data: []byte(`<div>{{template "content" .}}</div>`)}
+	files["layouts/plain.txt"] = &fstest.MapFile{Data: []byte(`{{template "content" .}}`)}
+
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{{Name: "test", FS: files}},
+	})
+	require.NoError(t, err)
+	return manager
+}
+
+func TestRenderAll(t *testing.T) {
+	manager := renderAllManager(t, fstest.MapFS{
+		"emails/welcome.html":        {Data: []byte(`{{define "content"}}<p>Hi {{.Name}}</p>{{end}}`)},
+		"emails/welcome.txt":         {Data: []byte(`{{define "content"}}Hi {{.Name}}{{end}}`)},
+		"emails/welcome.sample.json": {Data: []byte(`{"Name": "Ada"}`)},
+		"emails/reset.html":          {Data: []byte(`{{define "content"}}<p>Reset</p>{{end}}`)},
+	})
+
+	assert.True(t, mailpentest.RenderAll(t, manager, nil))
+}
+
+func TestRenderAll_Failures(t *testing.T) {
+	manager := renderAllManager(t, fstest.MapFS{
+		"emails/welcome.html": {Data: []byte(`{{define "content"}}<p>Hi {{.User.Name}}</p>{{end}}`)},
+		"emails/invoice.html": {Data: []byte(`{{define "content"}}<p>{{.Total}}</p>{{end}}`)},
+	})
+
+	data := func(name string) (any, error) {
+		switch name {
+		case "welcome":
+			return map[string]any{"User": "ada"}, nil
+		case "invoice":
+			return nil, errors.New("no fixture")
+		}
+		return nil, nil
+	}
+
+	rt := &recordingT{TB: t}
+	assert.False(t, mailpentest.RenderAll(rt, manager, data, mailpentest.WithLayouts("base")))
+	require.Len(t, rt.errors, 1)
+	report := rt.errors[0]
+	assert.Contains(t, report, "2 problem(s) rendering 2 email(s) with 1 layout(s):")
+	assert.Contains(t, report, "\n  invoice: failed to get data: no fixture")
+	assert.Contains(t, report, "\n  welcome (layout base): failed to render")
+	assert.Contains(t, report, "can't evaluate field Name")
+
+	rt = &recordingT{TB: t}
+	assert.True(t, mailpentest.RenderAll(rt, manager, data, mailpentest.WithLayouts("base"), mailpentest.WithSkip("welcome", "invoice")))
+	assert.Empty(t, rt.errors)
+}
+
+func TestRenderAll_DevMode(t *testing.T) {
+	manager := renderAllManager(t, fstest.MapFS{
+		"emails/welcome.html": {Data: []byte(`{{define "content"}}<p>{{len 3}}</p>{{end}}`)},
+	})
+	manager.DevMode(true)
+
+	rt := &recordingT{TB: t}
+	assert.False(t, mailpentest.RenderAll(rt, manager, nil, mailpentest.WithLayouts("plain")))
+	require.Len(t, rt.errors, 1)
+	assert.Contains(t, rt.errors[0], "welcome (layout plain): failed to render HTML template")
+}
@@ -2,13 +2,20 @@ package mailpen
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"html/template"
 	"io/fs"
 	"path"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/patrickward/mailpen/templates"
 )
@@ -26,6 +33,12 @@ type TemplateSource struct {
 	FS   fs.FS  // File system for the templates
 }
 
+// Errors returned when rendering, matched with errors.Is
+var (
+	ErrTemplateNotFound = errors.New("template not found") // No source has the email template
+	ErrLayoutNotFound   = errors.New("layout not found")   // No source has the layout
+)
+
 // TemplateFormat represents the format of a template
 type TemplateFormat string
 
@@ -37,22 +50,46 @@ const (
 // Manager handles templates loading, caching, and rendering
 type Manager struct {
 	funcMap       template.FuncMap
-	processor     HTMLProcessor
+	processors    []ContextProcessor
+	textConverter TextConverter
+	analyzers     []HTMLAnalyzer
 	defaultLayout string
 	sources       []TemplateSource
 	theme         map[string]any
+	baseTheme     map[string]any
+	themeFile     *ThemeFile
+	themeStamp    themeStamp
+	devMode       atomic.Bool
+	strictTheme   bool
+	policy        RenderPolicy
 	baseTemplates map[TemplateFormat]*template.Template
-	emailCache    map[string]*template.Template
+	emailCache    *templateCache
+	schemas       map[string]DataSchema
+	components    map[string]customComponent
+	tracer        trace.Tracer
+	renderTiming  RenderTimingFunc
 	mu            sync.RWMutex
 }
 
 // ManagerConfig configures the templates manager
 type ManagerConfig struct {
-	FuncMap       template.FuncMap
-	Processor     HTMLProcessor
-	Sources       []TemplateSource
-	Theme         map[string]any
-	DefaultLayout string
+	FuncMap           template.FuncMap
+	Processor         HTMLProcessor
+	Processors        []ContextProcessor // Context-aware processors applied after Processor
+	TextConverter     TextConverter      // Derives the text body from the processed HTML when no text template exists
+	Analyzers         []HTMLAnalyzer     // Inspect the processed HTML and report warnings on the rendered email
+	Sources           []TemplateSource
+	Theme             map[string]any
+	ThemeFile         *ThemeFile // Optional JSON theme file merged over Theme
+	DefaultLayout     string
+	DevMode           bool                  // Start in development mode (see Manager.DevMode)
+	StrictTheme       bool                  // Fail rendering when a theme path without a fallback is not found
+	RenderPolicy      RenderPolicy          // Formats every email must have (defaults to RequireHTML)
+	Schemas           map[string]DataSchema // Data schemas by email template name, checked before rendering
+	TemplateCacheSize int                   // Parsed email templates kept, counting each format, variant and brand (defaults to DefaultTemplateCacheSize)
+
+	TracerProvider trace.TracerProvider // OpenTelemetry tracer provider (defaults to the global provider)
+	RenderTiming   RenderTimingFunc     // Receives the stage timings of each render (optional)
 }
 
 // DefaultProcessor provides a pass-through implementation
@@ -82,16 +119,37 @@ func NewManager(config *ManagerConfig) (*Manager, error) {
 	}
 
 	m := &Manager{
-		processor:     config.Processor,
+		processors:    append([]ContextProcessor{AdaptProcessor(config.Processor)}, config.Processors...),
+		textConverter: config.TextConverter,
+		analyzers:     config.Analyzers,
 		defaultLayout: config.DefaultLayout,
 		sources:       make([]TemplateSource, 0),
 		baseTemplates: make(map[TemplateFormat]*template.Template),
-		emailCache:    make(map[string]*template.Template),
+		emailCache:    newTemplateCache(config.TemplateCacheSize),
+		schemas:       make(map[string]DataSchema),
+		components:    make(map[string]customComponent),
+		funcMap:       config.FuncMap,
 		theme:         config.Theme,
+		baseTheme:     config.Theme,
+		themeFile:     config.ThemeFile,
+		strictTheme:   config.StrictTheme,
+		policy:        config.RenderPolicy,
+		tracer:        newTracer(config.TracerProvider),
+		renderTiming:  config.RenderTiming,
+	}
+
+	m.devMode.Store(config.DevMode)
+
+	for name, schema := range config.Schemas {
+		m.RegisterSchema(name, schema)
+	}
+
+	if err := m.loadThemeFile(true); err != nil {
+		return nil, err
 	}
 
 	// Merge function maps
-	m.funcMap = MergeFuncMaps(DefaultFuncMap(), m.funcMap, m.themeFuncs())
+	m.funcMap = MergeFuncMaps(DefaultFuncMap(), m.funcMap, m.themeFuncs(), m.componentFuncs())
 
 	// Initialize base template sets
 	m.baseTemplates[FormatText] = template.New("text-base").Funcs(m.funcMap)
@@ -150,7 +208,8 @@ func (m *Manager) loadBaseTemplates() error {
 		}
 	}
 
-	return nil
+	// Registered components are loaded last, so they survive reloads
+	return m.loadComponents()
 }
 
 // loadDirectory walks an entire directory tree looking for templates
@@ -212,103 +271,283 @@ func (m *Manager) templateName(rootDir, filePath string) string {
 
 // RenderedEmail represents a rendered email
 type RenderedEmail struct {
-	Text string
-	HTML string
+	Subject  string // Rendered subject block or subject file, empty when the email has neither
+	Text     string
+	HTML     string
+	Warnings []string // Non-fatal issues reported by analyzers
+}
+
+// RenderOptions configures a single render
+type RenderOptions struct {
+	Layout  string         // Layout to use (defaults to the manager's default layout)
+	Variant string         // Theme variant name used to cache templates rendered with Theme
+	Theme   map[string]any // Theme used in place of the manager's theme (requires Variant)
+	Message *Message       // Message being rendered, made available to context processors
+	Policy  RenderPolicy   // Formats the email must have (defaults to the manager's policy)
 }
 
 // RenderEmail renders an email template with optional layout
 func (m *Manager) RenderEmail(name string, data interface{}, layout string) (*RenderedEmail, error) {
+	return m.Render(context.Background(), name, data, RenderOptions{Layout: layout})
+}
+
+// RenderEmailWithTheme renders an email template using the given theme in place of the manager's theme.
+// Templates are cached per variant name, so a variant name must always refer to the same theme.
+func (m *Manager) RenderEmailWithTheme(name string, data interface{}, layout, variant string, theme map[string]any) (*RenderedEmail, error) {
+	return m.Render(context.Background(), name, data, RenderOptions{Layout: layout, Variant: variant, Theme: theme})
+}
+
+// Render renders both formats of an email. The context and options are passed to context processors, and a
+// theme variant, when given, binds the theme functions to that theme. In development mode a failed render
+// returns an error page for the failure along with the error.
+func (m *Manager) Render(ctx context.Context, name string, data interface{}, opts RenderOptions) (email *RenderedEmail, err error) {
+	layout := opts.Layout
 	if layout == "" {
 		layout = m.defaultLayout
 	}
 
+	ctx, span := m.tracer.Start(ctx, "mailpen.render", trace.WithAttributes(
+		attribute.String("mailpen.template", name),
+		attribute.String("mailpen.layout", layout),
+	))
+	defer func() { endSpan(span, err) }()
+
+	// Development mode is read once, so toggling it mid-render cannot bind per-render functions to a cached
+	// template
+	dev := m.devMode.Load()
+
+	timings, report := m.startTimings(ctx, name, layout)
+	email, err = m.render(ctx, name, data, opts, timings, dev)
+	report(err)
+	if err != nil && dev {
+		return m.errorEmail(name, err), err
+	}
+	return email, err
+}
+
+// render renders both formats of an email
+func (m *Manager) render(ctx context.Context, name string, data interface{}, opts RenderOptions, timings *RenderTimings, dev bool) (*RenderedEmail, error) {
+	layout, variant, theme := opts.Layout, opts.Variant, opts.Theme
+	if variant == "" || theme == nil {
+		variant, theme = "", nil
+	}
+
+	if dev {
+		if err := m.reload(false); err != nil {
+			return nil, fmt.Errorf("failed to reload templates: %w", err)
+		}
+	}
+
+	if layout == "" {
+		layout = m.defaultLayout
+	}
+
+	if err := m.validateData(name, data); err != nil {
+		return nil, err
+	}
+
+	return m.renderFormats(ctx, name, layout, theme, data, opts.Message, m.renderPolicy(opts.Policy), timings, dev, func(format TemplateFormat) (*template.Template, error) {
+		return m.getEmailTemplate(name, format, variant, theme, dev)
+	})
+}
+
+// renderFormats executes the layout with the templates returned by lookup for each format, then processes
+// and analyzes the HTML and falls back to converting it when there is no text version. The policy decides
+// whether a missing text template fails the render. Stage durations are recorded in timings when it is not
+// nil. In development mode (dev), the templates from lookup must not be shared, since missing theme paths are
+// recorded through their functions.
+func (m *Manager) renderFormats(ctx context.Context, name, layout string, theme map[string]any, data interface{}, msg *Message, policy RenderPolicy, timings *RenderTimings, dev bool, lookup func(TemplateFormat) (*template.Template, error)) (*RenderedEmail, error) {
 	email := &RenderedEmail{}
 
-	// Try text version
-	if tmpl, err := m.getEmailTemplate(name, layout, FormatText); err == nil {
-		text, err := m.executeTemplate(tmpl, "layout:"+layout, data)
+	var missing []string
+	if dev {
+		lookup = m.recordMissingTheme(lookup, theme, &missing)
+	}
+
+	// Text version, which the policy may allow to be missing. Other errors, such as a text template that
+	// does not parse, always fail the render.
+	textTmpl, textErr := lookup(FormatText)
+	textMissing := errors.Is(textErr, ErrTemplateNotFound) || errors.Is(textErr, ErrLayoutNotFound)
+	switch {
+	case textErr != nil && !textMissing:
+		return nil, fmt.Errorf("failed to render text template: %w", textErr)
+	case textMissing && policy == RequireBoth:
+		return nil, fmt.Errorf("failed to render text template: %w", textErr)
+	case textErr == nil:
+		start := time.Now()
+		text, err := m.executeTemplate(textTmpl, "layout:"+layout, data)
 		if err != nil {
 			return nil, fmt.Errorf("failed to render text template: %w", err)
 		}
 		email.Text = text
+		if timings != nil {
+			timings.Text = time.Since(start)
+		}
 	}
 
-	// Try HTML version
-	if tmpl, err := m.getEmailTemplate(name, layout, FormatHTML); err == nil {
-		html, err := m.executeTemplate(tmpl, "layout:"+layout, data)
+	// HTML version, which every policy but HTMLOptional requires
+	htmlTmpl, err := lookup(FormatHTML)
+	htmlMissing := errors.Is(err, ErrTemplateNotFound) || errors.Is(err, ErrLayoutNotFound)
+	if err == nil {
+		start := time.Now()
+		html, err := m.executeTemplate(htmlTmpl, "layout:"+layout, data)
 		if err != nil {
 			return nil, fmt.Errorf("failed to render HTML template: %w", err)
 		}
+		if timings != nil {
+			timings.HTML = time.Since(start)
+		}
 
-		if m.processor != nil {
-			html, err = m.processor.Process(html)
+		html, err = m.process(ctx, html, name, layout, theme, msg, timings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process HTML: %w", err)
+		}
+		email.HTML = html
+
+		for _, analyzer := range m.analyzers {
+			start := time.Now()
+			warnings, err := analyzer.Analyze(html)
 			if err != nil {
-				return nil, fmt.Errorf("failed to process HTML: %w", err)
+				return nil, fmt.Errorf("failed to analyze HTML: %w", err)
+			}
+			email.Warnings = append(email.Warnings, warnings...)
+			if timings != nil {
+				timings.Analyzers = append(timings.Analyzers, StageTiming{Name: stageName(analyzer), Duration: time.Since(start)})
 			}
 		}
-		email.HTML = html
-	} else {
+	} else if errors.Is(err, ErrTemplateNotFound) && errors.Is(textErr, ErrTemplateNotFound) {
+		return nil, fmt.Errorf("no templates found for email %q: %w", name, err)
+	} else if !htmlMissing || textErr != nil || policy != HTMLOptional {
 		return nil, fmt.Errorf("failed to render HTML template: %w", err)
 	}
 
+	// The subject comes from the text template first, since it is not escaped for HTML
+	if email.Subject, err = m.renderSubject(data, textTmpl, htmlTmpl); err != nil {
+		return nil, fmt.Errorf("failed to render subject: %w", err)
+	}
+
+	if email.Text == "" && email.HTML != "" && m.textConverter != nil {
+		start := time.Now()
+		text, err := m.textConverter.Convert(email.HTML)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert HTML to text: %w", err)
+		}
+		email.Text = text
+		if timings != nil {
+			timings.TextConvert = time.Since(start)
+		}
+	} else if textMissing && (policy == RequireHTML || policy == HTMLOptional) {
+		email.Warnings = append(email.Warnings, fmt.Sprintf("text: version skipped: %v, and no text converter is configured", textErr))
+	}
+
 	if email.Text == "" && email.HTML == "" {
 		return nil, fmt.Errorf("no templates found for email %q", name)
 	}
 
+	email.Warnings = append(email.Warnings, missingThemeWarnings(missing)...)
 	return email, nil
 }
 
-// getEmailTemplate gets or creates an email template
-func (m *Manager) getEmailTemplate(name, layout string, format TemplateFormat) (*template.Template, error) {
-	cacheKey := fmt.Sprintf("%s:%s:%s", format, name, layout)
+// process runs the HTML through the processor chain
+func (m *Manager) process(ctx context.Context, html, name, layout string, theme map[string]any, msg *Message, timings *RenderTimings) (_ string, err error) {
+	if len(m.processors) == 0 {
+		return html, nil
+	}
 
-	m.mu.RLock()
-	if tmpl, ok := m.emailCache[cacheKey]; ok {
-		m.mu.RUnlock()
-		return tmpl, nil
+	ctx, span := m.tracer.Start(ctx, "mailpen.process", trace.WithAttributes(
+		attribute.Int("mailpen.processors", len(m.processors)),
+	))
+	defer func() { endSpan(span, err) }()
+
+	if theme == nil {
+		theme = m.Theme()
 	}
-	m.mu.RUnlock()
 
-	// Need write lock to create template
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	rc := &RenderContext{
+		HTML:     html,
+		Template: name,
+		Layout:   layout,
+		Theme:    theme,
+		Message:  msg,
+	}
+	if msg != nil {
+		rc.Locale = msg.Locale
+	}
+
+	for _, processor := range m.processors {
+		start := time.Now()
+		processed, err := processor.Process(ctx, rc)
+		if err != nil {
+			return "", err
+		}
+		rc.HTML = processed
+		if timings != nil {
+			timings.Processors = append(timings.Processors, StageTiming{Name: stageName(processor), Duration: time.Since(start)})
+		}
+	}
+
+	return rc.HTML, nil
+}
 
-	// Check cache again
-	if tmpl, ok := m.emailCache[cacheKey]; ok {
-		return tmpl, nil
+// getEmailTemplate gets or creates an email template. When a theme variant is given, the theme functions
+// of the cloned template are bound to that theme and the result is cached under the variant name. The
+// template holds every layout, so one cached template serves all of them.
+func (m *Manager) getEmailTemplate(name string, format TemplateFormat, variant string, theme map[string]any, dev bool) (*template.Template, error) {
+	cacheKey := fmt.Sprintf("%s:%s:%s", format, name, variant)
+	// Development mode parses the email on every render so edits show up and per-render functions can
+	// be bound to the template
+	if dev {
+		return m.buildEmailTemplate(name, format, theme)
 	}
 
-	// Clone base template
-	base := m.baseTemplates[format]
-	tmpl, err := base.Clone()
+	return m.emailCache.get(cacheKey, func() (*template.Template, error) {
+		return m.buildEmailTemplate(name, format, theme)
+	})
+}
+
+// buildEmailTemplate clones the base template for a format and parses the email into it. It holds the read
+// lock only, so templates for different emails are built concurrently.
+func (m *Manager) buildEmailTemplate(name string, format TemplateFormat, theme map[string]any) (*template.Template, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tmpl, err := m.baseTemplates[format].Clone()
 	if err != nil {
 		return nil, err
 	}
 
-	// Find email template in sources (last one wins)
+	if theme != nil {
+		tmpl.Funcs(themeFuncs(func() map[string]any { return theme }, m.strictTheme, nil))
+	}
+
 	filename := path.Join(EmailsDir, name+format.Extension())
-	found := false
+	content, found := m.readEmail(name, format)
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, filename)
+	}
 
-	for i := len(m.sources) - 1; i >= 0; i-- {
-		source := m.sources[i]
-		if content, err := fs.ReadFile(source.FS, filename); err == nil {
-			if _, err := tmpl.New(name).Parse(string(content)); err != nil {
-				return nil, err
-			}
-			found = true
-			break
-		}
+	if _, err := tmpl.New(name).Parse(content); err != nil {
+		return nil, err
 	}
 
-	if !found {
-		return nil, fmt.Errorf("template %s not found", filename)
+	if err := m.parseSubject(tmpl, name); err != nil {
+		return nil, err
 	}
 
-	// Cache and return
-	m.emailCache[cacheKey] = tmpl
 	return tmpl, nil
 }
 
+// readEmail reads an email template from the sources (last one wins)
+func (m *Manager) readEmail(name string, format TemplateFormat) (string, bool) {
+	filename := path.Join(EmailsDir, name+format.Extension())
+	for i := len(m.sources) - 1; i >= 0; i-- {
+		if content, err := fs.ReadFile(m.sources[i].FS, filename); err == nil {
+			return string(content), true
+		}
+	}
+	return "", false
+}
+
 // Extension returns the file extension for a template format
 func (f TemplateFormat) Extension() string {
 	switch f {
@@ -323,6 +562,10 @@ func (f TemplateFormat) Extension() string {
 
 // executeTemplate executes a template with the given name and data
 func (m *Manager) executeTemplate(t *template.Template, name string, data interface{}) (string, error) {
+	if layout, ok := strings.CutPrefix(name, "layout:"); ok && t.Lookup(name) == nil {
+		return "", fmt.Errorf("%w: %s", ErrLayoutNotFound, layout)
+	}
+
 	var buf bytes.Buffer
 	if err := t.ExecuteTemplate(&buf, name, data); err != nil {
 		return "", err
@@ -332,9 +575,7 @@ func (m *Manager) executeTemplate(t *template.Template, name string, data interf
 
 // ClearCache clears the email template cache
 func (m *Manager) ClearCache() {
-	m.mu.Lock()
-	m.emailCache = make(map[string]*template.Template)
-	m.mu.Unlock()
+	m.emailCache.clear()
 }
 
 // AddFunc adds a function to the templates manager
@@ -366,15 +607,139 @@ func (m *Manager) AddFuncs(funcs template.FuncMap) error {
 	return nil
 }
 
-// themeFuncs returns the theme functions
+// themeFuncs returns the theme functions. They read the current theme on every call so a reloaded
+// theme file takes effect without rebuilding the function map.
 func (m *Manager) themeFuncs() template.FuncMap {
+	return themeFuncs(m.Theme, m.strictTheme, nil)
+}
+
+// themeFuncs returns the theme functions bound to the theme returned by current. missing, when not nil, is
+// called with each theme path that resolves to no value.
+func themeFuncs(current func() map[string]any, strict bool, missing func(path string)) template.FuncMap {
 	return template.FuncMap{
-		"theme": func(path string) any {
-			return GetThemeValue(m.theme, path)
+		"theme": func(path string, fallback ...any) (any, error) {
+			value, err := resolveThemeValue(current(), strict, path, fallback...)
+			if value == nil && err == nil && missing != nil {
+				missing(path)
+			}
+			return value, err
+		},
+		"theme_dark_mode": func() template.HTML {
+			return darkModeHead(current())
+		},
+		"theme_styles": func() template.HTML {
+			return themeStylesHead(current())
 		},
 	}
 }
 
+// Theme returns the theme used by the manager
+func (m *Manager) Theme() map[string]any {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.theme
+}
+
+// UpdateTheme merges overrides over the theme at runtime and clears the template cache, so templates cached
+// with a theme variant pick up the change. A theme file, when configured, still takes precedence on reload.
+func (m *Manager) UpdateTheme(overrides map[string]any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.baseTheme = MergeTheme(m.baseTheme, overrides)
+	m.theme = MergeTheme(m.theme, overrides)
+	m.emailCache.clear()
+}
+
+// Reload re-reads the theme file, if any, reloads the base templates from all sources, and clears the
+// email cache. Development mode reloads before every render, re-reading the theme file only when it changed.
+func (m *Manager) Reload() error {
+	return m.reload(true)
+}
+
+// reload reloads the theme file and templates. The theme file is only re-read when force is set or its
+// modification time or size changed since the last load.
+func (m *Manager) reload(force bool) error {
+	if err := m.loadThemeFile(force); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.emailCache.clear()
+	return m.loadBaseTemplates()
+}
+
+// themeStamp identifies a version of the theme file
+type themeStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// loadThemeFile merges the configured theme file over the base theme. Unless force is set, an unchanged file
+// is not read again.
+func (m *Manager) loadThemeFile(force bool) error {
+	if m.themeFile == nil {
+		return nil
+	}
+
+	var stamp themeStamp
+	info, statErr := fs.Stat(m.themeFile.FS, m.themeFile.Path)
+	if statErr == nil {
+		stamp = themeStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+
+	m.mu.RLock()
+	unchanged := statErr == nil && stamp == m.themeStamp
+	m.mu.RUnlock()
+	if unchanged && !force {
+		return nil
+	}
+
+	overrides, err := LoadTheme(m.themeFile.FS, m.themeFile.Path)
+	if err != nil {
+		m.mu.Lock()
+		m.themeStamp = themeStamp{}
+		m.mu.Unlock()
+		return err
+	}
+
+	theme := MergeTheme(m.baseTheme, overrides)
+
+	m.mu.Lock()
+	m.theme = theme
+	m.themeStamp = stamp
+	m.mu.Unlock()
+
+	return nil
+}
+
+// colorSchemeMeta declares light and dark support so clients apply the dark palette block
+const colorSchemeMeta = `<meta name="color-scheme" content="light dark"/>
+<meta name="supported-color-schemes" content="light dark"/>
+`
+
+// darkModeHead renders the color-scheme meta tags and dark palette style block for a layout head
+func darkModeHead(theme map[string]any) template.HTML {
+	css := DarkModeCSS(theme)
+	if css == "" {
+		return ""
+	}
+
+	return template.HTML(colorSchemeMeta + "<style type=\"text/css\">\n" + css + "</style>")
+}
+
+// themeStylesHead renders the full theme stylesheet, including the dark palette, for a layout head
+func themeStylesHead(theme map[string]any) template.HTML {
+	var head string
+	if len(DarkColors(theme)) > 0 {
+		head = colorSchemeMeta
+	}
+
+	return template.HTML(head + "<style type=\"text/css\">\n" + ThemeCSS(theme) + "</style>")
+}
+
 // AddSource adds a new template source to the manager
 func (m *Manager) AddSource(source TemplateSource) error {
 	m.mu.Lock()
@@ -384,8 +749,61 @@ func (m *Manager) AddSource(source TemplateSource) error {
 	m.sources = append(m.sources, source)
 
 	// Clear cache since we have new sources
-	m.emailCache = make(map[string]*template.Template)
+	m.emailCache.clear()
 
 	// Reload base templates to incorporate new source
 	return m.loadBaseTemplates()
 }
+
+// Layouts returns the sorted names of the layouts in all sources, e.g. "base" and "marketing"
+func (m *Manager) Layouts() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var names []string
+	for _, t := range m.baseTemplates[FormatHTML].Templates() {
+		if name, ok := strings.CutPrefix(t.Name(), "layout:"); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Emails returns the sorted names of the email templates in all sources, in either format. Emails in
+// subdirectories are named by their path, e.g. "account/welcome".
+func (m *Manager) Emails() ([]string, error) {
+	m.mu.RLock()
+	sources := m.sources
+	m.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	for _, source := range sources {
+		err := fs.WalkDir(source.FS, EmailsDir, func(filePath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return fmt.Errorf("walk error for %s: %w", filePath, err)
+			}
+			if d.IsDir() || formatFromFile(filePath) == "" || strings.HasSuffix(filePath, subjectExtension) {
+				return nil
+			}
+
+			seen[m.templateName(EmailsDir, filePath)] = true
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
@@ -1,12 +1,16 @@
 package mailpen_test
 
 import (
+	"html/template"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/processors/plaintext"
 )
 
 func TestManager_RenderEmail(t *testing.T) {
@@ -221,3 +225,101 @@ func TestManager_CacheClearing(t *testing.T) {
 	require.NoError(t, err)
 	assert.Contains(t, email.HTML, "Welcome, John Doe!")
 }
+
+func TestManager_TextConverter(t *testing.T) {
+	templateFS := fstest.MapFS{
+		"emails/html-only.html": {Data: []byte(`{{define "content"}}<h1>Hello {{.Name}}</h1><p>Visit <a href="https://example.com">us</a>.</p>{{end}}`)},
+		"emails/both.html":      {Data: []byte(`{{define "content"}}<p>HTML</p>{{end}}`)},
+		"emails/both.txt":       {Data: []byte(`{{define "content"}}Hand-written text{{end}}`)},
+	}
+
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources:       []mailpen.TemplateSource{{Name: "html", FS: templateFS}},
+		TextConverter: plaintext.New(),
+	})
+	require.NoError(t, err)
+
+	email, err := manager.RenderEmail("html-only", map[string]any{"Name": "John"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello John\n\nVisit us (https://example.com).", email.Text)
+
+	// A text template always takes precedence over the converted HTML
+	email, err = manager.RenderEmail("both", nil, "")
+	require.NoError(t, err)
+	assert.Contains(t, email.Text, "Hand-written text")
+}
+
+func TestManager_ConfigFuncMap(t *testing.T) {
+	templateFS := fstest.MapFS{
+		"emails/shout.html": {Data: []byte(`{{define "content"}}{{shout .Name}}{{end}}`)},
+	}
+
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{{Name: "test", FS: templateFS}},
+		FuncMap: template.FuncMap{"shout": strings.ToUpper},
+	})
+	require.NoError(t, err)
+
+	email, err := manager.RenderEmail("shout", map[string]any{"Name": "john"}, "")
+	require.NoError(t, err)
+	assert.Contains(t, email.HTML, "JOHN")
+}
+
+// analyzerFunc adapts a function to the mailpen.HTMLAnalyzer interface
+type analyzerFunc func(html string) ([]string, error)
+
+func (f analyzerFunc) Analyze(html string) ([]string, error) {
+	return f(html)
+}
+
+func TestManager_Analyzers(t *testing.T) {
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{{Name: "default", FS: testFS(t, "default")}},
+		Analyzers: []mailpen.HTMLAnalyzer{
+			analyzerFunc(func(html string) ([]string, error) {
+				return []string{"first"}, nil
+			}),
+			analyzerFunc(func(html string) ([]string, error) {
+				return []string{"second"}, nil
+			}),
+		},
+	})
+	require.NoError(t, err)
+
+	email, err := manager.RenderEmail("simple", nil, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, email.Warnings)
+	assert.Contains(t, email.HTML, "Default HTML email without layout", "analyzers must not modify the HTML")
+}
+
+func TestManager_Emails(t *testing.T) {
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{
+			{Name: "base", FS: fstest.MapFS{
+				"emails/welcome.html":       {Data: []byte(`{{define "content"}}Hi{{end}}`)},
+				"emails/welcome.txt":        {Data: []byte(`{{define "content"}}Hi{{end}}`)},
+				"emails/account/reset.html": {Data: []byte(`{{define "content"}}Reset{{end}}`)},
+				"emails/notes.md":           {Data: []byte(`not a template`)},
+			}},
+			{Name: "override", FS: fstest.MapFS{
+				"emails/welcome.html": {Data: []byte(`{{define "content"}}Hello{{end}}`)},
+				"emails/receipt.txt":  {Data: []byte(`{{define "content"}}Paid{{end}}`)},
+			}},
+			{Name: "no emails", FS: fstest.MapFS{}},
+		},
+	})
+	require.NoError(t, err)
+
+	emails, err := manager.Emails()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"account/reset", "receipt", "welcome"}, emails)
+}
+
+func TestManager_Layouts(t *testing.T) {
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"base", "marketing"}, manager.Layouts())
+}
@@ -1,27 +1,38 @@
 package mailpen
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path"
+	"time"
 )
 
 // Message represents the content and recipients of an email message
 type Message struct {
-	From        string         // Sender email address
-	To          []string       // List of recipient email addresses
-	Cc          []string       // List of CC email addresses
-	Bcc         []string       // List of BCC email addresses
-	ReplyTo     string         // Reply-to email address
-	Subject     string         // Email subject
-	Data        map[string]any // Data to be passed to the templates
-	Layout      string         // Layout name to process
-	Template    string         // Template name to process
-	TextBody    string         // Text body of the email
-	HTMLBody    string         // HTML body of the email
-	Attachments []Attachment   // List of attachments
+	ID          string            // Unique message identifier, generated on send when empty
+	From        string            // Sender email address
+	To          []string          // List of recipient email addresses
+	Cc          []string          // List of CC email addresses
+	Bcc         []string          // List of BCC email addresses
+	ReplyTo     string            // Reply-to email address
+	Subject     string            // Email subject
+	Data        map[string]any    // Data to be passed to the templates
+	Layout      string            // Layout name to process
+	Template    string            // Template name to process
+	TextBody    string            // Text body of the email
+	HTMLBody    string            // HTML body of the email
+	Attachments []Attachment      // List of attachments
+	Metadata    map[string]string // Arbitrary metadata for processors and tracking (not sent as headers)
+	Locale      string            // Locale of the message (e.g. "en-US"), made available to processors
+	Tags        []string          // Tags used to route processors (e.g. "transactional")
+	Headers     map[string]string // Additional headers to send with the message (e.g. "List-Unsubscribe")
+	SendAt      time.Time         // When to deliver the message; zero sends immediately
+	SkipArchive bool              // Do not copy Config.AlwaysBcc on this message (for sensitive mail)
+
+	ProviderMessageID string // Message ID reported by the provider after a successful send, if any
 }
 
 // Attachment represents an email attachment
@@ -29,6 +40,7 @@ type Attachment struct {
 	Filename    string
 	Data        io.Reader
 	ContentType ContentType
+	ContentID   string // Content-ID of an inline attachment, referenced from the HTML body as cid:<ContentID>
 }
 
 // Builder provides a fluent interface for constructing emails
@@ -120,6 +132,75 @@ func (b *Builder) Layout(name string) *Builder {
 	return b
 }
 
+// ID sets the unique identifier of the message
+func (b *Builder) ID(id string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	b.msg.ID = id
+	return b
+}
+
+// Tag adds tags to the message
+func (b *Builder) Tag(tags ...string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	b.msg.Tags = append(b.msg.Tags, tags...)
+	return b
+}
+
+// Locale sets the locale of the message
+func (b *Builder) Locale(locale string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	b.msg.Locale = locale
+	return b
+}
+
+// Metadata sets a metadata value on the message, such as a campaign or user identifier used by message processors
+func (b *Builder) Metadata(key, value string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if b.msg.Metadata == nil {
+		b.msg.Metadata = make(map[string]string)
+	}
+	b.msg.Metadata[key] = value
+	return b
+}
+
+// SendAt schedules the message for delivery at the given time
+func (b *Builder) SendAt(t time.Time) *Builder {
+	if b.err != nil {
+		return b
+	}
+	b.msg.SendAt = t
+	return b
+}
+
+// SkipArchive keeps Config.AlwaysBcc recipients off the message, for sensitive mail such as password resets
+func (b *Builder) SkipArchive() *Builder {
+	if b.err != nil {
+		return b
+	}
+	b.msg.SkipArchive = true
+	return b
+}
+
+// Header sets an additional header on the message
+func (b *Builder) Header(key, value string) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if b.msg.Headers == nil {
+		b.msg.Headers = make(map[string]string)
+	}
+	b.msg.Headers[key] = value
+	return b
+}
+
 // Attach adds an attachment to the email. The data is read from the provided reader and the content type is inferred from the filename.
 func (b *Builder) Attach(filename string, data io.Reader) *Builder {
 	if b.err != nil {
@@ -145,6 +226,25 @@ func (b *Builder) AttachWithContentType(filename string, data io.Reader, content
 	return b
 }
 
+// AttachEvent adds the event as an "invite.ics" calendar attachment.
+func (b *Builder) AttachEvent(event CalendarEvent) *Builder {
+	return b.AttachWithContentType("invite.ics", bytes.NewReader(event.ICS()), TypeTextCalendar+"; method=PUBLISH")
+}
+
+// Embed adds an inline attachment to the email that the HTML body can reference as cid:<contentID>. The data is read from the provided reader.
+func (b *Builder) Embed(filename, contentID string, data io.Reader, contentType ContentType) *Builder {
+	if b.err != nil {
+		return b
+	}
+	b.msg.Attachments = append(b.msg.Attachments, Attachment{
+		Filename:    filename,
+		Data:        data,
+		ContentType: contentType,
+		ContentID:   contentID,
+	})
+	return b
+}
+
 // OpenFileAttachment is a helper that returns a file reader and a cleanup function
 // for an attachment file. The filename is extracted from the filepath.
 // It returns the filename, a reader for the file, a cleanup function, and an error if the file cannot be opened.
@@ -190,7 +290,7 @@ func (b *Builder) Build() (*Message, error) {
 		return nil, b.err
 	}
 	if len(b.msg.To) == 0 {
-		return nil, errors.New("email must have at least one recipient")
+		return nil, ErrNoRecipients
 	}
 	return b.msg, nil
 }
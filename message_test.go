@@ -52,6 +52,17 @@ func TestMessageBuilder(t *testing.T) {
 				assert.Equal(t, "reply@example.com", msg.ReplyTo)
 			},
 		},
+		{
+			name: "message with metadata",
+			build: func(b *mailpen.Builder) {
+				b.To("user@example.com").
+					Metadata("campaign", "spring").
+					Metadata("user_id", "42")
+			},
+			validate: func(t *testing.T, msg *mailpen.Message) {
+				assert.Equal(t, map[string]string{"campaign": "spring", "user_id": "42"}, msg.Metadata)
+			},
+		},
 		{
 			name:      "missing recipient",
 			build:     func(b *mailpen.Builder) {},
@@ -2,19 +2,66 @@ package mailpen
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
 )
 
+// ErrModuleNotInitialized is returned by Module methods called before Init
+var ErrModuleNotInitialized = errors.New("mailpen module is not initialized")
+
+// Worker is a background process a Module runs alongside its Mailpen instance, such as queue workers or a
+// digest scheduler. Start must not block, and work that outlives it must not depend on ctx, which may be a
+// startup deadline. Stop waits for the work to finish within the deadline of ctx.
+type Worker interface {
+	Start(ctx context.Context, mp *Mailpen) error
+	Stop(ctx context.Context) error
+}
+
+// WorkerQueue is implemented by queues that deliver with their own workers, such as queue.Queue. A Module
+// starts the workers of the queue set with WithQueue, so enqueued mail is delivered without a separate
+// WithWorker.
+type WorkerQueue interface {
+	Worker() Worker
+}
+
 type Module struct {
 	config   *Config
 	mailpen  *Mailpen
 	provider Provider
+	options  []Option
+	workers  []Worker
+	started  []Worker // Workers started by Start, in order
+}
+
+// ModuleOption configures a Module
+type ModuleOption func(m *Module)
+
+// WithMailpenOptions sets the options the module creates its Mailpen instance with, such as WithQueue
+func WithMailpenOptions(opts ...Option) ModuleOption {
+	return func(m *Module) {
+		m.options = append(m.options, opts...)
+	}
+}
+
+// WithWorker adds a worker that the module starts with Start and stops with Stop
+func WithWorker(w Worker) ModuleOption {
+	return func(m *Module) {
+		m.workers = append(m.workers, w)
+	}
 }
 
-func NewModule(provider Provider, config *Config) *Module {
-	return &Module{
+func NewModule(provider Provider, config *Config, opts ...ModuleOption) *Module {
+	m := &Module{
 		config:   config,
 		provider: provider,
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 func (m *Module) ID() string {
@@ -22,7 +69,7 @@ func (m *Module) ID() string {
 }
 
 func (m *Module) Init() error {
-	mp, err := New(m.provider, m.config)
+	mp, err := New(m.provider, m.config, m.options...)
 	if err != nil {
 		return err
 	}
@@ -30,14 +77,122 @@ func (m *Module) Init() error {
 	return nil
 }
 
-func (m *Module) Start(_ context.Context) error {
+// Start starts the workers in order, followed by the workers of the Mailpen's queue when it is a WorkerQueue
+// that was not also added with WithWorker. When one fails to start, those already started are stopped and the
+// error is returned.
+func (m *Module) Start(ctx context.Context) error {
+	if m.mailpen == nil {
+		return ErrModuleNotInitialized
+	}
+
+	for _, w := range m.startOrder() {
+		if err := w.Start(ctx, m.mailpen); err != nil {
+			return errors.Join(fmt.Errorf("failed to start worker: %w", err), m.stopWorkers(context.WithoutCancel(ctx)))
+		}
+		m.started = append(m.started, w)
+	}
 	return nil
 }
 
-func (m *Module) Stop(_ context.Context) error {
-	return nil
+// startOrder returns the workers to start: those added with WithWorker, then the queue's worker
+func (m *Module) startOrder() []Worker {
+	wq, ok := m.mailpen.queue.(WorkerQueue)
+	if !ok {
+		return m.workers
+	}
+
+	qw := wq.Worker()
+	if qw == nil {
+		return m.workers
+	}
+	// Comparing with a worker of a non-comparable type panics, so such queue workers are always added
+	if reflect.TypeOf(qw).Comparable() && slices.Contains(m.workers, qw) {
+		return m.workers
+	}
+	return append(slices.Clip(m.workers), qw)
+}
+
+// Stop shuts down the Mailpen instance, flushing queued messages within the deadline of ctx (see
+// Mailpen.Shutdown), and then stops the workers in reverse order within the same deadline
+func (m *Module) Stop(ctx context.Context) error {
+	if m.mailpen == nil {
+		return nil
+	}
+	_, err := m.mailpen.Shutdown(ctx)
+	return errors.Join(err, m.stopWorkers(ctx))
+}
+
+// stopWorkers stops the started workers in reverse order
+func (m *Module) stopWorkers(ctx context.Context) error {
+	var errs []error
+	for _, w := range slices.Backward(m.started) {
+		if err := w.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop worker: %w", err))
+		}
+	}
+	m.started = nil
+	return errors.Join(errs...)
+}
+
+// Health reports the health of the Mailpen instance (see Mailpen.Health)
+func (m *Module) Health(ctx context.Context) (Health, error) {
+	if m.mailpen == nil {
+		return Health{}, ErrModuleNotInitialized
+	}
+	return m.mailpen.Health(ctx), nil
 }
 
 func (m *Module) Mailpen() *Mailpen {
 	return m.mailpen
 }
+
+// BackgroundWorker returns a Worker that calls run in a goroutine on Start, and on Stop cancels its context
+// and waits for it to return. Use it for loops such as digest.Engine.Run.
+func BackgroundWorker(run func(ctx context.Context, mp *Mailpen)) Worker {
+	return &backgroundWorker{run: run}
+}
+
+type backgroundWorker struct {
+	run    func(ctx context.Context, mp *Mailpen)
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Start implements Worker. The run context is detached from ctx, so it lasts until Stop.
+func (w *backgroundWorker) Start(ctx context.Context, mp *Mailpen) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.done != nil {
+		return errors.New("worker already started")
+	}
+
+	ctx, w.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	w.done = make(chan struct{})
+	go func(done chan struct{}) {
+		defer close(done)
+		w.run(ctx, mp)
+	}(w.done)
+	return nil
+}
+
+// Stop implements Worker
+func (w *backgroundWorker) Stop(ctx context.Context) error {
+	w.mu.Lock()
+	cancel, done := w.cancel, w.done
+	w.cancel, w.done = nil, nil
+	w.mu.Unlock()
+
+	if done == nil {
+		return nil
+	}
+
+	cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,166 @@
+package mailpen_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+// recordingWorker records the order workers are started and stopped in
+type recordingWorker struct {
+	name     string
+	log      *[]string
+	startErr error
+	mp       *mailpen.Mailpen
+}
+
+func (w *recordingWorker) Start(_ context.Context, mp *mailpen.Mailpen) error {
+	if w.startErr != nil {
+		return w.startErr
+	}
+	w.mp = mp
+	*w.log = append(*w.log, "start "+w.name)
+	return nil
+}
+
+func (w *recordingWorker) Stop(context.Context) error {
+	*w.log = append(*w.log, "stop "+w.name)
+	return nil
+}
+
+// checkedProvider is a mock provider that implements mailpen.HealthChecker
+type checkedProvider struct {
+	mockProvider
+	err error
+}
+
+func (p *checkedProvider) CheckHealth(context.Context) error { return p.err }
+
+// sizedQueue is a queue that implements mailpen.QueueSizer
+type sizedQueue struct {
+	n   int
+	err error
+}
+
+func (q *sizedQueue) Enqueue(context.Context, *mailpen.Message) error { return nil }
+func (q *sizedQueue) Len(context.Context) (int, error)                { return q.n, q.err }
+
+func TestModule_Lifecycle(t *testing.T) {
+	t.Run("workers start in order and stop in reverse", func(t *testing.T) {
+		var log []string
+		first := &recordingWorker{name: "first", log: &log}
+		second := &recordingWorker{name: "second", log: &log}
+
+		module := mailpen.NewModule(&mockProvider{}, &mailpen.Config{}, mailpen.WithWorker(first), mailpen.WithWorker(second))
+		require.NoError(t, module.Init())
+		require.NoError(t, module.Start(context.Background()))
+		assert.Same(t, module.Mailpen(), first.mp)
+
+		require.NoError(t, module.Stop(context.Background()))
+		assert.Equal(t, []string{"start first", "start second", "stop second", "stop first"}, log)
+		assert.ErrorIs(t, module.Mailpen().Enqueue(context.Background(), mailpen.NewMessage().To("a@example.com").Must()), mailpen.ErrShutdown)
+	})
+
+	t.Run("failed start stops started workers", func(t *testing.T) {
+		var log []string
+		failing := &recordingWorker{name: "failing", log: &log, startErr: errors.New("boom")}
+
+		module := mailpen.NewModule(&mockProvider{}, &mailpen.Config{},
+			mailpen.WithWorker(&recordingWorker{name: "first", log: &log}), mailpen.WithWorker(failing))
+		require.NoError(t, module.Init())
+
+		err := module.Start(context.Background())
+		assert.ErrorContains(t, err, "failed to start worker: boom")
+		assert.Equal(t, []string{"start first", "stop first"}, log)
+	})
+
+	t.Run("start before init", func(t *testing.T) {
+		module := mailpen.NewModule(&mockProvider{}, &mailpen.Config{})
+		assert.ErrorIs(t, module.Start(context.Background()), mailpen.ErrModuleNotInitialized)
+		_, err := module.Health(context.Background())
+		assert.ErrorIs(t, err, mailpen.ErrModuleNotInitialized)
+	})
+}
+
+func TestModule_Health(t *testing.T) {
+	tests := []struct {
+		name        string
+		provider    mailpen.Provider
+		queue       mailpen.Queue
+		wantQueued  int
+		wantHealthy bool
+	}{
+		{name: "no checks", provider: &mockProvider{}, wantQueued: -1, wantHealthy: true},
+		{name: "reachable provider and queue", provider: &checkedProvider{}, queue: &sizedQueue{n: 3}, wantQueued: 3, wantHealthy: true},
+		{name: "unreachable provider", provider: &checkedProvider{err: mailpen.ErrProviderUnavailable}, wantQueued: -1},
+		{name: "queue error", provider: &mockProvider{}, queue: &sizedQueue{err: errors.New("redis down")}, wantQueued: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []mailpen.Option
+			if tt.queue != nil {
+				opts = append(opts, mailpen.WithQueue(tt.queue))
+			}
+			module := mailpen.NewModule(tt.provider, &mailpen.Config{}, mailpen.WithMailpenOptions(opts...))
+			require.NoError(t, module.Init())
+
+			health, err := module.Health(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, "mock", health.Provider)
+			assert.Equal(t, tt.wantQueued, health.Queued)
+			assert.Equal(t, tt.wantHealthy, health.Healthy())
+		})
+	}
+
+	t.Run("shut down", func(t *testing.T) {
+		module := mailpen.NewModule(&mockProvider{}, &mailpen.Config{})
+		require.NoError(t, module.Init())
+		require.NoError(t, module.Stop(context.Background()))
+
+		health, err := module.Health(context.Background())
+		require.NoError(t, err)
+		assert.True(t, health.ShutDown)
+		assert.False(t, health.Healthy())
+	})
+}
+
+func TestBackgroundWorker(t *testing.T) {
+	var mu sync.Mutex
+	var ticks int
+	worker := mailpen.BackgroundWorker(func(ctx context.Context, _ *mailpen.Mailpen) {
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				mu.Lock()
+				ticks++
+				mu.Unlock()
+			}
+		}
+	})
+
+	startCtx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, worker.Start(startCtx, nil))
+	cancel()
+	assert.Error(t, worker.Start(context.Background(), nil))
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return ticks > 2
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, worker.Stop(context.Background()))
+	require.NoError(t, worker.Stop(context.Background()))
+}
@@ -0,0 +1,96 @@
+package mailpen
+
+import (
+	"errors"
+	"time"
+)
+
+// Clock supplies the current time. Tests can use a fixed clock to get stable template dates and scheduling.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to the Clock interface
+type ClockFunc func() time.Time
+
+// Now implements Clock
+func (f ClockFunc) Now() time.Time { return f() }
+
+// systemClock is the Clock that reads the system time
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// WithClock sets the clock used for template dates, scheduling decisions, and event timestamps (defaults to
+// the system clock)
+func WithClock(clock Clock) Option {
+	return func(m *Mailpen) error {
+		if clock == nil {
+			clock = systemClock{}
+		}
+		m.clock = clock
+		return nil
+	}
+}
+
+// WithDefaultLayout sets the layout used for messages that do not name one, in place of Config.DefaultLayout
+// or the default layout of a shared manager
+func WithDefaultLayout(name string) Option {
+	return func(m *Mailpen) error {
+		if name != "" {
+			m.defaultLayout = name
+		}
+		return nil
+	}
+}
+
+// WithTemplateSources adds template sources after those in Config.Sources. Later sources override earlier ones.
+// It cannot be combined with WithManager.
+func WithTemplateSources(sources ...TemplateSource) Option {
+	return func(m *Mailpen) error {
+		m.sources = append(m.sources, sources...)
+		return nil
+	}
+}
+
+// WithManager renders with an existing template manager instead of creating one, so instances with different
+// providers or senders share one compiled template set. The template fields of Config, such as Sources,
+// Theme, FuncMap, and the HTML processors, are ignored; the manager's own configuration applies. Instances
+// sharing a manager share its template cache, theme, and schemas.
+func WithManager(manager *Manager) Option {
+	return func(m *Mailpen) error {
+		if manager == nil {
+			return errors.New("manager is required")
+		}
+		m.templateMgr = manager
+		return nil
+	}
+}
+
+// WithSendTimeout limits rendering, processing, and provider delivery of each message, in place of
+// Config.SendTimeout
+func WithSendTimeout(timeout time.Duration) Option {
+	return func(m *Mailpen) error {
+		m.sendTimeout = timeout
+		return nil
+	}
+}
+
+// WithMessageProcessors adds message processors after those in Config.MessageProcessors
+func WithMessageProcessors(processors ...MessageProcessor) Option {
+	return func(m *Mailpen) error {
+		m.processors = append(m.processors, processors...)
+		return nil
+	}
+}
+
+// WithProvider replaces the provider passed to New, for example to wrap it with a router or a test double
+func WithProvider(provider Provider) Option {
+	return func(m *Mailpen) error {
+		if provider == nil {
+			return errors.New("provider is required")
+		}
+		m.provider = provider
+		return nil
+	}
+}
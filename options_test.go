@@ -0,0 +1,133 @@
+package mailpen_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+func TestOptions(t *testing.T) {
+	fixed := time.Date(2031, 5, 1, 12, 0, 0, 0, time.UTC)
+	mock := &mockProvider{}
+	other := &mockProvider{}
+	calls := 0
+
+	mp, err := mailpen.New(mock, &mailpen.Config{From: "sender@example.com", CompanyName: "Acme"},
+		mailpen.WithProvider(other),
+		mailpen.WithClock(mailpen.ClockFunc(func() time.Time { return fixed })),
+		mailpen.WithTemplateSources(mailpen.TemplateSource{Name: "base", FS: testFS(t, "base")}),
+		mailpen.WithDefaultLayout("marketing"),
+		mailpen.WithMessageProcessors(&countingProcessor{calls: &calls}),
+		mailpen.WithSendTimeout(time.Second),
+	)
+	require.NoError(t, err)
+
+	data := mp.NewTemplateData()
+	assert.Equal(t, 2031, data["CurrentYear"])
+	assert.Equal(t, "May 1, 2031", data["CurrentDate"])
+
+	msg := mailpen.NewMessage().To("a@example.com").Subject("Welcome").Template("welcome").
+		WithData(map[string]any{"Name": "Jane"}).Must()
+	require.NoError(t, mp.Send(context.Background(), msg))
+
+	assert.Zero(t, mock.sendCalls)
+	assert.Equal(t, 1, other.sendCalls)
+	assert.Equal(t, 1, calls)
+	assert.Contains(t, other.lastMessage.HTMLBody, "Welcome, Jane!")
+
+	rendered, err := mp.Render(context.Background(), mailpen.NewMessage().To("a@example.com").Subject("Welcome").
+		Template("welcome").Layout("base").Must())
+	require.NoError(t, err)
+	assert.NotEqual(t, other.lastMessage.HTMLBody, rendered.HTML, "the default layout is marketing")
+
+	_, err = mailpen.New(mock, &mailpen.Config{}, mailpen.WithProvider(nil))
+	assert.Error(t, err)
+}
+
+func TestOptions_ClockSchedulesMessages(t *testing.T) {
+	now := time.Date(2031, 5, 1, 12, 0, 0, 0, time.UTC)
+	mock := &mockProvider{}
+	mp, err := mailpen.New(mock, &mailpen.Config{From: "sender@example.com"},
+		mailpen.WithClock(mailpen.ClockFunc(func() time.Time { return now })))
+	require.NoError(t, err)
+
+	msg := mailpen.NewMessage().To("a@example.com").Subject("Hi").SendAt(now.Add(-time.Minute)).Must()
+	msg.TextBody = "Hi"
+	require.NoError(t, mp.Send(context.Background(), msg), "a SendAt in the clock's past sends immediately")
+	assert.Equal(t, 1, mock.sendCalls)
+
+	msg = mailpen.NewMessage().To("a@example.com").Subject("Hi").SendAt(now.Add(time.Minute)).Must()
+	msg.TextBody = "Hi"
+	assert.ErrorIs(t, mp.Send(context.Background(), msg), mailpen.ErrSchedulingUnavailable)
+}
+
+func TestWithManager(t *testing.T) {
+	source := &countingFS{FS: fstest.MapFS{
+		"layouts/base.html":   {Data: []byte(`<html>{{template "content" .}}</html>`)},
+		"layouts/plain.html":  {Data: []byte(`<div>{{template "content" .}}</div>`)},
+		"emails/welcome.html": {Data: []byte(`{{define "content"}}Welcome {{.Name}}{{end}}`)},
+		"emails/shared.html":  {Data: []byte(`{{define "content"}}Shared{{end}}`)},
+	}, opens: make(map[string]int)}
+
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{{Name: "test", FS: source}},
+	})
+	require.NoError(t, err)
+	opens := source.count("layouts/base.html")
+
+	transactional, marketing := &mockProvider{}, &mockProvider{}
+	txMailpen, err := mailpen.New(transactional, &mailpen.Config{From: "noreply@example.com"}, mailpen.WithManager(manager))
+	require.NoError(t, err)
+	mkMailpen, err := mailpen.New(marketing, &mailpen.Config{From: "news@example.com"},
+		mailpen.WithManager(manager), mailpen.WithDefaultLayout("plain"))
+	require.NoError(t, err)
+
+	t.Run("instances reuse the compiled templates", func(t *testing.T) {
+		assert.Same(t, manager, txMailpen.Manager())
+		assert.Same(t, manager, mkMailpen.Manager())
+		assert.Equal(t, opens, source.count("layouts/base.html"))
+	})
+
+	t.Run("instances keep their own provider, sender, and default layout", func(t *testing.T) {
+		msg := func() *mailpen.Message {
+			return mailpen.NewMessage().To("ada@example.com").Subject("Hi").Template("welcome").
+				WithData(map[string]any{"Name": "Ada"}).Must()
+		}
+
+		require.NoError(t, txMailpen.Send(context.Background(), msg()))
+		assert.Equal(t, "noreply@example.com", transactional.lastMessage.From)
+		assert.Equal(t, "<html>Welcome Ada</html>", transactional.lastMessage.HTMLBody)
+
+		require.NoError(t, mkMailpen.Send(context.Background(), msg()))
+		assert.Equal(t, "news@example.com", marketing.lastMessage.From)
+		assert.Equal(t, "<div>Welcome Ada</div>", marketing.lastMessage.HTMLBody)
+	})
+
+	t.Run("the template cache is shared", func(t *testing.T) {
+		msg := func() *mailpen.Message {
+			return mailpen.NewMessage().To("ada@example.com").Subject("Hi").Template("shared").Must()
+		}
+		require.NoError(t, txMailpen.Send(context.Background(), msg()))
+		require.NoError(t, mkMailpen.Send(context.Background(), msg()))
+		assert.Equal(t, 1, source.count("emails/shared.html"))
+	})
+
+	t.Run("manager options cannot be combined", func(t *testing.T) {
+		_, err := mailpen.New(&mockProvider{}, &mailpen.Config{}, mailpen.WithManager(manager),
+			mailpen.WithTemplateSources(mailpen.TemplateSource{Name: "extra", FS: fstest.MapFS{}}))
+		assert.ErrorContains(t, err, "template sources cannot be added to a shared manager")
+
+		_, err = mailpen.New(&mockProvider{}, &mailpen.Config{}, mailpen.WithManager(manager),
+			mailpen.WithRenderTiming(func(context.Context, mailpen.RenderTimings) {}))
+		assert.ErrorContains(t, err, "render timing cannot be set on a shared manager")
+
+		_, err = mailpen.New(&mockProvider{}, &mailpen.Config{}, mailpen.WithManager(nil))
+		assert.ErrorContains(t, err, "manager is required")
+	})
+}
@@ -0,0 +1,48 @@
+package mailpen
+
+// RenderPolicy decides which formats an email must have to render. A format a policy allows to be missing is
+// skipped, and RequireHTML reports it in the rendered email's warnings. Only HTMLOptional renders text-only
+// emails.
+type RenderPolicy int
+
+const (
+	// RequireHTML requires an HTML template. A missing text template is derived from the HTML with the
+	// TextConverter, or skipped with a warning when there is none. It is the default.
+	RequireHTML RenderPolicy = iota + 1
+	// RequireBoth requires both an HTML and a text template, failing with ErrTemplateNotFound otherwise.
+	// Converting the HTML does not count as a text version.
+	RequireBoth
+	// TextOptional requires an HTML template, like RequireHTML, for emails that are HTML-only by design: a
+	// text version that cannot be derived is skipped without a warning.
+	TextOptional
+	// HTMLOptional allows text-only emails: a missing HTML template is skipped when there is a text template. A
+	// missing text template is handled as with RequireHTML.
+	HTMLOptional
+)
+
+// String returns the policy name
+func (p RenderPolicy) String() string {
+	switch p {
+	case RequireHTML:
+		return "RequireHTML"
+	case RequireBoth:
+		return "RequireBoth"
+	case TextOptional:
+		return "TextOptional"
+	case HTMLOptional:
+		return "HTMLOptional"
+	default:
+		return "RenderPolicy(unset)"
+	}
+}
+
+// renderPolicy returns the policy of a render: the render option, when set, or the manager's
+func (m *Manager) renderPolicy(p RenderPolicy) RenderPolicy {
+	if p != 0 {
+		return p
+	}
+	if m.policy != 0 {
+		return m.policy
+	}
+	return RequireHTML
+}
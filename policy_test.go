@@ -0,0 +1,157 @@
+package mailpen_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/processors/plaintext"
+)
+
+func TestManager_RenderPolicy(t *testing.T) {
+	files := fstest.MapFS{
+		"layouts/base.html":       {Data: []byte(`<p>{{template "content" .}}</p>`)},
+		"layouts/base.txt":        {Data: []byte(`{{template "content" .}}`)},
+		"emails/both.html":        {Data: []byte(`{{define "content"}}Both{{end}}`)},
+		"emails/both.txt":         {Data: []byte(`{{define "content"}}Both text{{end}}`)},
+		"emails/html-only.html":   {Data: []byte(`{{define "content"}}HTML only{{end}}`)},
+		"emails/text-only.txt":    {Data: []byte(`{{define "content"}}Text only{{end}}`)},
+		"emails/broken-text.html": {Data: []byte(`{{define "content"}}Broken text{{end}}`)},
+		"emails/broken-text.txt":  {Data: []byte(`{{define "content"}}{{.Name{{end}}`)},
+	}
+
+	tests := []struct {
+		name         string
+		policy       mailpen.RenderPolicy
+		optPolicy    mailpen.RenderPolicy
+		converter    bool
+		email        string
+		wantText     string
+		wantWarnings []string
+		wantErr      error
+		errContains  string
+	}{
+		{name: "both formats", email: "both", wantText: "Both text"},
+		{
+			name:         "missing text is skipped with a warning",
+			email:        "html-only",
+			wantWarnings: []string{"text: version skipped: template not found: emails/html-only.txt, and no text converter is configured"},
+		},
+		{name: "missing text is converted", email: "html-only", converter: true, wantText: "HTML only"},
+		{name: "text optional skips without a warning", policy: mailpen.TextOptional, email: "html-only"},
+		{
+			name:        "require both fails without text",
+			policy:      mailpen.RequireBoth,
+			email:       "html-only",
+			wantErr:     mailpen.ErrTemplateNotFound,
+			errContains: "failed to render text template: template not found: emails/html-only.txt",
+		},
+		{
+			name:      "require both does not accept converted text",
+			policy:    mailpen.RequireBoth,
+			converter: true,
+			email:     "html-only",
+			wantErr:   mailpen.ErrTemplateNotFound,
+		},
+		{name: "require both with both formats", policy: mailpen.RequireBoth, email: "both", wantText: "Both text"},
+		{
+			name:      "render option overrides the manager policy",
+			optPolicy: mailpen.RequireBoth,
+			email:     "html-only",
+			wantErr:   mailpen.ErrTemplateNotFound,
+		},
+		{
+			name:        "text only fails by default",
+			email:       "text-only",
+			wantErr:     mailpen.ErrTemplateNotFound,
+			errContains: "failed to render HTML template: template not found: emails/text-only.html",
+		},
+		{
+			name:        "text only fails with require HTML",
+			policy:      mailpen.RequireHTML,
+			email:       "text-only",
+			wantErr:     mailpen.ErrTemplateNotFound,
+			errContains: "failed to render HTML template: template not found: emails/text-only.html",
+		},
+		{
+			name:        "text only fails with require both",
+			policy:      mailpen.RequireBoth,
+			email:       "text-only",
+			wantErr:     mailpen.ErrTemplateNotFound,
+			errContains: "failed to render HTML template: template not found: emails/text-only.html",
+		},
+		{
+			name:        "text only fails with text optional",
+			policy:      mailpen.TextOptional,
+			email:       "text-only",
+			wantErr:     mailpen.ErrTemplateNotFound,
+			errContains: "failed to render HTML template: template not found: emails/text-only.html",
+		},
+		{name: "text only renders with HTML optional", policy: mailpen.HTMLOptional, email: "text-only", wantText: "Text only"},
+		{
+			name:         "HTML optional still reports missing text",
+			policy:       mailpen.HTMLOptional,
+			email:        "html-only",
+			wantWarnings: []string{"text: version skipped: template not found: emails/html-only.txt, and no text converter is configured"},
+		},
+		{
+			name:        "HTML optional with no templates",
+			policy:      mailpen.HTMLOptional,
+			email:       "missing",
+			wantErr:     mailpen.ErrTemplateNotFound,
+			errContains: `no templates found for email "missing"`,
+		},
+		{
+			name:        "invalid text template fails",
+			policy:      mailpen.TextOptional,
+			email:       "broken-text",
+			errContains: "failed to render text template",
+		},
+		{
+			name:        "no templates",
+			email:       "missing",
+			wantErr:     mailpen.ErrTemplateNotFound,
+			errContains: `no templates found for email "missing"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &mailpen.ManagerConfig{
+				Sources:      []mailpen.TemplateSource{{Name: "test", FS: files}},
+				RenderPolicy: tt.policy,
+			}
+			if tt.converter {
+				config.TextConverter = plaintext.New()
+			}
+			manager, err := mailpen.NewManager(config)
+			require.NoError(t, err)
+
+			email, err := manager.Render(context.Background(), tt.email, nil, mailpen.RenderOptions{Policy: tt.optPolicy})
+			if tt.wantErr != nil || tt.errContains != "" {
+				require.Error(t, err)
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+				}
+				assert.ErrorContains(t, err, tt.errContains)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Contains(t, email.Text, tt.wantText)
+			assert.Equal(t, tt.wantWarnings, email.Warnings)
+		})
+	}
+}
+
+func TestRenderPolicy_String(t *testing.T) {
+	assert.Equal(t, "RequireHTML", mailpen.RequireHTML.String())
+	assert.Equal(t, "RequireBoth", mailpen.RequireBoth.String())
+	assert.Equal(t, "TextOptional", mailpen.TextOptional.String())
+	assert.Equal(t, "HTMLOptional", mailpen.HTMLOptional.String())
+	assert.Equal(t, "RenderPolicy(unset)", mailpen.RenderPolicy(0).String())
+}
@@ -0,0 +1,92 @@
+package preview
+
+import "html/template"
+
+// indexPage is the preview UI. Links are relative so the handler works wherever it is mounted.
+var indexPage = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{with .Current}}{{.}} – {{end}}Email preview</title>
+<style>
+body { margin: 0; display: flex; height: 100vh; font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; font-size: 14px; }
+nav { width: 240px; overflow-y: auto; border-right: 1px solid #ddd; background: #fafafa; }
+nav h1 { font-size: 16px; margin: 16px; }
+nav a { display: block; padding: 6px 16px; color: #333; text-decoration: none; }
+nav a.active { background: #e3f2fd; font-weight: bold; }
+main { flex: 1; display: flex; flex-direction: column; background: #eee; min-width: 0; }
+.toolbar { display: flex; gap: 16px; padding: 8px 16px; background: #fff; border-bottom: 1px solid #ddd; }
+.toolbar a { color: #1976d2; text-decoration: none; }
+.toolbar a.active { font-weight: bold; color: #333; }
+.frame { flex: 1; display: flex; justify-content: center; padding: 16px; overflow: auto; }
+iframe { border: 0; background: #fff; height: 100%; width: 100%; }
+.mobile iframe { width: 375px; }
+form { padding: 8px 16px; background: #fff; border-top: 1px solid #ddd; }
+textarea { width: 100%; height: 120px; font-family: monospace; font-size: 12px; box-sizing: border-box; }
+</style>
+{{.Head}}
+</head>
+<body>
+<nav>
+<h1>Email preview</h1>
+{{range .Emails}}<a href="?email={{.}}&view={{$.View}}&width={{$.Width}}"{{if eq . $.Current}} class="active"{{end}}>{{.}}</a>
+{{else}}<p style="margin: 16px">No emails found.</p>
+{{end}}
+</nav>
+<main>
+{{with .Current}}
+<div class="toolbar">
+<a href="?email={{.}}&view={{$.View}}&width=desktop{{if $.Custom}}&data={{$.Data}}{{end}}"{{if eq $.Width "desktop"}} class="active"{{end}}>Desktop</a>
+<a href="?email={{.}}&view={{$.View}}&width=mobile{{if $.Custom}}&data={{$.Data}}{{end}}"{{if eq $.Width "mobile"}} class="active"{{end}}>Mobile</a>
+<span>|</span>
+<a href="?email={{.}}&view=html&width={{$.Width}}{{if $.Custom}}&data={{$.Data}}{{end}}"{{if eq $.View "html"}} class="active"{{end}}>HTML</a>
+<a href="?email={{.}}&view=text&width={{$.Width}}{{if $.Custom}}&data={{$.Data}}{{end}}"{{if eq $.View "text"}} class="active"{{end}}>Text</a>
+<a href="?email={{.}}&view=inspect&width={{$.Width}}{{if $.Custom}}&data={{$.Data}}{{end}}"{{if eq $.View "inspect"}} class="active"{{end}}>Inspect</a>
+</div>
+<div class="frame {{$.Width}}">
+{{if eq $.View "inspect"}}
+<iframe id="preview" src="inspect/{{.}}{{if $.Custom}}?data={{$.Data}}{{end}}"></iframe>
+{{else}}
+<iframe id="preview" src="render/{{.}}?format={{$.View}}{{if $.Custom}}&data={{$.Data}}{{end}}"></iframe>
+{{end}}
+</div>
+<form method="get">
+<input type="hidden" name="email" value="{{.}}">
+<input type="hidden" name="view" value="{{$.View}}">
+<input type="hidden" name="width" value="{{$.Width}}">
+<textarea name="data" placeholder="{&quot;Name&quot;: &quot;Ada&quot;}">{{$.Data}}</textarea>
+<button type="submit">Render with data</button>
+<a href="?email={{.}}&view={{$.View}}&width={{$.Width}}">Reset</a>
+</form>
+{{end}}
+</main>
+</body>
+</html>
+`))
+
+// inspectPage shows the details of a rendered email
+var inspectPage = template.Must(template.New("inspect").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Email}} – Inspect</title>
+<style>
+body { margin: 16px; font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; font-size: 14px; }
+h2 { font-size: 15px; margin: 24px 0 8px; }
+pre { background: #f6f6f6; padding: 12px; overflow: auto; white-space: pre-wrap; font-size: 12px; }
+.warning { color: #b26a00; }
+</style>
+</head>
+<body>
+<h2>Sizes</h2>
+<p>HTML: {{.HTMLSize}} bytes{{if .Clipped}} <strong class="warning">(over {{.ClipSize}} bytes; Gmail clips the message)</strong>{{end}}<br>
+Text: {{.TextSize}} bytes</p>
+<h2>Warnings</h2>
+{{with .Rendered.Warnings}}<ul>{{range .}}<li class="warning">{{.}}</li>{{end}}</ul>{{else}}<p>None</p>{{end}}
+<h2>Text</h2>
+<pre>{{.Rendered.Text}}</pre>
+<h2>HTML source</h2>
+<pre>{{.Rendered.HTML}}</pre>
+</body>
+</html>
+`))
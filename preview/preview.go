@@ -0,0 +1,234 @@
+// Package preview provides an http.Handler that lists, renders, and inspects the emails of a mailpen Manager.
+// Mount it inside an application, behind the application's own authentication, to preview emails with
+// sample or arbitrary data.
+package preview
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/patrickward/mailpen"
+)
+
+// ClipSize is the HTML size above which Gmail clips messages, reported by the inspect page
+const ClipSize = 102 * 1024
+
+// maxDataSize limits the size of a posted data payload
+const maxDataSize = 1 << 20
+
+// Option configures a preview handler
+type Option func(h *handler)
+
+// WithSampleData sets a function that returns the data an email is rendered with when the request does not
+// include any. Without it, emails are rendered with their sample data files (see Manager.SampleData).
+func WithSampleData(fn func(ctx context.Context, email string) (map[string]any, error)) Option {
+	return func(h *handler) {
+		h.sampleData = fn
+	}
+}
+
+// WithLayout sets the layout emails are rendered into (defaults to the manager's default layout)
+func WithLayout(layout string) Option {
+	return func(h *handler) {
+		h.layout = layout
+	}
+}
+
+// WithHead adds markup to the head of the preview UI, such as a stylesheet or a live-reload script
+func WithHead(head template.HTML) Option {
+	return func(h *handler) {
+		h.head = head
+	}
+}
+
+// Handler returns an http.Handler serving the preview UI and these endpoints, relative to where it is
+// mounted:
+//
+//	GET  /                   the preview UI
+//	GET  /render/{email}     the rendered HTML, or text with ?format=text
+//	POST /render/{email}     the same, rendered with the JSON request body as data
+//	GET  /inspect/{email}    warnings, sizes, text, and HTML source of the rendered email
+//
+// GET requests take the data as JSON in the "data" query parameter and fall back to WithSampleData. Mount
+// the handler with http.StripPrefix and a trailing slash, e.g. mux.Handle("/admin/emails/",
+// http.StripPrefix("/admin/emails", preview.Handler(manager))).
+func Handler(manager *mailpen.Manager, opts ...Option) http.Handler {
+	h := &handler{manager: manager, mux: http.NewServeMux()}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.sampleData == nil {
+		h.sampleData = func(_ context.Context, email string) (map[string]any, error) {
+			return manager.SampleData(email)
+		}
+	}
+
+	h.mux.HandleFunc("GET /{$}", h.handleIndex)
+	h.mux.HandleFunc("GET /render/{email...}", h.handleRender)
+	h.mux.HandleFunc("POST /render/{email...}", h.handleRender)
+	h.mux.HandleFunc("GET /inspect/{email...}", h.handleInspect)
+
+	return h
+}
+
+type handler struct {
+	manager    *mailpen.Manager
+	sampleData func(ctx context.Context, email string) (map[string]any, error)
+	layout     string
+	head       template.HTML
+	mux        *http.ServeMux
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// handleIndex serves the preview UI
+func (h *handler) handleIndex(w http.ResponseWriter, r *http.Request) {
+	emails, err := h.manager.Emails()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	query := r.URL.Query()
+	current := query.Get("email")
+	if current == "" && len(emails) > 0 {
+		current = emails[0]
+	}
+
+	view := query.Get("view")
+	if view != "text" && view != "inspect" {
+		view = "html"
+	}
+
+	width := query.Get("width")
+	if width != "mobile" {
+		width = "desktop"
+	}
+
+	// Show the sample data in the editor when the request has none
+	data := query.Get("data")
+	if data == "" && current != "" {
+		if sample, err := h.sampleData(r.Context(), current); err == nil && sample != nil {
+			if encoded, err := json.MarshalIndent(sample, "", "  "); err == nil {
+				data = string(encoded)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err = indexPage.Execute(w, map[string]any{
+		"Head":    h.head,
+		"Emails":  emails,
+		"Current": current,
+		"View":    view,
+		"Width":   width,
+		"Data":    data,
+		"Custom":  query.Get("data") != "",
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// handleRender writes the rendered HTML or text of an email, or of the error page for a failed render in
+// development mode
+func (h *handler) handleRender(w http.ResponseWriter, r *http.Request) {
+	email, err := h.render(r)
+	if err != nil && email == nil {
+		http.Error(w, err.Error(), statusOf(err))
+		return
+	}
+
+	if r.URL.Query().Get("format") == "text" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = io.WriteString(w, email.Text)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = io.WriteString(w, email.HTML)
+}
+
+// handleInspect serves the warnings, sizes, text, and HTML source of an email, or of the error page for a
+// failed render in development mode
+func (h *handler) handleInspect(w http.ResponseWriter, r *http.Request) {
+	email, err := h.render(r)
+	if err != nil && email == nil {
+		http.Error(w, err.Error(), statusOf(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err = inspectPage.Execute(w, map[string]any{
+		"Email":    r.PathValue("email"),
+		"Rendered": email,
+		"HTMLSize": len(email.HTML),
+		"TextSize": len(email.Text),
+		"Clipped":  len(email.HTML) > ClipSize,
+		"ClipSize": ClipSize,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// badRequestError marks errors caused by the request data
+type badRequestError struct{ err error }
+
+func (e badRequestError) Error() string { return e.err.Error() }
+func (e badRequestError) Unwrap() error { return e.err }
+
+// statusOf returns the response status for a render error
+func statusOf(err error) int {
+	if _, ok := err.(badRequestError); ok {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, mailpen.ErrTemplateNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// render renders the email named in the path with the request's data
+func (h *handler) render(r *http.Request) (*mailpen.RenderedEmail, error) {
+	name := r.PathValue("email")
+
+	data, err := h.data(r, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.manager.Render(r.Context(), name, data, mailpen.RenderOptions{Layout: h.layout})
+}
+
+// data returns the data from a POST body or the "data" query parameter, falling back to the sample data
+func (h *handler) data(r *http.Request, name string) (map[string]any, error) {
+	var raw string
+	if r.Method == http.MethodPost {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxDataSize))
+		if err != nil {
+			return nil, badRequestError{fmt.Errorf("failed to read data: %w", err)}
+		}
+		raw = string(body)
+	} else {
+		raw = r.URL.Query().Get("data")
+	}
+
+	if strings.TrimSpace(raw) != "" {
+		data := map[string]any{}
+		if err := json.Unmarshal([]byte(raw), &data); err != nil {
+			return nil, badRequestError{fmt.Errorf("invalid data: %w", err)}
+		}
+		return data, nil
+	}
+
+	return h.sampleData(r.Context(), name)
+}
@@ -0,0 +1,157 @@
+package preview_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/preview"
+)
+
+// warnAnalyzer reports a warning for every email
+type warnAnalyzer struct{}
+
+func (warnAnalyzer) Analyze(string) ([]string, error) { return []string{"image missing alt text"}, nil }
+
+func newManager(t *testing.T) *mailpen.Manager {
+	t.Helper()
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{{Name: "test", FS: fstest.MapFS{
+			"emails/welcome.html":       {Data: []byte(`{{define "content"}}<p>Welcome, {{.Name}}!</p>{{end}}`)},
+			"emails/welcome.txt":        {Data: []byte(`{{define "content"}}Welcome, {{.Name}}!{{end}}`)},
+			"emails/account/reset.html": {Data: []byte(`{{define "content"}}<p>Reset</p>{{end}}`)},
+		}}},
+		Analyzers: []mailpen.HTMLAnalyzer{warnAnalyzer{}},
+	})
+	require.NoError(t, err)
+	return manager
+}
+
+func sampleData(_ context.Context, email string) (map[string]any, error) {
+	if email == "welcome" {
+		return map[string]any{"Name": "Sample"}, nil
+	}
+	return nil, nil
+}
+
+func serve(handler http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rec
+}
+
+func TestHandler_Index(t *testing.T) {
+	handler := preview.Handler(newManager(t), preview.WithSampleData(sampleData), preview.WithHead(`<link rel="stylesheet" href="/custom.css">`))
+
+	rec := serve(handler, http.MethodGet, "/", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, `>account/reset</a>`)
+	assert.Contains(t, body, `>welcome</a>`)
+	assert.Contains(t, body, `src="render/account/reset?format=html"`)
+	assert.Contains(t, body, `<link rel="stylesheet" href="/custom.css">`)
+
+	rec = serve(handler, http.MethodGet, "/?email=welcome&view=inspect&width=mobile", "")
+	body = rec.Body.String()
+	assert.Contains(t, body, `src="inspect/welcome"`)
+	assert.Contains(t, body, `class="frame mobile"`)
+	assert.Contains(t, body, `&#34;Name&#34;: &#34;Sample&#34;`)
+
+	data := url.QueryEscape(`{"Name":"Grace"}`)
+	rec = serve(handler, http.MethodGet, "/?email=welcome&data="+data, "")
+	assert.Contains(t, rec.Body.String(), `src="render/welcome?format=html&data=%7b%22Name%22%3a%22Grace%22%7d"`)
+}
+
+func TestHandler_Render(t *testing.T) {
+	handler := preview.Handler(newManager(t), preview.WithSampleData(sampleData))
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		body        string
+		wantCode    int
+		wantType    string
+		wantContain string
+	}{
+		{name: "sample data", method: http.MethodGet, target: "/render/welcome", wantCode: http.StatusOK, wantType: "text/html; charset=utf-8", wantContain: "Welcome, Sample!"},
+		{name: "query data", method: http.MethodGet, target: "/render/welcome?data=" + url.QueryEscape(`{"Name":"Grace"}`), wantCode: http.StatusOK, wantContain: "Welcome, Grace!"},
+		{name: "posted data", method: http.MethodPost, target: "/render/welcome", body: `{"Name":"Ada"}`, wantCode: http.StatusOK, wantContain: "Welcome, Ada!"},
+		{name: "text", method: http.MethodGet, target: "/render/welcome?format=text", wantCode: http.StatusOK, wantType: "text/plain; charset=utf-8", wantContain: "Welcome, Sample!"},
+		{name: "nested email", method: http.MethodGet, target: "/render/account/reset", wantCode: http.StatusOK, wantContain: "Reset"},
+		{name: "invalid data", method: http.MethodPost, target: "/render/welcome", body: `{`, wantCode: http.StatusBadRequest, wantContain: "invalid data"},
+		{name: "unknown email", method: http.MethodGet, target: "/render/missing", wantCode: http.StatusNotFound, wantContain: "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(handler, tt.method, tt.target, tt.body)
+			assert.Equal(t, tt.wantCode, rec.Code)
+			if tt.wantType != "" {
+				assert.Equal(t, tt.wantType, rec.Header().Get("Content-Type"))
+			}
+			assert.Contains(t, rec.Body.String(), tt.wantContain)
+		})
+	}
+}
+
+func TestHandler_SampleDataError(t *testing.T) {
+	handler := preview.Handler(newManager(t), preview.WithSampleData(func(context.Context, string) (map[string]any, error) {
+		return nil, errors.New("fixtures unavailable")
+	}))
+
+	rec := serve(handler, http.MethodGet, "/render/welcome", "")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, rec.Body.String(), "fixtures unavailable")
+}
+
+func TestHandler_ManagerSampleData(t *testing.T) {
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{{Name: "test", FS: fstest.MapFS{
+			"emails/welcome.html":        {Data: []byte(`{{define "content"}}<p>Welcome, {{.Name}}!</p>{{end}}`)},
+			"emails/welcome.sample.json": {Data: []byte(`{"Name": "Sam"}`)},
+		}}},
+	})
+	require.NoError(t, err)
+	handler := preview.Handler(manager)
+
+	rec := serve(handler, http.MethodGet, "/render/welcome", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Welcome, Sam!")
+
+	rec = serve(handler, http.MethodGet, "/?email=welcome", "")
+	assert.Contains(t, rec.Body.String(), `&#34;Name&#34;: &#34;Sam&#34;`)
+}
+
+func TestHandler_Inspect(t *testing.T) {
+	handler := preview.Handler(newManager(t), preview.WithSampleData(sampleData))
+
+	rec := serve(handler, http.MethodGet, "/inspect/welcome", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.Contains(t, body, "image missing alt text")
+	assert.Contains(t, body, "HTML: ")
+	assert.Contains(t, body, "&lt;p&gt;Welcome, Sample!&lt;/p&gt;")
+}
+
+func TestHandler_Mounted(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/admin/emails/", http.StripPrefix("/admin/emails", preview.Handler(newManager(t))))
+
+	rec := serve(mux, http.MethodGet, "/admin/emails/", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `>welcome</a>`)
+
+	rec = serve(mux, http.MethodPost, "/admin/emails/render/welcome", `{"Name":"Ada"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Welcome, Ada!")
+}
@@ -0,0 +1,54 @@
+package mailpen
+
+import "context"
+
+// RenderContext describes the email whose HTML is being processed
+type RenderContext struct {
+	HTML     string         // The HTML produced by the template and any earlier processors
+	Template string         // Name of the email template
+	Layout   string         // Name of the layout used to render the email
+	Locale   string         // Locale of the message, if any
+	Theme    map[string]any // Theme used to render the email
+	Message  *Message       // The message being rendered, or nil when rendering without one
+}
+
+// Metadata returns the message metadata value for key, or an empty string when there is no message
+func (rc *RenderContext) Metadata(key string) string {
+	if rc.Message == nil {
+		return ""
+	}
+	return rc.Message.Metadata[key]
+}
+
+// ContextProcessor defines the interface for processing HTML with access to the request context and the
+// email being rendered, enabling cancellation and per-message or per-tenant processing
+type ContextProcessor interface {
+	Process(ctx context.Context, rc *RenderContext) (string, error)
+}
+
+// ContextProcessorFunc adapts a function to the ContextProcessor interface
+type ContextProcessorFunc func(ctx context.Context, rc *RenderContext) (string, error)
+
+// Process calls f(ctx, rc)
+func (f ContextProcessorFunc) Process(ctx context.Context, rc *RenderContext) (string, error) {
+	return f(ctx, rc)
+}
+
+// AdaptProcessor wraps an HTMLProcessor as a ContextProcessor. The context is checked for cancellation
+// before the wrapped processor runs.
+func AdaptProcessor(p HTMLProcessor) ContextProcessor {
+	return adaptedProcessor{p}
+}
+
+// adaptedProcessor is an HTMLProcessor adapted to the ContextProcessor interface
+type adaptedProcessor struct {
+	HTMLProcessor
+}
+
+// Process checks the context, then processes the HTML
+func (a adaptedProcessor) Process(ctx context.Context, rc *RenderContext) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return a.HTMLProcessor.Process(rc.HTML)
+}
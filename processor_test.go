@@ -0,0 +1,69 @@
+package mailpen_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+// upperProcessor implements the legacy mailpen.HTMLProcessor interface
+type upperProcessor struct{}
+
+func (upperProcessor) Process(html string) (string, error) {
+	return strings.ReplaceAll(html, "welcome", "WELCOME"), nil
+}
+
+func TestContextProcessors(t *testing.T) {
+	var seen *mailpen.RenderContext
+
+	mock := &mockProvider{}
+	mp, err := mailpen.New(mock, &mailpen.Config{
+		From:          "sender@example.com",
+		HTMLProcessor: upperProcessor{},
+		Processors: []mailpen.ContextProcessor{
+			mailpen.ContextProcessorFunc(func(ctx context.Context, rc *mailpen.RenderContext) (string, error) {
+				seen = rc
+				return rc.HTML + "<!-- " + rc.Metadata("tenant") + " -->", nil
+			}),
+		},
+		Sources: []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+	})
+	require.NoError(t, err)
+
+	msg := mailpen.NewMessage().
+		To("recipient@example.com").
+		Template("welcome").
+		Locale("en-GB").
+		Metadata("tenant", "acme").
+		WithData(map[string]any{"Name": "John"}).
+		Must()
+
+	require.NoError(t, mp.Send(context.Background(), msg))
+
+	require.NotNil(t, seen)
+	assert.Equal(t, "welcome", seen.Template)
+	assert.Equal(t, "base", seen.Layout)
+	assert.Equal(t, "en-GB", seen.Locale)
+	assert.Same(t, msg, seen.Message)
+	assert.NotNil(t, seen.Theme)
+	assert.Contains(t, seen.HTML, `class="WELCOME"`, "legacy processors run first")
+	assert.True(t, strings.HasSuffix(mock.lastMessage.HTMLBody, "<!-- acme -->"))
+}
+
+func TestAdaptProcessor(t *testing.T) {
+	p := mailpen.AdaptProcessor(upperProcessor{})
+
+	html, err := p.Process(context.Background(), &mailpen.RenderContext{HTML: "welcome"})
+	require.NoError(t, err)
+	assert.Equal(t, "WELCOME", html)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = p.Process(ctx, &mailpen.RenderContext{HTML: "welcome"})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,247 @@
+// Package accessibility checks rendered HTML emails for common accessibility problems.
+package accessibility
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/patrickward/mailpen"
+)
+
+// MinContrast is the default minimum contrast ratio, matching WCAG AA for normal text
+const MinContrast = 4.5
+
+// htmlTag matches an explicit <html> tag, so fragments aren't reported for a missing lang attribute
+var htmlTag = regexp.MustCompile(`(?i)<html[\s>]`)
+
+// Checker reports accessibility warnings for rendered HTML without modifying it. It implements the
+// mailpen.HTMLAnalyzer interface.
+type Checker struct {
+	theme       map[string]any
+	minContrast float64
+}
+
+// Option configures a Checker
+type Option func(c *Checker)
+
+// WithTheme checks the contrast of the theme's text colors against its backgrounds, and of the primary
+// background color used as button text against the accent colors
+func WithTheme(theme map[string]any) Option {
+	return func(c *Checker) {
+		c.theme = theme
+	}
+}
+
+// WithMinContrast sets the minimum contrast ratio (defaults to MinContrast)
+func WithMinContrast(ratio float64) Option {
+	return func(c *Checker) {
+		c.minContrast = ratio
+	}
+}
+
+// New creates a new Checker
+func New(opts ...Option) *Checker {
+	c := &Checker{minContrast: MinContrast}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Analyze returns a warning for each image without an alt attribute, a document without a lang attribute,
+// a layout table without role="presentation", and each color pair below the minimum contrast ratio
+func (c *Checker) Analyze(content string) ([]string, error) {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	var warnings []string
+	if htmlTag.MatchString(content) {
+		if root := find(doc, atom.Html); root != nil && strings.TrimSpace(attr(root, "lang")) == "" {
+			warnings = append(warnings, `accessibility: <html> is missing a lang attribute`)
+		}
+	}
+
+	walk(doc, func(n *html.Node) {
+		switch n.DataAtom {
+		case atom.Img:
+			if _, ok := lookupAttr(n, "alt"); !ok {
+				warnings = append(warnings, fmt.Sprintf(`accessibility: <img src=%q> is missing an alt attribute`, attr(n, "src")))
+			}
+		case atom.Table:
+			if attr(n, "role") != "presentation" && !hasHeaderCells(n) {
+				warnings = append(warnings, `accessibility: layout <table> is missing role="presentation"`)
+			}
+		}
+
+		style := parseStyle(attr(n, "style"))
+		fg, bg := style["color"], style["background-color"]
+		if fg != "" && bg != "" {
+			if w := c.checkContrast(fmt.Sprintf("<%s>", n.Data), fg, bg); w != "" {
+				warnings = append(warnings, w)
+			}
+		}
+	})
+
+	warnings = append(warnings, c.themeWarnings()...)
+
+	return warnings, nil
+}
+
+// themeWarnings checks the contrast of the theme's color token pairs
+func (c *Checker) themeWarnings() []string {
+	if c.theme == nil {
+		return nil
+	}
+
+	var warnings []string
+	check := func(fgPath, bgPath string) {
+		fg, _ := mailpen.GetThemeValue(c.theme, fgPath).(string)
+		bg, _ := mailpen.GetThemeValue(c.theme, bgPath).(string)
+		if fg == "" || bg == "" {
+			return
+		}
+		if w := c.checkContrast(fmt.Sprintf("theme %s on %s", fgPath, bgPath), fg, bg); w != "" {
+			warnings = append(warnings, w)
+		}
+	}
+
+	textColors, _ := mailpen.GetThemeValue(c.theme, "colors.text").(map[string]any)
+	for _, name := range sortedKeys(textColors) {
+		check("colors.text."+name, "colors.background.primary")
+	}
+
+	// Buttons and alerts render the primary background color as text on the accent colors
+	for _, accent := range []string{"primary", "secondary", "success", "danger", "warning"} {
+		check("colors.background.primary", "colors."+accent)
+	}
+
+	return warnings
+}
+
+// checkContrast returns a warning when the contrast between two colors is below the minimum ratio
+func (c *Checker) checkContrast(subject, fg, bg string) string {
+	ratio, ok := ContrastRatio(fg, bg)
+	if !ok || ratio >= c.minContrast {
+		return ""
+	}
+	return fmt.Sprintf("accessibility: %s has a contrast ratio of %.2f:1 (%s on %s), below %.1f:1", subject, ratio, fg, bg, c.minContrast)
+}
+
+// ContrastRatio returns the WCAG contrast ratio between two hex colors (#rgb or #rrggbb). It returns false when
+// either color can't be parsed.
+func ContrastRatio(fg, bg string) (float64, bool) {
+	l1, ok1 := luminance(fg)
+	l2, ok2 := luminance(bg)
+	if !ok1 || !ok2 {
+		return 0, false
+	}
+
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+	return (l1 + 0.05) / (l2 + 0.05), true
+}
+
+// luminance returns the relative luminance of a hex color
+func luminance(color string) (float64, bool) {
+	hex := strings.TrimPrefix(strings.TrimSpace(color), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 || !strings.HasPrefix(strings.TrimSpace(color), "#") {
+		return 0, false
+	}
+
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	channel := func(shift uint) float64 {
+		c := float64((value>>shift)&0xff) / 255
+		if c <= 0.03928 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+
+	return 0.2126*channel(16) + 0.7152*channel(8) + 0.0722*channel(0), true
+}
+
+// parseStyle parses an inline style attribute into lower-cased property names and their values
+func parseStyle(style string) map[string]string {
+	props := make(map[string]string)
+	for _, decl := range strings.Split(style, ";") {
+		name, value, ok := strings.Cut(decl, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important"))
+		props[strings.ToLower(strings.TrimSpace(name))] = value
+	}
+	return props
+}
+
+// hasHeaderCells reports whether a table has header cells, marking it as a data table
+func hasHeaderCells(table *html.Node) bool {
+	found := false
+	walk(table, func(n *html.Node) {
+		if n.DataAtom == atom.Th {
+			found = true
+		}
+	})
+	return found
+}
+
+// walk calls fn for every element node below n, in document order
+func walk(n *html.Node, fn func(n *html.Node)) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode {
+			fn(child)
+		}
+		walk(child, fn)
+	}
+}
+
+// find returns the first element of the given type below n
+func find(n *html.Node, a atom.Atom) *html.Node {
+	var found *html.Node
+	walk(n, func(child *html.Node) {
+		if found == nil && child.DataAtom == a {
+			found = child
+		}
+	})
+	return found
+}
+
+func attr(n *html.Node, name string) string {
+	value, _ := lookupAttr(n, name)
+	return value
+}
+
+func lookupAttr(n *html.Node, name string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,76 @@
+package accessibility_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/processors/accessibility"
+)
+
+func TestChecker_Analyze(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []accessibility.Option
+		input    string
+		want     []string
+		wantNone bool
+	}{
+		{
+			name:  "missing lang",
+			input: `<html><body><p>Hi</p></body></html>`,
+			want:  []string{`accessibility: <html> is missing a lang attribute`},
+		},
+		{
+			name:     "accessible document",
+			input:    `<html lang="en"><body><table role="presentation"><tr><td><img src="logo.png" alt=""></td></tr></table><table><tr><th>Name</th></tr></table></body></html>`,
+			wantNone: true,
+		},
+		{
+			name:  "missing alt and role",
+			input: `<table><tr><td><img src="logo.png"></td></tr></table>`,
+			want: []string{
+				`accessibility: <img src="logo.png"> is missing an alt attribute`,
+				`accessibility: layout <table> is missing role="presentation"`,
+			},
+		},
+		{
+			name:  "poor inline contrast",
+			input: `<p style="color: #aaa; background-color: #ffffff !important;">Faint</p>`,
+			want:  []string{`accessibility: <p> has a contrast ratio of 2.32:1 (#aaa on #ffffff), below 4.5:1`},
+		},
+		{
+			name:  "theme contrast",
+			opts:  []accessibility.Option{accessibility.WithTheme(mailpen.DefaultTheme())},
+			input: `<p>Hi</p>`,
+			want: []string{
+				`accessibility: theme colors.text.muted on colors.background.primary has a contrast ratio of 2.85:1 (#999999 on #ffffff), below 4.5:1`,
+				`accessibility: theme colors.background.primary on colors.primary has a contrast ratio of 3.06:1 (#ffffff on #4DA647), below 4.5:1`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := accessibility.New(tt.opts...).Analyze(tt.input)
+			require.NoError(t, err)
+			if tt.wantNone {
+				assert.Empty(t, warnings)
+			}
+			for _, want := range tt.want {
+				assert.Contains(t, warnings, want)
+			}
+		})
+	}
+}
+
+func TestContrastRatio(t *testing.T) {
+	ratio, ok := accessibility.ContrastRatio("#000", "#ffffff")
+	require.True(t, ok)
+	assert.InDelta(t, 21.0, ratio, 0.01)
+
+	_, ok = accessibility.ContrastRatio("red", "#ffffff")
+	assert.False(t, ok)
+}
@@ -0,0 +1,185 @@
+// Package caniemail reports HTML and CSS features in rendered emails that major email clients don't fully
+// support, using data in the format published by caniemail.com.
+package caniemail
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Checker reports client support warnings for rendered HTML without modifying it. It implements the
+// mailpen.HTMLAnalyzer interface.
+type Checker struct {
+	data     *Data
+	families map[string]bool
+	ignore   map[string]bool
+	partial  bool
+}
+
+// Option configures a Checker
+type Option func(c *Checker)
+
+// WithData sets the support data (defaults to the bundled data). Use LoadData to read the full dataset from
+// https://www.caniemail.com/api/data.json.
+func WithData(data *Data) Option {
+	return func(c *Checker) {
+		c.data = data
+	}
+}
+
+// WithClients limits the report to the given client families, e.g. "gmail" or "outlook" (defaults to all
+// families in the data)
+func WithClients(families ...string) Option {
+	return func(c *Checker) {
+		c.families = make(map[string]bool, len(families))
+		for _, family := range families {
+			c.families[family] = true
+		}
+	}
+}
+
+// WithIgnore skips features by slug, e.g. "css-border-radius" for rounded corners that degrade gracefully
+func WithIgnore(slugs ...string) Option {
+	return func(c *Checker) {
+		for _, slug := range slugs {
+			c.ignore[slug] = true
+		}
+	}
+}
+
+// WithPartial sets whether partial support is reported along with no support (defaults to true)
+func WithPartial(report bool) Option {
+	return func(c *Checker) {
+		c.partial = report
+	}
+}
+
+// New creates a new Checker
+func New(opts ...Option) *Checker {
+	c := &Checker{ignore: make(map[string]bool), partial: true}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.data == nil {
+		c.data = DefaultData()
+	}
+	return c
+}
+
+// Finding is a feature used by an email that a client doesn't fully support
+type Finding struct {
+	Feature Feature
+	Client  Client
+	Support Support
+	Note    string // Note explaining partial support, if any
+}
+
+// Check returns a finding for each detected feature and client without full support, ordered by feature
+// and client
+func (c *Checker) Check(content string) ([]Finding, error) {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	usage := scan(doc)
+
+	var findings []Finding
+	for _, slug := range detect(usage) {
+		feature, ok := c.data.features[slug]
+		if !ok || c.ignore[slug] {
+			continue
+		}
+
+		for _, client := range feature.clients() {
+			if c.families != nil && !c.families[client.Family] {
+				continue
+			}
+
+			support := feature.Support[client]
+			if support == Unsupported || (support == Partial && c.partial) {
+				findings = append(findings, Finding{Feature: feature, Client: client, Support: support, Note: feature.Notes[client]})
+			}
+		}
+	}
+
+	return findings, nil
+}
+
+// Analyze returns a warning for each detected feature and client family without full support, listing the
+// affected platforms, e.g. "caniemail: display:flex is not supported in Outlook (Windows)"
+func (c *Checker) Analyze(content string) ([]string, error) {
+	findings, err := c.Check(content)
+	if err != nil {
+		return nil, err
+	}
+
+	type group struct {
+		feature   Feature
+		family    string
+		support   Support
+		platforms []string
+		notes     []string
+	}
+
+	var groups []*group
+	index := make(map[string]*group)
+	for _, f := range findings {
+		key := f.Feature.Slug + "|" + f.Client.Family + "|" + string(f.Support)
+		g, ok := index[key]
+		if !ok {
+			g = &group{feature: f.Feature, family: f.Client.Family, support: f.Support}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		g.platforms = append(g.platforms, c.data.platformName(f.Client.Platform))
+		if f.Note != "" && !contains(g.notes, f.Note) {
+			g.notes = append(g.notes, f.Note)
+		}
+	}
+
+	warnings := make([]string, 0, len(groups))
+	for _, g := range groups {
+		level := "not supported"
+		if g.support == Partial {
+			level = "partially supported"
+		}
+
+		warning := fmt.Sprintf("caniemail: %s is %s in %s (%s)", g.feature.Title, level,
+			c.data.familyName(g.family), strings.Join(g.platforms, ", "))
+		if len(g.notes) > 0 {
+			warning += ": " + strings.Join(g.notes, " ")
+		}
+		warnings = append(warnings, warning)
+	}
+
+	return warnings, nil
+}
+
+// contains reports whether a slice contains a string
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedClients returns the clients of a support map ordered by family and platform
+func sortedClients(support map[Client]Support) []Client {
+	clients := make([]Client, 0, len(support))
+	for client := range support {
+		clients = append(clients, client)
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		if clients[i].Family != clients[j].Family {
+			return clients[i].Family < clients[j].Family
+		}
+		return clients[i].Platform < clients[j].Platform
+	})
+	return clients
+}
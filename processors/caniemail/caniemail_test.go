@@ -0,0 +1,130 @@
+package caniemail_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/processors/caniemail"
+)
+
+var _ mailpen.HTMLAnalyzer = (*caniemail.Checker)(nil)
+
+func TestChecker_Analyze(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []caniemail.Option
+		input    string
+		want     []string
+		wantNone bool
+	}{
+		{
+			name:  "inline flexbox",
+			opts:  []caniemail.Option{caniemail.WithClients("outlook")},
+			input: `<div style="display: flex !important">Hi</div>`,
+			want:  []string{`caniemail: display:flex is not supported in Outlook (Windows)`},
+		},
+		{
+			name:  "stylesheet grid",
+			opts:  []caniemail.Option{caniemail.WithClients("outlook")},
+			input: `<style>.cols { display:grid; }</style><div class="cols">Hi</div>`,
+			want:  []string{`caniemail: display:grid is not supported in Outlook (Android, iOS, Outlook.com, Windows)`},
+		},
+		{
+			name:  "partial support with note",
+			opts:  []caniemail.Option{caniemail.WithClients("outlook")},
+			input: `<style>@media (max-width: 600px) { td { padding: 0; } }</style>`,
+			want: []string{
+				`caniemail: @media is partially supported in Outlook (Outlook.com): Partial support. Only width queries are supported.`,
+				`caniemail: @media is not supported in Outlook (Windows)`,
+			},
+		},
+		{
+			name:  "partial support disabled",
+			opts:  []caniemail.Option{caniemail.WithClients("outlook"), caniemail.WithPartial(false)},
+			input: `<style>@media (max-width: 600px) { td { padding: 0; } }</style>`,
+			want:  []string{`caniemail: @media is not supported in Outlook (Windows)`},
+		},
+		{
+			name:  "elements and images",
+			opts:  []caniemail.Option{caniemail.WithClients("outlook"), caniemail.WithPartial(false)},
+			input: `<svg></svg><img src="https://example.com/logo.webp?v=2" alt="">`,
+			want: []string{
+				`caniemail: <svg> is not supported in Outlook (Android, iOS, Outlook.com, Windows)`,
+				`caniemail: WebP image format is not supported in Outlook (Windows)`,
+			},
+		},
+		{
+			name:     "ignored feature",
+			opts:     []caniemail.Option{caniemail.WithClients("outlook"), caniemail.WithIgnore("css-border-radius")},
+			input:    `<a style="border-radius: 4px">Go</a>`,
+			wantNone: true,
+		},
+		{
+			name:     "widely supported markup",
+			input:    `<table role="presentation"><tr><td style="padding: 8px; color: #333333;"><img src="logo.png" alt=""></td></tr></table>`,
+			wantNone: true,
+		},
+		{
+			name:     "commented out CSS",
+			input:    `<style>/* .a { display: flex; } */</style>`,
+			wantNone: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings, err := caniemail.New(tt.opts...).Analyze(tt.input)
+			require.NoError(t, err)
+			if tt.wantNone {
+				assert.Empty(t, warnings)
+				return
+			}
+			assert.Equal(t, tt.want, warnings)
+		})
+	}
+}
+
+func TestChecker_Check(t *testing.T) {
+	findings, err := caniemail.New(caniemail.WithClients("gmail")).Check(`<div style="position: absolute">Hi</div>`)
+	require.NoError(t, err)
+	require.NotEmpty(t, findings)
+
+	for _, f := range findings {
+		assert.Equal(t, "css-position", f.Feature.Slug)
+		assert.Equal(t, "gmail", f.Client.Family)
+		assert.Equal(t, caniemail.Unsupported, f.Support)
+		assert.Equal(t, "https://www.caniemail.com/features/css-position/", f.Feature.URL)
+	}
+}
+
+func TestLoadData(t *testing.T) {
+	data, err := caniemail.LoadData(strings.NewReader(`{
+		"nicenames": {"family": {"acme": "Acme Mail"}, "platform": {"web": "Web"}},
+		"data": [{
+			"slug": "css-opacity",
+			"title": "opacity",
+			"url": "https://www.caniemail.com/features/css-opacity/",
+			"category": "css",
+			"stats": {"acme": {"web": {"2019-01": "n", "2023-10": "a #1", "2023-02": "y"}}},
+			"notes_by_num": {"1": "Only on images."}
+		}]
+	}`))
+	require.NoError(t, err)
+
+	feature, ok := data.Feature("css-opacity")
+	require.True(t, ok)
+	client := caniemail.Client{Family: "acme", Platform: "web"}
+	assert.Equal(t, caniemail.Partial, feature.Support[client])
+	assert.Equal(t, "Only on images.", feature.Notes[client])
+
+	warnings, err := caniemail.New(caniemail.WithData(data)).Analyze(`<p style="opacity: .5">Hi</p>`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`caniemail: opacity is partially supported in Acme Mail (Web): Only on images.`}, warnings)
+
+	_, err = caniemail.LoadData(strings.NewReader(`not json`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,214 @@
+package caniemail
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// bundled is a subset of the caniemail.com dataset covering the features this package detects
+//
+//go:embed data.json
+var bundled []byte
+
+// Support is the level of support of a feature in a client
+type Support string
+
+const (
+	Supported   Support = "y"
+	Partial     Support = "a"
+	Unsupported Support = "n"
+	Unknown     Support = "u"
+)
+
+// Client is an email client platform, such as Outlook on Windows
+type Client struct {
+	Family   string // e.g. "outlook"
+	Platform string // e.g. "windows"
+}
+
+// Feature is an HTML or CSS feature and its support in each client
+type Feature struct {
+	Slug     string // e.g. "css-display-flex"
+	Title    string // e.g. "display:flex"
+	URL      string // Feature page on caniemail.com
+	Category string // "html", "css", "image", or "others"
+	Support  map[Client]Support
+	Notes    map[Client]string // Notes for clients with partial support
+}
+
+// clients returns the clients of the feature in a stable order
+func (f Feature) clients() []Client {
+	return sortedClients(f.Support)
+}
+
+// Data is a set of features and their client support
+type Data struct {
+	features  map[string]Feature
+	families  map[string]string
+	platforms map[string]string
+}
+
+// Feature returns a feature by slug
+func (d *Data) Feature(slug string) (Feature, bool) {
+	feature, ok := d.features[slug]
+	return feature, ok
+}
+
+// familyName returns the display name of a client family
+func (d *Data) familyName(family string) string {
+	if name := d.families[family]; name != "" {
+		return name
+	}
+	return family
+}
+
+// platformName returns the display name of a client platform
+func (d *Data) platformName(platform string) string {
+	if name := d.platforms[platform]; name != "" {
+		return name
+	}
+	return platform
+}
+
+// DefaultData returns the bundled data, a subset of the caniemail.com dataset for the detected features
+var DefaultData = sync.OnceValue(func() *Data {
+	data, err := parseData(bundled)
+	if err != nil {
+		panic(fmt.Sprintf("caniemail: invalid bundled data: %v", err))
+	}
+	return data
+})
+
+// apiData is the format of https://www.caniemail.com/api/data.json
+type apiData struct {
+	NiceNames struct {
+		Family   map[string]string `json:"family"`
+		Platform map[string]string `json:"platform"`
+	} `json:"nicenames"`
+	Data []struct {
+		Slug       string                                  `json:"slug"`
+		Title      string                                  `json:"title"`
+		URL        string                                  `json:"url"`
+		Category   string                                  `json:"category"`
+		Stats      map[string]map[string]map[string]string `json:"stats"`
+		NotesByNum map[string]string                       `json:"notes_by_num"`
+	} `json:"data"`
+}
+
+// LoadData reads support data in the format of https://www.caniemail.com/api/data.json. The support of each
+// client platform is taken from its most recently tested version.
+func LoadData(r io.Reader) (*Data, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data: %w", err)
+	}
+	return parseData(content)
+}
+
+// parseData parses caniemail API data
+func parseData(content []byte) (*Data, error) {
+	var raw apiData
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse data: %w", err)
+	}
+
+	data := &Data{
+		features:  make(map[string]Feature, len(raw.Data)),
+		families:  raw.NiceNames.Family,
+		platforms: raw.NiceNames.Platform,
+	}
+
+	for _, entry := range raw.Data {
+		feature := Feature{
+			Slug:     entry.Slug,
+			Title:    entry.Title,
+			URL:      entry.URL,
+			Category: entry.Category,
+			Support:  make(map[Client]Support),
+			Notes:    make(map[Client]string),
+		}
+
+		for family, platforms := range entry.Stats {
+			for platform, versions := range platforms {
+				client := Client{Family: family, Platform: platform}
+				support, notes := parseSupport(latest(versions))
+				feature.Support[client] = support
+
+				var texts []string
+				for _, num := range notes {
+					if note := entry.NotesByNum[num]; note != "" {
+						texts = append(texts, note)
+					}
+				}
+				if len(texts) > 0 {
+					feature.Notes[client] = strings.Join(texts, " ")
+				}
+			}
+		}
+
+		data.features[entry.Slug] = feature
+	}
+
+	return data, nil
+}
+
+// latest returns the support value of the most recent version. Versions are years, dates, or version
+// numbers, so they are compared as dotted numbers.
+func latest(versions map[string]string) string {
+	keys := make([]string, 0, len(versions))
+	for version := range versions {
+		keys = append(keys, version)
+	}
+	sort.Slice(keys, func(i, j int) bool { return versionLess(keys[i], keys[j]) })
+
+	if len(keys) == 0 {
+		return ""
+	}
+	return versions[keys[len(keys)-1]]
+}
+
+// versionLess compares versions such as "2019", "2020-02", or "16.0" part by part
+func versionLess(a, b string) bool {
+	split := func(s string) []string {
+		return strings.FieldsFunc(s, func(r rune) bool { return r == '.' || r == '-' })
+	}
+
+	pa, pb := split(a), split(b)
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if pa[i] == pb[i] {
+			continue
+		}
+		if len(pa[i]) != len(pb[i]) {
+			return len(pa[i]) < len(pb[i])
+		}
+		return pa[i] < pb[i]
+	}
+	return len(pa) < len(pb)
+}
+
+// parseSupport parses a support value such as "y", "n", or "a #1 #2" into the level and note numbers
+func parseSupport(value string) (Support, []string) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return Unknown, nil
+	}
+
+	var notes []string
+	for _, field := range fields[1:] {
+		if num, ok := strings.CutPrefix(field, "#"); ok {
+			notes = append(notes, num)
+		}
+	}
+
+	switch Support(fields[0]) {
+	case Supported, Partial, Unsupported:
+		return Support(fields[0]), notes
+	default:
+		return Unknown, notes
+	}
+}
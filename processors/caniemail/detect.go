@@ -0,0 +1,188 @@
+package caniemail
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// usage is the HTML and CSS used by an email
+type usage struct {
+	declarations []declaration
+	atRules      map[string]bool
+	elements     map[string]bool
+	images       []string
+}
+
+// declaration is a CSS property and value, lowercased
+type declaration struct {
+	property string
+	value    string
+}
+
+var (
+	cssComment     = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	cssAtRule      = regexp.MustCompile(`@([a-z-]+)`)
+	cssAtPrelude   = regexp.MustCompile(`@[a-z-]+[^{;]*`)
+	cssDeclaration = regexp.MustCompile(`([a-z-]+)\s*:\s*([^;{}]+)`)
+)
+
+// scan collects the CSS declarations, at-rules, elements, and image sources of a document
+func scan(doc *html.Node) usage {
+	u := usage{atRules: make(map[string]bool), elements: make(map[string]bool)}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			u.elements[n.Data] = true
+
+			for _, attr := range n.Attr {
+				switch {
+				case attr.Key == "style":
+					u.parseCSS(attr.Val)
+				case attr.Key == "src" && n.Data == "img":
+					u.images = append(u.images, attr.Val)
+				}
+			}
+
+			if n.Data == "style" {
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					if c.Type == html.TextNode {
+						u.parseCSS(c.Data)
+					}
+				}
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	return u
+}
+
+// parseCSS adds the at-rules and declarations of a stylesheet or style attribute
+func (u *usage) parseCSS(css string) {
+	css = strings.ToLower(cssComment.ReplaceAllString(css, ""))
+
+	for _, m := range cssAtRule.FindAllStringSubmatch(css, -1) {
+		u.atRules[m[1]] = true
+	}
+
+	// Drop at-rule preludes so media queries such as (max-width: 600px) aren't read as declarations
+	css = cssAtPrelude.ReplaceAllString(css, "")
+
+	for _, m := range cssDeclaration.FindAllStringSubmatch(css, -1) {
+		value := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(m[2]), "!important"))
+		u.declarations = append(u.declarations, declaration{property: m[1], value: value})
+	}
+}
+
+// rule reports whether an email uses a feature
+type rule struct {
+	slug   string
+	detect func(u usage) bool
+}
+
+// rules are the features this package detects, in report order
+var rules = []rule{
+	{"css-display-flex", declared(func(d declaration) bool {
+		return d.property == "display" && (d.value == "flex" || d.value == "inline-flex")
+	})},
+	{"css-display-grid", declared(func(d declaration) bool {
+		return d.property == "display" && (d.value == "grid" || d.value == "inline-grid")
+	})},
+	{"css-position", declared(func(d declaration) bool {
+		return d.property == "position" && d.value != "static"
+	})},
+	{"css-background-image", declared(func(d declaration) bool {
+		return d.property == "background-image" || (d.property == "background" && strings.Contains(d.value, "url("))
+	})},
+	{"css-linear-gradient", declared(func(d declaration) bool {
+		return strings.Contains(d.value, "linear-gradient(")
+	})},
+	{"css-border-radius", declared(func(d declaration) bool {
+		return strings.HasPrefix(d.property, "border") && strings.HasSuffix(d.property, "radius")
+	})},
+	{"css-box-shadow", declared(func(d declaration) bool {
+		return d.property == "box-shadow"
+	})},
+	{"css-max-width", declared(func(d declaration) bool {
+		return d.property == "max-width"
+	})},
+	{"css-float", declared(func(d declaration) bool {
+		return d.property == "float" && d.value != "none"
+	})},
+	{"css-opacity", declared(func(d declaration) bool {
+		return d.property == "opacity"
+	})},
+	{"css-variables", declared(func(d declaration) bool {
+		return strings.HasPrefix(d.property, "--") || strings.Contains(d.value, "var(--")
+	})},
+	{"css-at-media", atRule("media")},
+	{"css-at-font-face", atRule("font-face")},
+	{"css-animation", func(u usage) bool {
+		return u.atRules["keyframes"] || declared(func(d declaration) bool {
+			return strings.HasPrefix(d.property, "animation")
+		})(u)
+	}},
+	{"html-svg", element("svg")},
+	{"html-video", element("video")},
+	{"html-form", element("form")},
+	{"image-webp", image(".webp")},
+	{"image-svg", image(".svg")},
+}
+
+// detect returns the slugs of the features an email uses
+func detect(u usage) []string {
+	var slugs []string
+	for _, r := range rules {
+		if r.detect(u) {
+			slugs = append(slugs, r.slug)
+		}
+	}
+	return slugs
+}
+
+// declared matches any CSS declaration
+func declared(match func(d declaration) bool) func(u usage) bool {
+	return func(u usage) bool {
+		for _, d := range u.declarations {
+			if match(d) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// atRule matches a CSS at-rule
+func atRule(name string) func(u usage) bool {
+	return func(u usage) bool {
+		return u.atRules[name]
+	}
+}
+
+// element matches an HTML element
+func element(name string) func(u usage) bool {
+	return func(u usage) bool {
+		return u.elements[name]
+	}
+}
+
+// image matches an image source by file extension
+func image(ext string) func(u usage) bool {
+	return func(u usage) bool {
+		for _, src := range u.images {
+			src, _, _ = strings.Cut(src, "?")
+			if strings.EqualFold(path.Ext(src), ext) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,146 @@
+// Package clicktrack rewrites outbound links through a click-tracking redirect endpoint. Each rewritten link
+// carries a signed token, so the endpoint only redirects to targets that were present in a sent email.
+package clicktrack
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/internal/hosts"
+	"github.com/patrickward/mailpen/internal/htmlattr"
+)
+
+// placeholderPattern matches {name} placeholders in a redirect URL template
+var placeholderPattern = regexp.MustCompile(`\{([a-zA-Z0-9_.-]+)\}`)
+
+// Processor rewrites the http(s) links in rendered HTML through a tracking redirect URL. It implements both the
+// mailpen.HTMLProcessor and mailpen.MessageProcessor interfaces; as a message processor, metadata values of the
+// message can be used as placeholders in the redirect URL.
+type Processor struct {
+	redirect string
+	secret   []byte
+	excluded []string
+}
+
+// Option configures a Processor
+type Option func(p *Processor)
+
+// WithExcludedHosts leaves links to the given hosts, or their subdomains, untouched
+func WithExcludedHosts(hosts ...string) Option {
+	return func(p *Processor) {
+		p.excluded = append(p.excluded, hosts...)
+	}
+}
+
+// New creates a new Processor. The redirect URL template must contain a {url} placeholder, which is replaced
+// with the query-escaped link target, and may contain a {token} placeholder for the signature of the target,
+// e.g. "https://track.example.com/click?u={url}&t={token}". Any other {key} placeholder is replaced with the
+// message metadata value for key.
+func New(redirect string, secret []byte, opts ...Option) (*Processor, error) {
+	if !strings.Contains(redirect, "{url}") {
+		return nil, errors.New("redirect URL must contain a {url} placeholder")
+	}
+	if len(secret) == 0 {
+		return nil, errors.New("secret is required")
+	}
+
+	p := &Processor{redirect: redirect, secret: secret}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p, nil
+}
+
+// Process rewrites every trackable link in the HTML
+func (p *Processor) Process(html string) (string, error) {
+	return p.rewrite(html, nil)
+}
+
+// ProcessMessage rewrites every trackable link in the message HTML body, using the message metadata for
+// additional placeholders
+func (p *Processor) ProcessMessage(_ context.Context, msg *mailpen.Message) error {
+	if msg.HTMLBody == "" {
+		return nil
+	}
+
+	html, err := p.rewrite(msg.HTMLBody, msg.Metadata)
+	if err != nil {
+		return err
+	}
+	msg.HTMLBody = html
+	return nil
+}
+
+// rewrite replaces the href of each trackable link with its tracking URL
+func (p *Processor) rewrite(html string, metadata map[string]string) (string, error) {
+	return htmlattr.Replace(html, "href", []string{"a", "area", "v:roundrect"}, func(_, href string) (string, error) {
+		target := strings.TrimSpace(href)
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || hosts.Match(u.Hostname(), p.excluded) {
+			return href, nil
+		}
+
+		return p.trackingURL(target, metadata), nil
+	})
+}
+
+// trackingURL expands the redirect template for a target
+func (p *Processor) trackingURL(target string, metadata map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(p.redirect, func(placeholder string) string {
+		switch key := placeholder[1 : len(placeholder)-1]; key {
+		case "url":
+			return url.QueryEscape(target)
+		case "token":
+			return Sign(p.secret, target)
+		default:
+			return url.QueryEscape(metadata[key])
+		}
+	})
+}
+
+// Sign returns the token for a link target
+func Sign(secret []byte, target string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(target))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether token is a valid signature for the link target
+func Verify(secret []byte, target, token string) bool {
+	expected, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(target))
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+// Handler returns a redirect endpoint for tracking URLs that read the target from the urlParam query parameter
+// and the token from tokenParam. Valid clicks are reported to onClick, when set, before redirecting; requests
+// with a missing or invalid token are rejected so the endpoint can't be used as an open redirect.
+func Handler(secret []byte, urlParam, tokenParam string, onClick func(r *http.Request, target string)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		target := query.Get(urlParam)
+		if target == "" || !Verify(secret, target, query.Get(tokenParam)) {
+			http.Error(w, "invalid tracking link", http.StatusBadRequest)
+			return
+		}
+
+		if onClick != nil {
+			onClick(r, target)
+		}
+
+		http.Redirect(w, r, target, http.StatusFound)
+	})
+}
@@ -0,0 +1,87 @@
+package clicktrack_test
+
+import (
+	"context"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/processors/clicktrack"
+)
+
+var secret = []byte("test-secret")
+
+func TestNew(t *testing.T) {
+	_, err := clicktrack.New("https://track.example.com/click", secret)
+	assert.Error(t, err)
+
+	_, err = clicktrack.New("https://track.example.com/click?u={url}", nil)
+	assert.Error(t, err)
+}
+
+func TestProcessor_Process(t *testing.T) {
+	p, err := clicktrack.New("https://track.example.com/click?u={url}&t={token}", secret,
+		clicktrack.WithExcludedHosts("unsubscribe.example.com"))
+	require.NoError(t, err)
+
+	out, err := p.Process(`<a href="https://example.com/a?x=1&amp;y=2">A</a>` +
+		`<a href="https://unsubscribe.example.com/u">Unsubscribe</a>` +
+		`<a href="mailto:help@example.com">Help</a>` +
+		`<!--[if mso]><v:roundrect href="https://example.com/button" stroke="f"><![endif]-->`)
+	require.NoError(t, err)
+
+	target := "https://example.com/a?x=1&y=2"
+	want := "https://track.example.com/click?u=" + url.QueryEscape(target) + "&t=" + clicktrack.Sign(secret, target)
+	assert.Contains(t, out, `href="`+html.EscapeString(want)+`"`)
+
+	button := "https://example.com/button"
+	want = "https://track.example.com/click?u=" + url.QueryEscape(button) + "&t=" + clicktrack.Sign(secret, button)
+	assert.Contains(t, out, `<v:roundrect href="`+html.EscapeString(want)+`" stroke="f">`)
+	assert.Contains(t, out, `<a href="https://unsubscribe.example.com/u">`)
+	assert.Contains(t, out, `<a href="mailto:help@example.com">`)
+}
+
+func TestProcessor_ProcessMessage(t *testing.T) {
+	p, err := clicktrack.New("https://track.example.com/c/{message_id}?u={url}", secret)
+	require.NoError(t, err)
+
+	msg := &mailpen.Message{
+		HTMLBody: `<a href="https://example.com">A</a>`,
+		Metadata: map[string]string{"message_id": "abc 123"},
+	}
+	require.NoError(t, p.ProcessMessage(context.Background(), msg))
+	assert.Equal(t, `<a href="https://track.example.com/c/abc+123?u=https%3A%2F%2Fexample.com">A</a>`, msg.HTMLBody)
+}
+
+func TestHandler(t *testing.T) {
+	var clicked string
+	handler := clicktrack.Handler(secret, "u", "t", func(_ *http.Request, target string) {
+		clicked = target
+	})
+
+	target := "https://example.com/a"
+
+	t.Run("valid token redirects", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/click?u="+url.QueryEscape(target)+"&t="+clicktrack.Sign(secret, target), nil)
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, target, rec.Header().Get("Location"))
+		assert.Equal(t, target, clicked)
+	})
+
+	t.Run("invalid token rejected", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/click?u="+url.QueryEscape("https://evil.example.com")+"&t="+clicktrack.Sign(secret, target), nil)
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -0,0 +1,198 @@
+// Package htmlvalidate checks rendered HTML emails for markup problems that browsers forgive but email
+// clients, Outlook in particular, render badly: unclosed tags, duplicate IDs, and images without dimensions.
+package htmlvalidate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Kind identifies the kind of problem found
+type Kind string
+
+const (
+	UnclosedTag      Kind = "unclosed-tag"       // An element is opened but never closed
+	UnexpectedEndTag Kind = "unexpected-end-tag" // An end tag has no matching open element
+	DuplicateID      Kind = "duplicate-id"       // Several elements share an id
+	ImageDimensions  Kind = "image-dimensions"   // An <img> is missing a width or height attribute
+)
+
+// Issue is a problem found in the HTML
+type Issue struct {
+	Kind    Kind
+	Line    int // 1-based line of the element
+	Message string
+}
+
+// String returns the issue as "line N: message"
+func (i Issue) String() string {
+	return fmt.Sprintf("line %d: %s", i.Line, i.Message)
+}
+
+// Validator reports markup problems in rendered HTML without modifying it. It implements the
+// mailpen.HTMLAnalyzer interface.
+type Validator struct {
+	ignore map[Kind]bool
+}
+
+// Option configures a Validator
+type Option func(v *Validator)
+
+// WithIgnore skips the given kinds of problems
+func WithIgnore(kinds ...Kind) Option {
+	return func(v *Validator) {
+		for _, kind := range kinds {
+			v.ignore[kind] = true
+		}
+	}
+}
+
+// New creates a new Validator
+func New(opts ...Option) *Validator {
+	v := &Validator{ignore: make(map[Kind]bool)}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Analyze returns a warning for each problem found by Validate
+func (v *Validator) Analyze(content string) ([]string, error) {
+	issues, err := v.Validate(content)
+	if err != nil {
+		return nil, err
+	}
+
+	warnings := make([]string, len(issues))
+	for i, issue := range issues {
+		warnings[i] = "html: " + issue.String()
+	}
+	return warnings, nil
+}
+
+// element is an open element on the tokenizer stack
+type element struct {
+	name string
+	line int
+}
+
+// Validate tokenizes the HTML and returns its problems, sorted by line. Unlike a browser, it does not imply
+// end tags: elements whose end tag is optional in HTML, such as <p> and <td>, must be closed too, because
+// email clients do not agree on where they end.
+func (v *Validator) Validate(content string) ([]Issue, error) {
+	var issues []Issue
+	report := func(kind Kind, line int, format string, args ...any) {
+		if !v.ignore[kind] {
+			issues = append(issues, Issue{Kind: kind, Line: line, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+
+	var stack []element
+	ids := make(map[string]int)
+	line := 1
+
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if err := z.Err(); !errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("failed to tokenize HTML: %w", err)
+			}
+			break
+		}
+		start := line
+		line += strings.Count(string(z.Raw()), "\n")
+
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			if id, ok := attr(token, "id"); ok && id != "" {
+				if first, seen := ids[id]; seen {
+					report(DuplicateID, start, "id %q is already used on line %d", id, first)
+				} else {
+					ids[id] = start
+				}
+			}
+
+			if token.DataAtom == atom.Img {
+				src, _ := attr(token, "src")
+				for _, dimension := range []string{"width", "height"} {
+					if _, ok := attr(token, dimension); !ok {
+						report(ImageDimensions, start, "<img src=%q> is missing a %s attribute", src, dimension)
+					}
+				}
+			}
+
+			if tt == html.StartTagToken && !voidElements[token.DataAtom] {
+				stack = append(stack, element{name: token.Data, line: start})
+			}
+
+		case html.EndTagToken:
+			token := z.Token()
+			if voidElements[token.DataAtom] {
+				continue
+			}
+
+			open := -1
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i].name == token.Data {
+					open = i
+					break
+				}
+			}
+			if open < 0 {
+				report(UnexpectedEndTag, start, "</%s> has no matching <%s>", token.Data, token.Data)
+				continue
+			}
+
+			for _, unclosed := range stack[open+1:] {
+				report(UnclosedTag, unclosed.line, "<%s> is not closed before </%s> on line %d", unclosed.name, token.Data, start)
+			}
+			stack = stack[:open]
+		}
+	}
+
+	for _, unclosed := range stack {
+		report(UnclosedTag, unclosed.line, "<%s> is never closed", unclosed.name)
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Line < issues[j].Line })
+	return issues, nil
+}
+
+// voidElements have no end tag
+var voidElements = map[atom.Atom]bool{
+	atom.Area: true, atom.Base: true, atom.Br: true, atom.Col: true, atom.Embed: true, atom.Hr: true,
+	atom.Img: true, atom.Input: true, atom.Link: true, atom.Meta: true, atom.Source: true, atom.Track: true,
+	atom.Wbr: true,
+}
+
+// attr returns the value of a token attribute and whether it is present
+func attr(token html.Token, name string) (string, bool) {
+	for _, a := range token.Attr {
+		if a.Key == name {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// AssertValid fails the test with each problem found in the HTML
+func AssertValid(t testing.TB, content string, opts ...Option) {
+	t.Helper()
+
+	issues, err := New(opts...).Validate(content)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	for _, issue := range issues {
+		t.Errorf("invalid HTML: %s [%s]", issue, issue.Kind)
+	}
+}
@@ -0,0 +1,128 @@
+package htmlvalidate_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/processors/htmlvalidate"
+	"github.com/patrickward/mailpen/templates"
+)
+
+var _ mailpen.HTMLAnalyzer = (*htmlvalidate.Validator)(nil)
+
+func TestValidator_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		opts []htmlvalidate.Option
+		want []htmlvalidate.Issue
+	}{
+		{
+			name: "valid",
+			html: "<html><head><meta charset=\"utf-8\"><title>Hi</title></head>\n<body><p id=\"a\">Hi<br>there</p><img src=\"x.png\" width=\"10\" height=\"10\" /><!--[if mso]><table><tr><![endif]--></body></html>",
+		},
+		{
+			name: "unclosed tag",
+			html: "<table>\n<tr>\n<td>one\n</tr>\n</table>",
+			want: []htmlvalidate.Issue{
+				{Kind: htmlvalidate.UnclosedTag, Line: 3, Message: "<td> is not closed before </tr> on line 4"},
+			},
+		},
+		{
+			name: "never closed",
+			html: "<div>\n<p>text",
+			want: []htmlvalidate.Issue{
+				{Kind: htmlvalidate.UnclosedTag, Line: 1, Message: "<div> is never closed"},
+				{Kind: htmlvalidate.UnclosedTag, Line: 2, Message: "<p> is never closed"},
+			},
+		},
+		{
+			name: "unexpected end tag",
+			html: "<p>text</p>\n</div>",
+			want: []htmlvalidate.Issue{
+				{Kind: htmlvalidate.UnexpectedEndTag, Line: 2, Message: "</div> has no matching <div>"},
+			},
+		},
+		{
+			name: "duplicate id",
+			html: "<p id=\"intro\">a</p>\n<p id=\"intro\">b</p>",
+			want: []htmlvalidate.Issue{
+				{Kind: htmlvalidate.DuplicateID, Line: 2, Message: `id "intro" is already used on line 1`},
+			},
+		},
+		{
+			name: "image dimensions",
+			html: "<img src=\"logo.png\" width=\"100\">",
+			want: []htmlvalidate.Issue{
+				{Kind: htmlvalidate.ImageDimensions, Line: 1, Message: `<img src="logo.png"> is missing a height attribute`},
+			},
+		},
+		{
+			name: "ignored kinds",
+			html: "<img src=\"logo.png\">\n<div>",
+			opts: []htmlvalidate.Option{htmlvalidate.WithIgnore(htmlvalidate.ImageDimensions)},
+			want: []htmlvalidate.Issue{
+				{Kind: htmlvalidate.UnclosedTag, Line: 2, Message: "<div> is never closed"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues, err := htmlvalidate.New(tt.opts...).Validate(tt.html)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, issues)
+		})
+	}
+}
+
+func TestValidator_Analyze(t *testing.T) {
+	warnings, err := htmlvalidate.New().Analyze("<div>\n<img src=\"a.png\" width=\"1\" height=\"1\">")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"html: line 1: <div> is never closed"}, warnings)
+}
+
+func TestValidator_WithManager(t *testing.T) {
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Analyzers: []mailpen.HTMLAnalyzer{htmlvalidate.New()},
+	})
+	require.NoError(t, err)
+
+	email, err := manager.Compose(context.Background(), templates.Layout{
+		Subject: "Weekly report",
+		Components: []templates.Component{
+			templates.Heading{Text: "Weekly report"},
+			templates.Paragraph{Text: "Here is how your week went."},
+			templates.Button{Text: "View dashboard", URL: "https://example.com/dashboard"},
+		},
+	}, nil, mailpen.RenderOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, email.Warnings)
+}
+
+// recordingT records test failures
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertValid(t *testing.T) {
+	rt := &recordingT{TB: t}
+	htmlvalidate.AssertValid(rt, "<p id=\"a\"></p><p id=\"a\"></p>")
+	assert.Equal(t, []string{`invalid HTML: line 1: id "a" is already used on line 1 [duplicate-id]`}, rt.errors)
+
+	rt = &recordingT{TB: t}
+	htmlvalidate.AssertValid(rt, "<p></p>")
+	assert.Empty(t, rt.errors)
+}
@@ -0,0 +1,306 @@
+// Package imageembed converts remote images in rendered HTML into inline (CID) attachments, so emails display
+// their images even when the mail client blocks remote content.
+package imageembed
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/internal/hosts"
+	"github.com/patrickward/mailpen/internal/htmlattr"
+)
+
+// DefaultMaxSize is the default maximum size of a single downloaded image
+const DefaultMaxSize = 2 * 1024 * 1024
+
+// DefaultCacheTTL is the default time downloaded images are cached
+const DefaultCacheTTL = time.Hour
+
+// DefaultCacheSize is the default maximum total size of the cached images
+const DefaultCacheSize = 32 * 1024 * 1024
+
+// asset is a downloaded image
+type asset struct {
+	data        []byte
+	contentType string
+	fetched     time.Time
+}
+
+// cacheEntry is a cached image, or one being downloaded. ready is closed once img and err are set.
+type cacheEntry struct {
+	ready chan struct{}
+	img   asset
+	err   error
+}
+
+// Processor downloads images from the configured hosts and embeds them as inline attachments. It implements the
+// mailpen.MessageProcessor interface.
+type Processor struct {
+	hosts     []string
+	client    *http.Client
+	maxSize   int64
+	cacheTTL  time.Duration
+	cacheSize int64
+	domain    string
+
+	mu     sync.Mutex
+	cache  map[string]*cacheEntry
+	cached int64 // Total size of the downloaded images in the cache
+}
+
+// Option configures a Processor
+type Option func(p *Processor)
+
+// WithHTTPClient sets the HTTP client used to download images
+func WithHTTPClient(client *http.Client) Option {
+	return func(p *Processor) {
+		p.client = client
+	}
+}
+
+// WithMaxSize sets the maximum size, in bytes, of a single image. Larger images fail the send.
+func WithMaxSize(size int64) Option {
+	return func(p *Processor) {
+		p.maxSize = size
+	}
+}
+
+// WithCacheTTL sets how long downloaded images are cached (defaults to DefaultCacheTTL). A zero TTL keeps images
+// until they are evicted to make room for others, and a negative TTL disables caching.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(p *Processor) {
+		p.cacheTTL = ttl
+	}
+}
+
+// WithCacheSize sets the maximum total size, in bytes, of the cached images (defaults to DefaultCacheSize). The
+// oldest images are evicted to make room for new ones, and images larger than the whole cache are not cached.
+func WithCacheSize(size int64) Option {
+	return func(p *Processor) {
+		p.cacheSize = size
+	}
+}
+
+// WithContentIDDomain sets the domain part of generated Content-IDs (defaults to "mailpen")
+func WithContentIDDomain(domain string) Option {
+	return func(p *Processor) {
+		p.domain = domain
+	}
+}
+
+// New creates a new Processor that embeds images hosted on the given hosts or their subdomains
+func New(hosts []string, opts ...Option) *Processor {
+	p := &Processor{
+		hosts:     hosts,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		maxSize:   DefaultMaxSize,
+		cacheTTL:  DefaultCacheTTL,
+		cacheSize: DefaultCacheSize,
+		domain:    "mailpen",
+		cache:     make(map[string]*cacheEntry),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// ProcessMessage replaces the src of each matching <img> tag with a cid: reference and attaches the image
+// inline. An image used several times is only attached once.
+func (p *Processor) ProcessMessage(ctx context.Context, msg *mailpen.Message) error {
+	if msg.HTMLBody == "" {
+		return nil
+	}
+
+	embedded := make(map[string]string)
+
+	html, err := htmlattr.Replace(msg.HTMLBody, "src", []string{"img"}, func(_, src string) (string, error) {
+		if cid, ok := embedded[src]; ok {
+			return "cid:" + cid, nil
+		}
+
+		u, err := url.Parse(src)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !hosts.Match(u.Hostname(), p.hosts) {
+			return src, nil
+		}
+
+		img, err := p.fetch(ctx, src)
+		if err != nil {
+			return "", err
+		}
+
+		cid := p.contentID(src)
+		embedded[src] = cid
+		msg.Attachments = append(msg.Attachments, mailpen.Attachment{
+			Filename:    filename(u, img.contentType),
+			Data:        bytes.NewReader(img.data),
+			ContentType: mailpen.ContentType(img.contentType),
+			ContentID:   cid,
+		})
+
+		return "cid:" + cid, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	msg.HTMLBody = html
+	return nil
+}
+
+// ClearCache removes all cached images. Downloads in progress finish for their callers but are not cached.
+func (p *Processor) ClearCache() {
+	p.mu.Lock()
+	p.cache = make(map[string]*cacheEntry)
+	p.cached = 0
+	p.mu.Unlock()
+}
+
+// fetch returns the image at src from the cache or by downloading it. Callers that miss the same image while it
+// is being downloaded wait for that download and share its result. Download errors are not cached.
+func (p *Processor) fetch(ctx context.Context, src string) (asset, error) {
+	if p.cacheTTL < 0 {
+		return p.download(ctx, src)
+	}
+
+	p.mu.Lock()
+	entry, ok := p.cache[src]
+	if ok && p.expired(entry) {
+		p.remove(src, entry)
+		ok = false
+	}
+	if !ok {
+		entry = &cacheEntry{ready: make(chan struct{})}
+		p.cache[src] = entry
+	}
+	p.mu.Unlock()
+
+	if ok {
+		select {
+		case <-entry.ready:
+			return entry.img, entry.err
+		case <-ctx.Done():
+			return asset{}, fmt.Errorf("failed to download image %s: %w", src, ctx.Err())
+		}
+	}
+
+	entry.img, entry.err = p.download(ctx, src)
+	close(entry.ready)
+
+	p.mu.Lock()
+	if p.cache[src] == entry {
+		if entry.err != nil || int64(len(entry.img.data)) > p.cacheSize {
+			delete(p.cache, src)
+		} else {
+			p.cached += int64(len(entry.img.data))
+			p.evict()
+		}
+	}
+	p.mu.Unlock()
+
+	return entry.img, entry.err
+}
+
+// expired reports whether a downloaded cache entry is older than the cache TTL. The caller must hold p.mu.
+func (p *Processor) expired(entry *cacheEntry) bool {
+	select {
+	case <-entry.ready:
+		return p.cacheTTL > 0 && time.Since(entry.img.fetched) >= p.cacheTTL
+	default:
+		return false
+	}
+}
+
+// remove deletes a downloaded cache entry. The caller must hold p.mu.
+func (p *Processor) remove(src string, entry *cacheEntry) {
+	delete(p.cache, src)
+	p.cached -= int64(len(entry.img.data))
+}
+
+// evict removes the oldest downloaded images until the cache fits in its size limit. The caller must hold p.mu.
+func (p *Processor) evict() {
+	for p.cached > p.cacheSize {
+		var oldest string
+		var entry *cacheEntry
+		for src, e := range p.cache {
+			select {
+			case <-e.ready:
+			default:
+				continue
+			}
+			if entry == nil || e.img.fetched.Before(entry.img.fetched) {
+				oldest, entry = src, e
+			}
+		}
+		if entry == nil {
+			return
+		}
+		p.remove(oldest, entry)
+	}
+}
+
+// download fetches the image at src
+func (p *Processor) download(ctx context.Context, src string) (asset, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, src, nil)
+	if err != nil {
+		return asset{}, fmt.Errorf("failed to create request for image %s: %w", src, err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return asset{}, fmt.Errorf("failed to download image %s: %w", src, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return asset{}, fmt.Errorf("failed to download image %s: status %d", src, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, p.maxSize+1))
+	if err != nil {
+		return asset{}, fmt.Errorf("failed to read image %s: %w", src, err)
+	}
+	if int64(len(data)) > p.maxSize {
+		return asset{}, fmt.Errorf("image %s exceeds the maximum size of %d bytes", src, p.maxSize)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	return asset{data: data, contentType: contentType, fetched: time.Now()}, nil
+}
+
+// contentID returns a stable Content-ID for an image URL
+func (p *Processor) contentID(src string) string {
+	sum := sha256.Sum256([]byte(src))
+	return "img-" + hex.EncodeToString(sum[:8]) + "@" + p.domain
+}
+
+// filename returns the attachment filename for an image URL, adding an extension from the content type if needed
+func filename(u *url.URL, contentType string) string {
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		name = "image"
+	}
+
+	if path.Ext(name) == "" {
+		if exts, err := mime.ExtensionsByType(contentType); err == nil && len(exts) > 0 {
+			name += exts[0]
+		}
+	}
+
+	return name
+}
@@ -0,0 +1,163 @@
+package imageembed_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/processors/imageembed"
+)
+
+func TestProcessor_ProcessMessage(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/logo.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("png-data"))
+		case "/large.png":
+			_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	host := mustHost(t, server.URL)
+	p := imageembed.New([]string{host}, imageembed.WithMaxSize(50))
+
+	t.Run("embeds matching images once", func(t *testing.T) {
+		msg := &mailpen.Message{
+			HTMLBody: `<img src="` + server.URL + `/logo.png" alt="Logo"><img src="` + server.URL + `/logo.png">` +
+				`<img src="https://cdn.example.com/other.png">`,
+		}
+		require.NoError(t, p.ProcessMessage(context.Background(), msg))
+
+		require.Len(t, msg.Attachments, 1)
+		att := msg.Attachments[0]
+		assert.Equal(t, "logo.png", att.Filename)
+		assert.Equal(t, mailpen.ContentType("image/png"), att.ContentType)
+		assert.True(t, strings.HasSuffix(att.ContentID, "@mailpen"))
+
+		data, err := io.ReadAll(att.Data)
+		require.NoError(t, err)
+		assert.Equal(t, "png-data", string(data))
+
+		assert.Equal(t, 2, strings.Count(msg.HTMLBody, `src="cid:`+att.ContentID+`"`))
+		assert.Contains(t, msg.HTMLBody, `src="https://cdn.example.com/other.png"`)
+	})
+
+	t.Run("uses the cache", func(t *testing.T) {
+		before := requests
+		msg := &mailpen.Message{HTMLBody: `<img src="` + server.URL + `/logo.png">`}
+		require.NoError(t, p.ProcessMessage(context.Background(), msg))
+		assert.Equal(t, before, requests)
+	})
+
+	t.Run("download errors fail the message", func(t *testing.T) {
+		msg := &mailpen.Message{HTMLBody: `<img src="` + server.URL + `/missing.png">`}
+		err := p.ProcessMessage(context.Background(), msg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "status 404")
+	})
+
+	t.Run("size limit", func(t *testing.T) {
+		msg := &mailpen.Message{HTMLBody: `<img src="` + server.URL + `/large.png">`}
+		err := p.ProcessMessage(context.Background(), msg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "exceeds the maximum size")
+	})
+}
+
+func TestProcessor_Cache(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/slow.png" {
+			<-release
+		}
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("12345678"))
+	}))
+	defer server.Close()
+
+	host := mustHost(t, server.URL)
+	embed := func(p *imageembed.Processor, name string) {
+		t.Helper()
+		msg := &mailpen.Message{HTMLBody: `<img src="` + server.URL + "/" + name + `">`}
+		require.NoError(t, p.ProcessMessage(context.Background(), msg))
+		require.Len(t, msg.Attachments, 1)
+	}
+
+	t.Run("concurrent misses download once", func(t *testing.T) {
+		requests.Store(0)
+		p := imageembed.New([]string{host})
+
+		var wg sync.WaitGroup
+		for range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				msg := &mailpen.Message{HTMLBody: `<img src="` + server.URL + `/slow.png">`}
+				assert.NoError(t, p.ProcessMessage(context.Background(), msg))
+			}()
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("evicts the oldest image when full", func(t *testing.T) {
+		requests.Store(0)
+		p := imageembed.New([]string{host}, imageembed.WithCacheSize(10))
+
+		embed(p, "a.png")
+		embed(p, "b.png")
+		embed(p, "b.png")
+		assert.Equal(t, int32(2), requests.Load())
+
+		embed(p, "a.png")
+		assert.Equal(t, int32(3), requests.Load())
+	})
+
+	t.Run("images larger than the cache are not cached", func(t *testing.T) {
+		requests.Store(0)
+		p := imageembed.New([]string{host}, imageembed.WithCacheSize(4))
+
+		embed(p, "a.png")
+		embed(p, "a.png")
+		assert.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("expired images are downloaded again", func(t *testing.T) {
+		requests.Store(0)
+		p := imageembed.New([]string{host}, imageembed.WithCacheTTL(time.Nanosecond))
+
+		embed(p, "a.png")
+		time.Sleep(time.Millisecond)
+		embed(p, "a.png")
+		assert.Equal(t, int32(2), requests.Load())
+	})
+}
+
+func mustHost(t *testing.T, raw string) string {
+	t.Helper()
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	return u.Hostname()
+}
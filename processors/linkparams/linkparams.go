@@ -0,0 +1,118 @@
+// Package linkparams appends analytics or custom query parameters to the links in rendered HTML.
+package linkparams
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/patrickward/mailpen/internal/hosts"
+	"github.com/patrickward/mailpen/internal/htmlattr"
+)
+
+// Processor appends query parameters to every http(s) link in rendered HTML. It implements the
+// mailpen.HTMLProcessor interface.
+type Processor struct {
+	params    map[string]string
+	allowed   []string
+	denied    []string
+	overwrite bool
+}
+
+// Option configures a Processor
+type Option func(p *Processor)
+
+// WithAllowedHosts limits decoration to links whose host matches one of the given hosts or their subdomains
+func WithAllowedHosts(hosts ...string) Option {
+	return func(p *Processor) {
+		p.allowed = append(p.allowed, hosts...)
+	}
+}
+
+// WithDeniedHosts skips links whose host matches one of the given hosts or their subdomains
+func WithDeniedHosts(hosts ...string) Option {
+	return func(p *Processor) {
+		p.denied = append(p.denied, hosts...)
+	}
+}
+
+// WithOverwrite replaces parameters that are already present on a link. By default, existing values are kept.
+func WithOverwrite() Option {
+	return func(p *Processor) {
+		p.overwrite = true
+	}
+}
+
+// New creates a new Processor that appends the given query parameters
+func New(params map[string]string, opts ...Option) *Processor {
+	p := &Processor{params: params}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// UTM returns the standard UTM parameters for the given source, medium, and campaign. Empty values are omitted.
+func UTM(source, medium, campaign string) map[string]string {
+	params := make(map[string]string)
+	for key, value := range map[string]string{
+		"utm_source":   source,
+		"utm_medium":   medium,
+		"utm_campaign": campaign,
+	} {
+		if value != "" {
+			params[key] = value
+		}
+	}
+	return params
+}
+
+// Process appends the configured parameters to the href of every matching link
+func (p *Processor) Process(html string) (string, error) {
+	if len(p.params) == 0 {
+		return html, nil
+	}
+
+	return htmlattr.Replace(html, "href", []string{"a", "area", "v:roundrect"}, func(_, href string) (string, error) {
+		return p.decorate(href), nil
+	})
+}
+
+// decorate returns the link with the parameters applied, or unchanged if it should not be decorated
+func (p *Processor) decorate(href string) string {
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !p.matchHost(u.Hostname()) {
+		return href
+	}
+
+	query := u.Query()
+	keys := make([]string, 0, len(p.params))
+	for key := range p.params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	changed := false
+	for _, key := range keys {
+		if query.Has(key) && !p.overwrite {
+			continue
+		}
+		query.Set(key, p.params[key])
+		changed = true
+	}
+
+	if !changed {
+		return href
+	}
+
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// matchHost reports whether links to the host should be decorated
+func (p *Processor) matchHost(host string) bool {
+	if hosts.Match(host, p.denied) {
+		return false
+	}
+	return len(p.allowed) == 0 || hosts.Match(host, p.allowed)
+}
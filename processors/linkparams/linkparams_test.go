@@ -0,0 +1,68 @@
+package linkparams_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen/processors/linkparams"
+)
+
+func TestProcessor_Process(t *testing.T) {
+	utm := linkparams.UTM("newsletter", "email", "spring")
+
+	tests := []struct {
+		name  string
+		opts  []linkparams.Option
+		input string
+		want  string
+	}{
+		{
+			name:  "appends parameters",
+			input: `<a href="https://example.com/page" style="color: red;">Page</a>`,
+			want:  `<a href="https://example.com/page?utm_campaign=spring&amp;utm_medium=email&amp;utm_source=newsletter" style="color: red;">Page</a>`,
+		},
+		{
+			name:  "keeps existing query and values",
+			input: `<a href='https://example.com/?id=1&amp;utm_source=partner'>Page</a>`,
+			want:  `<a href='https://example.com/?id=1&amp;utm_campaign=spring&amp;utm_medium=email&amp;utm_source=partner'>Page</a>`,
+		},
+		{
+			name:  "overwrites existing values",
+			opts:  []linkparams.Option{linkparams.WithOverwrite()},
+			input: `<a href="https://example.com/?utm_source=partner">Page</a>`,
+			want:  `<a href="https://example.com/?utm_campaign=spring&amp;utm_medium=email&amp;utm_source=newsletter">Page</a>`,
+		},
+		{
+			name:  "skips non-http links and other tags",
+			input: `<a href="mailto:help@example.com">Mail</a><a href="#top">Top</a><link href="https://example.com/style.css">`,
+			want:  `<a href="mailto:help@example.com">Mail</a><a href="#top">Top</a><link href="https://example.com/style.css">`,
+		},
+		{
+			name:  "outlook VML buttons",
+			input: `<!--[if mso]><v:roundrect xmlns:v="urn:schemas-microsoft-com:vml" href="https://example.com/go" stroke="f"><![endif]-->`,
+			want:  `<!--[if mso]><v:roundrect xmlns:v="urn:schemas-microsoft-com:vml" href="https://example.com/go?utm_campaign=spring&amp;utm_medium=email&amp;utm_source=newsletter" stroke="f"><![endif]-->`,
+		},
+		{
+			name:  "allowed hosts",
+			opts:  []linkparams.Option{linkparams.WithAllowedHosts("example.com")},
+			input: `<a href="https://www.example.com">A</a><a href="https://other.com">B</a>`,
+			want:  `<a href="https://www.example.com?utm_campaign=spring&amp;utm_medium=email&amp;utm_source=newsletter">A</a><a href="https://other.com">B</a>`,
+		},
+		{
+			name:  "denied hosts",
+			opts:  []linkparams.Option{linkparams.WithDeniedHosts("other.com")},
+			input: `<a href="https://other.com/x">B</a>`,
+			want:  `<a href="https://other.com/x">B</a>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := linkparams.New(utm, tt.opts...).Process(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// Package openpixel injects a 1x1 open-tracking image into rendered HTML messages.
+package openpixel
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"sort"
+
+	"github.com/patrickward/mailpen"
+)
+
+// URLFunc returns the tracking pixel URL for a message. Returning an empty URL skips injection for that message.
+type URLFunc func(ctx context.Context, msg *mailpen.Message) (string, error)
+
+// closingBody matches the closing body tag
+var closingBody = regexp.MustCompile(`(?i)</body\s*>`)
+
+// Processor injects an open-tracking pixel into the HTML body of each message. It implements the
+// mailpen.MessageProcessor interface.
+type Processor struct {
+	url URLFunc
+}
+
+// New creates a new Processor that generates the pixel URL for each message with fn
+func New(fn URLFunc) *Processor {
+	return &Processor{url: fn}
+}
+
+// MetadataURL returns a URLFunc that adds the given metadata keys of each message as query parameters to base,
+// so the tracking endpoint can identify the message when it records an open. Messages missing all of the keys
+// are skipped.
+func MetadataURL(base string, keys ...string) URLFunc {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+
+	return func(_ context.Context, msg *mailpen.Message) (string, error) {
+		u, err := url.Parse(base)
+		if err != nil {
+			return "", fmt.Errorf("invalid tracking pixel URL: %w", err)
+		}
+
+		query := u.Query()
+		found := false
+		for _, key := range sorted {
+			if value, ok := msg.Metadata[key]; ok {
+				query.Set(key, value)
+				found = true
+			}
+		}
+		if !found {
+			return "", nil
+		}
+
+		u.RawQuery = query.Encode()
+		return u.String(), nil
+	}
+}
+
+// ProcessMessage injects the tracking pixel before the closing body tag, or at the end of the HTML body
+// when there is none. Messages without an HTML body are left unchanged.
+func (p *Processor) ProcessMessage(ctx context.Context, msg *mailpen.Message) error {
+	if msg.HTMLBody == "" {
+		return nil
+	}
+
+	src, err := p.url(ctx, msg)
+	if err != nil {
+		return fmt.Errorf("failed to generate tracking pixel URL: %w", err)
+	}
+	if src == "" {
+		return nil
+	}
+
+	pixel := Tag(src)
+	if loc := closingBody.FindStringIndex(msg.HTMLBody); loc != nil {
+		msg.HTMLBody = msg.HTMLBody[:loc[0]] + pixel + msg.HTMLBody[loc[0]:]
+		return nil
+	}
+
+	msg.HTMLBody += pixel
+	return nil
+}
+
+// Tag returns the image tag for a tracking pixel
+func Tag(src string) string {
+	return `<img src="` + html.EscapeString(src) + `" width="1" height="1" alt="" border="0" style="display: block; width: 1px; height: 1px; border: 0; margin: 0; padding: 0;" />`
+}
@@ -0,0 +1,76 @@
+package openpixel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/processors/openpixel"
+)
+
+func TestProcessor_ProcessMessage(t *testing.T) {
+	pixelURL := openpixel.MetadataURL("https://track.example.com/open", "message_id", "campaign")
+
+	tests := []struct {
+		name    string
+		fn      openpixel.URLFunc
+		msg     *mailpen.Message
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "injects before closing body",
+			fn:   pixelURL,
+			msg: &mailpen.Message{
+				HTMLBody: "<html><body><p>Hi</p></BODY></html>",
+				Metadata: map[string]string{"message_id": "abc", "campaign": "spring"},
+			},
+			want: `<html><body><p>Hi</p>` + openpixel.Tag("https://track.example.com/open?campaign=spring&message_id=abc") + `</BODY></html>`,
+		},
+		{
+			name: "appends to fragments",
+			fn:   pixelURL,
+			msg: &mailpen.Message{
+				HTMLBody: "<p>Hi</p>",
+				Metadata: map[string]string{"message_id": "abc"},
+			},
+			want: `<p>Hi</p>` + openpixel.Tag("https://track.example.com/open?message_id=abc"),
+		},
+		{
+			name: "skips messages without metadata",
+			fn:   pixelURL,
+			msg:  &mailpen.Message{HTMLBody: "<p>Hi</p>"},
+			want: "<p>Hi</p>",
+		},
+		{
+			name: "skips text-only messages",
+			fn:   pixelURL,
+			msg:  &mailpen.Message{TextBody: "Hi", Metadata: map[string]string{"message_id": "abc"}},
+		},
+		{
+			name: "callback error",
+			fn: func(context.Context, *mailpen.Message) (string, error) {
+				return "", errors.New("boom")
+			},
+			msg:     &mailpen.Message{HTMLBody: "<p>Hi</p>"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := openpixel.New(tt.fn).ProcessMessage(context.Background(), tt.msg)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tt.msg.HTMLBody)
+		})
+	}
+}
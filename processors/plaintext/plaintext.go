@@ -0,0 +1,316 @@
+// Package plaintext derives a plain-text alternative from rendered HTML email content.
+package plaintext
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Converter converts rendered HTML into readable plain text. Layout tables are flattened, data tables are
+// rendered as aligned columns, and link targets are preserved next to their text.
+type Converter struct {
+	omitLinks bool
+}
+
+// Option configures a Converter
+type Option func(c *Converter)
+
+// WithoutLinks omits link targets, keeping only the link text
+func WithoutLinks() Option {
+	return func(c *Converter) {
+		c.omitLinks = true
+	}
+}
+
+// New creates a new Converter
+func New(opts ...Option) *Converter {
+	c := &Converter{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Convert converts an HTML document or fragment into plain text
+func (c *Converter) Convert(content string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	w := &textWriter{}
+	c.walk(w, doc)
+
+	return w.String(), nil
+}
+
+// walk writes the text for a node and its children
+func (c *Converter) walk(w *textWriter, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		w.writeText(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		c.walkChildren(w, n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Head, atom.Style, atom.Script, atom.Title:
+		return
+	case atom.Br:
+		w.lineBreak()
+	case atom.Hr:
+		w.block(2)
+		w.writeRaw("----------------------------------------")
+		w.block(2)
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.P, atom.Blockquote, atom.Pre:
+		w.block(2)
+		c.walkChildren(w, n)
+		w.block(2)
+	case atom.Ul, atom.Ol:
+		w.block(2)
+		c.walkList(w, n)
+		w.block(2)
+	case atom.A:
+		c.walkLink(w, n)
+	case atom.Img:
+		if alt := strings.TrimSpace(attr(n, "alt")); alt != "" {
+			w.writeText(alt)
+		}
+	case atom.Table:
+		if isDataTable(n) {
+			w.block(2)
+			c.writeTable(w, n)
+			w.block(2)
+			return
+		}
+		c.walkChildren(w, n)
+	case atom.Div, atom.Tr, atom.Li, atom.Section, atom.Header, atom.Footer, atom.Article:
+		w.block(1)
+		c.walkChildren(w, n)
+		w.block(1)
+	default:
+		c.walkChildren(w, n)
+	}
+}
+
+// walkChildren walks each child of a node in order
+func (c *Converter) walkChildren(w *textWriter, n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.walk(w, child)
+	}
+}
+
+// walkList writes list items with bullets, or numbers for ordered lists
+func (c *Converter) walkList(w *textWriter, n *html.Node) {
+	index := 0
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || child.DataAtom != atom.Li {
+			continue
+		}
+
+		index++
+		w.block(1)
+		if n.DataAtom == atom.Ol {
+			w.writeRaw(fmt.Sprintf("%d. ", index))
+		} else {
+			w.writeRaw("- ")
+		}
+		c.walkChildren(w, child)
+	}
+}
+
+// walkLink writes the link text followed by its target
+func (c *Converter) walkLink(w *textWriter, n *html.Node) {
+	inner := &textWriter{}
+	c.walkChildren(inner, n)
+	text := strings.Join(strings.Fields(inner.String()), " ")
+
+	href := strings.TrimSpace(attr(n, "href"))
+	if c.omitLinks || href == "" || strings.HasPrefix(href, "#") {
+		w.writeText(text)
+		return
+	}
+
+	target := strings.TrimPrefix(href, "mailto:")
+	switch {
+	case text == "" || text == href || text == target:
+		w.writeText(target)
+	default:
+		w.writeText(text + " (" + target + ")")
+	}
+}
+
+// writeTable writes a data table as aligned, pipe-separated columns
+func (c *Converter) writeTable(w *textWriter, table *html.Node) {
+	var rows [][]string
+	var widths []int
+
+	for _, tr := range tableRows(table) {
+		var row []string
+		for cell := tr.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.Type != html.ElementNode || (cell.DataAtom != atom.Td && cell.DataAtom != atom.Th) {
+				continue
+			}
+
+			inner := &textWriter{}
+			c.walkChildren(inner, cell)
+			text := strings.Join(strings.Fields(inner.String()), " ")
+
+			if col := len(row); col >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[len(row)] = max(widths[len(row)], utf8.RuneCountInString(text))
+			row = append(row, text)
+		}
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+
+	for i, row := range rows {
+		cells := make([]string, len(row))
+		for col, text := range row {
+			cells[col] = text + strings.Repeat(" ", widths[col]-utf8.RuneCountInString(text))
+		}
+		if i > 0 {
+			w.lineBreak()
+		}
+		w.writeRaw(strings.TrimRight(strings.Join(cells, " | "), " "))
+	}
+}
+
+// isDataTable reports whether a table holds tabular data rather than layout. Tables with header cells,
+// or without role="presentation" and nested tables, are treated as data.
+func isDataTable(table *html.Node) bool {
+	rows := tableRows(table)
+	for _, tr := range rows {
+		for cell := tr.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.Type == html.ElementNode && cell.DataAtom == atom.Th {
+				return true
+			}
+		}
+	}
+
+	if attr(table, "role") == "presentation" {
+		return false
+	}
+
+	return len(rows) > 0 && !hasDescendant(table, atom.Table)
+}
+
+// tableRows returns the rows that belong directly to a table, including those inside thead, tbody, and tfoot
+func tableRows(table *html.Node) []*html.Node {
+	var rows []*html.Node
+	for child := table.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		switch child.DataAtom {
+		case atom.Tr:
+			rows = append(rows, child)
+		case atom.Thead, atom.Tbody, atom.Tfoot:
+			rows = append(rows, tableRows(child)...)
+		}
+	}
+	return rows
+}
+
+// hasDescendant reports whether any descendant of n is an element of the given type
+func hasDescendant(n *html.Node, a atom.Atom) bool {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.DataAtom == a {
+			return true
+		}
+		if hasDescendant(child, a) {
+			return true
+		}
+	}
+	return false
+}
+
+// attr returns the value of the named attribute, or an empty string if it is not set
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// textWriter accumulates text while collapsing whitespace and block separators
+type textWriter struct {
+	b        strings.Builder
+	newlines int  // Newlines requested before the next text
+	space    bool // Whether a space is pending before the next word
+}
+
+// writeText writes text with HTML whitespace collapsing applied
+func (w *textWriter) writeText(s string) {
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		if s != "" {
+			w.space = true
+		}
+		return
+	}
+
+	if startsWithSpace(s) {
+		w.space = true
+	}
+
+	w.writeRaw(strings.Join(words, " "))
+
+	if endsWithSpace(s) {
+		w.space = true
+	}
+}
+
+// writeRaw writes text as-is after flushing any pending separators
+func (w *textWriter) writeRaw(s string) {
+	if w.b.Len() > 0 {
+		if w.newlines > 0 {
+			w.b.WriteString(strings.Repeat("\n", w.newlines))
+		} else if w.space {
+			w.b.WriteByte(' ')
+		}
+	}
+
+	w.b.WriteString(s)
+	w.newlines = 0
+	w.space = false
+}
+
+// block requests that the next text starts after at least n newlines
+func (w *textWriter) block(n int) {
+	w.newlines = max(w.newlines, n)
+}
+
+// lineBreak forces a line break, even if one is already pending
+func (w *textWriter) lineBreak() {
+	if w.b.Len() == 0 {
+		return
+	}
+	w.b.WriteByte('\n')
+	w.space = false
+}
+
+// String returns the accumulated text
+func (w *textWriter) String() string {
+	return strings.TrimSpace(w.b.String())
+}
+
+func startsWithSpace(s string) bool {
+	return s != "" && strings.TrimLeft(s, " \t\r\n\f") != s
+}
+
+func endsWithSpace(s string) bool {
+	return s != "" && strings.TrimRight(s, " \t\r\n\f") != s
+}
@@ -0,0 +1,62 @@
+package plaintext_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen/processors/plaintext"
+)
+
+func TestConverter_Convert(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []plaintext.Option
+		input string
+		want  string
+	}{
+		{
+			name:  "paragraphs and headings",
+			input: `<html><head><title>Ignored</title><style>p { color: red; }</style></head><body><h1>Welcome</h1><p>Hello   there,<br>friend.</p><p>Second</p></body></html>`,
+			want:  "Welcome\n\nHello there,\nfriend.\n\nSecond",
+		},
+		{
+			name:  "links preserved",
+			input: `<p>Visit <a href="https://example.com">our site</a> or <a href="https://example.com/docs">https://example.com/docs</a>. Email <a href="mailto:help@example.com">help@example.com</a>.</p>`,
+			want:  "Visit our site (https://example.com) or https://example.com/docs. Email help@example.com.",
+		},
+		{
+			name:  "links omitted",
+			opts:  []plaintext.Option{plaintext.WithoutLinks()},
+			input: `<p>Visit <a href="https://example.com">our site</a>.</p>`,
+			want:  "Visit our site.",
+		},
+		{
+			name:  "lists",
+			input: `<ul><li>One</li><li>Two</li></ul><ol><li>First</li><li>Second</li></ol>`,
+			want:  "- One\n- Two\n\n1. First\n2. Second",
+		},
+		{
+			name: "layout tables are flattened",
+			input: `<table role="presentation"><tr><td><table role="presentation"><tr><td><p>Inside</p></td></tr></table></td></tr>
+				<tr><td><img src="logo.png" alt="ACME Logo"></td></tr></table>`,
+			want: "Inside\n\nACME Logo",
+		},
+		{
+			name: "data tables are aligned",
+			input: `<table role="presentation"><tr><th>Name</th><th>Role</th></tr>
+				<tr><td>John Doe</td><td>Engineer</td></tr>
+				<tr><td>Jane</td><td>Manager</td></tr></table>`,
+			want: "Name     | Role\nJohn Doe | Engineer\nJane     | Manager",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := plaintext.New(tt.opts...).Convert(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// Package preheader injects hidden preview text into rendered HTML messages.
+package preheader
+
+import (
+	"context"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/patrickward/mailpen"
+)
+
+// MetadataKey is the default message metadata key holding the preheader text
+const MetadataKey = "preheader"
+
+// DefaultPadding is the default number of &nbsp;&zwnj; pairs appended after the preheader text. The padding
+// keeps clients from filling the preview with the first lines of the body.
+const DefaultPadding = 90
+
+// openingBody matches the opening body tag
+var openingBody = regexp.MustCompile(`(?i)<body\b[^>]*>`)
+
+// Processor injects a hidden preheader right after the opening body tag. It implements the
+// mailpen.MessageProcessor interface.
+type Processor struct {
+	key     string
+	padding int
+}
+
+// Option configures a Processor
+type Option func(p *Processor)
+
+// WithMetadataKey sets the message metadata key holding the preheader text
+func WithMetadataKey(key string) Option {
+	return func(p *Processor) {
+		p.key = key
+	}
+}
+
+// WithPadding sets the number of &nbsp;&zwnj; pairs appended after the preheader text
+func WithPadding(n int) Option {
+	return func(p *Processor) {
+		p.padding = n
+	}
+}
+
+// New creates a new Processor
+func New(opts ...Option) *Processor {
+	p := &Processor{
+		key:     MetadataKey,
+		padding: DefaultPadding,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// ProcessMessage injects the preheader when the message metadata contains one. The preheader is placed right
+// after the opening body tag, or at the start of the HTML body when there is none.
+func (p *Processor) ProcessMessage(_ context.Context, msg *mailpen.Message) error {
+	text := strings.TrimSpace(msg.Metadata[p.key])
+	if text == "" || msg.HTMLBody == "" {
+		return nil
+	}
+
+	block := Block(text, p.padding)
+	if loc := openingBody.FindStringIndex(msg.HTMLBody); loc != nil {
+		msg.HTMLBody = msg.HTMLBody[:loc[1]] + block + msg.HTMLBody[loc[1]:]
+		return nil
+	}
+
+	msg.HTMLBody = block + msg.HTMLBody
+	return nil
+}
+
+// Block returns the hidden preheader markup for text followed by padding &nbsp;&zwnj; pairs
+func Block(text string, padding int) string {
+	return `<div style="display: none; font-size: 1px; line-height: 1px; max-height: 0; max-width: 0; opacity: 0; overflow: hidden; mso-hide: all;">` +
+		html.EscapeString(text) + strings.Repeat("&nbsp;&zwnj;", max(padding, 0)) + `</div>`
+}
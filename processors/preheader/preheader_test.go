@@ -0,0 +1,59 @@
+package preheader_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/processors/preheader"
+)
+
+func TestProcessor_ProcessMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []preheader.Option
+		msg  *mailpen.Message
+		want string
+	}{
+		{
+			name: "injected after body",
+			msg: &mailpen.Message{
+				HTMLBody: `<html><body style="margin: 0;"><p>Hi</p></body></html>`,
+				Metadata: map[string]string{"preheader": "Your order & more"},
+			},
+			want: `<html><body style="margin: 0;">` + preheader.Block("Your order & more", preheader.DefaultPadding) + `<p>Hi</p></body></html>`,
+		},
+		{
+			name: "fragment with custom key and padding",
+			opts: []preheader.Option{preheader.WithMetadataKey("preview"), preheader.WithPadding(2)},
+			msg: &mailpen.Message{
+				HTMLBody: `<p>Hi</p>`,
+				Metadata: map[string]string{"preview": "Hello"},
+			},
+			want: preheader.Block("Hello", 2) + `<p>Hi</p>`,
+		},
+		{
+			name: "no preheader",
+			msg:  &mailpen.Message{HTMLBody: `<body><p>Hi</p></body>`},
+			want: `<body><p>Hi</p></body>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, preheader.New(tt.opts...).ProcessMessage(context.Background(), tt.msg))
+			assert.Equal(t, tt.want, tt.msg.HTMLBody)
+		})
+	}
+}
+
+func TestBlock(t *testing.T) {
+	block := preheader.Block("Your order & more", 3)
+	assert.Contains(t, block, "display: none;")
+	assert.Contains(t, block, "Your order &amp; more")
+	assert.Equal(t, 3, strings.Count(block, "&nbsp;&zwnj;"))
+}
@@ -0,0 +1,200 @@
+// Package spamcheck scores rendered messages for common spam-filter triggers.
+package spamcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/internal/hosts"
+	"github.com/patrickward/mailpen/internal/htmlattr"
+	"github.com/patrickward/mailpen/processors/plaintext"
+)
+
+// DefaultThreshold is the default score at which a message is reported or blocked
+const DefaultThreshold = 5.0
+
+// ErrSpamThreshold is returned when a message is blocked for scoring above the threshold
+var ErrSpamThreshold = errors.New("message exceeds the spam score threshold")
+
+// DefaultShorteners lists well-known URL shortener hosts
+var DefaultShorteners = []string{
+	"bit.ly", "buff.ly", "cutt.ly", "goo.gl", "is.gd", "ow.ly", "rebrand.ly", "shorturl.at", "t.co", "tinyurl.com",
+}
+
+// Mode determines what happens when a message scores at or above the threshold
+type Mode int
+
+const (
+	// Warn reports the message to the report function and sends it anyway
+	Warn Mode = iota
+	// Block rejects the message with ErrSpamThreshold
+	Block
+)
+
+// Hit is a single triggered rule
+type Hit struct {
+	Rule        string
+	Description string
+	Score       float64
+}
+
+// Report is the result of scoring a message
+type Report struct {
+	Score float64
+	Hits  []Hit
+}
+
+// Warnings returns a description of each triggered rule
+func (r Report) Warnings() []string {
+	warnings := make([]string, 0, len(r.Hits))
+	for _, hit := range r.Hits {
+		warnings = append(warnings, fmt.Sprintf("spam: %s (%.1f): %s", hit.Rule, hit.Score, hit.Description))
+	}
+	return warnings
+}
+
+// ReportFunc receives the report for each message scoring at or above the threshold
+type ReportFunc func(ctx context.Context, msg *mailpen.Message, report Report)
+
+// Analyzer scores messages for spam triggers. It implements the mailpen.MessageProcessor interface.
+type Analyzer struct {
+	threshold  float64
+	mode       Mode
+	report     ReportFunc
+	shorteners []string
+	converter  *plaintext.Converter
+}
+
+// Option configures an Analyzer
+type Option func(a *Analyzer)
+
+// WithThreshold sets the score at which a message is reported or blocked
+func WithThreshold(threshold float64) Option {
+	return func(a *Analyzer) {
+		a.threshold = threshold
+	}
+}
+
+// WithMode sets whether messages above the threshold are only reported (Warn) or rejected (Block)
+func WithMode(mode Mode) Option {
+	return func(a *Analyzer) {
+		a.mode = mode
+	}
+}
+
+// WithReportFunc sets the function that receives reports for messages at or above the threshold
+func WithReportFunc(fn ReportFunc) Option {
+	return func(a *Analyzer) {
+		a.report = fn
+	}
+}
+
+// WithShorteners replaces the list of URL shortener hosts
+func WithShorteners(hosts ...string) Option {
+	return func(a *Analyzer) {
+		a.shorteners = hosts
+	}
+}
+
+// New creates a new Analyzer
+func New(opts ...Option) *Analyzer {
+	a := &Analyzer{
+		threshold:  DefaultThreshold,
+		mode:       Warn,
+		shorteners: DefaultShorteners,
+		converter:  plaintext.New(plaintext.WithoutLinks()),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// ProcessMessage scores the message and, when the score reaches the threshold, reports it and blocks it in
+// Block mode. The message itself is never modified.
+func (a *Analyzer) ProcessMessage(ctx context.Context, msg *mailpen.Message) error {
+	report, err := a.Score(msg)
+	if err != nil {
+		return err
+	}
+
+	if report.Score < a.threshold {
+		return nil
+	}
+
+	if a.report != nil {
+		a.report(ctx, msg, report)
+	}
+
+	if a.mode == Block {
+		return fmt.Errorf("%w: score %.1f >= %.1f: %s", ErrSpamThreshold, report.Score, a.threshold,
+			strings.Join(report.Warnings(), "; "))
+	}
+
+	return nil
+}
+
+// Score evaluates every rule against the message
+func (a *Analyzer) Score(msg *mailpen.Message) (Report, error) {
+	var report Report
+	hit := func(rule, description string, score float64) {
+		report.Hits = append(report.Hits, Hit{Rule: rule, Description: description, Score: score})
+		report.Score += score
+	}
+
+	if msg.HTMLBody != "" && strings.TrimSpace(msg.TextBody) == "" {
+		hit("missing_text_part", "HTML message without a plain-text alternative", 1.0)
+	}
+
+	if isShouting(msg.Subject) {
+		hit("caps_subject", "subject is written in capital letters", 1.5)
+	}
+
+	if strings.Count(msg.Subject, "!") > 1 {
+		hit("subject_exclamations", "subject contains multiple exclamation marks", 0.5)
+	}
+
+	if msg.HTMLBody != "" {
+		text, err := a.converter.Convert(msg.HTMLBody)
+		if err != nil {
+			return Report{}, err
+		}
+
+		images := len(htmlattr.Find(msg.HTMLBody, "src", []string{"img"}))
+		textLen := len([]rune(strings.Join(strings.Fields(text), " ")))
+		switch {
+		case images > 0 && textLen < 50:
+			hit("image_only", "message consists almost entirely of images", 2.5)
+		case images > 0 && textLen/images < 200:
+			hit("image_text_ratio", fmt.Sprintf("%d images for %d characters of text", images, textLen), 1.5)
+		}
+
+		for _, href := range htmlattr.Find(msg.HTMLBody, "href", []string{"a", "area"}) {
+			if u, err := url.Parse(strings.TrimSpace(href)); err == nil && hosts.Match(u.Hostname(), a.shorteners) {
+				hit("url_shortener", fmt.Sprintf("link uses the URL shortener %s", u.Hostname()), 2.0)
+				break
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// isShouting reports whether most letters of a subject with at least eight letters are upper case
+func isShouting(subject string) bool {
+	letters, upper := 0, 0
+	for _, r := range subject {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	return letters >= 8 && float64(upper)/float64(letters) >= 0.7
+}
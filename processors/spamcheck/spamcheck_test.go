@@ -0,0 +1,92 @@
+package spamcheck_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/processors/spamcheck"
+)
+
+func rules(report spamcheck.Report) []string {
+	var names []string
+	for _, hit := range report.Hits {
+		names = append(names, hit.Rule)
+	}
+	return names
+}
+
+func TestAnalyzer_Score(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *mailpen.Message
+		want []string
+	}{
+		{
+			name: "clean message",
+			msg: &mailpen.Message{
+				Subject:  "Your receipt from ACME",
+				TextBody: "Thanks for your order.",
+				HTMLBody: "<p>" + strings.Repeat("Thanks for your order. ", 20) + `</p><img src="logo.png" alt="ACME">`,
+			},
+		},
+		{
+			name: "spammy message",
+			msg: &mailpen.Message{
+				Subject:  "FREE MONEY INSIDE!!!",
+				HTMLBody: `<a href="https://bit.ly/abc"><img src="promo.png"></a>`,
+			},
+			want: []string{"missing_text_part", "caps_subject", "subject_exclamations", "image_only", "url_shortener"},
+		},
+		{
+			name: "image heavy",
+			msg: &mailpen.Message{
+				Subject:  "Spring catalog",
+				TextBody: "See our catalog.",
+				HTMLBody: `<p>See the full spring catalog online today, with new arrivals.</p><img src="a.png"><img src="b.png">`,
+			},
+			want: []string{"image_text_ratio"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := spamcheck.New().Score(tt.msg)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.want, rules(report))
+		})
+	}
+}
+
+func TestAnalyzer_ProcessMessage(t *testing.T) {
+	spammy := &mailpen.Message{
+		Subject:  "FREE MONEY INSIDE!!!",
+		HTMLBody: `<a href="https://bit.ly/abc"><img src="promo.png"></a>`,
+	}
+
+	t.Run("warn mode reports and sends", func(t *testing.T) {
+		var reported spamcheck.Report
+		a := spamcheck.New(spamcheck.WithReportFunc(func(_ context.Context, _ *mailpen.Message, r spamcheck.Report) {
+			reported = r
+		}))
+
+		require.NoError(t, a.ProcessMessage(context.Background(), spammy))
+		assert.Equal(t, 7.5, reported.Score)
+		assert.Contains(t, reported.Warnings(), "spam: url_shortener (2.0): link uses the URL shortener bit.ly")
+	})
+
+	t.Run("block mode rejects", func(t *testing.T) {
+		a := spamcheck.New(spamcheck.WithMode(spamcheck.Block))
+		err := a.ProcessMessage(context.Background(), spammy)
+		assert.ErrorIs(t, err, spamcheck.ErrSpamThreshold)
+	})
+
+	t.Run("below threshold", func(t *testing.T) {
+		a := spamcheck.New(spamcheck.WithMode(spamcheck.Block), spamcheck.WithThreshold(10))
+		assert.NoError(t, a.ProcessMessage(context.Background(), spammy))
+	})
+}
@@ -0,0 +1,68 @@
+// Package urlresolver rewrites relative URLs in rendered HTML into absolute URLs, so templates can reference
+// paths like /img/logo.png and still work in mail clients.
+package urlresolver
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/patrickward/mailpen/internal/htmlattr"
+)
+
+// attributes lists the URL attributes that are resolved and the tags they are resolved on
+var attributes = map[string][]string{
+	"href":       {"a", "area", "link"},
+	"src":        {"img", "source", "video", "audio"},
+	"background": {"body", "table", "td", "th"},
+	"poster":     {"video"},
+}
+
+// Processor resolves relative href, src, and background URLs against a base URL. It implements the
+// mailpen.HTMLProcessor interface.
+type Processor struct {
+	base *url.URL
+}
+
+// New creates a new Processor that resolves URLs against baseURL, which is typically Config.BaseURL
+func New(baseURL string) (*Processor, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if !base.IsAbs() || base.Host == "" {
+		return nil, errors.New("base URL must be absolute")
+	}
+
+	return &Processor{base: base}, nil
+}
+
+// Process rewrites every relative URL in the HTML
+func (p *Processor) Process(html string) (string, error) {
+	var err error
+	for attr, tags := range attributes {
+		html, err = htmlattr.Replace(html, attr, tags, func(_, value string) (string, error) {
+			return p.resolve(value), nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	return html, nil
+}
+
+// resolve returns the absolute form of a relative URL, leaving absolute URLs, fragments, and unparsable values alone
+func (p *Processor) resolve(value string) string {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return value
+	}
+
+	ref, err := url.Parse(trimmed)
+	if err != nil || ref.IsAbs() {
+		return value
+	}
+
+	return p.base.ResolveReference(ref).String()
+}
@@ -0,0 +1,63 @@
+package urlresolver_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen/processors/urlresolver"
+)
+
+func TestNew(t *testing.T) {
+	_, err := urlresolver.New("/relative")
+	assert.Error(t, err)
+
+	_, err = urlresolver.New("https://example.com")
+	assert.NoError(t, err)
+}
+
+func TestProcessor_Process(t *testing.T) {
+	tests := []struct {
+		name  string
+		base  string
+		input string
+		want  string
+	}{
+		{
+			name:  "root-relative paths",
+			base:  "https://example.com",
+			input: `<a href="/account">Account</a><img src="/img/logo.png" alt="Logo">`,
+			want:  `<a href="https://example.com/account">Account</a><img src="https://example.com/img/logo.png" alt="Logo">`,
+		},
+		{
+			name:  "paths relative to a base path",
+			base:  "https://example.com/app/",
+			input: `<a href="settings?tab=email">Settings</a><td background="img/bg.png">`,
+			want:  `<a href="https://example.com/app/settings?tab=email">Settings</a><td background="https://example.com/app/img/bg.png">`,
+		},
+		{
+			name:  "protocol-relative URLs",
+			base:  "https://example.com",
+			input: `<img src="//cdn.example.com/a.png">`,
+			want:  `<img src="https://cdn.example.com/a.png">`,
+		},
+		{
+			name:  "absolute and special URLs are unchanged",
+			base:  "https://example.com",
+			input: `<a href="https://other.com/x">X</a><a href="mailto:a@example.com">M</a><a href="#top">T</a><img src="cid:logo@mailpen"><img src="data:image/png;base64,AAAA">`,
+			want:  `<a href="https://other.com/x">X</a><a href="mailto:a@example.com">M</a><a href="#top">T</a><img src="cid:logo@mailpen"><img src="data:image/png;base64,AAAA">`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := urlresolver.New(tt.base)
+			require.NoError(t, err)
+
+			got, err := p.Process(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -21,9 +21,10 @@ type Provider interface {
 
 // Capabilities defines what features a provider supports
 type Capabilities struct {
-	MaxRecipients      int
-	MaxAttachmentSize  int64
-	SupportsTemplates  bool
-	SupportsHTMLOnly   bool
-	SupportsScheduling bool
+	MaxRecipients          int
+	MaxAttachmentSize      int64 // Largest attachment in bytes (zero is unlimited)
+	MaxTotalAttachmentSize int64 // Largest total size of a message's attachments in bytes (zero is unlimited)
+	SupportsTemplates      bool
+	SupportsHTMLOnly       bool
+	SupportsScheduling     bool
 }
@@ -0,0 +1,117 @@
+// Package memory provides a mailpen.Provider that keeps sent messages in memory instead of delivering them,
+// for tests and local development.
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/patrickward/mailpen"
+)
+
+// Provider records sent messages. It is safe for concurrent use.
+type Provider struct {
+	mu      sync.Mutex
+	sent    []mailpen.Message
+	sendErr error
+}
+
+// New creates a memory provider
+func New() *Provider {
+	return &Provider{}
+}
+
+// Send records a copy of the message. Attachment data is read into memory, so the recorded attachments can
+// be read any number of times.
+func (p *Provider) Send(ctx context.Context, msg *mailpen.Message) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sendErr != nil {
+		return p.sendErr
+	}
+
+	recorded := *msg
+	recorded.Attachments = make([]mailpen.Attachment, len(msg.Attachments))
+	for i, att := range msg.Attachments {
+		if att.Data == nil {
+			return fmt.Errorf("nil reader for attachment %s", att.Filename)
+		}
+		data, err := io.ReadAll(att.Data)
+		if err != nil {
+			return fmt.Errorf("failed to read attachment %s: %w", att.Filename, err)
+		}
+		att.Data = bytes.NewReader(data)
+		recorded.Attachments[i] = att
+	}
+
+	if msg.ProviderMessageID == "" {
+		msg.ProviderMessageID = "memory-" + strconv.Itoa(len(p.sent)+1)
+	}
+	recorded.ProviderMessageID = msg.ProviderMessageID
+
+	p.sent = append(p.sent, recorded)
+	return nil
+}
+
+func (p *Provider) Name() string {
+	return "memory"
+}
+
+func (p *Provider) Validate(msg *mailpen.Message) error {
+	if len(msg.To) == 0 {
+		return mailpen.ErrNoRecipients
+	}
+	return nil
+}
+
+func (p *Provider) Capabilities() mailpen.Capabilities {
+	return mailpen.Capabilities{
+		SupportsTemplates: true,
+		SupportsHTMLOnly:  true,
+	}
+}
+
+// Messages returns copies of the sent messages, oldest first. The attachment readers of each copy start at
+// the beginning of the data.
+func (p *Provider) Messages() []mailpen.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	messages := make([]mailpen.Message, len(p.sent))
+	for i, msg := range p.sent {
+		messages[i] = msg
+		messages[i].Attachments = make([]mailpen.Attachment, len(msg.Attachments))
+		for j, att := range msg.Attachments {
+			if r, ok := att.Data.(*bytes.Reader); ok {
+				data := make([]byte, r.Size())
+				_, _ = r.ReadAt(data, 0)
+				att.Data = bytes.NewReader(data)
+			}
+			messages[i].Attachments[j] = att
+		}
+	}
+	return messages
+}
+
+// Reset removes all sent messages
+func (p *Provider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sent = nil
+}
+
+// FailWith makes every following Send return err, for testing error handling. A nil err restores sending.
+func (p *Provider) FailWith(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sendErr = err
+}
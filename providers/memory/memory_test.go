@@ -0,0 +1,63 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/providers/memory"
+)
+
+func TestProvider_Send(t *testing.T) {
+	provider := memory.New()
+
+	msg := &mailpen.Message{
+		To:       []string{"user@example.com"},
+		From:     "sender@example.com",
+		Subject:  "Receipt",
+		HTMLBody: "<p>Thanks</p>",
+		Attachments: []mailpen.Attachment{
+			{Filename: "receipt.txt", Data: strings.NewReader("total: 10"), ContentType: mailpen.TypeTextPlain},
+		},
+	}
+	require.NoError(t, provider.Send(context.Background(), msg))
+	assert.Equal(t, "memory-1", msg.ProviderMessageID)
+
+	for i := 0; i < 2; i++ {
+		messages := provider.Messages()
+		require.Len(t, messages, 1)
+		assert.Equal(t, "Receipt", messages[0].Subject)
+
+		data, err := io.ReadAll(messages[0].Attachments[0].Data)
+		require.NoError(t, err)
+		assert.Equal(t, "total: 10", string(data))
+	}
+
+	provider.Reset()
+	assert.Empty(t, provider.Messages())
+}
+
+func TestProvider_FailWith(t *testing.T) {
+	provider := memory.New()
+	msg := &mailpen.Message{To: []string{"user@example.com"}}
+
+	provider.FailWith(errors.New("mailbox full"))
+	assert.EqualError(t, provider.Send(context.Background(), msg), "mailbox full")
+	assert.Empty(t, provider.Messages())
+
+	provider.FailWith(nil)
+	require.NoError(t, provider.Send(context.Background(), msg))
+	assert.Len(t, provider.Messages(), 1)
+}
+
+func TestProvider_Validate(t *testing.T) {
+	provider := memory.New()
+	assert.Error(t, provider.Validate(&mailpen.Message{}))
+	assert.NoError(t, provider.Validate(&mailpen.Message{To: []string{"user@example.com"}}))
+}
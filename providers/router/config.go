@@ -0,0 +1,90 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/patrickward/mailpen"
+)
+
+// Config is a routing rules configuration, typically loaded from JSON:
+//
+//	{
+//	  "default": "ses",
+//	  "rules": [
+//	    {"tag": "marketing", "provider": "mailgun"},
+//	    {"domain": ["internal.example.com"], "provider": "relay"}
+//	  ]
+//	}
+type Config struct {
+	Default string       `json:"default"` // Provider used when no rule matches
+	Rules   []RuleConfig `json:"rules"`
+}
+
+// RuleConfig is a single routing rule. Every condition that is set must match; a rule without conditions
+// matches every message.
+type RuleConfig struct {
+	Provider string            `json:"provider"`
+	Tag      string            `json:"tag,omitempty"`
+	Template string            `json:"template,omitempty"`
+	Domain   []string          `json:"domain,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// LoadConfig reads a JSON routing configuration
+func LoadConfig(r io.Reader) (*Config, error) {
+	var cfg Config
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode routing config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// FromConfig creates a Router from a configuration. Providers are looked up by the names used in the
+// configuration.
+func FromConfig(cfg *Config, providers map[string]mailpen.Provider) (*Router, error) {
+	lookup := func(name string) (mailpen.Provider, error) {
+		p, ok := providers[name]
+		if !ok || p == nil {
+			return nil, fmt.Errorf("unknown provider %q", name)
+		}
+		return p, nil
+	}
+
+	fallback, err := lookup(cfg.Default)
+	if err != nil {
+		return nil, fmt.Errorf("default provider: %w", err)
+	}
+
+	var opts []Option
+	for i, rc := range cfg.Rules {
+		p, err := lookup(rc.Provider)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+		opts = append(opts, Route(rc.matcher(), p))
+	}
+
+	return New(fallback, opts...)
+}
+
+// matcher combines the conditions of a rule
+func (rc RuleConfig) matcher() Matcher {
+	var matchers []Matcher
+	if rc.Tag != "" {
+		matchers = append(matchers, Tag(rc.Tag))
+	}
+	if rc.Template != "" {
+		matchers = append(matchers, Template(rc.Template))
+	}
+	if len(rc.Domain) > 0 {
+		matchers = append(matchers, Domain(rc.Domain...))
+	}
+	for k, v := range rc.Metadata {
+		matchers = append(matchers, Metadata(k, v))
+	}
+	return All(matchers...)
+}
@@ -0,0 +1,176 @@
+// Package router provides a mailpen.Provider that selects the provider for each message by rules, such as
+// sending marketing mail through one provider and internal mail through an SMTP relay.
+package router
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/patrickward/mailpen"
+)
+
+// Matcher reports whether a rule applies to a message
+type Matcher func(msg *mailpen.Message) bool
+
+// rule routes matching messages to a provider
+type rule struct {
+	match    Matcher
+	provider mailpen.Provider
+}
+
+// Router is a mailpen.Provider that sends each message through the provider of the first matching rule, or
+// through the default provider when no rule matches
+type Router struct {
+	fallback mailpen.Provider
+	rules    []rule
+}
+
+// Option configures a Router
+type Option func(r *Router)
+
+// Route sends messages matching m through provider. Rules are checked in the order they were added.
+func Route(m Matcher, provider mailpen.Provider) Option {
+	return func(r *Router) {
+		r.rules = append(r.rules, rule{match: m, provider: provider})
+	}
+}
+
+// New creates a new Router that falls back to the given provider
+func New(fallback mailpen.Provider, opts ...Option) (*Router, error) {
+	if fallback == nil {
+		return nil, errors.New("default provider is required")
+	}
+
+	r := &Router{fallback: fallback}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	for _, rule := range r.rules {
+		if rule.match == nil || rule.provider == nil {
+			return nil, errors.New("route requires a matcher and a provider")
+		}
+	}
+
+	return r, nil
+}
+
+// Provider returns the provider a message is routed to
+func (r *Router) Provider(msg *mailpen.Message) mailpen.Provider {
+	for _, rule := range r.rules {
+		if rule.match(msg) {
+			return rule.provider
+		}
+	}
+	return r.fallback
+}
+
+// Send implements mailpen.Provider
+func (r *Router) Send(ctx context.Context, msg *mailpen.Message) error {
+	return r.Provider(msg).Send(ctx, msg)
+}
+
+// Name implements mailpen.Provider
+func (r *Router) Name() string {
+	return "router"
+}
+
+// Validate implements mailpen.Provider by validating the message against its routed provider
+func (r *Router) Validate(msg *mailpen.Message) error {
+	return r.Provider(msg).Validate(msg)
+}
+
+// Capabilities implements mailpen.Provider. It reports the capabilities every routed provider supports, since
+// Mailpen checks them before a message is routed.
+func (r *Router) Capabilities() mailpen.Capabilities {
+	caps := r.fallback.Capabilities()
+	for _, rule := range r.rules {
+		c := rule.provider.Capabilities()
+		caps.MaxRecipients = minLimit(caps.MaxRecipients, c.MaxRecipients)
+		caps.MaxAttachmentSize = minLimit(caps.MaxAttachmentSize, c.MaxAttachmentSize)
+		caps.MaxTotalAttachmentSize = minLimit(caps.MaxTotalAttachmentSize, c.MaxTotalAttachmentSize)
+		caps.SupportsTemplates = caps.SupportsTemplates && c.SupportsTemplates
+		caps.SupportsHTMLOnly = caps.SupportsHTMLOnly && c.SupportsHTMLOnly
+		caps.SupportsScheduling = caps.SupportsScheduling && c.SupportsScheduling
+	}
+	return caps
+}
+
+// minLimit returns the smaller of two limits, where zero means unlimited
+func minLimit[T int | int64](a, b T) T {
+	switch {
+	case a == 0:
+		return b
+	case b == 0:
+		return a
+	default:
+		return min(a, b)
+	}
+}
+
+// Tag matches messages with any of the given tags
+func Tag(tags ...string) Matcher {
+	return func(msg *mailpen.Message) bool {
+		return slices.ContainsFunc(msg.Tags, func(tag string) bool { return slices.Contains(tags, tag) })
+	}
+}
+
+// Template matches messages rendered from any of the given templates
+func Template(names ...string) Matcher {
+	return func(msg *mailpen.Message) bool {
+		return slices.Contains(names, msg.Template)
+	}
+}
+
+// Metadata matches messages with the given metadata value
+func Metadata(key, value string) Matcher {
+	return func(msg *mailpen.Message) bool {
+		v, ok := msg.Metadata[key]
+		return ok && v == value
+	}
+}
+
+// Domain matches messages whose recipients are all in the given domains, since a message can only be sent
+// through one provider
+func Domain(domains ...string) Matcher {
+	return func(msg *mailpen.Message) bool {
+		matched := false
+		for _, list := range [][]string{msg.To, msg.Cc, msg.Bcc} {
+			for _, addr := range list {
+				addr = mailpen.NormalizeAddress(addr)
+				domain := addr[strings.LastIndex(addr, "@")+1:]
+				if !slices.ContainsFunc(domains, func(d string) bool { return strings.EqualFold(d, domain) }) {
+					return false
+				}
+				matched = true
+			}
+		}
+		return matched
+	}
+}
+
+// All matches messages that match every matcher
+func All(matchers ...Matcher) Matcher {
+	return func(msg *mailpen.Message) bool {
+		for _, m := range matchers {
+			if !m(msg) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Any matches messages that match at least one matcher
+func Any(matchers ...Matcher) Matcher {
+	return func(msg *mailpen.Message) bool {
+		for _, m := range matchers {
+			if m(msg) {
+				return true
+			}
+		}
+		return false
+	}
+}
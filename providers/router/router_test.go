@@ -0,0 +1,142 @@
+package router_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/providers/router"
+)
+
+type fakeProvider struct {
+	name string
+	caps mailpen.Capabilities
+	sent []*mailpen.Message
+}
+
+func (p *fakeProvider) Send(_ context.Context, msg *mailpen.Message) error {
+	p.sent = append(p.sent, msg)
+	return nil
+}
+
+func (p *fakeProvider) Name() string                       { return p.name }
+func (p *fakeProvider) Validate(*mailpen.Message) error    { return nil }
+func (p *fakeProvider) Capabilities() mailpen.Capabilities { return p.caps }
+
+func TestRouter(t *testing.T) {
+	ses := &fakeProvider{name: "ses"}
+	mailgun := &fakeProvider{name: "mailgun"}
+	relay := &fakeProvider{name: "relay"}
+
+	r, err := router.New(ses,
+		router.Route(router.Tag("marketing"), mailgun),
+		router.Route(router.Domain("internal.example.com"), relay),
+		router.Route(router.All(router.Template("invoice"), router.Metadata("region", "eu")), relay),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		msg  *mailpen.Message
+		want *fakeProvider
+	}{
+		{
+			name: "tag",
+			msg:  mailpen.NewMessage().To("jane@customer.com").Tag("marketing").Must(),
+			want: mailgun,
+		},
+		{
+			name: "all recipients in domain",
+			msg:  mailpen.NewMessage().To("Ops <ops@Internal.example.com>").Cc("dev@internal.example.com").Must(),
+			want: relay,
+		},
+		{
+			name: "mixed domains use the default",
+			msg:  mailpen.NewMessage().To("ops@internal.example.com").Cc("jane@customer.com").Must(),
+			want: ses,
+		},
+		{
+			name: "combined matchers",
+			msg:  mailpen.NewMessage().To("jane@customer.com").Template("invoice").Metadata("region", "eu").Must(),
+			want: relay,
+		},
+		{
+			name: "no match",
+			msg:  mailpen.NewMessage().To("jane@customer.com").Template("invoice").Must(),
+			want: ses,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Same(t, tt.want, r.Provider(tt.msg))
+
+			before := len(tt.want.sent)
+			require.NoError(t, r.Send(context.Background(), tt.msg))
+			assert.Len(t, tt.want.sent, before+1)
+		})
+	}
+}
+
+func TestRouter_Capabilities(t *testing.T) {
+	r, err := router.New(
+		&fakeProvider{caps: mailpen.Capabilities{MaxRecipients: 50, SupportsScheduling: true, SupportsHTMLOnly: true}},
+		router.Route(router.Tag("bulk"), &fakeProvider{caps: mailpen.Capabilities{MaxRecipients: 1000, MaxAttachmentSize: 10 << 20, MaxTotalAttachmentSize: 20 << 20, SupportsHTMLOnly: true}}),
+	)
+	require.NoError(t, err)
+
+	assert.Equal(t, mailpen.Capabilities{
+		MaxRecipients:          50,
+		MaxAttachmentSize:      10 << 20,
+		MaxTotalAttachmentSize: 20 << 20,
+		SupportsHTMLOnly:       true,
+	}, r.Capabilities())
+	assert.Equal(t, "router", r.Name())
+}
+
+func TestNew_Errors(t *testing.T) {
+	_, err := router.New(nil)
+	assert.Error(t, err)
+
+	_, err = router.New(&fakeProvider{}, router.Route(router.Tag("x"), nil))
+	assert.Error(t, err)
+}
+
+func TestFromConfig(t *testing.T) {
+	ses := &fakeProvider{name: "ses"}
+	mailgun := &fakeProvider{name: "mailgun"}
+	relay := &fakeProvider{name: "relay"}
+	providers := map[string]mailpen.Provider{"ses": ses, "mailgun": mailgun, "relay": relay}
+
+	cfg, err := router.LoadConfig(strings.NewReader(`{
+		"default": "ses",
+		"rules": [
+			{"tag": "marketing", "provider": "mailgun"},
+			{"domain": ["internal.example.com"], "provider": "relay"},
+			{"template": "receipt", "metadata": {"region": "eu"}, "provider": "relay"}
+		]
+	}`))
+	require.NoError(t, err)
+
+	r, err := router.FromConfig(cfg, providers)
+	require.NoError(t, err)
+
+	assert.Same(t, mailgun, r.Provider(mailpen.NewMessage().To("a@customer.com").Tag("marketing").Must()))
+	assert.Same(t, relay, r.Provider(mailpen.NewMessage().To("a@internal.example.com").Must()))
+	assert.Same(t, relay, r.Provider(mailpen.NewMessage().To("a@customer.com").Template("receipt").Metadata("region", "eu").Must()))
+	assert.Same(t, ses, r.Provider(mailpen.NewMessage().To("a@customer.com").Template("receipt").Must()))
+
+	t.Run("unknown provider", func(t *testing.T) {
+		_, err := router.FromConfig(&router.Config{Default: "ses", Rules: []router.RuleConfig{{Provider: "postmark"}}}, providers)
+		assert.ErrorContains(t, err, `unknown provider "postmark"`)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		_, err := router.LoadConfig(strings.NewReader(`{"default": "ses", "routes": []}`))
+		assert.Error(t, err)
+	})
+}
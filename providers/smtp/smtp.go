@@ -1,8 +1,13 @@
 package smtp
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
 	"time"
 
 	gomail "github.com/wneessen/go-mail"
@@ -15,20 +20,44 @@ type Client interface {
 	DialAndSend(messages ...*gomail.Msg) error
 }
 
+// ContextClient is implemented by clients that abort a send when its context is done, such as *gomail.Client
+type ContextClient interface {
+	DialAndSendWithContext(ctx context.Context, messages ...*gomail.Msg) error
+}
+
 // Config holds SMTP-specific configuration
 type Config struct {
-	Host      string
-	Port      int
-	Username  string
-	Password  string
-	AuthType  string // Type of SMTP authentication
-	TLSPolicy int    // TLS policy for the SMTP connection
-
-	// Retry configuration
+	Host      string `env:"HOST"`
+	Port      int    `env:"PORT"`
+	Username  string `env:"USERNAME"`
+	Password  string `env:"PASSWORD"`
+	AuthType  string `env:"AUTH"` // Type of SMTP authentication
+	TLSPolicy int    `env:"TLS"`  // TLS policy for the SMTP connection
+
+	// Timeout limits connecting to and talking with the server (defaults to 10 seconds). The send context's
+	// deadline, such as mailpen.Config.SendTimeout, also applies.
+	Timeout time.Duration `env:"TIMEOUT"`
+
+	// Deprecated: Configure retries with mailpen.Config.RetryPolicy. When no policy is set there, a RetryCount
+	// above 1 becomes the default policy: up to RetryCount attempts, RetryDelay apart, for every error as before.
 	RetryCount int
+	// Deprecated: Configure retries with mailpen.Config.RetryPolicy.
 	RetryDelay time.Duration
 }
 
+// EnvPrefix is the prefix of the environment variables read by LoadConfigFromEnv
+const EnvPrefix = mailpen.EnvPrefix + "SMTP_"
+
+// LoadConfigFromEnv returns a Config read from MAILPEN_SMTP_HOST, MAILPEN_SMTP_PORT, MAILPEN_SMTP_USERNAME,
+// MAILPEN_SMTP_PASSWORD, MAILPEN_SMTP_AUTH, MAILPEN_SMTP_TLS, and MAILPEN_SMTP_TIMEOUT
+func LoadConfigFromEnv() (*Config, error) {
+	config := &Config{}
+	if err := mailpen.LoadEnv(EnvPrefix, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
 type Provider struct {
 	client Client
 	config *Config
@@ -43,22 +72,30 @@ func WithClient(client Client) Option {
 	}
 }
 
+// WithLogger was used to report retried sends.
+//
+// Deprecated: Retries are made and logged by Mailpen, using mailpen.Config.Logger. WithLogger does nothing.
+func WithLogger(*slog.Logger) Option {
+	return func(*Provider) {}
+}
+
 // New creates a new SMTP provider
 func New(config *Config, opts ...Option) (*Provider, error) {
 	if config == nil {
 		return nil, fmt.Errorf("config is required")
 	}
 
-	if config.RetryCount == 0 {
-		config.RetryCount = 1
-	}
-
 	authType := authTypeFromString(config.AuthType)
 	tlsPolicy := tlsPolicyFromInt(config.TLSPolicy)
 
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
 	client, err := gomail.NewClient(
 		config.Host,
-		gomail.WithTimeout(10*time.Second),
+		gomail.WithTimeout(timeout),
 		gomail.WithSMTPAuth(authType),
 		gomail.WithPort(config.Port),
 		gomail.WithUsername(config.Username),
@@ -83,42 +120,101 @@ func New(config *Config, opts ...Option) (*Provider, error) {
 
 // Send implements mailpen.Provider
 func (p *Provider) Send(ctx context.Context, msg *mailpen.Message) error {
+	email, err := p.build(msg)
+	if err != nil {
+		return err
+	}
+
+	if err := p.send(ctx, email); err != nil {
+		return err
+	}
+
+	msg.ProviderMessageID = email.GetMessageID()
+	return nil
+}
+
+// EML returns the message as it would be sent over SMTP, in RFC 5322 format, for tools that inspect raw
+// messages such as spam checkers
+func EML(msg *mailpen.Message) ([]byte, error) {
+	var p Provider
+	email, err := p.build(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if _, err := email.WriteTo(&buf); err != nil {
+		return nil, fmt.Errorf("failed to write message: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// build converts a message into a go-mail message
+func (p *Provider) build(msg *mailpen.Message) (*gomail.Msg, error) {
 	email := gomail.NewMsg()
 	email.Subject(msg.Subject)
+	for key, value := range msg.Headers {
+		email.SetGenHeader(gomail.Header(key), value)
+	}
 
 	if err := p.setAddresses(email, msg); err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := p.setBodies(email, msg); err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := p.addAttachments(email, msg.Attachments); err != nil {
-		return err
+		return nil, err
+	}
+
+	if email.GetMessageID() == "" {
+		email.SetMessageID()
 	}
 
-	return p.sendWithRetry(email)
+	return email, nil
 }
 
 func (p *Provider) Name() string {
 	return "smtp"
 }
 
+// DefaultRetryPolicy implements mailpen.RetryPolicyProvider, keeping the deprecated RetryCount and RetryDelay
+// working when Mailpen has no retry policy of its own. It returns nil when RetryCount allows a single attempt.
+func (p *Provider) DefaultRetryPolicy() mailpen.RetryPolicy {
+	if p.config.RetryCount <= 1 {
+		return nil
+	}
+	return fixedRetry{attempts: p.config.RetryCount, delay: p.config.RetryDelay}
+}
+
+// fixedRetry retries a fixed number of times with a fixed delay. Like the retry loop RetryCount used to
+// configure, it retries every error, including permanent ones that mailpen.IsRetryable rejects.
+type fixedRetry struct {
+	attempts int
+	delay    time.Duration
+}
+
+func (r fixedRetry) Retry(attempt int, _ error) (time.Duration, bool) {
+	return r.delay, attempt < r.attempts
+}
+
 func (p *Provider) Validate(msg *mailpen.Message) error {
 	if len(msg.To) == 0 {
-		return fmt.Errorf("at least one recipient is required")
+		return mailpen.ErrNoRecipients
 	}
 	return nil
 }
 
 func (p *Provider) Capabilities() mailpen.Capabilities {
 	return mailpen.Capabilities{
-		MaxRecipients:      1000,
-		MaxAttachmentSize:  25 * 1024 * 1024,
-		SupportsTemplates:  true,
-		SupportsHTMLOnly:   true,
-		SupportsScheduling: false,
+		MaxRecipients:          1000,
+		MaxAttachmentSize:      25 * 1024 * 1024,
+		MaxTotalAttachmentSize: 25 * 1024 * 1024,
+		SupportsTemplates:      true,
+		SupportsHTMLOnly:       true,
+		SupportsScheduling:     false,
 	}
 }
 
@@ -134,6 +230,14 @@ func (p *Provider) addAttachments(email *gomail.Msg, attachments []mailpen.Attac
 			return fmt.Errorf("nil reader for attachment %s", att.Filename)
 		}
 
+		if att.ContentID != "" {
+			opts = append(opts, gomail.WithFileContentID(att.ContentID))
+			if err := email.EmbedReader(att.Filename, att.Data, opts...); err != nil {
+				return fmt.Errorf("failed to embed file %s: %w", att.Filename, err)
+			}
+			continue
+		}
+
 		if err := email.AttachReader(att.Filename, att.Data, opts...); err != nil {
 			return fmt.Errorf("failed to attach file %s: %w", att.Filename, err)
 		}
@@ -189,21 +293,55 @@ func (p *Provider) setBodies(email *gomail.Msg, msg *mailpen.Message) error {
 	return nil
 }
 
-// sendWithRetry sends the email with retries
-func (p *Provider) sendWithRetry(email *gomail.Msg) error {
-	var lastErr error
-	for i := 0; i < p.config.RetryCount; i++ {
-		if err := p.client.DialAndSend(email); err != nil {
-			lastErr = err
-			if i < p.config.RetryCount-1 {
-				time.Sleep(p.config.RetryDelay)
-				continue
-			}
-		} else {
-			return nil
+// send delivers the email, honoring the context when the client supports it. Retries are handled by
+// Mailpen's retry policy; SMTP send errors report whether they are temporary, which the default policy uses
+// to decide whether to retry.
+func (p *Provider) send(ctx context.Context, email *gomail.Msg) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	var err error
+	if client, ok := p.client.(ContextClient); ok {
+		err = client.DialAndSendWithContext(ctx, email)
+	} else {
+		err = p.client.DialAndSend(email)
+	}
+	if err != nil {
+		if unavailable(err) {
+			return fmt.Errorf("failed to send email: %w: %w", mailpen.ErrProviderUnavailable, err)
 		}
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// CheckHealth implements mailpen.HealthChecker by opening a TCP connection to the server, without
+// authenticating or sending. Failures wrap mailpen.ErrProviderUnavailable.
+func (p *Provider) CheckHealth(ctx context.Context) error {
+	port := p.config.Port
+	if port == 0 {
+		port = 25
 	}
-	return fmt.Errorf("failed to send email after %d attempts: %w", p.config.RetryCount, lastErr)
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(p.config.Host, strconv.Itoa(port)))
+	if err != nil {
+		return fmt.Errorf("%w: %w", mailpen.ErrProviderUnavailable, err)
+	}
+	return conn.Close()
+}
+
+// unavailable reports whether a send error means the SMTP server could not be reached: a network error,
+// such as a refused connection or failed DNS lookup, or a failed connection check
+func unavailable(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var sendErr *gomail.SendError
+	return errors.As(err, &sendErr) && sendErr.Reason == gomail.ErrConnCheck
 }
 
 // authTypeFromString converts a string to a gomail.SMTPAuthType
@@ -2,6 +2,8 @@ package smtp_test
 
 import (
 	"context"
+	"errors"
+	"net"
 	"strings"
 	"testing"
 	"time"
@@ -70,12 +72,10 @@ func TestProvider_Send(t *testing.T) {
 			},
 		},
 		{
-			name: "retry on failure",
+			name: "send failure is not retried",
 			config: &smtp.Config{
-				Host:       "smtp.example.com",
-				Port:       587,
-				RetryCount: 3,
-				RetryDelay: time.Millisecond,
+				Host: "smtp.example.com",
+				Port: 587,
 			},
 			message: &mailpen.Message{
 				From:    "sender@example.com",
@@ -86,10 +86,10 @@ func TestProvider_Send(t *testing.T) {
 				m.err = &gomail.SendError{}
 			},
 			verify: func(t *testing.T, m *mockSMTPClient) {
-				assert.Equal(t, 3, m.sendCalls) // Verifies retry attempts
+				assert.Equal(t, 1, m.sendCalls) // Retries are left to Mailpen's retry policy
 			},
 			wantErr:    true,
-			errMessage: "failed to send email after 3 attempts",
+			errMessage: "failed to send email",
 		},
 		{
 			name: "with attachments",
@@ -114,6 +114,52 @@ func TestProvider_Send(t *testing.T) {
 				require.Len(t, m.messages, 1)
 			},
 		},
+		{
+			name: "with custom headers",
+			config: &smtp.Config{
+				Host: "smtp.example.com",
+				Port: 587,
+			},
+			message: &mailpen.Message{
+				From:     "sender@example.com",
+				To:       []string{"recipient@example.com"},
+				Subject:  "Test Email",
+				TextBody: "Hello World",
+				Headers:  map[string]string{"X-Campaign": "spring"},
+			},
+			verify: func(t *testing.T, m *mockSMTPClient) {
+				require.Len(t, m.messages, 1)
+				assert.Equal(t, []string{"spring"}, m.messages[0].GetGenHeader("X-Campaign"))
+			},
+		},
+		{
+			name: "with inline attachments",
+			config: &smtp.Config{
+				Host: "smtp.example.com",
+				Port: 587,
+			},
+			message: &mailpen.Message{
+				From:     "sender@example.com",
+				To:       []string{"recipient@example.com"},
+				Subject:  "Test Email",
+				HTMLBody: `<img src="cid:logo@example.com">`,
+				Attachments: []mailpen.Attachment{
+					{
+						Filename:    "logo.png",
+						Data:        strings.NewReader("png"),
+						ContentType: "image/png",
+						ContentID:   "logo@example.com",
+					},
+				},
+			},
+			verify: func(t *testing.T, m *mockSMTPClient) {
+				require.Len(t, m.messages, 1)
+				embeds := m.messages[0].GetEmbeds()
+				require.Len(t, embeds, 1)
+				assert.Empty(t, m.messages[0].GetAttachments())
+				assert.Equal(t, "logo@example.com", embeds[0].Header.Get("Content-ID"))
+			},
+		},
 		{
 			name: "with cc and bcc",
 			config: &smtp.Config{
@@ -169,6 +215,165 @@ func TestProvider_Send(t *testing.T) {
 	}
 }
 
+func TestProvider_SendSetsProviderMessageID(t *testing.T) {
+	mock := &mockSMTPClient{}
+	provider, err := smtp.New(&smtp.Config{Host: "smtp.example.com", Port: 587}, smtp.WithClient(mock))
+	require.NoError(t, err)
+
+	msg := &mailpen.Message{From: "sender@example.com", To: []string{"recipient@example.com"}, Subject: "Test"}
+	require.NoError(t, provider.Send(context.Background(), msg))
+	require.Len(t, mock.messages, 1)
+	assert.NotEmpty(t, msg.ProviderMessageID)
+	assert.Equal(t, mock.messages[0].GetMessageID(), msg.ProviderMessageID)
+
+	msg = &mailpen.Message{
+		From:    "sender@example.com",
+		To:      []string{"recipient@example.com"},
+		Subject: "Test",
+		Headers: map[string]string{"Message-ID": "<custom@example.com>"},
+	}
+	require.NoError(t, provider.Send(context.Background(), msg))
+	assert.Equal(t, "<custom@example.com>", msg.ProviderMessageID)
+}
+
+// contextSMTPClient records the context it is sent with
+type contextSMTPClient struct {
+	mockSMTPClient
+	ctx context.Context
+}
+
+func (c *contextSMTPClient) DialAndSendWithContext(ctx context.Context, messages ...*gomail.Msg) error {
+	c.ctx = ctx
+	return c.DialAndSend(messages...)
+}
+
+func TestProvider_SendContext(t *testing.T) {
+	msg := &mailpen.Message{From: "sender@example.com", To: []string{"recipient@example.com"}, Subject: "Test"}
+
+	t.Run("context client receives the context", func(t *testing.T) {
+		client := &contextSMTPClient{}
+		provider, err := smtp.New(&smtp.Config{Host: "smtp.example.com", Port: 587}, smtp.WithClient(client))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		require.NoError(t, provider.Send(ctx, msg))
+		require.NotNil(t, client.ctx)
+		_, ok := client.ctx.Deadline()
+		assert.True(t, ok)
+	})
+
+	t.Run("done context is not sent", func(t *testing.T) {
+		client := &mockSMTPClient{}
+		provider, err := smtp.New(&smtp.Config{Host: "smtp.example.com", Port: 587}, smtp.WithClient(client))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		assert.ErrorIs(t, provider.Send(ctx, msg), context.Canceled)
+		assert.Equal(t, 0, client.sendCalls)
+	})
+}
+
+func TestProvider_SendErrors(t *testing.T) {
+	msg := &mailpen.Message{From: "sender@example.com", To: []string{"recipient@example.com"}, Subject: "Test"}
+
+	tests := []struct {
+		name            string
+		err             error
+		wantUnavailable bool
+	}{
+		{
+			name:            "connection refused",
+			err:             &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")},
+			wantUnavailable: true,
+		},
+		{
+			name:            "DNS failure",
+			err:             &net.DNSError{Err: "no such host", Name: "smtp.example.com"},
+			wantUnavailable: true,
+		},
+		{
+			name: "rejected recipient",
+			err:  &gomail.SendError{Reason: gomail.ErrSMTPRcptTo},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := smtp.New(&smtp.Config{Host: "smtp.example.com", Port: 587}, smtp.WithClient(&mockSMTPClient{err: tt.err}))
+			require.NoError(t, err)
+
+			err = provider.Send(context.Background(), msg)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.wantUnavailable, errors.Is(err, mailpen.ErrProviderUnavailable))
+		})
+	}
+}
+
+func TestProvider_DeprecatedRetryCount(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    *smtp.Config
+		policy    mailpen.RetryPolicy
+		sendErr   error
+		wantCalls int
+	}{
+		{
+			name:      "retry count becomes the default policy",
+			config:    &smtp.Config{Host: "smtp.example.com", Port: 587, RetryCount: 3, RetryDelay: time.Millisecond},
+			wantCalls: 3,
+		},
+		{
+			name:      "retry count retries permanent errors as before",
+			config:    &smtp.Config{Host: "smtp.example.com", Port: 587, RetryCount: 3, RetryDelay: time.Millisecond},
+			sendErr:   mailpen.Permanent(errors.New("550 mailbox unavailable")),
+			wantCalls: 3,
+		},
+		{
+			name:      "no retry count sends once",
+			config:    &smtp.Config{Host: "smtp.example.com", Port: 587},
+			wantCalls: 1,
+		},
+		{
+			name:      "configured policy wins",
+			config:    &smtp.Config{Host: "smtp.example.com", Port: 587, RetryCount: 3},
+			policy:    mailpen.ExponentialRetry{MaxAttempts: 2, InitialDelay: time.Millisecond},
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sendErr := tt.sendErr
+			if sendErr == nil {
+				sendErr = errors.New("connection reset")
+			}
+			client := &mockSMTPClient{err: sendErr}
+			provider, err := smtp.New(tt.config, smtp.WithClient(client))
+			require.NoError(t, err)
+
+			mp, err := mailpen.New(provider, &mailpen.Config{From: "sender@example.com", RetryPolicy: tt.policy})
+			require.NoError(t, err)
+
+			msg := mailpen.NewMessage().To("recipient@example.com").Subject("Test").Must()
+			require.Error(t, mp.Send(context.Background(), msg))
+			assert.Equal(t, tt.wantCalls, client.sendCalls)
+		})
+	}
+}
+
+func TestProvider_Validate(t *testing.T) {
+	provider, err := smtp.New(&smtp.Config{Host: "smtp.example.com", Port: 587})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, provider.Validate(&mailpen.Message{}), mailpen.ErrNoRecipients)
+	assert.NoError(t, provider.Validate(&mailpen.Message{To: []string{"recipient@example.com"}}))
+}
+
 func TestNew(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -219,3 +424,66 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestEML(t *testing.T) {
+	eml, err := smtp.EML(&mailpen.Message{
+		From:     "sender@example.com",
+		To:       []string{"recipient@example.com"},
+		Subject:  "Welcome",
+		TextBody: "Hello",
+		HTMLBody: "<p>Hello</p>",
+		Headers:  map[string]string{"X-Campaign": "welcome"},
+	})
+	require.NoError(t, err)
+
+	raw := string(eml)
+	assert.Contains(t, raw, "Subject: Welcome\r\n")
+	assert.Contains(t, raw, "To: <recipient@example.com>\r\n")
+	assert.Contains(t, raw, "X-Campaign: welcome\r\n")
+	assert.Contains(t, raw, "Message-ID: <")
+	assert.Contains(t, raw, "multipart/alternative")
+	assert.Contains(t, raw, "<p>Hello</p>")
+
+	_, err = smtp.EML(&mailpen.Message{From: "not an address", To: []string{"recipient@example.com"}})
+	assert.ErrorContains(t, err, "failed to set from address")
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Setenv("MAILPEN_SMTP_HOST", "smtp.example.com")
+	t.Setenv("MAILPEN_SMTP_PORT", "587")
+	t.Setenv("MAILPEN_SMTP_USERNAME", "apikey")
+	t.Setenv("MAILPEN_SMTP_PASSWORD", "secret")
+	t.Setenv("MAILPEN_SMTP_AUTH", "PLAIN")
+	t.Setenv("MAILPEN_SMTP_TLS", "2")
+	t.Setenv("MAILPEN_SMTP_TIMEOUT", "30s")
+
+	config, err := smtp.LoadConfigFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, &smtp.Config{
+		Host:      "smtp.example.com",
+		Port:      587,
+		Username:  "apikey",
+		Password:  "secret",
+		AuthType:  "PLAIN",
+		TLSPolicy: 2,
+		Timeout:   30 * time.Second,
+	}, config)
+
+	t.Setenv("MAILPEN_SMTP_PORT", "smtp")
+	_, err = smtp.LoadConfigFromEnv()
+	assert.ErrorIs(t, err, mailpen.ErrInvalidConfig)
+	assert.ErrorContains(t, err, "MAILPEN_SMTP_PORT")
+}
+
+func TestProvider_CheckHealth(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().(*net.TCPAddr)
+
+	provider, err := smtp.New(&smtp.Config{Host: "127.0.0.1", Port: addr.Port})
+	require.NoError(t, err)
+	assert.NoError(t, provider.CheckHealth(context.Background()))
+
+	require.NoError(t, listener.Close())
+	assert.ErrorIs(t, provider.CheckHealth(context.Background()), mailpen.ErrProviderUnavailable)
+}
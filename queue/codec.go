@@ -0,0 +1,164 @@
+package queue
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/patrickward/mailpen"
+)
+
+// jobRecord is the serialized form of a Job
+type jobRecord struct {
+	ID          string        `json:"id"`
+	Message     messageRecord `json:"message"`
+	Attempts    int           `json:"attempts"`
+	MaxAttempts int           `json:"max_attempts,omitempty"`
+	EnqueuedAt  time.Time     `json:"enqueued_at"`
+	NotBefore   time.Time     `json:"not_before,omitempty"`
+	LastError   string        `json:"last_error,omitempty"`
+	Priority    Priority      `json:"priority,omitempty"`
+}
+
+// messageRecord is the serialized form of a mailpen.Message, with attachment data read into memory
+type messageRecord struct {
+	ID          string             `json:"id,omitempty"`
+	From        string             `json:"from,omitempty"`
+	To          []string           `json:"to,omitempty"`
+	Cc          []string           `json:"cc,omitempty"`
+	Bcc         []string           `json:"bcc,omitempty"`
+	ReplyTo     string             `json:"reply_to,omitempty"`
+	Subject     string             `json:"subject,omitempty"`
+	Data        map[string]any     `json:"data,omitempty"`
+	Layout      string             `json:"layout,omitempty"`
+	Template    string             `json:"template,omitempty"`
+	TextBody    string             `json:"text_body,omitempty"`
+	HTMLBody    string             `json:"html_body,omitempty"`
+	Attachments []attachmentRecord `json:"attachments,omitempty"`
+	Metadata    map[string]string  `json:"metadata,omitempty"`
+	Locale      string             `json:"locale,omitempty"`
+	Tags        []string           `json:"tags,omitempty"`
+	Headers     map[string]string  `json:"headers,omitempty"`
+	SendAt      time.Time          `json:"send_at,omitempty"`
+	SkipArchive bool               `json:"skip_archive,omitempty"`
+}
+
+// attachmentRecord is the serialized form of a mailpen.Attachment
+type attachmentRecord struct {
+	Filename    string              `json:"filename"`
+	ContentType mailpen.ContentType `json:"content_type,omitempty"`
+	ContentID   string              `json:"content_id,omitempty"`
+	Data        []byte              `json:"data"`
+}
+
+// MarshalJob serializes a job to JSON for stores that persist jobs outside the process. Attachment readers
+// are consumed and replaced with in-memory readers over the same data. Template data is encoded as JSON, so
+// numbers decode as float64 and custom types decode as maps.
+func MarshalJob(job *Job) ([]byte, error) {
+	msg := job.Message
+	if msg == nil {
+		return nil, fmt.Errorf("job %s has no message", job.ID)
+	}
+
+	rec := jobRecord{
+		ID:          job.ID,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		EnqueuedAt:  job.EnqueuedAt,
+		NotBefore:   job.NotBefore,
+		LastError:   job.LastError,
+		Priority:    job.Priority,
+		Message: messageRecord{
+			ID:          msg.ID,
+			From:        msg.From,
+			To:          msg.To,
+			Cc:          msg.Cc,
+			Bcc:         msg.Bcc,
+			ReplyTo:     msg.ReplyTo,
+			Subject:     msg.Subject,
+			Data:        msg.Data,
+			Layout:      msg.Layout,
+			Template:    msg.Template,
+			TextBody:    msg.TextBody,
+			HTMLBody:    msg.HTMLBody,
+			Metadata:    msg.Metadata,
+			Locale:      msg.Locale,
+			Tags:        msg.Tags,
+			Headers:     msg.Headers,
+			SendAt:      msg.SendAt,
+			SkipArchive: msg.SkipArchive,
+		},
+	}
+
+	for i, att := range msg.Attachments {
+		var data []byte
+		if att.Data != nil {
+			var err error
+			if data, err = io.ReadAll(att.Data); err != nil {
+				return nil, fmt.Errorf("failed to read attachment %s: %w", att.Filename, err)
+			}
+			msg.Attachments[i].Data = bytes.NewReader(data)
+		}
+
+		rec.Message.Attachments = append(rec.Message.Attachments, attachmentRecord{
+			Filename:    att.Filename,
+			ContentType: att.ContentType,
+			ContentID:   att.ContentID,
+			Data:        data,
+		})
+	}
+
+	return json.Marshal(rec)
+}
+
+// UnmarshalJob deserializes a job encoded with MarshalJob
+func UnmarshalJob(data []byte) (*Job, error) {
+	var rec jobRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("failed to decode job: %w", err)
+	}
+
+	m := rec.Message
+	msg := &mailpen.Message{
+		ID:          m.ID,
+		From:        m.From,
+		To:          m.To,
+		Cc:          m.Cc,
+		Bcc:         m.Bcc,
+		ReplyTo:     m.ReplyTo,
+		Subject:     m.Subject,
+		Data:        m.Data,
+		Layout:      m.Layout,
+		Template:    m.Template,
+		TextBody:    m.TextBody,
+		HTMLBody:    m.HTMLBody,
+		Metadata:    m.Metadata,
+		Locale:      m.Locale,
+		Tags:        m.Tags,
+		Headers:     m.Headers,
+		SendAt:      m.SendAt,
+		SkipArchive: m.SkipArchive,
+	}
+
+	for _, att := range m.Attachments {
+		msg.Attachments = append(msg.Attachments, mailpen.Attachment{
+			Filename:    att.Filename,
+			Data:        bytes.NewReader(att.Data),
+			ContentType: att.ContentType,
+			ContentID:   att.ContentID,
+		})
+	}
+
+	return &Job{
+		ID:          rec.ID,
+		Message:     msg,
+		Attempts:    rec.Attempts,
+		MaxAttempts: rec.MaxAttempts,
+		EnqueuedAt:  rec.EnqueuedAt,
+		NotBefore:   rec.NotBefore,
+		LastError:   rec.LastError,
+		Priority:    rec.Priority,
+	}, nil
+}
@@ -0,0 +1,83 @@
+package queue_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/queue"
+)
+
+func TestMarshalJob(t *testing.T) {
+	msg := mailpen.NewMessage().
+		To("recipient@example.com").
+		Cc("cc@example.com").
+		Subject("Invoice").
+		Template("invoice").
+		WithData(map[string]any{"Total": 42}).
+		Metadata("campaign", "billing").
+		Tag("transactional").
+		Header("X-Campaign", "billing").
+		SendAt(time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC)).
+		SkipArchive().
+		Embed("logo.png", "logo", strings.NewReader("png-data"), "image/png").
+		Must()
+
+	job := &queue.Job{
+		ID:          "job-1",
+		Message:     msg,
+		Attempts:    2,
+		MaxAttempts: 5,
+		EnqueuedAt:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		LastError:   "timeout",
+		Priority:    queue.PriorityHigh,
+	}
+
+	data, err := queue.MarshalJob(job)
+	require.NoError(t, err)
+
+	// The original attachment is still readable after marshaling
+	original, err := io.ReadAll(msg.Attachments[0].Data)
+	require.NoError(t, err)
+	assert.Equal(t, "png-data", string(original))
+
+	got, err := queue.UnmarshalJob(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, "job-1", got.ID)
+	assert.Equal(t, 2, got.Attempts)
+	assert.Equal(t, 5, got.MaxAttempts)
+	assert.True(t, job.EnqueuedAt.Equal(got.EnqueuedAt))
+	assert.Equal(t, queue.PriorityHigh, got.Priority)
+	assert.Equal(t, "timeout", got.LastError)
+
+	assert.Equal(t, msg.To, got.Message.To)
+	assert.Equal(t, msg.Cc, got.Message.Cc)
+	assert.Equal(t, "Invoice", got.Message.Subject)
+	assert.Equal(t, "invoice", got.Message.Template)
+	assert.Equal(t, float64(42), got.Message.Data["Total"])
+	assert.Equal(t, "billing", got.Message.Metadata["campaign"])
+	assert.Equal(t, []string{"transactional"}, got.Message.Tags)
+	assert.Equal(t, "billing", got.Message.Headers["X-Campaign"])
+	assert.True(t, msg.SendAt.Equal(got.Message.SendAt))
+	assert.True(t, got.Message.SkipArchive)
+
+	require.Len(t, got.Message.Attachments, 1)
+	att := got.Message.Attachments[0]
+	assert.Equal(t, "logo.png", att.Filename)
+	assert.Equal(t, "logo", att.ContentID)
+	assert.Equal(t, mailpen.ContentType("image/png"), att.ContentType)
+	content, err := io.ReadAll(att.Data)
+	require.NoError(t, err)
+	assert.Equal(t, "png-data", string(content))
+}
+
+func TestUnmarshalJob_Invalid(t *testing.T) {
+	_, err := queue.UnmarshalJob([]byte("not json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,130 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+
+	"github.com/patrickward/mailpen"
+)
+
+// Priority classifies jobs so that transactional mail is not held up by bulk mail. Higher priorities are
+// delivered first.
+type Priority int
+
+const (
+	PriorityBulk   Priority = -1 // Newsletters, digests, and other batch mail
+	PriorityNormal Priority = 0  // Default priority
+	PriorityHigh   Priority = 1  // Password resets, receipts, and other transactional mail
+)
+
+// String returns the name of the priority
+func (p Priority) String() string {
+	switch p {
+	case PriorityBulk:
+		return "bulk"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	default:
+		return "priority(" + strconv.Itoa(int(p)) + ")"
+	}
+}
+
+// lane is a store and the workers dedicated to jobs of one priority
+type lane struct {
+	priority Priority
+	store    Store
+	workers  int
+}
+
+// WithLane gives jobs of a priority their own store and workers, so jobs in other lanes never delay them.
+// Jobs whose priority has no lane use the default lane, made of the store passed to New and the workers set
+// with WithWorkers. A lane for PriorityNormal replaces the default lane.
+func WithLane(priority Priority, store Store, workers int) Option {
+	return func(q *Queue) {
+		if workers < 1 {
+			workers = 1
+		}
+		q.lanes = slices.DeleteFunc(q.lanes, func(l *lane) bool { return l.priority == priority })
+		q.lanes = append(q.lanes, &lane{priority: priority, store: store, workers: workers})
+	}
+}
+
+// WithPriorityFunc sets the function Enqueue uses to pick a message's priority. Messages are PriorityNormal
+// by default.
+func WithPriorityFunc(fn func(msg *mailpen.Message) Priority) Option {
+	return func(q *Queue) {
+		q.classify = fn
+	}
+}
+
+// ByTag returns a priority function that uses the priority of the first message tag found in tags, or
+// PriorityNormal if none is found
+func ByTag(tags map[string]Priority) func(msg *mailpen.Message) Priority {
+	return func(msg *mailpen.Message) Priority {
+		for _, tag := range msg.Tags {
+			if p, ok := tags[tag]; ok {
+				return p
+			}
+		}
+		return PriorityNormal
+	}
+}
+
+// addDefaultLane adds the default lane unless a PriorityNormal lane was configured, and orders the lanes from
+// highest to lowest priority
+func (q *Queue) addDefaultLane() {
+	if !slices.ContainsFunc(q.lanes, func(l *lane) bool { return l.priority == PriorityNormal }) {
+		q.lanes = append(q.lanes, &lane{priority: PriorityNormal, store: q.store, workers: q.workers})
+	}
+
+	slices.SortFunc(q.lanes, func(a, b *lane) int { return int(b.priority) - int(a.priority) })
+}
+
+// laneFor returns the lane for a priority, falling back to the PriorityNormal lane
+func (q *Queue) laneFor(p Priority) *lane {
+	var fallback *lane
+	for _, l := range q.lanes {
+		if l.priority == p {
+			return l
+		}
+		if l.priority == PriorityNormal {
+			fallback = l
+		}
+	}
+	return fallback
+}
+
+// pendingLister lists the pending jobs of every lane. It reports false if any lane's store cannot list them.
+func (q *Queue) pendingLister() (PendingLister, bool) {
+	listers := make(laneListers, 0, len(q.lanes))
+	for _, l := range q.lanes {
+		lister, ok := l.store.(PendingLister)
+		if !ok {
+			return nil, false
+		}
+		listers = append(listers, lister)
+	}
+	return listers, true
+}
+
+// laneListers combines the pending jobs of several stores
+type laneListers []PendingLister
+
+// Pending implements PendingLister
+func (ls laneListers) Pending(ctx context.Context) ([]*Job, error) {
+	var jobs []*Job
+	var errs []error
+	for _, l := range ls {
+		pending, err := l.Pending(ctx)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		jobs = append(jobs, pending...)
+	}
+	return jobs, errors.Join(errs...)
+}
@@ -0,0 +1,87 @@
+package queue_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/queue"
+)
+
+func TestMemoryStore_Priority(t *testing.T) {
+	store := queue.NewMemoryStore(0)
+	ctx := context.Background()
+
+	require.NoError(t, store.Push(ctx, &queue.Job{ID: "bulk", Priority: queue.PriorityBulk}))
+	require.NoError(t, store.Push(ctx, &queue.Job{ID: "normal"}))
+	require.NoError(t, store.Push(ctx, &queue.Job{ID: "high", Priority: queue.PriorityHigh}))
+	require.NoError(t, store.Push(ctx, &queue.Job{ID: "later", Priority: queue.PriorityHigh, NotBefore: time.Now().Add(time.Hour)}))
+
+	for _, want := range []string{"high", "normal", "bulk"} {
+		job, err := store.Pop(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, want, job.ID)
+	}
+}
+
+func TestQueue_Lanes(t *testing.T) {
+	release := make(chan struct{})
+	bulkStarted := make(chan struct{}, 1)
+	sender := &mockSender{}
+	blocking := &laneSender{next: sender, release: release, started: bulkStarted}
+
+	bulk := queue.NewMemoryStore(0)
+	high := queue.NewMemoryStore(0)
+	q := queue.New(queue.NewMemoryStore(0),
+		queue.WithLane(queue.PriorityBulk, bulk, 1),
+		queue.WithLane(queue.PriorityHigh, high, 1),
+		queue.WithPriorityFunc(queue.ByTag(map[string]queue.Priority{
+			"newsletter":     queue.PriorityBulk,
+			"password-reset": queue.PriorityHigh,
+		})),
+	)
+	require.NoError(t, q.Start(context.Background(), blocking))
+
+	newsletter := newMessage("newsletter")
+	newsletter.Tags = []string{"newsletter"}
+	require.NoError(t, q.Enqueue(context.Background(), newsletter))
+	<-bulkStarted
+
+	reset := newMessage("reset")
+	reset.Tags = []string{"password-reset"}
+	require.NoError(t, q.Enqueue(context.Background(), reset))
+	require.NoError(t, q.Enqueue(context.Background(), newMessage("welcome")))
+
+	// The bulk worker is busy, but the other lanes still deliver
+	assert.Eventually(t, func() bool { return sender.sentCount() == 2 }, time.Second, 5*time.Millisecond)
+
+	close(release)
+	assert.Eventually(t, func() bool { return sender.sentCount() == 3 }, time.Second, 5*time.Millisecond)
+	require.NoError(t, q.Stop(context.Background()))
+}
+
+func TestPriority_String(t *testing.T) {
+	assert.Equal(t, "bulk", queue.PriorityBulk.String())
+	assert.Equal(t, "normal", queue.PriorityNormal.String())
+	assert.Equal(t, "high", queue.PriorityHigh.String())
+	assert.Equal(t, "priority(5)", queue.Priority(5).String())
+}
+
+// laneSender blocks newsletter sends until release is closed
+type laneSender struct {
+	next    *mockSender
+	release chan struct{}
+	started chan struct{}
+}
+
+func (s *laneSender) Send(ctx context.Context, msg *mailpen.Message) error {
+	if msg.Subject == "newsletter" {
+		s.started <- struct{}{}
+		<-s.release
+	}
+	return s.next.Send(ctx, msg)
+}
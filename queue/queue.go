@@ -0,0 +1,521 @@
+// Package queue delivers mailpen messages in the background using a pool of workers, so callers do not
+// block on provider round-trips.
+package queue
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/patrickward/mailpen"
+)
+
+var (
+	ErrQueueFull  = errors.New("queue is full")
+	ErrNotStarted = errors.New("queue has not been started")
+	ErrStopped    = errors.New("queue has been stopped")
+)
+
+// Sender sends a message. *mailpen.Mailpen implements Sender.
+type Sender interface {
+	Send(ctx context.Context, msg *mailpen.Message) error
+}
+
+// Queue delivers enqueued messages with a pool of workers
+type Queue struct {
+	store    Store
+	workers  int
+	retry    RetryPolicy
+	onFail   func(job *Job, err error)
+	lanes    []*lane
+	classify func(msg *mailpen.Message) Priority
+	logger   *slog.Logger
+
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	started  bool
+	stopped  bool
+	inflight map[string]*Job
+}
+
+// Option configures a Queue
+type Option func(q *Queue)
+
+// WithWorkers sets the number of concurrent workers for the default lane (defaults to 1)
+func WithWorkers(n int) Option {
+	return func(q *Queue) {
+		q.workers = n
+	}
+}
+
+// WithRetryPolicy sets the retry policy used for jobs that do not set MaxAttempts. Each attempt is a full Send,
+// so when the sender is a *mailpen.Mailpen its own RetryPolicy runs inside every attempt and a job can reach
+// the provider up to MaxAttempts times the Mailpen's attempts. Give the Mailpen that delivers queued mail
+// mailpen.NoRetry to leave retries to the queue.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(q *Queue) {
+		q.retry = policy
+	}
+}
+
+// WithFailureHandler sets a function called when a job fails permanently and is dead-lettered
+func WithFailureHandler(fn func(job *Job, err error)) Option {
+	return func(q *Queue) {
+		q.onFail = fn
+	}
+}
+
+// WithLogger sets the logger used to report store failures. When it is not set, a queue started with a
+// *mailpen.Mailpen uses the Mailpen's Config.Logger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(q *Queue) {
+		q.logger = logger
+	}
+}
+
+// New creates a new Queue backed by the given store. A nil store uses an unbounded MemoryStore.
+func New(store Store, opts ...Option) *Queue {
+	if store == nil {
+		store = NewMemoryStore(0)
+	}
+
+	q := &Queue{
+		store:   store,
+		workers: 1,
+		retry:   DefaultRetryPolicy,
+	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	if q.workers < 1 {
+		q.workers = 1
+	}
+	q.addDefaultLane()
+
+	return q
+}
+
+// Enqueue adds a message to the queue using the queue's retry policy and priority function. A message with
+// SendAt set is not delivered before that time. It implements mailpen.Queue.
+func (q *Queue) Enqueue(ctx context.Context, msg *mailpen.Message) error {
+	job := &Job{Message: msg, NotBefore: msg.SendAt}
+	if q.classify != nil && msg != nil {
+		job.Priority = q.classify(msg)
+	}
+	return q.EnqueueJob(ctx, job)
+}
+
+// EnqueueJob adds a job to the lane for its priority. Setting MaxAttempts or NotBefore on the job overrides
+// the queue's retry policy or delays the first attempt.
+func (q *Queue) EnqueueJob(ctx context.Context, job *Job) error {
+	if job == nil || job.Message == nil {
+		return errors.New("job message is required")
+	}
+
+	q.mu.Lock()
+	stopped := q.stopped
+	q.mu.Unlock()
+	if stopped {
+		return ErrStopped
+	}
+
+	if err := bufferAttachments(job.Message); err != nil {
+		return err
+	}
+
+	if job.ID == "" {
+		job.ID = newJobID()
+	}
+	if job.EnqueuedAt.IsZero() {
+		job.EnqueuedAt = time.Now()
+	}
+
+	if err := q.laneFor(job.Priority).store.Push(ctx, job); err != nil {
+		return fmt.Errorf("failed to enqueue message: %w", err)
+	}
+
+	return nil
+}
+
+// Start starts the workers, which deliver jobs with the sender until Stop is called or ctx is done
+func (q *Queue) Start(ctx context.Context, sender Sender) error {
+	if sender == nil {
+		return errors.New("sender is required")
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.stopped {
+		return ErrStopped
+	}
+	if q.started {
+		return errors.New("queue already started")
+	}
+
+	if mp, ok := sender.(*mailpen.Mailpen); ok && q.logger == nil {
+		q.logger = mp.Config().Logger
+	}
+	if q.logger == nil {
+		q.logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+
+	ctx, q.cancel = context.WithCancel(ctx)
+	q.started = true
+
+	for _, l := range q.lanes {
+		for i := 0; i < l.workers; i++ {
+			q.wg.Add(1)
+			go q.work(ctx, sender, l.store)
+		}
+	}
+
+	return nil
+}
+
+// Stop stops accepting new jobs and waits for in-flight deliveries to finish or for ctx to be done. Pending
+// jobs remain in the store.
+func (q *Queue) Stop(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.started {
+		q.mu.Unlock()
+		return ErrNotStarted
+	}
+	q.stopped = true
+	q.cancel()
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Flush stops accepting new jobs and keeps the workers delivering until no job is ready, or until ctx is
+// done, and then stops them. It returns the messages left undelivered: jobs scheduled for later, jobs still
+// pending when ctx is done, and deliveries still in flight. Durable stores keep these jobs for the next start.
+// Flush implements mailpen.QueueFlusher.
+//
+// When a lane's store does not implement PendingLister, its pending jobs cannot be reported, so the workers
+// are stopped right away and no messages are returned.
+func (q *Queue) Flush(ctx context.Context) ([]*mailpen.Message, error) {
+	q.mu.Lock()
+	q.stopped = true
+	started := q.started
+	q.mu.Unlock()
+
+	lister, canList := q.pendingLister()
+	if started && canList {
+		q.waitIdle(ctx, lister)
+	}
+
+	var errs []error
+	if started {
+		errs = append(errs, q.Stop(ctx))
+	}
+	if !canList {
+		return nil, errors.Join(errs...)
+	}
+
+	pending, err := lister.Pending(context.WithoutCancel(ctx))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to list pending jobs: %w", err))
+	}
+
+	q.mu.Lock()
+	seen := make(map[string]bool)
+	var msgs []*mailpen.Message
+	for _, job := range append(pending, mapValues(q.inflight)...) {
+		if !seen[job.ID] {
+			seen[job.ID] = true
+			msgs = append(msgs, job.Message)
+		}
+	}
+	q.mu.Unlock()
+
+	return msgs, errors.Join(errs...)
+}
+
+// waitIdle waits until no pending job is ready and no delivery is in flight, or until ctx is done
+func (q *Queue) waitIdle(ctx context.Context, lister PendingLister) {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		jobs, err := lister.Pending(ctx)
+		if err == nil && !q.busy(jobs, time.Now()) {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// busy reports whether a delivery is in flight or any of the jobs is ready at now
+func (q *Queue) busy(jobs []*Job, now time.Time) bool {
+	q.mu.Lock()
+	inflight := len(q.inflight)
+	q.mu.Unlock()
+	if inflight > 0 {
+		return true
+	}
+
+	for _, job := range jobs {
+		if !job.NotBefore.After(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// mapValues returns the values of a map in unspecified order
+func mapValues(m map[string]*Job) []*Job {
+	values := make([]*Job, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	return values
+}
+
+// popBackoff is the delay before a worker pops again after its store failed, given the failures in a row
+var popBackoff = ExponentialBackoff(100*time.Millisecond, 30*time.Second)
+
+// work delivers jobs from a lane's store until ctx is done. When the store fails, such as when its database
+// is unreachable, the worker backs off before trying again.
+func (q *Queue) work(ctx context.Context, sender Sender, store Store) {
+	defer q.wg.Done()
+
+	failures := 0
+	for {
+		job, err := store.Pop(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			failures++
+			delay := popBackoff(failures)
+			q.logger.ErrorContext(ctx, "queue: failed to pop job",
+				slog.Any("error", err),
+				slog.Int("failures", failures),
+				slog.Duration("retry_in", delay),
+			)
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			continue
+		}
+		failures = 0
+
+		// In-flight deliveries are allowed to finish when the queue is stopped
+		q.deliver(context.WithoutCancel(ctx), sender, store, job)
+	}
+}
+
+// deliver makes one delivery attempt and acknowledges, retries, or dead-letters the job. Errors that
+// mailpen.IsRetryable rejects, such as render failures and permanent provider errors, are dead-lettered right
+// away.
+func (q *Queue) deliver(ctx context.Context, sender Sender, store Store, job *Job) {
+	q.mu.Lock()
+	if q.inflight == nil {
+		q.inflight = make(map[string]*Job)
+	}
+	q.inflight[job.ID] = job
+	q.mu.Unlock()
+
+	defer func() {
+		q.mu.Lock()
+		delete(q.inflight, job.ID)
+		q.mu.Unlock()
+	}()
+
+	job.Attempts++
+
+	msg := attemptMessage(job.Message)
+	err := sender.Send(ctx, msg)
+	if job.Message.ID == "" {
+		job.Message.ID = msg.ID
+	}
+	if err == nil {
+		if err := store.Ack(ctx, job); err != nil {
+			// The store may deliver the job again, sending the message twice
+			q.storeError(ctx, "queue: failed to acknowledge job", job, err)
+		}
+		return
+	}
+
+	job.LastError = err.Error()
+
+	maxAttempts := job.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = q.retry.MaxAttempts
+	}
+
+	if !mailpen.IsRetryable(err) || job.Attempts >= maxAttempts {
+		if err := store.Fail(ctx, job); err != nil {
+			q.storeError(ctx, "queue: failed to dead-letter job", job, err)
+		}
+		if q.onFail != nil {
+			q.onFail(job, err)
+		}
+		return
+	}
+
+	var delay time.Duration
+	if q.retry.Backoff != nil {
+		delay = q.retry.Backoff(job.Attempts)
+	}
+	if err := store.Retry(ctx, job, time.Now().Add(delay)); err != nil {
+		q.storeError(ctx, "queue: failed to schedule retry", job, err)
+	}
+}
+
+// bufferAttachments reads the attachments of msg into memory, so every delivery attempt can send them again.
+// Durable stores hold the same bytes, since MarshalJob buffers attachments the same way.
+func bufferAttachments(msg *mailpen.Message) error {
+	if len(msg.Attachments) == 0 {
+		return nil
+	}
+
+	attachments := slices.Clone(msg.Attachments)
+	for i, att := range attachments {
+		if att.Data == nil {
+			continue
+		}
+		data, err := io.ReadAll(att.Data)
+		if err != nil {
+			return fmt.Errorf("failed to read attachment %q: %w", att.Filename, err)
+		}
+		attachments[i].Data = bytes.NewReader(data)
+	}
+	msg.Attachments = attachments
+	return nil
+}
+
+// attemptMessage returns a copy of msg for one delivery attempt. Its attachments read the buffered bytes from
+// the start without moving the job's readers, and changes made while sending, such as the rendered body, are
+// not kept for the next attempt.
+func attemptMessage(msg *mailpen.Message) *mailpen.Message {
+	c := *msg
+	c.To = slices.Clone(msg.To)
+	c.Cc = slices.Clone(msg.Cc)
+	c.Bcc = slices.Clone(msg.Bcc)
+	c.Tags = slices.Clone(msg.Tags)
+	c.Data = maps.Clone(msg.Data)
+	c.Metadata = maps.Clone(msg.Metadata)
+	c.Headers = maps.Clone(msg.Headers)
+
+	c.Attachments = slices.Clone(msg.Attachments)
+	for i, att := range c.Attachments {
+		if r, ok := att.Data.(*bytes.Reader); ok {
+			c.Attachments[i].Data = io.NewSectionReader(r, 0, r.Size())
+		}
+	}
+	return &c
+}
+
+// storeError logs a store failure while settling a delivered job
+func (q *Queue) storeError(ctx context.Context, msg string, job *Job, err error) {
+	q.logger.ErrorContext(ctx, msg,
+		slog.String("job_id", job.ID),
+		slog.String("message_id", job.Message.ID),
+		slog.Int("attempts", job.Attempts),
+		slog.Any("error", err),
+	)
+}
+
+// newJobID generates a random job identifier
+func newJobID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Len returns the number of jobs held by the queue's stores, including delayed jobs. It implements
+// mailpen.QueueSizer. Each store must report its size with a Len method or by implementing PendingLister.
+func (q *Queue) Len(ctx context.Context) (int, error) {
+	total := 0
+	for _, l := range q.lanes {
+		n, err := storeLen(ctx, l.store)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// storeLen returns the number of jobs in a store
+func storeLen(ctx context.Context, store Store) (int, error) {
+	switch s := store.(type) {
+	case interface{ Len() int }:
+		return s.Len(), nil
+	case interface {
+		Len(ctx context.Context) (int, error)
+	}:
+		return s.Len(ctx)
+	case interface {
+		Len(ctx context.Context) (int64, error)
+	}:
+		n, err := s.Len(ctx)
+		return int(n), err
+	case PendingLister:
+		jobs, err := s.Pending(ctx)
+		return len(jobs), err
+	default:
+		return 0, fmt.Errorf("store %T cannot report its size", store)
+	}
+}
+
+// Worker returns the queue as a mailpen.Worker, so a mailpen.Module starts its workers with the module's
+// Mailpen instance and stops them when the module stops
+func (q *Queue) Worker() mailpen.Worker {
+	return queueWorker{q}
+}
+
+// queueWorker adapts a Queue to mailpen.Worker
+type queueWorker struct {
+	q *Queue
+}
+
+// Start implements mailpen.Worker. The workers run until Stop, not until ctx is done.
+func (w queueWorker) Start(ctx context.Context, mp *mailpen.Mailpen) error {
+	return w.q.Start(context.WithoutCancel(ctx), mp)
+}
+
+// Stop implements mailpen.Worker. Stopping a queue that was never started is not an error.
+func (w queueWorker) Stop(ctx context.Context) error {
+	if err := w.q.Stop(ctx); err != nil && !errors.Is(err, ErrNotStarted) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,488 @@
+package queue_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/providers/memory"
+	"github.com/patrickward/mailpen/queue"
+)
+
+// mockSender records sent messages and fails according to its fail function
+type mockSender struct {
+	mu    sync.Mutex
+	sent  []*mailpen.Message
+	calls int
+	fail  func(call int) error
+}
+
+func (s *mockSender) Send(_ context.Context, msg *mailpen.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	if s.fail != nil {
+		if err := s.fail(s.calls); err != nil {
+			return err
+		}
+	}
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func (s *mockSender) sentCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sent)
+}
+
+func newMessage(subject string) *mailpen.Message {
+	return mailpen.NewMessage().To("recipient@example.com").Subject(subject).Must()
+}
+
+func TestQueue_Delivers(t *testing.T) {
+	sender := &mockSender{}
+	q := queue.New(queue.NewMemoryStore(10), queue.WithWorkers(3))
+	require.NoError(t, q.Start(context.Background(), sender))
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, q.Enqueue(context.Background(), newMessage("hello")))
+	}
+
+	assert.Eventually(t, func() bool { return sender.sentCount() == 5 }, time.Second, 5*time.Millisecond)
+	require.NoError(t, q.Stop(context.Background()))
+}
+
+func TestQueue_Retry(t *testing.T) {
+	tests := []struct {
+		name        string
+		fail        func(call int) error
+		maxAttempts int
+		wantSent    int
+		wantCalls   int
+		wantDead    int
+	}{
+		{
+			name: "succeeds after transient failures",
+			fail: func(call int) error {
+				if call < 3 {
+					return errors.New("temporary")
+				}
+				return nil
+			},
+			wantSent:  1,
+			wantCalls: 3,
+		},
+		{
+			name:      "dead-letters after max attempts",
+			fail:      func(int) error { return errors.New("down") },
+			wantCalls: 4,
+			wantDead:  1,
+		},
+		{
+			name:        "job max attempts overrides policy",
+			fail:        func(int) error { return errors.New("down") },
+			maxAttempts: 2,
+			wantCalls:   2,
+			wantDead:    1,
+		},
+		{
+			name:      "permanent errors are not retried",
+			fail:      func(int) error { return queue.Permanent(errors.New("invalid recipient")) },
+			wantCalls: 1,
+			wantDead:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &mockSender{fail: tt.fail}
+			store := queue.NewMemoryStore(10)
+
+			var failed []*queue.Job
+			var mu sync.Mutex
+			q := queue.New(store,
+				queue.WithRetryPolicy(queue.RetryPolicy{
+					MaxAttempts: 4,
+					Backoff:     func(int) time.Duration { return time.Millisecond },
+				}),
+				queue.WithFailureHandler(func(job *queue.Job, err error) {
+					mu.Lock()
+					defer mu.Unlock()
+					failed = append(failed, job)
+				}),
+			)
+			require.NoError(t, q.Start(context.Background(), sender))
+
+			require.NoError(t, q.EnqueueJob(context.Background(), &queue.Job{
+				Message:     newMessage("retry"),
+				MaxAttempts: tt.maxAttempts,
+			}))
+
+			assert.Eventually(t, func() bool {
+				sender.mu.Lock()
+				defer sender.mu.Unlock()
+				return sender.calls == tt.wantCalls && store.Len() == 0
+			}, time.Second, 5*time.Millisecond)
+			require.NoError(t, q.Stop(context.Background()))
+
+			assert.Equal(t, tt.wantSent, sender.sentCount())
+			assert.Len(t, store.DeadLetters(), tt.wantDead)
+			assert.Len(t, failed, tt.wantDead)
+			if tt.wantDead > 0 {
+				assert.Equal(t, tt.wantCalls, store.DeadLetters()[0].Attempts)
+				assert.NotEmpty(t, store.DeadLetters()[0].LastError)
+			}
+		})
+	}
+}
+
+// failingStore wraps a MemoryStore and fails Pop and Ack as configured
+// codecStore round-trips jobs through MarshalJob and UnmarshalJob on Push and Retry, as durable stores do
+type codecStore struct {
+	*queue.MemoryStore
+	t *testing.T
+}
+
+func (s *codecStore) Push(ctx context.Context, job *queue.Job) error {
+	return s.MemoryStore.Push(ctx, s.roundTrip(job))
+}
+
+func (s *codecStore) Retry(ctx context.Context, job *queue.Job, at time.Time) error {
+	return s.MemoryStore.Retry(ctx, s.roundTrip(job), at)
+}
+
+func (s *codecStore) roundTrip(job *queue.Job) *queue.Job {
+	data, err := queue.MarshalJob(job)
+	require.NoError(s.t, err)
+	decoded, err := queue.UnmarshalJob(data)
+	require.NoError(s.t, err)
+	return decoded
+}
+
+// attachmentSender records the attachment contents of each attempt and fails the first one
+type attachmentSender struct {
+	mu       sync.Mutex
+	contents []string
+}
+
+func (s *attachmentSender) Send(_ context.Context, msg *mailpen.Message) error {
+	data, err := io.ReadAll(msg.Attachments[0].Data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.contents = append(s.contents, string(data))
+	if len(s.contents) == 1 {
+		return errors.New("temporary")
+	}
+	return nil
+}
+
+func (s *attachmentSender) attempts() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.contents)
+}
+
+func TestQueue_RetryResendsAttachments(t *testing.T) {
+	tests := []struct {
+		name  string
+		store func(t *testing.T) queue.Store
+	}{
+		{
+			name:  "memory store",
+			store: func(*testing.T) queue.Store { return queue.NewMemoryStore(10) },
+		},
+		{
+			name:  "marshalling store",
+			store: func(t *testing.T) queue.Store { return &codecStore{MemoryStore: queue.NewMemoryStore(10), t: t} },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &attachmentSender{}
+			q := queue.New(tt.store(t), queue.WithRetryPolicy(queue.RetryPolicy{
+				MaxAttempts: 3,
+				Backoff:     func(int) time.Duration { return time.Millisecond },
+			}))
+			require.NoError(t, q.Start(context.Background(), sender))
+
+			msg := newMessage("attachment")
+			msg.Attachments = []mailpen.Attachment{{Filename: "hello.txt", Data: strings.NewReader("hello")}}
+			require.NoError(t, q.Enqueue(context.Background(), msg))
+
+			assert.Eventually(t, func() bool { return len(sender.attempts()) == 2 }, time.Second, 5*time.Millisecond)
+			require.NoError(t, q.Stop(context.Background()))
+			assert.Equal(t, []string{"hello", "hello"}, sender.attempts())
+		})
+	}
+}
+
+func TestQueue_DeadLettersNonRetryableErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "render failure", err: mailpen.Permanent(mailpen.ErrTemplateNotFound)},
+		{name: "attachment too large", err: &mailpen.AttachmentSizeError{Filename: "big.pdf", Limit: 10}},
+		{name: "canceled", err: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &mockSender{fail: func(int) error { return tt.err }}
+			store := queue.NewMemoryStore(10)
+			q := queue.New(store, queue.WithRetryPolicy(queue.RetryPolicy{
+				MaxAttempts: 4,
+				Backoff:     func(int) time.Duration { return time.Millisecond },
+			}))
+			require.NoError(t, q.Start(context.Background(), sender))
+			require.NoError(t, q.Enqueue(context.Background(), newMessage("dead")))
+
+			assert.Eventually(t, func() bool { return len(store.DeadLetters()) == 1 }, time.Second, 5*time.Millisecond)
+			require.NoError(t, q.Stop(context.Background()))
+
+			sender.mu.Lock()
+			defer sender.mu.Unlock()
+			assert.Equal(t, 1, sender.calls)
+		})
+	}
+}
+
+type failingStore struct {
+	*queue.MemoryStore
+	pops    atomic.Int32
+	popErr  error
+	ackErr  error
+	logsMu  sync.Mutex
+	logsBuf bytes.Buffer
+}
+
+func (s *failingStore) Pop(ctx context.Context) (*queue.Job, error) {
+	s.pops.Add(1)
+	if s.popErr != nil {
+		return nil, s.popErr
+	}
+	return s.MemoryStore.Pop(ctx)
+}
+
+func (s *failingStore) Ack(ctx context.Context, job *queue.Job) error {
+	if s.ackErr != nil {
+		return s.ackErr
+	}
+	return s.MemoryStore.Ack(ctx, job)
+}
+
+func (s *failingStore) Write(p []byte) (int, error) {
+	s.logsMu.Lock()
+	defer s.logsMu.Unlock()
+	return s.logsBuf.Write(p)
+}
+
+func (s *failingStore) logs() string {
+	s.logsMu.Lock()
+	defer s.logsMu.Unlock()
+	return s.logsBuf.String()
+}
+
+func TestQueue_StoreErrors(t *testing.T) {
+	t.Run("pop failures back off and are logged", func(t *testing.T) {
+		store := &failingStore{MemoryStore: queue.NewMemoryStore(0), popErr: errors.New("connection refused")}
+		q := queue.New(store, queue.WithLogger(slog.New(slog.NewTextHandler(store, nil))))
+
+		require.NoError(t, q.Start(context.Background(), &mockSender{}))
+		time.Sleep(250 * time.Millisecond)
+		require.NoError(t, q.Stop(context.Background()))
+
+		assert.LessOrEqual(t, store.pops.Load(), int32(3), "workers back off instead of spinning")
+		assert.Contains(t, store.logs(), "queue: failed to pop job")
+		assert.Contains(t, store.logs(), "connection refused")
+	})
+
+	t.Run("ack failures are logged", func(t *testing.T) {
+		store := &failingStore{MemoryStore: queue.NewMemoryStore(0), ackErr: errors.New("connection reset")}
+		q := queue.New(store, queue.WithLogger(slog.New(slog.NewTextHandler(store, nil))))
+		require.NoError(t, q.Enqueue(context.Background(), newMessage("hello")))
+
+		require.NoError(t, q.Start(context.Background(), &mockSender{}))
+		assert.Eventually(t, func() bool { return strings.Contains(store.logs(), "queue: failed to acknowledge job") },
+			time.Second, 5*time.Millisecond)
+		require.NoError(t, q.Stop(context.Background()))
+
+		assert.Contains(t, store.logs(), "connection reset")
+	})
+}
+
+func TestQueue_Lifecycle(t *testing.T) {
+	q := queue.New(queue.NewMemoryStore(1))
+
+	assert.ErrorIs(t, q.Stop(context.Background()), queue.ErrNotStarted)
+
+	require.NoError(t, q.Enqueue(context.Background(), newMessage("first")))
+	assert.ErrorIs(t, q.Enqueue(context.Background(), newMessage("second")), queue.ErrQueueFull)
+
+	sender := &mockSender{}
+	require.NoError(t, q.Start(context.Background(), sender))
+	assert.Error(t, q.Start(context.Background(), sender))
+	assert.Eventually(t, func() bool { return sender.sentCount() == 1 }, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, q.Stop(context.Background()))
+	assert.ErrorIs(t, q.Enqueue(context.Background(), newMessage("late")), queue.ErrStopped)
+}
+
+func TestQueue_Flush(t *testing.T) {
+	t.Run("delivers ready jobs and reports delayed ones", func(t *testing.T) {
+		sender := &mockSender{}
+		q := queue.New(queue.NewMemoryStore(10))
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, q.Enqueue(context.Background(), newMessage("now")))
+		}
+		later := newMessage("later")
+		later.SendAt = time.Now().Add(time.Hour)
+		require.NoError(t, q.Enqueue(context.Background(), later))
+
+		require.NoError(t, q.Start(context.Background(), sender))
+		undelivered, err := q.Flush(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, 3, sender.sentCount())
+		require.Len(t, undelivered, 1)
+		assert.Equal(t, "later", undelivered[0].Subject)
+		assert.ErrorIs(t, q.Enqueue(context.Background(), newMessage("late")), queue.ErrStopped)
+	})
+
+	t.Run("reports in-flight jobs at the deadline", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		sender := &mockSender{fail: func(int) error {
+			<-release
+			return nil
+		}}
+		q := queue.New(queue.NewMemoryStore(10))
+		require.NoError(t, q.Enqueue(context.Background(), newMessage("stuck")))
+		require.NoError(t, q.Start(context.Background(), sender))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		undelivered, err := q.Flush(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Len(t, undelivered, 1)
+		assert.Equal(t, "stuck", undelivered[0].Subject)
+	})
+
+	t.Run("not started", func(t *testing.T) {
+		q := queue.New(queue.NewMemoryStore(10))
+		require.NoError(t, q.Enqueue(context.Background(), newMessage("pending")))
+
+		undelivered, err := q.Flush(context.Background())
+		require.NoError(t, err)
+		assert.Len(t, undelivered, 1)
+	})
+}
+
+func TestMemoryStore_NotBefore(t *testing.T) {
+	store := queue.NewMemoryStore(0)
+	ctx := context.Background()
+
+	require.NoError(t, store.Push(ctx, &queue.Job{ID: "later", NotBefore: time.Now().Add(30 * time.Millisecond)}))
+	require.NoError(t, store.Push(ctx, &queue.Job{ID: "now"}))
+
+	job, err := store.Pop(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "now", job.ID)
+
+	start := time.Now()
+	job, err = store.Pop(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "later", job.ID)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = store.Pop(timeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := queue.ExponentialBackoff(time.Second, 5*time.Second)
+
+	assert.Equal(t, time.Second, backoff(1))
+	assert.Equal(t, 2*time.Second, backoff(2))
+	assert.Equal(t, 4*time.Second, backoff(3))
+	assert.Equal(t, 5*time.Second, backoff(4))
+	assert.Equal(t, 5*time.Second, backoff(10))
+}
+
+func TestQueue_Len(t *testing.T) {
+	q := queue.New(queue.NewMemoryStore(0), queue.WithLane(queue.PriorityHigh, queue.NewMemoryStore(0), 1))
+
+	n, err := q.Len(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	require.NoError(t, q.Enqueue(context.Background(), newMessage("normal")))
+	require.NoError(t, q.EnqueueJob(context.Background(), &queue.Job{Message: newMessage("urgent"), Priority: queue.PriorityHigh}))
+
+	n, err = q.Len(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
+
+func TestQueue_ModuleWorker(t *testing.T) {
+	tests := []struct {
+		name       string
+		withWorker bool
+	}{
+		{name: "worker added explicitly", withWorker: true},
+		{name: "worker started from the configured queue"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := memory.New()
+			q := queue.New(queue.NewMemoryStore(0))
+			opts := []mailpen.ModuleOption{mailpen.WithMailpenOptions(mailpen.WithQueue(q))}
+			if tt.withWorker {
+				opts = append(opts, mailpen.WithWorker(q.Worker()))
+			}
+			module := mailpen.NewModule(provider, &mailpen.Config{From: "sender@example.com"}, opts...)
+			require.NoError(t, module.Init())
+
+			// The queue keeps running after the start context is done
+			startCtx, cancel := context.WithCancel(context.Background())
+			require.NoError(t, module.Start(startCtx))
+			cancel()
+
+			msg := newMessage("queued")
+			msg.TextBody = "Hello"
+			require.NoError(t, module.Mailpen().Enqueue(context.Background(), msg))
+			assert.Eventually(t, func() bool { return len(provider.Messages()) == 1 }, time.Second, 5*time.Millisecond)
+
+			health, err := module.Health(context.Background())
+			require.NoError(t, err)
+			assert.True(t, health.Healthy())
+			assert.Equal(t, 0, health.Queued)
+
+			require.NoError(t, module.Stop(context.Background()))
+			assert.ErrorIs(t, q.Enqueue(context.Background(), newMessage("late")), queue.ErrStopped)
+		})
+	}
+}
@@ -0,0 +1,289 @@
+// Package redisqueue provides a queue.Store backed by Redis, so queued mail survives process restarts and
+// can be drained by workers in several processes.
+package redisqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/patrickward/mailpen/queue"
+)
+
+// Store is a queue.Store backed by Redis. Ready jobs are kept in a list, delayed jobs and in-flight jobs in
+// sorted sets scored by time, and job payloads in a hash. A popped job that is not acknowledged, retried, or
+// failed within the visibility timeout (for example, because its worker crashed) becomes ready again.
+type Store struct {
+	client       redis.UniversalClient
+	prefix       string
+	visibility   time.Duration
+	pollInterval time.Duration
+	maxLen       int64
+}
+
+// Option configures a Store
+type Option func(s *Store)
+
+// WithPrefix sets the key prefix (defaults to "mailpen:queue")
+func WithPrefix(prefix string) Option {
+	return func(s *Store) {
+		s.prefix = prefix
+	}
+}
+
+// WithVisibilityTimeout sets how long a popped job stays hidden from other workers (defaults to five minutes)
+func WithVisibilityTimeout(d time.Duration) Option {
+	return func(s *Store) {
+		s.visibility = d
+	}
+}
+
+// WithPollInterval sets how often Pop checks for ready jobs when the queue is empty (defaults to 250ms)
+func WithPollInterval(d time.Duration) Option {
+	return func(s *Store) {
+		s.pollInterval = d
+	}
+}
+
+// WithMaxLen sets the maximum number of queued jobs; Push returns queue.ErrQueueFull beyond it
+func WithMaxLen(n int64) Option {
+	return func(s *Store) {
+		s.maxLen = n
+	}
+}
+
+// New creates a new Redis-backed store
+func New(client redis.UniversalClient, opts ...Option) (*Store, error) {
+	if client == nil {
+		return nil, errors.New("redis client is required")
+	}
+
+	s := &Store{
+		client:       client,
+		prefix:       "mailpen:queue",
+		visibility:   5 * time.Minute,
+		pollInterval: 250 * time.Millisecond,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s, nil
+}
+
+func (s *Store) jobsKey() string     { return s.prefix + ":jobs" }
+func (s *Store) readyKey() string    { return s.prefix + ":ready" }
+func (s *Store) delayedKey() string  { return s.prefix + ":delayed" }
+func (s *Store) inflightKey() string { return s.prefix + ":inflight" }
+func (s *Store) deadKey() string     { return s.prefix + ":dead" }
+
+// Push implements queue.Store
+func (s *Store) Push(ctx context.Context, job *queue.Job) error {
+	if job.ID == "" {
+		return errors.New("job ID is required")
+	}
+
+	if s.maxLen > 0 {
+		n, err := s.client.HLen(ctx, s.jobsKey()).Result()
+		if err != nil {
+			return fmt.Errorf("failed to check queue length: %w", err)
+		}
+		if n >= s.maxLen {
+			return queue.ErrQueueFull
+		}
+	}
+
+	payload, err := queue.MarshalJob(job)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, s.jobsKey(), job.ID, payload)
+		if job.NotBefore.After(time.Now()) {
+			pipe.ZAdd(ctx, s.delayedKey(), redis.Z{Score: millis(job.NotBefore), Member: job.ID})
+		} else {
+			pipe.LPush(ctx, s.readyKey(), job.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to push job: %w", err)
+	}
+
+	return nil
+}
+
+// popScript moves due delayed jobs and expired in-flight jobs to the ready list, then pops one ready job
+// and marks it in flight until its visibility deadline.
+var popScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1])
+for _, id in ipairs(due) do
+	redis.call('ZREM', KEYS[2], id)
+	redis.call('LPUSH', KEYS[1], id)
+end
+local expired = redis.call('ZRANGEBYSCORE', KEYS[3], '-inf', ARGV[1])
+for _, id in ipairs(expired) do
+	redis.call('ZREM', KEYS[3], id)
+	redis.call('RPUSH', KEYS[1], id)
+end
+while true do
+	local id = redis.call('RPOP', KEYS[1])
+	if not id then
+		return false
+	end
+	local payload = redis.call('HGET', KEYS[4], id)
+	if payload then
+		redis.call('ZADD', KEYS[3], ARGV[2], id)
+		return payload
+	end
+end
+`)
+
+// Pop implements queue.Store
+func (s *Store) Pop(ctx context.Context) (*queue.Job, error) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		now := time.Now()
+		keys := []string{s.readyKey(), s.delayedKey(), s.inflightKey(), s.jobsKey()}
+		payload, err := popScript.Run(ctx, s.client, keys, millisString(now), millisString(now.Add(s.visibility))).Text()
+		switch {
+		case err == nil:
+			return queue.UnmarshalJob([]byte(payload))
+		case !errors.Is(err, redis.Nil):
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("failed to pop job: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Ack implements queue.Store
+func (s *Store) Ack(ctx context.Context, job *queue.Job) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, s.inflightKey(), job.ID)
+		pipe.HDel(ctx, s.jobsKey(), job.ID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to ack job: %w", err)
+	}
+	return nil
+}
+
+// Retry implements queue.Store
+func (s *Store) Retry(ctx context.Context, job *queue.Job, at time.Time) error {
+	job.NotBefore = at
+	payload, err := queue.MarshalJob(job)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, s.inflightKey(), job.ID)
+		pipe.HSet(ctx, s.jobsKey(), job.ID, payload)
+		pipe.ZAdd(ctx, s.delayedKey(), redis.Z{Score: millis(at), Member: job.ID})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to retry job: %w", err)
+	}
+	return nil
+}
+
+// Fail implements queue.Store by moving the job to the dead-letter hash
+func (s *Store) Fail(ctx context.Context, job *queue.Job) error {
+	payload, err := queue.MarshalJob(job)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, s.inflightKey(), job.ID)
+		pipe.HDel(ctx, s.jobsKey(), job.ID)
+		pipe.HSet(ctx, s.deadKey(), job.ID, payload)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter job: %w", err)
+	}
+	return nil
+}
+
+// Len returns the number of queued jobs, including delayed and in-flight jobs
+func (s *Store) Len(ctx context.Context) (int64, error) {
+	return s.client.HLen(ctx, s.jobsKey()).Result()
+}
+
+// Pending implements queue.PendingLister. Delayed and in-flight jobs are included.
+func (s *Store) Pending(ctx context.Context) ([]*queue.Job, error) {
+	return s.jobs(ctx, s.jobsKey())
+}
+
+// DeadLetters returns the jobs that failed permanently
+func (s *Store) DeadLetters(ctx context.Context) ([]*queue.Job, error) {
+	return s.jobs(ctx, s.deadKey())
+}
+
+// jobs decodes every job in a hash
+func (s *Store) jobs(ctx context.Context, key string) ([]*queue.Job, error) {
+	payloads, err := s.client.HVals(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	jobs := make([]*queue.Job, 0, len(payloads))
+	for _, payload := range payloads {
+		job, err := queue.UnmarshalJob([]byte(payload))
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// Requeue moves a dead-lettered job back to the queue with its attempts reset
+func (s *Store) Requeue(ctx context.Context, id string) error {
+	payload, err := s.client.HGet(ctx, s.deadKey(), id).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return fmt.Errorf("dead letter %s not found", id)
+		}
+		return fmt.Errorf("failed to load dead letter: %w", err)
+	}
+
+	job, err := queue.UnmarshalJob([]byte(payload))
+	if err != nil {
+		return err
+	}
+	job.Attempts = 0
+	job.NotBefore = time.Time{}
+
+	if err := s.Push(ctx, job); err != nil {
+		return err
+	}
+	return s.client.HDel(ctx, s.deadKey(), id).Err()
+}
+
+func millis(t time.Time) float64 {
+	return float64(t.UnixMilli())
+}
+
+func millisString(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
@@ -0,0 +1,178 @@
+package redisqueue_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/queue"
+	"github.com/patrickward/mailpen/queue/redisqueue"
+)
+
+func newStore(t *testing.T, opts ...redisqueue.Option) *redisqueue.Store {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	opts = append([]redisqueue.Option{redisqueue.WithPollInterval(5 * time.Millisecond)}, opts...)
+	store, err := redisqueue.New(client, opts...)
+	require.NoError(t, err)
+	return store
+}
+
+func newJob(id string) *queue.Job {
+	return &queue.Job{
+		ID:      id,
+		Message: mailpen.NewMessage().To("recipient@example.com").Subject("Hello " + id).Must(),
+	}
+}
+
+func popWithin(t *testing.T, store *redisqueue.Store, d time.Duration) (*queue.Job, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return store.Pop(ctx)
+}
+
+func TestStore_PushPopAck(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(t)
+
+	require.NoError(t, store.Push(ctx, newJob("a")))
+	require.NoError(t, store.Push(ctx, newJob("b")))
+
+	job, err := popWithin(t, store, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "a", job.ID)
+	assert.Equal(t, "Hello a", job.Message.Subject)
+	require.NoError(t, store.Ack(ctx, job))
+
+	job, err = popWithin(t, store, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "b", job.ID)
+	require.NoError(t, store.Ack(ctx, job))
+
+	n, err := store.Len(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	_, err = popWithin(t, store, 20*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestStore_RetryDelay(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(t)
+
+	require.NoError(t, store.Push(ctx, newJob("a")))
+	job, err := popWithin(t, store, time.Second)
+	require.NoError(t, err)
+
+	job.Attempts = 1
+	require.NoError(t, store.Retry(ctx, job, time.Now().Add(50*time.Millisecond)))
+
+	_, err = popWithin(t, store, 10*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	job, err = popWithin(t, store, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "a", job.ID)
+	assert.Equal(t, 1, job.Attempts)
+}
+
+func TestStore_VisibilityTimeout(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(t, redisqueue.WithVisibilityTimeout(30*time.Millisecond))
+
+	require.NoError(t, store.Push(ctx, newJob("a")))
+	_, err := popWithin(t, store, time.Second)
+	require.NoError(t, err)
+
+	// The job was never acknowledged, so it becomes visible again
+	job, err := popWithin(t, store, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "a", job.ID)
+}
+
+func TestStore_DeadLetters(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(t)
+
+	require.NoError(t, store.Push(ctx, newJob("a")))
+	job, err := popWithin(t, store, time.Second)
+	require.NoError(t, err)
+
+	job.Attempts = 3
+	job.LastError = "mailbox unavailable"
+	require.NoError(t, store.Fail(ctx, job))
+
+	dead, err := store.DeadLetters(ctx)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "mailbox unavailable", dead[0].LastError)
+
+	require.NoError(t, store.Requeue(ctx, "a"))
+	dead, err = store.DeadLetters(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, dead)
+
+	job, err = popWithin(t, store, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 0, job.Attempts)
+
+	assert.Error(t, store.Requeue(ctx, "missing"))
+}
+
+func TestStore_MaxLen(t *testing.T) {
+	ctx := context.Background()
+	store := newStore(t, redisqueue.WithMaxLen(1))
+
+	require.NoError(t, store.Push(ctx, newJob("a")))
+	assert.ErrorIs(t, store.Push(ctx, newJob("b")), queue.ErrQueueFull)
+}
+
+func TestStore_WithQueue(t *testing.T) {
+	store := newStore(t)
+	q := queue.New(store, queue.WithWorkers(2))
+
+	sent := make(chan *mailpen.Message, 1)
+	require.NoError(t, q.Start(context.Background(), senderFunc(func(ctx context.Context, msg *mailpen.Message) error {
+		sent <- msg
+		return nil
+	})))
+	defer func() { _ = q.Stop(context.Background()) }()
+
+	msg := mailpen.NewMessage().
+		To("recipient@example.com").
+		Subject("Receipt").
+		Attach("receipt.txt", strings.NewReader("total: $10")).
+		Must()
+	require.NoError(t, q.Enqueue(context.Background(), msg))
+
+	select {
+	case got := <-sent:
+		assert.Equal(t, "Receipt", got.Subject)
+		require.Len(t, got.Attachments, 1)
+		data, err := io.ReadAll(got.Attachments[0].Data)
+		require.NoError(t, err)
+		assert.Equal(t, "total: $10", string(data))
+	case <-time.After(time.Second):
+		t.Fatal("message was not delivered")
+	}
+}
+
+type senderFunc func(ctx context.Context, msg *mailpen.Message) error
+
+func (f senderFunc) Send(ctx context.Context, msg *mailpen.Message) error {
+	return f(ctx, msg)
+}
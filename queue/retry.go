@@ -0,0 +1,42 @@
+package queue
+
+import (
+	"time"
+
+	"github.com/patrickward/mailpen"
+)
+
+// RetryPolicy controls how failed deliveries are retried
+type RetryPolicy struct {
+	MaxAttempts int                             // Maximum delivery attempts per job, including the first
+	Backoff     func(attempt int) time.Duration // Delay before the next attempt, given the attempts made so far
+}
+
+// DefaultRetryPolicy makes up to five attempts with exponential backoff from one second to five minutes
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	Backoff:     ExponentialBackoff(time.Second, 5*time.Minute),
+}
+
+// ExponentialBackoff returns a backoff function that doubles the delay after each attempt, starting at
+// initial and capped at limit
+func ExponentialBackoff(initial, limit time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		delay := initial
+		for i := 1; i < attempt && delay < limit; i++ {
+			delay *= 2
+		}
+		return min(delay, limit)
+	}
+}
+
+// Permanent wraps an error so the queue dead-letters the job instead of retrying it. It is equivalent to
+// mailpen.Permanent.
+func Permanent(err error) error {
+	return mailpen.Permanent(err)
+}
+
+// IsPermanent reports whether an error was marked with Permanent or mailpen.Permanent
+func IsPermanent(err error) bool {
+	return mailpen.IsPermanent(err)
+}
@@ -0,0 +1,330 @@
+// Package sqloutbox provides a queue.Store that implements the transactional outbox pattern with
+// database/sql. Messages enqueued with a transaction in the context are written in that transaction, so no
+// email is sent for an operation that is rolled back.
+package sqloutbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/patrickward/mailpen/queue"
+	"github.com/patrickward/mailpen/sqldialect"
+)
+
+const (
+	statusPending = "pending"
+	statusDead    = "dead"
+)
+
+// Dialect describes the SQL differences between databases
+type Dialect = sqldialect.Dialect
+
+var (
+	// Postgres uses $1-style placeholders
+	Postgres = sqldialect.Postgres
+	// MySQL uses ? placeholders and a MEDIUMTEXT payload to fit attachments
+	MySQL = sqldialect.MySQL
+	// SQLite uses ? placeholders
+	SQLite = sqldialect.SQLite
+)
+
+// execer is satisfied by *sql.DB, *sql.Tx, and *sql.Conn
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+type txKey struct{}
+
+// WithTx returns a context that makes Push write jobs in the given transaction. Pass it to Mailpen.Enqueue
+// to enqueue mail atomically with the caller's own writes. Mailpen publishes the Queued event when Enqueue
+// returns, before the transaction commits, so a rollback leaves subscribers with an event for mail that was
+// never stored; subscribers can use TxFrom to write in the same transaction.
+func WithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFrom returns the transaction set with WithTx, or nil when the context has none
+func TxFrom(ctx context.Context) *sql.Tx {
+	tx, _ := ctx.Value(txKey{}).(*sql.Tx)
+	return tx
+}
+
+// Store is a queue.Store backed by a SQL table. Workers claim rows by setting a lock deadline, so several
+// processes can dispatch from the same table; rows whose lock expires (for example, after a crash) are
+// claimed again.
+type Store struct {
+	db           *sql.DB
+	dialect      Dialect
+	table        string
+	visibility   time.Duration
+	pollInterval time.Duration
+}
+
+// Option configures a Store
+type Option func(s *Store)
+
+// WithTable sets the outbox table name (defaults to "mailpen_outbox")
+func WithTable(table string) Option {
+	return func(s *Store) {
+		s.table = table
+	}
+}
+
+// WithVisibilityTimeout sets how long a claimed row stays locked (defaults to five minutes)
+func WithVisibilityTimeout(d time.Duration) Option {
+	return func(s *Store) {
+		s.visibility = d
+	}
+}
+
+// WithPollInterval sets how often Pop checks for ready rows when none are available (defaults to one second)
+func WithPollInterval(d time.Duration) Option {
+	return func(s *Store) {
+		s.pollInterval = d
+	}
+}
+
+// New creates a new SQL outbox store
+func New(db *sql.DB, dialect Dialect, opts ...Option) (*Store, error) {
+	if db == nil {
+		return nil, errors.New("database is required")
+	}
+	if dialect.Placeholder == nil {
+		return nil, errors.New("dialect is required")
+	}
+
+	s := &Store{
+		db:           db,
+		dialect:      dialect,
+		table:        "mailpen_outbox",
+		visibility:   5 * time.Minute,
+		pollInterval: time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s, nil
+}
+
+// Migrate creates the outbox table if it does not exist
+func (s *Store) Migrate(ctx context.Context) error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id VARCHAR(64) PRIMARY KEY,
+	payload %s NOT NULL,
+	status VARCHAR(16) NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT,
+	available_at BIGINT NOT NULL,
+	locked_until BIGINT NOT NULL DEFAULT 0,
+	created_at BIGINT NOT NULL
+)`, s.table, s.dialect.PayloadType)
+
+	if _, err := s.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create outbox table: %w", err)
+	}
+	return nil
+}
+
+// query rewrites ? placeholders for the dialect
+func (s *Store) query(q string) string {
+	return s.dialect.Rebind(q)
+}
+
+// Push implements queue.Store. The job is written in the transaction from WithTx when the context has one.
+func (s *Store) Push(ctx context.Context, job *queue.Job) error {
+	if job.ID == "" {
+		return errors.New("job ID is required")
+	}
+
+	payload, err := queue.MarshalJob(job)
+	if err != nil {
+		return err
+	}
+
+	var exec execer = s.db
+	if tx := TxFrom(ctx); tx != nil {
+		exec = tx
+	}
+
+	now := time.Now()
+	availableAt := job.NotBefore
+	if availableAt.IsZero() {
+		availableAt = now
+	}
+
+	_, err = exec.ExecContext(ctx, s.query(fmt.Sprintf(
+		"INSERT INTO %s (id, payload, status, attempts, available_at, locked_until, created_at) VALUES (?, ?, ?, ?, ?, 0, ?)",
+		s.table)),
+		job.ID, string(payload), statusPending, job.Attempts, availableAt.UnixMilli(), now.UnixMilli(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write outbox row: %w", err)
+	}
+	return nil
+}
+
+// Pop implements queue.Store
+func (s *Store) Pop(ctx context.Context) (*queue.Job, error) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := s.claim(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+		if job != nil {
+			return job, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim locks the next ready row, returning nil when there is none. A row claimed concurrently by another
+// worker is skipped.
+func (s *Store) claim(ctx context.Context) (*queue.Job, error) {
+	for {
+		now := time.Now().UnixMilli()
+
+		var id, payload string
+		err := s.db.QueryRowContext(ctx, s.query(fmt.Sprintf(
+			"SELECT id, payload FROM %s WHERE status = ? AND available_at <= ? AND locked_until <= ? ORDER BY available_at, created_at LIMIT 1",
+			s.table)),
+			statusPending, now, now,
+		).Scan(&id, &payload)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to select outbox row: %w", err)
+		}
+
+		res, err := s.db.ExecContext(ctx, s.query(fmt.Sprintf(
+			"UPDATE %s SET locked_until = ? WHERE id = ? AND status = ? AND locked_until <= ?",
+			s.table)),
+			now+s.visibility.Milliseconds(), id, statusPending, now,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim outbox row: %w", err)
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			continue // Claimed by another worker
+		}
+
+		return queue.UnmarshalJob([]byte(payload))
+	}
+}
+
+// Ack implements queue.Store by deleting the delivered row
+func (s *Store) Ack(ctx context.Context, job *queue.Job) error {
+	if _, err := s.db.ExecContext(ctx, s.query(fmt.Sprintf("DELETE FROM %s WHERE id = ?", s.table)), job.ID); err != nil {
+		return fmt.Errorf("failed to delete outbox row: %w", err)
+	}
+	return nil
+}
+
+// Retry implements queue.Store
+func (s *Store) Retry(ctx context.Context, job *queue.Job, at time.Time) error {
+	job.NotBefore = at
+	return s.update(ctx, job, statusPending, at)
+}
+
+// Fail implements queue.Store by marking the row as dead
+func (s *Store) Fail(ctx context.Context, job *queue.Job) error {
+	return s.update(ctx, job, statusDead, time.Now())
+}
+
+// update stores the job's state and releases its lock
+func (s *Store) update(ctx context.Context, job *queue.Job, status string, availableAt time.Time) error {
+	payload, err := queue.MarshalJob(job)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, s.query(fmt.Sprintf(
+		"UPDATE %s SET payload = ?, status = ?, attempts = ?, last_error = ?, available_at = ?, locked_until = 0 WHERE id = ?",
+		s.table)),
+		string(payload), status, job.Attempts, job.LastError, availableAt.UnixMilli(), job.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update outbox row: %w", err)
+	}
+	return nil
+}
+
+// Len returns the number of pending rows, including delayed and claimed rows
+func (s *Store) Len(ctx context.Context) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, s.query(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE status = ?", s.table)), statusPending).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count outbox rows: %w", err)
+	}
+	return n, nil
+}
+
+// Pending implements queue.PendingLister. Delayed and claimed rows are included.
+func (s *Store) Pending(ctx context.Context) ([]*queue.Job, error) {
+	return s.jobs(ctx, statusPending)
+}
+
+// DeadLetters returns the jobs that failed permanently
+func (s *Store) DeadLetters(ctx context.Context) ([]*queue.Job, error) {
+	return s.jobs(ctx, statusDead)
+}
+
+// jobs decodes every row with the given status
+func (s *Store) jobs(ctx context.Context, status string) ([]*queue.Job, error) {
+	rows, err := s.db.QueryContext(ctx, s.query(fmt.Sprintf("SELECT payload FROM %s WHERE status = ? ORDER BY created_at", s.table)), status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s jobs: %w", status, err)
+	}
+	defer rows.Close()
+
+	var jobs []*queue.Job
+	for rows.Next() {
+		var payload string
+		if err := rows.Scan(&payload); err != nil {
+			return nil, fmt.Errorf("failed to scan %s job: %w", status, err)
+		}
+		job, err := queue.UnmarshalJob([]byte(payload))
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// Requeue returns a dead-lettered job to the outbox with its attempts reset
+func (s *Store) Requeue(ctx context.Context, id string) error {
+	var payload string
+	err := s.db.QueryRowContext(ctx, s.query(fmt.Sprintf("SELECT payload FROM %s WHERE id = ? AND status = ?", s.table)), id, statusDead).Scan(&payload)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("dead letter %s not found", id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load dead letter: %w", err)
+	}
+
+	job, err := queue.UnmarshalJob([]byte(payload))
+	if err != nil {
+		return err
+	}
+	job.Attempts = 0
+	job.NotBefore = time.Time{}
+
+	return s.update(ctx, job, statusPending, time.Now())
+}
@@ -0,0 +1,202 @@
+package sqloutbox_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/queue"
+	"github.com/patrickward/mailpen/queue/sqloutbox"
+)
+
+func newStore(t *testing.T, opts ...sqloutbox.Option) (*sqloutbox.Store, *sql.DB) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", "file:"+t.Name()+"?mode=memory&cache=shared")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+
+	opts = append([]sqloutbox.Option{sqloutbox.WithPollInterval(5 * time.Millisecond)}, opts...)
+	store, err := sqloutbox.New(db, sqloutbox.SQLite, opts...)
+	require.NoError(t, err)
+	require.NoError(t, store.Migrate(context.Background()))
+	return store, db
+}
+
+func newJob(id string) *queue.Job {
+	return &queue.Job{
+		ID:      id,
+		Message: mailpen.NewMessage().To("recipient@example.com").Subject("Hello " + id).Must(),
+	}
+}
+
+func popWithin(t *testing.T, store *sqloutbox.Store, d time.Duration) (*queue.Job, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return store.Pop(ctx)
+}
+
+func TestNew(t *testing.T) {
+	_, err := sqloutbox.New(nil, sqloutbox.SQLite)
+	assert.Error(t, err)
+
+	_, err = sqloutbox.New(&sql.DB{}, sqloutbox.Dialect{})
+	assert.Error(t, err)
+}
+
+func TestStore_Transaction(t *testing.T) {
+	tests := []struct {
+		name    string
+		commit  bool
+		wantLen int
+	}{
+		{name: "committed transaction", commit: true, wantLen: 1},
+		{name: "rolled back transaction", commit: false, wantLen: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store, db := newStore(t)
+
+			tx, err := db.BeginTx(ctx, nil)
+			require.NoError(t, err)
+			require.NoError(t, store.Push(sqloutbox.WithTx(ctx, tx), newJob("a")))
+
+			if tt.commit {
+				require.NoError(t, tx.Commit())
+			} else {
+				require.NoError(t, tx.Rollback())
+			}
+
+			n, err := store.Len(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantLen, n)
+		})
+	}
+}
+
+func TestStore_PopAckRetry(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newStore(t)
+
+	require.NoError(t, store.Push(ctx, newJob("a")))
+
+	job, err := popWithin(t, store, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello a", job.Message.Subject)
+
+	// A claimed row is not handed to another worker
+	_, err = popWithin(t, store, 20*time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	job.Attempts = 1
+	job.LastError = "timeout"
+	require.NoError(t, store.Retry(ctx, job, time.Now().Add(30*time.Millisecond)))
+
+	job, err = popWithin(t, store, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 1, job.Attempts)
+	require.NoError(t, store.Ack(ctx, job))
+
+	n, err := store.Len(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestStore_VisibilityTimeout(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newStore(t, sqloutbox.WithVisibilityTimeout(20*time.Millisecond))
+
+	require.NoError(t, store.Push(ctx, newJob("a")))
+	_, err := popWithin(t, store, time.Second)
+	require.NoError(t, err)
+
+	job, err := popWithin(t, store, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "a", job.ID)
+}
+
+func TestStore_DeadLetters(t *testing.T) {
+	ctx := context.Background()
+	store, _ := newStore(t)
+
+	require.NoError(t, store.Push(ctx, newJob("a")))
+	job, err := popWithin(t, store, time.Second)
+	require.NoError(t, err)
+
+	job.Attempts = 5
+	job.LastError = "mailbox unavailable"
+	require.NoError(t, store.Fail(ctx, job))
+
+	dead, err := store.DeadLetters(ctx)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, "mailbox unavailable", dead[0].LastError)
+
+	require.NoError(t, store.Requeue(ctx, "a"))
+	assert.Error(t, store.Requeue(ctx, "a"))
+
+	job, err = popWithin(t, store, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 0, job.Attempts)
+}
+
+func TestStore_WithMailpen(t *testing.T) {
+	ctx := context.Background()
+	store, db := newStore(t)
+
+	q := queue.New(store)
+	provider := &recordingProvider{sent: make(chan *mailpen.Message, 1)}
+	mp, err := mailpen.New(provider, &mailpen.Config{From: "sender@example.com"}, mailpen.WithQueue(q))
+	require.NoError(t, err)
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+
+	// The Queued event is published before the commit, with the transaction in its context
+	var queuedTx *sql.Tx
+	mp.Subscribe(func(ctx context.Context, _ mailpen.Event) error {
+		queuedTx = sqloutbox.TxFrom(ctx)
+		return nil
+	}, mailpen.EventQueued)
+
+	msg := mailpen.NewMessage().To("recipient@example.com").Subject("Welcome").Must()
+	require.NoError(t, mp.Enqueue(sqloutbox.WithTx(ctx, tx), msg))
+	assert.Same(t, tx, queuedTx)
+	require.NoError(t, tx.Commit())
+
+	require.NoError(t, q.Start(ctx, mp))
+	defer func() { _ = q.Stop(ctx) }()
+
+	select {
+	case got := <-provider.sent:
+		assert.Equal(t, "Welcome", got.Subject)
+	case <-time.After(time.Second):
+		t.Fatal("message was not dispatched")
+	}
+}
+
+// recordingProvider implements mailpen.Provider and reports sent messages on a channel
+type recordingProvider struct {
+	sent chan *mailpen.Message
+}
+
+func (p *recordingProvider) Send(_ context.Context, msg *mailpen.Message) error {
+	p.sent <- msg
+	return nil
+}
+
+func (p *recordingProvider) Name() string { return "recording" }
+
+func (p *recordingProvider) Validate(*mailpen.Message) error { return nil }
+
+func (p *recordingProvider) Capabilities() mailpen.Capabilities { return mailpen.Capabilities{} }
@@ -0,0 +1,187 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/patrickward/mailpen"
+)
+
+// Job is a queued message along with its delivery state
+type Job struct {
+	ID          string           // Unique job identifier
+	Message     *mailpen.Message // Message to send
+	Attempts    int              // Number of delivery attempts made so far
+	MaxAttempts int              // Maximum delivery attempts (0 uses the queue's retry policy)
+	EnqueuedAt  time.Time        // When the job was first enqueued
+	NotBefore   time.Time        // Earliest time the job may be delivered
+	LastError   string           // Error from the most recent failed attempt
+	Priority    Priority         // Lane the job is delivered in
+}
+
+// Store persists queued jobs. Implementations must be safe for concurrent use by multiple workers.
+type Store interface {
+	// Push adds a new job to the store. It returns ErrQueueFull when the store is at capacity.
+	Push(ctx context.Context, job *Job) error
+	// Pop blocks until a job is ready for delivery or the context is done
+	Pop(ctx context.Context) (*Job, error)
+	// Ack marks a job as delivered
+	Ack(ctx context.Context, job *Job) error
+	// Retry returns a job to the store for another attempt at the given time
+	Retry(ctx context.Context, job *Job, at time.Time) error
+	// Fail moves a job that will not be retried to the dead-letter list
+	Fail(ctx context.Context, job *Job) error
+}
+
+// PendingLister is implemented by stores that can list the jobs they have not delivered yet, which Flush uses
+// to wait for ready jobs and report what is left
+type PendingLister interface {
+	Pending(ctx context.Context) ([]*Job, error)
+}
+
+// MemoryStore is a bounded, in-memory Store. Jobs are lost when the process exits.
+type MemoryStore struct {
+	capacity int
+	mu       sync.Mutex
+	pending  []*Job
+	dead     []*Job
+	notify   chan struct{}
+}
+
+// NewMemoryStore creates a new in-memory store holding at most capacity pending jobs. A capacity of zero
+// or less means the store is unbounded.
+func NewMemoryStore(capacity int) *MemoryStore {
+	return &MemoryStore{
+		capacity: capacity,
+		notify:   make(chan struct{}, 1),
+	}
+}
+
+// Push implements Store
+func (s *MemoryStore) Push(_ context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.capacity > 0 && len(s.pending) >= s.capacity {
+		return ErrQueueFull
+	}
+
+	s.pending = append(s.pending, job)
+	s.signal()
+	return nil
+}
+
+// Pop implements Store
+func (s *MemoryStore) Pop(ctx context.Context) (*Job, error) {
+	for {
+		job, wait := s.next(time.Now())
+		if job != nil {
+			return job, nil
+		}
+
+		var timer *time.Timer
+		var ready <-chan time.Time
+		if wait > 0 {
+			timer = time.NewTimer(wait)
+			ready = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-s.notify:
+		case <-ready:
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// next removes and returns the oldest job with the highest priority that is ready at now. When no job is
+// ready, it returns how long to wait for the earliest scheduled job, or zero if there is none.
+func (s *MemoryStore) next(now time.Time) (*Job, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var wait time.Duration
+	best := -1
+	for i, job := range s.pending {
+		if !job.NotBefore.After(now) {
+			if best < 0 || job.Priority > s.pending[best].Priority {
+				best = i
+			}
+			continue
+		}
+		if d := job.NotBefore.Sub(now); wait == 0 || d < wait {
+			wait = d
+		}
+	}
+
+	if best < 0 {
+		return nil, wait
+	}
+
+	job := s.pending[best]
+	s.pending = append(s.pending[:best], s.pending[best+1:]...)
+	if len(s.pending) > 0 {
+		s.signal() // Wake another worker for the remaining jobs
+	}
+	return job, 0
+}
+
+// Ack implements Store
+func (s *MemoryStore) Ack(context.Context, *Job) error {
+	return nil
+}
+
+// Retry implements Store. Retried jobs are accepted even when the store is at capacity.
+func (s *MemoryStore) Retry(_ context.Context, job *Job, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job.NotBefore = at
+	s.pending = append(s.pending, job)
+	s.signal()
+	return nil
+}
+
+// Fail implements Store
+func (s *MemoryStore) Fail(_ context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dead = append(s.dead, job)
+	return nil
+}
+
+// Len returns the number of pending jobs, including those waiting to be retried
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// Pending implements PendingLister. In-flight jobs are not included.
+func (s *MemoryStore) Pending(context.Context) ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Job(nil), s.pending...), nil
+}
+
+// DeadLetters returns the jobs that failed permanently
+func (s *MemoryStore) DeadLetters() []*Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Job(nil), s.dead...)
+}
+
+// signal wakes a waiting Pop without blocking. The caller must hold s.mu.
+func (s *MemoryStore) signal() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
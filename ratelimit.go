@@ -0,0 +1,177 @@
+package mailpen
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimit is a token-bucket limit. A zero PerSecond means unlimited.
+type RateLimit struct {
+	PerSecond float64 `env:"PER_SECOND"` // Sustained messages per second
+	Burst     int     `env:"BURST"`      // Messages that may be sent at once before the rate applies (defaults to 1)
+}
+
+// limiter returns a limiter for the limit, or nil when the limit is unlimited
+func (l RateLimit) limiter() *rate.Limiter {
+	if l.PerSecond <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(l.PerSecond), max(l.Burst, 1))
+}
+
+// ThrottleStats reports how often and how long sends waited on a rate limit
+type ThrottleStats struct {
+	Waits    int64         // Number of sends that had to wait
+	WaitTime time.Duration // Total time spent waiting
+}
+
+// RateLimiter limits the overall send rate and the rate per recipient domain. It is safe for concurrent use.
+type RateLimiter struct {
+	global  *rate.Limiter
+	domains map[string]*rate.Limiter
+
+	mu          sync.Mutex
+	globalStats ThrottleStats
+	domainStats map[string]ThrottleStats
+}
+
+// NewRateLimiter creates a rate limiter with an overall limit and limits for specific recipient domains (for
+// example, "gmail.com"). Domains are matched case-insensitively and exactly.
+func NewRateLimiter(global RateLimit, domains map[string]RateLimit) *RateLimiter {
+	l := &RateLimiter{
+		global:      global.limiter(),
+		domains:     make(map[string]*rate.Limiter),
+		domainStats: make(map[string]ThrottleStats),
+	}
+
+	for domain, limit := range domains {
+		if lim := limit.limiter(); lim != nil {
+			l.domains[strings.ToLower(domain)] = lim
+		}
+	}
+
+	return l
+}
+
+// WithRateLimiter sets the rate limiter applied before each provider send. Use it to share a limiter
+// between Mailpen instances.
+func WithRateLimiter(l *RateLimiter) Option {
+	return func(m *Mailpen) error {
+		m.limiter = l
+		return nil
+	}
+}
+
+// RateLimiter returns the rate limiter applied before each provider send, or nil if there is none
+func (m *Mailpen) RateLimiter() *RateLimiter {
+	return m.limiter
+}
+
+// Wait blocks until the message may be sent under the overall limit and the limit of every recipient domain.
+// It returns the total time waited, or the context's error if ctx is done first.
+func (l *RateLimiter) Wait(ctx context.Context, msg *Message) (time.Duration, error) {
+	waited, err := l.wait(ctx, l.global, "")
+	if err != nil {
+		return waited, err
+	}
+
+	for _, domain := range recipientDomains(msg) {
+		lim, ok := l.domains[domain]
+		if !ok {
+			continue
+		}
+		d, err := l.wait(ctx, lim, domain)
+		waited += d
+		if err != nil {
+			return waited, err
+		}
+	}
+
+	return waited, nil
+}
+
+// wait waits on a single limiter and records the wait under the domain, or globally when domain is empty
+func (l *RateLimiter) wait(ctx context.Context, lim *rate.Limiter, domain string) (time.Duration, error) {
+	if lim == nil {
+		return 0, nil
+	}
+
+	r := lim.Reserve()
+	delay := r.Delay()
+	if delay == 0 {
+		return 0, nil
+	}
+
+	l.record(domain, delay)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return delay, nil
+	case <-ctx.Done():
+		r.Cancel()
+		return 0, ctx.Err()
+	}
+}
+
+// record adds a wait to the statistics
+func (l *RateLimiter) record(domain string, delay time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if domain == "" {
+		l.globalStats.Waits++
+		l.globalStats.WaitTime += delay
+		return
+	}
+
+	stats := l.domainStats[domain]
+	stats.Waits++
+	stats.WaitTime += delay
+	l.domainStats[domain] = stats
+}
+
+// Stats returns the overall throttle statistics
+func (l *RateLimiter) Stats() ThrottleStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.globalStats
+}
+
+// DomainStats returns the throttle statistics for each limited domain that has had to wait
+func (l *RateLimiter) DomainStats() map[string]ThrottleStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := make(map[string]ThrottleStats, len(l.domainStats))
+	for domain, s := range l.domainStats {
+		stats[domain] = s
+	}
+	return stats
+}
+
+// recipientDomains returns the distinct, lowercased domains of all recipients of a message
+func recipientDomains(msg *Message) []string {
+	seen := make(map[string]bool)
+	var domains []string
+	for _, list := range [][]string{msg.To, msg.Cc, msg.Bcc} {
+		for _, addr := range list {
+			at := strings.LastIndex(addr, "@")
+			if at < 0 {
+				continue
+			}
+			domain := strings.ToLower(strings.TrimSpace(strings.TrimSuffix(addr[at+1:], ">")))
+			if domain != "" && !seen[domain] {
+				seen[domain] = true
+				domains = append(domains, domain)
+			}
+		}
+	}
+	return domains
+}
@@ -0,0 +1,103 @@
+package mailpen_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+func TestRateLimiter_Wait(t *testing.T) {
+	tests := []struct {
+		name       string
+		global     mailpen.RateLimit
+		domains    map[string]mailpen.RateLimit
+		recipients [][]string
+		wantWaits  int64
+		wantDomain map[string]int64
+	}{
+		{
+			name:       "unlimited",
+			recipients: [][]string{{"a@gmail.com"}, {"b@gmail.com"}, {"c@gmail.com"}},
+		},
+		{
+			name:       "global limit",
+			global:     mailpen.RateLimit{PerSecond: 100, Burst: 1},
+			recipients: [][]string{{"a@gmail.com"}, {"b@yahoo.com"}, {"c@example.com"}},
+			wantWaits:  2,
+		},
+		{
+			name:       "domain limit",
+			domains:    map[string]mailpen.RateLimit{"Gmail.com": {PerSecond: 100, Burst: 1}},
+			recipients: [][]string{{"a@gmail.com"}, {"b@yahoo.com"}, {"c@GMAIL.com"}},
+			wantDomain: map[string]int64{"gmail.com": 1},
+		},
+		{
+			name:       "duplicate domains in one message count once",
+			domains:    map[string]mailpen.RateLimit{"gmail.com": {PerSecond: 100, Burst: 1}},
+			recipients: [][]string{{"a@gmail.com", "b@gmail.com"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := mailpen.NewRateLimiter(tt.global, tt.domains)
+
+			for _, to := range tt.recipients {
+				_, err := limiter.Wait(context.Background(), &mailpen.Message{To: to})
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantWaits, limiter.Stats().Waits)
+			domainStats := limiter.DomainStats()
+			assert.Len(t, domainStats, len(tt.wantDomain))
+			for domain, waits := range tt.wantDomain {
+				assert.Equal(t, waits, domainStats[domain].Waits)
+				assert.Positive(t, domainStats[domain].WaitTime)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_WaitCanceled(t *testing.T) {
+	limiter := mailpen.NewRateLimiter(mailpen.RateLimit{PerSecond: 0.1}, nil)
+	msg := &mailpen.Message{To: []string{"a@example.com"}}
+
+	_, err := limiter.Wait(context.Background(), msg)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = limiter.Wait(ctx, msg)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestMailpen_RateLimit(t *testing.T) {
+	mock := &mockProvider{}
+	mp, err := mailpen.New(mock, &mailpen.Config{
+		From:      "sender@example.com",
+		RateLimit: mailpen.RateLimit{PerSecond: 50, Burst: 1},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, mp.RateLimiter())
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		msg := mailpen.NewMessage().To("recipient@example.com").Subject("Test").Must()
+		require.NoError(t, mp.Send(context.Background(), msg))
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+	assert.Equal(t, int64(2), mp.RateLimiter().Stats().Waits)
+	assert.Equal(t, 3, mock.sendCalls)
+}
+
+func TestMailpen_WithoutRateLimit(t *testing.T) {
+	mp, err := mailpen.New(&mockProvider{}, &mailpen.Config{From: "sender@example.com"})
+	require.NoError(t, err)
+	assert.Nil(t, mp.RateLimiter())
+}
@@ -0,0 +1,42 @@
+package mailpen
+
+import (
+	"context"
+	"maps"
+	"slices"
+)
+
+// Render prepares and renders a message the same way Send does, with the same template data, brand, render
+// hooks, HTML processing, and message processors, and returns the output without sending it. Use it for
+// preview endpoints, tests, and stored copies. The message itself is left unchanged; recipients are not
+// checked against suppressions or the safety net, and BeforeSend hooks do not run.
+func (m *Mailpen) Render(ctx context.Context, msg *Message) (*RenderedEmail, error) {
+	msg = msg.clone()
+
+	rendered, err := m.renderMessage(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Message processors may rewrite the bodies, so report the final message content
+	email := &RenderedEmail{Subject: msg.Subject, Text: msg.TextBody, HTML: msg.HTMLBody}
+	if rendered != nil {
+		email.Warnings = rendered.Warnings
+	}
+	return email, nil
+}
+
+// clone returns a copy of the message that can be modified without affecting the original. Attachment data
+// readers are shared.
+func (m *Message) clone() *Message {
+	c := *m
+	c.To = slices.Clone(m.To)
+	c.Cc = slices.Clone(m.Cc)
+	c.Bcc = slices.Clone(m.Bcc)
+	c.Tags = slices.Clone(m.Tags)
+	c.Attachments = slices.Clone(m.Attachments)
+	c.Data = maps.Clone(m.Data)
+	c.Metadata = maps.Clone(m.Metadata)
+	c.Headers = maps.Clone(m.Headers)
+	return &c
+}
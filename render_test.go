@@ -0,0 +1,71 @@
+package mailpen_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+// footerProcessor appends a footer to the HTML body
+type footerProcessor struct{}
+
+func (footerProcessor) ProcessMessage(_ context.Context, msg *mailpen.Message) error {
+	msg.HTMLBody += "<!-- footer -->"
+	msg.Metadata["processed"] = "yes"
+	return nil
+}
+
+func TestMailpen_Render(t *testing.T) {
+	mock := &mockProvider{}
+	beforeSend := 0
+	mp, err := mailpen.New(mock, &mailpen.Config{
+		From:              "sender@example.com",
+		CompanyName:       "Acme",
+		Sources:           []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+		MessageProcessors: []mailpen.MessageProcessor{footerProcessor{}},
+	}, mailpen.WithHooks(mailpen.Hooks{
+		BeforeSend: func(context.Context, *mailpen.Message) error {
+			beforeSend++
+			return nil
+		},
+	}))
+	require.NoError(t, err)
+
+	msg := mailpen.NewMessage().To("jane@example.com").Subject("Welcome").Template("welcome").Metadata("user", "1").
+		WithData(map[string]any{"Name": "Jane"}).Must()
+
+	rendered, err := mp.Render(context.Background(), msg)
+	require.NoError(t, err)
+
+	assert.Contains(t, rendered.HTML, "Welcome, Jane!")
+	assert.Contains(t, rendered.HTML, "Acme")
+	assert.True(t, strings.HasSuffix(rendered.HTML, "<!-- footer -->"))
+	assert.NotEmpty(t, rendered.Text)
+
+	assert.Empty(t, msg.HTMLBody, "the message is not modified")
+	assert.Empty(t, msg.From)
+	assert.Equal(t, map[string]string{"user": "1"}, msg.Metadata)
+	assert.Zero(t, beforeSend)
+	assert.Zero(t, mock.sendCalls)
+}
+
+func TestMailpen_RenderErrors(t *testing.T) {
+	mp, err := mailpen.New(&mockProvider{}, &mailpen.Config{
+		From:    "sender@example.com",
+		Sources: []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+	})
+	require.NoError(t, err)
+
+	_, err = mp.Render(context.Background(), &mailpen.Message{Template: "missing"})
+	assert.Error(t, err)
+
+	rendered, err := mp.Render(context.Background(), &mailpen.Message{TextBody: "plain"})
+	require.NoError(t, err)
+	assert.Equal(t, "plain", rendered.Text)
+	assert.Empty(t, rendered.HTML)
+}
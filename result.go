@@ -0,0 +1,70 @@
+package mailpen
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// SendResult describes the outcome of a send, so callers can persist correlation IDs
+type SendResult struct {
+	MessageID         string        // Mailpen message ID
+	Provider          string        // Name of the provider that handled the message
+	ProviderMessageID string        // Message ID reported by the provider, if any
+	Accepted          []string      // Recipients the provider accepted
+	Suppressed        []string      // Recipients skipped because they are on the suppression list
+	Redirected        []string      // Recipients replaced or dropped by the safety net
+	Scheduled         bool          // Whether the message was deferred to the queue for later delivery
+	Attempts          int           // Number of provider send attempts
+	RenderDuration    time.Duration // Time spent rendering templates
+	SendDuration      time.Duration // Time spent in the provider, including retries
+}
+
+// ProviderSelector is implemented by providers that delegate each message to another provider, such as
+// providers/router. Mailpen uses it to report the provider that actually handled a message.
+type ProviderSelector interface {
+	Provider(msg *Message) Provider
+}
+
+// resultKey is the context key of the SendResult being filled in by a send
+type resultKey struct{}
+
+// SendWithResult sends a message like Send and also returns a SendResult describing the outcome. The result
+// is returned even when the send fails, with the fields known at the time of the failure.
+func (m *Mailpen) SendWithResult(ctx context.Context, msg *Message) (*SendResult, error) {
+	res := &SendResult{}
+	err := m.Send(context.WithValue(ctx, resultKey{}, res), msg)
+	return res, err
+}
+
+// resultFrom returns the SendResult being filled in by a send, or nil when the caller did not ask for one
+func resultFrom(ctx context.Context) *SendResult {
+	res, _ := ctx.Value(resultKey{}).(*SendResult)
+	return res
+}
+
+// providerName returns the name of the provider that handles a message
+func (m *Mailpen) providerName(msg *Message) string {
+	if selector, ok := m.provider.(ProviderSelector); ok {
+		if p := selector.Provider(msg); p != nil {
+			return p.Name()
+		}
+	}
+	return m.provider.Name()
+}
+
+// recordResult fills in the provider outcome of a send
+func (m *Mailpen) recordResult(ctx context.Context, msg *Message, attempts int, duration time.Duration, err error) {
+	res := resultFrom(ctx)
+	if res == nil {
+		return
+	}
+
+	res.Provider = m.providerName(msg)
+	res.Attempts = attempts
+	res.SendDuration = duration
+	if err == nil {
+		res.ProviderMessageID = msg.ProviderMessageID
+		res.Accepted = slices.Concat(msg.To, msg.Cc, msg.Bcc)
+	}
+}
@@ -0,0 +1,141 @@
+package mailpen_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/providers/router"
+	"github.com/patrickward/mailpen/queue"
+	"github.com/patrickward/mailpen/suppression"
+)
+
+// idProvider reports a provider message ID for each send
+type idProvider struct {
+	mockProvider
+	name string
+}
+
+func (p *idProvider) Send(ctx context.Context, msg *mailpen.Message) error {
+	if err := p.mockProvider.Send(ctx, msg); err != nil {
+		return err
+	}
+	msg.ProviderMessageID = "provider-" + msg.ID
+	return nil
+}
+
+func (p *idProvider) Name() string { return p.name }
+
+func TestMailpen_SendWithResult(t *testing.T) {
+	store := suppression.NewMemoryStore()
+	require.NoError(t, store.Suppress(context.Background(), mailpen.Suppression{Address: "blocked@example.com"}))
+
+	provider := &idProvider{name: "ses"}
+	mp, err := mailpen.New(provider, &mailpen.Config{
+		From:      "sender@example.com",
+		Sources:   []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+		SafetyNet: &mailpen.SafetyNet{RedirectTo: "dev@example.com", AllowedDomains: []string{"example.com"}},
+	}, mailpen.WithSuppressionStore(store))
+	require.NoError(t, err)
+
+	msg := mailpen.NewMessage().
+		To("jane@example.com", "blocked@example.com").
+		Cc("bob@customer.com").
+		Template("welcome").
+		WithData(map[string]any{"Name": "Jane"}).
+		Must()
+
+	res, err := mp.SendWithResult(context.Background(), msg)
+	require.NoError(t, err)
+
+	assert.Equal(t, msg.ID, res.MessageID)
+	assert.Equal(t, "ses", res.Provider)
+	assert.Equal(t, "provider-"+msg.ID, res.ProviderMessageID)
+	assert.Equal(t, []string{"jane@example.com", "dev@example.com"}, res.Accepted)
+	assert.Equal(t, []string{"blocked@example.com"}, res.Suppressed)
+	assert.Equal(t, []string{"bob@customer.com"}, res.Redirected)
+	assert.Equal(t, 1, res.Attempts)
+	assert.Positive(t, res.RenderDuration)
+	assert.False(t, res.Scheduled)
+
+	t.Run("failed send", func(t *testing.T) {
+		provider.err = errors.New("provider down")
+		defer func() { provider.err = nil }()
+
+		msg := mailpen.NewMessage().To("jane@example.com").Subject("Hi").Must()
+		msg.TextBody = "Hello"
+
+		res, err := mp.SendWithResult(context.Background(), msg)
+		assert.Error(t, err)
+		require.NotNil(t, res)
+		assert.Equal(t, msg.ID, res.MessageID)
+		assert.Equal(t, 1, res.Attempts)
+		assert.Empty(t, res.Accepted)
+		assert.Empty(t, res.ProviderMessageID)
+	})
+}
+
+func TestMailpen_SendWithResultScheduled(t *testing.T) {
+	q := queue.New(queue.NewMemoryStore(10))
+	mp, err := mailpen.New(&mockProvider{}, &mailpen.Config{From: "sender@example.com"}, mailpen.WithQueue(q))
+	require.NoError(t, err)
+
+	msg := mailpen.NewMessage().To("jane@example.com").Subject("Later").SendAt(time.Now().Add(time.Hour)).Must()
+	msg.TextBody = "Hello"
+
+	res, err := mp.SendWithResult(context.Background(), msg)
+	require.NoError(t, err)
+	assert.True(t, res.Scheduled)
+	assert.Equal(t, msg.ID, res.MessageID)
+	assert.Zero(t, res.Attempts)
+}
+
+func TestMailpen_SendWithResultRouted(t *testing.T) {
+	marketing := &idProvider{name: "mailgun"}
+	r, err := router.New(&idProvider{name: "ses"}, router.Route(router.Tag("marketing"), marketing))
+	require.NoError(t, err)
+
+	mp, err := mailpen.New(r, &mailpen.Config{From: "sender@example.com"})
+	require.NoError(t, err)
+
+	msg := mailpen.NewMessage().To("jane@example.com").Subject("Sale").Tag("marketing").Must()
+	msg.TextBody = "Hello"
+
+	res, err := mp.SendWithResult(context.Background(), msg)
+	require.NoError(t, err)
+	assert.Equal(t, "mailgun", res.Provider)
+	assert.Equal(t, 1, marketing.sendCalls)
+}
+
+func TestResults_AsyncAndBatch(t *testing.T) {
+	mp, err := mailpen.New(&idProvider{name: "ses"}, &mailpen.Config{From: "sender@example.com"})
+	require.NoError(t, err)
+
+	msg := mailpen.NewMessage().To("jane@example.com").Subject("Hi").Must()
+	msg.TextBody = "Hello"
+
+	f := mp.SendAsync(context.Background(), msg)
+	require.NoError(t, f.Wait(context.Background()))
+	require.NotNil(t, f.Result())
+	assert.Equal(t, "provider-"+msg.ID, f.Result().ProviderMessageID)
+
+	batch := []*mailpen.Message{
+		mailpen.NewMessage().To("a@example.com").Subject("A").Must(),
+		mailpen.NewMessage().To("b@example.com").Subject("B").Must(),
+	}
+	for _, m := range batch {
+		m.TextBody = "Hello"
+	}
+
+	for _, r := range mp.SendMany(context.Background(), batch) {
+		require.NoError(t, r.Err)
+		require.NotNil(t, r.Result)
+		assert.Equal(t, r.Message.To, r.Result.Accepted)
+		assert.Equal(t, r.Message.ID, r.Result.MessageID)
+	}
+}
@@ -0,0 +1,123 @@
+package mailpen
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy decides whether a failed provider send is retried
+type RetryPolicy interface {
+	// Retry reports whether to make another attempt after the given attempt (starting at 1) failed with err,
+	// and how long to wait before it
+	Retry(attempt int, err error) (time.Duration, bool)
+}
+
+// NoRetry is a RetryPolicy that never retries
+var NoRetry RetryPolicy = noRetry{}
+
+type noRetry struct{}
+
+func (noRetry) Retry(int, error) (time.Duration, bool) { return 0, false }
+
+// ExponentialRetry retries retryable errors with exponential backoff and jitter
+type ExponentialRetry struct {
+	MaxAttempts  int              // Maximum attempts, including the first (defaults to 3)
+	InitialDelay time.Duration    // Delay before the first retry (defaults to 500ms)
+	MaxDelay     time.Duration    // Upper bound for the delay (defaults to 30s)
+	Jitter       float64          // Fraction of the delay to randomize, from 0 to 1 (e.g. 0.2 for ±20%)
+	Retryable    func(error) bool // Classifies errors (defaults to IsRetryable)
+}
+
+// Retry implements RetryPolicy
+func (p ExponentialRetry) Retry(attempt int, err error) (time.Duration, bool) {
+	maxAttempts := p.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = 3
+	}
+	if attempt >= maxAttempts {
+		return 0, false
+	}
+
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+	if !retryable(err) {
+		return 0, false
+	}
+
+	initial, limit := p.InitialDelay, p.MaxDelay
+	if initial == 0 {
+		initial = 500 * time.Millisecond
+	}
+	if limit == 0 {
+		limit = 30 * time.Second
+	}
+
+	delay := initial
+	for i := 1; i < attempt && delay < limit; i++ {
+		delay *= 2
+	}
+	delay = min(delay, limit)
+
+	if p.Jitter > 0 {
+		spread := float64(delay) * min(p.Jitter, 1)
+		delay += time.Duration(spread * (2*rand.Float64() - 1))
+	}
+
+	return delay, true
+}
+
+// RetryPolicyProvider is implemented by providers that bring their own retry policy, such as the SMTP provider
+// when its deprecated RetryCount is set. The policy is used when neither Config.RetryPolicy nor WithRetryPolicy
+// sets one; a nil policy leaves the default.
+type RetryPolicyProvider interface {
+	DefaultRetryPolicy() RetryPolicy
+}
+
+// WithRetryPolicy sets the policy used to retry failed provider sends
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(m *Mailpen) error {
+		m.retry = policy
+		return nil
+	}
+}
+
+// permanentError marks an error as not worth retrying
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps an error so that it is not retried. Providers use it for failures such as rejected recipients.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether an error was marked with Permanent
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
+
+// IsRetryable reports whether a send error is worth retrying. Permanent errors and context cancellation are
+// not retryable, and errors that report whether they are temporary (such as SMTP send errors) are trusted.
+func IsRetryable(err error) bool {
+	if err == nil || IsPermanent(err) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var temp interface{ IsTemp() bool }
+	if errors.As(err, &temp) {
+		return temp.IsTemp()
+	}
+
+	return true
+}
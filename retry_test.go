@@ -0,0 +1,229 @@
+package mailpen_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+// tempError reports whether it is temporary, like an SMTP send error
+type tempError struct{ temp bool }
+
+func (e tempError) Error() string { return "smtp error" }
+func (e tempError) IsTemp() bool  { return e.temp }
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "plain error", err: errors.New("connection reset"), want: true},
+		{name: "permanent", err: mailpen.Permanent(errors.New("bad recipient")), want: false},
+		{name: "wrapped permanent", err: fmt.Errorf("send: %w", mailpen.Permanent(errors.New("bad recipient"))), want: false},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "deadline", err: fmt.Errorf("send: %w", context.DeadlineExceeded), want: false},
+		{name: "temporary", err: fmt.Errorf("send: %w", tempError{temp: true}), want: true},
+		{name: "not temporary", err: tempError{temp: false}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, mailpen.IsRetryable(tt.err))
+		})
+	}
+}
+
+func TestExponentialRetry(t *testing.T) {
+	policy := mailpen.ExponentialRetry{MaxAttempts: 4, InitialDelay: time.Second, MaxDelay: 3 * time.Second}
+	err := errors.New("timeout")
+
+	tests := []struct {
+		attempt   int
+		wantDelay time.Duration
+		wantRetry bool
+	}{
+		{attempt: 1, wantDelay: time.Second, wantRetry: true},
+		{attempt: 2, wantDelay: 2 * time.Second, wantRetry: true},
+		{attempt: 3, wantDelay: 3 * time.Second, wantRetry: true},
+		{attempt: 4, wantRetry: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("attempt %d", tt.attempt), func(t *testing.T) {
+			delay, retry := policy.Retry(tt.attempt, err)
+			assert.Equal(t, tt.wantRetry, retry)
+			assert.Equal(t, tt.wantDelay, delay)
+		})
+	}
+
+	_, retry := policy.Retry(1, mailpen.Permanent(err))
+	assert.False(t, retry)
+
+	jittered := mailpen.ExponentialRetry{InitialDelay: time.Second, Jitter: 0.5}
+	for i := 0; i < 20; i++ {
+		delay, retry := jittered.Retry(1, err)
+		require.True(t, retry)
+		assert.GreaterOrEqual(t, delay, 500*time.Millisecond)
+		assert.LessOrEqual(t, delay, 1500*time.Millisecond)
+	}
+
+	custom := mailpen.ExponentialRetry{Retryable: func(error) bool { return false }}
+	_, retry = custom.Retry(1, err)
+	assert.False(t, retry)
+}
+
+// flakyProvider implements mailpen.Provider and fails a number of times before succeeding
+type flakyProvider struct {
+	mockProvider
+	failures int
+	failWith error
+}
+
+func (p *flakyProvider) Send(ctx context.Context, msg *mailpen.Message) error {
+	p.sendCalls++
+	if p.sendCalls <= p.failures {
+		return p.failWith
+	}
+	return nil
+}
+
+func TestMailpen_Retry(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    mailpen.RetryPolicy
+		failures  int
+		failWith  error
+		wantErr   bool
+		wantCalls int
+	}{
+		{
+			name:      "no retry by default",
+			failures:  1,
+			failWith:  errors.New("timeout"),
+			wantErr:   true,
+			wantCalls: 1,
+		},
+		{
+			name:      "retries until success",
+			policy:    mailpen.ExponentialRetry{MaxAttempts: 3, InitialDelay: time.Millisecond},
+			failures:  2,
+			failWith:  errors.New("timeout"),
+			wantCalls: 3,
+		},
+		{
+			name:      "gives up after max attempts",
+			policy:    mailpen.ExponentialRetry{MaxAttempts: 2, InitialDelay: time.Millisecond},
+			failures:  5,
+			failWith:  errors.New("timeout"),
+			wantErr:   true,
+			wantCalls: 2,
+		},
+		{
+			name:      "permanent errors are not retried",
+			policy:    mailpen.ExponentialRetry{MaxAttempts: 3, InitialDelay: time.Millisecond},
+			failures:  5,
+			failWith:  mailpen.Permanent(errors.New("mailbox does not exist")),
+			wantErr:   true,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &flakyProvider{failures: tt.failures, failWith: tt.failWith}
+
+			var afterSend int
+			mp, err := mailpen.New(provider, &mailpen.Config{
+				From:        "sender@example.com",
+				RetryPolicy: tt.policy,
+			}, mailpen.WithHooks(mailpen.Hooks{
+				AfterSend: func(context.Context, *mailpen.Message, error) { afterSend++ },
+			}))
+			require.NoError(t, err)
+
+			msg := mailpen.NewMessage().To("recipient@example.com").Subject("Test").Must()
+			err = mp.Send(context.Background(), msg)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCalls, provider.sendCalls)
+			assert.Equal(t, 1, afterSend)
+		})
+	}
+}
+
+func TestMailpen_RetryCanceled(t *testing.T) {
+	provider := &flakyProvider{failures: 5, failWith: errors.New("timeout")}
+	mp, err := mailpen.New(provider, &mailpen.Config{From: "sender@example.com"},
+		mailpen.WithRetryPolicy(mailpen.ExponentialRetry{MaxAttempts: 5, InitialDelay: time.Hour}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	msg := mailpen.NewMessage().To("recipient@example.com").Subject("Test").Must()
+	assert.ErrorIs(t, mp.Send(ctx, msg), context.DeadlineExceeded)
+	assert.Equal(t, 1, provider.sendCalls)
+}
+
+// attachmentReadingProvider reads every attachment on each send, like a real provider, and fails the first attempt
+type attachmentReadingProvider struct {
+	mockProvider
+	bodies []string
+}
+
+func (p *attachmentReadingProvider) Send(ctx context.Context, msg *mailpen.Message) error {
+	var body strings.Builder
+	for _, att := range msg.Attachments {
+		if _, err := io.Copy(&body, att.Data); err != nil {
+			return err
+		}
+	}
+	p.bodies = append(p.bodies, body.String())
+	if len(p.bodies) == 1 {
+		return errors.New("timeout")
+	}
+	return nil
+}
+
+func TestMailpen_RetryAttachments(t *testing.T) {
+	tests := []struct {
+		name string
+		data io.Reader
+	}{
+		{name: "seekable reader", data: bytes.NewReader([]byte("hello world"))},
+		{name: "streamed reader", data: struct{ io.Reader }{strings.NewReader("hello world")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &attachmentReadingProvider{}
+			mp, err := mailpen.New(provider, &mailpen.Config{From: "sender@example.com"},
+				mailpen.WithRetryPolicy(mailpen.ExponentialRetry{MaxAttempts: 2, InitialDelay: time.Millisecond}))
+			require.NoError(t, err)
+
+			msg := mailpen.NewMessage().
+				To("recipient@example.com").
+				Subject("Test").
+				Attach("hello.txt", tt.data).
+				Must()
+			require.NoError(t, mp.Send(context.Background(), msg))
+
+			assert.Equal(t, []string{"hello world", "hello world"}, provider.bodies)
+		})
+	}
+}
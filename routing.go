@@ -0,0 +1,113 @@
+package mailpen
+
+import (
+	"context"
+	"path"
+	"slices"
+)
+
+// Condition reports whether a processor applies to the email being rendered
+type Condition func(rc *RenderContext) bool
+
+// ForLayouts matches emails rendered with one of the given layouts
+func ForLayouts(layouts ...string) Condition {
+	return func(rc *RenderContext) bool {
+		return slices.Contains(layouts, rc.Layout)
+	}
+}
+
+// ForTemplates matches emails whose template name matches one of the given path.Match patterns
+// (e.g. "auth/*" or "password-reset")
+func ForTemplates(patterns ...string) Condition {
+	return func(rc *RenderContext) bool {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, rc.Template); ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ForTags matches messages tagged with any of the given tags
+func ForTags(tags ...string) Condition {
+	return func(rc *RenderContext) bool {
+		if rc.Message == nil {
+			return false
+		}
+		for _, tag := range rc.Message.Tags {
+			if slices.Contains(tags, tag) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not inverts a condition
+func Not(cond Condition) Condition {
+	return func(rc *RenderContext) bool {
+		return !cond(rc)
+	}
+}
+
+// When applies a context processor only to emails matching the condition
+func When(cond Condition, p ContextProcessor) ContextProcessor {
+	return ContextProcessorFunc(func(ctx context.Context, rc *RenderContext) (string, error) {
+		if !cond(rc) {
+			return rc.HTML, nil
+		}
+		return p.Process(ctx, rc)
+	})
+}
+
+// WhenMessage applies a message processor only to messages matching the condition. The condition receives the
+// message's template and the layout it was rendered with, which is the default layout when the message does
+// not name one.
+func WhenMessage(cond Condition, p MessageProcessor) MessageProcessor {
+	return messageProcessorFunc(func(ctx context.Context, msg *Message) error {
+		layout := msg.Layout
+		if resolved, ok := ctx.Value(layoutKey{}).(string); ok && layout == "" {
+			layout = resolved
+		}
+
+		rc := &RenderContext{
+			HTML:     msg.HTMLBody,
+			Template: msg.Template,
+			Layout:   layout,
+			Locale:   msg.Locale,
+			Message:  msg,
+		}
+		if !cond(rc) {
+			return nil
+		}
+		return p.ProcessMessage(ctx, msg)
+	})
+}
+
+// messageProcessorFunc adapts a function to the MessageProcessor interface
+type messageProcessorFunc func(ctx context.Context, msg *Message) error
+
+func (f messageProcessorFunc) ProcessMessage(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
+
+// layoutKey is the context key of the layout a message was rendered with, set for its message processors
+type layoutKey struct{}
+
+// messageLayout returns the layout a message is rendered with: its own, the Mailpen's default, or the template
+// manager's default. It is empty for messages without a template.
+func (m *Mailpen) messageLayout(msg *Message) string {
+	switch {
+	case msg.Template == "":
+		return ""
+	case msg.Layout != "":
+		return msg.Layout
+	case m.defaultLayout != "":
+		return m.defaultLayout
+	case m.templateMgr != nil:
+		return m.templateMgr.defaultLayout
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,104 @@
+package mailpen_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+func TestConditions(t *testing.T) {
+	rc := &mailpen.RenderContext{
+		Template: "auth/password-reset",
+		Layout:   "base",
+		Message:  &mailpen.Message{Tags: []string{"transactional"}},
+	}
+
+	assert.True(t, mailpen.ForLayouts("marketing", "base")(rc))
+	assert.False(t, mailpen.ForLayouts("marketing")(rc))
+	assert.True(t, mailpen.ForTemplates("auth/*")(rc))
+	assert.False(t, mailpen.ForTemplates("welcome", "billing/*")(rc))
+	assert.True(t, mailpen.ForTags("transactional")(rc))
+	assert.False(t, mailpen.ForTags("bulk")(rc))
+	assert.True(t, mailpen.Not(mailpen.ForTags("bulk"))(rc))
+	assert.False(t, mailpen.ForTags("transactional")(&mailpen.RenderContext{}))
+}
+
+func TestWhen(t *testing.T) {
+	marker := mailpen.ContextProcessorFunc(func(ctx context.Context, rc *mailpen.RenderContext) (string, error) {
+		return rc.HTML + "<!-- tracked -->", nil
+	})
+
+	mock := &mockProvider{}
+	mp, err := mailpen.New(mock, &mailpen.Config{
+		From: "sender@example.com",
+		Processors: []mailpen.ContextProcessor{
+			mailpen.When(mailpen.Not(mailpen.ForTags("transactional")), marker),
+		},
+		Sources: []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+	})
+	require.NoError(t, err)
+
+	msg := mailpen.NewMessage().To("recipient@example.com").Template("welcome").Must()
+	require.NoError(t, mp.Send(context.Background(), msg))
+	assert.Contains(t, mock.lastMessage.HTMLBody, "<!-- tracked -->")
+
+	msg = mailpen.NewMessage().To("recipient@example.com").Template("welcome").Tag("transactional").Must()
+	require.NoError(t, mp.Send(context.Background(), msg))
+	assert.NotContains(t, mock.lastMessage.HTMLBody, "<!-- tracked -->")
+}
+
+func TestWhenMessage(t *testing.T) {
+	calls := 0
+	counter := &countingProcessor{calls: &calls}
+	p := mailpen.WhenMessage(mailpen.ForTemplates("newsletter-*"), counter)
+
+	require.NoError(t, p.ProcessMessage(context.Background(), &mailpen.Message{Template: "newsletter-march"}))
+	require.NoError(t, p.ProcessMessage(context.Background(), &mailpen.Message{Template: "password-reset"}))
+	assert.Equal(t, 1, calls)
+}
+
+func TestWhenMessage_DefaultLayout(t *testing.T) {
+	tests := []struct {
+		name      string
+		layout    string
+		wantCalls int
+	}{
+		{name: "unset layout matches the default", wantCalls: 1},
+		{name: "named default layout", layout: "base", wantCalls: 1},
+		{name: "other layout", layout: "marketing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			mp, err := mailpen.New(&mockProvider{}, &mailpen.Config{
+				From:    "sender@example.com",
+				Sources: []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+				MessageProcessors: []mailpen.MessageProcessor{
+					mailpen.WhenMessage(mailpen.ForLayouts("base"), &countingProcessor{calls: &calls}),
+				},
+			})
+			require.NoError(t, err)
+
+			msg := mailpen.NewMessage().To("recipient@example.com").Subject("Test").Template("simple").Must()
+			msg.Layout = tt.layout
+			require.NoError(t, mp.Send(context.Background(), msg))
+
+			assert.Equal(t, tt.wantCalls, calls)
+			assert.Equal(t, tt.layout, msg.Layout, "the message layout is left as set")
+		})
+	}
+}
+
+type countingProcessor struct {
+	calls *int
+}
+
+func (p *countingProcessor) ProcessMessage(context.Context, *mailpen.Message) error {
+	*p.calls++
+	return nil
+}
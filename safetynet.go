@@ -0,0 +1,102 @@
+package mailpen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// ErrRecipientNotAllowed is returned when the safety net leaves a message without any allowed recipients
+var ErrRecipientNotAllowed = errors.New("recipient is not allowed in this environment")
+
+// SafetyNet keeps development and staging environments from emailing real recipients. Recipients that are
+// not allowed are replaced with RedirectTo, or dropped when RedirectTo is empty, and the subject is
+// annotated with the original recipients.
+type SafetyNet struct {
+	RedirectTo       string   // Catch-all address that receives mail for recipients that are not allowed
+	AllowedDomains   []string // Recipient domains that are delivered as usual (e.g. "example.com")
+	AllowedAddresses []string // Recipient addresses that are delivered as usual
+}
+
+// WithSafetyNet enables the safety net for non-production environments
+func WithSafetyNet(net SafetyNet) Option {
+	return func(m *Mailpen) error {
+		m.safetyNet = &net
+		return nil
+	}
+}
+
+// allowed reports whether the safety net lets mail through to an address. The catch-all address is always
+// allowed, so a message that was already redirected passes through unchanged when it is sent again.
+func (s *SafetyNet) allowed(address string) bool {
+	address = NormalizeAddress(address)
+	if s.RedirectTo != "" && NormalizeAddress(s.RedirectTo) == address {
+		return true
+	}
+	for _, allowed := range s.AllowedAddresses {
+		if NormalizeAddress(allowed) == address {
+			return true
+		}
+	}
+
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return false
+	}
+	return slices.ContainsFunc(s.AllowedDomains, func(domain string) bool {
+		return strings.EqualFold(strings.TrimSpace(domain), address[at+1:])
+	})
+}
+
+// applySafetyNet rewrites or drops the recipients of a message that the safety net does not allow, and returns
+// the blocked recipients for annotateSubject. It returns a permanent ErrRecipientNotAllowed when no recipients
+// remain.
+func (m *Mailpen) applySafetyNet(ctx context.Context, msg *Message) ([]string, error) {
+	net := m.safetyNet
+	if net == nil {
+		return nil, nil
+	}
+
+	var blocked []string
+	filter := func(list []string) []string {
+		var kept []string
+		for _, addr := range list {
+			if net.allowed(addr) {
+				kept = append(kept, addr)
+			} else {
+				blocked = append(blocked, addr)
+			}
+		}
+		return kept
+	}
+	msg.To, msg.Cc, msg.Bcc = filter(msg.To), filter(msg.Cc), filter(msg.Bcc)
+
+	if len(blocked) == 0 {
+		return nil, nil
+	}
+	if res := resultFrom(ctx); res != nil {
+		res.Redirected = blocked
+	}
+
+	if net.RedirectTo != "" && !slices.Contains(msg.To, net.RedirectTo) {
+		msg.To = append(msg.To, net.RedirectTo)
+	}
+
+	if len(msg.To)+len(msg.Cc)+len(msg.Bcc) == 0 {
+		return nil, Permanent(fmt.Errorf("%w: %s", ErrRecipientNotAllowed, strings.Join(blocked, ", ")))
+	}
+
+	m.logger.InfoContext(ctx, "mailpen: safety net rewrote recipients", append(m.logAttrs(msg), slog.Int("blocked", len(blocked)))...)
+	return blocked, nil
+}
+
+// annotateSubject prefixes the subject with the recipients the safety net blocked. It runs after rendering, so
+// the annotation is added to the template's subject rather than replacing it.
+func annotateSubject(msg *Message, blocked []string) {
+	if len(blocked) > 0 {
+		msg.Subject = fmt.Sprintf("[%s] %s", strings.Join(blocked, ", "), msg.Subject)
+	}
+}
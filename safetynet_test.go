@@ -0,0 +1,120 @@
+package mailpen_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+func TestMailpen_SafetyNet(t *testing.T) {
+	tests := []struct {
+		name        string
+		net         mailpen.SafetyNet
+		to          []string
+		cc          []string
+		wantErr     error
+		wantTo      []string
+		wantCc      []string
+		wantSubject string
+	}{
+		{
+			name:        "redirects all recipients",
+			net:         mailpen.SafetyNet{RedirectTo: "dev@example.com"},
+			to:          []string{"jane@customer.com"},
+			cc:          []string{"bob@customer.com"},
+			wantTo:      []string{"dev@example.com"},
+			wantSubject: "[jane@customer.com, bob@customer.com] Welcome",
+		},
+		{
+			name:        "allowed domains pass through",
+			net:         mailpen.SafetyNet{RedirectTo: "dev@example.com", AllowedDomains: []string{"Example.com"}},
+			to:          []string{"qa@example.com", "jane@customer.com"},
+			wantTo:      []string{"qa@example.com", "dev@example.com"},
+			wantSubject: "[jane@customer.com] Welcome",
+		},
+		{
+			name:        "allowed addresses pass through unchanged",
+			net:         mailpen.SafetyNet{RedirectTo: "dev@example.com", AllowedAddresses: []string{"tester@customer.com"}},
+			to:          []string{"Tester <tester@customer.com>"},
+			wantTo:      []string{"Tester <tester@customer.com>"},
+			wantSubject: "Welcome",
+		},
+		{
+			name:        "allowlist drops other recipients",
+			net:         mailpen.SafetyNet{AllowedDomains: []string{"example.com"}},
+			to:          []string{"qa@example.com"},
+			cc:          []string{"jane@customer.com"},
+			wantTo:      []string{"qa@example.com"},
+			wantSubject: "[jane@customer.com] Welcome",
+		},
+		{
+			name:    "no allowed recipients",
+			net:     mailpen.SafetyNet{AllowedDomains: []string{"example.com"}},
+			to:      []string{"jane@customer.com"},
+			wantErr: mailpen.ErrRecipientNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &mockProvider{}
+			mp, err := mailpen.New(mock, &mailpen.Config{From: "sender@example.com"}, mailpen.WithSafetyNet(tt.net))
+			require.NoError(t, err)
+
+			msg := mailpen.NewMessage().To(tt.to...).Cc(tt.cc...).Subject("Welcome").Must()
+			msg.TextBody = "Hello"
+
+			err = mp.Send(context.Background(), msg)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.True(t, mailpen.IsPermanent(err))
+				assert.Equal(t, 0, mock.sendCalls)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTo, mock.lastMessage.To)
+			assert.Equal(t, tt.wantCc, mock.lastMessage.Cc)
+			assert.Equal(t, tt.wantSubject, mock.lastMessage.Subject)
+		})
+	}
+}
+
+func TestMailpen_SafetyNetResend(t *testing.T) {
+	mock := &mockProvider{}
+	mp, err := mailpen.New(mock, &mailpen.Config{
+		From:      "sender@example.com",
+		SafetyNet: &mailpen.SafetyNet{RedirectTo: "dev@example.com"},
+	})
+	require.NoError(t, err)
+
+	msg := mailpen.NewMessage().To("jane@customer.com").Subject("Welcome").Must()
+	msg.TextBody = "Hello"
+
+	require.NoError(t, mp.Send(context.Background(), msg))
+	require.NoError(t, mp.Send(context.Background(), msg))
+
+	assert.Equal(t, []string{"dev@example.com"}, mock.lastMessage.To)
+	assert.Equal(t, "[jane@customer.com] Welcome", mock.lastMessage.Subject)
+}
+
+func TestMailpen_SafetyNetTemplateSubject(t *testing.T) {
+	mock := &mockProvider{}
+	mp, err := mailpen.New(mock, &mailpen.Config{
+		From:        "sender@example.com",
+		CompanyName: "ACME",
+		SafetyNet:   &mailpen.SafetyNet{RedirectTo: "dev@example.com"},
+		Sources:     []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+	})
+	require.NoError(t, err)
+
+	msg := mailpen.NewMessage().To("real@customer.com").Template("welcome").Must()
+
+	require.NoError(t, mp.Send(context.Background(), msg))
+	assert.Equal(t, []string{"dev@example.com"}, mock.lastMessage.To)
+	assert.Equal(t, "[real@customer.com] Welcome to ACME", mock.lastMessage.Subject)
+}
@@ -0,0 +1,49 @@
+package mailpen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sampleExtensions are the sample data file extensions, in lookup order
+var sampleExtensions = []string{".sample.json", ".sample.yaml", ".sample.yml"}
+
+// SampleData returns the sample data of an email from emails/<name>.sample.json, .sample.yaml, or .sample.yml
+// (last source wins). Previews and the mailpen CLI render emails with it when no other data is given. It
+// returns nil when the email has no sample data file.
+func (m *Manager) SampleData(name string) (map[string]any, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.sampleData(name)
+}
+
+// sampleData reads the sample data of an email. The caller must hold m.mu.
+func (m *Manager) sampleData(name string) (map[string]any, error) {
+	for i := len(m.sources) - 1; i >= 0; i-- {
+		for _, ext := range sampleExtensions {
+			filename := path.Join(EmailsDir, name+ext)
+			content, err := fs.ReadFile(m.sources[i].FS, filename)
+			if err != nil {
+				continue
+			}
+
+			data := map[string]any{}
+			if ext == ".sample.json" {
+				err = json.Unmarshal(content, &data)
+			} else {
+				err = yaml.Unmarshal(content, &data)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse sample data %s: %w", filename, err)
+			}
+			return data, nil
+		}
+	}
+
+	return nil, nil
+}
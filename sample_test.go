@@ -0,0 +1,59 @@
+package mailpen_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+func TestManager_SampleData(t *testing.T) {
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{
+			{Name: "base", FS: fstest.MapFS{
+				"emails/welcome.html":              {Data: []byte(`{{define "content"}}Hi {{.Name}}{{end}}`)},
+				"emails/welcome.sample.json":       {Data: []byte(`{"Name": "Ada"}`)},
+				"emails/receipt.html":              {Data: []byte(`{{define "content"}}{{.Total}}{{end}}`)},
+				"emails/receipt.sample.json":       {Data: []byte(`{"Total": 10}`)},
+				"emails/account/reset.sample.yaml": {Data: []byte("Link: https://example.com/reset\n")},
+				"emails/broken.sample.json":        {Data: []byte(`{`)},
+			}},
+			{Name: "override", FS: fstest.MapFS{
+				"emails/receipt.sample.yml": {Data: []byte("Total: 20\n")},
+			}},
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		email   string
+		want    map[string]any
+		wantErr bool
+	}{
+		{name: "json", email: "welcome", want: map[string]any{"Name": "Ada"}},
+		{name: "later source wins", email: "receipt", want: map[string]any{"Total": 20}},
+		{name: "nested yaml", email: "account/reset", want: map[string]any{"Link": "https://example.com/reset"}},
+		{name: "no sample", email: "missing", want: nil},
+		{name: "invalid", email: "broken", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := manager.SampleData(tt.email)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, data)
+		})
+	}
+
+	emails, err := manager.Emails()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"receipt", "welcome"}, emails)
+}
@@ -0,0 +1,55 @@
+package mailpen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSchedulingUnavailable is returned when a message is scheduled for later delivery, but the provider does
+// not support scheduling and no queue is configured
+var ErrSchedulingUnavailable = errors.New("scheduled delivery requires a provider with scheduling support or a queue")
+
+// schedule defers a message with a future SendAt to the queue when the provider cannot schedule it natively.
+// It reports whether the message was deferred.
+func (m *Mailpen) schedule(ctx context.Context, msg *Message) (bool, error) {
+	if msg.SendAt.IsZero() || !msg.SendAt.After(m.clock.Now()) {
+		return false, nil
+	}
+
+	if m.provider.Capabilities().SupportsScheduling {
+		return false, nil
+	}
+
+	if m.queue == nil {
+		return false, ErrSchedulingUnavailable
+	}
+
+	if err := m.Enqueue(ctx, msg); err != nil {
+		return false, fmt.Errorf("failed to schedule message: %w", err)
+	}
+
+	if res := resultFrom(ctx); res != nil {
+		res.MessageID = msg.ID
+		res.Scheduled = true
+	}
+
+	return true, nil
+}
+
+// NextLocalTime returns the first time at or after from when the clock in loc reads hour:minute. Use it to
+// schedule messages for a recipient's local time, such as "9am in the recipient's timezone".
+func NextLocalTime(from time.Time, loc *time.Location, hour, minute int) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	local := from.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, loc)
+	if next.Before(local) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, hour, minute, 0, 0, loc)
+	}
+
+	return next
+}
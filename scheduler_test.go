@@ -0,0 +1,119 @@
+package mailpen_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/queue"
+)
+
+func TestNextLocalTime(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		from time.Time
+		loc  *time.Location
+		want time.Time
+	}{
+		{
+			name: "later the same day",
+			from: time.Date(2024, 3, 1, 6, 0, 0, 0, newYork),
+			loc:  newYork,
+			want: time.Date(2024, 3, 1, 9, 0, 0, 0, newYork),
+		},
+		{
+			name: "already passed today",
+			from: time.Date(2024, 3, 1, 10, 0, 0, 0, newYork),
+			loc:  newYork,
+			want: time.Date(2024, 3, 2, 9, 0, 0, 0, newYork),
+		},
+		{
+			name: "exactly now",
+			from: time.Date(2024, 3, 1, 9, 0, 0, 0, newYork),
+			loc:  newYork,
+			want: time.Date(2024, 3, 1, 9, 0, 0, 0, newYork),
+		},
+		{
+			name: "from another timezone",
+			from: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), // 07:00 in New York
+			loc:  newYork,
+			want: time.Date(2024, 3, 1, 9, 0, 0, 0, newYork),
+		},
+		{
+			name: "across daylight saving change",
+			from: time.Date(2024, 3, 9, 10, 0, 0, 0, newYork),
+			loc:  newYork,
+			want: time.Date(2024, 3, 10, 9, 0, 0, 0, newYork),
+		},
+		{
+			name: "nil location uses UTC",
+			from: time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mailpen.NextLocalTime(tt.from, tt.loc, 9, 0)
+			assert.True(t, tt.want.Equal(got), "want %s, got %s", tt.want, got)
+		})
+	}
+}
+
+func TestMailpen_SendScheduled(t *testing.T) {
+	t.Run("without queue", func(t *testing.T) {
+		mock := &mockProvider{}
+		mp, err := mailpen.New(mock, &mailpen.Config{From: "sender@example.com"})
+		require.NoError(t, err)
+
+		msg := mailpen.NewMessage().To("recipient@example.com").Subject("Later").SendAt(time.Now().Add(time.Hour)).Must()
+		assert.ErrorIs(t, mp.Send(context.Background(), msg), mailpen.ErrSchedulingUnavailable)
+		assert.Equal(t, 0, mock.sendCalls)
+	})
+
+	t.Run("native scheduling", func(t *testing.T) {
+		mock := &mockProvider{capabilities: mailpen.Capabilities{SupportsScheduling: true}}
+		mp, err := mailpen.New(mock, &mailpen.Config{From: "sender@example.com"})
+		require.NoError(t, err)
+
+		msg := mailpen.NewMessage().To("recipient@example.com").Subject("Later").SendAt(time.Now().Add(time.Hour)).Must()
+		require.NoError(t, mp.Send(context.Background(), msg))
+		assert.Equal(t, 1, mock.sendCalls)
+	})
+
+	t.Run("past send time", func(t *testing.T) {
+		mock := &mockProvider{}
+		mp, err := mailpen.New(mock, &mailpen.Config{From: "sender@example.com"})
+		require.NoError(t, err)
+
+		msg := mailpen.NewMessage().To("recipient@example.com").Subject("Now").SendAt(time.Now().Add(-time.Minute)).Must()
+		require.NoError(t, mp.Send(context.Background(), msg))
+		assert.Equal(t, 1, mock.sendCalls)
+	})
+
+	t.Run("deferred to queue", func(t *testing.T) {
+		mock := &mockProvider{}
+		store := queue.NewMemoryStore(0)
+		q := queue.New(store)
+		mp, err := mailpen.New(mock, &mailpen.Config{From: "sender@example.com"}, mailpen.WithQueue(q))
+		require.NoError(t, err)
+
+		msg := mailpen.NewMessage().To("recipient@example.com").Subject("Soon").SendAt(time.Now().Add(50 * time.Millisecond)).Must()
+		require.NoError(t, mp.Send(context.Background(), msg))
+		assert.Equal(t, 0, mock.sendCalls)
+		assert.Equal(t, 1, store.Len())
+
+		require.NoError(t, q.Start(context.Background(), mp))
+		defer func() { _ = q.Stop(context.Background()) }()
+
+		assert.Eventually(t, func() bool { return store.Len() == 0 }, time.Second, 5*time.Millisecond)
+		assert.False(t, time.Now().Before(msg.SendAt))
+	})
+}
@@ -0,0 +1,303 @@
+package mailpen
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInvalidTemplateData is returned when template data does not match the schema registered for the template
+var ErrInvalidTemplateData = errors.New("invalid template data")
+
+// DataSchema validates the data passed to a template before it is rendered
+type DataSchema interface {
+	// Validate returns a description of each problem with the data, or nil if the data is valid
+	Validate(data any) []string
+}
+
+// DataError lists the problems found in the data for a template. It matches ErrInvalidTemplateData.
+type DataError struct {
+	Template string
+	Problems []string
+}
+
+func (e *DataError) Error() string {
+	return fmt.Sprintf("invalid data for template %q: %s", e.Template, strings.Join(e.Problems, "; "))
+}
+
+func (e *DataError) Is(target error) bool { return target == ErrInvalidTemplateData }
+
+// FieldType is the expected type of a template data field
+type FieldType string
+
+const (
+	FieldAny    FieldType = ""       // Any value
+	FieldString FieldType = "string" // A string
+	FieldNumber FieldType = "number" // Any integer or floating-point number
+	FieldInt    FieldType = "int"    // An integer, or a floating-point number without a fraction
+	FieldBool   FieldType = "bool"   // A boolean
+	FieldTime   FieldType = "time"   // A time.Time
+	FieldList   FieldType = "list"   // A slice or array
+	FieldMap    FieldType = "map"    // A map or struct, checked against Fields when set
+)
+
+// Field describes one field of a MapSchema
+type Field struct {
+	Type     FieldType
+	Required bool
+	Fields   MapSchema // Schema for the nested fields of a FieldMap
+}
+
+// MapSchema is a DataSchema that describes template data by field name, as used in the template (for
+// example, "Name" for .Name)
+type MapSchema map[string]Field
+
+// Validate implements DataSchema
+func (s MapSchema) Validate(data any) []string {
+	return s.validate("", reflect.ValueOf(data))
+}
+
+// validate checks the fields of a map or struct value, prefixing problems with the path to the value
+func (s MapSchema) validate(prefix string, v reflect.Value) []string {
+	v = indirect(v)
+	if !v.IsValid() || (v.Kind() != reflect.Map && v.Kind() != reflect.Struct) {
+		return []string{fmt.Sprintf("%s must be a map or struct, got %s", pathOrDot(prefix), describe(v))}
+	}
+
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, name := range names {
+		field, path := s[name], prefix+"."+name
+		value := lookup(v, name)
+		if isMissing(value) {
+			if field.Required {
+				problems = append(problems, path+" is missing")
+			}
+			continue
+		}
+
+		if !field.Type.matches(value) {
+			problems = append(problems, fmt.Sprintf("%s must be %s, got %s", path, field.Type.article(), describe(value)))
+			continue
+		}
+
+		if field.Type == FieldMap && field.Fields != nil {
+			problems = append(problems, field.Fields.validate(path, value)...)
+		}
+	}
+
+	return problems
+}
+
+// StructSchema returns a DataSchema built from the exported fields of a struct type, such as
+// StructSchema(WelcomeData{}). Data passes when it is a value of that type, or a map with a key for each field
+// holding a value of a compatible type. Fields are required unless tagged `mailpen:"optional"`, and nested
+// structs are checked the same way.
+func StructSchema(v any) DataSchema {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("mailpen: StructSchema requires a struct, got %T", v))
+	}
+	return structSchema{typ: t, fields: structFields(t)}
+}
+
+// structSchema checks data against a struct type
+type structSchema struct {
+	typ    reflect.Type
+	fields MapSchema
+}
+
+// Validate implements DataSchema
+func (s structSchema) Validate(data any) []string {
+	if v := indirect(reflect.ValueOf(data)); v.IsValid() && v.Type() == s.typ {
+		return nil
+	}
+	return s.fields.validate("", reflect.ValueOf(data))
+}
+
+// structFields describes the exported fields of a struct type as a MapSchema
+func structFields(t reflect.Type) MapSchema {
+	schema := make(MapSchema)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		field := Field{Type: fieldTypeOf(f.Type), Required: f.Tag.Get("mailpen") != "optional"}
+		if ft := derefType(f.Type); field.Type == FieldMap && ft.Kind() == reflect.Struct {
+			field.Fields = structFields(ft)
+		}
+		schema[f.Name] = field
+	}
+	return schema
+}
+
+// fieldTypeOf maps a Go type to the FieldType it is validated as
+func fieldTypeOf(t reflect.Type) FieldType {
+	t = derefType(t)
+	if t == reflect.TypeOf(time.Time{}) {
+		return FieldTime
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return FieldString
+	case reflect.Bool:
+		return FieldBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return FieldInt
+	case reflect.Float32, reflect.Float64:
+		return FieldNumber
+	case reflect.Slice, reflect.Array:
+		return FieldList
+	case reflect.Map, reflect.Struct:
+		return FieldMap
+	default:
+		return FieldAny
+	}
+}
+
+// matches reports whether a value has the field type
+func (ft FieldType) matches(v reflect.Value) bool {
+	v = indirect(v)
+	switch ft {
+	case FieldAny:
+		return true
+	case FieldString:
+		return v.Kind() == reflect.String
+	case FieldBool:
+		return v.Kind() == reflect.Bool
+	case FieldNumber:
+		return isInt(v) || isFloat(v)
+	case FieldInt:
+		return isInt(v) || (isFloat(v) && v.Float() == math.Trunc(v.Float()))
+	case FieldTime:
+		return v.Type() == reflect.TypeOf(time.Time{})
+	case FieldList:
+		return v.Kind() == reflect.Slice || v.Kind() == reflect.Array
+	case FieldMap:
+		return v.Kind() == reflect.Map || v.Kind() == reflect.Struct
+	default:
+		return false
+	}
+}
+
+// article returns the field type with an indefinite article, for error messages
+func (ft FieldType) article() string {
+	switch ft {
+	case FieldInt:
+		return "an int"
+	case FieldTime:
+		return "a time"
+	default:
+		return "a " + string(ft)
+	}
+}
+
+// validateData checks data against the schema registered for a template, if any
+func (m *Manager) validateData(name string, data any) error {
+	m.mu.RLock()
+	schema, ok := m.schemas[name]
+	m.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	if problems := schema.Validate(data); len(problems) > 0 {
+		return &DataError{Template: name, Problems: problems}
+	}
+	return nil
+}
+
+// RegisterSchema registers a schema that the data for the named email template must match before it is
+// rendered. Passing a nil schema removes the registration.
+func (m *Manager) RegisterSchema(name string, schema DataSchema) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if schema == nil {
+		delete(m.schemas, name)
+		return
+	}
+	m.schemas[name] = schema
+}
+
+// RegisterSchema registers a schema that the data for the named email template must match before it is
+// rendered. See Manager.RegisterSchema.
+func (m *Mailpen) RegisterSchema(name string, schema DataSchema) {
+	m.templateMgr.RegisterSchema(name, schema)
+}
+
+// lookup returns the value of a map key or struct field by name
+func lookup(v reflect.Value, name string) reflect.Value {
+	if v.Kind() == reflect.Struct {
+		return v.FieldByName(name)
+	}
+	if v.Type().Key().Kind() != reflect.String {
+		return reflect.Value{}
+	}
+	return v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+}
+
+// isMissing reports whether a looked-up value is absent or nil
+func isMissing(v reflect.Value) bool {
+	return !indirect(v).IsValid()
+}
+
+// indirect follows interfaces and pointers, returning an invalid value for nil
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// derefType follows pointer types
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+func isInt(v reflect.Value) bool {
+	return v.CanInt() || v.CanUint()
+}
+
+func isFloat(v reflect.Value) bool {
+	return v.CanFloat()
+}
+
+// describe names the type of a value for error messages
+func describe(v reflect.Value) string {
+	v = indirect(v)
+	if !v.IsValid() {
+		return "nil"
+	}
+	return v.Type().String()
+}
+
+// pathOrDot returns the path, or "." for the top-level data
+func pathOrDot(path string) string {
+	if path == "" {
+		return "."
+	}
+	return path
+}
@@ -0,0 +1,132 @@
+package mailpen_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+type receiptData struct {
+	Name   string
+	Amount float64
+	Items  []string
+	PaidAt time.Time
+	Note   string `mailpen:"optional"`
+	Card   struct {
+		Last4 string
+	}
+}
+
+func TestMapSchema_Validate(t *testing.T) {
+	schema := mailpen.MapSchema{
+		"Name":   {Type: mailpen.FieldString, Required: true},
+		"Amount": {Type: mailpen.FieldNumber, Required: true},
+		"Count":  {Type: mailpen.FieldInt},
+		"Items":  {Type: mailpen.FieldList},
+		"User": {Type: mailpen.FieldMap, Fields: mailpen.MapSchema{
+			"Email": {Type: mailpen.FieldString, Required: true},
+		}},
+	}
+
+	tests := []struct {
+		name string
+		data any
+		want []string
+	}{
+		{
+			name: "valid",
+			data: map[string]any{"Name": "Jane", "Amount": 9.99, "Count": float64(2), "Items": []string{"a"}},
+		},
+		{
+			name: "named map type",
+			data: mailpen.TemplateData{"Name": "Jane", "Amount": 10},
+		},
+		{
+			name: "missing and wrongly typed",
+			data: map[string]any{"Amount": "9.99", "Count": 1.5},
+			want: []string{".Amount must be a number, got string", ".Count must be an int, got float64", ".Name is missing"},
+		},
+		{
+			name: "nil counts as missing",
+			data: map[string]any{"Name": nil, "Amount": 1},
+			want: []string{".Name is missing"},
+		},
+		{
+			name: "nested",
+			data: map[string]any{"Name": "Jane", "Amount": 1, "User": map[string]any{"Email": 42}},
+			want: []string{".User.Email must be a string, got int"},
+		},
+		{
+			name: "not a map",
+			data: "Jane",
+			want: []string{". must be a map or struct, got string"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, schema.Validate(tt.data))
+		})
+	}
+}
+
+func TestStructSchema_Validate(t *testing.T) {
+	schema := mailpen.StructSchema(receiptData{})
+
+	assert.Empty(t, schema.Validate(receiptData{Name: "Jane"}), "values of the struct type are valid")
+	assert.Empty(t, schema.Validate(&receiptData{}))
+
+	assert.Empty(t, schema.Validate(map[string]any{
+		"Name":   "Jane",
+		"Amount": 12,
+		"Items":  []any{"Widget"},
+		"PaidAt": time.Now(),
+		"Card":   map[string]any{"Last4": "4242"},
+	}))
+
+	assert.Equal(t, []string{
+		".Amount must be a number, got string",
+		".Card.Last4 is missing",
+		".Name is missing",
+		".PaidAt must be a time, got string",
+	}, schema.Validate(map[string]any{
+		"Amount": "12",
+		"Items":  []string{},
+		"PaidAt": "yesterday",
+		"Card":   map[string]any{},
+	}))
+
+	assert.Panics(t, func() { mailpen.StructSchema("not a struct") })
+}
+
+func TestMailpen_TemplateSchema(t *testing.T) {
+	mock := &mockProvider{}
+	mp, err := mailpen.New(mock, &mailpen.Config{
+		From:    "sender@example.com",
+		Sources: []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+		Schemas: map[string]mailpen.DataSchema{
+			"welcome": mailpen.MapSchema{"Name": {Type: mailpen.FieldString, Required: true}},
+		},
+	})
+	require.NoError(t, err)
+
+	msg := mailpen.NewMessage().To("a@example.com").Subject("Welcome").Template("welcome").Must()
+	err = mp.Send(context.Background(), msg)
+	require.ErrorIs(t, err, mailpen.ErrInvalidTemplateData)
+	assert.Contains(t, err.Error(), `invalid data for template "welcome": .Name is missing`)
+	assert.Zero(t, mock.sendCalls)
+
+	msg = mailpen.NewMessage().To("a@example.com").Subject("Welcome").Template("welcome").
+		WithData(map[string]any{"Name": "Jane"}).Must()
+	require.NoError(t, mp.Send(context.Background(), msg))
+	assert.Contains(t, mock.lastMessage.HTMLBody, "Welcome, Jane!")
+
+	mp.RegisterSchema("welcome", nil)
+	msg = mailpen.NewMessage().To("a@example.com").Subject("Welcome").Template("welcome").Must()
+	require.NoError(t, mp.Send(context.Background(), msg))
+}
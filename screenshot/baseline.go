@@ -0,0 +1,104 @@
+package screenshot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// UpdateEnv is the environment variable that makes AssertBaseline replace the baselines with new captures
+const UpdateEnv = "MAILPEN_UPDATE_SCREENSHOTS"
+
+// AssertBaseline captures the HTML and compares each width with the baseline "<name>-<width>.png" in dir.
+// Missing baselines are written, and all of them are rewritten when UpdateEnv is set. On a mismatch, the
+// capture is saved next to the baseline as "<name>-<width>.actual.png" and the test fails. The test is skipped
+// when no browser is available.
+func AssertBaseline(t testing.TB, r *Renderer, html, dir, name string) {
+	t.Helper()
+
+	if !r.Available() {
+		t.Skip("screenshot: " + ErrBrowserNotFound.Error())
+	}
+
+	shots, err := r.Capture(context.Background(), html)
+	if err != nil {
+		t.Fatalf("screenshot: %v", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("screenshot: %v", err)
+	}
+
+	update := os.Getenv(UpdateEnv) != ""
+	for _, shot := range shots {
+		path := filepath.Join(dir, FileName(name, shot.Width))
+
+		baseline, err := os.ReadFile(path)
+		if update || os.IsNotExist(err) {
+			if err := os.WriteFile(path, shot.PNG, 0o644); err != nil {
+				t.Fatalf("screenshot: %v", err)
+			}
+			t.Logf("screenshot: wrote baseline %s", path)
+			continue
+		}
+		if err != nil {
+			t.Fatalf("screenshot: %v", err)
+		}
+
+		diff, err := Diff(baseline, shot.PNG)
+		if err != nil {
+			t.Fatalf("screenshot: %v", err)
+		}
+		if diff > r.tolerance {
+			actual := strings.TrimSuffix(path, ".png") + ".actual.png"
+			_ = os.WriteFile(actual, shot.PNG, 0o644)
+			t.Errorf("screenshot: %s differs from the baseline in %.2f%% of pixels (see %s; set %s=1 to update)",
+				path, diff*100, actual, UpdateEnv)
+		}
+	}
+}
+
+// Diff returns the fraction of pixels, from 0 to 1, that differ between two PNG images. Images of different
+// sizes differ completely.
+func Diff(a, b []byte) (float64, error) {
+	imgA, err := png.Decode(bytes.NewReader(a))
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode image: %w", err)
+	}
+	imgB, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	boundsA, boundsB := imgA.Bounds(), imgB.Bounds()
+	if boundsA.Dx() != boundsB.Dx() || boundsA.Dy() != boundsB.Dy() {
+		return 1, nil
+	}
+	if boundsA.Empty() {
+		return 0, nil
+	}
+
+	differing := 0
+	for y := 0; y < boundsA.Dy(); y++ {
+		for x := 0; x < boundsA.Dx(); x++ {
+			if !samePixel(imgA, imgB, boundsA.Min.X+x, boundsA.Min.Y+y, boundsB.Min.X+x, boundsB.Min.Y+y) {
+				differing++
+			}
+		}
+	}
+
+	return float64(differing) / float64(boundsA.Dx()*boundsA.Dy()), nil
+}
+
+// samePixel reports whether two pixels have the same color
+func samePixel(a, b image.Image, ax, ay, bx, by int) bool {
+	r1, g1, b1, a1 := a.At(ax, ay).RGBA()
+	r2, g2, b2, a2 := b.At(bx, by).RGBA()
+	return r1 == r2 && g1 == g2 && b1 == b2 && a1 == a2
+}
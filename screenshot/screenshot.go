@@ -0,0 +1,215 @@
+// Package screenshot captures PNG screenshots of rendered emails with headless Chrome, for visual review and
+// regression baselines. It requires Chrome or Chromium on the machine running it.
+package screenshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// ErrBrowserNotFound is returned when no Chrome or Chromium executable is found
+var ErrBrowserNotFound = errors.New("chrome or chromium not found")
+
+// DefaultWidths are the viewport widths captured unless WithWidths is used: a phone, the usual email width,
+// and a desktop client
+var DefaultWidths = []int{375, 600, 1024}
+
+// Screenshot is a full-page capture of an email at one viewport width
+type Screenshot struct {
+	Width int    // Viewport width in CSS pixels
+	PNG   []byte // PNG image of the full page
+}
+
+// Renderer captures screenshots with a headless browser
+type Renderer struct {
+	execPath  string
+	widths    []int
+	height    int
+	timeout   time.Duration
+	tolerance float64
+}
+
+// Option configures a Renderer
+type Option func(r *Renderer)
+
+// WithWidths sets the viewport widths to capture (defaults to DefaultWidths)
+func WithWidths(widths ...int) Option {
+	return func(r *Renderer) {
+		r.widths = widths
+	}
+}
+
+// WithExecPath sets the Chrome or Chromium executable (defaults to the first one found on the PATH or in the
+// usual install locations)
+func WithExecPath(path string) Option {
+	return func(r *Renderer) {
+		r.execPath = path
+	}
+}
+
+// WithTimeout limits how long capturing one email may take (defaults to 30 seconds)
+func WithTimeout(timeout time.Duration) Option {
+	return func(r *Renderer) {
+		r.timeout = timeout
+	}
+}
+
+// WithTolerance sets the fraction of pixels, from 0 to 1, that may differ from a baseline in AssertBaseline
+// (defaults to 0). A small tolerance absorbs font antialiasing differences between machines.
+func WithTolerance(tolerance float64) Option {
+	return func(r *Renderer) {
+		r.tolerance = tolerance
+	}
+}
+
+// New creates a Renderer
+func New(opts ...Option) *Renderer {
+	r := &Renderer{
+		widths:  DefaultWidths,
+		height:  800,
+		timeout: 30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Available reports whether a browser can be found, so tests can skip when there is none
+func (r *Renderer) Available() bool {
+	return r.browser() != ""
+}
+
+// Capture loads the HTML in a headless browser and captures a full-page screenshot at each width
+func (r *Renderer) Capture(ctx context.Context, html string) ([]Screenshot, error) {
+	execPath := r.browser()
+	if execPath == "" {
+		return nil, ErrBrowserNotFound
+	}
+	if len(r.widths) == 0 {
+		return nil, errors.New("no widths to capture")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	allocOpts := append(chromedp.DefaultExecAllocatorOptions[:], chromedp.ExecPath(execPath))
+	ctx, cancelAlloc := chromedp.NewExecAllocator(ctx, allocOpts...)
+	defer cancelAlloc()
+
+	ctx, cancelBrowser := chromedp.NewContext(ctx)
+	defer cancelBrowser()
+
+	err := chromedp.Run(ctx,
+		chromedp.Navigate("about:blank"),
+		setContent(html),
+		chromedp.Poll(`document.readyState === "complete"`, nil),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load email: %w", err)
+	}
+
+	shots := make([]Screenshot, 0, len(r.widths))
+	for _, width := range r.widths {
+		var png []byte
+		err := chromedp.Run(ctx,
+			chromedp.EmulateViewport(int64(width), int64(r.height)),
+			chromedp.FullScreenshot(&png, 100),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to capture width %d: %w", width, err)
+		}
+		shots = append(shots, Screenshot{Width: width, PNG: png})
+	}
+
+	return shots, nil
+}
+
+// WriteFiles captures the HTML and writes one PNG per width to dir, named "<name>-<width>.png". It returns
+// the paths of the written files.
+func (r *Renderer) WriteFiles(ctx context.Context, html, dir, name string) ([]string, error) {
+	shots, err := r.Capture(ctx, html)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(shots))
+	for _, shot := range shots {
+		path := filepath.Join(dir, FileName(name, shot.Width))
+		if err := os.WriteFile(path, shot.PNG, 0o644); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
+// FileName returns the file name of a screenshot, "<name>-<width>.png"
+func FileName(name string, width int) string {
+	return name + "-" + strconv.Itoa(width) + ".png"
+}
+
+// setContent replaces the document of the main frame with the HTML
+func setContent(html string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		tree, err := page.GetFrameTree().Do(ctx)
+		if err != nil {
+			return err
+		}
+		return page.SetDocumentContent(tree.Frame.ID, html).Do(ctx)
+	})
+}
+
+// browserNames are the executables looked up on the PATH
+var browserNames = []string{
+	"headless-shell",
+	"chromium",
+	"chromium-browser",
+	"google-chrome",
+	"google-chrome-stable",
+	"chrome",
+}
+
+// browserPaths are the usual install locations checked when no browser is on the PATH
+var browserPaths = []string{
+	"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+	"/Applications/Chromium.app/Contents/MacOS/Chromium",
+	`C:\Program Files\Google\Chrome\Application\chrome.exe`,
+	`C:\Program Files (x86)\Google\Chrome\Application\chrome.exe`,
+}
+
+// browser returns the browser executable, or an empty string when none is found
+func (r *Renderer) browser() string {
+	if r.execPath != "" {
+		if _, err := os.Stat(r.execPath); err == nil {
+			return r.execPath
+		}
+		return ""
+	}
+
+	for _, name := range browserNames {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	for _, path := range browserPaths {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
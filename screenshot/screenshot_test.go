@@ -0,0 +1,97 @@
+package screenshot_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen/screenshot"
+)
+
+// solid returns a PNG of the given size filled with one color, with the first n pixels painted black
+func solid(t *testing.T, width, height, n int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < width*height; i++ {
+		c := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+		if i < n {
+			c = color.RGBA{A: 255}
+		}
+		img.Set(i%width, i/width, c)
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []byte
+		want float64
+	}{
+		{name: "identical", a: solid(t, 10, 10, 0), b: solid(t, 10, 10, 0), want: 0},
+		{name: "some pixels", a: solid(t, 10, 10, 0), b: solid(t, 10, 10, 5), want: 0.05},
+		{name: "different sizes", a: solid(t, 10, 10, 0), b: solid(t, 10, 20, 0), want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := screenshot.Diff(tt.a, tt.b)
+			require.NoError(t, err)
+			assert.InDelta(t, tt.want, diff, 1e-9)
+		})
+	}
+
+	_, err := screenshot.Diff([]byte("not a png"), solid(t, 1, 1, 0))
+	assert.ErrorContains(t, err, "failed to decode image")
+}
+
+func TestFileName(t *testing.T) {
+	assert.Equal(t, "welcome-375.png", screenshot.FileName("welcome", 375))
+}
+
+func TestRenderer_BrowserNotFound(t *testing.T) {
+	r := screenshot.New(screenshot.WithExecPath(filepath.Join(t.TempDir(), "no-chrome")))
+	assert.False(t, r.Available())
+
+	_, err := r.Capture(context.Background(), "<p>Hi</p>")
+	assert.ErrorIs(t, err, screenshot.ErrBrowserNotFound)
+}
+
+func TestRenderer_Capture(t *testing.T) {
+	r := screenshot.New(screenshot.WithWidths(320, 640))
+	if !r.Available() {
+		t.Skip("no browser available")
+	}
+
+	shots, err := r.Capture(context.Background(), `<html><body style="margin:0"><p>Hello</p></body></html>`)
+	require.NoError(t, err)
+	require.Len(t, shots, 2)
+
+	for i, width := range []int{320, 640} {
+		assert.Equal(t, width, shots[i].Width)
+		img, err := png.Decode(bytes.NewReader(shots[i].PNG))
+		require.NoError(t, err)
+		assert.Equal(t, width, img.Bounds().Dx())
+	}
+}
+
+func TestAssertBaseline(t *testing.T) {
+	r := screenshot.New(screenshot.WithWidths(320))
+	dir := t.TempDir()
+	html := `<html><body style="margin:0"><p>Baseline</p></body></html>`
+
+	// The first run writes the baseline and the second compares against it
+	screenshot.AssertBaseline(t, r, html, dir, "welcome")
+	assert.FileExists(t, filepath.Join(dir, "welcome-320.png"))
+	screenshot.AssertBaseline(t, r, html, dir, "welcome")
+}
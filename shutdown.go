@@ -0,0 +1,52 @@
+package mailpen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// ErrShutdown is returned by Enqueue and SendAsync once Shutdown has been called
+var ErrShutdown = errors.New("mailpen is shut down")
+
+// QueueFlusher is implemented by queues that can finish delivering their ready jobs and report the rest. The
+// queue package's Queue implements it.
+type QueueFlusher interface {
+	Flush(ctx context.Context) ([]*Message, error)
+}
+
+// ShutdownReport describes what was left undelivered when Shutdown returned
+type ShutdownReport struct {
+	// Undelivered holds the queued messages that were not delivered before the deadline, including those
+	// scheduled for later. Durable queues keep them for the next start.
+	Undelivered []*Message
+}
+
+// Shutdown stops accepting new enqueues and asynchronous sends, waits for in-flight sends started with
+// SendAsync, and flushes the queue if it implements QueueFlusher, all within the deadline of ctx. The report
+// lists what was left undelivered and is returned even when an error occurs.
+func (m *Mailpen) Shutdown(ctx context.Context) (*ShutdownReport, error) {
+	m.closed.Store(true)
+	report := &ShutdownReport{}
+
+	var errs []error
+	if err := m.Drain(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to drain async sends: %w", err))
+	}
+
+	if flusher, ok := m.queue.(QueueFlusher); ok {
+		undelivered, err := flusher.Flush(ctx)
+		report.Undelivered = undelivered
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to flush queue: %w", err))
+		}
+	}
+
+	if len(report.Undelivered) > 0 {
+		m.logger.WarnContext(ctx, "mailpen: shutdown left messages undelivered",
+			slog.Int("undelivered", len(report.Undelivered)))
+	}
+
+	return report, errors.Join(errs...)
+}
@@ -0,0 +1,60 @@
+package mailpen_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/queue"
+)
+
+func TestMailpen_Shutdown(t *testing.T) {
+	t.Run("flushes the queue and reports undelivered messages", func(t *testing.T) {
+		mock := &mockProvider{}
+		store := queue.NewMemoryStore(10)
+		q := queue.New(store)
+		mp, err := mailpen.New(mock, &mailpen.Config{From: "sender@example.com"}, mailpen.WithQueue(q))
+		require.NoError(t, err)
+
+		require.NoError(t, mp.Enqueue(context.Background(), mailpen.NewMessage().To("a@example.com").Subject("Now").Must()))
+		later := mailpen.NewMessage().To("b@example.com").Subject("Later").Must()
+		later.SendAt = time.Now().Add(time.Hour)
+		require.NoError(t, mp.Enqueue(context.Background(), later))
+		require.NoError(t, q.Start(context.Background(), mp))
+
+		report, err := mp.Shutdown(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, mock.sendCalls)
+		require.Len(t, report.Undelivered, 1)
+		assert.Equal(t, "Later", report.Undelivered[0].Subject)
+	})
+
+	t.Run("rejects new work", func(t *testing.T) {
+		mp, err := mailpen.New(&mockProvider{}, &mailpen.Config{From: "sender@example.com"},
+			mailpen.WithQueue(queue.New(queue.NewMemoryStore(10))))
+		require.NoError(t, err)
+
+		report, err := mp.Shutdown(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, report.Undelivered)
+
+		msg := mailpen.NewMessage().To("a@example.com").Subject("Test").Must()
+		assert.ErrorIs(t, mp.Enqueue(context.Background(), msg), mailpen.ErrShutdown)
+		assert.ErrorIs(t, mp.SendAsync(context.Background(), msg).Wait(context.Background()), mailpen.ErrShutdown)
+	})
+}
+
+func TestModule_Stop(t *testing.T) {
+	mod := mailpen.NewModule(&mockProvider{}, &mailpen.Config{From: "sender@example.com"})
+	require.NoError(t, mod.Stop(context.Background()))
+
+	require.NoError(t, mod.Init())
+	require.NoError(t, mod.Stop(context.Background()))
+
+	msg := mailpen.NewMessage().To("a@example.com").Subject("Test").Must()
+	assert.ErrorIs(t, mod.Mailpen().SendAsync(context.Background(), msg).Wait(context.Background()), mailpen.ErrShutdown)
+}
@@ -0,0 +1,85 @@
+package mailpen
+
+import (
+	"io/fs"
+	"slices"
+	"strings"
+
+	"github.com/patrickward/mailpen/templates"
+)
+
+// SocialLink is a social media profile rendered by the @social component
+type SocialLink struct {
+	Network string // Key in Config.SocialMediaLinks (e.g. "linkedin")
+	Label   string // Display name (e.g. "LinkedIn")
+	URL     string // Profile URL
+	Icon    string // Icon URL; empty when no icon is available and the link is rendered as text
+}
+
+// socialLabels holds the display names of networks whose name is not simply capitalized
+var socialLabels = map[string]string{
+	"github":   "GitHub",
+	"linkedin": "LinkedIn",
+	"tiktok":   "TikTok",
+	"x":        "X",
+	"youtube":  "YouTube",
+}
+
+// SocialIcons returns the bundled social media icons, named after the network (e.g. "linkedin.png"). Serve them
+// at Config.SocialIconBaseURL so the @social component can link to them:
+//
+//	http.Handle("/email-icons/", http.StripPrefix("/email-icons/", http.FileServerFS(mailpen.SocialIcons())))
+func SocialIcons() fs.FS {
+	icons, _ := fs.Sub(templates.FS, "icons")
+	return icons
+}
+
+// socialLinks returns the configured social media links sorted by network, with their icons resolved
+func socialLinks(cfg *Config) []SocialLink {
+	links := make([]SocialLink, 0, len(cfg.SocialMediaLinks))
+	for network, url := range cfg.SocialMediaLinks {
+		if url == "" {
+			continue
+		}
+		key := strings.ToLower(network)
+		links = append(links, SocialLink{
+			Network: network,
+			Label:   socialLabel(key),
+			URL:     url,
+			Icon:    socialIcon(cfg, key),
+		})
+	}
+
+	slices.SortFunc(links, func(a, b SocialLink) int {
+		return strings.Compare(a.Network, b.Network)
+	})
+	return links
+}
+
+// socialLabel returns the display name of a network
+func socialLabel(network string) string {
+	if label, ok := socialLabels[network]; ok {
+		return label
+	}
+	if network == "" {
+		return network
+	}
+	return strings.ToUpper(network[:1]) + network[1:]
+}
+
+// socialIcon returns the icon URL of a network: a configured icon, or a bundled one served at the icon base URL
+func socialIcon(cfg *Config, network string) string {
+	for name, icon := range cfg.SocialIcons {
+		if strings.EqualFold(name, network) {
+			return icon
+		}
+	}
+
+	if cfg.SocialIconBaseURL == "" {
+		return ""
+	}
+	if _, err := fs.Stat(SocialIcons(), network+".png"); err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(cfg.SocialIconBaseURL, "/") + "/" + network + ".png"
+}
@@ -0,0 +1,44 @@
+// Package sqldialect describes the SQL differences between the databases supported by mailpen's
+// database/sql stores.
+package sqldialect
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect describes the SQL differences between databases
+type Dialect struct {
+	Name        string
+	Placeholder func(n int) string // Returns the placeholder for the nth (1-based) argument
+	PayloadType string             // Column type for large serialized values
+}
+
+var (
+	// Postgres uses $1-style placeholders
+	Postgres = Dialect{Name: "postgres", Placeholder: func(n int) string { return "$" + strconv.Itoa(n) }, PayloadType: "TEXT"}
+	// MySQL uses ? placeholders and MEDIUMTEXT for large values
+	MySQL = Dialect{Name: "mysql", Placeholder: func(int) string { return "?" }, PayloadType: "MEDIUMTEXT"}
+	// SQLite uses ? placeholders
+	SQLite = Dialect{Name: "sqlite", Placeholder: func(int) string { return "?" }, PayloadType: "TEXT"}
+)
+
+// Rebind rewrites ? placeholders in a query for the dialect
+func (d Dialect) Rebind(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString(d.Placeholder(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Placeholders returns n comma-separated ? placeholders, for use in IN clauses before Rebind
+func Placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
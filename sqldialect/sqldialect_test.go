@@ -0,0 +1,28 @@
+package sqldialect_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/patrickward/mailpen/sqldialect"
+)
+
+func TestDialect_Rebind(t *testing.T) {
+	query := "SELECT a FROM t WHERE b = ? AND c IN (" + sqldialect.Placeholders(2) + ")"
+
+	tests := []struct {
+		dialect sqldialect.Dialect
+		want    string
+	}{
+		{dialect: sqldialect.Postgres, want: "SELECT a FROM t WHERE b = $1 AND c IN ($2, $3)"},
+		{dialect: sqldialect.MySQL, want: "SELECT a FROM t WHERE b = ? AND c IN (?, ?)"},
+		{dialect: sqldialect.SQLite, want: "SELECT a FROM t WHERE b = ? AND c IN (?, ?)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect.Name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.dialect.Rebind(query))
+		})
+	}
+}
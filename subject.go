@@ -0,0 +1,60 @@
+package mailpen
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// subjectExtension is the extension of subject templates, e.g. emails/welcome.subject.txt
+const subjectExtension = ".subject.txt"
+
+// subjectBlock is the template that renders an email's subject. Emails define it with
+// {{define "subject"}}...{{end}}, or in a subject file, which takes precedence.
+const subjectBlock = "subject"
+
+// readSubject reads the subject template of an email from the sources (last one wins). The caller must hold
+// m.mu.
+func (m *Manager) readSubject(name string) (string, bool) {
+	filename := path.Join(EmailsDir, name+subjectExtension)
+	for i := len(m.sources) - 1; i >= 0; i-- {
+		if content, err := fs.ReadFile(m.sources[i].FS, filename); err == nil {
+			return string(content), true
+		}
+	}
+	return "", false
+}
+
+// parseSubject parses the subject file of an email, when there is one, as the subject block of tmpl. The
+// caller must hold m.mu.
+func (m *Manager) parseSubject(tmpl *template.Template, name string) error {
+	content, found := m.readSubject(name)
+	if !found {
+		return nil
+	}
+	if _, err := tmpl.New(subjectBlock).Parse(strings.TrimSpace(content)); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path.Join(EmailsDir, name+subjectExtension), err)
+	}
+	return nil
+}
+
+// renderSubject executes the subject block of the first template that renders a non-empty subject. Templates
+// are HTML-escaped and subjects are a single line, so the result is unescaped and its whitespace collapsed.
+func (m *Manager) renderSubject(data interface{}, tmpls ...*template.Template) (string, error) {
+	for _, tmpl := range tmpls {
+		if tmpl == nil || tmpl.Lookup(subjectBlock) == nil {
+			continue
+		}
+		subject, err := m.executeTemplate(tmpl, subjectBlock, data)
+		if err != nil {
+			return "", err
+		}
+		if subject = strings.Join(strings.Fields(html.UnescapeString(subject)), " "); subject != "" {
+			return subject, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,105 @@
+package mailpen_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+func subjectFS() fstest.MapFS {
+	return fstest.MapFS{
+		"layouts/base.html":                 {Data: []byte(`<title>{{block "subject" .}}{{end}}</title>{{template "content" .}}`)},
+		"layouts/base.txt":                  {Data: []byte(`{{template "content" .}}`)},
+		"emails/welcome.html":               {Data: []byte(`{{define "content"}}<p>Hi {{.Name}}</p>{{end}}`)},
+		"emails/welcome.subject.txt":        {Data: []byte("Welcome, {{.Name}}!\n")},
+		"emails/html-block.html":            {Data: []byte(`{{define "subject"}}Tom & {{.Name}}{{end}}{{define "content"}}Hi{{end}}`)},
+		"emails/text-block.html":            {Data: []byte(`{{define "subject"}}From HTML{{end}}{{define "content"}}Hi{{end}}`)},
+		"emails/text-block.txt":             {Data: []byte(`{{define "subject"}}  From   text {{end}}{{define "content"}}Hi{{end}}`)},
+		"emails/overridden.html":            {Data: []byte(`{{define "subject"}}From block{{end}}{{define "content"}}Hi{{end}}`)},
+		"emails/overridden.subject.txt":     {Data: []byte(`From file`)},
+		"emails/no-subject.html":            {Data: []byte(`{{define "content"}}Hi{{end}}`)},
+		"emails/broken-subject.html":        {Data: []byte(`{{define "content"}}Hi{{end}}`)},
+		"emails/broken-subject.subject.txt": {Data: []byte(`{{.Name`)},
+	}
+}
+
+func TestManager_RenderSubject(t *testing.T) {
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{{Name: "test", FS: subjectFS()}},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		email       string
+		wantSubject string
+		errContains string
+	}{
+		{name: "subject file", email: "welcome", wantSubject: "Welcome, Ada!"},
+		{name: "HTML subject block is unescaped", email: "html-block", wantSubject: "Tom & Ada"},
+		{name: "text subject block wins", email: "text-block", wantSubject: "From text"},
+		{name: "subject file overrides the block", email: "overridden", wantSubject: "From file"},
+		{name: "no subject", email: "no-subject", wantSubject: ""},
+		{name: "invalid subject file", email: "broken-subject", errContains: "failed to parse emails/broken-subject.subject.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email, err := manager.Render(context.Background(), tt.email, map[string]any{"Name": "Ada"}, mailpen.RenderOptions{})
+			if tt.errContains != "" {
+				assert.ErrorContains(t, err, tt.errContains)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantSubject, email.Subject)
+		})
+	}
+
+	t.Run("subject file is used as the HTML title", func(t *testing.T) {
+		email, err := manager.Render(context.Background(), "welcome", map[string]any{"Name": "Ada"}, mailpen.RenderOptions{})
+		require.NoError(t, err)
+		assert.Contains(t, email.HTML, "<title>Welcome, Ada!</title>")
+	})
+
+	t.Run("subject files are not emails", func(t *testing.T) {
+		emails, err := manager.Emails()
+		require.NoError(t, err)
+		assert.NotContains(t, emails, "welcome.subject")
+		assert.Contains(t, emails, "welcome")
+	})
+}
+
+func TestMailpen_SendTemplateSubject(t *testing.T) {
+	mock := &mockProvider{}
+	mp, err := mailpen.New(mock, &mailpen.Config{
+		From:    "sender@example.com",
+		Sources: []mailpen.TemplateSource{{Name: "test", FS: subjectFS()}},
+	})
+	require.NoError(t, err)
+
+	t.Run("rendered subject fills an empty subject", func(t *testing.T) {
+		msg := mailpen.NewMessage().To("ada@example.com").Template("welcome").WithData(map[string]any{"Name": "Ada"}).Must()
+		require.NoError(t, mp.Send(context.Background(), msg))
+		assert.Equal(t, "Welcome, Ada!", mock.lastMessage.Subject)
+	})
+
+	t.Run("explicit subject wins", func(t *testing.T) {
+		msg := mailpen.NewMessage().To("ada@example.com").Subject("Hello").Template("welcome").WithData(map[string]any{"Name": "Ada"}).Must()
+		require.NoError(t, mp.Send(context.Background(), msg))
+		assert.Equal(t, "Hello", mock.lastMessage.Subject)
+	})
+
+	t.Run("render reports the subject", func(t *testing.T) {
+		msg := mailpen.NewMessage().To("ada@example.com").Template("welcome").WithData(map[string]any{"Name": "Ada"}).Must()
+		email, err := mp.Render(context.Background(), msg)
+		require.NoError(t, err)
+		assert.Equal(t, "Welcome, Ada!", email.Subject)
+		assert.Empty(t, msg.Subject)
+	})
+}
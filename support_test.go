@@ -7,7 +7,7 @@ import (
 )
 
 // testFS creates a testing filesystem from the testdata directory
-func testFS(t *testing.T, dir string) fs.FS {
+func testFS(t testing.TB, dir string) fs.FS {
 	t.Helper()
 	return os.DirFS("testdata/" + dir)
 }
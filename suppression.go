@@ -0,0 +1,121 @@
+package mailpen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// ErrSuppressedRecipient is matched by errors.Is when every recipient of a message is suppressed
+var ErrSuppressedRecipient = errors.New("recipient is suppressed")
+
+// SuppressionReason records why an address is suppressed
+type SuppressionReason string
+
+const (
+	SuppressionBounce      SuppressionReason = "bounce"      // Hard bounce
+	SuppressionComplaint   SuppressionReason = "complaint"   // Spam complaint
+	SuppressionUnsubscribe SuppressionReason = "unsubscribe" // Recipient unsubscribed
+	SuppressionManual      SuppressionReason = "manual"      // Added by an operator
+)
+
+// Suppression is an address that must not receive mail
+type Suppression struct {
+	Address   string
+	Reason    SuppressionReason
+	CreatedAt time.Time
+}
+
+// SuppressionStore holds suppressed addresses. Addresses are normalized with NormalizeAddress before they
+// are stored or looked up. See the suppression package for implementations.
+type SuppressionStore interface {
+	// Suppress adds or replaces a suppression
+	Suppress(ctx context.Context, s Suppression) error
+	// Unsuppress removes an address from the list
+	Unsuppress(ctx context.Context, address string) error
+	// Suppressed returns the normalized addresses among the given ones that are suppressed
+	Suppressed(ctx context.Context, addresses []string) ([]string, error)
+}
+
+// SuppressedError is returned when a message was not sent because all of its recipients are suppressed.
+// It matches ErrSuppressedRecipient with errors.Is.
+type SuppressedError struct {
+	Recipients []string
+}
+
+func (e *SuppressedError) Error() string {
+	return fmt.Sprintf("all recipients are suppressed: %s", strings.Join(e.Recipients, ", "))
+}
+
+// Is reports whether target is ErrSuppressedRecipient
+func (e *SuppressedError) Is(target error) bool {
+	return target == ErrSuppressedRecipient
+}
+
+// WithSuppressionStore sets the suppression list checked before each message is sent
+func WithSuppressionStore(store SuppressionStore) Option {
+	return func(m *Mailpen) error {
+		m.suppressions = store
+		return nil
+	}
+}
+
+// NormalizeAddress returns the lowercased bare address of an email address, dropping any display name
+func NormalizeAddress(address string) string {
+	if parsed, err := mail.ParseAddress(address); err == nil {
+		address = parsed.Address
+	}
+	return strings.ToLower(strings.TrimSpace(address))
+}
+
+// filterSuppressed removes suppressed recipients from a message. It returns a permanent SuppressedError
+// when no recipients remain.
+func (m *Mailpen) filterSuppressed(ctx context.Context, msg *Message) error {
+	if m.suppressions == nil {
+		return nil
+	}
+
+	all := make([]string, 0, len(msg.To)+len(msg.Cc)+len(msg.Bcc))
+	all = append(append(append(all, msg.To...), msg.Cc...), msg.Bcc...)
+	if len(all) == 0 {
+		return nil
+	}
+
+	suppressed, err := m.suppressions.Suppressed(ctx, all)
+	if err != nil {
+		return fmt.Errorf("failed to check suppression list: %w", err)
+	}
+	if len(suppressed) == 0 {
+		return nil
+	}
+
+	blocked := make(map[string]bool, len(suppressed))
+	for _, addr := range suppressed {
+		blocked[NormalizeAddress(addr)] = true
+	}
+
+	keep := func(list []string) []string {
+		var kept []string
+		for _, addr := range list {
+			if !blocked[NormalizeAddress(addr)] {
+				kept = append(kept, addr)
+			}
+		}
+		return kept
+	}
+	msg.To, msg.Cc, msg.Bcc = keep(msg.To), keep(msg.Cc), keep(msg.Bcc)
+	if res := resultFrom(ctx); res != nil {
+		res.Suppressed = suppressed
+	}
+
+	if len(msg.To)+len(msg.Cc)+len(msg.Bcc) == 0 {
+		return Permanent(&SuppressedError{Recipients: suppressed})
+	}
+
+	m.logger.InfoContext(ctx, "mailpen: skipped suppressed recipients", append(m.logAttrs(msg), slog.Int("suppressed", len(suppressed)))...)
+	return nil
+}
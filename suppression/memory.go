@@ -0,0 +1,67 @@
+// Package suppression provides mailpen.SuppressionStore implementations.
+package suppression
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/patrickward/mailpen"
+)
+
+// MemoryStore is an in-memory suppression list. It is safe for concurrent use.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	entries map[string]mailpen.Suppression
+}
+
+// NewMemoryStore creates an empty in-memory suppression list
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]mailpen.Suppression)}
+}
+
+// Suppress implements mailpen.SuppressionStore
+func (s *MemoryStore) Suppress(_ context.Context, sup mailpen.Suppression) error {
+	sup.Address = mailpen.NormalizeAddress(sup.Address)
+	if sup.CreatedAt.IsZero() {
+		sup.CreatedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[sup.Address] = sup
+	return nil
+}
+
+// Unsuppress implements mailpen.SuppressionStore
+func (s *MemoryStore) Unsuppress(_ context.Context, address string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, mailpen.NormalizeAddress(address))
+	return nil
+}
+
+// Suppressed implements mailpen.SuppressionStore
+func (s *MemoryStore) Suppressed(_ context.Context, addresses []string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var suppressed []string
+	seen := make(map[string]bool)
+	for _, addr := range addresses {
+		addr = mailpen.NormalizeAddress(addr)
+		if _, ok := s.entries[addr]; ok && !seen[addr] {
+			seen[addr] = true
+			suppressed = append(suppressed, addr)
+		}
+	}
+	return suppressed, nil
+}
+
+// Get returns the suppression for an address, if any
+func (s *MemoryStore) Get(_ context.Context, address string) (mailpen.Suppression, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	sup, ok := s.entries[mailpen.NormalizeAddress(address)]
+	return sup, ok, nil
+}
@@ -0,0 +1,149 @@
+package suppression
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/sqldialect"
+)
+
+// SQLStore is a suppression list stored in a database table via database/sql
+type SQLStore struct {
+	db      *sql.DB
+	dialect sqldialect.Dialect
+	table   string
+}
+
+// SQLOption configures a SQLStore
+type SQLOption func(s *SQLStore)
+
+// WithTable sets the table name (defaults to "mailpen_suppressions")
+func WithTable(table string) SQLOption {
+	return func(s *SQLStore) {
+		s.table = table
+	}
+}
+
+// NewSQLStore creates a suppression list backed by a SQL table
+func NewSQLStore(db *sql.DB, dialect sqldialect.Dialect, opts ...SQLOption) (*SQLStore, error) {
+	if db == nil {
+		return nil, errors.New("database is required")
+	}
+	if dialect.Placeholder == nil {
+		return nil, errors.New("dialect is required")
+	}
+
+	s := &SQLStore{db: db, dialect: dialect, table: "mailpen_suppressions"}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Migrate creates the suppression table if it does not exist
+func (s *SQLStore) Migrate(ctx context.Context) error {
+	query := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	address VARCHAR(320) PRIMARY KEY,
+	reason VARCHAR(32) NOT NULL,
+	created_at BIGINT NOT NULL
+)`, s.table)
+
+	if _, err := s.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create suppression table: %w", err)
+	}
+	return nil
+}
+
+// Suppress implements mailpen.SuppressionStore
+func (s *SQLStore) Suppress(ctx context.Context, sup mailpen.Suppression) error {
+	address := mailpen.NormalizeAddress(sup.Address)
+	if sup.CreatedAt.IsZero() {
+		sup.CreatedAt = time.Now()
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, s.dialect.Rebind(fmt.Sprintf("DELETE FROM %s WHERE address = ?", s.table)), address); err != nil {
+		return fmt.Errorf("failed to replace suppression: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, s.dialect.Rebind(fmt.Sprintf(
+		"INSERT INTO %s (address, reason, created_at) VALUES (?, ?, ?)", s.table)),
+		address, string(sup.Reason), sup.CreatedAt.UnixMilli(),
+	); err != nil {
+		return fmt.Errorf("failed to add suppression: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// Unsuppress implements mailpen.SuppressionStore
+func (s *SQLStore) Unsuppress(ctx context.Context, address string) error {
+	_, err := s.db.ExecContext(ctx, s.dialect.Rebind(fmt.Sprintf("DELETE FROM %s WHERE address = ?", s.table)), mailpen.NormalizeAddress(address))
+	if err != nil {
+		return fmt.Errorf("failed to remove suppression: %w", err)
+	}
+	return nil
+}
+
+// Suppressed implements mailpen.SuppressionStore
+func (s *SQLStore) Suppressed(ctx context.Context, addresses []string) ([]string, error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+
+	args := make([]any, len(addresses))
+	for i, addr := range addresses {
+		args[i] = mailpen.NormalizeAddress(addr)
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.dialect.Rebind(fmt.Sprintf(
+		"SELECT address FROM %s WHERE address IN (%s)", s.table, sqldialect.Placeholders(len(args)))),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check suppressions: %w", err)
+	}
+	defer rows.Close()
+
+	var suppressed []string
+	for rows.Next() {
+		var addr string
+		if err := rows.Scan(&addr); err != nil {
+			return nil, fmt.Errorf("failed to scan suppression: %w", err)
+		}
+		suppressed = append(suppressed, addr)
+	}
+	return suppressed, rows.Err()
+}
+
+// Get returns the suppression for an address, if any
+func (s *SQLStore) Get(ctx context.Context, address string) (mailpen.Suppression, bool, error) {
+	var reason string
+	var created int64
+	address = mailpen.NormalizeAddress(address)
+
+	err := s.db.QueryRowContext(ctx, s.dialect.Rebind(fmt.Sprintf(
+		"SELECT reason, created_at FROM %s WHERE address = ?", s.table)), address,
+	).Scan(&reason, &created)
+	if errors.Is(err, sql.ErrNoRows) {
+		return mailpen.Suppression{}, false, nil
+	}
+	if err != nil {
+		return mailpen.Suppression{}, false, fmt.Errorf("failed to load suppression: %w", err)
+	}
+
+	return mailpen.Suppression{
+		Address:   address,
+		Reason:    mailpen.SuppressionReason(reason),
+		CreatedAt: time.UnixMilli(created),
+	}, true, nil
+}
@@ -0,0 +1,83 @@
+package suppression_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/sqldialect"
+	"github.com/patrickward/mailpen/suppression"
+)
+
+// store is the common interface of the stores under test
+type store interface {
+	mailpen.SuppressionStore
+	Get(ctx context.Context, address string) (mailpen.Suppression, bool, error)
+}
+
+func stores(t *testing.T) map[string]store {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", "file:"+t.Name()+"?mode=memory&cache=shared")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+
+	sqlStore, err := suppression.NewSQLStore(db, sqldialect.SQLite)
+	require.NoError(t, err)
+	require.NoError(t, sqlStore.Migrate(context.Background()))
+
+	return map[string]store{
+		"memory": suppression.NewMemoryStore(),
+		"sql":    sqlStore,
+	}
+}
+
+func TestStores(t *testing.T) {
+	for name, s := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			require.NoError(t, s.Suppress(ctx, mailpen.Suppression{Address: "Jane <JANE@example.com>", Reason: mailpen.SuppressionBounce}))
+			require.NoError(t, s.Suppress(ctx, mailpen.Suppression{Address: "bob@example.com", Reason: mailpen.SuppressionComplaint}))
+
+			suppressed, err := s.Suppressed(ctx, []string{"jane@example.com", "alice@example.com", "Bob@Example.com"})
+			require.NoError(t, err)
+			assert.ElementsMatch(t, []string{"jane@example.com", "bob@example.com"}, suppressed)
+
+			sup, ok, err := s.Get(ctx, "jane@example.com")
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.Equal(t, mailpen.SuppressionBounce, sup.Reason)
+			assert.False(t, sup.CreatedAt.IsZero())
+
+			// Suppressing again replaces the reason
+			require.NoError(t, s.Suppress(ctx, mailpen.Suppression{Address: "jane@example.com", Reason: mailpen.SuppressionUnsubscribe}))
+			sup, _, err = s.Get(ctx, "jane@example.com")
+			require.NoError(t, err)
+			assert.Equal(t, mailpen.SuppressionUnsubscribe, sup.Reason)
+
+			require.NoError(t, s.Unsuppress(ctx, "JANE@example.com"))
+			_, ok, err = s.Get(ctx, "jane@example.com")
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			suppressed, err = s.Suppressed(ctx, nil)
+			require.NoError(t, err)
+			assert.Empty(t, suppressed)
+		})
+	}
+}
+
+func TestNewSQLStore(t *testing.T) {
+	_, err := suppression.NewSQLStore(nil, sqldialect.SQLite)
+	assert.Error(t, err)
+
+	_, err = suppression.NewSQLStore(&sql.DB{}, sqldialect.Dialect{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,93 @@
+package mailpen_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/suppression"
+)
+
+func TestNormalizeAddress(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "jane@example.com", want: "jane@example.com"},
+		{in: " Jane@Example.COM ", want: "jane@example.com"},
+		{in: "Jane Doe <Jane@Example.com>", want: "jane@example.com"},
+		{in: "not an address", want: "not an address"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.want, mailpen.NormalizeAddress(tt.in))
+		})
+	}
+}
+
+func TestMailpen_Suppressions(t *testing.T) {
+	tests := []struct {
+		name      string
+		to        []string
+		cc        []string
+		wantErr   bool
+		wantTo    []string
+		sendCalls int
+	}{
+		{
+			name:      "no suppressed recipients",
+			to:        []string{"alice@example.com"},
+			wantTo:    []string{"alice@example.com"},
+			sendCalls: 1,
+		},
+		{
+			name:      "suppressed recipients are skipped",
+			to:        []string{"alice@example.com", "Blocked <blocked@example.com>"},
+			cc:        []string{"BLOCKED@example.com"},
+			wantTo:    []string{"alice@example.com"},
+			sendCalls: 1,
+		},
+		{
+			name:    "all recipients suppressed",
+			to:      []string{"blocked@example.com"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := suppression.NewMemoryStore()
+			require.NoError(t, store.Suppress(context.Background(), mailpen.Suppression{
+				Address: "blocked@example.com",
+				Reason:  mailpen.SuppressionBounce,
+			}))
+
+			mock := &mockProvider{}
+			mp, err := mailpen.New(mock, &mailpen.Config{From: "sender@example.com"}, mailpen.WithSuppressionStore(store))
+			require.NoError(t, err)
+
+			msg := mailpen.NewMessage().To(tt.to...).Cc(tt.cc...).Subject("Test").Must()
+			err = mp.Send(context.Background(), msg)
+
+			assert.Equal(t, tt.sendCalls, mock.sendCalls)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, mailpen.ErrSuppressedRecipient)
+				assert.True(t, mailpen.IsPermanent(err))
+
+				var suppressed *mailpen.SuppressedError
+				require.ErrorAs(t, err, &suppressed)
+				assert.Equal(t, []string{"blocked@example.com"}, suppressed.Recipients)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTo, mock.lastMessage.To)
+			assert.Empty(t, mock.lastMessage.Cc)
+		})
+	}
+}
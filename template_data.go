@@ -1,15 +1,25 @@
 package mailpen
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"strings"
 	"time"
 )
 
 type TemplateData map[string]any
 
 func NewTemplateData(cfg *Config) TemplateData {
-	now := time.Now()
+	return newTemplateData(cfg, time.Now())
+}
 
+// newTemplateData returns the default template data with timestamps taken from now
+func newTemplateData(cfg *Config, now time.Time) TemplateData {
+	now = cfg.localTime(now)
+	dateLayout, timestampLayout := cfg.dateLayouts()
 	data := TemplateData{
 		"BaseURL":          cfg.BaseURL,
 		"Copyright":        fmt.Sprintf("© %d %s. All rights reserved", now.Year(), cfg.CompanyName),
@@ -22,10 +32,13 @@ func NewTemplateData(cfg *Config) TemplateData {
 		"WebsiteName":      cfg.WebsiteName,
 		"WebsiteURL":       cfg.WebsiteURL,
 		"CurrentYear":      now.Year(),
-		"CurrentTimestamp": now.Format("2006-01-02 15:04:05"),
-		"CurrentDate":      now.Format("January 2, 2006"),
+		"CurrentTimestamp": now.Format(timestampLayout),
+		"CurrentDate":      now.Format(dateLayout),
+		"Now":              now,
 		"SiteLinks":        cfg.SiteLinks,
 		"SocialMediaLinks": cfg.SocialMediaLinks,
+		"SocialLinks":      socialLinks(cfg),
+		"FooterData":       footerData(cfg, now),
 	}
 
 	return data
@@ -80,3 +93,189 @@ func (td TemplateData) MergeKeys(data map[string]any) TemplateData {
 
 	return merged
 }
+
+// DeepMerge returns the data with data merged in at every depth: where both hold maps, the keys are merged
+// instead of the new map replacing the existing one, so a partial override keeps the defaults it does not
+// set. Maps with string keys count as maps, and so does a struct default, such as FooterData, overridden
+// with a map; the result is then a map[string]any. Other values, including structs, replace the existing
+// value. Neither map is modified.
+func (td TemplateData) DeepMerge(data map[string]any) TemplateData {
+	return deepMerge(td, data)
+}
+
+// deepMerge merges overlay into a copy of base, recursively
+func deepMerge(base, overlay map[string]any) map[string]any {
+	result := make(map[string]any, len(base)+len(overlay))
+	maps.Copy(result, base)
+
+	for k, v := range overlay {
+		if merged, ok := mergeValues(result[k], v); ok {
+			result[k] = merged
+			continue
+		}
+		result[k] = v
+	}
+
+	return result
+}
+
+// mergeValues merges an overlay value into a base value when the overlay is a map and the base is a map or
+// a struct. It reports whether the values were merged.
+func mergeValues(base, overlay any) (any, bool) {
+	// Keep the type of string maps, such as SiteLinks, when both sides are string maps
+	if b, ok := base.(map[string]string); ok {
+		if o, ok := overlay.(map[string]string); ok {
+			merged := maps.Clone(b)
+			maps.Copy(merged, o)
+			return merged, true
+		}
+	}
+
+	o := indirect(reflect.ValueOf(overlay))
+	if !o.IsValid() || o.Kind() != reflect.Map {
+		return nil, false
+	}
+	overlayMap, ok := toMap(o)
+	if !ok {
+		return nil, false
+	}
+	baseMap, ok := toMap(indirect(reflect.ValueOf(base)))
+	if !ok {
+		return nil, false
+	}
+
+	return deepMerge(baseMap, overlayMap), true
+}
+
+// toMap converts a map with string keys, or the exported fields of a struct, to a map[string]any
+func toMap(v reflect.Value) (map[string]any, bool) {
+	switch {
+	case !v.IsValid():
+		return nil, false
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		if m, ok := v.Interface().(map[string]any); ok {
+			return m, true
+		}
+		m := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = iter.Value().Interface()
+		}
+		return m, true
+	case v.Kind() == reflect.Struct:
+		m := make(map[string]any, v.NumField())
+		for i := range v.NumField() {
+			if field := v.Type().Field(i); field.IsExported() {
+				m[field.Name] = v.Field(i).Interface()
+			}
+		}
+		return m, true
+	default:
+		return nil, false
+	}
+}
+
+// Get returns the value at a dot-separated path, such as "user.address.city" or "FooterData.SupportEmail",
+// descending through maps with string keys and struct fields. It reports whether the path exists.
+func (td TemplateData) Get(path string) (any, bool) {
+	if path == "" {
+		return nil, false
+	}
+
+	v := reflect.ValueOf(map[string]any(td))
+	for _, part := range strings.Split(path, ".") {
+		v = indirect(v)
+		if !v.IsValid() || (v.Kind() != reflect.Map && v.Kind() != reflect.Struct) {
+			return nil, false
+		}
+		if v = lookup(v, part); !v.IsValid() || !v.CanInterface() {
+			return nil, false
+		}
+	}
+
+	return v.Interface(), true
+}
+
+// Has reports whether the path exists and holds a non-nil value
+func (td TemplateData) Has(path string) bool {
+	value, ok := td.Get(path)
+	return ok && !isMissing(reflect.ValueOf(value))
+}
+
+// GetString returns the string at path, or "" when the path is missing or does not hold a string
+func (td TemplateData) GetString(path string) string {
+	value, _ := td.Get(path)
+	if v := indirect(reflect.ValueOf(value)); v.IsValid() && v.Kind() == reflect.String {
+		return v.String()
+	}
+	return ""
+}
+
+// GetInt returns the integer at path, or 0 when the path is missing or does not hold an integer. Whole
+// floats, such as numbers decoded from JSON, count as integers.
+func (td TemplateData) GetInt(path string) int {
+	value, _ := td.Get(path)
+	v := indirect(reflect.ValueOf(value))
+	switch {
+	case v.CanInt():
+		return int(v.Int())
+	case v.CanUint():
+		return int(v.Uint())
+	case v.CanFloat() && v.Float() == math.Trunc(v.Float()):
+		return int(v.Float())
+	default:
+		return 0
+	}
+}
+
+// GetBool returns the bool at path, or false when the path is missing or does not hold a bool
+func (td TemplateData) GetBool(path string) bool {
+	value, _ := td.Get(path)
+	if v := indirect(reflect.ValueOf(value)); v.IsValid() && v.Kind() == reflect.Bool {
+		return v.Bool()
+	}
+	return false
+}
+
+// GetMap returns the map at path, or nil when the path is missing or does not hold a map[string]any
+func (td TemplateData) GetMap(path string) map[string]any {
+	value, _ := td.Get(path)
+	switch m := value.(type) {
+	case map[string]any:
+		return m
+	case TemplateData:
+		return m
+	default:
+		return nil
+	}
+}
+
+// SetPath sets the value at a dot-separated path, such as "footer.support.email", creating the maps along
+// the way. The maps on the path are copied before they are changed, so maps shared with other data, such as
+// the original of a Merge, are not modified. It fails when a value on the path is not a map[string]any.
+func (td TemplateData) SetPath(path string, value any) error {
+	if path == "" {
+		return errors.New("path is required")
+	}
+
+	parts := strings.Split(path, ".")
+	current := map[string]any(td)
+	for i, part := range parts[:len(parts)-1] {
+		var next map[string]any
+		switch existing := current[part].(type) {
+		case nil:
+			next = make(map[string]any)
+		case map[string]any:
+			next = maps.Clone(existing)
+		case TemplateData:
+			next = maps.Clone(existing)
+		default:
+			return fmt.Errorf("cannot set %q: %q holds a %T, not a map", path, strings.Join(parts[:i+1], "."), existing)
+		}
+		current[part] = next
+		current = next
+	}
+
+	current[parts[len(parts)-1]] = value
+	return nil
+}
@@ -1,8 +1,13 @@
 package mailpen_test
 
 import (
+	"context"
 	"reflect"
 	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/patrickward/mailpen"
 )
@@ -171,3 +176,170 @@ func TestTemplateData_MergeKeys(t *testing.T) {
 		})
 	}
 }
+
+func TestTemplateData_Getters(t *testing.T) {
+	data := mailpen.TemplateData{
+		"name":    "Ada",
+		"count":   3,
+		"total":   float64(42), // As decoded from JSON
+		"ratio":   0.5,
+		"active":  true,
+		"missing": nil,
+		"user": map[string]any{
+			"address": map[string]any{"city": "London"},
+			"age":     uint8(36),
+		},
+		"FooterData": mailpen.FooterData{SupportEmail: "help@example.com"},
+		"links":      map[string]string{"home": "https://example.com"},
+		"nested":     mailpen.TemplateData{"key": "value"},
+	}
+
+	tests := []struct {
+		path       string
+		wantString string
+		wantInt    int
+		wantBool   bool
+		wantHas    bool
+	}{
+		{path: "name", wantString: "Ada", wantHas: true},
+		{path: "count", wantInt: 3, wantHas: true},
+		{path: "total", wantInt: 42, wantHas: true},
+		{path: "ratio", wantHas: true},
+		{path: "active", wantBool: true, wantHas: true},
+		{path: "missing"},
+		{path: "unknown"},
+		{path: "user.address.city", wantString: "London", wantHas: true},
+		{path: "user.age", wantInt: 36, wantHas: true},
+		{path: "user.address.city.name"},
+		{path: "FooterData.SupportEmail", wantString: "help@example.com", wantHas: true},
+		{path: "FooterData.Unknown"},
+		{path: "links.home", wantString: "https://example.com", wantHas: true},
+		{path: "nested.key", wantString: "value", wantHas: true},
+		{path: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.wantString, data.GetString(tt.path))
+			assert.Equal(t, tt.wantInt, data.GetInt(tt.path))
+			assert.Equal(t, tt.wantBool, data.GetBool(tt.path))
+			assert.Equal(t, tt.wantHas, data.Has(tt.path))
+		})
+	}
+
+	t.Run("get reports nil values", func(t *testing.T) {
+		value, ok := data.Get("missing")
+		assert.True(t, ok)
+		assert.Nil(t, value)
+	})
+
+	t.Run("get map", func(t *testing.T) {
+		assert.Equal(t, map[string]any{"city": "London"}, data.GetMap("user.address"))
+		assert.Equal(t, map[string]any{"key": "value"}, data.GetMap("nested"))
+		assert.Nil(t, data.GetMap("name"))
+	})
+}
+
+func TestTemplateData_SetPath(t *testing.T) {
+	t.Run("creates nested maps", func(t *testing.T) {
+		data := mailpen.TemplateData{}
+		require.NoError(t, data.SetPath("footer.support.email", "help@example.com"))
+		assert.Equal(t, "help@example.com", data.GetString("footer.support.email"))
+		assert.Equal(t, mailpen.TemplateData{
+			"footer": map[string]any{"support": map[string]any{"email": "help@example.com"}},
+		}, data)
+	})
+
+	t.Run("does not modify shared maps", func(t *testing.T) {
+		shared := map[string]any{"support": map[string]any{"email": "old@example.com", "phone": "555"}}
+		base := mailpen.TemplateData{"footer": shared}
+		data := base.Merge(nil)
+
+		require.NoError(t, data.SetPath("footer.support.email", "new@example.com"))
+		assert.Equal(t, "new@example.com", data.GetString("footer.support.email"))
+		assert.Equal(t, "555", data.GetString("footer.support.phone"))
+		assert.Equal(t, "old@example.com", base.GetString("footer.support.email"))
+	})
+
+	t.Run("top-level key", func(t *testing.T) {
+		data := mailpen.TemplateData{}
+		require.NoError(t, data.SetPath("name", "Ada"))
+		assert.Equal(t, "Ada", data["name"])
+	})
+
+	t.Run("non-map on the path", func(t *testing.T) {
+		data := mailpen.TemplateData{"footer": "text"}
+		err := data.SetPath("footer.support.email", "help@example.com")
+		assert.EqualError(t, err, `cannot set "footer.support.email": "footer" holds a string, not a map`)
+		assert.Equal(t, "text", data["footer"])
+	})
+
+	t.Run("empty path", func(t *testing.T) {
+		assert.Error(t, mailpen.TemplateData{}.SetPath("", 1))
+	})
+}
+
+func TestTemplateData_DeepMerge(t *testing.T) {
+	t.Run("nested maps", func(t *testing.T) {
+		base := mailpen.TemplateData{
+			"user": map[string]any{"name": "Ada", "plan": map[string]any{"name": "pro", "seats": 5}},
+		}
+		merged := base.DeepMerge(map[string]any{
+			"user": map[string]any{"plan": map[string]any{"seats": 10}},
+		})
+
+		assert.Equal(t, "Ada", merged.GetString("user.name"))
+		assert.Equal(t, "pro", merged.GetString("user.plan.name"))
+		assert.Equal(t, 10, merged.GetInt("user.plan.seats"))
+		assert.Equal(t, 5, base.GetInt("user.plan.seats"), "base must not change")
+	})
+
+	t.Run("string maps keep their type", func(t *testing.T) {
+		base := mailpen.TemplateData{"SiteLinks": map[string]string{"home": "/", "help": "/help"}}
+		merged := base.DeepMerge(map[string]any{"SiteLinks": map[string]string{"help": "/support"}})
+
+		assert.Equal(t, map[string]string{"home": "/", "help": "/support"}, merged["SiteLinks"])
+		assert.Equal(t, "/help", base.GetString("SiteLinks.help"), "base must not change")
+	})
+
+	t.Run("struct default with map override", func(t *testing.T) {
+		base := mailpen.TemplateData{"FooterData": mailpen.FooterData{CompanyName: "Acme", SupportEmail: "help@acme.test"}}
+		merged := base.DeepMerge(map[string]any{"FooterData": map[string]any{"SupportEmail": "vip@acme.test"}})
+
+		assert.Equal(t, "Acme", merged.GetString("FooterData.CompanyName"))
+		assert.Equal(t, "vip@acme.test", merged.GetString("FooterData.SupportEmail"))
+	})
+
+	t.Run("non-map values replace", func(t *testing.T) {
+		base := mailpen.TemplateData{"user": map[string]any{"name": "Ada"}, "count": 1}
+		merged := base.DeepMerge(map[string]any{"user": "Grace", "count": 2})
+
+		assert.Equal(t, "Grace", merged["user"])
+		assert.Equal(t, 2, merged["count"])
+	})
+
+	t.Run("overlay is not modified", func(t *testing.T) {
+		overlay := map[string]any{"user": map[string]any{"email": "ada@example.com"}}
+		mailpen.TemplateData{"user": map[string]any{"name": "Ada"}}.DeepMerge(overlay)
+
+		assert.Equal(t, map[string]any{"user": map[string]any{"email": "ada@example.com"}}, overlay)
+	})
+}
+
+func TestMailpen_RenderDeepMergesDefaults(t *testing.T) {
+	mp, err := mailpen.New(&mockProvider{}, &mailpen.Config{
+		From:         "sender@example.com",
+		CompanyName:  "Default Inc",
+		SupportEmail: "help@example.com",
+		Sources: []mailpen.TemplateSource{{Name: "test", FS: fstest.MapFS{
+			"emails/footer.html": {Data: []byte(`{{define "content"}}{{.FooterData.CompanyName}}, {{.FooterData.SupportEmail}}{{end}}`)},
+		}}},
+	})
+	require.NoError(t, err)
+
+	msg := mailpen.NewMessage().To("recipient@example.com").Template("footer").
+		WithData(map[string]any{"FooterData": map[string]any{"SupportEmail": "vip@example.com"}}).Must()
+	email, err := mp.Render(context.Background(), msg)
+	require.NoError(t, err)
+	assert.Contains(t, email.HTML, "Default Inc, vip@example.com")
+}
@@ -0,0 +1,189 @@
+package templates
+
+// Component is a building block of an email composed in Go. Template returns the name of the component
+// template (e.g. "@hero") and Data the value passed to it.
+type Component interface {
+	Template() string
+	Data() any
+}
+
+// Layout is an email composed from components, rendered in order into the content block of a layout
+type Layout struct {
+	Name       string      // Layout to render into (defaults to the manager's default layout)
+	Subject    string      // Subject block, used as the HTML document title
+	Components []Component // Components rendered in order
+}
+
+// Hero renders the @hero component
+type Hero struct {
+	Headline   string
+	Subhead    string
+	Image      string // Full-width image above the text
+	ImageAlt   string
+	Background string // Theme color name (defaults to "primary")
+	ButtonText string
+	ButtonURL  string
+}
+
+// Template implements Component
+func (Hero) Template() string { return "@hero" }
+
+// Data implements Component
+func (h Hero) Data() any {
+	return map[string]any{
+		"headline":   h.Headline,
+		"subhead":    h.Subhead,
+		"image":      h.Image,
+		"imageAlt":   h.ImageAlt,
+		"background": h.Background,
+		"buttonText": h.ButtonText,
+		"buttonURL":  h.ButtonURL,
+	}
+}
+
+// Heading renders the @h1, @h2, or @h3 component
+type Heading struct {
+	Text  string
+	Level int    // 1 to 3 (defaults to 1)
+	Align string // "left", "center", or "right"
+}
+
+// Template implements Component
+func (h Heading) Template() string {
+	switch h.Level {
+	case 2:
+		return "@h2"
+	case 3:
+		return "@h3"
+	default:
+		return "@h1"
+	}
+}
+
+// Data implements Component
+func (h Heading) Data() any {
+	return map[string]any{"text": h.Text, "align": h.Align}
+}
+
+// Paragraph renders the @p, @p-lead, or @p-muted component
+type Paragraph struct {
+	Text  string
+	Style string // "lead" or "muted" (defaults to a regular paragraph)
+	Align string // "left", "center", or "right"
+}
+
+// Template implements Component
+func (p Paragraph) Template() string {
+	switch p.Style {
+	case "lead":
+		return "@p-lead"
+	case "muted":
+		return "@p-muted"
+	default:
+		return "@p"
+	}
+}
+
+// Data implements Component
+func (p Paragraph) Data() any {
+	return map[string]any{"text": p.Text, "align": p.Align}
+}
+
+// Button renders the @button component
+type Button struct {
+	Text  string
+	URL   string
+	Style string // Theme color name (defaults to "primary")
+	Width string // Width of the Outlook button, e.g. "280px"
+}
+
+// Template implements Component
+func (Button) Template() string { return "@button" }
+
+// Data implements Component
+func (b Button) Data() any {
+	return map[string]any{"Text": b.Text, "URL": b.URL, "Style": b.Style, "Width": b.Width}
+}
+
+// Table renders the @data-table component
+type Table struct {
+	Headers []string
+	Rows    [][]string
+}
+
+// Template implements Component
+func (Table) Template() string { return "@data-table" }
+
+// Data implements Component
+func (t Table) Data() any {
+	headers := make([]map[string]any, len(t.Headers))
+	for i, header := range t.Headers {
+		headers[i] = map[string]any{"Text": header, "Width": ""}
+	}
+
+	rows := make([]map[string]any, len(t.Rows))
+	for i, row := range t.Rows {
+		cells := make([]map[string]any, len(row))
+		for j, cell := range row {
+			cells[j] = map[string]any{"Text": cell, "Width": ""}
+		}
+		rows[i] = map[string]any{"Cells": cells}
+	}
+
+	return map[string]any{"Headers": headers, "Rows": rows}
+}
+
+// Alert renders the @alert component
+type Alert struct {
+	Title      string
+	Message    string
+	Style      string // Theme color name (defaults to "primary")
+	ButtonText string
+	ButtonURL  string
+}
+
+// Template implements Component
+func (Alert) Template() string { return "@alert" }
+
+// Data implements Component
+func (a Alert) Data() any {
+	return map[string]any{
+		"title":      a.Title,
+		"message":    a.Message,
+		"style":      a.Style,
+		"buttonText": a.ButtonText,
+		"buttonURL":  a.ButtonURL,
+	}
+}
+
+// List renders the @list component
+type List struct {
+	Items []string
+	Type  string // "ul", "ol", or "check" (defaults to "ul")
+}
+
+// Template implements Component
+func (List) Template() string { return "@list" }
+
+// Data implements Component
+func (l List) Data() any {
+	return map[string]any{"items": l.Items, "type": l.Type}
+}
+
+// Divider renders the @divider component
+type Divider struct{}
+
+// Template implements Component
+func (Divider) Template() string { return "@divider" }
+
+// Data implements Component
+func (Divider) Data() any { return nil }
+
+// Spacer renders the @spacer component
+type Spacer struct{}
+
+// Template implements Component
+func (Spacer) Template() string { return "@spacer" }
+
+// Data implements Component
+func (Spacer) Data() any { return nil }
@@ -2,5 +2,5 @@ package templates
 
 import "embed"
 
-//go:embed components layouts
+//go:embed components layouts icons
 var FS embed.FS
@@ -0,0 +1,450 @@
+// Package testutil runs a Mailpit server in a Docker container for integration tests. Each server gets its own
+// container on random host ports, so parallel tests don't share messages.
+package testutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/patrickward/mailpen/mailpentest"
+	"github.com/patrickward/mailpen/providers/smtp"
+)
+
+const (
+	MailpitImage    = "axllent/mailpit:latest"
+	MailpitSMTPPort = "1025/tcp"
+	MailpitUIPort   = "8025/tcp"
+)
+
+type EmailAddress struct {
+	Name    string `json:"Name"`
+	Address string `json:"Address"`
+}
+
+type MailpitMessage struct {
+	ID          string         `json:"ID"`
+	MessageID   string         `json:"MessageID"`
+	Read        bool           `json:"Read"`
+	From        EmailAddress   `json:"From"`
+	To          []EmailAddress `json:"To"`
+	Subject     string         `json:"Subject"`
+	Attachments int            `json:"Attachments"`
+	Snippet     string         `json:"Snippet"`
+}
+
+// MailpitMessageDetail is a message with its bodies and attachments
+type MailpitMessageDetail struct {
+	ID          string              `json:"ID"`
+	MessageID   string              `json:"MessageID"`
+	From        EmailAddress        `json:"From"`
+	To          []EmailAddress      `json:"To"`
+	Cc          []EmailAddress      `json:"Cc"`
+	Bcc         []EmailAddress      `json:"Bcc"`
+	ReplyTo     []EmailAddress      `json:"ReplyTo"`
+	Subject     string              `json:"Subject"`
+	Date        time.Time           `json:"Date"`
+	Tags        []string            `json:"Tags"`
+	Size        int                 `json:"Size"`
+	Text        string              `json:"Text"`
+	HTML        string              `json:"HTML"`
+	Inline      []MailpitAttachment `json:"Inline"` // Inline parts, such as embedded images
+	Attachments []MailpitAttachment `json:"Attachments"`
+}
+
+// Attachment returns the attachment or inline part with the given file name
+func (d *MailpitMessageDetail) Attachment(filename string) (MailpitAttachment, bool) {
+	for _, parts := range [][]MailpitAttachment{d.Attachments, d.Inline} {
+		for _, att := range parts {
+			if att.FileName == filename {
+				return att, true
+			}
+		}
+	}
+	return MailpitAttachment{}, false
+}
+
+// MailpitAttachment is an attachment of a message
+type MailpitAttachment struct {
+	PartID      string `json:"PartID"`
+	FileName    string `json:"FileName"`
+	ContentType string `json:"ContentType"`
+	ContentID   string `json:"ContentID"`
+	Size        int    `json:"Size"`
+}
+
+type mailpitResponse struct {
+	Total         int              `json:"total"`
+	Unread        int              `json:"unread"`
+	Count         int              `json:"count"`
+	MessagesCount int              `json:"messages_count"`
+	Start         int              `json:"start"`
+	Tags          []string         `json:"tags"`
+	Messages      []MailpitMessage `json:"messages"`
+}
+
+// Mailpit is a running Mailpit container
+type Mailpit struct {
+	Host     string // Host of the mapped ports
+	SMTPPort int    // Host port of the SMTP server
+	APIURL   string // Base URL of the web UI and API, e.g. "http://localhost:32768"
+
+	container testcontainers.Container
+	client    *http.Client
+}
+
+// mailpitPollInterval is how often WaitForMessage searches for the message
+const mailpitPollInterval = 100 * time.Millisecond
+
+// StartMailpit starts a Mailpit container and waits until its SMTP server and API are ready. Call Terminate
+// to stop it.
+func StartMailpit(ctx context.Context) (*Mailpit, error) {
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        MailpitImage,
+			ExposedPorts: []string{MailpitSMTPPort, MailpitUIPort},
+			WaitingFor: wait.ForAll(
+				wait.ForListeningPort(MailpitSMTPPort),
+				wait.ForHTTP("/api/v1/info").WithPort(MailpitUIPort),
+			).WithDeadline(time.Minute),
+		},
+		Started: true,
+	})
+	if err != nil {
+		if container != nil {
+			_ = container.Terminate(ctx)
+		}
+		return nil, fmt.Errorf("failed to start Mailpit container: %w", err)
+	}
+
+	m := &Mailpit{container: container, client: &http.Client{Timeout: 10 * time.Second}}
+
+	if m.Host, err = container.Host(ctx); err != nil {
+		_ = container.Terminate(ctx)
+		return nil, err
+	}
+
+	smtpPort, err := container.MappedPort(ctx, MailpitSMTPPort)
+	if err != nil {
+		_ = container.Terminate(ctx)
+		return nil, err
+	}
+	m.SMTPPort = smtpPort.Int()
+
+	uiPort, err := container.MappedPort(ctx, MailpitUIPort)
+	if err != nil {
+		_ = container.Terminate(ctx)
+		return nil, err
+	}
+	m.APIURL = fmt.Sprintf("http://%s:%s", m.Host, uiPort.Port())
+
+	return m, nil
+}
+
+// Terminate stops and removes the container
+func (m *Mailpit) Terminate(ctx context.Context) error {
+	return m.container.Terminate(ctx)
+}
+
+// SMTPConfig returns an SMTP provider configuration for the container, without authentication or TLS
+func (m *Mailpit) SMTPConfig() *smtp.Config {
+	return &smtp.Config{
+		Host:      m.Host,
+		Port:      m.SMTPPort,
+		AuthType:  "NOAUTH",
+		TLSPolicy: 0,
+	}
+}
+
+// Messages returns the messages received by Mailpit, newest first
+func (m *Mailpit) Messages(ctx context.Context) ([]MailpitMessage, error) {
+	var response mailpitResponse
+	if err := m.get(ctx, "/api/v1/messages", "messages", &response); err != nil {
+		return nil, err
+	}
+	return response.Messages, nil
+}
+
+// Search returns the messages matching a Mailpit search query, newest first. The query uses Mailpit's search
+// syntax, e.g. `to:user@example.com subject:"Welcome"` or `has:attachment`.
+func (m *Mailpit) Search(ctx context.Context, query string) ([]MailpitMessage, error) {
+	var response mailpitResponse
+	if err := m.get(ctx, "/api/v1/search?query="+url.QueryEscape(query), "search results", &response); err != nil {
+		return nil, err
+	}
+	return response.Messages, nil
+}
+
+// Message returns a message with its bodies and attachments
+func (m *Mailpit) Message(ctx context.Context, id string) (*MailpitMessageDetail, error) {
+	var detail MailpitMessageDetail
+	if err := m.get(ctx, "/api/v1/message/"+url.PathEscape(id), "message", &detail); err != nil {
+		return nil, err
+	}
+	return &detail, nil
+}
+
+// Headers returns the headers of a message, keyed by canonical header name
+func (m *Mailpit) Headers(ctx context.Context, id string) (http.Header, error) {
+	var headers map[string][]string
+	if err := m.get(ctx, "/api/v1/message/"+url.PathEscape(id)+"/headers", "message headers", &headers); err != nil {
+		return nil, err
+	}
+
+	h := make(http.Header, len(headers))
+	for name, values := range headers {
+		for _, value := range values {
+			h.Add(name, value)
+		}
+	}
+	return h, nil
+}
+
+// AttachmentContent returns the decoded content of an attachment or inline part of a message
+func (m *Mailpit) AttachmentContent(ctx context.Context, id, partID string) ([]byte, error) {
+	return m.read(ctx, "/api/v1/message/"+url.PathEscape(id)+"/part/"+url.PathEscape(partID), "attachment")
+}
+
+// Raw returns the source of a message, as received
+func (m *Mailpit) Raw(ctx context.Context, id string) ([]byte, error) {
+	return m.read(ctx, "/api/v1/message/"+url.PathEscape(id)+"/raw", "raw message")
+}
+
+// AwaitMessage polls Mailpit until a message matches the search query and returns the newest match, or fails
+// when none arrives within the timeout. An empty query matches any message.
+func (m *Mailpit) AwaitMessage(ctx context.Context, query string, timeout time.Duration) (*MailpitMessageDetail, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(mailpitPollInterval)
+	defer ticker.Stop()
+
+	for {
+		var messages []MailpitMessage
+		var err error
+		if query == "" {
+			messages, err = m.Messages(ctx)
+		} else {
+			messages, err = m.Search(ctx, query)
+		}
+		if err != nil && ctx.Err() == nil {
+			return nil, err
+		}
+		if len(messages) > 0 {
+			return m.Message(ctx, messages[0].ID)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("no Mailpit message matching %q received within %s", query, timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeleteMessages deletes all messages from Mailpit
+func (m *Mailpit) DeleteMessages(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, m.APIURL+"/api/v1/messages", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := m.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to clear messages: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to clear messages, status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// get requests an API path and decodes the JSON response into v
+func (m *Mailpit) get(ctx context.Context, path, what string, v any) error {
+	resp, err := m.do(ctx, path, what)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode Mailpit %s: %w", what, err)
+	}
+	return nil
+}
+
+// read requests an API path and returns the response body
+func (m *Mailpit) read(ctx context.Context, path, what string) ([]byte, error) {
+	resp, err := m.do(ctx, path, what)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Mailpit %s: %w", what, err)
+	}
+	return body, nil
+}
+
+// do sends a GET request to an API path and fails unless the response is 200 OK
+func (m *Mailpit) do(ctx context.Context, path, what string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.APIURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := m.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Mailpit %s: %w", what, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to get Mailpit %s, status: %d", what, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// httpClient returns the client for API requests, so a Mailpit created with only an APIURL (e.g. for a server
+// started outside the tests) works too
+func (m *Mailpit) httpClient() *http.Client {
+	if m.client == nil {
+		return &http.Client{Timeout: 10 * time.Second}
+	}
+	return m.client
+}
+
+// SetupMailpit starts a Mailpit container for a test and removes it when the test ends. The test is skipped
+// when Docker is not available.
+func SetupMailpit(t *testing.T) *Mailpit {
+	t.Helper()
+
+	skipWithoutDocker(t)
+
+	m, err := StartMailpit(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to start Mailpit: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := m.Terminate(context.Background()); err != nil {
+			t.Errorf("Failed to cleanup Mailpit container: %v", err)
+		}
+	})
+
+	return m
+}
+
+// skipWithoutDocker skips the test when Docker is not available. testcontainers panics instead of skipping
+// when it finds no Docker host at all.
+func skipWithoutDocker(t *testing.T) {
+	t.Helper()
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Skipf("Docker not available, skipping: %v", r)
+		}
+	}()
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+}
+
+// GetMessages returns the messages received by Mailpit, failing the test on error
+func (m *Mailpit) GetMessages(t testing.TB) []MailpitMessage {
+	t.Helper()
+
+	messages, err := m.Messages(context.Background())
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return messages
+}
+
+// SearchMessages returns the messages matching a Mailpit search query, failing the test on error
+func (m *Mailpit) SearchMessages(t testing.TB, query string) []MailpitMessage {
+	t.Helper()
+
+	messages, err := m.Search(context.Background(), query)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return messages
+}
+
+// WaitForMessage waits until a message matches the search query and returns it, failing the test when none
+// arrives within the timeout
+func (m *Mailpit) WaitForMessage(t testing.TB, query string, timeout time.Duration) *MailpitMessageDetail {
+	t.Helper()
+
+	detail, err := m.AwaitMessage(context.Background(), query, timeout)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return detail
+}
+
+// SentMessages returns the received messages, oldest first, for the mailpentest assertions
+func (m *Mailpit) SentMessages(t testing.TB) []mailpentest.Message {
+	t.Helper()
+
+	summaries := m.GetMessages(t)
+	messages := make([]mailpentest.Message, 0, len(summaries))
+	for i := len(summaries) - 1; i >= 0; i-- {
+		detail, err := m.Message(context.Background(), summaries[i].ID)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+
+		msg := mailpentest.Message{
+			From:    detail.From.Address,
+			To:      addresses(detail.To),
+			Cc:      addresses(detail.Cc),
+			Bcc:     addresses(detail.Bcc),
+			Subject: detail.Subject,
+			HTML:    detail.HTML,
+			Text:    detail.Text,
+		}
+		for _, att := range detail.Attachments {
+			msg.Attachments = append(msg.Attachments, mailpentest.Attachment{
+				Filename:    att.FileName,
+				ContentType: att.ContentType,
+				Size:        att.Size,
+			})
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages
+}
+
+// addresses returns the addresses of a Mailpit address list
+func addresses(list []EmailAddress) []string {
+	if len(list) == 0 {
+		return nil
+	}
+	out := make([]string, len(list))
+	for i, a := range list {
+		out[i] = a.Address
+	}
+	return out
+}
+
+// ClearMessages deletes all messages from Mailpit, failing the test on error
+func (m *Mailpit) ClearMessages(t testing.TB) {
+	t.Helper()
+
+	if err := m.DeleteMessages(context.Background()); err != nil {
+		t.Fatalf("%v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package testutil_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/mailpentest"
+	"github.com/patrickward/mailpen/providers/smtp"
+	"github.com/patrickward/mailpen/testutil"
+)
+
+var _ mailpentest.Mailbox = (*testutil.Mailpit)(nil)
+
+func TestSetupMailpit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping Mailpit container in short mode")
+	}
+	t.Parallel()
+
+	mailpit := testutil.SetupMailpit(t)
+
+	provider, err := smtp.New(mailpit.SMTPConfig())
+	require.NoError(t, err)
+
+	err = provider.Send(context.Background(), &mailpen.Message{
+		To:       []string{"user@example.com"},
+		From:     "sender@example.com",
+		Subject:  "Hello from a container",
+		TextBody: "Hi",
+	})
+	require.NoError(t, err)
+
+	messages := mailpit.GetMessages(t)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "Hello from a container", messages[0].Subject)
+	assert.Equal(t, "user@example.com", messages[0].To[0].Address)
+
+	detail := mailpit.WaitForMessage(t, "to:user@example.com", 10*time.Second)
+	assert.Equal(t, "Hi", detail.Text)
+
+	mailpentest.AssertSentTo(t, mailpit, "user@example.com")
+	mailpentest.AssertSubjectContains(t, mailpit, "container")
+	mailpentest.AssertAttachmentCount(t, mailpit, 0)
+
+	mailpit.ClearMessages(t)
+	assert.Empty(t, mailpit.GetMessages(t))
+}
+
+// fakeMailpit serves the Mailpit API endpoints used by the client. Searches return no messages until the
+// given number of searches has been made.
+func fakeMailpit(t *testing.T, emptySearches int32) (*testutil.Mailpit, *atomic.Int32) {
+	t.Helper()
+
+	var searches atomic.Int32
+	writeJSON := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(v)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/search", func(w http.ResponseWriter, r *http.Request) {
+		if searches.Add(1) <= emptySearches || r.URL.Query().Get("query") != "to:user@example.com" {
+			writeJSON(w, map[string]any{"messages": []any{}})
+			return
+		}
+		writeJSON(w, map[string]any{"messages": []any{map[string]any{"ID": "abc", "Subject": "Welcome"}}})
+	})
+	mux.HandleFunc("GET /api/v1/message/abc", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"ID":      "abc",
+			"Subject": "Welcome",
+			"To":      []any{map[string]any{"Address": "user@example.com"}},
+			"HTML":    "<p>Hi</p>",
+			"Text":    "Hi",
+			"Date":    "2024-03-01T10:00:00Z",
+			"Attachments": []any{
+				map[string]any{"PartID": "2", "FileName": "invoice.pdf", "ContentType": "application/pdf", "Size": 7},
+			},
+			"Inline": []any{
+				map[string]any{"PartID": "3", "FileName": "logo.png", "ContentType": "image/png", "ContentID": "logo"},
+			},
+		})
+	})
+	mux.HandleFunc("GET /api/v1/message/abc/headers", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string][]string{"List-Unsubscribe": {"<https://example.com/unsubscribe>"}})
+	})
+	mux.HandleFunc("GET /api/v1/message/abc/part/2", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("%PDF-1."))
+	})
+	mux.HandleFunc("GET /api/v1/message/abc/raw", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("Subject: Welcome\r\n\r\nHi"))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return &testutil.Mailpit{APIURL: server.URL}, &searches
+}
+
+func TestMailpit_Message(t *testing.T) {
+	mailpit, _ := fakeMailpit(t, 0)
+	ctx := context.Background()
+
+	detail, err := mailpit.Message(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "<p>Hi</p>", detail.HTML)
+	assert.Equal(t, "Hi", detail.Text)
+	assert.Equal(t, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), detail.Date)
+
+	att, ok := detail.Attachment("invoice.pdf")
+	require.True(t, ok)
+	assert.Equal(t, "application/pdf", att.ContentType)
+	inline, ok := detail.Attachment("logo.png")
+	require.True(t, ok)
+	assert.Equal(t, "logo", inline.ContentID)
+	_, ok = detail.Attachment("missing.txt")
+	assert.False(t, ok)
+
+	content, err := mailpit.AttachmentContent(ctx, "abc", att.PartID)
+	require.NoError(t, err)
+	assert.Equal(t, "%PDF-1.", string(content))
+
+	headers, err := mailpit.Headers(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "<https://example.com/unsubscribe>", headers.Get("List-Unsubscribe"))
+
+	raw, err := mailpit.Raw(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "Subject: Welcome\r\n\r\nHi", string(raw))
+
+	_, err = mailpit.Message(ctx, "missing")
+	assert.EqualError(t, err, "failed to get Mailpit message, status: 404")
+}
+
+func TestMailpit_Search(t *testing.T) {
+	mailpit, _ := fakeMailpit(t, 0)
+
+	messages := mailpit.SearchMessages(t, "to:user@example.com")
+	require.Len(t, messages, 1)
+	assert.Equal(t, "abc", messages[0].ID)
+
+	assert.Empty(t, mailpit.SearchMessages(t, "to:other@example.com"))
+}
+
+func TestMailpit_AwaitMessage(t *testing.T) {
+	mailpit, searches := fakeMailpit(t, 2)
+
+	detail := mailpit.WaitForMessage(t, "to:user@example.com", 5*time.Second)
+	assert.Equal(t, "Welcome", detail.Subject)
+	assert.Equal(t, int32(3), searches.Load())
+
+	_, err := mailpit.AwaitMessage(context.Background(), "to:other@example.com", 250*time.Millisecond)
+	assert.EqualError(t, err, `no Mailpit message matching "to:other@example.com" received within 250ms`)
+}
@@ -0,0 +1,284 @@
+package testutil
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/providers/smtp"
+)
+
+const (
+	SpamAssassinImage = "instantlinux/spamassassin:latest"
+	SpamAssassinPort  = "783/tcp"
+
+	// SpamdAddrEnv names an existing spamd server, such as "localhost:783", used by SetupSpamAssassin in
+	// place of a container
+	SpamdAddrEnv = "MAILPEN_SPAMD_ADDR"
+)
+
+// SpamRule is a SpamAssassin rule that matched a message
+type SpamRule struct {
+	Name        string
+	Score       float64
+	Description string
+}
+
+// SpamReport is the SpamAssassin verdict on a message
+type SpamReport struct {
+	Spam      bool
+	Score     float64
+	Threshold float64 // Score at which SpamAssassin considers a message spam
+	Rules     []SpamRule
+	Report    string // Full report text from spamd
+}
+
+// SpamAssassin is a spamd server, in a container or at an existing address
+type SpamAssassin struct {
+	Addr string // host:port of spamd
+
+	container testcontainers.Container
+}
+
+// StartSpamAssassin starts a SpamAssassin container and waits until spamd accepts connections. Call Terminate
+// to stop it. The first start can take a few minutes while the image updates its rules.
+func StartSpamAssassin(ctx context.Context) (*SpamAssassin, error) {
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        SpamAssassinImage,
+			ExposedPorts: []string{SpamAssassinPort},
+			WaitingFor:   wait.ForListeningPort(SpamAssassinPort).WithStartupTimeout(5 * time.Minute),
+		},
+		Started: true,
+	})
+	if err != nil {
+		if container != nil {
+			_ = container.Terminate(ctx)
+		}
+		return nil, fmt.Errorf("failed to start SpamAssassin container: %w", err)
+	}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		_ = container.Terminate(ctx)
+		return nil, err
+	}
+	port, err := container.MappedPort(ctx, SpamAssassinPort)
+	if err != nil {
+		_ = container.Terminate(ctx)
+		return nil, err
+	}
+
+	return &SpamAssassin{Addr: net.JoinHostPort(host, port.Port()), container: container}, nil
+}
+
+// Terminate stops and removes the container. It does nothing for an existing spamd server.
+func (s *SpamAssassin) Terminate(ctx context.Context) error {
+	if s.container == nil {
+		return nil
+	}
+	return s.container.Terminate(ctx)
+}
+
+// Check scores a raw RFC 5322 message
+func (s *SpamAssassin) Check(ctx context.Context, eml []byte) (*SpamReport, error) {
+	return CheckSpam(ctx, s.Addr, eml)
+}
+
+// CheckMessage scores a message as it would be sent over SMTP
+func (s *SpamAssassin) CheckMessage(ctx context.Context, msg *mailpen.Message) (*SpamReport, error) {
+	eml, err := smtp.EML(msg)
+	if err != nil {
+		return nil, err
+	}
+	return s.Check(ctx, eml)
+}
+
+// SetupSpamAssassin returns the spamd server named by MAILPEN_SPAMD_ADDR, or starts a SpamAssassin container
+// for a test and removes it when the test ends. The test is skipped when neither is available.
+func SetupSpamAssassin(t *testing.T) *SpamAssassin {
+	t.Helper()
+
+	if addr := os.Getenv(SpamdAddrEnv); addr != "" {
+		return &SpamAssassin{Addr: addr}
+	}
+
+	skipWithoutDocker(t)
+
+	s, err := StartSpamAssassin(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to start SpamAssassin: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := s.Terminate(context.Background()); err != nil {
+			t.Errorf("Failed to cleanup SpamAssassin container: %v", err)
+		}
+	})
+
+	return s
+}
+
+// AssertSpamScoreBelow fails the test unless the message scores below max, listing the matched rules
+func (s *SpamAssassin) AssertSpamScoreBelow(t testing.TB, msg *mailpen.Message, max float64) *SpamReport {
+	t.Helper()
+
+	report, err := s.CheckMessage(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if report.Score >= max {
+		var rules strings.Builder
+		for _, rule := range report.Rules {
+			fmt.Fprintf(&rules, "\n  %5.1f %s: %s", rule.Score, rule.Name, rule.Description)
+		}
+		t.Errorf("spam score %.1f is not below %.1f; matched rules:%s", report.Score, max, rules.String())
+	}
+
+	return report
+}
+
+// spamdTimeout bounds a spamd request when the context has no deadline
+const spamdTimeout = 30 * time.Second
+
+// CheckSpam sends a raw message to the spamd server at addr with the spamc REPORT command and returns the
+// verdict
+func CheckSpam(ctx context.Context, addr string, eml []byte) (*SpamReport, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to spamd: %w", err)
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(spamdTimeout)
+	}
+	_ = conn.SetDeadline(deadline)
+
+	if _, err := fmt.Fprintf(conn, "REPORT SPAMC/1.5\r\nContent-length: %d\r\n\r\n", len(eml)); err != nil {
+		return nil, fmt.Errorf("failed to send message to spamd: %w", err)
+	}
+	if _, err := conn.Write(eml); err != nil {
+		return nil, fmt.Errorf("failed to send message to spamd: %w", err)
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		_ = tcp.CloseWrite()
+	}
+
+	return readSpamdResponse(bufio.NewReader(conn))
+}
+
+// readSpamdResponse parses a spamd response: a status line, headers including "Spam: True ; 6.1 / 5.0",
+// a blank line, and the report
+func readSpamdResponse(r *bufio.Reader) (*SpamReport, error) {
+	status, err := r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spamd response: %w", err)
+	}
+	fields := strings.Fields(status)
+	if len(fields) < 3 || !strings.HasPrefix(fields[0], "SPAMD/") {
+		return nil, fmt.Errorf("invalid spamd response %q", strings.TrimSpace(status))
+	}
+	if fields[1] != "0" {
+		return nil, fmt.Errorf("spamd error: %s", strings.Join(fields[1:], " "))
+	}
+
+	report := &SpamReport{}
+	scored := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("failed to read spamd response: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+
+		name, value, _ := strings.Cut(line, ":")
+		if !strings.EqualFold(name, "Spam") {
+			continue
+		}
+		if report.Spam, report.Score, report.Threshold, err = parseSpamHeader(value); err != nil {
+			return nil, err
+		}
+		scored = true
+	}
+	if !scored {
+		return nil, fmt.Errorf("spamd response has no Spam header")
+	}
+
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spamd report: %w", err)
+	}
+	report.Report = string(body)
+	report.Rules = parseSpamRules(report.Report)
+
+	return report, nil
+}
+
+// parseSpamHeader parses the value of a spamd Spam header, e.g. "True ; 6.1 / 5.0"
+func parseSpamHeader(value string) (bool, float64, float64, error) {
+	verdict, scores, ok := strings.Cut(value, ";")
+	score, threshold, ok2 := strings.Cut(scores, "/")
+	if !ok || !ok2 {
+		return false, 0, 0, fmt.Errorf("invalid spamd Spam header %q", value)
+	}
+
+	s, err := strconv.ParseFloat(strings.TrimSpace(score), 64)
+	if err != nil {
+		return false, 0, 0, fmt.Errorf("invalid spamd score %q", score)
+	}
+	th, err := strconv.ParseFloat(strings.TrimSpace(threshold), 64)
+	if err != nil {
+		return false, 0, 0, fmt.Errorf("invalid spamd threshold %q", threshold)
+	}
+
+	spam := strings.EqualFold(strings.TrimSpace(verdict), "true") || strings.EqualFold(strings.TrimSpace(verdict), "yes")
+	return spam, s, th, nil
+}
+
+// spamRuleLine matches a rule line of the report table, e.g. " 1.2 MISSING_HEADERS        Missing To: header"
+var spamRuleLine = regexp.MustCompile(`^\s*(-?\d+(?:\.\d+)?)\s+([A-Z0-9_]+)\s+(.*)$`)
+
+// parseSpamRules reads the matched rules from the table at the end of a report. Indented lines continue
+// the previous rule's description.
+func parseSpamRules(report string) []SpamRule {
+	var rules []SpamRule
+	inTable := false
+	for _, line := range strings.Split(report, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "----") {
+			inTable = true
+			continue
+		}
+		if !inTable || strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		if match := spamRuleLine.FindStringSubmatch(line); match != nil {
+			score, _ := strconv.ParseFloat(match[1], 64)
+			rules = append(rules, SpamRule{Name: match[2], Score: score, Description: strings.TrimSpace(match[3])})
+		} else if len(rules) > 0 {
+			last := &rules[len(rules)-1]
+			last.Description += " " + strings.TrimSpace(line)
+		}
+	}
+	return rules
+}
@@ -0,0 +1,160 @@
+package testutil_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/testutil"
+)
+
+const spamdReport = `Spam detection software, running on the system "spamd", has
+identified this incoming email as possible spam.
+
+Content analysis details:   (6.1 points, 5.0 required)
+
+ pts rule name              description
+---- ---------------------- --------------------------------------------------
+ 3.5 HTML_IMAGE_ONLY_08     BODY: HTML: images with 400-800 bytes of words
+ 2.6 MIME_HTML_ONLY         BODY: Message only has text/html MIME parts
+                            and nothing else
+-0.0 NO_RELAYS              Informational: message was not relayed via SMTP
+`
+
+// fakeSpamd serves a canned spamd response and records the request body
+func fakeSpamd(t *testing.T, response string) (string, <-chan string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	bodies := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		length := 0
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if line == "\r\n" {
+				break
+			}
+			if value, ok := strings.CutPrefix(line, "Content-length: "); ok {
+				length, _ = strconv.Atoi(strings.TrimSpace(value))
+			}
+		}
+		body := make([]byte, length)
+		_, _ = io.ReadFull(r, body)
+		bodies <- string(body)
+
+		_, _ = io.WriteString(conn, response)
+	}()
+
+	return listener.Addr().String(), bodies
+}
+
+func TestCheckSpam(t *testing.T) {
+	addr, bodies := fakeSpamd(t, "SPAMD/1.1 0 EX_OK\r\nContent-length: 100\r\nSpam: True ; 6.1 / 5.0\r\n\r\n"+spamdReport)
+
+	report, err := testutil.CheckSpam(context.Background(), addr, []byte("Subject: hi\r\n\r\nbody"))
+	require.NoError(t, err)
+	assert.Equal(t, "Subject: hi\r\n\r\nbody", <-bodies)
+
+	assert.True(t, report.Spam)
+	assert.Equal(t, 6.1, report.Score)
+	assert.Equal(t, 5.0, report.Threshold)
+	assert.Equal(t, []testutil.SpamRule{
+		{Name: "HTML_IMAGE_ONLY_08", Score: 3.5, Description: "BODY: HTML: images with 400-800 bytes of words"},
+		{Name: "MIME_HTML_ONLY", Score: 2.6, Description: "BODY: Message only has text/html MIME parts and nothing else"},
+		{Name: "NO_RELAYS", Score: 0, Description: "Informational: message was not relayed via SMTP"},
+	}, report.Rules)
+}
+
+func TestCheckSpam_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		wantErr  string
+	}{
+		{name: "spamd error", response: "SPAMD/1.0 76 Bad header line\r\n", wantErr: "spamd error: 76 Bad header line"},
+		{name: "not spamd", response: "HTTP/1.1 400 Bad Request\r\n", wantErr: "invalid spamd response"},
+		{name: "no score", response: "SPAMD/1.1 0 EX_OK\r\n\r\n", wantErr: "no Spam header"},
+		{name: "bad score", response: "SPAMD/1.1 0 EX_OK\r\nSpam: False ; high / 5.0\r\n\r\n", wantErr: `invalid spamd score`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, _ := fakeSpamd(t, tt.response)
+			_, err := testutil.CheckSpam(context.Background(), addr, []byte("Subject: hi\r\n\r\nbody"))
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+// recordingT records test failures
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertSpamScoreBelow(t *testing.T) {
+	msg := &mailpen.Message{
+		From:     "sender@example.com",
+		To:       []string{"recipient@example.com"},
+		Subject:  "Welcome",
+		HTMLBody: "<p>Hello</p>",
+	}
+
+	addr, bodies := fakeSpamd(t, "SPAMD/1.1 0 EX_OK\r\nSpam: True ; 6.1 / 5.0\r\n\r\n"+spamdReport)
+	rt := &recordingT{TB: t}
+	report := (&testutil.SpamAssassin{Addr: addr}).AssertSpamScoreBelow(rt, msg, 5)
+	assert.Equal(t, 6.1, report.Score)
+	assert.Contains(t, <-bodies, "Subject: Welcome")
+	require.Len(t, rt.errors, 1)
+	assert.Contains(t, rt.errors[0], "spam score 6.1 is not below 5.0")
+	assert.Contains(t, rt.errors[0], "3.5 HTML_IMAGE_ONLY_08: BODY: HTML: images with 400-800 bytes of words")
+
+	addr, _ = fakeSpamd(t, "SPAMD/1.1 0 EX_OK\r\nSpam: False ; 0.4 / 5.0\r\n\r\n")
+	rt = &recordingT{TB: t}
+	(&testutil.SpamAssassin{Addr: addr}).AssertSpamScoreBelow(rt, msg, 5)
+	assert.Empty(t, rt.errors)
+}
+
+func TestSetupSpamAssassin(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping SpamAssassin container in short mode")
+	}
+
+	spamd := testutil.SetupSpamAssassin(t)
+	report := spamd.AssertSpamScoreBelow(t, &mailpen.Message{
+		From:     "sender@example.com",
+		To:       []string{"recipient@example.com"},
+		Subject:  "Your account is ready",
+		TextBody: "Hello, your account is ready. Sign in at https://example.com to get started.",
+		HTMLBody: `<p>Hello, your account is ready. <a href="https://example.com">Sign in</a> to get started.</p>`,
+	}, 5)
+	assert.Positive(t, report.Threshold)
+}
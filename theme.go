@@ -1,6 +1,12 @@
 package mailpen
 
-import "strings"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
 
 // DefaultTheme returns a theme map that works with built-in templates
 func DefaultTheme() map[string]any {
@@ -11,6 +17,7 @@ func DefaultTheme() map[string]any {
 			"success":   "#4caf50", // From button success
 			"danger":    "#f44336", // From button danger
 			"warning":   "#ffa500", // From button warning/default
+			"info":      "#2196f3", // Informational badges
 			"text": map[string]any{
 				"primary":   "#333333", // Dark text for main content
 				"secondary": "#666666", // Used in cards and less prominent text
@@ -71,6 +78,11 @@ func DefaultTheme() map[string]any {
 					"y": "12px",
 				},
 				"textTransform": "uppercase",
+				"vml": map[string]any{
+					"width":   "220px",
+					"height":  "44px",
+					"arcsize": "10%",
+				},
 			},
 			"card": map[string]any{
 				"padding": "20px",
@@ -89,6 +101,51 @@ func DefaultTheme() map[string]any {
 				"maxWidth": "200px",
 				"padding":  "30px",
 			},
+			"badge": map[string]any{
+				"padding":  "2px 8px",
+				"radius":   "10px",
+				"fontSize": "12px",
+			},
+			"rating": map[string]any{
+				"star":     "★",
+				"starSize": "32px",
+				"npsSize":  "36px",
+			},
+			"gallery": map[string]any{
+				"gap": "10px",
+			},
+			"stat": map[string]any{
+				"valueSize": "28px",
+				"padding":   "15px",
+			},
+			"list": map[string]any{
+				"bullet":    "•",
+				"checked":   "✓",
+				"unchecked": "○",
+			},
+			"avatar": map[string]any{
+				"size":     "48",
+				"fontSize": "18px",
+			},
+			"social": map[string]any{
+				"iconSize": "32",
+				"spacing":  "8px",
+			},
+			"progress": map[string]any{
+				"height":     "10px",
+				"trackColor": "#eeeeee",
+			},
+			"hero": map[string]any{
+				"padding":   "40px 20px",
+				"textColor": "#ffffff",
+				"headline": map[string]any{
+					"fontSize":   "32px",
+					"lineHeight": "40px",
+				},
+				"button": map[string]any{
+					"background": "#ffffff",
+				},
+			},
 		},
 		"layout": map[string]any{
 			"maxWidth": "600px",
@@ -97,6 +154,31 @@ func DefaultTheme() map[string]any {
 	}
 }
 
+// ErrUnknownThemePath is returned by strict theme lookups when a path does not exist in the theme
+var ErrUnknownThemePath = errors.New("unknown theme path")
+
+// ThemeFile identifies a JSON theme file within a file system
+type ThemeFile struct {
+	FS   fs.FS  // File system containing the theme file
+	Path string // Path to the theme file within FS
+}
+
+// LoadTheme reads a JSON theme file. The result contains only the tokens defined in the file and is
+// typically merged over DefaultTheme with MergeTheme.
+func LoadTheme(fsys fs.FS, path string) (map[string]any, error) {
+	content, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read theme file %s: %w", path, err)
+	}
+
+	var theme map[string]any
+	if err := json.Unmarshal(content, &theme); err != nil {
+		return nil, fmt.Errorf("failed to parse theme file %s: %w", path, err)
+	}
+
+	return theme, nil
+}
+
 // GetThemeValue safely traverses a theme map using dot notation
 func GetThemeValue(theme map[string]any, path string) any {
 	if path == "" {
@@ -120,3 +202,116 @@ func GetThemeValue(theme map[string]any, path string) any {
 
 	return nil
 }
+
+// LookupThemeValue traverses a theme map using dot notation like GetThemeValue, but returns
+// ErrUnknownThemePath instead of nil when the path does not exist.
+func LookupThemeValue(theme map[string]any, path string) (any, error) {
+	value := GetThemeValue(theme, path)
+	if value == nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownThemePath, path)
+	}
+	return value, nil
+}
+
+// resolveThemeValue implements the theme template function. When the path does not exist, the optional fallback
+// is returned as written, so theme "colors.brand" "#000000" falls back to black. Without a fallback, a missing
+// path returns nil, or an error in strict mode.
+func resolveThemeValue(theme map[string]any, strict bool, path string, fallback ...any) (any, error) {
+	if len(fallback) > 1 {
+		return nil, fmt.Errorf("theme %q: expected at most one fallback, got %d", path, len(fallback))
+	}
+
+	if value := GetThemeValue(theme, path); value != nil {
+		return value, nil
+	}
+
+	if len(fallback) == 1 {
+		return fallback[0], nil
+	}
+
+	if strict {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownThemePath, path)
+	}
+
+	return nil, nil
+}
+
+// DarkColors returns the dark variants defined under the theme's "dark.colors" map, flattened into
+// dot-separated token paths relative to "colors" (e.g. "text.primary"). Tokens without a dark variant
+// are omitted, so the result is empty when the theme has no dark palette.
+func DarkColors(theme map[string]any) map[string]string {
+	tokens := make(map[string]string)
+
+	colors, ok := GetThemeValue(theme, "dark.colors").(map[string]any)
+	if !ok {
+		return tokens
+	}
+
+	flattenTokens("", colors, tokens)
+	return tokens
+}
+
+// DarkModeCSS generates a prefers-color-scheme media query for the theme's dark palette. Each dark
+// token produces color, background, and border utility classes (e.g. .mp-color-text-primary) that
+// override inlined light values. It returns an empty string when the theme has no dark palette.
+func DarkModeCSS(theme map[string]any) string {
+	tokens := DarkColors(theme)
+	if len(tokens) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("@media (prefers-color-scheme: dark) {\n")
+	for _, p := range sortedKeys(tokens) {
+		class := strings.ReplaceAll(p, ".", "-")
+		value := tokens[p]
+		fmt.Fprintf(&b, "  .mp-color-%s { color: %s !important; }\n", class, value)
+		fmt.Fprintf(&b, "  .mp-bg-%s { background-color: %s !important; }\n", class, value)
+		fmt.Fprintf(&b, "  .mp-border-%s { border-color: %s !important; }\n", class, value)
+	}
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// flattenTokens walks a nested token map and records every string leaf under its dot-separated path
+func flattenTokens(prefix string, m map[string]any, out map[string]string) {
+	for key, value := range m {
+		p := key
+		if prefix != "" {
+			p = prefix + "." + key
+		}
+
+		switch v := value.(type) {
+		case string:
+			out[p] = v
+		case map[string]any:
+			flattenTokens(p, v, out)
+		}
+	}
+}
+
+// MergeTheme returns a copy of base with overrides applied recursively. Nested maps are merged key by key,
+// so an override only needs to contain the tokens it changes. Neither input is modified.
+func MergeTheme(base, overrides map[string]any) map[string]any {
+	result := make(map[string]any, len(base))
+	for k, v := range base {
+		if nested, ok := v.(map[string]any); ok {
+			v = MergeTheme(nested, nil)
+		}
+		result[k] = v
+	}
+
+	for k, v := range overrides {
+		if nested, ok := v.(map[string]any); ok {
+			if existing, ok := result[k].(map[string]any); ok {
+				result[k] = MergeTheme(existing, nested)
+				continue
+			}
+			v = MergeTheme(nested, nil)
+		}
+		result[k] = v
+	}
+
+	return result
+}
@@ -0,0 +1,75 @@
+package mailpen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// cssResets contains the client resets emitted ahead of the theme utilities
+const cssResets = `body, table, td, a { -webkit-text-size-adjust: 100%; -ms-text-size-adjust: 100%; }
+table, td { mso-table-lspace: 0pt; mso-table-rspace: 0pt; }
+table { border-collapse: collapse !important; }
+img { -ms-interpolation-mode: bicubic; border: 0; height: auto; line-height: 100%; outline: none; text-decoration: none; }
+body { height: 100% !important; margin: 0 !important; padding: 0 !important; width: 100% !important; }
+a[x-apple-data-detectors] { color: inherit !important; font-size: inherit !important; font-family: inherit !important; font-weight: inherit !important; line-height: inherit !important; text-decoration: none !important; }
+`
+
+// ThemeCSS renders the theme into a stylesheet of client resets and class-based utilities. Hybrid templates
+// can use the classes (e.g. mp-color-primary, mp-text-sm, mp-p-4) in clients that support <style> blocks while
+// keeping inlined styles as the fallback elsewhere. The dark palette block from DarkModeCSS is appended when
+// the theme defines one.
+func ThemeCSS(theme map[string]any) string {
+	var b strings.Builder
+	b.WriteString(cssResets)
+
+	if colors, ok := GetThemeValue(theme, "colors").(map[string]any); ok {
+		tokens := make(map[string]string)
+		flattenTokens("", colors, tokens)
+		for _, p := range sortedKeys(tokens) {
+			class := strings.ReplaceAll(p, ".", "-")
+			fmt.Fprintf(&b, ".mp-color-%s { color: %s; }\n", class, tokens[p])
+			fmt.Fprintf(&b, ".mp-bg-%s { background-color: %s; }\n", class, tokens[p])
+			fmt.Fprintf(&b, ".mp-border-%s { border-color: %s; }\n", class, tokens[p])
+		}
+	}
+
+	writeUtilities(&b, theme, "typography.font.size", ".mp-text-%s { font-size: %s; }\n")
+	writeUtilities(&b, theme, "typography.font.weight", ".mp-font-%s { font-weight: %s; }\n")
+	writeUtilities(&b, theme, "typography.font.lineHeight", ".mp-leading-%s { line-height: %s; }\n")
+	writeUtilities(&b, theme, "spacing", ".mp-p-%s { padding: %s; }\n")
+	writeUtilities(&b, theme, "spacing", ".mp-m-%s { margin: %s; }\n")
+	writeUtilities(&b, theme, "borders.radius", ".mp-rounded-%s { border-radius: %s; }\n")
+
+	for _, align := range []string{"left", "center", "right"} {
+		fmt.Fprintf(&b, ".mp-text-%s { text-align: %s; }\n", align, align)
+	}
+
+	b.WriteString(DarkModeCSS(theme))
+
+	return b.String()
+}
+
+// writeUtilities writes one rule per token found under the given theme path using the provided format
+func writeUtilities(b *strings.Builder, theme map[string]any, path, format string) {
+	group, ok := GetThemeValue(theme, path).(map[string]any)
+	if !ok {
+		return
+	}
+
+	tokens := make(map[string]string)
+	flattenTokens("", group, tokens)
+	for _, p := range sortedKeys(tokens) {
+		fmt.Fprintf(b, format, strings.ReplaceAll(p, ".", "-"), tokens[p])
+	}
+}
+
+// sortedKeys returns the keys of a token map in sorted order so generated CSS is deterministic
+func sortedKeys(tokens map[string]string) []string {
+	keys := make([]string, 0, len(tokens))
+	for k := range tokens {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
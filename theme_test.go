@@ -0,0 +1,236 @@
+package mailpen_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+func TestDarkModeCSS(t *testing.T) {
+	t.Run("no dark palette", func(t *testing.T) {
+		assert.Empty(t, mailpen.DarkColors(mailpen.DefaultTheme()))
+		assert.Empty(t, mailpen.DarkModeCSS(mailpen.DefaultTheme()))
+	})
+
+	t.Run("dark palette generates utility classes", func(t *testing.T) {
+		theme := mailpen.DefaultTheme()
+		theme["dark"] = map[string]any{
+			"colors": map[string]any{
+				"primary": "#6FCF6A",
+				"text": map[string]any{
+					"primary": "#eeeeee",
+				},
+			},
+		}
+
+		assert.Equal(t, map[string]string{
+			"primary":      "#6FCF6A",
+			"text.primary": "#eeeeee",
+		}, mailpen.DarkColors(theme))
+
+		css := mailpen.DarkModeCSS(theme)
+		assert.Contains(t, css, "@media (prefers-color-scheme: dark)")
+		assert.Contains(t, css, ".mp-color-primary { color: #6FCF6A !important; }")
+		assert.Contains(t, css, ".mp-bg-text-primary { background-color: #eeeeee !important; }")
+		assert.Contains(t, css, ".mp-border-text-primary { border-color: #eeeeee !important; }")
+	})
+
+	t.Run("built-in layout includes dark mode block", func(t *testing.T) {
+		theme := mailpen.DefaultTheme()
+		theme["dark"] = map[string]any{
+			"colors": map[string]any{
+				"background": map[string]any{
+					"primary": "#121212",
+				},
+			},
+		}
+
+		manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+			Theme:   theme,
+			Sources: []mailpen.TemplateSource{{Name: "default", FS: testFS(t, "default")}},
+		})
+		require.NoError(t, err)
+
+		email, err := manager.RenderEmail("simple", nil, "")
+		require.NoError(t, err)
+		assert.Contains(t, email.HTML, `<meta name="color-scheme" content="light dark"/>`)
+		assert.Contains(t, email.HTML, ".mp-bg-background-primary { background-color: #121212 !important; }")
+		assert.Contains(t, email.HTML, `class="mp-bg-background-primary mp-border-border"`)
+	})
+}
+
+func TestThemeCSS(t *testing.T) {
+	css := mailpen.ThemeCSS(mailpen.DefaultTheme())
+
+	assert.Contains(t, css, "table, td { mso-table-lspace: 0pt; mso-table-rspace: 0pt; }")
+	assert.Contains(t, css, ".mp-color-primary { color: #4DA647; }")
+	assert.Contains(t, css, ".mp-bg-background-secondary { background-color: #f8f8f8; }")
+	assert.Contains(t, css, ".mp-text-sm { font-size: 14px; }")
+	assert.Contains(t, css, ".mp-font-bold { font-weight: 700; }")
+	assert.Contains(t, css, ".mp-p-4 { padding: 20px; }")
+	assert.Contains(t, css, ".mp-rounded-md { border-radius: 4px; }")
+	assert.Contains(t, css, ".mp-text-center { text-align: center; }")
+	assert.NotContains(t, css, "prefers-color-scheme")
+
+	t.Run("rendered into the built-in layout head", func(t *testing.T) {
+		manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+			Sources: []mailpen.TemplateSource{{Name: "default", FS: testFS(t, "default")}},
+		})
+		require.NoError(t, err)
+
+		email, err := manager.RenderEmail("simple", nil, "")
+		require.NoError(t, err)
+		assert.Contains(t, email.HTML, `<style type="text/css">`)
+		assert.Contains(t, email.HTML, ".mp-color-primary { color: #4DA647; }")
+		assert.NotContains(t, email.HTML, `name="color-scheme"`)
+	})
+}
+
+func TestManager_ThemeHotReload(t *testing.T) {
+	themeFS := fstest.MapFS{
+		"theme.json": {Data: []byte(`{"colors": {"primary": "#111111"}}`)},
+	}
+	templateFS := fstest.MapFS{
+		"emails/themed.html": {Data: []byte(`{{define "content"}}<p style="color: {{theme "colors.primary"}};">v1</p>{{end}}`)},
+	}
+
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		ThemeFile: &mailpen.ThemeFile{FS: themeFS, Path: "theme.json"},
+		Sources:   []mailpen.TemplateSource{{Name: "dev", FS: templateFS}},
+		DevMode:   true,
+	})
+	require.NoError(t, err)
+
+	email, err := manager.RenderEmail("themed", nil, "")
+	require.NoError(t, err)
+	assert.Contains(t, email.HTML, `color: #111111;">v1`)
+	assert.Equal(t, "#666666", mailpen.GetThemeValue(manager.Theme(), "colors.text.secondary"), "file is merged over the default theme")
+
+	themeFS["theme.json"] = &fstest.MapFile{Data: []byte(`{"colors": {"primary": "#222222"}}`), ModTime: time.Now()}
+	templateFS["emails/themed.html"] = &fstest.MapFile{Data: []byte(`{{define "content"}}<p style="color: {{theme "colors.primary"}};">v2</p>{{end}}`)}
+
+	email, err = manager.RenderEmail("themed", nil, "")
+	require.NoError(t, err)
+	assert.Contains(t, email.HTML, `color: #222222;">v2`)
+
+	t.Run("invalid theme file is rendered into the email", func(t *testing.T) {
+		themeFS["theme.json"] = &fstest.MapFile{Data: []byte(`{`), ModTime: time.Now()}
+		email, err := manager.RenderEmail("themed", nil, "")
+		require.ErrorContains(t, err, "failed to parse theme file theme.json")
+		require.NotNil(t, email)
+		assert.Contains(t, email.HTML, "failed to parse theme file theme.json")
+		require.Len(t, email.Warnings, 1)
+		assert.Contains(t, email.Warnings[0], "failed to parse theme file theme.json")
+	})
+}
+
+// readCountingFS counts the files read from a map file system
+type readCountingFS struct {
+	fstest.MapFS
+	reads atomic.Int32
+}
+
+func (f *readCountingFS) ReadFile(name string) ([]byte, error) {
+	f.reads.Add(1)
+	return f.MapFS.ReadFile(name)
+}
+
+func TestManager_ThemeFileReadOnChange(t *testing.T) {
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	themeFS := &readCountingFS{MapFS: fstest.MapFS{
+		"theme.json": {Data: []byte(`{"colors": {"primary": "#111111"}}`), ModTime: modTime},
+	}}
+	templateFS := fstest.MapFS{
+		"emails/themed.html": {Data: []byte(`{{define "content"}}<p style="color: {{theme "colors.primary"}};">ok</p>{{end}}`)},
+	}
+
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		ThemeFile: &mailpen.ThemeFile{FS: themeFS, Path: "theme.json"},
+		Sources:   []mailpen.TemplateSource{{Name: "dev", FS: templateFS}},
+		DevMode:   true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), themeFS.reads.Load())
+
+	for range 3 {
+		email, err := manager.RenderEmail("themed", nil, "")
+		require.NoError(t, err)
+		assert.Contains(t, email.HTML, "color: #111111;")
+	}
+	assert.Equal(t, int32(1), themeFS.reads.Load(), "unchanged file is not read again")
+
+	themeFS.MapFS["theme.json"] = &fstest.MapFile{Data: []byte(`{"colors": {"primary": "#222222"}}`), ModTime: modTime.Add(time.Second)}
+	email, err := manager.RenderEmail("themed", nil, "")
+	require.NoError(t, err)
+	assert.Contains(t, email.HTML, "color: #222222;")
+	assert.Equal(t, int32(2), themeFS.reads.Load())
+
+	require.NoError(t, manager.Reload())
+	assert.Equal(t, int32(3), themeFS.reads.Load(), "Reload always reads the file")
+}
+
+func TestThemeLookups(t *testing.T) {
+	templateFS := fstest.MapFS{
+		"emails/fallback.html": {Data: []byte(`{{define "content"}}<p style="color: {{theme "colors.primary" "#000000"}}; border-color: {{theme "colors.brand" "#000000"}};">ok</p>{{end}}`)},
+		"emails/literal.html":  {Data: []byte(`{{define "content"}}<p title="{{theme "colors.brand" "colors.primary"}}">ok</p>{{end}}`)},
+		"emails/chain.html":    {Data: []byte(`{{define "content"}}<p style="color: {{theme "colors.brand" "colors.primary" "#000000"}};">ok</p>{{end}}`)},
+		"emails/typo.html":     {Data: []byte(`{{define "content"}}<p style="color: {{theme "colors.primray"}};">typo</p>{{end}}`)},
+	}
+
+	t.Run("lookup errors for unknown paths", func(t *testing.T) {
+		value, err := mailpen.LookupThemeValue(mailpen.DefaultTheme(), "colors.primary")
+		require.NoError(t, err)
+		assert.Equal(t, "#4DA647", value)
+
+		_, err = mailpen.LookupThemeValue(mailpen.DefaultTheme(), "colors.brand")
+		assert.ErrorIs(t, err, mailpen.ErrUnknownThemePath)
+	})
+
+	for _, strict := range []bool{false, true} {
+		manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+			Sources:     []mailpen.TemplateSource{{Name: "test", FS: templateFS}},
+			StrictTheme: strict,
+		})
+		require.NoError(t, err)
+
+		email, err := manager.RenderEmail("fallback", nil, "")
+		require.NoError(t, err)
+		assert.Contains(t, email.HTML, "color: #4DA647; border-color: #000000;")
+
+		email, err = manager.RenderEmail("literal", nil, "")
+		require.NoError(t, err)
+		assert.Contains(t, email.HTML, `title="colors.primary"`)
+
+		_, err = manager.RenderEmail("chain", nil, "")
+		assert.ErrorContains(t, err, "expected at most one fallback")
+
+		email, err = manager.RenderEmail("typo", nil, "")
+		if strict {
+			require.Error(t, err)
+			assert.ErrorIs(t, err, mailpen.ErrUnknownThemePath)
+			assert.Contains(t, err.Error(), `"colors.primray"`)
+		} else {
+			require.NoError(t, err)
+			assert.Contains(t, email.HTML, "typo")
+		}
+	}
+
+	t.Run("built-in components resolve in strict mode", func(t *testing.T) {
+		manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+			Sources:     []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+			StrictTheme: true,
+		})
+		require.NoError(t, err)
+
+		_, err = manager.RenderEmail("headers-test", map[string]any{"mainTitle": "Title"}, "")
+		require.NoError(t, err)
+		_, err = manager.RenderEmail("alert-test", map[string]any{"alertTitle": "Alert"}, "")
+		require.NoError(t, err)
+	})
+}
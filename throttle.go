@@ -0,0 +1,250 @@
+package mailpen
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AdaptiveThrottle slows sends to a recipient domain when the provider defers them, for example with an SMTP
+// 421 or 451 reply or an HTTP 429 response. Each deferral doubles the delay between sends to the domain, up
+// to MaxDelay, and pauses the domain for as long as the provider's retry-after hint asks. Each successful
+// send halves the delay until the domain is unthrottled again. It is safe for concurrent use.
+type AdaptiveThrottle struct {
+	minDelay time.Duration
+	maxDelay time.Duration
+
+	mu      sync.Mutex
+	domains map[string]*domainThrottle
+}
+
+// domainThrottle is the throttle state of one recipient domain
+type domainThrottle struct {
+	delay time.Duration // Minimum time between sends
+	next  time.Time     // Earliest time of the next send
+}
+
+// NewAdaptiveThrottle creates an adaptive throttle. minDelay is the delay applied after the first deferral
+// (defaults to 1s) and maxDelay caps the delay between sends to a domain (defaults to 5m).
+func NewAdaptiveThrottle(minDelay, maxDelay time.Duration) *AdaptiveThrottle {
+	if minDelay <= 0 {
+		minDelay = time.Second
+	}
+	if maxDelay <= 0 {
+		maxDelay = 5 * time.Minute
+	}
+
+	return &AdaptiveThrottle{
+		minDelay: minDelay,
+		maxDelay: max(minDelay, maxDelay),
+		domains:  make(map[string]*domainThrottle),
+	}
+}
+
+// WithAdaptiveThrottle sets the adaptive throttle applied before each provider send and updated with its
+// outcome. Use it to share a throttle between Mailpen instances.
+func WithAdaptiveThrottle(t *AdaptiveThrottle) Option {
+	return func(m *Mailpen) error {
+		m.adaptive = t
+		return nil
+	}
+}
+
+// AdaptiveThrottle returns the adaptive throttle, or nil if there is none
+func (m *Mailpen) AdaptiveThrottle() *AdaptiveThrottle {
+	return m.adaptive
+}
+
+// Wait blocks until the message may be sent to every throttled recipient domain. It returns the time waited,
+// or the context's error if ctx is done first.
+func (t *AdaptiveThrottle) Wait(ctx context.Context, msg *Message) (time.Duration, error) {
+	domains := recipientDomains(msg)
+
+	t.mu.Lock()
+	now := time.Now()
+	var until time.Time
+	for _, domain := range domains {
+		d, ok := t.domains[domain]
+		if !ok {
+			continue
+		}
+		if d.next.After(until) {
+			until = d.next
+		}
+	}
+	// Reserve the next slot so that concurrent sends are spaced out
+	start := maxTime(now, until)
+	for _, domain := range domains {
+		if d, ok := t.domains[domain]; ok {
+			d.next = start.Add(d.delay)
+		}
+	}
+	t.mu.Unlock()
+
+	wait := start.Sub(now)
+	if err := sleep(ctx, wait); err != nil {
+		return 0, err
+	}
+	return wait, nil
+}
+
+// Observe updates the throttle with the outcome of a send. Deferrals slow the message's recipient domains
+// down and successes speed them back up. Other errors are ignored.
+func (t *AdaptiveThrottle) Observe(msg *Message, err error) {
+	deferred := IsDeferral(err)
+	if err != nil && !deferred {
+		return
+	}
+	hint, _ := RetryAfterHint(err)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for _, domain := range recipientDomains(msg) {
+		d, ok := t.domains[domain]
+		if !deferred {
+			if !ok {
+				continue
+			}
+			if d.delay /= 2; d.delay < t.minDelay {
+				delete(t.domains, domain)
+			}
+			continue
+		}
+
+		if !ok {
+			d = &domainThrottle{}
+			t.domains[domain] = d
+		}
+		d.delay = min(max(d.delay*2, t.minDelay), t.maxDelay)
+		d.next = maxTime(d.next, now.Add(max(d.delay, hint)))
+	}
+}
+
+// Delays returns the current delay between sends for each throttled domain
+func (t *AdaptiveThrottle) Delays() map[string]time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delays := make(map[string]time.Duration, len(t.domains))
+	for domain, d := range t.domains {
+		delays[domain] = d.delay
+	}
+	return delays
+}
+
+// maxTime returns the later of two times
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// retryAfterError carries a provider's hint for when to try again
+type retryAfterError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *retryAfterError) Error() string             { return e.err.Error() }
+func (e *retryAfterError) Unwrap() error             { return e.err }
+func (e *retryAfterError) RetryAfter() time.Duration { return e.delay }
+
+// RetryAfter wraps a deferral error with the provider's hint for when to try again, such as an HTTP
+// Retry-After header parsed with ParseRetryAfter. The error is treated as a deferral.
+func RetryAfter(err error, delay time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &retryAfterError{err: err, delay: delay}
+}
+
+// retryAfterPattern matches retry hints in SMTP replies, such as "try again in 5 minutes", "retry after 60
+// seconds", or "Retry-After: 120"
+var retryAfterPattern = regexp.MustCompile(`(?i)(?:try again in|retry (?:again )?(?:after|in)|retry-after:)\s*(\d+)\s*(s|sec|secs|seconds?|m|min|mins|minutes?|h|hours?)?\b`)
+
+// RetryAfterHint returns how long the provider asked to wait before trying again. The hint comes from an
+// error wrapped with RetryAfter, or an error with a RetryAfter() time.Duration method, or is parsed from the
+// error text of an SMTP reply.
+func RetryAfterHint(err error) (time.Duration, bool) {
+	if err == nil {
+		return 0, false
+	}
+
+	var hinted interface{ RetryAfter() time.Duration }
+	if errors.As(err, &hinted) {
+		return hinted.RetryAfter(), true
+	}
+
+	match := retryAfterPattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0, false
+	}
+
+	n, convErr := strconv.Atoi(match[1])
+	if convErr != nil {
+		return 0, false
+	}
+
+	unit := time.Second
+	switch strings.ToLower(match[2]) {
+	case "m", "min", "mins", "minute", "minutes":
+		unit = time.Minute
+	case "h", "hour", "hours":
+		unit = time.Hour
+	}
+	return time.Duration(n) * unit, true
+}
+
+// ParseRetryAfter parses an HTTP Retry-After header value, given either in seconds or as an HTTP date
+func ParseRetryAfter(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+
+	return 0, false
+}
+
+// IsDeferral reports whether a send error means the provider is asking to slow down or try later: an error
+// wrapped with RetryAfter, an SMTP 4xx reply, a temporary SMTP send error, or an error reporting HTTP status
+// 429 with a StatusCode() int method
+func IsDeferral(err error) bool {
+	if err == nil || IsPermanent(err) {
+		return false
+	}
+
+	var hinted interface{ RetryAfter() time.Duration }
+	if errors.As(err, &hinted) {
+		return true
+	}
+
+	var reply *textproto.Error
+	if errors.As(err, &reply) {
+		return reply.Code >= 400 && reply.Code < 500
+	}
+
+	var status interface{ StatusCode() int }
+	if errors.As(err, &status) {
+		return status.StatusCode() == http.StatusTooManyRequests
+	}
+
+	var temp interface{ IsTemp() bool }
+	if errors.As(err, &temp) {
+		return temp.IsTemp()
+	}
+
+	return false
+}
@@ -0,0 +1,154 @@
+package mailpen_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+// statusError reports an HTTP status code like the errors of HTTP API providers
+type statusError struct{ code int }
+
+func (e statusError) Error() string   { return fmt.Sprintf("status %d", e.code) }
+func (e statusError) StatusCode() int { return e.code }
+
+func TestIsDeferral(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "plain error", err: errors.New("boom"), want: false},
+		{name: "smtp 421", err: fmt.Errorf("send: %w", &textproto.Error{Code: 421, Msg: "try later"}), want: true},
+		{name: "smtp 451", err: &textproto.Error{Code: 451, Msg: "rate limited"}, want: true},
+		{name: "smtp 550", err: &textproto.Error{Code: 550, Msg: "no such user"}, want: false},
+		{name: "http 429", err: statusError{code: 429}, want: true},
+		{name: "http 500", err: statusError{code: 500}, want: false},
+		{name: "retry after", err: mailpen.RetryAfter(errors.New("slow down"), time.Second), want: true},
+		{name: "permanent", err: mailpen.Permanent(&textproto.Error{Code: 421}), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, mailpen.IsDeferral(tt.err))
+		})
+	}
+}
+
+func TestRetryAfterHint(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "none", err: errors.New("451 temporary failure")},
+		{name: "wrapped", err: fmt.Errorf("send: %w", mailpen.RetryAfter(errors.New("429"), 90*time.Second)), want: 90 * time.Second, wantOK: true},
+		{name: "seconds", err: errors.New("421 4.7.0 Try again in 30 seconds"), want: 30 * time.Second, wantOK: true},
+		{name: "minutes", err: errors.New("451 Rate limited, retry after 5 minutes"), want: 5 * time.Minute, wantOK: true},
+		{name: "header", err: errors.New("Retry-After: 120"), want: 120 * time.Second, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := mailpen.RetryAfterHint(tt.err)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	d, ok := mailpen.ParseRetryAfter("120")
+	assert.True(t, ok)
+	assert.Equal(t, 120*time.Second, d)
+
+	d, ok = mailpen.ParseRetryAfter(time.Now().Add(time.Hour).UTC().Format("Mon, 02 Jan 2006 15:04:05 GMT"))
+	assert.True(t, ok)
+	assert.InDelta(t, time.Hour, d, float64(2*time.Second))
+
+	_, ok = mailpen.ParseRetryAfter("soon")
+	assert.False(t, ok)
+}
+
+func TestAdaptiveThrottle(t *testing.T) {
+	throttle := mailpen.NewAdaptiveThrottle(10*time.Millisecond, 40*time.Millisecond)
+	gmail := mailpen.NewMessage().To("a@gmail.com").Subject("Test").Must()
+	other := mailpen.NewMessage().To("b@example.com").Subject("Test").Must()
+	deferral := &textproto.Error{Code: 421, Msg: "too many messages"}
+
+	throttle.Observe(gmail, errors.New("connection reset"))
+	assert.Empty(t, throttle.Delays())
+
+	throttle.Observe(gmail, deferral)
+	assert.Equal(t, map[string]time.Duration{"gmail.com": 10 * time.Millisecond}, throttle.Delays())
+	for range 3 {
+		throttle.Observe(gmail, deferral)
+	}
+	assert.Equal(t, 40*time.Millisecond, throttle.Delays()["gmail.com"], "delay is capped")
+
+	waited, err := throttle.Wait(context.Background(), gmail)
+	require.NoError(t, err)
+	assert.Greater(t, waited, time.Duration(0))
+
+	waited, err = throttle.Wait(context.Background(), other)
+	require.NoError(t, err)
+	assert.Zero(t, waited, "other domains are not throttled")
+
+	for range 3 {
+		throttle.Observe(gmail, nil)
+	}
+	assert.Empty(t, throttle.Delays(), "successes recover the domain")
+}
+
+func TestAdaptiveThrottle_RetryAfterPausesDomain(t *testing.T) {
+	throttle := mailpen.NewAdaptiveThrottle(time.Millisecond, time.Second)
+	msg := mailpen.NewMessage().To("a@gmail.com").Subject("Test").Must()
+	throttle.Observe(msg, mailpen.RetryAfter(errors.New("429"), time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := throttle.Wait(ctx, msg)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// deferringProvider defers the first send with a retry-after hint and accepts the rest
+type deferringProvider struct {
+	mockProvider
+	hint time.Duration
+}
+
+func (p *deferringProvider) Send(ctx context.Context, msg *mailpen.Message) error {
+	if p.sendCalls++; p.sendCalls == 1 {
+		return mailpen.RetryAfter(errors.New("429 too many requests"), p.hint)
+	}
+	return nil
+}
+
+func TestMailpen_AdaptiveThrottle(t *testing.T) {
+	provider := &deferringProvider{hint: 30 * time.Millisecond}
+	throttle := mailpen.NewAdaptiveThrottle(5*time.Millisecond, time.Second)
+	mp, err := mailpen.New(provider, &mailpen.Config{From: "sender@example.com"},
+		mailpen.WithAdaptiveThrottle(throttle),
+		mailpen.WithRetryPolicy(mailpen.ExponentialRetry{InitialDelay: time.Millisecond}))
+	require.NoError(t, err)
+	assert.Same(t, throttle, mp.AdaptiveThrottle())
+
+	msg := mailpen.NewMessage().To("a@gmail.com").Subject("Test").Must()
+	msg.TextBody = "Hi"
+	start := time.Now()
+	require.NoError(t, mp.Send(context.Background(), msg))
+
+	assert.Equal(t, 2, provider.sendCalls)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond, "the retry honors the hint")
+	assert.Empty(t, throttle.Delays(), "the successful retry recovers the domain")
+}
@@ -0,0 +1,65 @@
+package mailpen
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// StageTiming is how long one stage of a render took
+type StageTiming struct {
+	Name     string // Stage name, e.g. the processor or analyzer type
+	Duration time.Duration
+}
+
+// RenderTimings reports how long each stage of a render took, so regressions in the template pipeline can
+// be measured in production
+type RenderTimings struct {
+	Template    string
+	Layout      string
+	Total       time.Duration // The whole render, including the stages below
+	Text        time.Duration // Executing the text template
+	HTML        time.Duration // Executing the HTML template
+	Processors  []StageTiming // Each HTML processor, in order
+	Analyzers   []StageTiming // Each HTML analyzer, in order
+	TextConvert time.Duration // Deriving the text version from the HTML, when there is no text template
+	Err         error         // The render error, if any
+}
+
+// RenderTimingFunc receives the timings of each render. It is called synchronously after the render, so it
+// should be fast, e.g. recording a metric.
+type RenderTimingFunc func(ctx context.Context, timings RenderTimings)
+
+// WithRenderTiming sets the function that receives the timings of each render. It cannot be combined with
+// WithManager; set ManagerConfig.RenderTiming on the shared manager instead.
+func WithRenderTiming(fn RenderTimingFunc) Option {
+	return func(m *Mailpen) error {
+		m.renderTiming = fn
+		return nil
+	}
+}
+
+// startTimings returns the timings of a render when a timing function is set, and a function that reports
+// them with the render error. Without a timing function, both are nil-safe no-ops.
+func (m *Manager) startTimings(ctx context.Context, name, layout string) (*RenderTimings, func(err error)) {
+	if m.renderTiming == nil {
+		return nil, func(error) {}
+	}
+
+	timings := &RenderTimings{Template: name, Layout: layout}
+	start := time.Now()
+	return timings, func(err error) {
+		timings.Total = time.Since(start)
+		timings.Err = err
+		m.renderTiming(ctx, *timings)
+	}
+}
+
+// stageName returns the name of a processor or analyzer for stage timings: its type, or the type of the
+// HTMLProcessor it adapts
+func stageName(stage any) string {
+	if adapted, ok := stage.(adaptedProcessor); ok {
+		stage = adapted.HTMLProcessor
+	}
+	return fmt.Sprintf("%T", stage)
+}
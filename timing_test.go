@@ -0,0 +1,90 @@
+package mailpen_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/processors/linkparams"
+	"github.com/patrickward/mailpen/processors/plaintext"
+	"github.com/patrickward/mailpen/templates"
+)
+
+func TestManager_RenderTiming(t *testing.T) {
+	var timings []mailpen.RenderTimings
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		Sources: []mailpen.TemplateSource{{Name: "test", FS: fstest.MapFS{
+			"layouts/base.html":   {Data: []byte(`<html><body>{{template "content" .}}</body></html>`)},
+			"emails/welcome.html": {Data: []byte(`{{define "content"}}<a href="https://example.com">Hi {{.Name}}</a>{{end}}`)},
+			"emails/broken.html":  {Data: []byte(`{{define "content"}}{{len 3}}{{end}}`)},
+		}}},
+		Processors:    []mailpen.ContextProcessor{mailpen.AdaptProcessor(linkparams.New(map[string]string{"utm_source": "email"}))},
+		TextConverter: plaintext.New(),
+		Analyzers: []mailpen.HTMLAnalyzer{analyzerFunc(func(html string) ([]string, error) {
+			return nil, nil
+		})},
+		RenderTiming: func(ctx context.Context, rt mailpen.RenderTimings) {
+			timings = append(timings, rt)
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = manager.RenderEmail("welcome", map[string]any{"Name": "Ada"}, "")
+	require.NoError(t, err)
+
+	require.Len(t, timings, 1)
+	rt := timings[0]
+	assert.Equal(t, "welcome", rt.Template)
+	assert.Equal(t, "base", rt.Layout)
+	assert.NoError(t, rt.Err)
+	assert.Positive(t, rt.Total)
+	assert.Positive(t, rt.HTML)
+	assert.Zero(t, rt.Text, "no text template")
+	assert.Positive(t, rt.TextConvert)
+
+	var names []string
+	for _, stage := range rt.Processors {
+		names = append(names, stage.Name)
+		assert.LessOrEqual(t, stage.Duration, rt.Total)
+	}
+	assert.Equal(t, []string{"*mailpen.DefaultProcessor", "*linkparams.Processor"}, names)
+	require.Len(t, rt.Analyzers, 1)
+	assert.Equal(t, "mailpen_test.analyzerFunc", rt.Analyzers[0].Name)
+
+	_, err = manager.RenderEmail("broken", nil, "")
+	require.Error(t, err)
+	require.Len(t, timings, 2)
+	assert.Equal(t, "broken", timings[1].Template)
+	assert.ErrorContains(t, timings[1].Err, "failed to render HTML template")
+
+	_, err = manager.Compose(context.Background(), templates.Layout{
+		Components: []templates.Component{templates.Paragraph{Text: "Hello"}},
+	}, nil, mailpen.RenderOptions{})
+	require.NoError(t, err)
+	require.Len(t, timings, 3)
+	assert.Equal(t, "composed", timings[2].Template)
+}
+
+func TestMailpen_WithRenderTiming(t *testing.T) {
+	var templatesRendered []string
+	mp, err := mailpen.New(&mockProvider{}, &mailpen.Config{
+		From:    "sender@example.com",
+		Sources: []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+	}, mailpen.WithRenderTiming(func(ctx context.Context, rt mailpen.RenderTimings) {
+		templatesRendered = append(templatesRendered, rt.Template+"/"+rt.Layout)
+	}))
+	require.NoError(t, err)
+
+	err = mp.Send(context.Background(), mailpen.NewMessage().
+		To("recipient@example.com").
+		Template("welcome").
+		WithData(map[string]any{"Name": "Ada"}).
+		Must())
+	require.NoError(t, err)
+	assert.Equal(t, "welcome/base", strings.Join(templatesRendered, ","))
+}
@@ -0,0 +1,37 @@
+package mailpen
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope name used for mailpen spans
+const tracerName = "github.com/patrickward/mailpen"
+
+// WithTracerProvider sets the OpenTelemetry tracer provider used for render, processor, and send spans. A
+// manager shared with WithManager keeps its own tracer provider for render and processor spans.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(m *Mailpen) error {
+		m.tracer = newTracer(tp)
+		return nil
+	}
+}
+
+// newTracer returns a tracer from the given provider, or from the global provider when tp is nil. The
+// global provider is a no-op until one is registered with otel.SetTracerProvider.
+func newTracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(tracerName)
+}
+
+// endSpan records an error, if any, and ends the span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
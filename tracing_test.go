@@ -0,0 +1,99 @@
+package mailpen_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/patrickward/mailpen"
+)
+
+func TestMailpen_Tracing(t *testing.T) {
+	tests := []struct {
+		name      string
+		sendErr   error
+		wantSpans []string
+		wantError bool
+	}{
+		{
+			name:      "successful send",
+			wantSpans: []string{"mailpen.process", "mailpen.render", "mailpen.provider.send", "mailpen.send"},
+		},
+		{
+			name:      "failed send",
+			sendErr:   errors.New("send failed"),
+			wantSpans: []string{"mailpen.process", "mailpen.render", "mailpen.provider.send", "mailpen.send"},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+			mp, err := mailpen.New(&mockProvider{err: tt.sendErr}, &mailpen.Config{
+				From:    "sender@example.com",
+				Sources: []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+				Processors: []mailpen.ContextProcessor{
+					mailpen.AdaptProcessor(&mailpen.DefaultProcessor{}),
+				},
+			}, mailpen.WithTracerProvider(tp))
+			require.NoError(t, err)
+
+			ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+			msg := mailpen.NewMessage().
+				To("recipient@example.com", "other@example.com").
+				Template("welcome").
+				WithData(map[string]any{"Name": "John"}).
+				Must()
+			err = mp.Send(ctx, msg)
+			parent.End()
+
+			if tt.wantError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			spans := recorder.Ended()
+			var names []string
+			byName := map[string]sdktrace.ReadOnlySpan{}
+			for _, span := range spans {
+				if span.Name() == "request" {
+					continue
+				}
+				names = append(names, span.Name())
+				byName[span.Name()] = span
+			}
+			assert.Equal(t, tt.wantSpans, names)
+
+			send := byName["mailpen.send"]
+			require.NotNil(t, send)
+			assert.Equal(t, parent.SpanContext().SpanID(), send.Parent().SpanID())
+			assert.Contains(t, send.Attributes(), attribute.String("mailpen.template", "welcome"))
+			assert.Contains(t, send.Attributes(), attribute.String("mailpen.provider", "mock"))
+			assert.Contains(t, send.Attributes(), attribute.Int("mailpen.recipients", 2))
+
+			provider := byName["mailpen.provider.send"]
+			assert.Equal(t, trace.SpanKindClient, provider.SpanKind())
+			assert.Equal(t, send.SpanContext().SpanID(), provider.Parent().SpanID())
+			assert.Equal(t, send.SpanContext().SpanID(), byName["mailpen.render"].Parent().SpanID())
+
+			if tt.wantError {
+				assert.Equal(t, codes.Error, send.Status().Code)
+				assert.Equal(t, codes.Error, provider.Status().Code)
+			} else {
+				assert.Equal(t, codes.Unset, send.Status().Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package mailpen
+
+import (
+	"context"
+	"reflect"
+)
+
+// Email is an email template bound to the type of its data, so a template's data is checked at compile time
+// instead of being assembled into a map by hand:
+//
+//	type WelcomeData struct{ Name string }
+//	var WelcomeEmail = mailpen.Define[WelcomeData]("welcome", "base")
+//
+//	err := mailpen.SendTyped(ctx, mp, WelcomeEmail, WelcomeData{Name: "Jane"}, "jane@example.com")
+type Email[T any] struct {
+	Template string              // Email template name
+	Layout   string              // Layout name (empty uses the default layout)
+	Subject  func(data T) string // Builds the subject from the data (optional)
+}
+
+// Define defines an email whose template renders data of type T
+func Define[T any](template, layout string) Email[T] {
+	return Email[T]{Template: template, Layout: layout}
+}
+
+// WithSubject returns a copy of the email that builds its subject from the data
+func (e Email[T]) WithSubject(fn func(data T) string) Email[T] {
+	e.Subject = fn
+	return e
+}
+
+// Message returns a message builder for the email with its template, layout, data, and subject set. The
+// exported fields of a struct become the template's top-level fields, so .Name refers to the Name field.
+func (e Email[T]) Message(data T) *Builder {
+	b := NewMessage().Template(e.Template).WithData(typedData(data))
+	if e.Layout != "" {
+		b.Layout(e.Layout)
+	}
+	if e.Subject != nil {
+		b.Subject(e.Subject(data))
+	}
+	return b
+}
+
+// SendTyped sends a typed email with data to the given recipients. Use Email.Message to set other message
+// fields before sending.
+func SendTyped[T any](ctx context.Context, mp *Mailpen, email Email[T], data T, to ...string) error {
+	msg, err := email.Message(data).To(to...).Build()
+	if err != nil {
+		return err
+	}
+	return mp.Send(ctx, msg)
+}
+
+// typedData converts typed email data to a template data map. Structs contribute their exported fields, with
+// embedded structs flattened, and maps with string keys are copied. Any other value is available as .Data.
+func typedData(data any) map[string]any {
+	v := indirect(reflect.ValueOf(data))
+	if !v.IsValid() {
+		return map[string]any{}
+	}
+
+	switch {
+	case v.Kind() == reflect.Struct:
+		out := make(map[string]any)
+		addStructFields(out, v)
+		return out
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		out := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[iter.Key().String()] = iter.Value().Interface()
+		}
+		return out
+	default:
+		return map[string]any{"Data": data}
+	}
+}
+
+// addStructFields adds the exported fields of a struct value to a map, flattening embedded structs. As in Go,
+// fields of the outer struct take precedence over promoted fields.
+func addStructFields(out map[string]any, v reflect.Value) {
+	t := v.Type()
+	var embedded []reflect.Value
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			if ev := indirect(v.Field(i)); ev.Kind() == reflect.Struct {
+				embedded = append(embedded, ev)
+				continue
+			}
+		}
+		if f.IsExported() {
+			out[f.Name] = v.Field(i).Interface()
+		}
+	}
+
+	for _, ev := range embedded {
+		promoted := make(map[string]any)
+		addStructFields(promoted, ev)
+		for k, val := range promoted {
+			if _, ok := out[k]; !ok {
+				out[k] = val
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package mailpen_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+type accountData struct {
+	Plan string
+	Name string
+}
+
+type welcomeData struct {
+	accountData
+	Name   string
+	secret string
+}
+
+func TestEmail_Message(t *testing.T) {
+	email := mailpen.Define[welcomeData]("welcome", "marketing").
+		WithSubject(func(d welcomeData) string { return "Welcome, " + d.Name })
+
+	msg := email.Message(welcomeData{Name: "Jane", accountData: accountData{Plan: "pro", Name: "ignored"}, secret: "x"}).
+		To("jane@example.com").Must()
+
+	assert.Equal(t, "welcome", msg.Template)
+	assert.Equal(t, "marketing", msg.Layout)
+	assert.Equal(t, "Welcome, Jane", msg.Subject)
+	assert.Equal(t, map[string]any{"Name": "Jane", "Plan": "pro"}, msg.Data)
+}
+
+func TestEmail_MessageData(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  *mailpen.Message
+		want map[string]any
+	}{
+		{
+			name: "pointer to struct",
+			msg:  mailpen.Define[*welcomeData]("welcome", "").Message(&welcomeData{Name: "Jane"}).To("a@example.com").Must(),
+			want: map[string]any{"Name": "Jane", "Plan": ""},
+		},
+		{
+			name: "nil pointer",
+			msg:  mailpen.Define[*welcomeData]("welcome", "").Message(nil).To("a@example.com").Must(),
+			want: map[string]any{},
+		},
+		{
+			name: "map",
+			msg:  mailpen.Define[map[string]string]("welcome", "").Message(map[string]string{"Name": "Jane"}).To("a@example.com").Must(),
+			want: map[string]any{"Name": "Jane"},
+		},
+		{
+			name: "scalar",
+			msg:  mailpen.Define[string]("welcome", "").Message("Jane").To("a@example.com").Must(),
+			want: map[string]any{"Data": "Jane"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.msg.Data)
+			assert.Empty(t, tt.msg.Layout)
+		})
+	}
+}
+
+func TestSendTyped(t *testing.T) {
+	mock := &mockProvider{}
+	mp, err := mailpen.New(mock, &mailpen.Config{
+		From:        "sender@example.com",
+		CompanyName: "Acme",
+		Sources:     []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+	})
+	require.NoError(t, err)
+
+	email := mailpen.Define[welcomeData]("welcome", "").
+		WithSubject(func(welcomeData) string { return "Welcome" })
+	require.NoError(t, mailpen.SendTyped(context.Background(), mp, email, welcomeData{Name: "Jane"}, "jane@example.com"))
+
+	assert.Equal(t, []string{"jane@example.com"}, mock.lastMessage.To)
+	assert.Contains(t, mock.lastMessage.HTMLBody, "Welcome, Jane!")
+	assert.Contains(t, mock.lastMessage.HTMLBody, "Acme")
+
+	assert.Error(t, mailpen.SendTyped(context.Background(), mp, email, welcomeData{Name: "Jane"}))
+}
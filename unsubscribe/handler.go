@@ -0,0 +1,133 @@
+package unsubscribe
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+
+	"github.com/patrickward/mailpen"
+)
+
+// HandlerOption configures an unsubscribe handler
+type HandlerOption func(h *handler)
+
+// WithCallback sets a function called for each verified unsubscribe, for example to record a per-campaign
+// preference. It is the only place campaign unsubscribes are recorded. When it returns an error, the request
+// fails.
+func WithCallback(fn func(ctx context.Context, claims Claims) error) HandlerOption {
+	return func(h *handler) {
+		h.callback = fn
+	}
+}
+
+// WithPages replaces the built-in confirmation and result pages. The template must define "confirm",
+// executed with the token for GET requests, and "done", executed with the Claims after unsubscribing.
+func WithPages(pages *template.Template) HandlerOption {
+	return func(h *handler) {
+		h.pages = pages
+	}
+}
+
+// Handler returns an http.Handler that verifies tokens and unsubscribes recipients. GET requests show a
+// confirmation page, so link scanners cannot unsubscribe anyone, and POST requests, including RFC 8058
+// one-click requests from mail clients, unsubscribe.
+//
+// Tokens without a campaign add the address to the suppression store with reason SuppressionUnsubscribe, which
+// stops all mail to it. Tokens for a campaign are only passed to the WithCallback function, since the
+// suppression store would also block unrelated mail such as password resets; without a callback they fail.
+// Pass a nil store to handle every unsubscribe with WithCallback.
+func Handler(signer *Signer, store mailpen.SuppressionStore, opts ...HandlerOption) http.Handler {
+	h := &handler{signer: signer, store: store, pages: defaultPages}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+type handler struct {
+	signer   *Signer
+	store    mailpen.SuppressionStore
+	callback func(ctx context.Context, claims Claims) error
+	pages    *template.Template
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := r.FormValue("token")
+	claims, err := h.signer.Verify(token)
+	switch {
+	case errors.Is(err, ErrExpiredToken):
+		http.Error(w, "This unsubscribe link has expired.", http.StatusGone)
+		return
+	case err != nil:
+		http.Error(w, "This unsubscribe link is not valid.", http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		h.render(w, "confirm", token)
+		return
+	}
+
+	if err := h.unsubscribe(r.Context(), claims); err != nil {
+		http.Error(w, "Unsubscribing failed. Please try again later.", http.StatusInternalServerError)
+		return
+	}
+	h.render(w, "done", claims)
+}
+
+// errNoCampaignCallback is returned for a campaign unsubscribe when there is no callback to record it
+var errNoCampaignCallback = errors.New("campaign unsubscribes require a callback")
+
+// unsubscribe records an unsubscribe from all mail in the store, and calls the callback
+func (h *handler) unsubscribe(ctx context.Context, claims Claims) error {
+	if claims.Campaign != "" && h.callback == nil {
+		return errNoCampaignCallback
+	}
+
+	if h.store != nil && claims.Campaign == "" {
+		err := h.store.Suppress(ctx, mailpen.Suppression{
+			Address:   claims.Address,
+			Reason:    mailpen.SuppressionUnsubscribe,
+			CreatedAt: h.signer.now(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if h.callback != nil {
+		return h.callback(ctx, claims)
+	}
+	return nil
+}
+
+// render writes a page
+func (h *handler) render(w http.ResponseWriter, name string, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = h.pages.ExecuteTemplate(w, name, data)
+}
+
+// defaultPages are the built-in confirmation and result pages
+var defaultPages = template.Must(template.New("pages").Parse(`
+{{define "confirm"}}<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body style="font-family: Arial, sans-serif; text-align: center; padding: 40px;">
+<h1>Unsubscribe</h1>
+<p>Click the button below to stop receiving these emails.</p>
+<form method="post"><input type="hidden" name="token" value="{{.}}"><button type="submit">Unsubscribe</button></form>
+</body></html>{{end}}
+{{define "done"}}<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Unsubscribed</title></head>
+<body style="font-family: Arial, sans-serif; text-align: center; padding: 40px;">
+<h1>You have been unsubscribed</h1>
+<p>{{.Address}} will no longer receive these emails.</p>
+</body></html>{{end}}
+`))
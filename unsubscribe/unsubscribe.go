@@ -0,0 +1,204 @@
+// Package unsubscribe generates signed, expiring unsubscribe links for recipients and campaigns, and verifies
+// them when recipients follow the link or use one-click unsubscribe (RFC 8058).
+package unsubscribe
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/patrickward/mailpen"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid unsubscribe token")
+	ErrExpiredToken = errors.New("unsubscribe token has expired")
+)
+
+// DefaultTTL is how long unsubscribe tokens stay valid unless WithTTL is used
+const DefaultTTL = 90 * 24 * time.Hour
+
+// Claims are the contents of a verified token
+type Claims struct {
+	Address   string    // Normalized recipient address
+	Campaign  string    // Campaign or list the recipient unsubscribes from (empty for all mail)
+	ExpiresAt time.Time // When the token stops being accepted
+}
+
+// payload is the signed part of a token
+type payload struct {
+	Address  string `json:"a"`
+	Campaign string `json:"c,omitempty"`
+	Expires  int64  `json:"e"`
+}
+
+// Signer creates and verifies unsubscribe tokens and links
+type Signer struct {
+	secret  []byte
+	baseURL string
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// Option configures a Signer
+type Option func(s *Signer)
+
+// WithTTL sets how long tokens stay valid (defaults to DefaultTTL)
+func WithTTL(ttl time.Duration) Option {
+	return func(s *Signer) {
+		s.ttl = ttl
+	}
+}
+
+// WithClock sets the function used to read the current time, mainly for testing
+func WithClock(now func() time.Time) Option {
+	return func(s *Signer) {
+		s.now = now
+	}
+}
+
+// NewSigner creates a signer that signs tokens with secret, which must be at least 32 bytes, and builds links
+// by adding a token query parameter to baseURL, the address where Handler is mounted
+func NewSigner(secret []byte, baseURL string, opts ...Option) (*Signer, error) {
+	if len(secret) < 32 {
+		return nil, errors.New("unsubscribe secret must be at least 32 bytes")
+	}
+	if _, err := url.Parse(baseURL); err != nil || baseURL == "" {
+		return nil, fmt.Errorf("invalid unsubscribe base URL %q", baseURL)
+	}
+
+	s := &Signer{
+		secret:  secret,
+		baseURL: baseURL,
+		ttl:     DefaultTTL,
+		now:     time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Token returns a signed token for the address and campaign. An empty campaign unsubscribes from all mail.
+func (s *Signer) Token(address, campaign string) (string, error) {
+	address = mailpen.NormalizeAddress(address)
+	if address == "" {
+		return "", errors.New("address is required")
+	}
+
+	data, err := json.Marshal(payload{Address: address, Campaign: campaign, Expires: s.now().Add(s.ttl).Unix()})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode unsubscribe token: %w", err)
+	}
+
+	body := base64.RawURLEncoding.EncodeToString(data)
+	return body + "." + base64.RawURLEncoding.EncodeToString(s.sign(body)), nil
+}
+
+// Verify checks a token's signature and expiry and returns its claims. It returns ErrInvalidToken or
+// ErrExpiredToken when the token is not accepted.
+func (s *Signer) Verify(token string) (Claims, error) {
+	body, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return Claims{}, ErrInvalidToken
+	}
+
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, s.sign(body)) {
+		return Claims{}, ErrInvalidToken
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	var p payload
+	if err := json.Unmarshal(data, &p); err != nil || p.Address == "" {
+		return Claims{}, ErrInvalidToken
+	}
+
+	claims := Claims{Address: p.Address, Campaign: p.Campaign, ExpiresAt: time.Unix(p.Expires, 0)}
+	if !s.now().Before(claims.ExpiresAt) {
+		return claims, ErrExpiredToken
+	}
+	return claims, nil
+}
+
+// URL returns the unsubscribe link for the address and campaign
+func (s *Signer) URL(address, campaign string) (string, error) {
+	token, err := s.Token(address, campaign)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(s.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid unsubscribe base URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// FuncMap returns the unsubscribeURL template function, used as {{unsubscribeURL .Email}} or
+// {{unsubscribeURL .Email "newsletter"}}. Add it to Config.FuncMap.
+func (s *Signer) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"unsubscribeURL": func(address string, campaign ...string) (string, error) {
+			return s.URL(address, strings.Join(campaign, ""))
+		},
+	}
+}
+
+// CampaignMetadataKey is the message metadata key Processor reads the campaign from
+const CampaignMetadataKey = "campaign"
+
+// Processor returns a message processor that adds List-Unsubscribe and List-Unsubscribe-Post headers for the
+// recipient, so mail clients can offer one-click unsubscribe. The campaign is read from the message's
+// "campaign" metadata. Every recipient sees the same headers, so messages with more than one recipient across
+// To, Cc, and Bcc, including Config.AlwaysBcc addresses, are left without them; send one message per
+// recipient to offer one-click unsubscribe.
+func (s *Signer) Processor() mailpen.MessageProcessor {
+	return processor{signer: s}
+}
+
+// processor adds unsubscribe headers to messages
+type processor struct {
+	signer *Signer
+}
+
+// ProcessMessage implements mailpen.MessageProcessor
+func (p processor) ProcessMessage(_ context.Context, msg *mailpen.Message) error {
+	if len(msg.To) != 1 || len(msg.Cc)+len(msg.Bcc) > 0 {
+		return nil
+	}
+
+	link, err := p.signer.URL(msg.To[0], msg.Metadata[CampaignMetadataKey])
+	if err != nil {
+		return fmt.Errorf("failed to create unsubscribe link: %w", err)
+	}
+
+	if msg.Headers == nil {
+		msg.Headers = make(map[string]string)
+	}
+	msg.Headers["List-Unsubscribe"] = "<" + link + ">"
+	msg.Headers["List-Unsubscribe-Post"] = "List-Unsubscribe=One-Click"
+	return nil
+}
+
+// sign returns the HMAC of a token body
+func (s *Signer) sign(body string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(body))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,277 @@
+package unsubscribe_test
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+	"github.com/patrickward/mailpen/suppression"
+	"github.com/patrickward/mailpen/unsubscribe"
+)
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+func newSigner(t *testing.T, now *time.Time) *unsubscribe.Signer {
+	t.Helper()
+	s, err := unsubscribe.NewSigner(secret, "https://example.com/unsubscribe?lang=en",
+		unsubscribe.WithTTL(time.Hour),
+		unsubscribe.WithClock(func() time.Time { return *now }))
+	require.NoError(t, err)
+	return s
+}
+
+func TestNewSigner(t *testing.T) {
+	_, err := unsubscribe.NewSigner([]byte("short"), "https://example.com/unsubscribe")
+	assert.Error(t, err)
+
+	_, err = unsubscribe.NewSigner(secret, "")
+	assert.Error(t, err)
+}
+
+func TestSigner_Verify(t *testing.T) {
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	signer := newSigner(t, &now)
+
+	token, err := signer.Token("Jane <Jane@Example.com>", "newsletter")
+	require.NoError(t, err)
+
+	claims, err := signer.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", claims.Address)
+	assert.Equal(t, "newsletter", claims.Campaign)
+	assert.True(t, claims.ExpiresAt.Equal(now.Add(time.Hour)))
+
+	other, err := unsubscribe.NewSigner([]byte("fedcba9876543210fedcba9876543210"), "https://example.com/u")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		verify  func() error
+		wantErr error
+	}{
+		{name: "tampered", verify: func() error { _, err := signer.Verify("x" + token); return err }, wantErr: unsubscribe.ErrInvalidToken},
+		{name: "malformed", verify: func() error { _, err := signer.Verify("not-a-token"); return err }, wantErr: unsubscribe.ErrInvalidToken},
+		{name: "other secret", verify: func() error { _, err := other.Verify(token); return err }, wantErr: unsubscribe.ErrInvalidToken},
+		{name: "expired", verify: func() error {
+			later := now.Add(2 * time.Hour)
+			_, err := newSigner(t, &later).Verify(token)
+			return err
+		}, wantErr: unsubscribe.ErrExpiredToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.verify(), tt.wantErr)
+		})
+	}
+
+	_, err = signer.Token("", "")
+	assert.Error(t, err)
+}
+
+func TestSigner_URLAndFuncMap(t *testing.T) {
+	now := time.Now()
+	signer := newSigner(t, &now)
+
+	tmpl := template.Must(template.New("t").Funcs(signer.FuncMap()).Parse(`{{unsubscribeURL .Email "weekly"}}`))
+	var buf bytes.Buffer
+	require.NoError(t, tmpl.Execute(&buf, map[string]any{"Email": "jane@example.com"}))
+
+	u, err := url.Parse(strings.ReplaceAll(buf.String(), "&amp;", "&"))
+	require.NoError(t, err)
+	assert.Equal(t, "en", u.Query().Get("lang"))
+
+	claims, err := signer.Verify(u.Query().Get("token"))
+	require.NoError(t, err)
+	assert.Equal(t, "weekly", claims.Campaign)
+}
+
+func TestSigner_ComplianceFooter(t *testing.T) {
+	now := time.Now()
+	signer := newSigner(t, &now)
+
+	manager, err := mailpen.NewManager(&mailpen.ManagerConfig{
+		FuncMap: signer.FuncMap(),
+		Sources: []mailpen.TemplateSource{{Name: "test", FS: fstest.MapFS{
+			"emails/news.html": {Data: []byte(`{{define "content"}}{{template "@compliance-footer" (dict "footer" .FooterData "unsubscribeURL" (unsubscribeURL .Email "weekly"))}}{{end}}`)},
+		}}},
+	})
+	require.NoError(t, err)
+
+	data := mailpen.NewTemplateData(&mailpen.Config{CompanyName: "ACME", PreferencesURL: "https://example.com/preferences"})
+	email, err := manager.RenderEmail("news", data.Merge(map[string]any{"Email": "jane@example.com"}), "")
+	require.NoError(t, err)
+
+	start := strings.Index(email.HTML, `<a href="https://example.com/unsubscribe?`)
+	require.GreaterOrEqual(t, start, 0)
+	link := email.HTML[start+len(`<a href="`):]
+	link = strings.ReplaceAll(link[:strings.Index(link, `"`)], "&amp;", "&")
+
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	claims, err := signer.Verify(u.Query().Get("token"))
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", claims.Address)
+	assert.Contains(t, email.HTML, `href="https://example.com/preferences"`)
+}
+
+func TestSigner_Processor(t *testing.T) {
+	now := time.Now()
+	signer := newSigner(t, &now)
+
+	msg := mailpen.NewMessage().To("jane@example.com").Subject("News").Metadata("campaign", "weekly").Must()
+	require.NoError(t, signer.Processor().ProcessMessage(context.Background(), msg))
+
+	assert.Equal(t, "List-Unsubscribe=One-Click", msg.Headers["List-Unsubscribe-Post"])
+	link := strings.Trim(msg.Headers["List-Unsubscribe"], "<>")
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	claims, err := signer.Verify(u.Query().Get("token"))
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", claims.Address)
+	assert.Equal(t, "weekly", claims.Campaign)
+}
+
+func TestSigner_ProcessorMultipleRecipients(t *testing.T) {
+	now := time.Now()
+	signer := newSigner(t, &now)
+
+	tests := []struct {
+		name string
+		msg  *mailpen.Message
+	}{
+		{name: "two To recipients", msg: mailpen.NewMessage().To("jane@example.com", "joe@example.com").Subject("News").Must()},
+		{name: "Cc recipient", msg: mailpen.NewMessage().To("jane@example.com").Cc("joe@example.com").Subject("News").Must()},
+		{name: "Bcc recipient", msg: mailpen.NewMessage().To("jane@example.com").Bcc("archive@example.com").Subject("News").Must()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, signer.Processor().ProcessMessage(context.Background(), tt.msg))
+			assert.NotContains(t, tt.msg.Headers, "List-Unsubscribe")
+			assert.NotContains(t, tt.msg.Headers, "List-Unsubscribe-Post")
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	now := time.Now()
+	signer := newSigner(t, &now)
+	token, err := signer.Token("jane@example.com", "")
+	require.NoError(t, err)
+
+	store := suppression.NewMemoryStore()
+	var unsubscribed []unsubscribe.Claims
+	h := unsubscribe.Handler(signer, store, unsubscribe.WithCallback(func(_ context.Context, c unsubscribe.Claims) error {
+		unsubscribed = append(unsubscribed, c)
+		return nil
+	}))
+
+	tests := []struct {
+		name       string
+		request    *http.Request
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "confirmation page",
+			request:    httptest.NewRequest(http.MethodGet, "/unsubscribe?token="+token, nil),
+			wantStatus: http.StatusOK,
+			wantBody:   `<form method="post">`,
+		},
+		{
+			name:       "invalid token",
+			request:    httptest.NewRequest(http.MethodPost, "/unsubscribe?token=bogus", nil),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "method not allowed",
+			request:    httptest.NewRequest(http.MethodDelete, "/unsubscribe?token="+token, nil),
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name: "one-click post",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/unsubscribe?token="+token, strings.NewReader("List-Unsubscribe=One-Click"))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				return r
+			}(),
+			wantStatus: http.StatusOK,
+			wantBody:   "jane@example.com will no longer receive these emails",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, tt.request)
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.wantBody)
+		})
+	}
+
+	suppressed, err := store.Suppressed(context.Background(), []string{"jane@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"jane@example.com"}, suppressed)
+	require.Len(t, unsubscribed, 1)
+	assert.Equal(t, "jane@example.com", unsubscribed[0].Address)
+
+	expired := now.Add(2 * time.Hour)
+	rec := httptest.NewRecorder()
+	unsubscribe.Handler(newSigner(t, &expired), store).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?token="+token, nil))
+	assert.Equal(t, http.StatusGone, rec.Code)
+}
+
+func TestHandler_CampaignUnsubscribe(t *testing.T) {
+	now := time.Now()
+	signer := newSigner(t, &now)
+	campaignToken, err := signer.Token("jane@example.com", "weekly")
+	require.NoError(t, err)
+	globalToken, err := signer.Token("joe@example.com", "")
+	require.NoError(t, err)
+
+	post := func(h http.Handler, token string) int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/unsubscribe?token="+token, nil))
+		return rec.Code
+	}
+
+	t.Run("campaign tokens are only passed to the callback", func(t *testing.T) {
+		store := suppression.NewMemoryStore()
+		var campaigns []string
+		h := unsubscribe.Handler(signer, store, unsubscribe.WithCallback(func(_ context.Context, c unsubscribe.Claims) error {
+			campaigns = append(campaigns, c.Campaign)
+			return nil
+		}))
+
+		assert.Equal(t, http.StatusOK, post(h, campaignToken))
+		assert.Equal(t, http.StatusOK, post(h, globalToken))
+
+		suppressed, err := store.Suppressed(context.Background(), []string{"jane@example.com", "joe@example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"joe@example.com"}, suppressed)
+		assert.Equal(t, []string{"weekly", ""}, campaigns)
+	})
+
+	t.Run("campaign tokens fail without a callback", func(t *testing.T) {
+		store := suppression.NewMemoryStore()
+		h := unsubscribe.Handler(signer, store)
+
+		assert.Equal(t, http.StatusInternalServerError, post(h, campaignToken))
+
+		suppressed, err := store.Suppressed(context.Background(), []string{"jane@example.com"})
+		require.NoError(t, err)
+		assert.Empty(t, suppressed)
+	})
+}
@@ -0,0 +1,135 @@
+package mailpen
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"sync/atomic"
+)
+
+// ConfigOverrides holds the settings a derived instance changes. Empty fields keep the parent's value.
+type ConfigOverrides struct {
+	// Name identifies the derived theme in the template cache, such as a tenant ID. Instances derived with the
+	// same name and a Theme must use the same theme. Without a name, each derived instance with a Theme
+	// compiles its own templates, so name instances that are derived repeatedly.
+	Name string
+
+	From            string
+	ReplyTo         string
+	BaseURL         string
+	CompanyName     string
+	CompanyAddress1 string
+	CompanyAddress2 string
+	LogoURL         string
+	SupportEmail    string
+	SupportPhone    string
+	WebsiteName     string
+	WebsiteURL      string
+	TimeZone        string // Time zone of template dates, e.g. for instances serving one region
+	Locale          string
+	DateFormat      string
+	TimestampFormat string
+
+	SiteLinks        map[string]string // Merged over the parent's site links
+	SocialMediaLinks map[string]string // Merged over the parent's social media links
+	Theme            map[string]any    // Merged over the parent's theme
+}
+
+// derivedVariants numbers unnamed derived themes
+var derivedVariants atomic.Int64
+
+// With returns an instance that uses the overrides for branding and configuration, for example per tenant
+// or per sub-product. The derived instance shares the template manager, provider, queue, stores, event
+// subscriptions, and shutdown state with its parent, so deriving is cheap.
+func (m *Mailpen) With(overrides ConfigOverrides) *Mailpen {
+	config := overrides.apply(m.Config())
+
+	d := *m
+	d.config = &atomic.Pointer[Config]{}
+	d.config.Store(config)
+	d.middleware = slices.Clip(m.middleware)
+	d.hooks = slices.Clip(m.hooks)
+	d.processors = slices.Clip(m.processors)
+
+	if overrides.Theme != nil {
+		name := overrides.Name
+		if name == "" {
+			name = "#" + strconv.FormatInt(derivedVariants.Add(1), 10)
+		}
+		d.variant = "with:" + name
+		if m.variant != "" {
+			d.variant = m.variant + "/" + d.variant
+		}
+		d.theme = MergeTheme(m.theme, overrides.Theme)
+	}
+
+	return &d
+}
+
+// UpdateConfig applies the overrides to the configuration at runtime, so branding and link changes such as a
+// new support address or logo take effect without a redeploy. Messages rendered after it returns use the new
+// values; renders in progress finish with the old ones. A Theme is merged over the template manager's theme,
+// which is shared with derived instances, and clears the template cache. Instances derived with With keep the
+// configuration they were derived from. The updated configuration is validated, and left unchanged when it is
+// invalid.
+func (m *Mailpen) UpdateConfig(overrides ConfigOverrides) error {
+	for {
+		current := m.Config()
+		updated := overrides.apply(current)
+		if err := updated.Validate(); err != nil {
+			return err
+		}
+		if m.config.CompareAndSwap(current, updated) {
+			break
+		}
+	}
+
+	if overrides.Theme != nil {
+		m.templateMgr.UpdateTheme(overrides.Theme)
+	}
+	return nil
+}
+
+// apply returns a copy of the config with the overrides applied. Theme and Name are not config fields and
+// are left to the caller.
+func (o ConfigOverrides) apply(base *Config) *Config {
+	config := *base
+	setIf(&config.From, o.From)
+	setIf(&config.ReplyTo, o.ReplyTo)
+	setIf(&config.BaseURL, o.BaseURL)
+	setIf(&config.CompanyName, o.CompanyName)
+	setIf(&config.CompanyAddress1, o.CompanyAddress1)
+	setIf(&config.CompanyAddress2, o.CompanyAddress2)
+	setIf(&config.LogoURL, o.LogoURL)
+	setIf(&config.SupportEmail, o.SupportEmail)
+	setIf(&config.SupportPhone, o.SupportPhone)
+	setIf(&config.WebsiteName, o.WebsiteName)
+	setIf(&config.WebsiteURL, o.WebsiteURL)
+	setIf(&config.TimeZone, o.TimeZone)
+	setIf(&config.Locale, o.Locale)
+	setIf(&config.DateFormat, o.DateFormat)
+	setIf(&config.TimestampFormat, o.TimestampFormat)
+	config.SiteLinks = mergeLinks(config.SiteLinks, o.SiteLinks)
+	config.SocialMediaLinks = mergeLinks(config.SocialMediaLinks, o.SocialMediaLinks)
+	return &config
+}
+
+// setIf sets the field to value unless value is empty
+func setIf(field *string, value string) {
+	if value != "" {
+		*field = value
+	}
+}
+
+// mergeLinks returns the base links with the overrides applied, without modifying either map
+func mergeLinks(base, overrides map[string]string) map[string]string {
+	if len(overrides) == 0 {
+		return base
+	}
+	merged := maps.Clone(base)
+	if merged == nil {
+		merged = make(map[string]string, len(overrides))
+	}
+	maps.Copy(merged, overrides)
+	return merged
+}
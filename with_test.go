@@ -0,0 +1,148 @@
+package mailpen_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/patrickward/mailpen"
+)
+
+func TestMailpen_With(t *testing.T) {
+	mock := &mockProvider{}
+	mp, err := mailpen.New(mock, &mailpen.Config{
+		From:        "sender@example.com",
+		CompanyName: "Default Inc",
+		SiteLinks:   map[string]string{"home": "https://example.com"},
+		Sources:     []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+	})
+	require.NoError(t, err)
+
+	acme := mp.With(mailpen.ConfigOverrides{
+		Name:        "acme",
+		From:        "hello@acme.test",
+		CompanyName: "ACME Corp",
+		SiteLinks:   map[string]string{"help": "https://acme.test/help"},
+		Theme:       map[string]any{"colors": map[string]any{"primary": "#ff0000"}},
+	})
+
+	newMsg := func() *mailpen.Message {
+		return mailpen.NewMessage().To("recipient@example.com").Template("headers-test").
+			WithData(map[string]any{"mainTitle": "Hello"}).Must()
+	}
+
+	t.Run("derived instance uses overrides", func(t *testing.T) {
+		require.NoError(t, acme.Send(context.Background(), newMsg()))
+		assert.Equal(t, "hello@acme.test", mock.lastMessage.From)
+		assert.Contains(t, mock.lastMessage.HTMLBody, "color: #ff0000;")
+
+		assert.Equal(t, "ACME Corp", acme.Config().CompanyName)
+		assert.Equal(t, map[string]string{"home": "https://example.com", "help": "https://acme.test/help"}, acme.Config().SiteLinks)
+	})
+
+	t.Run("parent is unchanged", func(t *testing.T) {
+		require.NoError(t, mp.Send(context.Background(), newMsg()))
+		assert.Equal(t, "sender@example.com", mock.lastMessage.From)
+		assert.Contains(t, mock.lastMessage.HTMLBody, "color: #4DA647;")
+		assert.Equal(t, "Default Inc", mp.Config().CompanyName)
+		assert.Equal(t, map[string]string{"home": "https://example.com"}, mp.Config().SiteLinks)
+	})
+
+	t.Run("nested derivation keeps the parent overrides", func(t *testing.T) {
+		billing := acme.With(mailpen.ConfigOverrides{From: "billing@acme.test"})
+		require.NoError(t, billing.Send(context.Background(), newMsg()))
+		assert.Equal(t, "billing@acme.test", mock.lastMessage.From)
+		assert.Contains(t, mock.lastMessage.HTMLBody, "color: #ff0000;")
+		assert.Equal(t, "ACME Corp", billing.Config().CompanyName)
+	})
+
+	t.Run("shares subscriptions and shutdown", func(t *testing.T) {
+		var events int
+		unsubscribe := mp.Subscribe(func(context.Context, mailpen.Event) error {
+			events++
+			return nil
+		}, mailpen.EventSent)
+		defer unsubscribe()
+
+		require.NoError(t, acme.Send(context.Background(), newMsg()))
+		assert.Equal(t, 1, events)
+
+		_, err := mp.Shutdown(context.Background())
+		require.NoError(t, err)
+		assert.ErrorIs(t, acme.SendAsync(context.Background(), newMsg()).Wait(context.Background()), mailpen.ErrShutdown)
+	})
+}
+
+func TestMailpen_UpdateConfig(t *testing.T) {
+	mock := &mockProvider{}
+	mp, err := mailpen.New(mock, &mailpen.Config{
+		From:         "sender@example.com",
+		SupportEmail: "help@example.com",
+		Sources:      []mailpen.TemplateSource{{Name: "base", FS: testFS(t, "base")}},
+	})
+	require.NoError(t, err)
+
+	acme := mp.With(mailpen.ConfigOverrides{
+		Name:  "acme",
+		Theme: map[string]any{"colors": map[string]any{"secondary": "#00ff00"}},
+	})
+
+	newMsg := func() *mailpen.Message {
+		return mailpen.NewMessage().To("recipient@example.com").Template("headers-test").
+			WithData(map[string]any{"mainTitle": "Hello"}).Must()
+	}
+
+	// Render the derived instance once so its themed templates are cached
+	_, err = acme.Render(context.Background(), newMsg())
+	require.NoError(t, err)
+
+	require.NoError(t, mp.UpdateConfig(mailpen.ConfigOverrides{
+		From:         "news@example.com",
+		SupportEmail: "support@example.com",
+		Theme:        map[string]any{"colors": map[string]any{"primary": "#123456"}},
+	}))
+
+	t.Run("new values are used", func(t *testing.T) {
+		require.NoError(t, mp.Send(context.Background(), newMsg()))
+		assert.Equal(t, "news@example.com", mock.lastMessage.From)
+		assert.Contains(t, mock.lastMessage.HTMLBody, "color: #123456;")
+		assert.Equal(t, "support@example.com", mp.NewTemplateData()["SupportEmail"])
+	})
+
+	t.Run("cached themed templates are rebuilt", func(t *testing.T) {
+		email, err := acme.Render(context.Background(), newMsg())
+		require.NoError(t, err)
+		assert.Contains(t, email.HTML, "color: #123456;")
+	})
+
+	t.Run("derived instances keep their config", func(t *testing.T) {
+		assert.Equal(t, "sender@example.com", acme.Config().From)
+		assert.Equal(t, "help@example.com", acme.Config().SupportEmail)
+	})
+
+	t.Run("invalid update is rejected", func(t *testing.T) {
+		err := mp.UpdateConfig(mailpen.ConfigOverrides{From: "not an address", CompanyName: "Acme"})
+		assert.ErrorIs(t, err, mailpen.ErrInvalidConfig)
+		assert.Equal(t, "news@example.com", mp.Config().From)
+		assert.Empty(t, mp.Config().CompanyName)
+	})
+
+	t.Run("concurrent updates and renders", func(t *testing.T) {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := range 50 {
+				assert.NoError(t, mp.UpdateConfig(mailpen.ConfigOverrides{CompanyName: fmt.Sprintf("Company %d", i)}))
+			}
+		}()
+		for range 50 {
+			_, err := mp.Render(context.Background(), newMsg())
+			require.NoError(t, err)
+		}
+		<-done
+		assert.Equal(t, "Company 49", mp.Config().CompanyName)
+	})
+}